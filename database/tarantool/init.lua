@@ -32,6 +32,482 @@ if not box.space[space_name] then
     log.info("Создан спейс: %s", space_name)
 end
 
+-- Поля, добавленные после первой версии схемы, допускают nil,
+-- поэтому старые кортежи остаются валидными без миграции
+local space = box.space[space_name]
+space:format({
+    {'id', 'string'},
+    {'creator', 'string'},
+    {'question', 'string'},
+    {'voters', 'map'},
+    {'options', 'map'},
+    {'is_closed', 'boolean'},
+    {'channel_id', 'string', is_nullable = true},
+    {'created_at', 'unsigned', is_nullable = true},
+    {'channel_only', 'boolean', is_nullable = true},
+    {'quorum', 'unsigned', is_nullable = true},
+    {'ranked', 'boolean', is_nullable = true},
+    {'option_order', 'array', is_nullable = true},
+    {'ballots', 'map', is_nullable = true},
+    {'winner', 'string', is_nullable = true},
+    {'pinned_post_id', 'string', is_nullable = true},
+    {'notify_voters', 'boolean', is_nullable = true},
+    {'expires_at', 'unsigned', is_nullable = true},
+    {'expiry_warned', 'boolean', is_nullable = true},
+    {'description', 'string', is_nullable = true},
+    {'tags', 'array', is_nullable = true},
+    {'weights', 'map', is_nullable = true},
+    {'weighted_options', 'map', is_nullable = true},
+    {'allow_abstain', 'boolean', is_nullable = true},
+    {'abstained', 'unsigned', is_nullable = true},
+    {'max_votes', 'unsigned', is_nullable = true},
+    {'vote_receipts', 'boolean', is_nullable = true},
+    {'option_emoji', 'map', is_nullable = true},
+    {'no_self_vote', 'boolean', is_nullable = true},
+    {'voter_salt', 'string', is_nullable = true},
+    {'members_only', 'boolean', is_nullable = true},
+    {'views', 'unsigned', is_nullable = true},
+    {'schema_version', 'unsigned', is_nullable = true},
+    {'featured', 'boolean', is_nullable = true},
+    {'allowed_voters', 'array', is_nullable = true}
+})
+
+-- Версия раскладки кортежа опроса - поле 32, поля следующих версий идут
+-- после него. Кортеж без версии записан ботом до её появления; кортежи
+-- старых версий переводятся в текущую при записи
+local POLL_FIELDS = 34
+local POLL_SCHEMA_FIELD = 32
+local POLL_SCHEMA_VERSION = 3
+
+local function legacy_poll(poll)
+    return poll.schema_version == nil or poll.schema_version < POLL_SCHEMA_VERSION
+end
+
+-- Дополняет кортеж старой версии до текущей: недостающие поля получают
+-- NULL, значения остальных не меняются
+local function upgrade_poll(polls, poll)
+    if not legacy_poll(poll) then
+        return poll
+    end
+    local row = poll:totable()
+    for i = #row + 1, POLL_FIELDS do
+        row[i] = box.NULL
+    end
+    row[POLL_SCHEMA_FIELD] = POLL_SCHEMA_VERSION
+    return polls:replace(row)
+end
+
+-- Перевод кортежа в текущую версию после записи, которую бот сделал
+-- update-запросом, а не хранимой функцией
+function polls_upgrade(name, id)
+    return box.atomic(function()
+        local poll = box.space[name]:get(id)
+        if poll == nil then
+            return 'not_found'
+        end
+        upgrade_poll(box.space[name], poll)
+        return 'ok'
+    end)
+end
+
+-- Кортежи старых версий среди первых limit кортежей: выборка, число
+-- старых в ней и число всех кортежей space
+function polls_legacy_count(name, limit)
+    local space = box.space[name]
+    local sampled, legacy = 0, 0
+    for _, poll in space:pairs() do
+        if sampled >= limit then
+            break
+        end
+        sampled = sampled + 1
+        if legacy_poll(poll) then
+            legacy = legacy + 1
+        end
+    end
+    return sampled, legacy, space:len()
+end
+
+-- Вторичные индексы для ListPolls
+space:create_index('creator', {
+    parts = {'creator'},
+    unique = false,
+    if_not_exists = true
+})
+space:create_index('channel', {
+    parts = {{'channel_id', 'string', is_nullable = true}},
+    unique = false,
+    if_not_exists = true
+})
+
+-- Голоса участников: по одной записи на пару (опрос, участник)
+local votes_name = os.getenv('TARANTOOL_VOTES') or 'poll_votes'
+local votes = box.schema.space.create(votes_name, {
+    if_not_exists = true,
+    format = {
+        {'poll_id', 'string'},
+        {'user_id', 'string'},
+        {'choices', 'array'},
+        {'voted_at', 'unsigned'}
+    }
+})
+votes:create_index('primary', {
+    parts = {'poll_id', 'user_id'},
+    if_not_exists = true
+})
+votes:format({
+    {'poll_id', 'string'},
+    {'user_id', 'string'},
+    {'choices', 'array'},
+    {'voted_at', 'unsigned'},
+    {'post_id', 'string', is_nullable = true},
+    {'weight', 'unsigned', is_nullable = true},
+    {'abstain', 'boolean', is_nullable = true}
+})
+
+-- Запись голоса на стороне Tarantool: проверки, запись голоса и счётчик
+-- опроса меняются одной транзакцией
+function polls_add_vote(polls_name, votes_name, id, user_id, choices, at, post_id, weight, abstain)
+    return box.atomic(function()
+        local polls = box.space[polls_name]
+        local votes = box.space[votes_name]
+        local poll = polls:get(id)
+        if poll == nil then
+            return 'not_found'
+        end
+        poll = upgrade_poll(polls, poll)
+        -- Кворум и максимум голосов считают участников, а не голоса
+        local quorum = poll.quorum or 0
+        local max_votes = poll.max_votes or 0
+        local count = votes.index.primary:count({id})
+        if poll.is_closed then
+            if max_votes > 0 and count >= max_votes then
+                return 'full'
+            end
+            return 'closed'
+        end
+        local abstained = poll.abstained or 0
+        local previous = votes:get({id, user_id})
+        if previous ~= nil then
+            -- Заменить можно только голос воздержавшегося, и только настоящим
+            if not previous.abstain or abstain then
+                return 'voted'
+            end
+            abstained = abstained - 1
+        end
+        votes:replace({id, user_id, choices, at, post_id, weight, abstain})
+        -- Замена воздержания не добавляет участника
+        if previous == nil then
+            count = count + 1
+        end
+        local reached = (quorum > 0 and count >= quorum) or (max_votes > 0 and count >= max_votes)
+
+        local ops = {{'=', 'is_closed', reached}}
+        if abstain then
+            abstained = abstained + 1
+        else
+            local choice = choices[1]
+            local options = poll.options
+            options[choice] = (options[choice] or 0) + 1
+            table.insert(ops, {'=', 'options', options})
+            -- Голос с весом прибавляется и к сумме весов варианта
+            if weight ~= nil and weight > 0 then
+                local weighted = poll.weighted_options or {}
+                weighted[choice] = (weighted[choice] or 0) + weight
+                table.insert(ops, {'=', 'weighted_options', weighted})
+            end
+        end
+        if abstained ~= (poll.abstained or 0) then
+            table.insert(ops, {'=', 'abstained', abstained})
+        end
+        polls:update(id, ops)
+        if reached then
+            return 'quorum'
+        end
+        return 'ok'
+    end)
+end
+
+function polls_delete_votes(votes_name, id)
+    return box.atomic(function()
+        local votes = box.space[votes_name]
+        for _, vote in votes.index.primary:pairs({id}) do
+            votes:delete({vote.poll_id, vote.user_id})
+        end
+        return 'ok'
+    end)
+end
+
+function polls_increment_option(name, id, option, delta)
+    return box.atomic(function()
+        local poll = box.space[name]:get(id)
+        if poll == nil then
+            return 'not_found'
+        end
+        poll = upgrade_poll(box.space[name], poll)
+        local options = poll.options
+        options[option] = (options[option] or 0) + delta
+        box.space[name]:update(id, {{'=', 'options', options}})
+        return 'ok'
+    end)
+end
+
+-- Отметка опроса администратором; поле есть только с версии 2, поэтому
+-- старый кортеж сначала переводится в текущую версию
+function polls_set_featured(name, id, featured)
+    return box.atomic(function()
+        local poll = box.space[name]:get(id)
+        if poll == nil then
+            return 'not_found'
+        end
+        upgrade_poll(box.space[name], poll)
+        box.space[name]:update(id, {{'=', 'featured', featured}})
+        return 'ok'
+    end)
+end
+
+-- Список участников, которым разрешено голосовать; поле есть только с
+-- версии 3. Пустой список - NULL: голосовать может любой
+function polls_set_voters(name, id, voters)
+    return box.atomic(function()
+        local poll = box.space[name]:get(id)
+        if poll == nil then
+            return 'not_found'
+        end
+        upgrade_poll(box.space[name], poll)
+        box.space[name]:update(id, {{'=', 'allowed_voters', voters}})
+        return 'ok'
+    end)
+end
+
+-- Просмотр итогов; у опросов, созданных до появления поля, счётчика нет
+function polls_increment_views(name, id)
+    return box.atomic(function()
+        local poll = box.space[name]:get(id)
+        if poll == nil then
+            return 'not_found'
+        end
+        poll = upgrade_poll(box.space[name], poll)
+        box.space[name]:update(id, {{'=', 'views', (poll.views or 0) + 1}})
+        return 'ok'
+    end)
+end
+
+-- Опрос для итогов: карты участников voters и ballots, которые остаются в
+-- опросах до переноса голосов в отдельный space, не передаются
+local function summary_row(poll)
+    local row = poll:totable()
+    row[4] = setmetatable({}, {__serialize = 'map'})
+    if row[13] ~= nil then
+        row[13] = box.NULL
+    end
+    return row
+end
+
+function polls_get_summary(name, id)
+    local poll = box.space[name]:get(id)
+    if poll == nil then
+        return nil
+    end
+    return summary_row(poll)
+end
+
+-- Сводки опросов по списку ID за один вызов; ID, которых нет, пропускаются
+function polls_get_many(name, ids)
+    local space = box.space[name]
+    local rows = setmetatable({}, {__serialize = 'array'})
+    for _, id in ipairs(ids) do
+        local poll = space:get(id)
+        if poll ~= nil then
+            table.insert(rows, summary_row(poll))
+        end
+    end
+    return rows
+end
+
+local function has_tag(tags, tag)
+    for _, t in ipairs(tags or {}) do
+        if t == tag then
+            return true
+        end
+    end
+    return false
+end
+
+-- Подсчёт опросов по фильтру ListPolls без передачи кортежей в бота.
+-- Создатель или канал выбирают индекс; если других предикатов нет,
+-- хватает index:count. Время создания - в секундах, границы строгие;
+-- featured NULL у старых кортежей - не отмеченный опрос
+function polls_count(name, creator, channel_id, closed, tag, created_after, created_before, featured)
+    local space = box.space[name]
+    local index, key = space.index.primary, {}
+    if creator ~= nil then
+        index, key = space.index.creator, {creator}
+    elseif channel_id ~= nil then
+        index, key = space.index.channel, {channel_id}
+    end
+    local by_channel = creator ~= nil and channel_id ~= nil
+    if not by_channel and closed == nil and tag == nil and created_after == nil and created_before == nil
+        and featured == nil then
+        return index:count(key)
+    end
+
+    local count = 0
+    for _, poll in index:pairs(key) do
+        local created = poll.created_at or 0
+        if (not by_channel or poll.channel_id == channel_id)
+            and (closed == nil or poll.is_closed == closed)
+            and (featured == nil or (poll.featured == true) == featured)
+            and (created_after == nil or created > created_after)
+            and (created_before == nil or created < created_before)
+            and (tag == nil or has_tag(poll.tags, tag)) then
+            count = count + 1
+        end
+    end
+    return count
+end
+
+-- Число голосов опроса по частичному ключу первичного индекса
+function polls_count_votes(votes_name, id)
+    return box.space[votes_name].index.primary:count({id})
+end
+
+-- Расписания повторяющихся опросов
+local schedules_name = os.getenv('TARANTOOL_SCHEDULES') or 'poll_schedules'
+local schedules = box.schema.space.create(schedules_name, {
+    if_not_exists = true,
+    format = {
+        {'id', 'string'},
+        {'creator', 'string'},
+        {'channel_id', 'string'},
+        {'cron', 'string'},
+        {'question', 'string'},
+        {'options', 'array'},
+        {'channel_only', 'boolean'},
+        {'quorum', 'unsigned'},
+        {'ranked', 'boolean'},
+        {'exclusive', 'boolean'},
+        {'created_at', 'unsigned'},
+        {'last_run_at', 'unsigned'}
+    }
+})
+-- unreachable_at добавлено позже и допускает nil, как новые поля опросов
+schedules:format({
+    {'id', 'string'},
+    {'creator', 'string'},
+    {'channel_id', 'string'},
+    {'cron', 'string'},
+    {'question', 'string'},
+    {'options', 'array'},
+    {'channel_only', 'boolean'},
+    {'quorum', 'unsigned'},
+    {'ranked', 'boolean'},
+    {'exclusive', 'boolean'},
+    {'created_at', 'unsigned'},
+    {'last_run_at', 'unsigned'},
+    {'unreachable_at', 'unsigned', is_nullable = true}
+})
+schedules:create_index('primary', {
+    parts = {'id'},
+    if_not_exists = true
+})
+schedules:create_index('channel', {
+    parts = {'channel_id'},
+    unique = false,
+    if_not_exists = true
+})
+
+-- Шаблоны опросов: имя уникально в области создателя или канала
+local templates_name = os.getenv('TARANTOOL_TEMPLATES') or 'poll_templates'
+local templates = box.schema.space.create(templates_name, {
+    if_not_exists = true,
+    format = {
+        {'scope', 'string'},
+        {'name', 'string'},
+        {'creator', 'string'},
+        {'question', 'string'},
+        {'options', 'array'},
+        {'description', 'string'},
+        {'tags', 'array'},
+        {'channel_only', 'boolean'},
+        {'quorum', 'unsigned'},
+        {'ranked', 'boolean'},
+        {'exclusive', 'boolean'},
+        {'pin', 'boolean'},
+        {'notify_voters', 'boolean'},
+        {'expires', 'unsigned'},
+        {'no_expire', 'boolean'},
+        {'created_at', 'unsigned'}
+    }
+})
+templates:create_index('primary', {
+    parts = {'scope', 'name'},
+    if_not_exists = true
+})
+
+-- Журнал событий опросов: записи только добавляются
+local audit_name = os.getenv('TARANTOOL_AUDIT') or 'poll_audit'
+local audit = box.schema.space.create(audit_name, {
+    if_not_exists = true,
+    format = {
+        {'id', 'string'},
+        {'poll_id', 'string'},
+        {'actor', 'string'},
+        {'action', 'string'},
+        {'detail', 'string'},
+        {'at', 'unsigned'}
+    }
+})
+audit:create_index('primary', {
+    parts = {'id'},
+    if_not_exists = true
+})
+audit:create_index('poll', {
+    parts = {'poll_id', 'at'},
+    unique = false,
+    if_not_exists = true
+})
+
+-- Настройки каналов: ежедневная сводка открытых опросов
+local channel_settings_name = os.getenv('TARANTOOL_CHANNEL_SETTINGS') or 'poll_channel_settings'
+local channel_settings = box.schema.space.create(channel_settings_name, {
+    if_not_exists = true,
+    format = {
+        {'channel_id', 'string'},
+        {'digest', 'boolean'},
+        {'updated_by', 'string'},
+        {'updated_at', 'unsigned'}
+    }
+})
+channel_settings:create_index('primary', {
+    parts = {'channel_id'},
+    if_not_exists = true
+})
+
+-- Статистика команд: сколько раз за день выполнялась каждая команда
+local usage_name = os.getenv('TARANTOOL_USAGE') or 'poll_usage'
+local usage = box.schema.space.create(usage_name, {
+    if_not_exists = true,
+    format = {
+        {'day', 'unsigned'},
+        {'command', 'string'},
+        {'count', 'unsigned'}
+    }
+})
+usage:create_index('primary', {
+    parts = {'day', 'command'},
+    if_not_exists = true
+})
+
+-- Пачка счётчиков {day, command, count} прибавляется за один вызов
+function usage_add(name, entries)
+    return box.atomic(function()
+        for _, e in ipairs(entries) do
+            box.space[name]:upsert({e[1], e[2], e[3]}, {{'+', 'count', e[3]}})
+        end
+        return 'ok'
+    end)
+end
+
 local user = os.getenv('TARANTOOL_USER')
 local password = os.getenv('TARANTOOL_PASSWORD')
 
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/rs/zerolog"
+
+	"polling_bot/internal/bot"
+	"polling_bot/internal/config"
+	"polling_bot/internal/health"
+)
+
+// selfCheck проверяет по шагам всё, что нужно боту для запуска, печатает
+// отчёт и возвращает код выхода: 0 - все шаги прошли, 1 - нет.
+func selfCheck(ctx context.Context, cfg config.Config, storageCfg config.StorageConfig, tarantoolCfg config.TarantoolConfig) int {
+	// Отчёт заменяет лог: об ошибках сообщают сами шаги
+	logger := zerolog.Nop()
+	// Проверка не должна ждать переподключений, как запуск бота
+	tarantoolCfg.Retries = 1
+
+	var (
+		mm    *bot.Bot
+		store storage
+	)
+	configuration := []health.Check{
+		{Name: "Конфигурация", Run: func(context.Context) error {
+			var err error
+			mm, err = bot.NewBot(cfg, logger, nil)
+			return err
+		}},
+	}
+	storageChecks := []health.Check{
+		{Name: "Хранилище " + storageCfg.Backend + ": подключение", Run: func(ctx context.Context) error {
+			var err error
+			store, err = newRepository(ctx, storageCfg, tarantoolCfg, logger)
+			return err
+		}},
+		{Name: "Хранилище " + storageCfg.Backend + ": ping", Run: func(ctx context.Context) error {
+			return store.ping(ctx)
+		}},
+		{Name: "Хранилище " + storageCfg.Backend + ": схема", Run: func(ctx context.Context) error {
+			return store.checkSchema(ctx)
+		}},
+	}
+	mattermostChecks := []health.Check{
+		{Name: "Mattermost: аутентификация", Run: func(ctx context.Context) error {
+			return mm.CheckAuth(ctx)
+		}},
+		{Name: "Mattermost: учётная запись бота", Run: func(ctx context.Context) error {
+			return mm.CheckBotAccount(ctx)
+		}},
+		{Name: "Mattermost: членство в команде", Run: func(ctx context.Context) error {
+			return mm.CheckTeams(ctx)
+		}},
+	}
+
+	// Хранилище и Mattermost проверяются независимо друг от друга, но
+	// только с корректной конфигурацией
+	results := health.Run(ctx, configuration)
+	if health.OK(results) {
+		results = append(results, health.Run(ctx, storageChecks)...)
+		results = append(results, health.Run(ctx, mattermostChecks)...)
+	} else {
+		results = append(results, health.Skip(storageChecks)...)
+		results = append(results, health.Skip(mattermostChecks)...)
+	}
+	if store.close != nil {
+		store.close()
+	}
+	if err := health.Render(os.Stdout, results); err != nil || !health.OK(results) {
+		return 1
+	}
+	return 0
+}
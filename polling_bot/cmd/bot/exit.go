@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"polling_bot/internal/mmclient"
+)
+
+// Причины, по которым бот не запустился или остановился; run оборачивает
+// ими ошибки, а main выбирает по ним код выхода, чтобы systemd или
+// оркестратор мог отличить неверные настройки от временной недоступности.
+var (
+	errConfig         = errors.New("неверная конфигурация")
+	errStorage        = errors.New("хранилище недоступно")
+	errMattermostAuth = errors.New("Mattermost не принял токен бота")
+)
+
+// Коды выхода по sysexits.h; остальные ошибки завершают бота с кодом 1
+const (
+	exitConfig         = 78 // EX_CONFIG
+	exitStorage        = 69 // EX_UNAVAILABLE
+	exitMattermostAuth = 77 // EX_NOPERM
+	exitFailure        = 1
+)
+
+// exitCode возвращает код выхода и причину для лога по ошибке run;
+// nil - штатное завершение.
+func exitCode(err error) (int, string) {
+	switch {
+	case err == nil:
+		return 0, ""
+	case errors.Is(err, errConfig):
+		return exitConfig, "config"
+	case errors.Is(err, errStorage):
+		return exitStorage, "storage"
+	case errors.Is(err, errMattermostAuth):
+		return exitMattermostAuth, "mattermost_auth"
+	default:
+		return exitFailure, "other"
+	}
+}
+
+// mattermostAuthFailed сообщает, что Mattermost отклонил токен бота.
+func mattermostAuthFailed(err error) bool {
+	return mmclient.StatusCode(err) == http.StatusUnauthorized
+}
@@ -2,62 +2,276 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"polling_bot/internal/bot"
+	"polling_bot/internal/buildinfo"
 	"polling_bot/internal/config"
 	"polling_bot/internal/database"
-	"polling_bot/internal/handler"
+	"polling_bot/internal/metrics"
 	"polling_bot/internal/repository"
 	"polling_bot/internal/service"
+	"polling_bot/pkg/pollbot"
 
+	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
 )
 
 func main() {
+	// Время работы бота в ответе на ping считается от запуска процесса
+	started := time.Now()
+	check := flag.Bool("check", false, "проверить настройки, хранилище и учётную запись Mattermost и выйти")
+	flag.Parse()
+
 	cfg := config.Load()
 
 	ctx, stop := signal.NotifyContext(
 		context.Background(),
-		os.Interrupt,    
-		syscall.SIGTERM, 
-		syscall.SIGQUIT, 
+		os.Interrupt,
+		syscall.SIGTERM,
+		syscall.SIGQUIT,
 	)
 	defer stop()
 
-    logger := zerolog.New(
-        zerolog.ConsoleWriter{
-            Out:        os.Stdout,
-            TimeFormat: time.RFC822,
-        },
-    ).With().Timestamp().Logger()
+	logger := zerolog.New(
+		zerolog.ConsoleWriter{
+			Out:        os.Stdout,
+			TimeFormat: time.RFC822,
+		},
+	).With().Timestamp().Logger()
+
+	storageCfg := config.StorageConfigLoad()
+	tarantoolCfg := config.TarantoolConfigLoad()
+
+	if *check {
+		os.Exit(selfCheck(ctx, cfg, storageCfg, tarantoolCfg))
+	}
+
+	err := run(ctx, runConfig{
+		bot:       cfg,
+		storage:   storageCfg,
+		tarantool: tarantoolCfg,
+		started:   started,
+	}, logger)
+	// os.Exit не выполняет отложенные вызовы
+	stop()
+	if code, reason := exitCode(err); err != nil {
+		logger.Error().Err(err).Str("reason", reason).Int("exit_code", code).Msg("Бот завершил работу с ошибкой")
+		os.Exit(code)
+	}
+	logger.Info().Int("exit_code", 0).Msg("Завершение работы бота выполнено")
+}
 
-    tarantoolCfg := config.TarantoolConfigLoad()
+// runConfig - всё, что нужно run для запуска бота.
+type runConfig struct {
+	bot       config.Config
+	storage   config.StorageConfig
+	tarantool config.TarantoolConfig
+	// Время запуска процесса для ответа на ping
+	started time.Time
 
-    conn, err := database.ConnectWithRetry(tarantoolCfg, logger)
-    if err != nil {
-        logger.Err(err).Msg("Не подключиться к Tarantool: %v")
-        return
-    }
-    defer conn.Close()
-    
-    repo := repository.NewTarantoolPollRepo(conn.Connection(), tarantoolCfg.Database)
+	// Подменяют подключение к хранилищу и клиент Mattermost в тестах;
+	// по умолчанию - newRepository и клиент по настройкам бота
+	openStorage func(ctx context.Context, storageCfg config.StorageConfig, tarantoolCfg config.TarantoolConfig, logger zerolog.Logger) (storage, error)
+	client      pollbot.Client
+}
 
-    service := service.NewPollService(repo)
+// run подключается к хранилищу и работает с Mattermost, пока не отменён
+// ctx. Ошибки запуска обёрнуты в errConfig, errStorage или
+// errMattermostAuth; всё, что run открыл, к возврату уже закрыто.
+// Остановка по отмене ctx - не ошибка.
+func run(ctx context.Context, rc runConfig, logger zerolog.Logger) error {
+	cfg, storageCfg := rc.bot, rc.storage
+	// Настройки проверяются до подключения к хранилищу: с ними бот всё
+	// равно не запустится
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", errConfig, err)
+	}
+	if err := storageCfg.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", errConfig, err)
+	}
 
-    handler := handler.NewPollCommandHandler(service)
+	openStorage := rc.openStorage
+	if openStorage == nil {
+		openStorage = newRepository
+	}
+	store, err := openStorage(ctx, storageCfg, rc.tarantool, logger)
+	if err != nil {
+		return fmt.Errorf("%w: подключение: %w", errStorage, err)
+	}
+	defer store.close()
+	if err := store.checkSchema(ctx); err != nil {
+		return fmt.Errorf("%w: хранилище не готово к работе бота: %w", errStorage, err)
+	}
+	if err := store.migrate(ctx); err != nil {
+		return fmt.Errorf("%w: не удалось обновить данные: %w", errStorage, err)
+	}
 
-	bot, err := bot.NewBot(cfg, logger, handler)
-    if  err != nil {
-		logger.Err(err).Msg("Не удалось создать бота: %v")
-        return 
+	repo, votes := store.polls, store.votes
+	if cfg.DebugAddr != "" {
+		// Считаются обращения к самому хранилищу, мимо кэша
+		repo = repository.NewInstrumentedRepo(repo, metrics.Stats)
+		votes = repository.NewInstrumentedVoteRepo(votes, metrics.Stats)
+		go func() {
+			if err := metrics.ServeDebug(ctx, cfg.DebugAddr, metrics.Stats, logger); err != nil {
+				logger.Err(err).Msg("Отладочный сервер остановлен с ошибкой")
+			}
+		}()
 	}
+	if storageCfg.CacheSize > 0 {
+		cached := repository.NewCachedRepo(repo, storageCfg.CacheTTL, storageCfg.CacheSize)
+		repo, votes = cached, cached.Votes(votes)
+	}
+
+	options := []pollbot.Option{
+		pollbot.WithConfig(cfg),
+		pollbot.WithLogger(logger),
+		pollbot.WithRepository(repo, votes),
+		pollbot.WithAuditRepository(store.audit),
+		pollbot.WithScheduleRepository(store.schedules),
+		pollbot.WithTemplateRepository(store.templates),
+		pollbot.WithChannelSettingsRepository(store.channelSettings),
+		pollbot.WithUsageRepository(store.usage),
+		pollbot.WithStoragePinger(storageCfg.Backend, store.pinger),
+		pollbot.WithVersion(buildinfo.Get().Version, rc.started),
+	}
+	if rc.client != nil {
+		options = append(options, pollbot.WithClient(rc.client))
+	}
+	pollBot, err := pollbot.New(options...)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errConfig, err)
+	}
+	if err := pollBot.Run(ctx); err != nil {
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case mattermostAuthFailed(err):
+			return fmt.Errorf("%w: %w", errMattermostAuth, err)
+		default:
+			return fmt.Errorf("бот остановлен: %w", err)
+		}
+	}
+	return nil
+}
+
+// storage - репозитории одного хранилища и закрытие его соединения.
+type storage struct {
+	polls     repository.PollRepository
+	votes     repository.VoteRepository
+	schedules repository.ScheduleRepository
+	templates repository.TemplateRepository
+	audit     repository.AuditRepository
+	// Настройки каналов: включённая сводка опросов
+	channelSettings repository.ChannelSettingsRepository
+	// Счётчики выполненных команд по дням
+	usage repository.UsageRepository
+	// ping проверяет, что хранилище отвечает
+	ping func(ctx context.Context) error
+	// pinger измеряет время ответа хранилища для команды ping
+	pinger service.StoragePinger
+	// checkSchema проверяет, что в хранилище есть всё нужное боту
+	checkSchema func(ctx context.Context) error
+	// migrate обновляет данные прежних версий; выполняется только после
+	// проверки схемы, в --check не выполняется
+	migrate func(ctx context.Context) error
+	close   func()
+}
+
+// newRepository подключается к выбранному в STORAGE_BACKEND хранилищу
+// и возвращает его репозитории.
+func newRepository(ctx context.Context, storageCfg config.StorageConfig, tarantoolCfg config.TarantoolConfig, logger zerolog.Logger) (storage, error) {
+	switch storageCfg.Backend {
+	case config.StorageTarantool:
+		pool, err := database.ConnectWithRetry(tarantoolCfg, logger)
+		if err != nil {
+			return storage{}, fmt.Errorf("Tarantool: %w", err)
+		}
+
+		retry := repository.RetryPolicy{
+			Attempts:  tarantoolCfg.VoteRetries,
+			BaseDelay: tarantoolCfg.VoteRetryDelay,
+		}
+		polls := repository.NewTarantoolPollRepo(pool, tarantoolCfg.Database, retry, logger)
+		votes := repository.NewTarantoolVoteRepo(pool, tarantoolCfg.Database, tarantoolCfg.Votes, retry, logger)
+		schedules := repository.NewTarantoolScheduleRepo(pool, tarantoolCfg.Schedules)
+		templates := repository.NewTarantoolTemplateRepo(pool, tarantoolCfg.Templates)
+		audit := repository.NewTarantoolAuditRepo(pool, tarantoolCfg.Audit)
+		channelSettings := repository.NewTarantoolChannelSettingsRepo(pool, tarantoolCfg.ChannelSettings)
+		usage := repository.NewTarantoolUsageRepo(pool, tarantoolCfg.Usage)
+		return storage{
+			polls:           polls,
+			votes:           votes,
+			schedules:       schedules,
+			templates:       templates,
+			audit:           audit,
+			channelSettings: channelSettings,
+			usage:           usage,
+			ping: func(ctx context.Context) error {
+				_, err := pool.Ping(ctx)
+				return err
+			},
+			pinger: pool,
+			checkSchema: func(ctx context.Context) error {
+				return repository.VerifySchema(ctx, polls, votes, schedules, templates, audit, channelSettings, usage)
+			},
+			migrate: func(ctx context.Context) error {
+				// Прежние версии хранили голоса в самом опросе
+				moved, err := votes.MigrateEmbeddedVotes(ctx)
+				if err != nil {
+					return err
+				}
+				if moved > 0 {
+					logger.Info().Int("votes", moved).Msg("Голоса перенесены из опросов в отдельный space")
+				}
+				// Кортежи старых версий схемы переводятся лениво при записи,
+				// здесь только оценивается, сколько их осталось
+				if _, err := polls.ReportLegacyTuples(ctx); err != nil {
+					logger.Warn().Err(err).Msg("Не удалось оценить число кортежей старой версии")
+				}
+				return nil
+			},
+			close: func() { pool.Close() },
+		}, nil
+
+	case config.StoragePostgres:
+		db, err := sql.Open("postgres", storageCfg.PostgresDSN)
+		if err != nil {
+			return storage{}, fmt.Errorf("PostgreSQL: %w", err)
+		}
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return storage{}, fmt.Errorf("PostgreSQL: %w", err)
+		}
+
+		repo := repository.NewPostgresPollRepo(db)
+		if err := repo.Migrate(ctx); err != nil {
+			db.Close()
+			return storage{}, fmt.Errorf("PostgreSQL: %w", err)
+		}
+		logger.Info().Msg("Используется хранилище PostgreSQL")
+		return storage{
+			polls:           repo,
+			votes:           repository.NewPostgresVoteRepo(db),
+			schedules:       repository.NewPostgresScheduleRepo(db),
+			templates:       repository.NewPostgresTemplateRepo(db),
+			audit:           repository.NewPostgresAuditRepo(db),
+			channelSettings: repository.NewPostgresChannelSettingsRepo(db),
+			usage:           repository.NewPostgresUsageRepo(db),
+			ping:            db.PingContext,
+			pinger:          repo,
+			// Таблицы создают миграции, уже применённые выше
+			checkSchema: func(context.Context) error { return nil },
+			migrate:     func(context.Context) error { return nil },
+			close:       func() { db.Close() },
+		}, nil
 
-	if err := bot.Start(ctx); err != nil {
-		logger.Err(err).Msg("Не удалось запустить бота: %v")
+	default:
+		return storage{}, fmt.Errorf("неизвестное хранилище STORAGE_BACKEND=%q", storageCfg.Backend)
 	}
-	logger.Info().Msg("Завершение работы бота выполнено")
 }
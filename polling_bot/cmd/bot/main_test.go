@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/repository"
+	"polling_bot/pkg/pollbot"
+)
+
+// rejectingClient - Mattermost, который не принимает токен бота.
+type rejectingClient struct {
+	pollbot.Client
+}
+
+func (rejectingClient) GetMe() (*pollbot.User, error) {
+	return nil, &mmclient.Error{StatusCode: http.StatusUnauthorized, Err: errors.New("invalid token")}
+}
+
+// memoryStorage - хранилище в памяти, которое считает закрытия; schemaErr
+// возвращает проверка схемы.
+func memoryStorage(closed *int, schemaErr error) storage {
+	polls := repository.NewMemoryPollRepo()
+	return storage{
+		polls:           polls,
+		votes:           repository.NewMemoryVoteRepo(polls),
+		schedules:       repository.NewMemoryScheduleRepo(),
+		templates:       repository.NewMemoryTemplateRepo(),
+		audit:           repository.NewMemoryAuditRepo(),
+		channelSettings: repository.NewMemoryChannelSettingsRepo(),
+		usage:           repository.NewMemoryUsageRepo(),
+		ping:            func(context.Context) error { return nil },
+		checkSchema:     func(context.Context) error { return schemaErr },
+		migrate:         func(context.Context) error { return nil },
+		close:           func() { *closed++ },
+	}
+}
+
+func testRunConfig() runConfig {
+	return runConfig{
+		bot: config.Config{
+			MattermostURL:     "https://mattermost.example.com",
+			BotToken:          "token",
+			CommandPrefix:     "!poll",
+			PollLimitTimezone: "UTC",
+		},
+		storage: config.StorageConfig{Backend: config.StorageTarantool},
+		started: time.Now(),
+	}
+}
+
+// Тест проверяет, к какой причине run относит сбой запуска и что открытое
+// до сбоя хранилище закрыто
+func TestRun_Failures(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(rc *runConfig)
+		openErr    error
+		schemaErr  error
+		wantErr    error
+		wantOpened bool
+		wantCode   int
+		wantReason string
+	}{
+		{
+			name:       "config without a token",
+			modify:     func(rc *runConfig) { rc.bot.BotToken = "" },
+			wantErr:    errConfig,
+			wantCode:   exitConfig,
+			wantReason: "config",
+		},
+		{
+			name:       "unknown storage backend",
+			modify:     func(rc *runConfig) { rc.storage.Backend = "mongo" },
+			wantErr:    errConfig,
+			wantCode:   exitConfig,
+			wantReason: "config",
+		},
+		{
+			name:       "tarantool unreachable",
+			openErr:    fmt.Errorf("Tarantool: %w", errors.New("connection refused")),
+			wantErr:    errStorage,
+			wantOpened: true,
+			wantCode:   exitStorage,
+			wantReason: "storage",
+		},
+		{
+			name:       "schema mismatch",
+			schemaErr:  errors.New("нет space polls"),
+			wantErr:    errStorage,
+			wantOpened: true,
+			wantCode:   exitStorage,
+			wantReason: "storage",
+		},
+		{
+			name:       "mattermost rejects the token",
+			wantErr:    errMattermostAuth,
+			wantOpened: true,
+			wantCode:   exitMattermostAuth,
+			wantReason: "mattermost_auth",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := testRunConfig()
+			if tt.modify != nil {
+				tt.modify(&rc)
+			}
+			opened, closed := false, 0
+			rc.openStorage = func(context.Context, config.StorageConfig, config.TarantoolConfig, zerolog.Logger) (storage, error) {
+				opened = true
+				if tt.openErr != nil {
+					return storage{}, tt.openErr
+				}
+				return memoryStorage(&closed, tt.schemaErr), nil
+			}
+			rc.client = rejectingClient{}
+
+			// Бот, который не запустился, не должен ждать отмены ctx
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := run(ctx, rc, zerolog.Nop())
+			require.Error(t, err)
+			assert.NoError(t, ctx.Err(), "run вернулся только по таймауту")
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantOpened, opened)
+			if tt.wantOpened && tt.openErr == nil {
+				assert.Equal(t, 1, closed, "хранилище закрыто один раз")
+			}
+
+			code, reason := exitCode(err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+// Тест проверяет коды выхода для ошибок, не относящихся к причинам запуска
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "clean shutdown", wantCode: 0},
+		{name: "other error", err: errors.New("websocket: обрыв"), wantCode: exitFailure},
+		{name: "wrapped twice", err: fmt.Errorf("запуск: %w", fmt.Errorf("%w: нет токена", errConfig)), wantCode: exitConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := exitCode(tt.err)
+			assert.Equal(t, tt.wantCode, code)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/service"
+)
+
+// Предел размера тела запроса на создание опроса
+const maxCreateBody = 64 << 10
+
+// Заголовок, по которому повтор запроса возвращает уже созданный опрос
+const headerIdempotencyKey = "Idempotency-Key"
+
+// PollCreator создаёт опросы; реализован service.PollServiceImpl.
+type PollCreator interface {
+	CreatePollWithID(ctx context.Context, userID, question string, options []string, opts service.CreateOptions) (service.CreatedPoll, error)
+}
+
+// SetPollCreator включает POST /api/v1/polls: опросы создаёт creator,
+// сообщение о них публикует announcer. serviceUser становится создателем
+// опроса, если в запросе нет user_id.
+func (s *Server) SetPollCreator(creator PollCreator, announcer service.Announcer, serviceUser string) {
+	s.creator = creator
+	s.announcer = announcer
+	s.serviceUser = serviceUser
+	s.mux.Handle("POST /api/v1/polls", s.authorized(ScopeWrite, s.createPoll))
+}
+
+type createPollRequest struct {
+	Question    string   `json:"question"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Options     []string `json:"options"`
+	ChannelID   string   `json:"channel_id"`
+	// Создатель опроса; пусто - служебный пользователь API
+	UserID      string `json:"user_id,omitempty"`
+	ChannelOnly bool   `json:"channel_only,omitempty"`
+	Quorum      int    `json:"quorum,omitempty"`
+	MaxVotes    int    `json:"max_votes,omitempty"`
+	Exclusive   bool   `json:"exclusive,omitempty"`
+	Ranked      bool   `json:"ranked,omitempty"`
+	MembersOnly bool   `json:"members_only,omitempty"`
+}
+
+type createdPollJSON struct {
+	ID string `json:"id"`
+	// Ответ бота, как на команду create
+	Message string `json:"message"`
+	// Сообщение опубликовано в канале
+	Announced bool `json:"announced"`
+}
+
+func (s *Server) createPoll(w http.ResponseWriter, r *http.Request) {
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCreateBody))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "слишком большое тело запроса")
+		return
+	}
+	req, err := parseCreateRequest(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key := r.Header.Get(headerIdempotencyKey)
+	if key == "" {
+		status, body, ok := s.create(r.Context(), req, "")
+		s.writeCreated(w, status, body, ok)
+		return
+	}
+
+	// Ключи разных клиентов не пересекаются
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	storeKey := token + "\x00" + key
+	fingerprint := sha256.Sum256(raw)
+
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	if stored, ok := s.idempotency.get(storeKey); ok {
+		if stored.fingerprint != fingerprint {
+			writeError(w, http.StatusUnprocessableEntity, "Idempotency-Key уже использован с другим запросом")
+			return
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		s.writeCreated(w, stored.status, stored.body, true)
+		return
+	}
+
+	status, body, ok := s.create(r.Context(), req, key)
+	// Ошибку клиент может повторить с тем же ключом, поэтому она не сохраняется
+	if ok {
+		s.idempotency.put(storeKey, storedResponse{fingerprint: fingerprint, status: status, body: body})
+	}
+	s.writeCreated(w, status, body, ok)
+}
+
+func (s *Server) writeCreated(w http.ResponseWriter, status int, body createdPollJSON, ok bool) {
+	if !ok {
+		writeError(w, status, body.Message)
+		return
+	}
+	if status == http.StatusCreated {
+		w.Header().Set("Location", "/api/v1/polls/"+body.ID)
+	}
+	writeJSON(w, status, body)
+}
+
+// create создаёт опрос и публикует его в канале. ok == false означает
+// ошибку: тогда status - её код, а body.Message - текст.
+func (s *Server) create(ctx context.Context, req createPollRequest, key string) (int, createdPollJSON, bool) {
+	userID := req.UserID
+	if userID == "" {
+		userID = s.serviceUser
+	}
+	// С ключом ID опроса определяется им, и повтор после перезапуска бота
+	// не создаст второй опрос
+	origin := service.Origin{ChannelID: req.ChannelID}
+	if key != "" {
+		origin.PostID = "api/" + key
+	}
+	ctx = service.WithOrigin(i18n.WithLocalizer(ctx, s.loc), origin)
+
+	created, err := s.creator.CreatePollWithID(ctx, userID, req.Question, req.Options, service.CreateOptions{
+		RestrictToChannel: req.ChannelOnly,
+		Quorum:            req.Quorum,
+		MaxVotes:          req.MaxVotes,
+		Exclusive:         req.Exclusive,
+		Ranked:            req.Ranked,
+		MembersOnly:       req.MembersOnly,
+		Description:       req.Description,
+		Tags:              req.Tags,
+	})
+	if err != nil {
+		status, message := s.serviceError(err)
+		return status, createdPollJSON{Message: message}, false
+	}
+
+	body := createdPollJSON{ID: created.ID, Message: created.Message}
+	if created.Duplicate {
+		// Сообщение о нём уже публиковалось при первом запросе
+		return http.StatusOK, body, true
+	}
+	if err := s.announcer.Announce(ctx, req.ChannelID, created.Message); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", created.ID).Msg("Не удалось опубликовать опрос, созданный через API")
+	} else {
+		body.Announced = true
+	}
+	return http.StatusCreated, body, true
+}
+
+func parseCreateRequest(raw []byte) (createPollRequest, error) {
+	var req createPollRequest
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return req, errors.New("неверное тело запроса: " + err.Error())
+	}
+	if strings.TrimSpace(req.Question) == "" {
+		return req, errors.New("нужен question")
+	}
+	if req.ChannelID == "" {
+		return req, errors.New("нужен channel_id")
+	}
+	return req, nil
+}
+
+// serviceError переводит ошибку сервиса опросов в HTTP-статус. Ошибки проверки
+// опроса отдаются тем же текстом, что бот отвечает в чате.
+func (s *Server) serviceError(err error) (int, string) {
+	var userErr *i18n.Error
+	switch {
+	case errors.Is(err, service.ErrServiceUnavailable):
+		return http.StatusServiceUnavailable, s.loc.Error(err)
+	case errors.As(err, &userErr) && userErr.Err == nil:
+		return http.StatusBadRequest, s.loc.Error(err)
+	default:
+		s.logger.Error().Err(err).Msg("Ошибка создания опроса через API")
+		return http.StatusInternalServerError, "внутренняя ошибка"
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+)
+
+const testWriteToken = "write-token"
+
+type recordingAnnouncer struct {
+	channels []string
+	err      error
+}
+
+func (a *recordingAnnouncer) Announce(ctx context.Context, channelID, message string) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.channels = append(a.channels, channelID)
+	return nil
+}
+
+func newWriteServer(t *testing.T) (*Server, repository.PollRepository, *recordingAnnouncer) {
+	t.Helper()
+	repo := repository.NewMemoryPollRepo()
+	announcer := &recordingAnnouncer{}
+	votes := repository.NewMemoryVoteRepo(repo)
+	s := NewServer(repo, votes, testToken, zerolog.Nop())
+	s.AddToken(testWriteToken, ScopeRead|ScopeWrite)
+	s.SetLocalizer(i18n.New("en"))
+	s.SetPollCreator(service.NewPollService(repo, votes, zerolog.Nop()), announcer, "api-bot")
+	return s, repo, announcer
+}
+
+func post(t *testing.T, h http.Handler, body, token, key string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/polls", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if key != "" {
+		req.Header.Set(headerIdempotencyKey, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+const validCreateBody = `{"question": "Обед?", "options": ["Пицца", "Суши"], "channel_id": "c1"}`
+
+// Тест проверяет права токенов: чтения недостаточно для создания опроса
+func TestServer_CreatePollScopes(t *testing.T) {
+	s, _, _ := newWriteServer(t)
+
+	assert.Equal(t, http.StatusUnauthorized, post(t, s, validCreateBody, "", "").Code)
+	assert.Equal(t, http.StatusForbidden, post(t, s, validCreateBody, testToken, "").Code)
+	assert.Equal(t, http.StatusCreated, post(t, s, validCreateBody, testWriteToken, "").Code)
+	// Токен записи может и читать
+	assert.Equal(t, http.StatusOK, get(t, s, "/api/v1/polls", testWriteToken).Code)
+}
+
+// Тест проверяет создание опроса и его публикацию в канале
+func TestServer_CreatePoll(t *testing.T) {
+	s, repo, announcer := newWriteServer(t)
+
+	rec := post(t, s, `{"question": "Обед?", "description": "В пятницу\nс 13:00", "tags": ["Food", "team-a"], "options": ["Пицца", "Суши"], "channel_id": "c1", "quorum": 3, "ranked": true}`, testWriteToken, "")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	created := decode[createdPollJSON](t, rec)
+	assert.True(t, created.Announced)
+	assert.Contains(t, created.Message, created.ID)
+	assert.Equal(t, "/api/v1/polls/"+created.ID, rec.Header().Get("Location"))
+	assert.Equal(t, []string{"c1"}, announcer.channels)
+
+	poll, err := repo.GetPoll(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "api-bot", poll.Creator)
+	assert.Equal(t, "c1", poll.ChannelID)
+	assert.Equal(t, 3, poll.Quorum)
+	assert.True(t, poll.Ranked)
+	assert.Equal(t, "В пятницу\nс 13:00", poll.Description)
+	assert.Equal(t, []string{"food", "team-a"}, poll.Tags)
+
+	rec = post(t, s, `{"question": "Ужин?", "options": ["Да"], "channel_id": "c1", "user_id": "u7"}`, testWriteToken, "")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	poll, err = repo.GetPoll(context.Background(), decode[createdPollJSON](t, rec).ID)
+	require.NoError(t, err)
+	assert.Equal(t, "u7", poll.Creator)
+}
+
+// Тест проверяет, что ошибки проверки опроса возвращаются 400 с текстом бота
+func TestServer_CreatePollValidation(t *testing.T) {
+	s, _, announcer := newWriteServer(t)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "bad json", body: `{"question":`, want: "неверное тело запроса"},
+		{name: "unknown field", body: `{"question": "Q", "channel_id": "c1", "anonymous": true}`, want: "неверное тело запроса"},
+		{name: "no question", body: `{"options": ["A"], "channel_id": "c1"}`, want: "нужен question"},
+		{name: "no channel", body: `{"question": "Q", "options": ["A"]}`, want: "нужен channel_id"},
+		{name: "no options", body: `{"question": "Q", "channel_id": "c1"}`, want: "at least one option is required"},
+		{name: "duplicate options", body: `{"question": "Q", "options": ["A", "A"], "channel_id": "c1"}`, want: "all poll options must be unique"},
+		{name: "long description", body: `{"question": "Q", "description": "` + strings.Repeat("я", 1001) + `", "options": ["A"], "channel_id": "c1"}`, want: "the description is longer than 1000 characters"},
+		{name: "invalid tag", body: `{"question": "Q", "tags": ["team a"], "options": ["A"], "channel_id": "c1"}`, want: "tag 'team a' may contain only letters, digits, - and _"},
+		{name: "negative quorum", body: `{"question": "Q", "options": ["A"], "channel_id": "c1", "quorum": -1}`, want: "the quorum must be a whole number of at least 1"},
+		{name: "negative max votes", body: `{"question": "Q", "options": ["A"], "channel_id": "c1", "max_votes": -1}`, want: "the vote limit must be a whole number of at least 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(t, s, tt.body, testWriteToken, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, decode[errorJSON](t, rec).Error, tt.want)
+		})
+	}
+	assert.Empty(t, announcer.channels)
+}
+
+// Тест проверяет, что повтор с тем же Idempotency-Key не создаёт второй опрос
+func TestServer_CreatePollIdempotent(t *testing.T) {
+	s, repo, announcer := newWriteServer(t)
+
+	first := post(t, s, validCreateBody, testWriteToken, "key-1")
+	require.Equal(t, http.StatusCreated, first.Code)
+	replay := post(t, s, validCreateBody, testWriteToken, "key-1")
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, decode[createdPollJSON](t, first).ID, decode[createdPollJSON](t, replay).ID)
+	assert.Len(t, announcer.channels, 1)
+
+	// Тот же ключ с другим телом - ошибка клиента
+	rec := post(t, s, `{"question": "Другой?", "options": ["A"], "channel_id": "c1"}`, testWriteToken, "key-1")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	// Забытый ключ (например, после перезапуска) всё равно не создаёт дубликат
+	s.idempotency = newIdempotencyKeys(idempotencyKeysSize, idempotencyKeysTTL)
+	rec = post(t, s, validCreateBody, testWriteToken, "key-1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, decode[createdPollJSON](t, first).ID, decode[createdPollJSON](t, rec).ID)
+	assert.Len(t, announcer.channels, 1)
+
+	// Другой ключ - новый опрос
+	assert.Equal(t, http.StatusCreated, post(t, s, validCreateBody, testWriteToken, "key-2").Code)
+	polls, _, err := repo.ListPolls(context.Background(), repository.ListFilter{})
+	require.NoError(t, err)
+	assert.Len(t, polls, 2)
+}
+
+// Тест проверяет, что сбой публикации не отменяет созданный опрос
+func TestServer_CreatePollAnnounceFailure(t *testing.T) {
+	s, repo, announcer := newWriteServer(t)
+	announcer.err = errors.New("mattermost недоступен")
+
+	rec := post(t, s, validCreateBody, testWriteToken, "")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	created := decode[createdPollJSON](t, rec)
+	assert.False(t, created.Announced)
+
+	_, err := repo.GetPoll(context.Background(), created.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Сколько ключей Idempotency-Key помнить и как долго
+const (
+	idempotencyKeysSize = 1000
+	idempotencyKeysTTL  = 24 * time.Hour
+)
+
+// storedResponse - ответ на создание опроса, сохранённый для повторов.
+type storedResponse struct {
+	// Хэш тела запроса: повтор с тем же ключом, но другим телом - ошибка клиента
+	fingerprint [32]byte
+	status      int
+	body        createdPollJSON
+}
+
+type idempotencyEntry struct {
+	key       string
+	response  storedResponse
+	expiresAt time.Time
+}
+
+// idempotencyKeys - LRU недавних ключей Idempotency-Key с ответами на них.
+// Безопасен для параллельных запросов.
+type idempotencyKeys struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+
+	now func() time.Time
+}
+
+func newIdempotencyKeys(size int, ttl time.Duration) *idempotencyKeys {
+	return &idempotencyKeys{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (k *idempotencyKeys) get(key string) (storedResponse, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	elem, ok := k.entries[key]
+	if !ok {
+		return storedResponse{}, false
+	}
+	entry := elem.Value.(*idempotencyEntry)
+	if !k.now().Before(entry.expiresAt) {
+		k.order.Remove(elem)
+		delete(k.entries, key)
+		return storedResponse{}, false
+	}
+	k.order.MoveToFront(elem)
+	return entry.response, true
+}
+
+func (k *idempotencyKeys) put(key string, response storedResponse) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry := &idempotencyEntry{key: key, response: response, expiresAt: k.now().Add(k.ttl)}
+	if elem, ok := k.entries[key]; ok {
+		elem.Value = entry
+		k.order.MoveToFront(elem)
+		return
+	}
+	k.entries[key] = k.order.PushFront(entry)
+
+	for k.order.Len() > k.size {
+		oldest := k.order.Back()
+		k.order.Remove(oldest)
+		delete(k.entries, oldest.Value.(*idempotencyEntry).key)
+	}
+}
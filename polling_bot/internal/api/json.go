@@ -0,0 +1,165 @@
+package api
+
+import (
+	"time"
+
+	"polling_bot/internal/buildinfo"
+	"polling_bot/internal/health"
+	"polling_bot/internal/models"
+	"polling_bot/internal/service"
+)
+
+// Ответы API не содержат ID голосовавших и бюллетени: только их число
+// и итоги по вариантам.
+
+type pollJSON struct {
+	ID          string     `json:"id"`
+	Creator     string     `json:"creator"`
+	Question    string     `json:"question"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Options     []string   `json:"options"`
+	Closed      bool       `json:"closed"`
+	ChannelID   string     `json:"channel_id,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ChannelOnly bool       `json:"channel_only"`
+	Quorum      int        `json:"quorum,omitempty"`
+	MaxVotes    int        `json:"max_votes,omitempty"`
+	Ranked      bool       `json:"ranked"`
+	Anonymous   bool       `json:"anonymous,omitempty"`
+	MembersOnly bool       `json:"members_only,omitempty"`
+	VoterCount  int        `json:"voter_count"`
+}
+
+type pollListJSON struct {
+	Polls []pollJSON `json:"polls"`
+	// Передать в cursor, чтобы получить следующую страницу; пусто - страниц больше нет
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type optionVotesJSON struct {
+	Option string `json:"option"`
+	Votes  int    `json:"votes"`
+}
+
+type roundJSON struct {
+	Votes      []optionVotesJSON `json:"votes"`
+	Eliminated string            `json:"eliminated,omitempty"`
+}
+
+type resultsJSON struct {
+	PollID       string            `json:"poll_id"`
+	Question     string            `json:"question"`
+	Description  string            `json:"description,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Closed       bool              `json:"closed"`
+	Ranked       bool              `json:"ranked"`
+	VoterCount   int               `json:"voter_count"`
+	Options      []optionVotesJSON `json:"options"`
+	Emoji        map[string]string `json:"emoji,omitempty"`
+	AllowAbstain bool              `json:"allow_abstain,omitempty"`
+	Abstained    int               `json:"abstained,omitempty"`
+	// Суммы весов голосов по вариантам, только в опросе с весами
+	Weighted     []optionVotesJSON `json:"weighted,omitempty"`
+	Rounds       []roundJSON       `json:"rounds,omitempty"`
+	RankedWinner string            `json:"ranked_winner,omitempty"`
+}
+
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+type checkJSON struct {
+	Name string `json:"name"`
+	// "ok", "fail" или "skipped"
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type readinessJSON struct {
+	Status   string          `json:"status"`
+	Checks   []checkJSON     `json:"checks,omitempty"`
+	Build    *buildinfo.Info `json:"build,omitempty"`
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+func newReadinessJSON(results []health.Result) readinessJSON {
+	out := readinessJSON{Status: "ok"}
+	if !health.OK(results) {
+		out.Status = "fail"
+	}
+	for _, result := range results {
+		check := checkJSON{Name: result.Name, Status: "ok"}
+		switch {
+		case result.Skipped:
+			check.Status = "skipped"
+		case result.Err != nil:
+			check.Status = "fail"
+			check.Error = result.Err.Error()
+		}
+		out.Checks = append(out.Checks, check)
+	}
+	return out
+}
+
+func newPollJSON(poll models.Poll) pollJSON {
+	// Описанию опроса хватает счётчиков, бюллетени нужны только итогам
+	results := service.BuildResults(poll, nil)
+	out := pollJSON{
+		ID:          poll.ID,
+		Creator:     poll.Creator,
+		Question:    poll.Question,
+		Description: poll.Description,
+		Tags:        poll.Tags,
+		Options:     make([]string, 0, len(results.Options)),
+		Closed:      poll.Closed,
+		ChannelID:   poll.ChannelID,
+		ChannelOnly: poll.RestrictToChannel,
+		Quorum:      poll.Quorum,
+		MaxVotes:    poll.MaxVotes,
+		Ranked:      poll.Ranked,
+		Anonymous:   poll.Anonymous(),
+		MembersOnly: poll.MembersOnly,
+		VoterCount:  results.VoterCount,
+	}
+	for _, votes := range results.Options {
+		out.Options = append(out.Options, votes.Option)
+	}
+	if !poll.CreatedAt.IsZero() {
+		createdAt := poll.CreatedAt.UTC()
+		out.CreatedAt = &createdAt
+	}
+	return out
+}
+
+func newResultsJSON(results service.Results) resultsJSON {
+	out := resultsJSON{
+		PollID:       results.PollID,
+		Question:     results.Question,
+		Description:  results.Description,
+		Tags:         results.Tags,
+		Closed:       results.Closed,
+		Ranked:       results.Ranked,
+		VoterCount:   results.VoterCount,
+		Options:      newOptionVotesJSON(results.Options),
+		Emoji:        results.Emoji,
+		RankedWinner: results.RankedWinner,
+		AllowAbstain: results.AllowAbstain,
+		Abstained:    results.Abstained,
+	}
+	if results.Weighted != nil {
+		out.Weighted = newOptionVotesJSON(results.Weighted)
+	}
+	for _, round := range results.Rounds {
+		out.Rounds = append(out.Rounds, roundJSON{Votes: newOptionVotesJSON(round.Votes), Eliminated: round.Eliminated})
+	}
+	return out
+}
+
+func newOptionVotesJSON(votes []service.OptionVotes) []optionVotesJSON {
+	out := make([]optionVotesJSON, 0, len(votes))
+	for _, v := range votes {
+		out = append(out, optionVotesJSON{Option: v.Option, Votes: v.Votes})
+	}
+	return out
+}
@@ -0,0 +1,343 @@
+// Package api отдаёт опросы по HTTP - например, для внутреннего дашборда -
+// и создаёт их по запросам других сервисов. Тот же сервер отвечает
+// на проверку работоспособности и отдаёт метрики expvar и Prometheus.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"polling_bot/internal/buildinfo"
+	"polling_bot/internal/health"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+)
+
+// Сколько ждать завершения текущих запросов при остановке
+const shutdownTimeout = 5 * time.Second
+
+// Сколько ждать проверок готовности, прежде чем ответить 503
+const readinessTimeout = 5 * time.Second
+
+// Scope - права токена API.
+type Scope int
+
+const (
+	ScopeRead Scope = 1 << iota
+	ScopeWrite
+)
+
+type Server struct {
+	polls  repository.PollRepository
+	votes  repository.VoteRepository
+	tokens map[string]Scope
+	logger zerolog.Logger
+	mux    *http.ServeMux
+
+	creator     PollCreator
+	announcer   service.Announcer
+	serviceUser string
+	loc         *i18n.Localizer
+	// Создание с Idempotency-Key выполняется по одному, чтобы два
+	// одновременных повтора не прошли мимо сохранённого ответа
+	createMu    sync.Mutex
+	idempotency *idempotencyKeys
+
+	readiness []health.Check
+	// Сборка и возможности бота в теле GET /readyz; nil - не показываются
+	build    *buildinfo.Info
+	features []service.Feature
+	// Метрики в формате Prometheus в GET /metrics; nil - маршрут отвечает 404
+	metrics http.Handler
+}
+
+// NewServer собирает маршруты сервера. Маршруты /api/v1 требуют заголовка
+// "Authorization: Bearer <token>"; token даёт право на чтение. Без единого
+// токена API выключен и отвечает 404, а /healthz, /readyz, /debug/vars и
+// /metrics доступны всегда.
+func NewServer(polls repository.PollRepository, votes repository.VoteRepository, token string, logger zerolog.Logger) *Server {
+	s := &Server{
+		polls:       polls,
+		votes:       votes,
+		tokens:      make(map[string]Scope),
+		logger:      logger,
+		mux:         http.NewServeMux(),
+		loc:         i18n.Default(),
+		idempotency: newIdempotencyKeys(idempotencyKeysSize, idempotencyKeysTTL),
+	}
+	s.AddToken(token, ScopeRead)
+	s.mux.HandleFunc("GET /healthz", s.health)
+	s.mux.HandleFunc("GET /readyz", s.ready)
+	s.mux.Handle("GET /debug/vars", expvar.Handler())
+	s.mux.HandleFunc("GET /metrics", s.serveMetrics)
+	s.mux.Handle("GET /api/v1/polls", s.authorized(ScopeRead, s.listPolls))
+	s.mux.Handle("GET /api/v1/polls/{id}", s.authorized(ScopeRead, s.getPoll))
+	s.mux.Handle("GET /api/v1/polls/{id}/results", s.authorized(ScopeRead, s.getResults))
+	return s
+}
+
+// AddToken разрешает токену действия scopes. Пустой токен игнорируется.
+func (s *Server) AddToken(token string, scopes Scope) {
+	if token != "" {
+		s.tokens[token] |= scopes
+	}
+}
+
+// SetReadiness задаёт проверки GET /readyz: хранилище, Mattermost.
+// Без проверок бот считается готовым, как только запущен сервер.
+func (s *Server) SetReadiness(checks ...health.Check) {
+	s.readiness = checks
+}
+
+// SetBuildInfo добавляет в тело GET /readyz сборку бота и возможности,
+// включённые настройками.
+func (s *Server) SetBuildInfo(build buildinfo.Info, features []service.Feature) {
+	s.build, s.features = &build, features
+}
+
+// SetLocalizer задаёт язык ошибок проверки опроса: они совпадают
+// с ответами бота в чате. По умолчанию - язык i18n.Default.
+func (s *Server) SetLocalizer(loc *i18n.Localizer) {
+	s.loc = loc
+}
+
+// SetMetrics задаёт ответ GET /metrics - метрики отслеживаемых опросов
+// для сборщика Prometheus.
+func (s *Server) SetMetrics(handler http.Handler) {
+	s.metrics = handler
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe обслуживает addr, пока не отменён ctx.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{Addr: addr, Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.logger.Warn().Err(err).Msg("HTTP-сервер остановлен не полностью")
+		}
+	}()
+
+	s.logger.Info().Str("addr", addr).Msg("HTTP-сервер запущен")
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-done
+	return nil
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metrics == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.metrics.ServeHTTP(w, r)
+}
+
+// ready отвечает 200, если все проверки готовности прошли, и 503 с
+// итогом каждой проверки, если нет.
+func (s *Server) ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	results := health.Run(ctx, s.readiness)
+	out := newReadinessJSON(results)
+	out.Build = s.build
+	if len(s.features) > 0 {
+		out.Features = make(map[string]bool, len(s.features))
+		for _, feature := range s.features {
+			out.Features[feature.Name] = feature.Enabled
+		}
+	}
+	if !health.OK(results) {
+		writeJSON(w, http.StatusServiceUnavailable, out)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// authorized пропускает запрос с токеном, у которого есть права need.
+func (s *Server) authorized(need Scope, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.tokens) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		scopes := s.tokenScopes(token)
+		if !ok || scopes == 0 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="polling_bot"`)
+			writeError(w, http.StatusUnauthorized, "нужен действующий bearer-токен")
+			return
+		}
+		if scopes&need != need {
+			writeError(w, http.StatusForbidden, "у токена нет прав на это действие")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// tokenScopes сравнивает токен со всеми известными за постоянное время,
+// чтобы его нельзя было подобрать по времени ответа.
+func (s *Server) tokenScopes(token string) Scope {
+	var scopes Scope
+	for known, granted := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+			scopes = granted
+		}
+	}
+	return scopes
+}
+
+func (s *Server) listPolls(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	polls, cursor, err := s.polls.ListPolls(r.Context(), filter)
+	if err != nil {
+		s.storageError(w, err)
+		return
+	}
+	out := pollListJSON{Polls: make([]pollJSON, 0, len(polls)), NextCursor: cursor}
+	for _, poll := range polls {
+		out.Polls = append(out.Polls, newPollJSON(poll))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) getPoll(w http.ResponseWriter, r *http.Request) {
+	poll, err := s.polls.GetPoll(r.Context(), r.PathValue("id"))
+	if err != nil {
+		s.storageError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPollJSON(poll))
+}
+
+func (s *Server) getResults(w http.ResponseWriter, r *http.Request) {
+	poll, err := s.polls.GetPoll(r.Context(), r.PathValue("id"))
+	if err != nil {
+		s.storageError(w, err)
+		return
+	}
+	// Рейтинговый опрос пересчитывается по бюллетеням участников
+	var ballots [][]string
+	if poll.Ranked {
+		votes, err := s.votes.ListVotes(r.Context(), poll.ID)
+		if err != nil {
+			s.storageError(w, err)
+			return
+		}
+		for _, vote := range votes {
+			if len(vote.Choices) > 0 {
+				ballots = append(ballots, vote.Choices)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, newResultsJSON(service.BuildResults(poll, ballots)))
+}
+
+// Параметры запроса списка опросов
+const (
+	paramCreator       = "creator"
+	paramChannel       = "channel_id"
+	paramTag           = "tag"
+	paramClosed        = "closed"
+	paramCreatedAfter  = "created_after"
+	paramCreatedBefore = "created_before"
+	paramCursor        = "cursor"
+	paramLimit         = "limit"
+)
+
+// parseListFilter разбирает фильтр ListPolls из параметров запроса. Время -
+// в RFC 3339, limit больше repository.MaxListLimit урезается хранилищем.
+func parseListFilter(r *http.Request) (repository.ListFilter, error) {
+	q := r.URL.Query()
+	filter := repository.ListFilter{
+		Creator:   q.Get(paramCreator),
+		ChannelID: q.Get(paramChannel),
+		// Метки хранятся в нижнем регистре
+		Tag:    strings.ToLower(strings.TrimSpace(q.Get(paramTag))),
+		Cursor: q.Get(paramCursor),
+	}
+	if v := q.Get(paramClosed); v != "" {
+		closed, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errInvalidParam(paramClosed)
+		}
+		filter.Closed = &closed
+	}
+	for name, dst := range map[string]*time.Time{
+		paramCreatedAfter:  &filter.CreatedAfter,
+		paramCreatedBefore: &filter.CreatedBefore,
+	} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, errInvalidParam(name)
+			}
+			*dst = t
+		}
+	}
+	if v := q.Get(paramLimit); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, errInvalidParam(paramLimit)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+func errInvalidParam(name string) error {
+	return errors.New("неверный параметр " + name)
+}
+
+// storageError переводит ошибку хранилища в HTTP-статус.
+func (s *Server) storageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		writeError(w, http.StatusNotFound, "опрос не найден")
+	case errors.Is(err, repository.ErrUnavailable):
+		writeError(w, http.StatusServiceUnavailable, "хранилище временно недоступно")
+	default:
+		s.logger.Error().Err(err).Msg("Ошибка запроса к API")
+		writeError(w, http.StatusInternalServerError, "внутренняя ошибка")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorJSON{Error: message})
+}
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/buildinfo"
+	"polling_bot/internal/health"
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+)
+
+const testToken = "secret-token"
+
+func pollID(i int) string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	repo := repository.NewMemoryPollRepo()
+	votes := repository.NewMemoryVoteRepo(repo)
+	ctx := context.Background()
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		var tags []string
+		if i >= 3 && i%2 == 1 {
+			tags = []string{"release"}
+		}
+		require.NoError(t, repo.SavePoll(ctx, models.Poll{
+			ID:          pollID(i),
+			Creator:     "creator1",
+			Question:    fmt.Sprintf("Вопрос %d", i),
+			Options:     map[string]int{"Пицца": 1, "Суши": 1},
+			OptionOrder: []string{"Пицца", "Суши"},
+			Closed:      i%2 == 0,
+			ChannelID:   "c1",
+			CreatedAt:   created.Add(time.Duration(i) * time.Hour),
+			Ranked:      i == 5,
+			Tags:        tags,
+		}))
+		require.NoError(t, votes.ImportVotes(ctx, []models.Vote{
+			{PollID: pollID(i), UserID: "u1", Choices: []string{"Пицца", "Суши"}},
+			{PollID: pollID(i), UserID: "u2", Choices: []string{"Суши", "Пицца"}},
+		}))
+	}
+	return NewServer(repo, votes, testToken, zerolog.Nop())
+}
+
+func get(t *testing.T, h http.Handler, path, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var out T
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	return out
+}
+
+// Тест проверяет, что без верного токена API отвечает 401
+func TestServer_Auth(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "no header", want: http.StatusUnauthorized},
+		{name: "wrong token", header: "Bearer other", want: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic " + testToken, want: http.StatusUnauthorized},
+		{name: "empty bearer", header: "Bearer ", want: http.StatusUnauthorized},
+		{name: "valid", header: "Bearer " + testToken, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{"/api/v1/polls", "/api/v1/polls/" + pollID(1), "/api/v1/polls/" + pollID(1) + "/results"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.header != "" {
+					req.Header.Set("Authorization", tt.header)
+				}
+				rec := httptest.NewRecorder()
+				s.ServeHTTP(rec, req)
+				assert.Equal(t, tt.want, rec.Code, path)
+				if tt.want == http.StatusUnauthorized {
+					assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+				}
+			}
+		})
+	}
+}
+
+// Тест проверяет, что без токена в конфигурации API выключен, а health и метрики работают
+func TestServer_WithoutToken(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewServer(repo, repository.NewMemoryVoteRepo(repo), "", zerolog.Nop())
+
+	assert.Equal(t, http.StatusNotFound, get(t, s, "/api/v1/polls", "").Code)
+	assert.Equal(t, http.StatusOK, get(t, s, "/healthz", "").Code)
+	assert.Equal(t, http.StatusOK, get(t, s, "/debug/vars", "").Code)
+}
+
+// Тест проверяет метрики Prometheus: без отслеживаемых опросов маршрута
+// нет, с ними он доступен без токена
+func TestServer_Metrics(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewServer(repo, repository.NewMemoryVoteRepo(repo), "token", zerolog.Nop())
+	assert.Equal(t, http.StatusNotFound, get(t, s, "/metrics", "").Code)
+
+	gauges := metrics.NewPollGauges(1)
+	gauges.Set("poll1", map[string]int{"A": 2})
+	s.SetMetrics(gauges)
+	rec := get(t, s, "/metrics", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `poll_votes{poll_id="poll1",option="A"} 2`)
+}
+
+// Тест проверяет проверку готовности: 200, пока проверки проходят,
+// и 503 с итогом каждой проверки, если нет
+func TestServer_Ready(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewServer(repo, repository.NewMemoryVoteRepo(repo), "", zerolog.Nop())
+	assert.Equal(t, http.StatusOK, get(t, s, "/readyz", "").Code, "без проверок сервер готов")
+
+	var storageErr error
+	s.SetReadiness(
+		health.Check{Name: "storage", Run: func(context.Context) error { return storageErr }},
+		health.Check{Name: "mattermost", Run: func(context.Context) error { return nil }},
+	)
+	rec := get(t, s, "/readyz", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", decode[readinessJSON](t, rec).Status)
+
+	storageErr = errors.New("Tarantool не отвечает")
+	rec = get(t, s, "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, readinessJSON{
+		Status: "fail",
+		Checks: []checkJSON{
+			{Name: "storage", Status: "fail", Error: "Tarantool не отвечает"},
+			{Name: "mattermost", Status: "skipped"},
+		},
+	}, decode[readinessJSON](t, rec))
+}
+
+// Тест проверяет сборку и возможности бота в теле проверки готовности
+func TestServer_ReadyBuildInfo(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewServer(repo, repository.NewMemoryVoteRepo(repo), "", zerolog.Nop())
+	s.SetBuildInfo(buildinfo.New("v1.4.0", "abc1234", ""), []service.Feature{
+		{Name: "schedules", Enabled: true},
+		{Name: "rich-results"},
+	})
+
+	rec := get(t, s, "/readyz", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"status": "ok",
+		"build": {"version": "v1.4.0", "commit": "abc1234", "date": "dev"},
+		"features": {"schedules": true, "rich-results": false}
+	}`, rec.Body.String())
+}
+
+// Тест проверяет постраничную выдачу и фильтры списка опросов
+func TestServer_ListPolls(t *testing.T) {
+	s := newTestServer(t)
+
+	ids := func(list pollListJSON) []string {
+		out := make([]string, 0, len(list.Polls))
+		for _, p := range list.Polls {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+
+	rec := get(t, s, "/api/v1/polls?limit=2", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	page := decode[pollListJSON](t, rec)
+	assert.Equal(t, []string{pollID(1), pollID(2)}, ids(page))
+	require.NotEmpty(t, page.NextCursor)
+
+	var all []string
+	all = append(all, ids(page)...)
+	for page.NextCursor != "" {
+		rec = get(t, s, "/api/v1/polls?limit=2&cursor="+page.NextCursor, testToken)
+		require.Equal(t, http.StatusOK, rec.Code)
+		page = decode[pollListJSON](t, rec)
+		all = append(all, ids(page)...)
+	}
+	assert.Equal(t, []string{pollID(1), pollID(2), pollID(3), pollID(4), pollID(5)}, all)
+
+	rec = get(t, s, "/api/v1/polls?closed=true", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{pollID(2), pollID(4)}, ids(decode[pollListJSON](t, rec)))
+
+	rec = get(t, s, "/api/v1/polls?created_after=2025-03-01T14:30:00Z&channel_id=c1", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{pollID(3), pollID(4), pollID(5)}, ids(decode[pollListJSON](t, rec)))
+
+	rec = get(t, s, "/api/v1/polls?tag=Release", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{pollID(3), pollID(5)}, ids(decode[pollListJSON](t, rec)))
+
+	rec = get(t, s, "/api/v1/polls?creator=someone", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"polls":[]}`+"\n", rec.Body.String())
+}
+
+// Тест проверяет ответ 400 на неверные параметры списка
+func TestServer_ListPollsBadParams(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, query := range []string{"limit=0", "limit=-1", "limit=many", "closed=maybe", "created_after=yesterday", "created_before=2025-03-01"} {
+		t.Run(query, func(t *testing.T) {
+			rec := get(t, s, "/api/v1/polls?"+query, testToken)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, decode[errorJSON](t, rec).Error, "неверный параметр")
+		})
+	}
+}
+
+// Тест проверяет опрос и его итоги, в которых нет ID голосовавших
+func TestServer_GetPollAndResults(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := get(t, s, "/api/v1/polls/"+pollID(5), testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	created := time.Date(2025, 3, 1, 17, 0, 0, 0, time.UTC)
+	assert.Equal(t, pollJSON{
+		ID: pollID(5), Creator: "creator1", Question: "Вопрос 5", Tags: []string{"release"}, Options: []string{"Пицца", "Суши"},
+		ChannelID: "c1", CreatedAt: &created, Ranked: true, VoterCount: 2,
+	}, decode[pollJSON](t, rec))
+	assert.NotContains(t, rec.Body.String(), "u1")
+
+	rec = get(t, s, "/api/v1/polls/"+pollID(5)+"/results", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	results := decode[resultsJSON](t, rec)
+	assert.Equal(t, 2, results.VoterCount)
+	assert.True(t, results.Ranked)
+	assert.NotEmpty(t, results.Rounds)
+	assert.Equal(t, "Пицца", results.RankedWinner)
+	assert.Equal(t, []string{"release"}, results.Tags)
+	assert.NotContains(t, rec.Body.String(), "u1")
+
+	rec = get(t, s, "/api/v1/polls/"+pollID(1)+"/results", testToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []optionVotesJSON{{"Пицца", 1}, {"Суши", 1}}, decode[resultsJSON](t, rec).Options)
+	assert.NotContains(t, rec.Body.String(), "weighted", "опрос без весов не показывает суммы весов")
+}
+
+// Тест проверяет ответ 404 для несуществующих опросов и маршрутов
+func TestServer_NotFound(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, path := range []string{"/api/v1/polls/" + pollID(42), "/api/v1/polls/" + pollID(42) + "/results"} {
+		rec := get(t, s, path, testToken)
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+		assert.Equal(t, "опрос не найден", decode[errorJSON](t, rec).Error)
+	}
+	assert.Equal(t, http.StatusNotFound, get(t, s, "/api/v1/polls/"+pollID(1)+"/voters", testToken).Code)
+}
+
+// unavailableRepo - хранилище, потерявшее соединение.
+type unavailableRepo struct {
+	repository.PollRepository
+}
+
+func (unavailableRepo) GetPoll(ctx context.Context, id string) (models.Poll, error) {
+	return models.Poll{}, repository.ErrUnavailable
+}
+
+func (unavailableRepo) ListPolls(ctx context.Context, filter repository.ListFilter) ([]models.Poll, string, error) {
+	return nil, "", fmt.Errorf("ошибка получения списка опросов: %w", repository.ErrUnavailable)
+}
+
+// Тест проверяет ответ 503, когда хранилище недоступно
+func TestServer_StorageUnavailable(t *testing.T) {
+	s := NewServer(unavailableRepo{}, nil, testToken, zerolog.Nop())
+
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, s, "/api/v1/polls", testToken).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, s, "/api/v1/polls/"+pollID(1), testToken).Code)
+}
@@ -0,0 +1,50 @@
+// Package audit описывает журнал событий жизненного цикла опросов:
+// кто и когда создал опрос, голосовал, завершил, передал или удалил его.
+package audit
+
+import "time"
+
+// Action - что произошло с опросом. Значения хранятся в журнале,
+// поэтому существующие менять нельзя.
+type Action string
+
+const (
+	ActionCreated Action = "created"
+	// Опрос создан командой clone; Detail - ID исходного опроса
+	ActionCloned Action = "cloned"
+	// Detail - выбор голосующего, в рейтинговом опросе через " > "
+	ActionVoted Action = "voted"
+	// Голос, после которого опрос закрылся по кворуму
+	ActionQuorumClosed Action = "quorum_closed"
+	// Голос, после которого опрос набрал максимум голосов и закрылся
+	ActionMaxVotesClosed Action = "max_votes_closed"
+	ActionEnded          Action = "ended"
+	ActionDeleted        Action = "deleted"
+	// Detail - ID выбранного победителя
+	ActionWinner Action = "winner"
+	// Detail - ID нового создателя
+	ActionTransferred Action = "transferred"
+	// Detail - настройка и новое значение: "quorum: 10"
+	ActionSettingChanged Action = "setting_changed"
+	// Detail - исправленный вопрос
+	ActionQuestionEdited Action = "question_edited"
+	// Опрос закрыт ботом по истечении срока
+	ActionExpired Action = "expired"
+	// Опрос удалён ботом: объявить его в канале так и не удалось
+	ActionDiscarded Action = "discarded"
+	// Администратор отметил опрос или снял отметку
+	ActionFeatured   Action = "featured"
+	ActionUnfeatured Action = "unfeatured"
+)
+
+// Event - запись журнала. Записи только добавляются: они не меняются
+// и остаются после удаления опроса.
+type Event struct {
+	ID     string
+	PollID string
+	// Кто выполнил действие; опрос по расписанию создаёт создатель расписания
+	Actor  string
+	Action Action
+	Detail string
+	At     time.Time
+}
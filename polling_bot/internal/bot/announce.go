@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+)
+
+const (
+	// Команда, ответ на которую бот готовит заранее временным сообщением
+	createCommand = "create"
+	// Сколько раз бот пытается показать ответ на create, прежде чем удалить
+	// созданный опрос. Ответы 429 повторяет ещё очередь исходящих запросов
+	announceAttempts = 3
+	// Пауза между попытками
+	announcePause = 2 * time.Second
+)
+
+// Discarder реализуют сервисы, которые могут удалить только что созданный
+// опрос, если бот так и не смог его объявить.
+type Discarder interface {
+	DiscardPoll(ctx context.Context, pollID string) error
+}
+
+// SetDiscarder включает удаление опросов, ответ на создание которых не
+// удалось опубликовать. Без него такой опрос остаётся и только пишется в лог.
+func (b *Bot) SetDiscarder(discarder Discarder) {
+	b.discarder = discarder
+}
+
+// pendingReply - временное сообщение «создаю опрос…», которое бот публикует
+// до выполнения create, чтобы потом заменить его ответом.
+type pendingReply struct {
+	// Команда - create: ответ показывается через finishCreate
+	active bool
+	// ID временного сообщения; пусто, если его опубликовать не удалось
+	postID string
+}
+
+// announcesCreate сообщает, готовит ли бот ответ на команду временным
+// сообщением: только для create, который действительно создаёт опрос.
+func announcesCreate(command string, args []string) bool {
+	return command == createCommand && !handler.IsDryRun(args)
+}
+
+// startCreate публикует временное сообщение до выполнения create. Сбой
+// не мешает команде: ответ тогда публикуется новым сообщением.
+func (b *Bot) startCreate(loc *i18n.Localizer, channelID string, pending *pendingReply) {
+	pending.active = true
+	post, err := b.client.CreatePost(&mmclient.Post{ChannelID: channelID, Message: loc.T(i18n.CreatingPoll)})
+	if err != nil {
+		b.logger.Warn().Err(err).Str("channel_id", channelID).Msg("Не удалось опубликовать сообщение о создании опроса")
+		return
+	}
+	pending.postID = post.ID
+}
+
+// finishCreate заменяет временное сообщение ответом на create, в том числе
+// ошибкой. Если ответ так и не удалось показать, созданный опрос удаляется:
+// иначе его ID никто бы не узнал. Если попытки прервала остановка бота,
+// опрос остаётся: ответ не доставлен не из-за Mattermost.
+func (b *Bot) finishCreate(ctx context.Context, post *mmclient.Post, pending pendingReply, response handler.Response) {
+	if response.Text == "" {
+		return
+	}
+	if pending.postID == "" && response.Ephemeral && b.sendEphemeral(post.UserID, post.ChannelID, response.Text) {
+		return
+	}
+	reply := b.replyPost(ctx, b.localizerFor(post.UserID), post.ChannelID, response)
+	err := b.deliverReply(ctx, reply, pending.postID)
+	if err == nil {
+		return
+	}
+	if response.PollID == "" {
+		b.logger.Error().Err(err).Str("channel_id", post.ChannelID).Msg("Не удалось опубликовать ответ на создание опроса")
+		return
+	}
+	if cancelled(ctx, err) {
+		b.logger.Warn().Err(err).Str("poll_id", response.PollID).Str("channel_id", post.ChannelID).
+			Msg("Опрос создан, но не объявлен: показ ответа прерван")
+		return
+	}
+	if b.discarder == nil {
+		b.logger.Error().Err(err).Str("poll_id", response.PollID).Str("channel_id", post.ChannelID).
+			Msg("Опрос создан, но не объявлен")
+		return
+	}
+	if discardErr := b.discarder.DiscardPoll(context.WithoutCancel(ctx), response.PollID); discardErr != nil {
+		b.logger.Error().Err(discardErr).AnErr("announce_error", err).Str("poll_id", response.PollID).Str("channel_id", post.ChannelID).
+			Msg("Опрос не объявлен и не удалён")
+		return
+	}
+	b.logger.Error().Err(err).Str("poll_id", response.PollID).Str("channel_id", post.ChannelID).
+		Msg("Опрос удалён: объявить его не удалось")
+}
+
+// cancelled сообщает, что показ ответа прервала отмена контекста команды,
+// а не ошибка Mattermost.
+func cancelled(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// deliverReply показывает ответ правкой временного сообщения postID или,
+// если его нет, новым сообщением, и повторяет попытку до announceAttempts раз.
+// Удалённое временное сообщение заменяется новым.
+func (b *Bot) deliverReply(ctx context.Context, reply *mmclient.Post, postID string) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if postID != "" {
+			reply.ID = postID
+			_, err = b.client.UpdatePost(reply)
+			if mmclient.StatusCode(err) == http.StatusNotFound {
+				postID = ""
+			}
+		} else {
+			reply.ID = ""
+			_, err = b.client.CreatePost(reply)
+		}
+		if err == nil || attempt == announceAttempts {
+			return err
+		}
+		b.logger.Warn().Err(err).Int("attempt", attempt).Str("channel_id", reply.ChannelID).Msg("Не удалось показать ответ на создание опроса, повтор")
+		select {
+		case <-b.after(announcePause):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
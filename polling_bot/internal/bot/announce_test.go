@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+
+	"github.com/rs/zerolog"
+)
+
+// failingRepo - хранилище в памяти, которое может отказать в сохранении
+// и удалении опроса.
+type failingRepo struct {
+	*repository.MemoryPollRepo
+	saveErr   error
+	deleteErr error
+}
+
+func (r *failingRepo) SavePoll(ctx context.Context, poll models.Poll) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	return r.MemoryPollRepo.SavePoll(ctx, poll)
+}
+
+func (r *failingRepo) DeletePoll(ctx context.Context, pollID string) error {
+	if r.deleteErr != nil {
+		return r.deleteErr
+	}
+	return r.MemoryPollRepo.DeletePoll(ctx, pollID)
+}
+
+// TestHandleWebSocketEvent_CreateAnnouncement проверяет, что бот публикует
+// временное сообщение до создания опроса и заменяет его ответом или ошибкой,
+// повторяет неудачный показ ответа и удаляет опрос, который так и не удалось
+// объявить, но не тот, показ которого прервала остановка бота.
+func TestHandleWebSocketEvent_CreateAnnouncement(t *testing.T) {
+	serverErr := &mmclient.Error{StatusCode: http.StatusInternalServerError, Err: errors.New("boom")}
+	notFound := &mmclient.Error{StatusCode: http.StatusNotFound, Err: errors.New("not found")}
+
+	tests := []struct {
+		name string
+		// Ошибки CreatePost и UpdatePost по очереди вызовов; дальше - успех
+		createErrs []error
+		updateErrs []error
+		saveErr    error
+		deleteErr  error
+		// Контекст команды отменяется во время паузы между попытками
+		cancelInPause bool
+
+		wantPosts   []string
+		wantUpdates []string
+		wantPolls   int
+	}{
+		{
+			name:        "provisional message replaced with poll",
+			wantPosts:   []string{"_Создаю опрос…_"},
+			wantUpdates: []string{"Голосование создано"},
+			wantPolls:   1,
+		},
+		{
+			name:        "poll creation failed",
+			saveErr:     errors.New("хранилище недоступно"),
+			wantPosts:   []string{"_Создаю опрос…_"},
+			wantUpdates: []string{"Ошибка при выполнении команды"},
+		},
+		{
+			name:        "update retried",
+			updateErrs:  []error{serverErr},
+			wantPosts:   []string{"_Создаю опрос…_"},
+			wantUpdates: []string{"Голосование создано", "Голосование создано"},
+			wantPolls:   1,
+		},
+		{
+			name:        "provisional message deleted",
+			updateErrs:  []error{notFound},
+			wantPosts:   []string{"_Создаю опрос…_", "Голосование создано"},
+			wantUpdates: []string{"Голосование создано"},
+			wantPolls:   1,
+		},
+		{
+			name:       "provisional message failed",
+			createErrs: []error{serverErr},
+			wantPosts:  []string{"_Создаю опрос…_", "Голосование создано"},
+			wantPolls:  1,
+		},
+		{
+			name:        "announcement failed",
+			updateErrs:  []error{serverErr, serverErr, serverErr},
+			wantPosts:   []string{"_Создаю опрос…_"},
+			wantUpdates: []string{"Голосование создано", "Голосование создано", "Голосование создано"},
+		},
+		{
+			name:       "no provisional message and announcement failed",
+			createErrs: []error{serverErr, serverErr, serverErr, serverErr},
+			wantPosts:  []string{"_Создаю опрос…_", "Голосование создано", "Голосование создано", "Голосование создано"},
+		},
+		{
+			name:        "announcement and discard failed",
+			updateErrs:  []error{serverErr, serverErr, serverErr},
+			deleteErr:   errors.New("хранилище недоступно"),
+			wantPosts:   []string{"_Создаю опрос…_"},
+			wantUpdates: []string{"Голосование создано", "Голосование создано", "Голосование создано"},
+			wantPolls:   1,
+		},
+		{
+			name:          "announcement interrupted by shutdown",
+			updateErrs:    []error{serverErr},
+			cancelInPause: true,
+			wantPosts:     []string{"_Создаю опрос…_"},
+			wantUpdates:   []string{"Голосование создано"},
+			wantPolls:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posts, updates []string
+			fc := &fakeClient{
+				createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+					posts = append(posts, post.Message)
+					if n := len(posts); n <= len(tt.createErrs) && tt.createErrs[n-1] != nil {
+						return nil, tt.createErrs[n-1]
+					}
+					return &mmclient.Post{ID: "reply" + string(rune('0'+len(posts))), ChannelID: post.ChannelID, Message: post.Message}, nil
+				},
+				updatePostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+					updates = append(updates, post.Message)
+					if post.ID != "reply1" {
+						t.Errorf("Правится не временное сообщение: %q", post.ID)
+					}
+					if n := len(updates); n <= len(tt.updateErrs) && tt.updateErrs[n-1] != nil {
+						return nil, tt.updateErrs[n-1]
+					}
+					return post, nil
+				},
+			}
+
+			repo := &failingRepo{MemoryPollRepo: repository.NewMemoryPollRepo(), saveErr: tt.saveErr, deleteErr: tt.deleteErr}
+			polls := service.NewPollService(repo, repository.NewMemoryVoteRepo(repo.MemoryPollRepo), zerolog.Nop())
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var pauses int
+			bot := &Bot{
+				logger:         zerolog.New(io.Discard),
+				botUser:        &mmclient.User{ID: "bot123"},
+				commandHandler: handler.NewPollCommandHandler(polls, i18n.New("ru"), handler.DefaultCommandPrefix),
+				localizer:      i18n.New("ru"),
+				client:         fc,
+				discarder:      polls,
+				after: func(d time.Duration) <-chan time.Time {
+					if tt.cancelInPause {
+						cancel()
+						return nil
+					}
+					pauses++
+					ch := make(chan time.Time, 1)
+					ch <- time.Time{}
+					return ch
+				},
+			}
+
+			bot.handleWebSocketEvent(ctx, postEvent(mmclient.EventPosted, "post1", `!poll create "Где обедаем?" "Пицца" "Суши"`))
+
+			assertMessages(t, "Опубликовано", posts, tt.wantPosts)
+			assertMessages(t, "Исправлено", updates, tt.wantUpdates)
+			if max(len(posts)-1, 0)+len(updates) > announceAttempts {
+				t.Errorf("Ответ показывался больше %d раз", announceAttempts)
+			}
+			if want := max(len(posts)-1, 0) + len(updates) - 1; !tt.cancelInPause && pauses != want {
+				t.Errorf("Ожидалось пауз: %d, получено: %d", want, pauses)
+			}
+			stored, _, err := repo.ListPolls(context.Background(), repository.ListFilter{})
+			if err != nil {
+				t.Fatalf("Ошибка чтения опросов: %v", err)
+			}
+			if len(stored) != tt.wantPolls {
+				t.Errorf("Ожидалось опросов в хранилище: %d, получено: %d", tt.wantPolls, len(stored))
+			}
+		})
+	}
+}
+
+// assertMessages сравнивает начала отправленных сообщений с ожидаемыми.
+func assertMessages(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s сообщений: %d, ожидалось %d: %q", what, len(got), len(want), got)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("%s сообщение %d: %q, ожидалось начало %q", what, i, got[i], want[i])
+		}
+	}
+}
+
+// TestAnnouncesCreate проверяет, что create --dry-run не публикует временное
+// сообщение: опрос при предпросмотре не создаётся.
+func TestAnnouncesCreate(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		want    bool
+	}{
+		{command: "create", args: []string{"Q", "A"}, want: true},
+		{command: "create", args: []string{"Q", "--dry-run", "A"}},
+		{command: "vote", args: []string{"p1", "A"}},
+	}
+	for _, tt := range tests {
+		if got := announcesCreate(tt.command, tt.args); got != tt.want {
+			t.Errorf("announcesCreate(%q, %q) = %v, ожидалось %v", tt.command, tt.args, got, tt.want)
+		}
+	}
+}
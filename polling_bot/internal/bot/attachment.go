@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+)
+
+// Цвет полосы вложения с итогами: открытый опрос - зелёный, закрытый - серый
+const (
+	colorPollOpen   = "#2eb886"
+	colorPollClosed = "#8c8c8c"
+)
+
+// replyPost собирает ответ бота в канал. С RichResults итоги опроса
+// уходят вложением, а текст ответа остаётся в нём запасным для клиентов,
+// которые вложения не показывают.
+func (b *Bot) replyPost(ctx context.Context, loc *i18n.Localizer, channelID string, response handler.Response) *mmclient.Post {
+	post := &mmclient.Post{ChannelID: channelID, Message: response.Text}
+	if !b.cfg.RichResults || response.Results == nil {
+		return post
+	}
+	creator := response.Results.Creator
+	if name, err := b.Username(ctx, creator); err == nil {
+		creator = name
+	} else {
+		b.logger.Debug().Err(err).Str("user_id", creator).Msg("Не удалось получить имя создателя опроса")
+	}
+	post.Message = ""
+	post.Props = map[string]interface{}{
+		mmclient.PropAttachments: []*mmclient.Attachment{resultsAttachment(loc, *response.Results, creator, response.Text)},
+	}
+	return post
+}
+
+// resultsAttachment строит вложение с итогами: вопрос заголовком, по полю
+// на вариант с числом голосов и долей от проголосовавших и подвал с ID
+// опроса и создателем.
+func resultsAttachment(loc *i18n.Localizer, results service.Results, creator, fallback string) *mmclient.Attachment {
+	attachment := &mmclient.Attachment{
+		Fallback: fallback,
+		Color:    colorPollOpen,
+		Title:    results.Question,
+		Text:     results.Description,
+		Footer:   loc.T(i18n.ResultsCardFooter, results.PollID, creator),
+	}
+	if results.Closed {
+		attachment.Color = colorPollClosed
+	}
+
+	weighted := make(map[string]int, len(results.Weighted))
+	for _, votes := range results.Weighted {
+		weighted[votes.Option] = votes.Votes
+	}
+	// Варианты страницы по алфавиту, как в текстовых итогах
+	for _, votes := range results.PageOptions() {
+		value := loc.T(i18n.ResultsCardVotes, votes.Votes, percent(votes.Votes, results.VoterCount))
+		if results.Weighted != nil {
+			value = loc.T(i18n.ResultsCardWeighted, votes.Votes, percent(votes.Votes, results.VoterCount), weighted[votes.Option])
+		}
+		attachment.Fields = append(attachment.Fields, &mmclient.AttachmentField{Title: results.Label(votes.Option), Value: value, Short: true})
+	}
+	if page := service.RenderResultsPage(loc, results); page != "" {
+		attachment.Fields = append(attachment.Fields, &mmclient.AttachmentField{Value: strings.TrimSuffix(page, "\n")})
+	}
+	if results.AllowAbstain {
+		attachment.Fields = append(attachment.Fields, &mmclient.AttachmentField{
+			Title: loc.T(i18n.ResultsCardAbstained),
+			Value: loc.T(i18n.ResultsCardVotes, results.Abstained, percent(results.Abstained, results.VoterCount+results.Abstained)),
+			Short: true,
+		})
+	}
+	if results.RankedWinner != "" {
+		attachment.Fields = append(attachment.Fields, &mmclient.AttachmentField{Title: loc.T(i18n.ResultsCardWinner), Value: results.RankedWinner})
+	}
+	return attachment
+}
+
+// percent - доля part от total в целых процентах; при total 0 - 0.
+func percent(part, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return int(math.Round(float64(part) * 100 / float64(total)))
+}
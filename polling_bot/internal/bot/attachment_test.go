@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+
+	"github.com/rs/zerolog"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталоны в testdata")
+
+// TestResultsAttachment проверяет props сообщения с итогами по эталонам в
+// testdata/attachments; go test -update перезаписывает эталоны.
+func TestResultsAttachment(t *testing.T) {
+	tests := []struct {
+		golden  string
+		results service.Results
+	}{
+		{
+			golden: "open.json",
+			results: service.Results{
+				PollID:      "poll1",
+				Creator:     "user1",
+				Question:    "Где обедаем?",
+				Description: "Решаем до полудня",
+				VoterCount:  3,
+				Options:     []service.OptionVotes{{Option: "Столовая", Votes: 2}, {Option: "Кафе", Votes: 1}},
+			},
+		},
+		{
+			golden: "closed_weighted.json",
+			results: service.Results{
+				PollID:       "poll2",
+				Creator:      "user1",
+				Question:     "Бюджет на квартал",
+				Closed:       true,
+				VoterCount:   3,
+				Options:      []service.OptionVotes{{Option: "Да", Votes: 1}, {Option: "Нет", Votes: 2}},
+				Weighted:     []service.OptionVotes{{Option: "Да", Votes: 5}, {Option: "Нет", Votes: 2}},
+				AllowAbstain: true,
+				Abstained:    1,
+			},
+		},
+		{
+			golden: "ranked.json",
+			results: service.Results{
+				PollID:       "poll3",
+				Creator:      "user1",
+				Question:     "Язык следующего сервиса",
+				Ranked:       true,
+				VoterCount:   3,
+				Options:      []service.OptionVotes{{Option: "Go", Votes: 2}, {Option: "Rust", Votes: 1}},
+				RankedWinner: "Go",
+			},
+		},
+		{golden: "paged.json", results: pagedResults()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			bot := &Bot{
+				cfg:       config.Config{RichResults: true},
+				logger:    zerolog.Nop(),
+				localizer: i18n.New("ru"),
+				client: &fakeClient{getUserFunc: func(userID string) (*mmclient.User, error) {
+					return &mmclient.User{ID: userID, Username: "alice"}, nil
+				}},
+			}
+			response := handler.Response{Text: "Итоги опроса " + tt.results.PollID, Results: &tt.results}
+			post := bot.replyPost(context.Background(), bot.localizer, "c1", response)
+			if post.Message != "" {
+				t.Errorf("С вложением текст сообщения должен быть пустым, получено: %q", post.Message)
+			}
+			got, err := json.MarshalIndent(post.Props, "", "  ")
+			if err != nil {
+				t.Fatalf("props не сериализуются: %v", err)
+			}
+			got = append(got, '\n')
+
+			path := filepath.Join("testdata", "attachments", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("Не удалось записать эталон: %v", err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Не удалось прочитать эталон %s: %v", tt.golden, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("props не совпадают с эталоном %s:\n%s", tt.golden, got)
+			}
+		})
+	}
+}
+
+// pagedResults - итоги опроса с вариантами на двух страницах, открытые на
+// второй.
+func pagedResults() service.Results {
+	results := service.Results{PollID: "poll4", Creator: "user1", Question: "Название проекта", VoterCount: 30, Page: 2}
+	for i := 1; i <= 30; i++ {
+		results.Options = append(results.Options, service.OptionVotes{Option: fmt.Sprintf("Вариант %02d", i), Votes: 1})
+	}
+	return results
+}
+
+// TestReplyPost_Plain проверяет, что без RichResults и для ответов без
+// итогов бот отправляет обычный текст без props.
+func TestReplyPost_Plain(t *testing.T) {
+	results := service.Results{PollID: "poll1", Question: "Где обедаем?"}
+	tests := []struct {
+		name     string
+		rich     bool
+		response handler.Response
+	}{
+		{name: "flag off", response: handler.Response{Text: "Итоги", Results: &results}},
+		{name: "no results", rich: true, response: handler.Response{Text: "Голос учтён"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := &Bot{cfg: config.Config{RichResults: tt.rich}, logger: zerolog.Nop(), client: &fakeClient{}}
+			post := bot.replyPost(context.Background(), i18n.New("ru"), "c1", tt.response)
+			if post.Message != tt.response.Text || post.Props != nil {
+				t.Errorf("Ожидался текст %q без props, получено: %q, %v", tt.response.Text, post.Message, post.Props)
+			}
+		})
+	}
+}
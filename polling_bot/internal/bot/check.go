@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"polling_bot/internal/mmclient"
+)
+
+// CheckAuth проверяет, что Mattermost доступен и принимает токен бота.
+// Проверки учётной записи выполняет запуск с --check, CheckAuth - ещё
+// и проверка готовности GET /readyz.
+func (b *Bot) CheckAuth(ctx context.Context) error {
+	_, err := b.me()
+	return err
+}
+
+// CheckBotAccount проверяет, что токен выдан учётной записи бота, а не
+// пользователя: иначе бот отвечал бы от имени человека.
+func (b *Bot) CheckBotAccount(ctx context.Context) error {
+	user, err := b.me()
+	if err != nil {
+		return err
+	}
+	if !user.IsBot {
+		return fmt.Errorf("пользователь @%s не является ботом", user.Username)
+	}
+	return nil
+}
+
+// CheckTeams проверяет, что бот добавлен хотя бы в одну команду: без этого
+// он не видит ни одного канала.
+func (b *Bot) CheckTeams(ctx context.Context) error {
+	user, err := b.me()
+	if err != nil {
+		return err
+	}
+	teams, err := b.client.GetTeamsForUser(user.ID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить команды бота: %w", err)
+	}
+	if len(teams) == 0 {
+		return fmt.Errorf("бот @%s не состоит ни в одной команде", user.Username)
+	}
+	return nil
+}
+
+// me запрашивает учётную запись, которой принадлежит токен бота.
+func (b *Bot) me() (*mmclient.User, error) {
+	user, err := b.client.GetMe()
+	switch {
+	case mmclient.StatusCode(err) == http.StatusUnauthorized:
+		return nil, fmt.Errorf("Mattermost отклонил токен бота: %w", err)
+	case err != nil:
+		return nil, fmt.Errorf("Mattermost недоступен: %w", err)
+	}
+	return user, nil
+}
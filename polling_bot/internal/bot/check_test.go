@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"polling_bot/internal/mmclient"
+)
+
+// TestChecks проверяет шаги проверки учётной записи бота в Mattermost.
+func TestChecks(t *testing.T) {
+	unauthorized := func() (*mmclient.User, error) {
+		return nil, &mmclient.Error{StatusCode: http.StatusUnauthorized, Err: errors.New("invalid token")}
+	}
+	botUser := func() (*mmclient.User, error) {
+		return &mmclient.User{ID: "bot123", Username: "pollbot", IsBot: true}, nil
+	}
+	tests := []struct {
+		name      string
+		getMe     func() (*mmclient.User, error)
+		teams     func(string) ([]*mmclient.Team, error)
+		check     func(b *Bot, ctx context.Context) error
+		wantError string
+	}{
+		{name: "auth ok", getMe: botUser, check: (*Bot).CheckAuth},
+		{name: "bad token", getMe: unauthorized, check: (*Bot).CheckAuth, wantError: "Mattermost отклонил токен бота"},
+		{name: "bot account", getMe: botUser, check: (*Bot).CheckBotAccount},
+		{
+			name: "user account",
+			getMe: func() (*mmclient.User, error) {
+				return &mmclient.User{ID: "u1", Username: "alice"}, nil
+			},
+			check:     (*Bot).CheckBotAccount,
+			wantError: "пользователь @alice не является ботом",
+		},
+		{name: "in team", getMe: botUser, check: (*Bot).CheckTeams},
+		{
+			name:  "no teams",
+			getMe: botUser,
+			teams: func(string) ([]*mmclient.Team, error) {
+				return []*mmclient.Team{}, nil
+			},
+			check:     (*Bot).CheckTeams,
+			wantError: "бот @pollbot не состоит ни в одной команде",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bot{client: &fakeClient{getMeFunc: tt.getMe, teamsFunc: tt.teams}}
+			err := tt.check(b, context.Background())
+			switch {
+			case tt.wantError == "" && err != nil:
+				t.Errorf("Неожиданная ошибка: %v", err)
+			case tt.wantError != "" && (err == nil || !strings.Contains(err.Error(), tt.wantError)):
+				t.Errorf("Ожидалась ошибка %q, получено: %v", tt.wantError, err)
+			}
+		})
+	}
+}
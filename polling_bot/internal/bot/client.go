@@ -10,94 +10,119 @@ import (
 
 	"polling_bot/internal/config"
 	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+	"polling_bot/internal/wizard"
 
-	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/rs/zerolog"
 )
 
-type MattermostClient interface {
-	GetMe(string) (*model.User, *model.Response)
-	CreatePost(*model.Post) (*model.Post, *model.Response)
-}
-
-type APIv4Client struct {
-	*model.Client4
-}
-
-func NewAPIv4Client(url, token string, timeout time.Duration) *APIv4Client {
-	client := model.NewAPIv4Client(url)
-	client.SetToken(token)
-	client.HttpClient = &http.Client{Timeout: timeout}
-	return &APIv4Client{Client4: client}
-}
-
-func (c *APIv4Client) GetMe(param string) (*model.User, *model.Response) {
-	return c.Client4.GetMe(param)
-}
-
-func (c *APIv4Client) CreatePost(post *model.Post) (*model.Post, *model.Response) {
-	return c.Client4.CreatePost(post)
-}
-
-type WebSocketClient interface {
-	Listen()
-	Close() 
-	EventChannel() <-chan *model.WebSocketEvent
-}
-
-type WSClientAdapter struct {
-	client *model.WebSocketClient
-}
-
-func NewWSClientAdapter(wsClient *model.WebSocketClient) *WSClientAdapter {
-	return &WSClientAdapter{client: wsClient}
-}
-
-func (w *WSClientAdapter) Listen() {
-	w.client.Listen()
-}
-
-func (w *WSClientAdapter) Close() {
-	w.client.Close()
-}
-
-func (w *WSClientAdapter) EventChannel() <-chan *model.WebSocketEvent {
-	return w.client.EventChannel
-}
-
 type Bot struct {
 	cfg      config.Config
 	logger   zerolog.Logger
-	client   MattermostClient
-	wsClient WebSocketClient
-	botUser  *model.User
+	client   mmclient.Client
+	// Очередь исходящих запросов, через неё же идёт client; nil, если
+	// client подставлен напрямую
+	outbox   *outbox
+	wsClient mmclient.WebSocket
+	botUser  *mmclient.User
 	commandHandler handler.CommandHandler
+
+	localizer *i18n.Localizer
+	// Недавно обработанные сообщения: по ним решается, выполнять ли правку
+	recent *recentPosts
+	// Ключи уже принятых событий: повторная доставка после переподключения игнорируется
+	seen *recentPosts
+	// Каналы, с которыми бот уже познакомился за последние introInterval
+	introduced *recentPosts
+	// Профили авторов команд для локали и проверки интеграций, заполняются
+	// при первой команде
+	authors   map[string]*mmclient.User
+	authorsMu sync.Mutex
+
+	// Фильтр каналов; nil - бот обрабатывает сообщения всех каналов
+	filter *channelFilter
+
+	scheduler Scheduler
+	expirer   Expirer
+	digester  Digester
+	discarder Discarder
+	// Пошаговое создание опросов; nil - мастер выключен
+	wizard *wizard.Wizard
+	// Часы планировщика, подменяются в тестах
+	clock func() time.Time
+	after func(d time.Duration) <-chan time.Time
 }
 
 func NewBot(cfg config.Config, logger zerolog.Logger, handler handler.CommandHandler) (*Bot, error){
-	if cfg.MattermostURL == "" || cfg.BotToken == "" {
-        return nil, fmt.Errorf("mattermost URL и токен обязательны для настройки")
-    }
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
     
-    normalizedURL := cfg.MattermostURL
-    if !strings.HasPrefix(normalizedURL, "http://") && !strings.HasPrefix(normalizedURL, "https://") {
-        normalizedURL = "http://" + normalizedURL
+    normalizedURL, err := normalizeURL(cfg.MattermostURL)
+    if err != nil {
+        return nil, err
     }
     cfg.MattermostURL = normalizedURL
 
+    // Клиент создаётся сразу: им пользуются проверки готовности,
+    // которые могут прийти раньше Start
+    outbox := newOutbox(mmclient.NewAPIClient(cfg.MattermostURL, cfg.BotToken, cfg.HTTPTimeout), cfg.OutboxRate, cfg.OutboxBurst, logger)
     return &Bot{
         cfg:            cfg,
         logger:         logger,
+        client:         outbox,
+        outbox:         outbox,
         commandHandler: handler,
+        localizer:      i18n.New(cfg.Language),
+        recent:         newRecentPosts(cfg.RecentPostsSize, cfg.RecentPostsTTL),
+        seen:           newRecentPosts(cfg.RecentPostsSize, cfg.RecentPostsTTL),
+        introduced:     newRecentPosts(cfg.RecentPostsSize, introInterval),
+        filter:         newChannelFilter(cfg),
+        clock:          time.Now,
+        after:          time.After,
     }, nil
 }
 
+// SetClient подменяет клиент REST API Mattermost, например клиентом
+// сервиса, в который встроен бот. Запросы по-прежнему идут через очередь
+// исходящих запросов. Вызывается до Start.
+func (b *Bot) SetClient(client mmclient.Client) {
+	if b.outbox == nil {
+		b.client = client
+		return
+	}
+	b.outbox.Client = client
+}
+
 func (b *Bot) Start(ctx context.Context) error {
 	if err := b.initialize(); err != nil {
 		return err
 	}
 
+	// Ответы обработчиков, завершившихся при остановке, ещё в очереди
+	defer b.drainOutbox()
 	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	if b.scheduler != nil || b.expirer != nil || b.digester != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.runScheduler(ctx)
+		}()
+	}
+
+	if b.cfg.Mode == config.ModeWebhook {
+		return b.serveWebhooks(ctx)
+	}
+	return b.listenWebSocket(ctx, &wg)
+}
+
+// listenWebSocket обрабатывает события WebSocket, пока не отменён ctx.
+func (b *Bot) listenWebSocket(ctx context.Context, wg *sync.WaitGroup) error {
 	defer func() {
 		wg.Wait()
 		b.wsClient.Close()
@@ -110,10 +135,13 @@ func (b *Bot) Start(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err() 
-		case event := <-b.wsClient.EventChannel():
+		case event := <-b.wsClient.Events():
+			metrics.EventsReceived.Add(1)
+			metrics.HandlersInFlight.Add(1)
 			wg.Add(1)
-			go func(e *model.WebSocketEvent) {
+			go func(e *mmclient.WSEvent) {
 				defer wg.Done()
+				defer metrics.HandlersInFlight.Add(-1)
 				b.handleWebSocketEvent(ctx, e)
 			}(event)
 		}
@@ -122,13 +150,18 @@ func (b *Bot) Start(ctx context.Context) error {
 
 func (b *Bot) initialize() error {
 	if b.client == nil {
-		b.client = NewAPIv4Client(b.cfg.MattermostURL, b.cfg.BotToken, b.cfg.HTTPTimeout)
+		b.outbox = newOutbox(mmclient.NewAPIClient(b.cfg.MattermostURL, b.cfg.BotToken, b.cfg.HTTPTimeout), b.cfg.OutboxRate, b.cfg.OutboxBurst, b.logger)
+		b.client = b.outbox
 	}
 
 	if err := b.authenticate(); err != nil {
 		return err
 	}
 
+	// В режиме webhook события приходят HTTP-запросами от Mattermost
+	if b.cfg.Mode == config.ModeWebhook {
+		return nil
+	}
 	if err := b.initWebSocket(); err != nil {
 		return err
 	}
@@ -136,13 +169,25 @@ func (b *Bot) initialize() error {
 	return nil
 }
 
+// drainOutbox отправляет то, что осталось в очереди, не дольше
+// OutboxDrainTimeout.
+func (b *Bot) drainOutbox() {
+	if b.outbox != nil {
+		b.outbox.drain(b.cfg.OutboxDrainTimeout)
+	}
+}
+
 func (b *Bot) authenticate() error {
-	user, resp := b.client.GetMe("")
-	if resp.Error != nil {
-		return resp.Error
+	user, err := b.client.GetMe()
+	if err != nil {
+		return err
 	}
 	b.botUser = user
 
+	if h, ok := b.commandHandler.(handler.BotIdentityAware); ok {
+		h.SetBotIdentity(user.Username, user.Nickname, strings.TrimSpace(user.FirstName+" "+user.LastName))
+	}
+
 	return nil
 }
 
@@ -151,57 +196,374 @@ func (b *Bot) initWebSocket() error {
         return nil
     }
 
-    wsURL := strings.Replace(b.cfg.MattermostURL, "http", "ws", 1)
-    wsClient, err := model.NewWebSocketClient4(wsURL, b.cfg.BotToken)
+    wsURL, err := webSocketURL(b.cfg.MattermostURL)
+    if err != nil {
+        return err
+    }
+    wsClient, err := mmclient.DialWebSocket(wsURL, b.cfg.BotToken)
     if err != nil {
         return fmt.Errorf("ошибка создания WebSocket клиента: %w", err)
     }
     
-    b.wsClient = NewWSClientAdapter(wsClient)
+    b.wsClient = wsClient
     return nil
 }
 
-func (b *Bot) handleWebSocketEvent(ctx context.Context, event *model.WebSocketEvent) {
-	if event.EventType() != model.WEBSOCKET_EVENT_POSTED {
+func (b *Bot) handleWebSocketEvent(ctx context.Context, event *mmclient.WSEvent) {
+	data := event.Data
+	var edited bool
+	switch event.Type {
+	case mmclient.EventPosted:
+	case mmclient.EventPostEdited:
+		edited = true
+	case mmclient.EventUserAdded:
+		b.handleUserAdded(event, data)
+		return
+	default:
+		b.skipEvent(event, data, skipEventType)
 		return
 	}
 
-	data := event.GetData()
-	rawPost, ok := data["post"].(string)
-	if !ok {
+	// Ответы мастеру создания опроса приходят без упоминания бота
+	addressed := b.addressed(event, data)
+	if !addressed && !b.wizardIn(event.ChannelID) {
+		metrics.EventsFiltered.Add(1)
+		return
+	}
+	post, reason := decodePost(data)
+	if post == nil {
+		b.skipEvent(event, data, reason)
 		return
 	}
 
-	post := model.PostFromJson(strings.NewReader(rawPost))
-	if post == nil || post.UserId == b.botUser.Id {
+	channelType, _ := data["channel_type"].(string)
+	direct := channelType == mmclient.ChannelDirect
+	if b.answerWizard(ctx, post, edited, direct) {
+		return
+	}
+	if !addressed {
+		metrics.EventsFiltered.Add(1)
+		return
+	}
+	var pending pendingReply
+	response := b.handlePost(ctx, post, edited, direct, &pending)
+	if pending.active {
+		b.finishCreate(ctx, post, pending, response)
 		return
 	}
 
-	command, args, isValid := b.commandHandler.ParseCommand(post.Message)
-	if !isValid {
+	if response.Ephemeral && b.sendEphemeral(post.UserID, post.ChannelID, response.Text) {
 		return
 	}
+	if response.Text != "" {
+		b.sendPost(b.replyPost(ctx, b.localizerFor(post.UserID), post.ChannelID, response))
+	}
+}
+
+// handlePost выполняет команду из сообщения и возвращает ответ бота;
+// пустой ответ - сообщение не было командой или уже обработано. Общий
+// путь для событий WebSocket и исходящих webhook. Ошибку команды
+// видит только её автор. Если передан pending, перед выполнением create
+// бот публикует временное сообщение, которое вызывающий заменит ответом.
+func (b *Bot) handlePost(ctx context.Context, post *mmclient.Post, edited, direct bool, pending *pendingReply) handler.Response {
+	if post.UserID == b.botUser.ID {
+		return handler.Response{}
+	}
+	if !b.firstDelivery(post, edited) {
+		b.logger.Debug().Str("post_id", post.ID).Msg("Повторная доставка события пропущена")
+		return handler.Response{}
+	}
+
+	command, args, isValid, parseErr := b.commandHandler.ParseCommand(post.Message)
+	// Автор проверяется только у команд: запрос к Mattermost на каждое
+	// сообщение канала был бы лишним
+	if !isValid || b.fromIgnoredIntegration(post) {
+		return handler.Response{}
+	}
+
+	signature := commandSignature(command, args)
+	if edited && !b.shouldRunEdit(post.ID, signature) {
+		b.logger.Debug().Str("post_id", post.ID).Msg("Правка уже выполненной команды пропущена")
+		return handler.Response{}
+	}
+
+	loc := b.localizerFor(post.UserID)
+	ctx = i18n.WithLocalizer(ctx, loc)
+	ctx = service.WithOrigin(ctx, service.Origin{
+		PostID:    post.ID,
+		ChannelID: post.ChannelID,
+		Direct:    direct,
+	})
+	if b.wizard != nil && parseErr == nil && command == createCommand && wizard.Requested(args, direct) {
+		return b.startWizard(loc, post, direct)
+	}
+	if pending != nil && parseErr == nil && announcesCreate(command, args) {
+		b.startCreate(loc, post.ChannelID, pending)
+	}
+	start := time.Now()
+	// Команду с ошибкой записи не выполняем: аргументы разобраны неверно
+	response, err := handler.Response{}, parseErr
+	if parseErr == nil {
+		response, err = b.commandHandler.HandleCommand(ctx, command, args, post.UserID)
+	}
+	// Аргументы не пишутся в журнал: в них бывают голоса и имена пользователей
+	latency := time.Since(start)
+
+	if err != nil {
+		b.logger.Error().Err(err).Str("command", command).Dur("latency", latency).Msg("Ошибка выполнения команды")
+		response = handler.Response{Text: loc.T(i18n.CommandFailed, loc.Error(err)), Ephemeral: true}
+	} else {
+		b.logger.Info().Str("command", command).Dur("latency", latency).Msg("Команда выполнена")
+	}
+	executed := err == nil && b.isExecutable(command, args)
+	b.remember(post.ID, postRecord{
+		signature: signature,
+		executed:  executed,
+	})
+
+	// Реакции достаточно, только если она поставлена: иначе автор
+	// не узнал бы, что голос учтён
+	if b.react(post, executed) && executed && response.Quiet && b.cfg.ReactionsOnly {
+		return handler.Response{}
+	}
+
+	if edited && response.Text != "" {
+		response.Text = loc.T(i18n.EditedReply, response.Text)
+	}
+	return response
+}
+
+// Реакции на сообщение с командой
+const (
+	emojiDone   = "white_check_mark"
+	emojiFailed = "x"
+)
+
+// react отмечает сообщение с командой реакцией ✅ или ❌ и сообщает,
+// удалось ли это. В режиме webhook и без ID сообщения реакция не ставится,
+// сбой реакции не мешает ответу и пишется в лог только для отладки.
+func (b *Bot) react(post *mmclient.Post, executed bool) bool {
+	if !b.cfg.Reactions || b.cfg.Mode == config.ModeWebhook || post.ID == "" {
+		return false
+	}
+	emoji := emojiDone
+	if !executed {
+		emoji = emojiFailed
+	}
+	reaction := &mmclient.Reaction{UserID: b.botUser.ID, PostID: post.ID, EmojiName: emoji}
+	if _, err := b.client.SaveReaction(reaction); err != nil {
+		b.logger.Debug().Err(err).Str("post_id", post.ID).Msg("Не удалось поставить реакцию на команду")
+		return false
+	}
+	return true
+}
+
+// firstDelivery отмечает событие как принятое и возвращает false, если оно
+// уже приходило. Правка одного сообщения различается по времени правки.
+func (b *Bot) firstDelivery(post *mmclient.Post, edited bool) bool {
+	if b.seen == nil || post.ID == "" {
+		return true
+	}
+	key := post.ID
+	if edited {
+		key = fmt.Sprintf("%s@%d", post.ID, post.EditAt)
+	}
+	return b.seen.claim(key)
+}
 
-	responseMessage, err := b.commandHandler.HandleCommand(ctx, command, args, post.UserId)
+// commandSignature - разобранная команда в виде, удобном для сравнения
+// исходного сообщения с правкой.
+func commandSignature(command string, args []string) string {
+	return strings.Join(append([]string{command}, args...), "\x00")
+}
+
+// shouldRunEdit решает, выполнять ли команду из отредактированного сообщения:
+// нет, если исходное сообщение уже выполнило ту же команду.
+func (b *Bot) shouldRunEdit(postID, signature string) bool {
+	if b.recent == nil {
+		return true
+	}
+	record, ok := b.recent.get(postID)
+	return !ok || !record.executed || record.signature != signature
+}
+
+func (b *Bot) remember(postID string, record postRecord) {
+	if b.recent != nil && postID != "" {
+		b.recent.put(postID, record)
+	}
+}
+
+// isExecutable уточняет у обработчика, была ли команда действительно
+// выполнена, а не ответила подсказкой о формате.
+func (b *Bot) isExecutable(command string, args []string) bool {
+	checker, ok := b.commandHandler.(handler.CommandChecker)
+	return !ok || checker.IsExecutable(command, args)
+}
 
+// IsChannelMember сообщает сервису, состоит ли пользователь в канале.
+func (b *Bot) IsChannelMember(ctx context.Context, channelID, userID string) (bool, error) {
+	member, err := b.client.GetChannelMember(channelID, userID)
+	if mmclient.StatusCode(err) == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки участника канала: %w", err)
+	}
+	return member != nil, nil
+}
+
+// Announce публикует сообщение сервиса в канале, например итоги опроса.
+func (b *Bot) Announce(ctx context.Context, channelID, message string) error {
+	if _, err := b.client.CreatePost(&mmclient.Post{ChannelID: channelID, Message: message}); err != nil {
+		return fmt.Errorf("ошибка публикации в канале %s: %w", channelID, err)
+	}
+	return nil
+}
+
+// Publish публикует сообщение в канале и возвращает его ID, чтобы сообщение
+// можно было закрепить.
+func (b *Bot) Publish(ctx context.Context, channelID, message string) (string, error) {
+	post, err := b.client.CreatePost(&mmclient.Post{ChannelID: channelID, Message: message})
 	if err != nil {
-		b.logger.Error().Err(err).Msg("Ошибка выполнения команды")
-		responseMessage = "Ошибка при выполнении команды"+err.Error()
+		return "", fmt.Errorf("ошибка публикации в канале %s: %w", channelID, err)
 	}
+	return post.ID, nil
+}
 
-	if responseMessage != "" {
-		b.sendResponse(post.ChannelId, responseMessage)
+// PinPost закрепляет сообщение в канале. Для этого боту нужны права
+// на управление закреплёнными сообщениями канала.
+func (b *Bot) PinPost(ctx context.Context, postID string) error {
+	if err := b.client.PinPost(postID); err != nil {
+		if mmclient.StatusCode(err) == http.StatusForbidden {
+			return fmt.Errorf("нет прав на закрепление сообщения %s: %w", postID, err)
+		}
+		return fmt.Errorf("ошибка закрепления сообщения %s: %w", postID, err)
 	}
+	return nil
+}
+
+// UnpinPost открепляет сообщение. Уже удалённое сообщение не считается ошибкой.
+func (b *Bot) UnpinPost(ctx context.Context, postID string) error {
+	err := b.client.UnpinPost(postID)
+	if mmclient.StatusCode(err) == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка открепления сообщения %s: %w", postID, err)
+	}
+	return nil
+}
+
+// EditPost заменяет текст сообщения бота, например закреплённого опроса
+// после исправления вопроса.
+func (b *Bot) EditPost(ctx context.Context, postID, message string) error {
+	if _, err := b.client.UpdatePost(&mmclient.Post{ID: postID, Message: message}); err != nil {
+		return fmt.Errorf("ошибка изменения сообщения %s: %w", postID, err)
+	}
+	return nil
+}
+
+// Username возвращает имя пользователя Mattermost для упоминания. Профиль
+// берётся из общего с авторами команд кэша: progress показывает до 50 имён
+// за раз.
+func (b *Bot) Username(ctx context.Context, userID string) (string, error) {
+	user, err := b.author(userID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения пользователя %s: %w", userID, err)
+	}
+	return user.Username, nil
+}
+
+// FindUser ищет пользователя по имени, например нового владельца опроса.
+func (b *Bot) FindUser(ctx context.Context, username string) (service.User, bool, error) {
+	user, err := b.client.GetUserByUsername(username)
+	if mmclient.StatusCode(err) == http.StatusNotFound {
+		return service.User{}, false, nil
+	}
+	if err != nil {
+		return service.User{}, false, fmt.Errorf("ошибка поиска пользователя %s: %w", username, err)
+	}
+	return service.User{ID: user.ID, Username: user.Username, IsBot: user.IsBot}, true, nil
+}
+
+// SendDirect отправляет личное сообщение, создавая при необходимости
+// личный канал бота с пользователем.
+func (b *Bot) SendDirect(ctx context.Context, userID, message string) error {
+	channel, err := b.client.CreateDirectChannel(b.botUser.ID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка создания личного канала с %s: %w", userID, err)
+	}
+	return b.Announce(ctx, channel.ID, message)
+}
+
+// localizerFor выбирает язык ответа: по локали пользователя, если это
+// включено в конфигурации, иначе язык установки. Профиль запрашивается
+// один раз на пользователя; неудачный запрос повторится при следующем сообщении.
+func (b *Bot) localizerFor(userID string) *i18n.Localizer {
+	if !b.cfg.UserLocale {
+		return b.localizer
+	}
+
+	user, err := b.author(userID)
+	if err != nil {
+		b.logger.Warn().Err(err).Str("user_id", userID).Msg("Не удалось получить локаль пользователя")
+		return b.localizer
+	}
+	if lang, ok := i18n.ParseLang(user.Locale); ok {
+		return i18n.New(string(lang))
+	}
+	return b.localizer
+}
+
+// author возвращает профиль автора команды или другого пользователя;
+// профиль запрашивается у Mattermost один раз, ошибки не запоминаются.
+func (b *Bot) author(userID string) (*mmclient.User, error) {
+	b.authorsMu.Lock()
+	user, ok := b.authors[userID]
+	b.authorsMu.Unlock()
+	if ok {
+		return user, nil
+	}
+
+	user, err := b.client.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	b.authorsMu.Lock()
+	if b.authors == nil {
+		b.authors = make(map[string]*mmclient.User)
+	}
+	b.authors[userID] = user
+	b.authorsMu.Unlock()
+	return user, nil
 }
 
 func (b *Bot) sendResponse(channelID, message string) {
-	response := &model.Post{
-		ChannelId: channelID,
+	b.sendPost(&mmclient.Post{
+		ChannelID: channelID,
 		Message:   message,
+	})
+}
+
+func (b *Bot) sendPost(post *mmclient.Post) {
+	if _, err := b.client.CreatePost(post); err != nil {
+		b.logger.Error().Err(err).Msg("Ошибка при отправке сообщения")
 	}
+	b.logger.Info().Msgf("Собщение успешно отправлено по этому ChannelID: %s", post.ChannelID)
+}
 
-	if _, resp := b.client.CreatePost(response); resp.Error != nil {
-		b.logger.Error().Err(resp.Error).Msg("Ошибка при отправке сообщения")
+// sendEphemeral показывает сообщение только пользователю userID. Возвращает
+// false, если Mattermost не принял такое сообщение (например, старая версия
+// API): тогда ответ отправляется обычным сообщением.
+func (b *Bot) sendEphemeral(userID, channelID, message string) bool {
+	if message == "" {
+		return false
+	}
+	post := &mmclient.Post{ChannelID: channelID, Message: message}
+	if _, err := b.client.CreatePostEphemeral(userID, post); err != nil {
+		b.logger.Warn().Err(err).Str("channel_id", channelID).Msg("Не удалось отправить ответ только автору, он будет виден каналу")
+		return false
 	}
-	b.logger.Info().Msgf("Собщение успешно отправлено по этому ChannelID: %s", channelID)
+	return true
 }
@@ -4,40 +4,139 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"polling_bot/internal/config"
+	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+	"polling_bot/internal/testutil/mmserver"
 
-	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/mock"
 )
 
 // Mocks 
 type fakeClient struct {
-	Transport      http.RoundTripper
-	getMeFunc      func(string) (*model.User, *model.Response)
-	createPostFunc func(*model.Post) (*model.Post, *model.Response)
+	getMeFunc      func() (*mmclient.User, error)
+	getUserFunc    func(string) (*mmclient.User, error)
+	getUsersFunc   func(userIDs []string) ([]*mmclient.User, error)
+	getMemberFunc  func(channelID, userID string) (*mmclient.ChannelMember, error)
+	getByNameFunc  func(username string) (*mmclient.User, error)
+	directFunc     func(userID1, userID2 string) (*mmclient.Channel, error)
+	createPostFunc func(*mmclient.Post) (*mmclient.Post, error)
+	ephemeralFunc  func(userID string, post *mmclient.Post) (*mmclient.Post, error)
+	updatePostFunc func(*mmclient.Post) (*mmclient.Post, error)
+	reactionFunc   func(*mmclient.Reaction) (*mmclient.Reaction, error)
+	pinFunc        func(postID string) error
+	unpinFunc      func(postID string) error
+	teamsFunc      func(userID string) ([]*mmclient.Team, error)
 }
 
-func (f *fakeClient) GetMe(param string) (*model.User, *model.Response) {
-	return f.getMeFunc(param)
+func (f *fakeClient) GetMe() (*mmclient.User, error) {
+	return f.getMeFunc()
 }
 
-func (f *fakeClient) CreatePost(post *model.Post) (*model.Post, *model.Response) {
+func (f *fakeClient) GetUser(userID string) (*mmclient.User, error) {
+	if f.getUserFunc != nil {
+		return f.getUserFunc(userID)
+	}
+	return &mmclient.User{ID: userID}, nil
+}
+
+func (f *fakeClient) GetUsersByIds(userIDs []string) ([]*mmclient.User, error) {
+	if f.getUsersFunc != nil {
+		return f.getUsersFunc(userIDs)
+	}
+	users := make([]*mmclient.User, 0, len(userIDs))
+	for _, userID := range userIDs {
+		users = append(users, &mmclient.User{ID: userID})
+	}
+	return users, nil
+}
+
+func (f *fakeClient) GetUserByUsername(username string) (*mmclient.User, error) {
+	if f.getByNameFunc != nil {
+		return f.getByNameFunc(username)
+	}
+	return &mmclient.User{ID: "id-" + username, Username: username}, nil
+}
+
+func (f *fakeClient) CreateDirectChannel(userID1, userID2 string) (*mmclient.Channel, error) {
+	if f.directFunc != nil {
+		return f.directFunc(userID1, userID2)
+	}
+	return &mmclient.Channel{ID: userID1 + "__" + userID2, Type: mmclient.ChannelDirect}, nil
+}
+
+func (f *fakeClient) GetChannelMember(channelID, userID string) (*mmclient.ChannelMember, error) {
+	if f.getMemberFunc != nil {
+		return f.getMemberFunc(channelID, userID)
+	}
+	return &mmclient.ChannelMember{ChannelID: channelID, UserID: userID}, nil
+}
+
+func (f *fakeClient) CreatePost(post *mmclient.Post) (*mmclient.Post, error) {
 	if f.createPostFunc != nil {
 		return f.createPostFunc(post)
 	}
-	return post, &model.Response{}
+	return post, nil
+}
+
+// Без ephemeralFunc клиент ведёт себя как Mattermost без поддержки
+// эфемерных сообщений: ответы уходят обычным сообщением
+func (f *fakeClient) CreatePostEphemeral(userID string, post *mmclient.Post) (*mmclient.Post, error) {
+	if f.ephemeralFunc != nil {
+		return f.ephemeralFunc(userID, post)
+	}
+	return nil, &mmclient.Error{StatusCode: http.StatusNotImplemented, Err: errors.New("not implemented")}
+}
+
+func (f *fakeClient) UpdatePost(post *mmclient.Post) (*mmclient.Post, error) {
+	if f.updatePostFunc != nil {
+		return f.updatePostFunc(post)
+	}
+	return post, nil
+}
+
+func (f *fakeClient) SaveReaction(reaction *mmclient.Reaction) (*mmclient.Reaction, error) {
+	if f.reactionFunc != nil {
+		return f.reactionFunc(reaction)
+	}
+	return reaction, nil
+}
+
+func (f *fakeClient) PinPost(postID string) error {
+	if f.pinFunc != nil {
+		return f.pinFunc(postID)
+	}
+	return nil
+}
+
+func (f *fakeClient) UnpinPost(postID string) error {
+	if f.unpinFunc != nil {
+		return f.unpinFunc(postID)
+	}
+	return nil
+}
+
+func (f *fakeClient) GetTeamsForUser(userID string) ([]*mmclient.Team, error) {
+	if f.teamsFunc != nil {
+		return f.teamsFunc(userID)
+	}
+	return []*mmclient.Team{{ID: "team1"}}, nil
 }
 
 type fakeWSClient struct {
-	events chan *model.WebSocketEvent
+	events chan *mmclient.WSEvent
 }
 
 func (f *fakeWSClient) Listen() {
@@ -47,34 +146,22 @@ func (f *fakeWSClient) Close() {
 	close(f.events)
 }
 
-func (f *fakeWSClient) EventChannel() <-chan *model.WebSocketEvent {
+func (f *fakeWSClient) Events() <-chan *mmclient.WSEvent {
 	return f.events
 }
 
-type mockHTTPClient struct{}
-
-func (m *mockHTTPClient) RoundTrip(req *http.Request) (*http.Response, error) {
-	if req.URL.Host == "dummy" && req.URL.Path == "/api/v4/users/me" {
-		return &http.Response{
-			StatusCode: http.StatusOK,
-			Body:       io.NopCloser(bytes.NewReader([]byte(`{"id":"bot123"}`))),
-		}, nil
-	}
-	return nil, fmt.Errorf("mockHTTPClient: unknown request %v", req.URL)
-}
-
 type MockCommandHandler struct {
 	mock.Mock
 }
 
-func (m *MockCommandHandler) ParseCommand(input string) (string, []string, bool) {
+func (m *MockCommandHandler) ParseCommand(input string) (string, []string, bool, error) {
 	args := m.Called(input)
-	return args.String(0), args.Get(1).([]string), args.Bool(2)
+	return args.String(0), args.Get(1).([]string), args.Bool(2), args.Error(3)
 }
 
-func (m *MockCommandHandler) HandleCommand(ctx context.Context, command string, args []string, userID string) (string, error) {
+func (m *MockCommandHandler) HandleCommand(ctx context.Context, command string, args []string, userID string) (handler.Response, error) {
 	arguments := m.Called(ctx, command, args, userID)
-	return arguments.String(0), arguments.Error(1)
+	return arguments.Get(0).(handler.Response), arguments.Error(1)
 }
 
 func (m *MockCommandHandler) GetHelpText() string {
@@ -113,11 +200,46 @@ func TestNewBot_ValidURL(t *testing.T) {
 	}
 }
 
+// TestSetClient проверяет, что подставленный клиент отвечает на запросы
+// бота через очередь исходящих запросов.
+func TestSetClient(t *testing.T) {
+	cfg := config.Config{
+		MattermostURL: "https://mattermost.example.com",
+		BotToken:      "dummy",
+		HTTPTimeout:   time.Second,
+	}
+	bot, err := NewBot(cfg, zerolog.Nop(), new(MockCommandHandler))
+	if err != nil {
+		t.Fatalf("Не удалось создать бота: %v", err)
+	}
+	var posted []*mmclient.Post
+	bot.SetClient(&fakeClient{
+		getMeFunc: func() (*mmclient.User, error) { return &mmclient.User{ID: "bot123", IsBot: true}, nil },
+		createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			posted = append(posted, post)
+			return post, nil
+		},
+	})
+
+	if err := bot.CheckAuth(context.Background()); err != nil {
+		t.Errorf("Ожидалась успешная проверка через подставленный клиент, получена ошибка: %v", err)
+	}
+	if err := bot.Announce(context.Background(), "channel1", "Привет"); err != nil {
+		t.Errorf("Ожидалась успешная отправка, получена ошибка: %v", err)
+	}
+	if bot.client != bot.outbox {
+		t.Error("Запросы подставленного клиента должны идти через очередь")
+	}
+	if len(posted) != 1 || posted[0].Message != "Привет" {
+		t.Errorf("Ожидалось одно сообщение через подставленный клиент, получено: %v", posted)
+	}
+}
+
 // TestAuthenticate_Success проверяет успешную аутентификацию бота.
 func TestAuthenticate_Success(t *testing.T) {
 	fc := &fakeClient{
-		getMeFunc: func(param string) (*model.User, *model.Response) {
-			return &model.User{Id: "bot123"}, &model.Response{}
+		getMeFunc: func() (*mmclient.User, error) {
+			return &mmclient.User{ID: "bot123"}, nil
 		},
 	}
 	bot := &Bot{
@@ -128,18 +250,41 @@ func TestAuthenticate_Success(t *testing.T) {
 	if err != nil {
 		t.Errorf("Ожидалась успешная аутентификация, получена ошибка: %v", err)
 	}
-	if bot.botUser == nil || bot.botUser.Id != "bot123" {
+	if bot.botUser == nil || bot.botUser.ID != "bot123" {
 		t.Error("botUser не установлен корректно после аутентификации")
 	}
 }
 
+// TestAuthenticate_PassesIdentity проверяет, что имя бота передаётся обработчику,
+// чтобы команды можно было вызывать упоминанием.
+func TestAuthenticate_PassesIdentity(t *testing.T) {
+	fc := &fakeClient{
+		getMeFunc: func() (*mmclient.User, error) {
+			return &mmclient.User{ID: "bot123", Username: "pollbot", FirstName: "Poll", LastName: "Bot"}, nil
+		},
+	}
+	h := handler.NewPollCommandHandler(nil, nil, handler.DefaultCommandPrefix)
+	bot := &Bot{
+		client:         fc,
+		logger:         zerolog.Nop(),
+		commandHandler: h,
+	}
+	if err := bot.authenticate(); err != nil {
+		t.Fatalf("Ожидалась успешная аутентификация, получена ошибка: %v", err)
+	}
+
+	for _, input := range []string{"@pollbot results p1", "@Poll Bot: results p1"} {
+		if cmd, _, ok, _ := h.ParseCommand(input); !ok || cmd != "results" {
+			t.Errorf("Упоминание %q не распознано как команда", input)
+		}
+	}
+}
+
 // TestAuthenticate_Error проверяет обработку ошибки аутентификации.
 func TestAuthenticate_Error(t *testing.T) {
 	fc := &fakeClient{
-		getMeFunc: func(param string) (*model.User, *model.Response) {
-			return nil, &model.Response{Error: &model.AppError{
-				Message: "authentication failed",
-			}}
+		getMeFunc: func() (*mmclient.User, error) {
+			return nil, &mmclient.Error{Err: errors.New("authentication failed")}
 		},
 	}
 	bot := &Bot{
@@ -164,7 +309,7 @@ func TestInitWebSocket_Success(t *testing.T) {
 	bot, _ := NewBot(cfg, zerolog.Nop(), mockHandler)
 
 	bot.wsClient = &fakeWSClient{
-		events: make(chan *model.WebSocketEvent),
+		events: make(chan *mmclient.WSEvent),
 	}
 
 	err := bot.initWebSocket()
@@ -195,306 +340,246 @@ func TestInitWebSocket_InvalidToken(t *testing.T) {
 	}
 }
 
-// TestInitialize_Success проверяет общую инициализацию бота.
-func TestInitialize_Success(t *testing.T) {
-	fc := &fakeClient{
-		getMeFunc: func(param string) (*model.User, *model.Response) {
-			return &model.User{Id: "bot123"}, &model.Response{}
-		},
-		createPostFunc: func(post *model.Post) (*model.Post, *model.Response) {
-			return post, &model.Response{}
-		},
-		Transport: &mockHTTPClient{},
+// startBot запускает бота с обработчиком h на поддельном сервере srv.
+// stop останавливает бота и ждёт, пока он обработает все полученные события.
+func startBot(t *testing.T, srv *mmserver.Server, cfg config.Config, h handler.CommandHandler) (stop func()) {
+	t.Helper()
+	cfg.MattermostURL = srv.URL
+	cfg.BotToken = srv.Token
+	cfg.HTTPTimeout = time.Second
+	cfg.OutboxDrainTimeout = time.Second
+	bot, err := NewBot(cfg, zerolog.Nop(), h)
+	if err != nil {
+		t.Fatalf("Не удалось создать бота: %v", err)
 	}
 
-	ws := &fakeWSClient{
-		events: make(chan *model.WebSocketEvent),
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- bot.Start(ctx) }()
+	return func() {
+		t.Helper()
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Ожидалось завершение Start с context.Canceled, получено: %v", err)
+		}
 	}
+}
+
+// expectPing настраивает обработчик на команду ping, ответ на которую
+// показывает, что бот обработал всё, что пришло до неё.
+func expectPing(m *MockCommandHandler) {
+	m.On("ParseCommand", "!poll ping").Return("ping", []string{}, true, nil)
+	m.On("HandleCommand", mock.Anything, "ping", []string{}, "user123").Return(handler.Response{Text: "pong"}, nil)
+}
 
+// sendPing отправляет команду ping и ждёт ответа на неё.
+func sendPing(t *testing.T, srv *mmserver.Server, wantPosts int) {
+	t.Helper()
+	srv.Posted(mmclient.Post{ChannelID: "test-channel", UserID: "user123", Message: "!poll ping"}, mmclient.ChannelOpen)
+	srv.WaitPosts(t, wantPosts)
+}
+
+// TestInitialize_Success проверяет общую инициализацию бота: вход под
+// пользователем бота и подключение WebSocket.
+func TestInitialize_Success(t *testing.T) {
+	srv := mmserver.New(t, mmclient.User{ID: "bot123", Username: "pollbot"})
 	cfg := config.Config{
-		MattermostURL: "http://dummy",
-		BotToken:      "dummy",
+		MattermostURL: srv.URL,
+		BotToken:      srv.Token,
 		HTTPTimeout:   time.Second,
 	}
 
 	mockHandler := new(MockCommandHandler)
 	bot, _ := NewBot(cfg, zerolog.Nop(), mockHandler)
-	bot.client = fc
-	bot.botUser = &model.User{Id: "bot123"}
-	bot.wsClient = ws
-
-	if apiClient, ok := bot.client.(*fakeClient); ok {
-		apiClient.Transport = &mockHTTPClient{}
-	} else {
-		t.Fatalf("Ожидалась ошибка типа *fakeClient, но получено %T", bot.client)
-	}
 
 	err := bot.initialize()
 	if err != nil {
-		t.Errorf("Ожидалось успешное выполнение initialize, получена ошибка: %v", err)
+		t.Fatalf("Ожидалось успешное выполнение initialize, получена ошибка: %v", err)
+	}
+	defer bot.wsClient.Close()
+	if bot.botUser == nil || bot.botUser.ID != "bot123" {
+		t.Errorf("Ожидался пользователь бота bot123, получено: %+v", bot.botUser)
+	}
+	if bot.wsClient == nil {
+		t.Error("Ожидалось, что wsClient будет инициализирован")
 	}
 }
 
-// TestHandleWebSocketEvent проверяет корректную обработку входящего события с командой.
+// TestHandleWebSocketEvent проверяет обработку входящего события с командой:
+// сообщение приходит по WebSocket, ответ публикуется через REST API.
 func TestHandleWebSocketEvent(t *testing.T) {
-	mockHandler := new(MockCommandHandler)
-	logger := zerolog.Nop()
-
 	tests := []struct {
-		name          string
-		inputMsg      string
-		mockSetup     func(*MockCommandHandler)
-		expectedCalls int
-		wantMessage   string
+		name        string
+		inputMsg    string
+		mockSetup   func(*MockCommandHandler)
+		wantMessage string
 	}{
 		{
 			name:     "valid create command",
 			inputMsg: `!poll create "Question" "Option1"`,
 			mockSetup: func(m *MockCommandHandler) {
 				m.On("ParseCommand", `!poll create "Question" "Option1"`).
-					Return("create", []string{"Question", "Option1"}, true).
+					Return("create", []string{"Question", "Option1"}, true, nil).
 					Once()
 				m.On("HandleCommand", mock.Anything, "create", []string{"Question", "Option1"}, "user123").
-					Return("Poll created", nil).
+					Return(handler.Response{Text: "Poll created"}, nil).
 					Once()
 			},
-			expectedCalls: 1,
-			wantMessage:   "Poll created",
+			wantMessage: "Poll created",
 		},
 		{
 			name:     "invalid command",
 			inputMsg: "invalid command",
 			mockSetup: func(m *MockCommandHandler) {
 				m.On("ParseCommand", "invalid command").
-					Return("", []string{}, false).
+					Return("", []string{}, false, nil).
 					Once()
 			},
-			expectedCalls: 1,
-			wantMessage:   "",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockHandler.ExpectedCalls = nil
-			if tt.mockSetup != nil {
-				tt.mockSetup(mockHandler)
-			}
+			mockHandler := new(MockCommandHandler)
+			tt.mockSetup(mockHandler)
+			expectPing(mockHandler)
 
-			var lastPost *model.Post
-			fc := &fakeClient{
-				createPostFunc: func(post *model.Post) (*model.Post, *model.Response) {
-					lastPost = post
-					return post, &model.Response{}
-				},
-			}
+			srv := mmserver.New(t, mmclient.User{ID: "bot123", Username: "pollbot"})
+			stop := startBot(t, srv, config.Config{}, mockHandler)
+			srv.Posted(mmclient.Post{ChannelID: "test-channel", UserID: "user123", Message: tt.inputMsg}, mmclient.ChannelOpen)
 
-			bot := &Bot{
-				commandHandler: mockHandler,
-				logger:         logger,
-				botUser:        &model.User{Id: "bot123"},
-				client:         fc,
-			}
-
-			post := model.Post{
-				ChannelId: "test-channel",
-				UserId:    "user123",
-				Message:   tt.inputMsg,
+			wantPosts := 1
+			if tt.wantMessage != "" {
+				wantPosts = 2
 			}
-			postBytes, _ := json.Marshal(&post)
+			sendPing(t, srv, wantPosts)
+			stop()
 
-			event := &model.WebSocketEvent{
-				Event: model.WEBSOCKET_EVENT_POSTED,
-				Data: map[string]interface{}{
-					"post": string(postBytes),
-				},
+			posts := srv.Posts()
+			if len(posts) != wantPosts {
+				t.Fatalf("Ожидалось сообщений: %d, получено: %+v", wantPosts, posts)
 			}
-
-			bot.handleWebSocketEvent(context.Background(), event)
-
+			// События обрабатываются параллельно: ответ на ping может прийти раньше
 			if tt.wantMessage != "" {
-				if lastPost == nil {
-					t.Fatal("Сообщение не было отправлено")
+				reply := posts[0]
+				if reply.Message == "pong" {
+					reply = posts[1]
 				}
-				if !strings.Contains(lastPost.Message, tt.wantMessage) {
-					t.Errorf("Ожидалось: %q\nПолучено: %q", tt.wantMessage, lastPost.Message)
+				if reply.Message != tt.wantMessage || reply.ChannelID != "test-channel" || reply.UserID != "bot123" {
+					t.Errorf("Ожидался ответ бота %q в test-channel, получено: %+v", tt.wantMessage, reply.Post)
 				}
-			} else if lastPost != nil {
-				t.Error("Сообщение было отправлено, хотя не должно было")
 			}
-
-			mockHandler.AssertNumberOfCalls(t, "HandleCommand", tt.expectedCalls)
 			mockHandler.AssertExpectations(t)
 		})
 	}
 }
 
-// TestHandleWebSocketEventEdgeCases проверяет обработку некорректных входящих данных.
-func TestHandleWebSocketEventEdgeCases(t *testing.T) {
+// TestHandleWebSocketEvent_Reactions_EndToEnd проверяет, что реакция на
+// команду ставится через REST API на сообщение из события.
+func TestHandleWebSocketEvent_Reactions_EndToEnd(t *testing.T) {
 	mockHandler := new(MockCommandHandler)
-	logger := zerolog.Nop()
+	expectPing(mockHandler)
+
+	srv := mmserver.New(t, mmclient.User{ID: "bot123", Username: "pollbot"})
+	stop := startBot(t, srv, config.Config{Reactions: true}, mockHandler)
+	post := srv.Posted(mmclient.Post{ChannelID: "test-channel", UserID: "user123", Message: "!poll ping"}, mmclient.ChannelOpen)
+	srv.WaitPosts(t, 1)
+	reactions := srv.WaitReactions(t, 1)
+	stop()
+
+	want := mmclient.Reaction{UserID: "bot123", PostID: post.ID, EmojiName: emojiDone}
+	if len(reactions) != 1 || reactions[0] != want {
+		t.Errorf("Ожидалась реакция %+v, получено: %+v", want, reactions)
+	}
+}
 
+// TestHandleWebSocketEventEdgeCases проверяет обработку некорректных входящих данных.
+func TestHandleWebSocketEventEdgeCases(t *testing.T) {
 	tests := []struct {
-		name        string
-		eventSetup  func() *model.WebSocketEvent
-		expectError bool
+		name  string
+		event mmclient.WSEvent
 	}{
 		{
-			name: "invalid post data",
-			eventSetup: func() *model.WebSocketEvent {
-				return &model.WebSocketEvent{
-					Event: model.WEBSOCKET_EVENT_POSTED,
-					Data:  map[string]interface{}{"post": 123},
-				}
-			},
+			name:  "invalid post data",
+			event: mmclient.WSEvent{Type: mmclient.EventPosted, ChannelID: "test-channel", Data: map[string]interface{}{"post": 123}},
 		},
 		{
-			name: "message from bot itself",
-			eventSetup: func() *model.WebSocketEvent {
-				post := model.Post{
-					UserId: "bot123",
-				}
-				postBytes, _ := json.Marshal(&post)
-				return &model.WebSocketEvent{
-					Event: model.WEBSOCKET_EVENT_POSTED,
-					Data:  map[string]interface{}{"post": string(postBytes)},
-				}
-			},
+			name:  "post without data",
+			event: mmclient.WSEvent{Type: mmclient.EventPosted, ChannelID: "test-channel"},
+		},
+		{
+			name:  "malformed post JSON",
+			event: mmclient.WSEvent{Type: mmclient.EventPosted, ChannelID: "test-channel", Data: map[string]interface{}{"post": `{"id":`}},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var called bool
-			fc := &fakeClient{
-				createPostFunc: func(post *model.Post) (*model.Post, *model.Response) {
-					called = true
-					return post, &model.Response{}
-				},
-			}
+			mockHandler := new(MockCommandHandler)
+			expectPing(mockHandler)
 
-			bot := &Bot{
-				commandHandler: mockHandler,
-				logger:         logger,
-				botUser:        &model.User{Id: "bot123"},
-				client:         fc,
-			}
-
-			event := tt.eventSetup()
-			bot.handleWebSocketEvent(context.Background(), event)
+			srv := mmserver.New(t, mmclient.User{ID: "bot123", Username: "pollbot"})
+			stop := startBot(t, srv, config.Config{}, mockHandler)
+			srv.Emit(tt.event)
+			sendPing(t, srv, 1)
+			stop()
 
-			if called {
-				t.Error("CreatePost был вызван неожиданно")
+			if posts := srv.Posts(); len(posts) != 1 {
+				t.Errorf("CreatePost был вызван неожиданно: %+v", posts)
 			}
 		})
 	}
 }
 
-// TestHandleWebSocketEventNonPosted проверяет, что события, отличные от WEBSOCKET_EVENT_POSTED, игнорируются.
+// TestHandleWebSocketEventNonPosted проверяет, что события, отличные от posted, игнорируются.
 func TestHandleWebSocketEventNonPosted(t *testing.T) {
-	called := false
-	fc := &fakeClient{
-		getMeFunc: func(param string) (*model.User, *model.Response) {
-			return &model.User{Id: "bot123"}, &model.Response{}
-		},
-		createPostFunc: func(post *model.Post) (*model.Post, *model.Response) {
-			called = true
-			return post, &model.Response{}
-		},
-	}
-
-	botInstance := &Bot{
-		logger:  zerolog.Nop(),
-		client:  fc,
-		botUser: &model.User{Id: "bot123"},
-		wsClient: &fakeWSClient{
-			events: make(chan *model.WebSocketEvent, 1),
-		},
-	}
-
-	event := &model.WebSocketEvent{
-		Event: "NON_POSTED_EVENT",
-		Data:  map[string]interface{}{"post": "{}"},
-	}
-	ctx := context.Background()
-	botInstance.handleWebSocketEvent(ctx, event)
-	if called {
-		t.Error("CreatePost не должен вызываться для событий, отличных от WEBSOCKET_EVENT_POSTED")
+	mockHandler := new(MockCommandHandler)
+	expectPing(mockHandler)
+
+	srv := mmserver.New(t, mmclient.User{ID: "bot123", Username: "pollbot"})
+	stop := startBot(t, srv, config.Config{}, mockHandler)
+	srv.Emit(mmclient.WSEvent{
+		Type:      "typing",
+		ChannelID: "test-channel",
+		Data:      map[string]interface{}{"post": `{"message":"!poll ping","user_id":"user123"}`},
+	})
+	sendPing(t, srv, 1)
+	stop()
+
+	if posts := srv.Posts(); len(posts) != 1 {
+		t.Errorf("CreatePost не должен вызываться для событий, отличных от posted: %+v", posts)
 	}
+	mockHandler.AssertNumberOfCalls(t, "HandleCommand", 1)
 }
 
 // TestHandleWebSocketEventOwnMessage проверяет, что сообщения, отправленные самим ботом, игнорируются.
 func TestHandleWebSocketEventOwnMessage(t *testing.T) {
-	called := false
-	fc := &fakeClient{
-		getMeFunc: func(param string) (*model.User, *model.Response) {
-			return &model.User{Id: "bot123"}, &model.Response{}
-		},
-		createPostFunc: func(post *model.Post) (*model.Post, *model.Response) {
-			called = true
-			return post, &model.Response{}
-		},
-	}
-
-	originalPost := model.Post{
-		ChannelId: "channel456",
-		UserId:    "bot123",
-		Message:   "Привет, бот!",
-	}
-	postBytes, err := json.Marshal(&originalPost)
-	if err != nil {
-		t.Fatal("Не удалось сериализовать пост:", err)
-	}
-	event := &model.WebSocketEvent{
-		Event: model.WEBSOCKET_EVENT_POSTED,
-		Data:  map[string]interface{}{"post": string(postBytes)},
-	}
+	mockHandler := new(MockCommandHandler)
+	expectPing(mockHandler)
 
-	botInstance := &Bot{
-		logger:  zerolog.Nop(),
-		client:  fc,
-		botUser: &model.User{Id: "bot123"},
-		wsClient: &fakeWSClient{
-			events: make(chan *model.WebSocketEvent, 1),
-		},
-	}
+	srv := mmserver.New(t, mmclient.User{ID: "bot123", Username: "pollbot"})
+	stop := startBot(t, srv, config.Config{}, mockHandler)
+	srv.Posted(mmclient.Post{ChannelID: "test-channel", UserID: "bot123", Message: "!poll ping"}, mmclient.ChannelOpen)
+	sendPing(t, srv, 1)
+	stop()
 
-	ctx := context.Background()
-	botInstance.handleWebSocketEvent(ctx, event)
-	if called {
-		t.Error("CreatePost не должен вызываться для сообщений, отправленных самим ботом")
+	if posts := srv.Posts(); len(posts) != 1 {
+		t.Errorf("CreatePost не должен вызываться для сообщений, отправленных самим ботом: %+v", posts)
 	}
+	mockHandler.AssertNumberOfCalls(t, "HandleCommand", 1)
 }
 
-
-
 // TestStart_CtxCanceled проверяет, что функция Start корректно завершается при отмене контекста.
 func TestStart_CtxCanceled(t *testing.T) {
-	fc := &fakeClient{
-		getMeFunc: func(param string) (*model.User, *model.Response) {
-			return &model.User{Id: "bot123"}, &model.Response{}
-		},
-		createPostFunc: func(post *model.Post) (*model.Post, *model.Response) {
-			return post, &model.Response{}
-		},
-		Transport: &mockHTTPClient{},
-	}
-
-	ws := &fakeWSClient{
-		events: make(chan *model.WebSocketEvent, 1),
-	}
-
+	srv := mmserver.New(t, mmclient.User{ID: "bot123"})
 	cfg := config.Config{
-		MattermostURL: "http://dummy",
-		BotToken:      "dummy",
+		MattermostURL: srv.URL,
+		BotToken:      srv.Token,
 		HTTPTimeout:   time.Second,
 	}
 
 	mockHandler := new(MockCommandHandler)
 	bot, _ := NewBot(cfg, zerolog.Nop(), mockHandler)
-	bot.client = fc
-	bot.botUser = &model.User{Id: "bot123"}
-	bot.wsClient = ws
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -509,58 +594,45 @@ func TestStart_CtxCanceled(t *testing.T) {
 
 // TestStart_AuthFail проверяет, что Start завершается с ошибкой при неуспешной аутентификации.
 func TestStart_AuthFail(t *testing.T) {
-	fc := &fakeClient{
-		getMeFunc: func(param string) (*model.User, *model.Response) {
-			return nil, &model.Response{Error: &model.AppError{Message: "authentication failed"}}
-		},
-	}
-
+	srv := mmserver.New(t, mmclient.User{ID: "bot123"})
 	cfg := config.Config{
-		MattermostURL: "http://dummy",
-		BotToken:      "dummy",
+		MattermostURL: srv.URL,
+		BotToken:      "wrong-token",
 		HTTPTimeout:   time.Second,
 	}
 
 	mockHandler := new(MockCommandHandler)
 	bot, _ := NewBot(cfg, zerolog.Nop(), mockHandler)
-	bot.client = fc
 
 	err := bot.Start(context.Background())
-	if err == nil {
-		t.Error("Ожидалась ошибка при аутентификации, но получен nil")
+	if mmclient.StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("Ожидалась ошибка 401 при аутентификации, получено: %v", err)
 	}
 }
 
 // TestStart_WebSocketFail проверяет, что Start завершается с ошибкой при неуспешной инициализации WebSocket.
 func TestStart_WebSocketFail(t *testing.T) {
-	fc := &fakeClient{
-		getMeFunc: func(param string) (*model.User, *model.Response) {
-			return &model.User{Id: "bot123"}, &model.Response{}
-		},
-	}
-
+	srv := mmserver.New(t, mmclient.User{ID: "bot123"})
+	srv.Fail("GET /api/v4/websocket", http.StatusServiceUnavailable)
 	cfg := config.Config{
-		MattermostURL: "http://dummy",
-		BotToken:      "dummy",
+		MattermostURL: srv.URL,
+		BotToken:      srv.Token,
 		HTTPTimeout:   time.Second,
 	}
 
 	mockHandler := new(MockCommandHandler)
 	bot, _ := NewBot(cfg, zerolog.Nop(), mockHandler)
-	bot.client = fc
-	bot.botUser = &model.User{Id: "bot123"}
-	bot.wsClient = nil
 
 	err := bot.Start(context.Background())
-	if err == nil {
-		t.Error("Ожидалась ошибка при инициализации WebSocket, но получен nil")
+	if err == nil || !strings.Contains(err.Error(), "ошибка создания WebSocket клиента") {
+		t.Errorf("Ожидалась ошибка при инициализации WebSocket, получено: %v", err)
 	}
 }
 
 // TestCloseConnections проверяет, что метод Close корректно закрывает канал событий.
 func TestCloseConnections(t *testing.T) {
 	ws := &fakeWSClient{
-		events: make(chan *model.WebSocketEvent),
+		events: make(chan *mmclient.WSEvent),
 	}
 
 	bot := &Bot{
@@ -578,3 +650,788 @@ func TestCloseConnections(t *testing.T) {
 		t.Error("EventChannel не закрыт")
 	}
 }
+
+// TestHandleWebSocketEvent_UserLocale проверяет ответ на языке профиля пользователя
+// и то, что профиль запрашивается один раз.
+func TestHandleWebSocketEvent_UserLocale(t *testing.T) {
+	mockHandler := new(MockCommandHandler)
+	mockHandler.On("ParseCommand", "!poll results p1").Return("results", []string{"p1"}, true, nil)
+	mockHandler.On("HandleCommand", mock.Anything, "results", []string{"p1"}, "user123").
+		Return(handler.Response{}, i18n.NewError(i18n.PollNotFound))
+
+	var posts []string
+	getUserCalls := 0
+	fc := &fakeClient{
+		getUserFunc: func(userID string) (*mmclient.User, error) {
+			getUserCalls++
+			return &mmclient.User{ID: userID, Locale: "en"}, nil
+		},
+		createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			posts = append(posts, post.Message)
+			return post, nil
+		},
+	}
+
+	bot := &Bot{
+		cfg:            config.Config{UserLocale: true},
+		commandHandler: mockHandler,
+		logger:         zerolog.Nop(),
+		botUser:        &mmclient.User{ID: "bot123"},
+		client:         fc,
+		localizer:      i18n.New("ru"),
+	}
+
+	postBytes, _ := json.Marshal(&mmclient.Post{ChannelID: "c1", UserID: "user123", Message: "!poll results p1"})
+	event := &mmclient.WSEvent{
+		Type: mmclient.EventPosted,
+		Data:  map[string]interface{}{"post": string(postBytes)},
+	}
+	bot.handleWebSocketEvent(context.Background(), event)
+	bot.handleWebSocketEvent(context.Background(), event)
+
+	want := "Command failed: poll not found"
+	if len(posts) != 2 || posts[0] != want || posts[1] != want {
+		t.Errorf("Ожидались два ответа %q, получено: %q", want, posts)
+	}
+	if getUserCalls != 1 {
+		t.Errorf("Профиль пользователя должен запрашиваться один раз, запросов: %d", getUserCalls)
+	}
+}
+
+// TestBot_UsernameCached проверяет, что имя участника берётся из общего с
+// авторами команд кэша профилей, а ошибка не запоминается.
+func TestBot_UsernameCached(t *testing.T) {
+	calls := map[string]int{}
+	fc := &fakeClient{
+		getUserFunc: func(userID string) (*mmclient.User, error) {
+			calls[userID]++
+			if userID == "broken" {
+				return nil, errors.New("timeout")
+			}
+			return &mmclient.User{ID: userID, Username: "name-" + userID}, nil
+		},
+	}
+	bot := &Bot{logger: zerolog.Nop(), client: fc}
+
+	if _, err := bot.author("u1"); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		name, err := bot.Username(context.Background(), "u1")
+		if err != nil || name != "name-u1" {
+			t.Errorf("Ожидалось имя name-u1, получено: %q, %v", name, err)
+		}
+		if _, err := bot.Username(context.Background(), "broken"); err == nil {
+			t.Error("Ожидалась ошибка получения пользователя")
+		}
+	}
+	if calls["u1"] != 1 || calls["broken"] != 2 {
+		t.Errorf("Запросы профилей: %v, ожидалось u1 - 1, broken - 2", calls)
+	}
+}
+
+// checkingHandler добавляет к MockCommandHandler проверку выполнимости команды
+type checkingHandler struct {
+	*MockCommandHandler
+	executable map[string]bool
+}
+
+func (h *checkingHandler) IsExecutable(command string, args []string) bool {
+	return h.executable[command]
+}
+
+func postEvent(eventType, postID, message string) *mmclient.WSEvent {
+	postBytes, _ := json.Marshal(&mmclient.Post{ID: postID, ChannelID: "c1", UserID: "user123", Message: message})
+	return &mmclient.WSEvent{
+		Type: eventType,
+		Data:  map[string]interface{}{"post": string(postBytes)},
+	}
+}
+
+// TestHandleWebSocketEvent_Edits проверяет обработку отредактированных сообщений.
+func TestHandleWebSocketEvent_Edits(t *testing.T) {
+	tests := []struct {
+		name      string
+		original  string
+		edit      string
+		wantCalls int
+		wantReply string
+	}{
+		{
+			name:      "edit after success is ignored",
+			original:  "!poll vote p1 A",
+			edit:      "!poll vote p1 A ",
+			wantCalls: 1,
+		},
+		{
+			name:      "edit after typo is executed",
+			original:  "!poll vot p1 A",
+			edit:      "!poll vote p1 A",
+			wantCalls: 2,
+			wantReply: "_Ответ на отредактированное сообщение_\nvote done",
+		},
+		{
+			name:      "edit after a non-command is executed",
+			original:  "!pol vote p1 A",
+			edit:      "!poll vote p1 A",
+			wantCalls: 1,
+			wantReply: "_Ответ на отредактированное сообщение_\nvote done",
+		},
+		{
+			name:      "edit with a different command is executed",
+			original:  "!poll vote p1 A",
+			edit:      "!poll vote p1 B",
+			wantCalls: 2,
+			wantReply: "_Ответ на отредактированное сообщение_\nvote done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockHandler := new(MockCommandHandler)
+			parse := func(input string) {
+				parts := strings.Fields(input)
+				if parts[0] != "!poll" {
+					mockHandler.On("ParseCommand", input).Return("", []string{}, false, nil)
+					return
+				}
+				mockHandler.On("ParseCommand", input).Return(parts[1], parts[2:], true, nil)
+				mockHandler.On("HandleCommand", mock.Anything, parts[1], parts[2:], "user123").Return(handler.Response{Text: parts[1]+" done"}, nil)
+			}
+			parse(tt.original)
+			parse(tt.edit)
+
+			var replies []string
+			fc := &fakeClient{
+				createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+					replies = append(replies, post.Message)
+					return post, nil
+				},
+			}
+			bot := &Bot{
+				commandHandler: &checkingHandler{MockCommandHandler: mockHandler, executable: map[string]bool{"vote": true}},
+				logger:         zerolog.Nop(),
+				botUser:        &mmclient.User{ID: "bot123"},
+				client:         fc,
+				recent:         newRecentPosts(10, time.Minute),
+			}
+
+			bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPosted, "post1", tt.original))
+			bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPostEdited, "post1", tt.edit))
+
+			mockHandler.AssertNumberOfCalls(t, "HandleCommand", tt.wantCalls)
+			last := ""
+			if len(replies) > 0 {
+				last = replies[len(replies)-1]
+			}
+			if tt.wantReply != "" && last != tt.wantReply {
+				t.Errorf("Ожидался ответ %q, получено: %q", tt.wantReply, last)
+			}
+			if tt.wantReply == "" && len(replies) != tt.wantCalls {
+				t.Errorf("Правка не должна порождать ответ, ответы: %q", replies)
+			}
+		})
+	}
+}
+
+// TestHandleWebSocketEvent_EditAfterEviction проверяет, что вытесненное из LRU
+// сообщение при правке выполняется заново: бот больше не помнит исходную команду.
+func TestHandleWebSocketEvent_EditAfterEviction(t *testing.T) {
+	mockHandler := new(MockCommandHandler)
+	for _, id := range []string{"p1", "p2"} {
+		msg := "!poll results " + id
+		mockHandler.On("ParseCommand", msg).Return("results", []string{id}, true, nil)
+		mockHandler.On("HandleCommand", mock.Anything, "results", []string{id}, "user123").Return(handler.Response{Text: "ok"}, nil)
+	}
+
+	bot := &Bot{
+		commandHandler: mockHandler,
+		logger:         zerolog.Nop(),
+		botUser:        &mmclient.User{ID: "bot123"},
+		client:         &fakeClient{},
+		recent:         newRecentPosts(1, time.Minute),
+	}
+
+	bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPosted, "post1", "!poll results p1"))
+	bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPosted, "post2", "!poll results p2"))
+	bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPostEdited, "post1", "!poll results p1"))
+	mockHandler.AssertNumberOfCalls(t, "HandleCommand", 3)
+
+	// Выполненная правка снова запомнена, повторная правка пропускается
+	bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPostEdited, "post1", "!poll results p1"))
+	mockHandler.AssertNumberOfCalls(t, "HandleCommand", 3)
+}
+
+// TestHandleWebSocketEvent_Redelivery проверяет, что повторно доставленное
+// событие, в том числе пришедшее параллельно, выполняется один раз.
+func TestHandleWebSocketEvent_Redelivery(t *testing.T) {
+	mockHandler := new(MockCommandHandler)
+	mockHandler.On("ParseCommand", "!poll results p1").Return("results", []string{"p1"}, true, nil)
+	mockHandler.On("HandleCommand", mock.Anything, "results", []string{"p1"}, "user123").Return(handler.Response{Text: "ok"}, nil)
+
+	bot := &Bot{
+		commandHandler: mockHandler,
+		logger:         zerolog.Nop(),
+		botUser:        &mmclient.User{ID: "bot123"},
+		client:         &fakeClient{},
+		seen:           newRecentPosts(10, time.Minute),
+	}
+
+	event := postEvent(mmclient.EventPosted, "post1", "!poll results p1")
+	bot.handleWebSocketEvent(context.Background(), event)
+	bot.handleWebSocketEvent(context.Background(), event)
+	mockHandler.AssertNumberOfCalls(t, "HandleCommand", 1)
+
+	var wg sync.WaitGroup
+	concurrent := postEvent(mmclient.EventPosted, "post2", "!poll results p1")
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bot.handleWebSocketEvent(context.Background(), concurrent)
+		}()
+	}
+	wg.Wait()
+	mockHandler.AssertNumberOfCalls(t, "HandleCommand", 2)
+}
+
+// TestHandleWebSocketEvent_LogsLatency проверяет, что выполненная команда
+// попадает в журнал с именем и временем выполнения, но без аргументов.
+func TestHandleWebSocketEvent_LogsLatency(t *testing.T) {
+	mockHandler := new(MockCommandHandler)
+	mockHandler.On("ParseCommand", "!poll vote p1 Пицца").Return("vote", []string{"p1", "Пицца"}, true, nil)
+	mockHandler.On("HandleCommand", mock.Anything, "vote", []string{"p1", "Пицца"}, "user123").Return(handler.Response{Text: "ok"}, nil)
+
+	var logs bytes.Buffer
+	bot := &Bot{
+		commandHandler: mockHandler,
+		logger:         zerolog.New(&logs),
+		botUser:        &mmclient.User{ID: "bot123"},
+		client:         &fakeClient{},
+	}
+	bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPosted, "post1", "!poll vote p1 Пицца"))
+
+	var entry map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &e); err == nil && e["message"] == "Команда выполнена" {
+			entry = e
+		}
+	}
+	if entry == nil {
+		t.Fatalf("Нет записи о выполненной команде в журнале: %s", logs.String())
+	}
+	if entry["command"] != "vote" {
+		t.Errorf("Ожидалась команда vote, получено: %v", entry["command"])
+	}
+	if _, ok := entry["latency"].(float64); !ok {
+		t.Errorf("Нет времени выполнения команды: %v", entry)
+	}
+	if strings.Contains(logs.String(), "Пицца") {
+		t.Errorf("Аргументы команды не должны попадать в журнал: %s", logs.String())
+	}
+}
+
+// TestHandleWebSocketEvent_RedeliveredEdits проверяет, что правки одного
+// сообщения различаются по времени правки, а повтор одной правки пропускается.
+func TestHandleWebSocketEvent_RedeliveredEdits(t *testing.T) {
+	mockHandler := new(MockCommandHandler)
+	for _, msg := range []string{"!poll vot p1 A", "!poll vote p1 A", "!poll vote p1 B"} {
+		parts := strings.Fields(msg)
+		mockHandler.On("ParseCommand", msg).Return(parts[1], parts[2:], true, nil)
+		mockHandler.On("HandleCommand", mock.Anything, parts[1], parts[2:], "user123").Return(handler.Response{Text: "done"}, nil)
+	}
+
+	bot := &Bot{
+		commandHandler: &checkingHandler{MockCommandHandler: mockHandler, executable: map[string]bool{"vote": true}},
+		logger:         zerolog.Nop(),
+		botUser:        &mmclient.User{ID: "bot123"},
+		client:         &fakeClient{},
+		recent:         newRecentPosts(10, time.Minute),
+		seen:           newRecentPosts(10, time.Minute),
+	}
+	edit := func(message string, editAt int64) *mmclient.WSEvent {
+		postBytes, _ := json.Marshal(&mmclient.Post{ID: "post1", ChannelID: "c1", UserID: "user123", Message: message, EditAt: editAt})
+		return &mmclient.WSEvent{
+			Type: mmclient.EventPostEdited,
+			Data:  map[string]interface{}{"post": string(postBytes)},
+		}
+	}
+
+	bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPosted, "post1", "!poll vot p1 A"))
+	bot.handleWebSocketEvent(context.Background(), edit("!poll vote p1 A", 100))
+	bot.handleWebSocketEvent(context.Background(), edit("!poll vote p1 A", 100))
+	mockHandler.AssertNumberOfCalls(t, "HandleCommand", 2)
+
+	bot.handleWebSocketEvent(context.Background(), edit("!poll vote p1 B", 200))
+	mockHandler.AssertNumberOfCalls(t, "HandleCommand", 3)
+}
+
+// TestIsChannelMember проверяет разбор ответа Mattermost о членстве в канале.
+func TestIsChannelMember(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantMember bool
+		wantErr    bool
+	}{
+		{name: "member", wantMember: true},
+		{name: "not a member", err: &mmclient.Error{StatusCode: http.StatusNotFound, Err: errors.New("not found")}},
+		{name: "API error", err: &mmclient.Error{StatusCode: http.StatusForbidden, Err: errors.New("forbidden")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := &Bot{client: &fakeClient{
+				getMemberFunc: func(channelID, userID string) (*mmclient.ChannelMember, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &mmclient.ChannelMember{ChannelID: channelID, UserID: userID}, nil
+				},
+			}}
+
+			member, err := bot.IsChannelMember(context.Background(), "c1", "user1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Неожиданная ошибка: %v", err)
+			}
+			if member != tt.wantMember {
+				t.Errorf("Ожидалось членство %v, получено: %v", tt.wantMember, member)
+			}
+		})
+	}
+}
+
+// TestFindUser проверяет поиск пользователя по имени и признак бота.
+func TestFindUser(t *testing.T) {
+	bot := &Bot{client: &fakeClient{
+		getByNameFunc: func(username string) (*mmclient.User, error) {
+			switch username {
+			case "ivan":
+				return &mmclient.User{ID: "u1", Username: "ivan"}, nil
+			case "helper":
+				return &mmclient.User{ID: "b1", Username: "helper", IsBot: true}, nil
+			}
+			return nil, &mmclient.Error{StatusCode: http.StatusNotFound, Err: errors.New("not found")}
+		},
+	}}
+
+	user, found, err := bot.FindUser(context.Background(), "ivan")
+	if err != nil || !found || user.ID != "u1" || user.IsBot {
+		t.Errorf("Ожидался пользователь u1, получено: %+v, %v, %v", user, found, err)
+	}
+	user, found, err = bot.FindUser(context.Background(), "helper")
+	if err != nil || !found || !user.IsBot {
+		t.Errorf("Ожидался бот helper, получено: %+v, %v, %v", user, found, err)
+	}
+	if _, found, err = bot.FindUser(context.Background(), "nobody"); err != nil || found {
+		t.Errorf("Несуществующий пользователь не должен находиться: %v, %v", found, err)
+	}
+}
+
+// TestSendDirect проверяет, что личное сообщение уходит в личный канал бота с пользователем.
+func TestSendDirect(t *testing.T) {
+	var posted *mmclient.Post
+	bot := &Bot{
+		botUser: &mmclient.User{ID: "bot123"},
+		client: &fakeClient{
+			createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+				posted = post
+				return post, nil
+			},
+		},
+	}
+
+	if err := bot.SendDirect(context.Background(), "u1", "Привет"); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if posted == nil || posted.ChannelID != "bot123__u1" || posted.Message != "Привет" {
+		t.Errorf("Сообщение отправлено не в личный канал: %+v", posted)
+	}
+}
+
+// TestHandleWebSocketEvent_Ephemeral проверяет, что ответ с Ephemeral и ошибка
+// команды видны только автору, а при отказе Mattermost уходят в канал.
+func TestHandleWebSocketEvent_Ephemeral(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      handler.Response
+		err           error
+		ephemeralFail bool
+		wantEphemeral []string
+		wantPublic    []string
+	}{
+		{
+			name:       "public result",
+			response:   handler.Response{Text: "Итоги"},
+			wantPublic: []string{"Итоги"},
+		},
+		{
+			name:          "usage",
+			response:      handler.Response{Text: "Формат", Ephemeral: true},
+			wantEphemeral: []string{"Формат"},
+		},
+		{
+			name:          "command error",
+			err:           i18n.NewError(i18n.PollNotFound),
+			wantEphemeral: []string{"Ошибка при выполнении команды: опрос не найден"},
+		},
+		{
+			name:          "fallback",
+			response:      handler.Response{Text: "Формат", Ephemeral: true},
+			ephemeralFail: true,
+			wantPublic:    []string{"Формат"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockHandler := new(MockCommandHandler)
+			mockHandler.On("ParseCommand", "!poll results p1").Return("results", []string{"p1"}, true, nil)
+			mockHandler.On("HandleCommand", mock.Anything, "results", []string{"p1"}, "user123").Return(tt.response, tt.err)
+
+			var ephemeral, public []string
+			fc := &fakeClient{
+				createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+					public = append(public, post.Message)
+					return post, nil
+				},
+				ephemeralFunc: func(userID string, post *mmclient.Post) (*mmclient.Post, error) {
+					if tt.ephemeralFail {
+						return nil, &mmclient.Error{StatusCode: http.StatusNotFound, Err: errors.New("not found")}
+					}
+					if userID != "user123" || post.ChannelID != "c1" {
+						t.Errorf("Эфемерное сообщение адресовано не автору %s: %+v", userID, post)
+					}
+					ephemeral = append(ephemeral, post.Message)
+					return post, nil
+				},
+			}
+			bot := &Bot{
+				commandHandler: mockHandler,
+				logger:         zerolog.Nop(),
+				botUser:        &mmclient.User{ID: "bot123"},
+				client:         fc,
+				localizer:      i18n.New("ru"),
+			}
+
+			bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPosted, "post1", "!poll results p1"))
+
+			if fmt.Sprint(ephemeral) != fmt.Sprint(tt.wantEphemeral) || fmt.Sprint(public) != fmt.Sprint(tt.wantPublic) {
+				t.Errorf("Ожидались эфемерные %q и публичные %q, получено %q и %q", tt.wantEphemeral, tt.wantPublic, ephemeral, public)
+			}
+		})
+	}
+}
+
+// TestHandleWebSocketEvent_MalformedCommand проверяет, что команда с незакрытой
+// кавычкой не выполняется, а автор получает ошибку с форматом команды на своём языке.
+func TestHandleWebSocketEvent_MalformedCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    string
+		message string
+		want    string
+	}{
+		{
+			name:    "unclosed quote",
+			lang:    "ru",
+			message: `!poll create "Q "A"`,
+			want:    `Ошибка при выполнении команды: незакрытая кавычка в команде. Формат: !poll create "Вопрос" "Опция 1" "Опция 2"...`,
+		},
+		{
+			name:    "trailing escape",
+			lang:    "en",
+			message: `!poll vote p1 "A\`,
+			want:    `Command failed: unclosed quote in the command: nothing follows \. Usage: !poll vote "Poll ID" "Choice"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ephemeral []string
+			fc := &fakeClient{
+				createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+					t.Errorf("Ошибка команды опубликована в канале: %q", post.Message)
+					return post, nil
+				},
+				ephemeralFunc: func(userID string, post *mmclient.Post) (*mmclient.Post, error) {
+					ephemeral = append(ephemeral, post.Message)
+					return post, nil
+				},
+			}
+			bot := &Bot{
+				commandHandler: handler.NewPollCommandHandler(nil, i18n.New(tt.lang), handler.DefaultCommandPrefix),
+				logger:         zerolog.Nop(),
+				botUser:        &mmclient.User{ID: "bot123"},
+				client:         fc,
+				localizer:      i18n.New(tt.lang),
+			}
+
+			bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPosted, "post1", tt.message))
+
+			if fmt.Sprint(ephemeral) != fmt.Sprint([]string{tt.want}) {
+				t.Errorf("Ожидалось %q, получено %q", tt.want, ephemeral)
+			}
+		})
+	}
+}
+
+// TestHandleWebSocketEvent_Reactions проверяет реакцию на команду по её итогу
+// и замену подтверждения голоса реакцией.
+func TestHandleWebSocketEvent_Reactions(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.Config
+		executable    bool
+		response      handler.Response
+		err           error
+		reactionFails bool
+		wantEmoji     []string
+		wantPosts     int
+	}{
+		{
+			name:       "success",
+			cfg:        config.Config{Reactions: true},
+			executable: true,
+			response:   handler.Response{Text: "Голос учтён", Quiet: true},
+			wantEmoji:  []string{"white_check_mark"},
+			wantPosts:  1,
+		},
+		{
+			name:       "failure",
+			cfg:        config.Config{Reactions: true},
+			executable: true,
+			err:        i18n.NewError(i18n.PollNotFound),
+			wantEmoji:  []string{"x"},
+			wantPosts:  1,
+		},
+		{
+			name:      "usage",
+			cfg:       config.Config{Reactions: true},
+			response:  handler.Response{Text: "Формат"},
+			wantEmoji: []string{"x"},
+			wantPosts: 1,
+		},
+		{
+			name:       "reaction instead of reply",
+			cfg:        config.Config{Reactions: true, ReactionsOnly: true},
+			executable: true,
+			response:   handler.Response{Text: "Голос учтён", Quiet: true},
+			wantEmoji:  []string{"white_check_mark"},
+		},
+		{
+			name:       "informative reply stays",
+			cfg:        config.Config{Reactions: true, ReactionsOnly: true},
+			executable: true,
+			response:   handler.Response{Text: "Итоги"},
+			wantEmoji:  []string{"white_check_mark"},
+			wantPosts:  1,
+		},
+		{
+			name:          "reply when reaction fails",
+			cfg:           config.Config{Reactions: true, ReactionsOnly: true},
+			executable:    true,
+			response:      handler.Response{Text: "Голос учтён", Quiet: true},
+			reactionFails: true,
+			wantPosts:     1,
+		},
+		{
+			name:       "disabled",
+			cfg:        config.Config{},
+			executable: true,
+			response:   handler.Response{Text: "Голос учтён", Quiet: true},
+			wantPosts:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockHandler := new(MockCommandHandler)
+			mockHandler.On("ParseCommand", "!poll vote p1 A").Return("vote", []string{"p1", "A"}, true, nil)
+			mockHandler.On("HandleCommand", mock.Anything, "vote", []string{"p1", "A"}, "user123").Return(tt.response, tt.err)
+
+			var emoji []string
+			posts := 0
+			fc := &fakeClient{
+				createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+					posts++
+					return post, nil
+				},
+				reactionFunc: func(reaction *mmclient.Reaction) (*mmclient.Reaction, error) {
+					if tt.reactionFails {
+						return nil, &mmclient.Error{StatusCode: http.StatusForbidden, Err: errors.New("forbidden")}
+					}
+					if reaction.PostID != "post1" || reaction.UserID != "bot123" {
+						t.Errorf("Реакция поставлена не на то сообщение: %+v", reaction)
+					}
+					emoji = append(emoji, reaction.EmojiName)
+					return reaction, nil
+				},
+			}
+			bot := &Bot{
+				cfg:            tt.cfg,
+				commandHandler: &checkingHandler{MockCommandHandler: mockHandler, executable: map[string]bool{"vote": tt.executable}},
+				logger:         zerolog.Nop(),
+				botUser:        &mmclient.User{ID: "bot123"},
+				client:         fc,
+				localizer:      i18n.New("ru"),
+			}
+
+			bot.handleWebSocketEvent(context.Background(), postEvent(mmclient.EventPosted, "post1", "!poll vote p1 A"))
+
+			if fmt.Sprint(emoji) != fmt.Sprint(tt.wantEmoji) {
+				t.Errorf("Ожидались реакции %q, получено %q", tt.wantEmoji, emoji)
+			}
+			if posts != tt.wantPosts {
+				t.Errorf("Ожидалось ответов: %d, получено: %d", tt.wantPosts, posts)
+			}
+		})
+	}
+}
+
+// TestPinnedPoll проверяет закрепление сообщения опроса с --pin и открепление
+// при завершении и удалении через настоящий сервис опросов.
+func TestPinnedPoll(t *testing.T) {
+	tests := []struct {
+		name      string
+		pinStatus int
+		finish    func(s *service.PollServiceImpl, ctx context.Context, pollID string) error
+		wantReply string
+		wantUnpin bool
+	}{
+		{
+			name: "end",
+			finish: func(s *service.PollServiceImpl, ctx context.Context, pollID string) error {
+				_, err := s.EndPoll(ctx, "user1", pollID)
+				return err
+			},
+			wantReply: "опубликован и закреплён",
+			wantUnpin: true,
+		},
+		{
+			name: "delete",
+			finish: func(s *service.PollServiceImpl, ctx context.Context, pollID string) error {
+				_, err := s.DeletePoll(ctx, "user1", pollID)
+				return err
+			},
+			wantReply: "опубликован и закреплён",
+			wantUnpin: true,
+		},
+		{
+			name:      "permission denied",
+			pinStatus: http.StatusForbidden,
+			finish: func(s *service.PollServiceImpl, ctx context.Context, pollID string) error {
+				_, err := s.EndPoll(ctx, "user1", pollID)
+				return err
+			},
+			wantReply: "закрепить его не удалось",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted []*mmclient.Post
+			var pinned, unpinned []string
+			fc := &fakeClient{
+				createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+					post.ID = fmt.Sprintf("post%d", len(posted)+1)
+					posted = append(posted, post)
+					return post, nil
+				},
+				pinFunc: func(postID string) error {
+					if tt.pinStatus != 0 {
+						return &mmclient.Error{StatusCode: tt.pinStatus, Err: errors.New("forbidden")}
+					}
+					pinned = append(pinned, postID)
+					return nil
+				},
+				unpinFunc: func(postID string) error {
+					unpinned = append(unpinned, postID)
+					return nil
+				},
+			}
+			bot := &Bot{client: fc, logger: zerolog.Nop(), botUser: &mmclient.User{ID: "bot123"}}
+
+			repo := repository.NewMemoryPollRepo()
+			s := service.NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.SetPinner(bot)
+			ctx := service.WithOrigin(context.Background(), service.Origin{PostID: "cmd1", ChannelID: "channel1"})
+
+			created, err := s.CreatePollWithID(ctx, "user1", "Обед?", []string{"Пицца", "Суши"}, service.CreateOptions{Pin: true})
+			if err != nil {
+				t.Fatalf("Опрос не создан: %v", err)
+			}
+			if !strings.Contains(created.Message, tt.wantReply) {
+				t.Errorf("Ожидался ответ с %q, получено: %q", tt.wantReply, created.Message)
+			}
+			if len(posted) != 1 || posted[0].ChannelID != "channel1" || !strings.Contains(posted[0].Message, "Обед?") {
+				t.Fatalf("Сообщение опроса не опубликовано в канале: %+v", posted)
+			}
+
+			poll, err := repo.GetPoll(ctx, created.ID)
+			if err != nil {
+				t.Fatalf("Опрос не сохранён: %v", err)
+			}
+			wantPinned := ""
+			if tt.pinStatus == 0 {
+				wantPinned = "post1"
+				if fmt.Sprint(pinned) != "[post1]" {
+					t.Errorf("Ожидалось закрепление post1, получено %q", pinned)
+				}
+			}
+			if poll.PinnedPostID != wantPinned {
+				t.Errorf("Ожидалось закреплённое сообщение %q, сохранено %q", wantPinned, poll.PinnedPostID)
+			}
+
+			if err := tt.finish(s, ctx, created.ID); err != nil {
+				t.Fatalf("Опрос не завершён: %v", err)
+			}
+			if tt.wantUnpin && fmt.Sprint(unpinned) != "[post1]" {
+				t.Errorf("Ожидалось открепление post1, получено %q", unpinned)
+			}
+			if !tt.wantUnpin && len(unpinned) != 0 {
+				t.Errorf("Незакреплённое сообщение не откреплять: %q", unpinned)
+			}
+		})
+	}
+}
+
+// TestEditPost проверяет, что правка сообщения меняет только его текст.
+func TestEditPost(t *testing.T) {
+	var updated *mmclient.Post
+	fc := &fakeClient{
+		updatePostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			updated = post
+			return post, nil
+		},
+	}
+	bot := &Bot{client: fc, logger: zerolog.Nop()}
+	if err := bot.EditPost(context.Background(), "post1", "Новый текст"); err != nil {
+		t.Fatalf("Ожидалась правка без ошибки, получено: %v", err)
+	}
+	if updated == nil || updated.ID != "post1" || updated.Message != "Новый текст" {
+		t.Errorf("Ожидалась правка сообщения post1, получено: %+v", updated)
+	}
+}
+
+// TestUnpinPost_Deleted проверяет, что открепление удалённого сообщения
+// не считается ошибкой.
+func TestUnpinPost_Deleted(t *testing.T) {
+	fc := &fakeClient{
+		unpinFunc: func(postID string) error {
+			return &mmclient.Error{StatusCode: http.StatusNotFound, Err: errors.New("not found")}
+		},
+	}
+	bot := &Bot{client: fc, logger: zerolog.Nop()}
+	if err := bot.UnpinPost(context.Background(), "post1"); err != nil {
+		t.Errorf("Ожидалось открепление без ошибки, получено: %v", err)
+	}
+
+	fc.unpinFunc = func(postID string) error {
+		return &mmclient.Error{StatusCode: http.StatusForbidden, Err: errors.New("forbidden")}
+	}
+	if err := bot.UnpinPost(context.Background(), "post1"); err == nil {
+		t.Error("Ожидалась ошибка открепления без прав")
+	}
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const (
+	// Сколько пользователей запрашивать у Mattermost одним запросом
+	usersBatchSize = 100
+	// Сколько запросов пользователей выполнять одновременно
+	usersConcurrency = 4
+)
+
+// DeactivatedUsers проверяет пользователей в Mattermost и возвращает
+// деактивированных. Большой список запрашивается частями по
+// usersBatchSize, не больше usersConcurrency запросов одновременно; сбой
+// любой части - сбой всей проверки, чтобы итоги не исключили только часть
+// деактивированных. Пользователя, которого Mattermost не нашёл, проверить
+// не по чему, и он не считается деактивированным.
+func (b *Bot) DeactivatedUsers(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	ids := slices.Clone(userIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	var (
+		mu          sync.Mutex
+		firstErr    error
+		deactivated = make(map[string]bool)
+		wg          sync.WaitGroup
+	)
+	slots := make(chan struct{}, usersConcurrency)
+	for batch := range slices.Chunk(ids, usersBatchSize) {
+		slots <- struct{}{}
+		if ctx.Err() != nil {
+			<-slots
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			users, err := b.client.GetUsersByIds(batch)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("ошибка получения %d пользователей: %w", len(batch), err)
+				}
+				return
+			}
+			for _, user := range users {
+				if user.Deactivated {
+					deactivated[user.ID] = true
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return deactivated, nil
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"polling_bot/internal/mmclient"
+)
+
+// TestDeactivatedUsers проверяет, что большой список участников
+// запрашивается частями без повторов и не больше usersConcurrency
+// запросами одновременно, а в ответ попадают только деактивированные.
+func TestDeactivatedUsers(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		batches  []int
+		inFlight atomic.Int32
+		peak     atomic.Int32
+	)
+	client := &fakeClient{getUsersFunc: func(userIDs []string) ([]*mmclient.User, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := peak.Load()
+			if n <= seen || peak.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		batches = append(batches, len(userIDs))
+		mu.Unlock()
+
+		users := make([]*mmclient.User, 0, len(userIDs))
+		for _, userID := range userIDs {
+			// Каждый десятый деактивирован, а u1 в Mattermost уже нет
+			if userID == "u1" {
+				continue
+			}
+			users = append(users, &mmclient.User{ID: userID, Deactivated: strings.HasSuffix(userID, "0")})
+		}
+		return users, nil
+	}}
+	b := &Bot{client: client}
+
+	userIDs := []string{"u1"}
+	for i := range 1000 {
+		userIDs = append(userIDs, fmt.Sprintf("u%d", i))
+	}
+	deactivated, err := b.DeactivatedUsers(context.Background(), userIDs)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if len(deactivated) != 100 || !deactivated["u10"] || deactivated["u11"] {
+		t.Errorf("Деактивированы %d участников, ожидалось 100 с u10 и без u11", len(deactivated))
+	}
+	total := 0
+	for _, n := range batches {
+		if n > usersBatchSize {
+			t.Errorf("Запрос на %d пользователей, ожидалось не больше %d", n, usersBatchSize)
+		}
+		total += n
+	}
+	if total != 1000 {
+		t.Errorf("Запрошено %d пользователей, ожидалось 1000 без повторов", total)
+	}
+	if p := peak.Load(); p > usersConcurrency {
+		t.Errorf("Одновременно %d запросов, ожидалось не больше %d", p, usersConcurrency)
+	}
+}
+
+// TestDeactivatedUsers_Error проверяет, что сбой одной части - сбой всей
+// проверки.
+func TestDeactivatedUsers_Error(t *testing.T) {
+	client := &fakeClient{getUsersFunc: func(userIDs []string) ([]*mmclient.User, error) {
+		if userIDs[0] == "u000" {
+			return nil, errors.New("timeout")
+		}
+		return []*mmclient.User{{ID: userIDs[0], Deactivated: true}}, nil
+	}}
+	b := &Bot{client: client}
+
+	userIDs := make([]string, 0, 300)
+	for i := range 300 {
+		userIDs = append(userIDs, fmt.Sprintf("u%03d", i))
+	}
+	deactivated, err := b.DeactivatedUsers(context.Background(), userIDs)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("Ожидалась ошибка с причиной timeout, получено: %v", err)
+	}
+	if deactivated != nil {
+		t.Errorf("При ошибке деактивированные не возвращаются, получено: %v", deactivated)
+	}
+}
@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+)
+
+// Digester готовит ежедневные сводки открытых опросов каналов, если в
+// минуту at наступает время сводки.
+type Digester interface {
+	RunDigests(ctx context.Context, at time.Time) []service.Digest
+}
+
+// SetDigester включает сводки: после запуска бот в начале каждой минуты
+// спрашивает digester о сводках и публикует их в каналах.
+func (b *Bot) SetDigester(digester Digester) {
+	b.digester = digester
+}
+
+// publishDigest публикует сводку в канале. Как и сообщения о сроке,
+// сводка не повторяется: недоступный канал только отмечается в логе.
+func (b *Bot) publishDigest(digest service.Digest) {
+	_, err := b.client.CreatePost(&mmclient.Post{ChannelID: digest.ChannelID, Message: digest.Message})
+	switch {
+	case err == nil:
+	case channelUnreachable(err):
+		b.logger.Warn().Err(err).Str("channel_id", digest.ChannelID).Msg("Бот не может писать в канал, сводка опросов не опубликована")
+	default:
+		b.logger.Error().Err(err).Str("channel_id", digest.ChannelID).Msg("Не удалось опубликовать сводку опросов")
+	}
+}
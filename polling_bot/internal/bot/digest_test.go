@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+
+	"github.com/rs/zerolog"
+)
+
+type fakeDigester struct {
+	calls   chan time.Time
+	digests map[time.Time][]service.Digest
+}
+
+func (d *fakeDigester) RunDigests(ctx context.Context, at time.Time) []service.Digest {
+	d.calls <- at
+	return d.digests[at]
+}
+
+// TestRunScheduler_Digest проверяет, что планировщик запускается ради одних
+// сводок, спрашивает о них в начале каждой минуты и публикует сводки в их
+// каналах.
+func TestRunScheduler_Digest(t *testing.T) {
+	posts := make(chan *mmclient.Post, 10)
+	bot, _ := NewBot(config.Config{MattermostURL: "http://dummy", BotToken: "dummy"}, zerolog.Nop(), new(MockCommandHandler))
+	bot.client = &fakeClient{
+		getMeFunc: func() (*mmclient.User, error) {
+			return &mmclient.User{ID: "bot123"}, nil
+		},
+		createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			posts <- post
+			return post, nil
+		},
+	}
+	bot.cfg.Mode = config.ModeWebhook
+	bot.cfg.WebhookAddr = "127.0.0.1:0"
+
+	clock := newFakeClock(time.Date(2025, 3, 3, 8, 59, 30, 0, time.UTC))
+	bot.clock, bot.after = clock.Now, clock.After
+	nine := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	digester := &fakeDigester{
+		calls: make(chan time.Time, 10),
+		digests: map[time.Time][]service.Digest{
+			nine: {{ChannelID: "c1", Message: "Открытые опросы c1"}, {ChannelID: "c2", Message: "Открытые опросы c2"}},
+		},
+	}
+	bot.SetDigester(digester)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = bot.Start(ctx)
+		close(done)
+	}()
+
+	<-clock.waits
+	clock.advance(nine)
+	if at := <-digester.calls; !at.Equal(nine) {
+		t.Errorf("Ожидался запрос сводок за 09:00, получено %v", at)
+	}
+	for _, want := range []string{"c1", "c2"} {
+		if post := <-posts; post.ChannelID != want || post.Message != "Открытые опросы "+want {
+			t.Errorf("Сводка опубликована не так: канал %q, сообщение %q", post.ChannelID, post.Message)
+		}
+	}
+
+	<-clock.waits
+	clock.advance(nine.Add(time.Minute))
+	<-digester.calls
+	<-clock.waits
+	select {
+	case post := <-posts:
+		t.Errorf("В 09:01 опубликовано лишнее сообщение в канал %q", post.ChannelID)
+	default:
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Бот не остановился после отмены контекста")
+	}
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"encoding/json"
+	"sort"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+)
+
+// Причины пропуска события WebSocket; по ним же названы счётчики
+// bot_events_skipped_total.<причина>
+const (
+	// Событие не о сообщении и не о добавлении в канал
+	skipEventType = "event_type"
+	// В событии нет сообщения: системные события, события с одним sender_name
+	skipNoPost = "no_post"
+	// Сообщение пришло не строкой JSON и не объектом
+	skipPostType = "post_type"
+	// Сообщение не разбирается как JSON
+	skipPostJSON = "post_json"
+	// В канал добавили не бота: Mattermost рассылает это событие всем
+	// участникам канала
+	skipOtherUser = "other_user"
+)
+
+// decodePost извлекает сообщение из данных события. Mattermost передаёт
+// его строкой с JSON, но некоторые версии сервера и прокси присылают
+// уже разобранный объект; принимаются оба вида. Если сообщения нет,
+// вместо него возвращается причина пропуска события.
+func decodePost(data map[string]interface{}) (*mmclient.Post, string) {
+	raw, ok := data["post"]
+	if !ok || raw == nil {
+		return nil, skipNoPost
+	}
+
+	var encoded []byte
+	switch v := raw.(type) {
+	case string:
+		encoded = []byte(v)
+	case map[string]interface{}:
+		var err error
+		if encoded, err = json.Marshal(v); err != nil {
+			return nil, skipPostJSON
+		}
+	default:
+		return nil, skipPostType
+	}
+
+	var post *mmclient.Post
+	if err := json.Unmarshal(encoded, &post); err != nil || post == nil {
+		return nil, skipPostJSON
+	}
+	return post, ""
+}
+
+// skipEvent учитывает пропущенное событие и пишет в журнал одну строку
+// с причиной и ключами данных события: по ним видно, какой формат
+// событий прислал сервер. Значения в журнал не попадают.
+func (b *Bot) skipEvent(event *mmclient.WSEvent, data map[string]interface{}, reason string) {
+	metrics.Stats.Counter("bot_events_skipped_total." + reason).Add(1)
+	b.logger.Debug().
+		Str("event", event.Type).
+		Str("reason", reason).
+		Strs("keys", dataKeys(data)).
+		Msg("Событие пропущено")
+}
+
+func dataKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
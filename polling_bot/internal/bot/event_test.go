@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+// loadEvent читает событие WebSocket, записанное с сервера Mattermost.
+func loadEvent(t *testing.T, name string) *mmclient.WSEvent {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "events", name))
+	if err != nil {
+		t.Fatalf("Не удалось прочитать событие %s: %v", name, err)
+	}
+	event, err := mmclient.ParseWSEvent(data)
+	if err != nil {
+		t.Fatalf("Событие %s не разбирается: %v", name, err)
+	}
+	return event
+}
+
+// TestHandleWebSocketEvent_Payloads проверяет разбор событий разных версий
+// сервера: сообщение строкой JSON и объектом доходит до обработчика, а
+// события без сообщения пропускаются с причиной в журнале и счётчике.
+func TestHandleWebSocketEvent_Payloads(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		wantParsed bool
+		wantReason string
+		wantKeys   []string
+	}{
+		{fixture: "mm5_posted.json", wantParsed: true},
+		{fixture: "mm9_posted.json", wantParsed: true},
+		{fixture: "post_edited.json", wantParsed: true},
+		{fixture: "post_object.json", wantParsed: true},
+		{fixture: "sender_name_only.json", wantReason: skipNoPost, wantKeys: []string{"channel_type", "sender_name", "team_id"}},
+		{fixture: "system_no_post.json", wantReason: skipNoPost, wantKeys: []string{"channel_display_name", "channel_name", "channel_type", "team_id"}},
+		{fixture: "post_number.json", wantReason: skipPostType, wantKeys: []string{"channel_type", "post", "team_id"}},
+		{fixture: "post_broken_json.json", wantReason: skipPostJSON, wantKeys: []string{"channel_type", "post", "team_id"}},
+		{fixture: "typing.json", wantReason: skipEventType, wantKeys: []string{"parent_id", "user_id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			mockHandler := new(MockCommandHandler)
+			mockHandler.On("ParseCommand", "!poll help").Return("", []string{}, false, nil)
+			var logs bytes.Buffer
+			bot := &Bot{
+				commandHandler: mockHandler,
+				logger:         zerolog.New(&logs),
+				botUser:        &mmclient.User{ID: "bot123"},
+				client:         &fakeClient{},
+			}
+			var skipped *metrics.Counter
+			var before int64
+			if tt.wantReason != "" {
+				skipped = metrics.Stats.Counter("bot_events_skipped_total." + tt.wantReason)
+				before = skipped.Value()
+			}
+
+			bot.handleWebSocketEvent(context.Background(), loadEvent(t, tt.fixture))
+
+			if tt.wantParsed {
+				mockHandler.AssertCalled(t, "ParseCommand", "!poll help")
+				if strings.Contains(logs.String(), "Событие пропущено") {
+					t.Errorf("Событие не должно пропускаться: %s", logs.String())
+				}
+				return
+			}
+			mockHandler.AssertNotCalled(t, "ParseCommand", mock.Anything)
+			if got := skipped.Value() - before; got != 1 {
+				t.Errorf("Ожидался один пропуск по причине %s, получено: %d", tt.wantReason, got)
+			}
+
+			lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+			if len(lines) != 1 {
+				t.Fatalf("Ожидалась одна строка в журнале, получено: %q", lines)
+			}
+			var entry struct {
+				Level   string   `json:"level"`
+				Event   string   `json:"event"`
+				Reason  string   `json:"reason"`
+				Keys    []string `json:"keys"`
+				Message string   `json:"message"`
+			}
+			if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+				t.Fatalf("Запись журнала не разбирается: %v", err)
+			}
+			if entry.Level != "debug" || entry.Message != "Событие пропущено" {
+				t.Errorf("Ожидалась отладочная запись о пропуске, получено: %s", lines[0])
+			}
+			if entry.Reason != tt.wantReason {
+				t.Errorf("Ожидалась причина %s, получено: %s", tt.wantReason, entry.Reason)
+			}
+			if entry.Event == "" {
+				t.Errorf("В записи нет типа события: %s", lines[0])
+			}
+			if strings.Join(entry.Keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("Ожидались ключи %v, получено: %v", tt.wantKeys, entry.Keys)
+			}
+			if strings.Contains(lines[0], "@alice") {
+				t.Errorf("Значения данных события не должны попадать в журнал: %s", lines[0])
+			}
+		})
+	}
+}
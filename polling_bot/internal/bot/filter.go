@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"strings"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/mmclient"
+)
+
+// channelFilter отбрасывает события из каналов, в которых бот не должен
+// отвечать, до разбора сообщения: на больших серверах бот состоит в сотнях
+// каналов и получает каждое сообщение в них. Личные сообщения боту
+// проходят всегда.
+type channelFilter struct {
+	allowedChannels map[string]bool
+	blockedChannels map[string]bool
+	allowedTeams    map[string]bool
+	// В каналах обрабатываются только сообщения с упоминанием бота
+	requireMention bool
+}
+
+// newChannelFilter возвращает nil, если фильтры не настроены: тогда
+// проверка не нужна вовсе.
+func newChannelFilter(cfg config.Config) *channelFilter {
+	if len(cfg.AllowedChannelIDs) == 0 && len(cfg.BlockedChannelIDs) == 0 && len(cfg.AllowedTeamIDs) == 0 && !cfg.RequireMention {
+		return nil
+	}
+	return &channelFilter{
+		allowedChannels: idSet(cfg.AllowedChannelIDs),
+		blockedChannels: idSet(cfg.BlockedChannelIDs),
+		allowedTeams:    idSet(cfg.AllowedTeamIDs),
+		requireMention:  cfg.RequireMention,
+	}
+}
+
+func idSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// allows решает, обрабатывать ли сообщение из канала. Запрещённый канал
+// не проходит никогда; если заданы разрешённые каналы или команды, канал
+// должен быть среди первых или принадлежать одной из вторых.
+func (f *channelFilter) allows(channelID, teamID string, mentioned bool) bool {
+	if f.blockedChannels[channelID] {
+		return false
+	}
+	if len(f.allowedChannels) > 0 || len(f.allowedTeams) > 0 {
+		if !f.allowedChannels[channelID] && !f.allowedTeams[teamID] {
+			return false
+		}
+	}
+	return !f.requireMention || mentioned
+}
+
+// addressed проверяет событие о сообщении по полям самого события, не
+// разбирая сообщение: канал берётся из адресатов события, команда и
+// упоминания - из его данных.
+func (b *Bot) addressed(event *mmclient.WSEvent, data map[string]interface{}) bool {
+	if b.filter == nil {
+		return true
+	}
+	if channelType, _ := data["channel_type"].(string); channelType == mmclient.ChannelDirect {
+		return true
+	}
+	channelID := event.ChannelID
+	teamID, _ := data["team_id"].(string)
+	// mentions - JSON-массив ID упомянутых пользователей
+	mentions, _ := data["mentions"].(string)
+	mentioned := b.botUser != nil && strings.Contains(mentions, `"`+b.botUser.ID+`"`)
+	return b.filter.allows(channelID, teamID, mentioned)
+}
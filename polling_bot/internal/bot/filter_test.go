@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/handler"
+	"polling_bot/internal/mmclient"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// channelEvent строит событие о сообщении в канале channelID команды teamID
+// с теми полями, которые Mattermost передаёт вместе с сообщением.
+func channelEvent(channelID, teamID, channelType string, mentions []string) *mmclient.WSEvent {
+	postBytes, _ := json.Marshal(&mmclient.Post{ID: "post1", ChannelID: channelID, UserID: "user123", Message: "!poll results p1"})
+	data := map[string]interface{}{
+		"post":         string(postBytes),
+		"team_id":      teamID,
+		"channel_type": channelType,
+	}
+	if mentions != nil {
+		raw, _ := json.Marshal(mentions)
+		data["mentions"] = string(raw)
+	}
+	return &mmclient.WSEvent{Type: mmclient.EventPosted, ChannelID: channelID, Data: data}
+}
+
+// TestNewChannelFilter_Disabled проверяет, что newChannelFilter не создаёт фильтр без настроек.
+func TestNewChannelFilter_Disabled(t *testing.T) {
+	assert.Nil(t, newChannelFilter(config.Config{}))
+	assert.NotNil(t, newChannelFilter(config.Config{RequireMention: true}))
+}
+
+// TestHandleWebSocketEvent_ChannelFilter проверяет, что отфильтрованные
+// события не доходят до обработчика команд, а разрешённые и личные
+// сообщения обрабатываются.
+func TestHandleWebSocketEvent_ChannelFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		channelID   string
+		teamID      string
+		channelType string
+		mentions    []string
+		wantCalls   int
+	}{
+		{
+			name:        "allowed channel",
+			cfg:         config.Config{AllowedChannelIDs: []string{"c1"}},
+			channelID:   "c1",
+			channelType: mmclient.ChannelOpen,
+			wantCalls:   1,
+		},
+		{
+			name:        "channel not in allow list",
+			cfg:         config.Config{AllowedChannelIDs: []string{"c1"}},
+			channelID:   "c2",
+			channelType: mmclient.ChannelOpen,
+		},
+		{
+			name:        "blocked channel",
+			cfg:         config.Config{BlockedChannelIDs: []string{"c1"}},
+			channelID:   "c1",
+			channelType: mmclient.ChannelOpen,
+		},
+		{
+			name:        "blocked channel of an allowed team",
+			cfg:         config.Config{AllowedTeamIDs: []string{"t1"}, BlockedChannelIDs: []string{"c1"}},
+			channelID:   "c1",
+			teamID:      "t1",
+			channelType: mmclient.ChannelOpen,
+		},
+		{
+			name:        "channel of an allowed team",
+			cfg:         config.Config{AllowedTeamIDs: []string{"t1"}},
+			channelID:   "c2",
+			teamID:      "t1",
+			channelType: mmclient.ChannelPrivate,
+			wantCalls:   1,
+		},
+		{
+			name:        "channel of another team",
+			cfg:         config.Config{AllowedTeamIDs: []string{"t1"}},
+			channelID:   "c2",
+			teamID:      "t2",
+			channelType: mmclient.ChannelOpen,
+		},
+		{
+			name:        "allowed channel outside allowed teams",
+			cfg:         config.Config{AllowedChannelIDs: []string{"c2"}, AllowedTeamIDs: []string{"t1"}},
+			channelID:   "c2",
+			teamID:      "t2",
+			channelType: mmclient.ChannelOpen,
+			wantCalls:   1,
+		},
+		{
+			name:        "mention required but missing",
+			cfg:         config.Config{RequireMention: true},
+			channelID:   "c1",
+			channelType: mmclient.ChannelOpen,
+			mentions:    []string{"other"},
+		},
+		{
+			name:        "mention required and present",
+			cfg:         config.Config{RequireMention: true},
+			channelID:   "c1",
+			channelType: mmclient.ChannelOpen,
+			mentions:    []string{"other", "bot123"},
+			wantCalls:   1,
+		},
+		{
+			name:        "direct message passes every filter",
+			cfg:         config.Config{AllowedChannelIDs: []string{"c1"}, BlockedChannelIDs: []string{"dm"}, RequireMention: true},
+			channelID:   "dm",
+			channelType: mmclient.ChannelDirect,
+			wantCalls:   1,
+		},
+		{
+			name:        "no filters",
+			channelID:   "c9",
+			channelType: mmclient.ChannelOpen,
+			wantCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockHandler := new(MockCommandHandler)
+			if tt.wantCalls > 0 {
+				mockHandler.On("ParseCommand", "!poll results p1").Return("results", []string{"p1"}, true, nil)
+				mockHandler.On("HandleCommand", mock.Anything, "results", []string{"p1"}, "user123").Return(handler.Response{Text: "ok"}, nil)
+			}
+			bot := &Bot{
+				commandHandler: mockHandler,
+				logger:         zerolog.Nop(),
+				botUser:        &mmclient.User{ID: "bot123"},
+				client:         &fakeClient{},
+				filter:         newChannelFilter(tt.cfg),
+			}
+
+			bot.handleWebSocketEvent(context.Background(), channelEvent(tt.channelID, tt.teamID, tt.channelType, tt.mentions))
+
+			// Отфильтрованное сообщение не доходит даже до разбора команды
+			mockHandler.AssertNumberOfCalls(t, "ParseCommand", tt.wantCalls)
+			mockHandler.AssertNumberOfCalls(t, "HandleCommand", tt.wantCalls)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"slices"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+)
+
+// fromIgnoredIntegration сообщает, что команду прислал бот или webhook,
+// которого нет в BOT_ALLOWED_INTEGRATIONS. Другие интеграции публикуют
+// сообщения с !poll, например пересылая сообщение пользователя, и без
+// проверки бот выполнял бы их от имени интеграции.
+func (b *Bot) fromIgnoredIntegration(post *mmclient.Post) bool {
+	if slices.Contains(b.cfg.AllowedIntegrationIDs, post.UserID) {
+		return false
+	}
+	if !post.FromIntegration() && !b.authorIsBot(post.UserID) {
+		return false
+	}
+	metrics.IntegrationPostsIgnored.Add(1)
+	b.logger.Debug().Str("post_id", post.ID).Str("user_id", post.UserID).Msg("Команда интеграции пропущена")
+	return true
+}
+
+// authorIsBot проверяет учётную запись автора: сообщения, которые бот
+// публикует через API, свойств from_bot не несут. Если Mattermost не
+// ответил, автор считается человеком: сбой API не должен отключать команды
+// пользователей.
+func (b *Bot) authorIsBot(userID string) bool {
+	user, err := b.author(userID)
+	if err != nil {
+		b.logger.Warn().Err(err).Str("user_id", userID).Msg("Не удалось проверить, является ли автор команды ботом")
+		return false
+	}
+	return user.IsBot
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/mmclient"
+)
+
+// TestHandlePost_Integrations проверяет, что команды ботов и webhook
+// пропускаются, если их автора нет в BOT_ALLOWED_INTEGRATIONS, а команды
+// людей выполняются, даже когда профиль автора не удалось получить.
+func TestHandlePost_Integrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		props   map[string]interface{}
+		allowed []string
+		want    bool
+	}{
+		{name: "user", userID: "user1", want: true},
+		{name: "webhook", userID: "user1", props: map[string]interface{}{mmclient.PropFromWebhook: "true"}},
+		{name: "bot prop", userID: "user1", props: map[string]interface{}{mmclient.PropFromBot: "true"}},
+		{name: "bot account", userID: "ci-bot"},
+		{name: "allowed bot account", userID: "ci-bot", allowed: []string{"ci-bot"}, want: true},
+		{name: "allowed webhook", userID: "hook", props: map[string]interface{}{mmclient.PropFromWebhook: "true"}, allowed: []string{"hook"}, want: true},
+		{name: "profile unavailable", userID: "broken", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wb := newWizardBot(config.Config{AllowedIntegrationIDs: tt.allowed})
+			wb.client.(*fakeClient).getUserFunc = func(userID string) (*mmclient.User, error) {
+				if userID == "broken" {
+					return nil, errors.New("timeout")
+				}
+				return &mmclient.User{ID: userID, IsBot: userID == "ci-bot"}, nil
+			}
+
+			event := wizardEvent("p1", "dm1", tt.userID, "!poll list", true)
+			postBytes, _ := json.Marshal(&mmclient.Post{ID: "p1", ChannelID: "dm1", UserID: tt.userID, Message: "!poll list", Props: tt.props})
+			event.Data["post"] = string(postBytes)
+			wb.handleWebSocketEvent(context.Background(), event)
+
+			if got := len(wb.posts) > 0; got != tt.want {
+				t.Errorf("Команда выполнена: %v, ожидалось: %v", got, tt.want)
+			}
+		})
+	}
+}
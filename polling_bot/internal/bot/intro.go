@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"time"
+
+	"polling_bot/internal/handler"
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+)
+
+// introInterval - не чаще одного знакомства с каналом: бота могут удалять
+// и добавлять снова, и каждое добавление не должно повторять справку.
+const introInterval = 24 * time.Hour
+
+// handleUserAdded представляет бота каналу, в который его добавили:
+// пишет основные команды и как открыть справку. В отличие от событий
+// о сообщениях, в данных события только добавленный участник, а канал
+// берётся из адресатов события.
+func (b *Bot) handleUserAdded(event *mmclient.WSEvent, data map[string]interface{}) {
+	userID, _ := data["user_id"].(string)
+	if b.botUser == nil || userID != b.botUser.ID || event.ChannelID == "" {
+		b.skipEvent(event, data, skipOtherUser)
+		return
+	}
+	// Упоминание не нужно: бота добавили в канал, и он отвечает сам
+	teamID, _ := data["team_id"].(string)
+	if b.filter != nil && !b.filter.allows(event.ChannelID, teamID, true) {
+		metrics.EventsFiltered.Add(1)
+		return
+	}
+	introducer, ok := b.commandHandler.(handler.Introducer)
+	if !ok || !b.introduced.claim(event.ChannelID) {
+		return
+	}
+
+	b.logger.Info().Str("channel_id", event.ChannelID).Msg("Бота добавили в канал")
+	b.sendResponse(event.ChannelID, introducer.IntroText(b.localizer))
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+
+	"github.com/rs/zerolog"
+)
+
+// newIntroBot возвращает бота, который складывает отправленные сообщения
+// в posts.
+func newIntroBot(cfg config.Config, posts *[]*mmclient.Post) *Bot {
+	return &Bot{
+		logger:         zerolog.New(io.Discard),
+		botUser:        &mmclient.User{ID: "bot123"},
+		commandHandler: handler.NewPollCommandHandler(nil, i18n.New("ru"), handler.DefaultCommandPrefix),
+		localizer:      i18n.New("ru"),
+		introduced:     newRecentPosts(10, introInterval),
+		filter:         newChannelFilter(cfg),
+		client: &fakeClient{createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			*posts = append(*posts, post)
+			return post, nil
+		}},
+	}
+}
+
+// TestHandleUserAdded проверяет знакомство с каналом по записанным событиям
+// user_added: бот пишет основные команды в канал, куда добавили его самого,
+// не чаще раза в introInterval на канал, и молчит, когда добавили другого
+// участника.
+func TestHandleUserAdded(t *testing.T) {
+	var posts []*mmclient.Post
+	bot := newIntroBot(config.Config{}, &posts)
+	now := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	bot.introduced.now = func() time.Time { return now }
+
+	bot.handleWebSocketEvent(context.Background(), loadEvent(t, "user_added_bot.json"))
+	if len(posts) != 1 {
+		t.Fatalf("Ожидалось одно сообщение, получено: %d", len(posts))
+	}
+	if posts[0].ChannelID != "c1" {
+		t.Errorf("Знакомство должно уйти в канал из адресатов события, получено: %s", posts[0].ChannelID)
+	}
+	for _, want := range []string{"Привет! Я бот опросов", "!poll create", "!poll vote", "!poll results", "Все команды: !poll help"} {
+		if !strings.Contains(posts[0].Message, want) {
+			t.Errorf("В знакомстве нет %q: %s", want, posts[0].Message)
+		}
+	}
+	if strings.Contains(posts[0].Message, "!poll delete") {
+		t.Errorf("В знакомстве должны быть только основные команды: %s", posts[0].Message)
+	}
+
+	// Бота удалили и добавили снова
+	bot.handleWebSocketEvent(context.Background(), loadEvent(t, "user_added_bot.json"))
+	if len(posts) != 1 {
+		t.Errorf("Повторное добавление в тот же день не должно повторять знакомство, сообщений: %d", len(posts))
+	}
+
+	other := loadEvent(t, "user_added_bot.json")
+	other.ChannelID = "c2"
+	bot.handleWebSocketEvent(context.Background(), other)
+	if len(posts) != 2 || posts[1].ChannelID != "c2" {
+		t.Errorf("В другом канале бот должен представиться, сообщения: %v", posts)
+	}
+
+	now = now.Add(introInterval)
+	bot.handleWebSocketEvent(context.Background(), loadEvent(t, "user_added_bot.json"))
+	if len(posts) != 3 {
+		t.Errorf("Через introInterval бот снова представляется, сообщений: %d", len(posts))
+	}
+
+	skipped := metrics.Stats.Counter("bot_events_skipped_total." + skipOtherUser)
+	before := skipped.Value()
+	bot.handleWebSocketEvent(context.Background(), loadEvent(t, "user_added_other.json"))
+	if len(posts) != 3 {
+		t.Errorf("Добавление другого участника не должно вызывать знакомство, сообщений: %d", len(posts))
+	}
+	if got := skipped.Value() - before; got != 1 {
+		t.Errorf("Ожидался один пропуск по причине %s, получено: %d", skipOtherUser, got)
+	}
+}
+
+// TestHandleUserAdded_Filter проверяет, что бот не представляется в
+// запрещённых каналах, а требование упоминания знакомству не мешает.
+func TestHandleUserAdded_Filter(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		wantPost bool
+	}{
+		{name: "blocked channel", cfg: config.Config{BlockedChannelIDs: []string{"c1"}}},
+		{name: "other team", cfg: config.Config{AllowedTeamIDs: []string{"team2"}}},
+		{name: "allowed team", cfg: config.Config{AllowedTeamIDs: []string{"team1"}}, wantPost: true},
+		{name: "mention required", cfg: config.Config{RequireMention: true}, wantPost: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posts []*mmclient.Post
+			bot := newIntroBot(tt.cfg, &posts)
+			bot.handleWebSocketEvent(context.Background(), loadEvent(t, "user_added_bot.json"))
+			if got := len(posts) == 1; got != tt.wantPost {
+				t.Errorf("Ожидалось знакомство: %v, сообщений: %d", tt.wantPost, len(posts))
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package bot
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// Сколько запросов отправляется одновременно: запросы одного канала
+	// всегда попадают в одну полосу и уходят по порядку
+	outboxLanes = 8
+	// Сколько запросов ждёт в полосе; больше - вызывающий ждёт места
+	outboxLaneSize = 256
+	// Сколько раз отправлять запрос, на который Mattermost ответил 429
+	outboxAttempts = 5
+	// Пауза после 429 без заголовка Retry-After
+	outboxDefaultPause = time.Second
+)
+
+var errOutboxClosed = errors.New("очередь сообщений Mattermost остановлена")
+
+// outbox - очередь исходящих запросов бота к Mattermost. Сообщения, правки,
+// реакции, закрепления и личные каналы отправляются не чаще rate в секунду
+// с запасом burst; после ответа 429 очередь целиком ждёт столько, сколько
+// указал Mattermost в Retry-After, и повторяет запрос. Чтение идёт мимо
+// очереди напрямую в client.
+type outbox struct {
+	mmclient.Client
+	logger zerolog.Logger
+
+	lanes []chan *outgoing
+	wg    sync.WaitGroup
+	// Закрывается, когда при остановке истёк срок: оставшиеся запросы
+	// завершаются ошибкой, не дожидаясь отправки
+	abort chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	limitMu     sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+
+	// Часы очереди, подменяются в тестах
+	now   func() time.Time
+	after func(d time.Duration) <-chan time.Time
+}
+
+// outgoing - запрос в очереди; результат отправки приходит в done.
+type outgoing struct {
+	call func() error
+	done chan error
+}
+
+func newOutbox(client mmclient.Client, rate float64, burst int, logger zerolog.Logger) *outbox {
+	o := &outbox{
+		Client: client,
+		logger: logger,
+		lanes:  make([]chan *outgoing, outboxLanes),
+		abort:  make(chan struct{}),
+		rate:   rate,
+		burst:  float64(max(burst, 1)),
+		now:    time.Now,
+		after:  time.After,
+	}
+	o.tokens = o.burst
+	o.last = o.now()
+	for i := range o.lanes {
+		o.lanes[i] = make(chan *outgoing, outboxLaneSize)
+		o.wg.Add(1)
+		go o.work(o.lanes[i])
+	}
+	return o
+}
+
+func (o *outbox) CreatePost(post *mmclient.Post) (created *mmclient.Post, err error) {
+	err = o.do(post.ChannelID, func() (err error) {
+		created, err = o.Client.CreatePost(post)
+		return err
+	})
+	return created, err
+}
+
+func (o *outbox) CreatePostEphemeral(userID string, post *mmclient.Post) (created *mmclient.Post, err error) {
+	err = o.do(post.ChannelID, func() (err error) {
+		created, err = o.Client.CreatePostEphemeral(userID, post)
+		return err
+	})
+	return created, err
+}
+
+func (o *outbox) UpdatePost(post *mmclient.Post) (updated *mmclient.Post, err error) {
+	err = o.do(post.ChannelID, func() (err error) {
+		updated, err = o.Client.UpdatePost(post)
+		return err
+	})
+	return updated, err
+}
+
+func (o *outbox) SaveReaction(reaction *mmclient.Reaction) (saved *mmclient.Reaction, err error) {
+	err = o.do(reaction.PostID, func() (err error) {
+		saved, err = o.Client.SaveReaction(reaction)
+		return err
+	})
+	return saved, err
+}
+
+func (o *outbox) PinPost(postID string) error {
+	return o.do(postID, func() error {
+		return o.Client.PinPost(postID)
+	})
+}
+
+func (o *outbox) UnpinPost(postID string) error {
+	return o.do(postID, func() error {
+		return o.Client.UnpinPost(postID)
+	})
+}
+
+// CreateDirectChannel тоже идёт через очередь: рассылка личных сообщений
+// создаёт канал на каждого получателя.
+func (o *outbox) CreateDirectChannel(userID1, userID2 string) (channel *mmclient.Channel, err error) {
+	err = o.do(userID2, func() (err error) {
+		channel, err = o.Client.CreateDirectChannel(userID1, userID2)
+		return err
+	})
+	return channel, err
+}
+
+// do ставит запрос в полосу ключа key и ждёт его отправки. Пока полоса
+// заполнена, вызывающий ждёт места в ней.
+func (o *outbox) do(key string, call func() error) error {
+	o.mu.RLock()
+	if o.closed {
+		o.mu.RUnlock()
+		return errOutboxClosed
+	}
+	job := &outgoing{call: call, done: make(chan error, 1)}
+	metrics.OutboxDepth.Add(1)
+	o.lanes[laneIndex(key)] <- job
+	o.mu.RUnlock()
+	return <-job.done
+}
+
+func laneIndex(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % outboxLanes)
+}
+
+func (o *outbox) work(lane <-chan *outgoing) {
+	defer o.wg.Done()
+	for job := range lane {
+		job.done <- o.send(job.call)
+		metrics.OutboxDepth.Add(-1)
+	}
+}
+
+// send отправляет запрос, когда это разрешает ограничение частоты, и
+// повторяет его после ответа 429.
+func (o *outbox) send(call func() error) error {
+	for attempt := 1; ; attempt++ {
+		if err := o.wait(); err != nil {
+			return err
+		}
+		err := call()
+		if mmclient.StatusCode(err) != http.StatusTooManyRequests || attempt == outboxAttempts {
+			return err
+		}
+		metrics.OutboxRateLimited.Add(1)
+		pause := o.pause(mmclient.RetryAfter(err))
+		o.logger.Warn().Dur("pause", pause).Int("attempt", attempt).Msg("Mattermost ограничил частоту запросов, очередь приостановлена")
+	}
+}
+
+// wait занимает место в ограничении частоты и ждёт своей очереди и
+// конца паузы после 429.
+func (o *outbox) wait() error {
+	select {
+	case <-o.abort:
+		return errOutboxClosed
+	default:
+	}
+
+	o.limitMu.Lock()
+	now := o.now()
+	var delay time.Duration
+	if o.rate > 0 {
+		o.tokens = math.Min(o.burst, o.tokens+now.Sub(o.last).Seconds()*o.rate)
+		o.last = now
+		o.tokens--
+		if o.tokens < 0 {
+			delay = time.Duration(-o.tokens / o.rate * float64(time.Second))
+		}
+	}
+	if pause := o.pausedUntil.Sub(now); pause > delay {
+		delay = pause
+	}
+	o.limitMu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-o.after(delay):
+		return nil
+	case <-o.abort:
+		return errOutboxClosed
+	}
+}
+
+// pause приостанавливает все полосы на d и возвращает паузу.
+func (o *outbox) pause(d time.Duration) time.Duration {
+	if d <= 0 {
+		d = outboxDefaultPause
+	}
+	o.limitMu.Lock()
+	defer o.limitMu.Unlock()
+	if until := o.now().Add(d); until.After(o.pausedUntil) {
+		o.pausedUntil = until
+	}
+	return d
+}
+
+// drain перестаёт принимать запросы и ждёт отправки уже поставленных,
+// но не дольше timeout: оставшиеся после этого завершаются ошибкой.
+func (o *outbox) drain(timeout time.Duration) {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return
+	}
+	o.closed = true
+	for _, lane := range o.lanes {
+		close(lane)
+	}
+	o.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		o.logger.Warn().Int64("depth", metrics.OutboxDepth.Value()).Msg("Очередь сообщений Mattermost не успела опустеть при остановке")
+		close(o.abort)
+		<-done
+	}
+}
@@ -0,0 +1,237 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+
+	"github.com/rs/zerolog"
+)
+
+// outboxClock - часы очереди в тестах: ожидание сразу продвигает время
+// и запоминается.
+type outboxClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *outboxClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *outboxClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *outboxClock) Waits() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+func newTestOutbox(client mmclient.Client, rate float64, burst int) (*outbox, *outboxClock) {
+	clock := &outboxClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	o := newOutbox(client, rate, burst, zerolog.Nop())
+	o.now, o.after, o.last = clock.Now, clock.After, clock.Now()
+	return o, clock
+}
+
+func rateLimited(retryAfter time.Duration) error {
+	return &mmclient.Error{StatusCode: http.StatusTooManyRequests, RetryAfter: retryAfter, Err: errors.New("too many requests")}
+}
+
+// TestOutbox_RateLimited проверяет паузу после ответа 429: на время из
+// Retry-After или на секунду без него, и ошибку, когда повторы кончились.
+func TestOutbox_RateLimited(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []error
+		wantCalls int
+		wantWaits []time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "retry after",
+			responses: []error{rateLimited(2 * time.Second), nil},
+			wantCalls: 2,
+			wantWaits: []time.Duration{2 * time.Second},
+		},
+		{
+			name:      "no header",
+			responses: []error{rateLimited(0), nil},
+			wantCalls: 2,
+			wantWaits: []time.Duration{time.Second},
+		},
+		{
+			name:      "attempts exhausted",
+			responses: []error{rateLimited(0), rateLimited(0), rateLimited(0), rateLimited(0), rateLimited(0)},
+			wantCalls: outboxAttempts,
+			wantWaits: []time.Duration{time.Second, time.Second, time.Second, time.Second},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := &fakeClient{
+				createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+					err := tt.responses[calls]
+					calls++
+					if err != nil {
+						return nil, err
+					}
+					return &mmclient.Post{ID: "post1", ChannelID: post.ChannelID}, nil
+				},
+			}
+			o, clock := newTestOutbox(client, 0, 1)
+			defer o.drain(time.Second)
+			limited := metrics.OutboxRateLimited.Value()
+
+			post, err := o.CreatePost(&mmclient.Post{ChannelID: "c1", Message: "Итоги"})
+			if tt.wantErr {
+				if mmclient.StatusCode(err) != http.StatusTooManyRequests {
+					t.Errorf("Ожидалась ошибка 429, получено: %v", err)
+				}
+			} else if err != nil || post.ID != "post1" {
+				t.Errorf("Ожидалось сообщение post1, получено: %v, %v", post, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Ожидалось %d запросов, получено: %d", tt.wantCalls, calls)
+			}
+			if got := clock.Waits(); !reflect.DeepEqual(got, tt.wantWaits) {
+				t.Errorf("Ожидались паузы %v, получено: %v", tt.wantWaits, got)
+			}
+			if got := metrics.OutboxRateLimited.Value() - limited; got != int64(len(tt.wantWaits)) {
+				t.Errorf("Ожидалось %d ответов 429 в счётчике, получено: %d", len(tt.wantWaits), got)
+			}
+		})
+	}
+}
+
+// TestOutbox_Rate проверяет, что после запаса burst запросы уходят
+// не чаще rate в секунду.
+func TestOutbox_Rate(t *testing.T) {
+	o, clock := newTestOutbox(&fakeClient{}, 10, 2)
+	defer o.drain(time.Second)
+
+	for i := 0; i < 4; i++ {
+		if err := o.PinPost(fmt.Sprintf("post%d", i)); err != nil {
+			t.Fatalf("PinPost: %v", err)
+		}
+	}
+	want := []time.Duration{100 * time.Millisecond, 100 * time.Millisecond}
+	if got := clock.Waits(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Ожидались паузы %v, получено: %v", want, got)
+	}
+}
+
+// TestOutbox_ChannelOrder проверяет, что сообщения канала уходят в порядке
+// постановки в очередь, даже если первое пришлось повторить после 429.
+func TestOutbox_ChannelOrder(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client := &fakeClient{
+		createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			mu.Lock()
+			sent = append(sent, post.Message)
+			first := len(sent) == 1
+			mu.Unlock()
+			if first {
+				close(started)
+				<-release
+				return nil, rateLimited(time.Second)
+			}
+			return post, nil
+		},
+	}
+	o, clock := newTestOutbox(client, 0, 1)
+	defer o.drain(time.Second)
+
+	var wg sync.WaitGroup
+	send := func(message string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := o.CreatePost(&mmclient.Post{ChannelID: "c1", Message: message}); err != nil {
+				t.Errorf("CreatePost(%s): %v", message, err)
+			}
+		}()
+	}
+	send("1")
+	<-started
+	lane := o.lanes[laneIndex("c1")]
+	for i := 2; i <= 4; i++ {
+		send(fmt.Sprint(i))
+		for len(lane) < i-1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if want := []string{"1", "1", "2", "3", "4"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("Ожидался порядок %v, получено: %v", want, sent)
+	}
+	if want := []time.Duration{time.Second}; !reflect.DeepEqual(clock.Waits(), want) {
+		t.Errorf("Ожидались паузы %v, получено: %v", want, clock.Waits())
+	}
+}
+
+// TestOutbox_Drain проверяет остановку очереди: поставленные запросы
+// отправляются, если успевают до срока, иначе завершаются ошибкой, а новые
+// запросы не принимаются.
+func TestOutbox_Drain(t *testing.T) {
+	t.Run("in time", func(t *testing.T) {
+		o := newOutbox(&fakeClient{}, 0, 1, zerolog.Nop())
+		if err := o.PinPost("post1"); err != nil {
+			t.Fatalf("PinPost: %v", err)
+		}
+		o.drain(time.Second)
+		if err := o.PinPost("post2"); !errors.Is(err, errOutboxClosed) {
+			t.Errorf("После остановки ожидалась ошибка errOutboxClosed, получено: %v", err)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		client := &fakeClient{
+			pinFunc: func(postID string) error { return rateLimited(time.Hour) },
+		}
+		o := newOutbox(client, 0, 1, zerolog.Nop())
+		result := make(chan error, 1)
+		limited := metrics.OutboxRateLimited.Value()
+		go func() { result <- o.PinPost("post1") }()
+		for metrics.OutboxRateLimited.Value() == limited {
+			time.Sleep(time.Millisecond)
+		}
+
+		start := time.Now()
+		o.drain(20 * time.Millisecond)
+		if took := time.Since(start); took > time.Second {
+			t.Errorf("Остановка заняла %v, ожидалось не дольше срока", took)
+		}
+		if err := <-result; !errors.Is(err, errOutboxClosed) {
+			t.Errorf("Ожидалась ошибка errOutboxClosed, получено: %v", err)
+		}
+	})
+}
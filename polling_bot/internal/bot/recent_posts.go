@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// postRecord - что бот сделал с сообщением.
+type postRecord struct {
+	// Команда с аргументами, как её разобрал обработчик
+	signature string
+	// Команда распознана и выполнена без ошибки
+	executed bool
+}
+
+type recentEntry struct {
+	postID    string
+	record    postRecord
+	expiresAt time.Time
+}
+
+// recentPosts - LRU недавно обработанных сообщений с ограничением по времени.
+// Безопасен для параллельных обработчиков событий.
+type recentPosts struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+
+	now func() time.Time
+}
+
+func newRecentPosts(size int, ttl time.Duration) *recentPosts {
+	return &recentPosts{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (r *recentPosts) get(postID string) (postRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.getLocked(postID)
+}
+
+func (r *recentPosts) put(postID string, record postRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.putLocked(postID, record)
+}
+
+// claim атомарно отмечает ключ как виденный и возвращает false,
+// если он уже был отмечен и ещё не устарел.
+func (r *recentPosts) claim(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.getLocked(key); ok {
+		return false
+	}
+	r.putLocked(key, postRecord{})
+	return true
+}
+
+func (r *recentPosts) getLocked(postID string) (postRecord, bool) {
+	elem, ok := r.entries[postID]
+	if !ok {
+		return postRecord{}, false
+	}
+	entry := elem.Value.(*recentEntry)
+	if !r.now().Before(entry.expiresAt) {
+		r.order.Remove(elem)
+		delete(r.entries, postID)
+		return postRecord{}, false
+	}
+	r.order.MoveToFront(elem)
+	return entry.record, true
+}
+
+func (r *recentPosts) putLocked(postID string, record postRecord) {
+	entry := &recentEntry{postID: postID, record: record, expiresAt: r.now().Add(r.ttl)}
+	if elem, ok := r.entries[postID]; ok {
+		elem.Value = entry
+		r.order.MoveToFront(elem)
+		return
+	}
+	r.entries[postID] = r.order.PushFront(entry)
+
+	for r.order.Len() > r.size {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*recentEntry).postID)
+	}
+}
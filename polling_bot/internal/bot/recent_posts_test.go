@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRecentPosts_Eviction проверяет вытеснение самых давних записей при переполнении.
+func TestRecentPosts_Eviction(t *testing.T) {
+	r := newRecentPosts(2, time.Minute)
+	r.put("p1", postRecord{signature: "a"})
+	r.put("p2", postRecord{signature: "b"})
+
+	// Чтение продлевает жизнь p1 в LRU
+	if _, ok := r.get("p1"); !ok {
+		t.Fatal("p1 должен быть в кэше")
+	}
+	r.put("p3", postRecord{signature: "c"})
+
+	if _, ok := r.get("p2"); ok {
+		t.Error("p2 должен быть вытеснен как самый давний")
+	}
+	for _, id := range []string{"p1", "p3"} {
+		if _, ok := r.get(id); !ok {
+			t.Errorf("%s должен остаться в кэше", id)
+		}
+	}
+}
+
+// TestRecentPosts_TTL проверяет забывание записей по истечении времени жизни.
+func TestRecentPosts_TTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newRecentPosts(10, time.Minute)
+	r.now = func() time.Time { return now }
+
+	r.put("p1", postRecord{signature: "a", executed: true})
+	record, ok := r.get("p1")
+	if !ok || !record.executed || record.signature != "a" {
+		t.Fatalf("Ожидалась сохранённая запись, получено: %+v, %v", record, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := r.get("p1"); ok {
+		t.Error("Запись должна устареть через TTL")
+	}
+	if len(r.entries) != 0 || r.order.Len() != 0 {
+		t.Error("Устаревшая запись должна удаляться")
+	}
+}
+
+// TestRecentPosts_Claim проверяет, что ключ занимается один раз до истечения TTL.
+func TestRecentPosts_Claim(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newRecentPosts(10, time.Minute)
+	r.now = func() time.Time { return now }
+
+	if !r.claim("p1") {
+		t.Fatal("Первая доставка должна быть принята")
+	}
+	if r.claim("p1") {
+		t.Error("Повторная доставка должна быть отклонена")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !r.claim("p1") {
+		t.Error("После истечения TTL ключ должен приниматься снова")
+	}
+}
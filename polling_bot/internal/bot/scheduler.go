@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+)
+
+// Scheduler создаёт опросы по расписаниям, срабатывающим в минуту at.
+type Scheduler interface {
+	RunDue(ctx context.Context, at time.Time) []service.ScheduledPoll
+}
+
+// Expirer закрывает опросы, срок которых наступил к at, и предупреждает
+// о скором закрытии.
+type Expirer interface {
+	RunExpiry(ctx context.Context, at time.Time) []service.ExpiryNotice
+}
+
+// SetExpirer включает закрытие опросов по сроку: в начале каждой минуты
+// бот публикует предупреждения и итоги закрытых опросов в их каналах.
+func (b *Bot) SetExpirer(expirer Expirer) {
+	b.expirer = expirer
+}
+
+// SetScheduler включает расписания: после запуска бот в начале каждой
+// минуты создаёт наступившие опросы и публикует их в каналах расписаний.
+func (b *Bot) SetScheduler(scheduler Scheduler) {
+	b.scheduler = scheduler
+}
+
+// publishNotice публикует предупреждение или итоги опроса, закрытого по
+// сроку. Каждое сообщение отправляется один раз, поэтому недоступный канал
+// только отмечается в логе.
+func (b *Bot) publishNotice(notice service.ExpiryNotice) {
+	_, err := b.client.CreatePost(&mmclient.Post{ChannelID: notice.ChannelID, Message: notice.Message})
+	switch {
+	case err == nil:
+	case channelUnreachable(err):
+		b.logger.Warn().Err(err).Str("channel_id", notice.ChannelID).Msg("Бот не может писать в канал опроса, сообщение о сроке не опубликовано")
+	default:
+		b.logger.Error().Err(err).Str("channel_id", notice.ChannelID).Msg("Не удалось опубликовать сообщение о сроке опроса")
+	}
+}
+
+// runScheduler просыпается в начале каждой минуты, пока не отменён ctx,
+// создаёт опросы по расписаниям, закрывает опросы по сроку и публикует
+// сводки опросов.
+// Минуты расписаний, пропущенные пока бот не работал или был занят, не
+// навёрстываются: каждый раз проверяется только наступившая минута. Опросы
+// с прошедшим сроком закрываются сразу после запуска, не дожидаясь минуты.
+func (b *Bot) runScheduler(ctx context.Context) {
+	ctx = i18n.WithLocalizer(ctx, b.localizer)
+	b.recoverExpiry(ctx)
+	for {
+		now := b.clock()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.after(next.Sub(now)):
+		}
+
+		// Проснувшись с опозданием, проверяем текущую минуту, а не ту, которую ждали
+		at := b.clock().Truncate(time.Minute)
+		if at.Before(next) {
+			at = next
+		}
+		if b.scheduler != nil {
+			for _, poll := range b.scheduler.RunDue(ctx, at) {
+				b.logger.Info().Str("schedule_id", poll.ScheduleID).Msg("Опрос по расписанию создан")
+				b.publishScheduled(ctx, poll)
+			}
+		}
+		if b.expirer != nil {
+			for _, notice := range b.expirer.RunExpiry(ctx, at) {
+				b.publishNotice(notice)
+			}
+		}
+		if b.digester != nil {
+			for _, digest := range b.digester.RunDigests(ctx, at) {
+				b.publishDigest(digest)
+			}
+		}
+	}
+}
+
+// recoverExpiry проверяет сроки опросов при запуске. Сроки и отметки о
+// предупреждении хранятся в опросах, а не в таймерах процесса, поэтому
+// после перезапуска достаточно одной проверки на текущее время: опросы,
+// срок которых прошёл, пока бот не работал, закрываются с итогами, а
+// закрытые и предупреждённые раньше повторно не объявляются.
+func (b *Bot) recoverExpiry(ctx context.Context) {
+	if b.expirer == nil {
+		return
+	}
+	notices := b.expirer.RunExpiry(ctx, b.clock())
+	if len(notices) > 0 {
+		b.logger.Info().Int("notices", len(notices)).Msg("Проверены сроки опросов после запуска")
+	}
+	for _, notice := range notices {
+		b.publishNotice(notice)
+	}
+}
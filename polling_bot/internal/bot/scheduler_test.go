@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+
+	"github.com/rs/zerolog"
+)
+
+// fakeClock отдаёт заданное время и будит планировщик только по команде теста.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits chan time.Duration
+	fire  chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waits: make(chan time.Duration), fire: make(chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+// advance переводит часы на t и будит ожидающий планировщик.
+func (c *fakeClock) advance(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+	c.fire <- t
+}
+
+type fakeScheduler struct {
+	calls chan time.Time
+	polls []service.ScheduledPoll
+}
+
+func (s *fakeScheduler) RunDue(ctx context.Context, at time.Time) []service.ScheduledPoll {
+	s.calls <- at
+	return s.polls
+}
+
+// TestRunScheduler проверяет, что планировщик просыпается в начале каждой минуты,
+// публикует созданные опросы и останавливается по отмене контекста.
+func TestRunScheduler(t *testing.T) {
+	posts := make(chan *mmclient.Post, 10)
+	bot, _ := NewBot(config.Config{MattermostURL: "http://dummy", BotToken: "dummy"}, zerolog.Nop(), new(MockCommandHandler))
+	bot.client = &fakeClient{createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+		posts <- post
+		return post, nil
+	}}
+
+	start := time.Date(2025, 3, 3, 9, 59, 30, 0, time.UTC)
+	clock := newFakeClock(start)
+	bot.clock, bot.after = clock.Now, clock.After
+	scheduler := &fakeScheduler{
+		calls: make(chan time.Time, 10),
+		polls: []service.ScheduledPoll{{ScheduleID: "s1", ChannelID: "c1", Message: "Опрос"}},
+	}
+	bot.SetScheduler(scheduler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		bot.runScheduler(ctx)
+		close(done)
+	}()
+
+	if d := <-clock.waits; d != 30*time.Second {
+		t.Errorf("Ожидалось ожидание до начала минуты (30s), получено %v", d)
+	}
+	clock.advance(start.Add(30 * time.Second))
+	if at := <-scheduler.calls; !at.Equal(time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Ожидался запуск расписаний за 10:00, получено %v", at)
+	}
+	post := <-posts
+	if post.ChannelID != "c1" || post.Message != "Опрос" {
+		t.Errorf("Опрос опубликован не так: канал %q, сообщение %q", post.ChannelID, post.Message)
+	}
+
+	// Планировщик проспал несколько минут: навёрстывать их он не должен
+	if d := <-clock.waits; d != time.Minute {
+		t.Errorf("Ожидалось ожидание в одну минуту, получено %v", d)
+	}
+	clock.advance(time.Date(2025, 3, 3, 10, 5, 10, 0, time.UTC))
+	if at := <-scheduler.calls; !at.Equal(time.Date(2025, 3, 3, 10, 5, 0, 0, time.UTC)) {
+		t.Errorf("Ожидался запуск за текущую минуту 10:05, получено %v", at)
+	}
+	<-posts
+	if d := <-clock.waits; d != 50*time.Second {
+		t.Errorf("После пропуска ожидалось ожидание до 10:06 (50s), получено %v", d)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Планировщик не остановился после отмены контекста")
+	}
+	if len(scheduler.calls) != 0 {
+		t.Errorf("Пропущенные минуты не должны запускаться, лишних вызовов: %d", len(scheduler.calls))
+	}
+}
+
+type fakeExpirer struct {
+	calls   chan time.Time
+	notices []service.ExpiryNotice
+}
+
+func (e *fakeExpirer) RunExpiry(ctx context.Context, at time.Time) []service.ExpiryNotice {
+	e.calls <- at
+	return e.notices
+}
+
+// TestRunScheduler_Expiry проверяет, что без расписаний планировщик всё равно
+// запускается, сразу после запуска проверяет сроки опросов и затем
+// публикует сообщения о сроке в начале каждой минуты.
+func TestRunScheduler_Expiry(t *testing.T) {
+	posts := make(chan *mmclient.Post, 10)
+	bot, _ := NewBot(config.Config{MattermostURL: "http://dummy", BotToken: "dummy"}, zerolog.Nop(), new(MockCommandHandler))
+	bot.client = &fakeClient{
+		getMeFunc: func() (*mmclient.User, error) {
+			return &mmclient.User{ID: "bot123"}, nil
+		},
+		createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			posts <- post
+			return post, nil
+		},
+	}
+	bot.cfg.Mode = config.ModeWebhook
+	bot.cfg.WebhookAddr = "127.0.0.1:0"
+
+	clock := newFakeClock(time.Date(2025, 3, 3, 9, 59, 0, 0, time.UTC))
+	bot.clock, bot.after = clock.Now, clock.After
+	expirer := &fakeExpirer{
+		calls:   make(chan time.Time, 10),
+		notices: []service.ExpiryNotice{{PollID: "p1", ChannelID: "c1", Message: "Опрос закроется завтра"}},
+	}
+	bot.SetExpirer(expirer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = bot.Start(ctx)
+		close(done)
+	}()
+
+	if at := <-expirer.calls; !at.Equal(time.Date(2025, 3, 3, 9, 59, 0, 0, time.UTC)) {
+		t.Errorf("Ожидалась проверка сроков сразу после запуска, получено %v", at)
+	}
+	if post := <-posts; post.ChannelID != "c1" {
+		t.Errorf("Сообщение о сроке после запуска опубликовано в канал %q", post.ChannelID)
+	}
+
+	<-clock.waits
+	clock.advance(time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC))
+	if at := <-expirer.calls; !at.Equal(time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Ожидалась проверка срока за 10:00, получено %v", at)
+	}
+	if post := <-posts; post.ChannelID != "c1" || post.Message != "Опрос закроется завтра" {
+		t.Errorf("Сообщение о сроке опубликовано не так: канал %q, сообщение %q", post.ChannelID, post.Message)
+	}
+
+	<-clock.waits
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Бот не остановился после отмены контекста")
+	}
+}
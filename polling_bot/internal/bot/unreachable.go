@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+)
+
+// ScheduleStopper реализуют сервисы расписаний, которые останавливают
+// расписание, если бот не может писать в его канал.
+type ScheduleStopper interface {
+	// MarkUnreachable возвращает true, только если расписание остановлено
+	// этим вызовом
+	MarkUnreachable(ctx context.Context, scheduleID string) (bool, error)
+}
+
+// channelUnreachable сообщает, что Mattermost отказал в публикации, потому
+// что бот не может писать в канал: его удалили из канала, канал удалён или
+// у бота нет прав. Повторять такую публикацию бесполезно.
+func channelUnreachable(err error) bool {
+	code := mmclient.StatusCode(err)
+	return code == http.StatusForbidden || code == http.StatusNotFound
+}
+
+// publishScheduled публикует опрос по расписанию. Если бот не может писать
+// в канал, опрос удаляется, расписание останавливается, а его создатель
+// один раз получает личное сообщение: иначе опрос создавался бы и пропадал
+// каждый запуск.
+func (b *Bot) publishScheduled(ctx context.Context, poll service.ScheduledPoll) {
+	log := b.logger.With().Str("schedule_id", poll.ScheduleID).Str("channel_id", poll.ChannelID).Logger()
+	_, err := b.client.CreatePost(&mmclient.Post{ChannelID: poll.ChannelID, Message: poll.Message})
+	if err == nil {
+		log.Info().Msg("Опрос по расписанию опубликован")
+		return
+	}
+	if !channelUnreachable(err) {
+		log.Error().Err(err).Msg("Не удалось опубликовать опрос по расписанию")
+		return
+	}
+
+	log.Warn().Err(err).Msg("Бот не может писать в канал расписания")
+	// Опрос нигде не объявлен, и проголосовать в нём никто не сможет
+	if b.discarder != nil && poll.PollID != "" {
+		if err := b.discarder.DiscardPoll(context.WithoutCancel(ctx), poll.PollID); err != nil {
+			log.Error().Err(err).Str("poll_id", poll.PollID).Msg("Не удалось удалить необъявленный опрос по расписанию")
+		}
+	}
+	stopper, ok := b.scheduler.(ScheduleStopper)
+	if !ok {
+		return
+	}
+	first, err := stopper.MarkUnreachable(ctx, poll.ScheduleID)
+	if err != nil {
+		log.Error().Err(err).Msg("Не удалось остановить расписание")
+		return
+	}
+	if !first {
+		return
+	}
+	message := b.localizerFor(poll.Creator).T(i18n.ScheduleUnreachable, poll.ScheduleID, poll.Question)
+	if err := b.SendDirect(ctx, poll.Creator, message); err != nil {
+		log.Warn().Err(err).Str("user_id", poll.Creator).Msg("Не удалось сообщить создателю об остановке расписания")
+	}
+}
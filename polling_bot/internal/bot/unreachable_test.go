@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+
+	"github.com/rs/zerolog"
+)
+
+// TestPublishScheduled_Unreachable проверяет, что после 403 или 404 бот
+// удаляет необъявленный опрос, останавливает расписание и один раз пишет
+// создателю, а другие ошибки публикации расписание не останавливают.
+func TestPublishScheduled_Unreachable(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		wantStopped bool
+	}{
+		{name: "forbidden", status: http.StatusForbidden, wantStopped: true},
+		{name: "channel not found", status: http.StatusNotFound, wantStopped: true},
+		{name: "server error", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+			pollRepo := repository.NewMemoryPollRepo()
+			polls := service.NewPollService(pollRepo, repository.NewMemoryVoteRepo(pollRepo), zerolog.Nop())
+			schedules := service.NewScheduleService(repository.NewMemoryScheduleRepo(), polls, zerolog.Nop())
+			if _, err := schedules.CreateSchedule(service.WithOrigin(ctx, service.Origin{ChannelID: "c1"}),
+				"creator1", "* * * * *", "Стендап?", []string{"Да", "Нет"}, service.CreateOptions{}); err != nil {
+				t.Fatalf("Ошибка создания расписания: %v", err)
+			}
+
+			var channelPosts int
+			var direct []string
+			fc := &fakeClient{createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+				if post.ChannelID != "c1" {
+					direct = append(direct, post.Message)
+					return post, nil
+				}
+				channelPosts++
+				return nil, &mmclient.Error{StatusCode: tt.status, Err: errors.New("отказ")}
+			}}
+			bot := &Bot{
+				logger:    zerolog.New(io.Discard),
+				botUser:   &mmclient.User{ID: "bot123"},
+				localizer: i18n.New("ru"),
+				client:    fc,
+				discarder: polls,
+			}
+			bot.SetScheduler(schedules)
+
+			at := time.Now().Truncate(time.Minute).Add(time.Hour)
+			for minute := range 2 {
+				for _, poll := range schedules.RunDue(ctx, at.Add(time.Duration(minute)*time.Minute)) {
+					bot.publishScheduled(ctx, poll)
+				}
+			}
+
+			stored, _, err := pollRepo.ListPolls(context.Background(), repository.ListFilter{})
+			if err != nil {
+				t.Fatalf("Ошибка чтения опросов: %v", err)
+			}
+			if !tt.wantStopped {
+				if channelPosts != 2 || len(stored) != 2 || len(direct) != 0 {
+					t.Errorf("Расписание не должно останавливаться: публикаций %d, опросов %d, личных сообщений %d",
+						channelPosts, len(stored), len(direct))
+				}
+				return
+			}
+			if channelPosts != 1 {
+				t.Errorf("После отказа канал не должен запрашиваться снова, публикаций: %d", channelPosts)
+			}
+			if len(stored) != 0 {
+				t.Errorf("Необъявленный опрос должен быть удалён, опросов: %d", len(stored))
+			}
+			if len(direct) != 1 || !strings.Contains(direct[0], "остановлено") || !strings.Contains(direct[0], "Стендап?") {
+				t.Errorf("Ожидалось одно личное сообщение об остановке расписания, получено: %q", direct)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// normalizeURL приводит адрес Mattermost к виду scheme://host[/path]:
+// адрес без схемы считается http, ws:// и wss:// заменяются на http:// и
+// https://, завершающий "/" отбрасывается. Путь сохраняется для установок
+// в подкаталоге, например https://chat.example.com/mattermost.
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("неверный адрес Mattermost %q: %w", raw, err)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "ws":
+		u.Scheme = "http"
+	case "https", "wss":
+		u.Scheme = "https"
+	default:
+		return "", fmt.Errorf("неподдерживаемая схема адреса Mattermost %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("в адресе Mattermost %q не указан хост", raw)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("адрес Mattermost %q не должен содержать логин, параметры или фрагмент", raw)
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// webSocketURL строит адрес WebSocket из адреса Mattermost: https -> wss,
+// http -> ws, хост и путь не меняются.
+func webSocketURL(mattermostURL string) (string, error) {
+	normalized, err := normalizeURL(mattermostURL)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return "", fmt.Errorf("неверный адрес Mattermost %q: %w", normalized, err)
+	}
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"time"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+)
+
+// Предел размера тела исходящего webhook
+const maxWebhookBody = 64 << 10
+
+// Сколько ждать завершения обработки webhook при остановке
+const webhookShutdownTimeout = 5 * time.Second
+
+// serveWebhooks принимает исходящие webhook Mattermost, пока не отменён ctx.
+func (b *Bot) serveWebhooks(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              b.cfg.WebhookAddr,
+		Handler:           b.webhookHandler(ctx),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+	b.logger.Info().Str("addr", b.cfg.WebhookAddr).Msg("Бот запущен в режиме webhook")
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		b.logger.Warn().Err(err).Msg("Сервер webhook остановлен не полностью")
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ctx.Err()
+}
+
+// webhookHandler принимает POST исходящего webhook Mattermost в виде формы
+// или JSON и выполняет команду из него тем же путём, что и события WebSocket.
+// Команды выполняются в контексте ctx, а не запроса: обрыв соединения
+// Mattermost не должен прерывать уже начатую запись голоса.
+func (b *Bot) webhookHandler(ctx context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "ожидается POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, err := parseWebhookPayload(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !b.validWebhookToken(payload.Token) {
+			http.Error(w, "неверный токен webhook", http.StatusUnauthorized)
+			return
+		}
+
+		post := &mmclient.Post{
+			ID:        payload.PostID,
+			ChannelID: payload.ChannelID,
+			UserID:    payload.UserID,
+			Message:   payload.Text,
+		}
+		metrics.EventsReceived.Add(1)
+		metrics.HandlersInFlight.Add(1)
+		// Исходящие webhook срабатывают только в публичных каналах
+		response := b.handlePost(ctx, post, false, false, nil)
+		metrics.HandlersInFlight.Add(-1)
+		responseMessage := response.Text
+		// Ответ на webhook виден всему каналу, поэтому ответ только автору
+		// отправляется через API
+		if responseMessage == "" || response.Ephemeral && b.sendEphemeral(post.UserID, post.ChannelID, responseMessage) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		reply := b.replyPost(ctx, b.localizerFor(post.UserID), post.ChannelID, response)
+		if b.cfg.WebhookReplyPost {
+			b.sendPost(reply)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mmclient.WebhookResponse{Text: &reply.Message, Props: reply.Props})
+	})
+}
+
+// parseWebhookPayload читает тело webhook: Mattermost отправляет его формой
+// или JSON в зависимости от настройки Content Type исходящего webhook.
+func parseWebhookPayload(w http.ResponseWriter, r *http.Request) (mmclient.WebhookPayload, error) {
+	var payload mmclient.WebhookPayload
+	r.Body = http.MaxBytesReader(w, r.Body, maxWebhookBody)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			return payload, errors.New("неверное тело webhook: " + err.Error())
+		}
+		return payload, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return payload, errors.New("неверное тело webhook: " + err.Error())
+	}
+	payload.Token = r.PostForm.Get("token")
+	payload.TeamID = r.PostForm.Get("team_id")
+	payload.TeamDomain = r.PostForm.Get("team_domain")
+	payload.ChannelID = r.PostForm.Get("channel_id")
+	payload.ChannelName = r.PostForm.Get("channel_name")
+	payload.UserID = r.PostForm.Get("user_id")
+	payload.UserName = r.PostForm.Get("user_name")
+	payload.PostID = r.PostForm.Get("post_id")
+	payload.Text = r.PostForm.Get("text")
+	payload.TriggerWord = r.PostForm.Get("trigger_word")
+	payload.FileIDs = r.PostForm.Get("file_ids")
+	return payload, nil
+}
+
+// validWebhookToken сравнивает токен со всеми настроенными за постоянное время.
+func (b *Bot) validWebhookToken(token string) bool {
+	valid := false
+	for _, known := range b.cfg.WebhookTokens {
+		if known != "" && subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
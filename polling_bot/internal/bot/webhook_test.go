@@ -0,0 +1,238 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/handler"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+)
+
+// Тела запросов, как их отправляет исходящий webhook Mattermost
+const (
+	webhookForm = "token=hook-token&team_id=team1&team_domain=dev&channel_id=channel1&channel_name=town-square" +
+		"&timestamp=1740830400000&user_id=user1&user_name=alice&post_id=post1" +
+		"&text=%21poll+results+p1&trigger_word=%21poll&file_ids="
+	webhookJSON = `{"token":"hook-token","team_id":"team1","team_domain":"dev","channel_id":"channel1",` +
+		`"channel_name":"town-square","timestamp":1740830400000,"user_id":"user1","user_name":"alice",` +
+		`"post_id":"post1","text":"!poll results p1","trigger_word":"!poll","file_ids":""}`
+)
+
+func newWebhookBot(t *testing.T, replyPost bool) (*Bot, *MockCommandHandler, *[]*mmclient.Post) {
+	t.Helper()
+	var posted []*mmclient.Post
+	fc := &fakeClient{
+		createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			posted = append(posted, post)
+			return post, nil
+		},
+		// В режиме webhook реакции не ставятся
+		reactionFunc: func(reaction *mmclient.Reaction) (*mmclient.Reaction, error) {
+			t.Errorf("Реакция в режиме webhook: %+v", reaction)
+			return reaction, nil
+		},
+	}
+	cfg := config.Config{
+		MattermostURL:    "http://dummy",
+		BotToken:         "dummy",
+		Mode:             config.ModeWebhook,
+		WebhookTokens:    []string{"other-token", "hook-token"},
+		WebhookReplyPost: replyPost,
+		Reactions:        true,
+		RecentPostsSize:  10,
+		RecentPostsTTL:   time.Minute,
+	}
+	mockHandler := new(MockCommandHandler)
+	bot, err := NewBot(cfg, zerolog.Nop(), mockHandler)
+	if err != nil {
+		t.Fatalf("Не удалось создать бота: %v", err)
+	}
+	bot.client = fc
+	bot.botUser = &mmclient.User{ID: "bot123"}
+	return bot, mockHandler, &posted
+}
+
+func postWebhook(bot *Bot, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	bot.webhookHandler(context.Background()).ServeHTTP(rec, req)
+	return rec
+}
+
+// TestWebhook_Reply проверяет, что команда из webhook в форме и в JSON
+// выполняется и ответ возвращается в теле ответа на webhook.
+func TestWebhook_Reply(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: webhookForm},
+		{name: "json", contentType: "application/json; charset=utf-8", body: webhookJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, mockHandler, posted := newWebhookBot(t, false)
+			mockHandler.On("ParseCommand", "!poll results p1").Return("results", []string{"p1"}, true, nil)
+			mockHandler.On("HandleCommand", mock.MatchedBy(func(ctx context.Context) bool {
+				origin := service.OriginFrom(ctx)
+				return origin.PostID == "post1" && origin.ChannelID == "channel1" && !origin.Direct
+			}), "results", []string{"p1"}, "user1").Return(handler.Response{Text: "Итоги опроса"}, nil)
+
+			rec := postWebhook(bot, tt.contentType, tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Ожидался статус 200, получен %d: %s", rec.Code, rec.Body.String())
+			}
+			var reply mmclient.WebhookResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+				t.Fatalf("Ответ не JSON: %v", err)
+			}
+			if reply.Text == nil || *reply.Text != "Итоги опроса" {
+				t.Errorf("Неверный ответ на webhook: %s", rec.Body.String())
+			}
+			if len(*posted) != 0 {
+				t.Errorf("Ответ не должен публиковаться отдельным сообщением")
+			}
+			mockHandler.AssertExpectations(t)
+		})
+	}
+}
+
+// TestWebhook_ReplyPost проверяет ответ отдельным сообщением через API.
+func TestWebhook_ReplyPost(t *testing.T) {
+	bot, mockHandler, posted := newWebhookBot(t, true)
+	mockHandler.On("ParseCommand", "!poll results p1").Return("results", []string{"p1"}, true, nil)
+	mockHandler.On("HandleCommand", mock.Anything, "results", []string{"p1"}, "user1").Return(handler.Response{Text: "Итоги опроса"}, nil)
+
+	rec := postWebhook(bot, "application/x-www-form-urlencoded", webhookForm)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("Ожидался пустой ответ 200, получен %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(*posted) != 1 || (*posted)[0].ChannelID != "channel1" || (*posted)[0].Message != "Итоги опроса" {
+		t.Errorf("Ответ не опубликован в канале: %+v", *posted)
+	}
+}
+
+// TestWebhook_Rejected проверяет отказ на неверный токен, метод и тело
+// без вызова обработчика команд.
+func TestWebhook_Rejected(t *testing.T) {
+	bot, mockHandler, _ := newWebhookBot(t, false)
+
+	rec := postWebhook(bot, "application/x-www-form-urlencoded", strings.Replace(webhookForm, "hook-token", "stolen", 1))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Ожидался статус 401 для чужого токена, получен %d", rec.Code)
+	}
+	rec = postWebhook(bot, "application/json", `{"token":`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус 400 для неверного JSON, получен %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	getRec := httptest.NewRecorder()
+	bot.webhookHandler(context.Background()).ServeHTTP(getRec, req)
+	if getRec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Ожидался статус 405 для GET, получен %d", getRec.Code)
+	}
+	mockHandler.AssertNotCalled(t, "ParseCommand", mock.Anything)
+}
+
+// TestWebhook_Redelivery проверяет, что повторная доставка webhook
+// и сообщения самого бота не выполняют команду.
+func TestWebhook_Redelivery(t *testing.T) {
+	bot, mockHandler, _ := newWebhookBot(t, false)
+	mockHandler.On("ParseCommand", "!poll results p1").Return("results", []string{"p1"}, true, nil).Once()
+	mockHandler.On("HandleCommand", mock.Anything, "results", []string{"p1"}, "user1").Return(handler.Response{Text: "Итоги опроса"}, nil).Once()
+
+	postWebhook(bot, "application/x-www-form-urlencoded", webhookForm)
+	rec := postWebhook(bot, "application/x-www-form-urlencoded", webhookForm)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("Повтор должен получить пустой ответ, получено: %s", rec.Body.String())
+	}
+
+	own := strings.Replace(strings.Replace(webhookForm, "user_id=user1", "user_id=bot123", 1), "post_id=post1", "post_id=post2", 1)
+	postWebhook(bot, "application/x-www-form-urlencoded", own)
+	mockHandler.AssertExpectations(t)
+}
+
+// TestNewBot_Mode проверяет проверку режима бота в конфигурации.
+func TestNewBot_Mode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		tokens  []string
+		wantErr bool
+	}{
+		{name: "default", mode: ""},
+		{name: "websocket", mode: config.ModeWebSocket},
+		{name: "webhook", mode: config.ModeWebhook, tokens: []string{"t"}},
+		{name: "webhook without tokens", mode: config.ModeWebhook, wantErr: true},
+		{name: "unknown", mode: "polling", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{MattermostURL: "http://dummy", BotToken: "dummy", Mode: tt.mode, WebhookTokens: tt.tokens}
+			_, err := NewBot(cfg, zerolog.Nop(), new(MockCommandHandler))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ожидалась ошибка: %v, получено: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestStart_Webhook проверяет, что в режиме webhook Start не открывает
+// WebSocket и завершается при отмене контекста.
+func TestStart_Webhook(t *testing.T) {
+	bot, _, _ := newWebhookBot(t, false)
+	bot.cfg.WebhookAddr = "127.0.0.1:0"
+	bot.client = &fakeClient{
+		getMeFunc: func() (*mmclient.User, error) {
+			return &mmclient.User{ID: "bot123"}, nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := bot.Start(ctx); err != context.Canceled {
+		t.Errorf("Ожидалась ошибка context.Canceled, получена: %v", err)
+	}
+	if bot.wsClient != nil {
+		t.Error("В режиме webhook WebSocket не должен создаваться")
+	}
+}
+
+// TestWebhook_Ephemeral проверяет, что ответ только автору отправляется через
+// API, а не в ответе на webhook, который увидел бы весь канал.
+func TestWebhook_Ephemeral(t *testing.T) {
+	bot, mockHandler, posted := newWebhookBot(t, false)
+	var recipients []string
+	var ephemeral []*mmclient.Post
+	bot.client.(*fakeClient).ephemeralFunc = func(userID string, post *mmclient.Post) (*mmclient.Post, error) {
+		recipients = append(recipients, userID)
+		ephemeral = append(ephemeral, post)
+		return post, nil
+	}
+	mockHandler.On("ParseCommand", "!poll results p1").Return("results", []string{"p1"}, true, nil)
+	mockHandler.On("HandleCommand", mock.Anything, "results", []string{"p1"}, "user1").Return(handler.Response{Text: "Формат", Ephemeral: true}, nil)
+
+	rec := postWebhook(bot, "application/x-www-form-urlencoded", webhookForm)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("Ожидался пустой ответ 200, получен %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(ephemeral) != 1 || recipients[0] != "user1" || ephemeral[0].Message != "Формат" {
+		t.Errorf("Ответ не отправлен автору %v: %+v", recipients, ephemeral)
+	}
+	if len(*posted) != 0 {
+		t.Errorf("Ответ не должен публиковаться в канале")
+	}
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/service"
+	"polling_bot/internal/wizard"
+)
+
+// SetWizard включает пошаговое создание опроса: create без аргументов в
+// личных сообщениях или create --wizard. Ответы мастеру приходят обычными
+// сообщениями без команды, поэтому мастер работает только с событиями
+// WebSocket: исходящие webhook таких сообщений не присылают.
+func (b *Bot) SetWizard(w *wizard.Wizard) {
+	b.wizard = w
+}
+
+// startWizard начинает сессию мастера и возвращает первый вопрос.
+func (b *Bot) startWizard(loc *i18n.Localizer, post *mmclient.Post, direct bool) handler.Response {
+	reply := b.wizard.Start(post.UserID, post.ChannelID, direct)
+	b.logger.Info().Str("channel_id", post.ChannelID).Msg("Запущен мастер создания опроса")
+	// В личных сообщениях вопросы мастера остаются в истории переписки
+	return handler.Response{Text: loc.T(reply.Prompt, reply.Args...), Ephemeral: !direct}
+}
+
+// wizardIn сообщает, может ли в канале быть ответ мастеру.
+func (b *Bot) wizardIn(channelID string) bool {
+	return b.wizard != nil && b.wizard.InChannel(channelID)
+}
+
+// answerWizard передаёт сообщение мастеру, если автор ведёт в этом канале
+// сессию, и сообщает, что сообщение обработано. Команды и правки мастеру
+// не передаются: они обрабатываются как обычно.
+func (b *Bot) answerWizard(ctx context.Context, post *mmclient.Post, edited, direct bool) bool {
+	if b.wizard == nil || edited || post.UserID == b.botUser.ID || !b.wizard.Active(post.UserID, post.ChannelID) {
+		return false
+	}
+	if _, _, isCommand, _ := b.commandHandler.ParseCommand(post.Message); isCommand {
+		return false
+	}
+	if !b.firstDelivery(post, false) {
+		return true
+	}
+
+	reply := b.wizard.Answer(post.UserID, post.ChannelID, post.Message)
+	if reply.Outcome == wizard.Finished {
+		b.finishWizard(ctx, post, reply.Session)
+		return true
+	}
+	if reply.Prompt != "" {
+		b.sendWizardPrompt(post.UserID, post.ChannelID, direct, b.localizerFor(post.UserID).T(reply.Prompt, reply.Args...))
+	}
+	return true
+}
+
+// finishWizard создаёт опрос по ответам сессии так же, как команда create
+// в канале, где запущен мастер. Если опрос создать не удалось, сессия
+// возвращается к вопросу о флагах.
+func (b *Bot) finishWizard(ctx context.Context, post *mmclient.Post, session wizard.Session) {
+	loc := b.localizerFor(post.UserID)
+	ctx = i18n.WithLocalizer(ctx, loc)
+	// По последнему ответу повторная доставка не создаст второй опрос
+	ctx = service.WithOrigin(ctx, service.Origin{
+		PostID:    post.ID,
+		ChannelID: session.ChannelID,
+		Direct:    session.Direct,
+	})
+
+	var pending pendingReply
+	b.startCreate(loc, session.ChannelID, &pending)
+	start := time.Now()
+	response, err := b.commandHandler.HandleCommand(ctx, createCommand, session.Args(), post.UserID)
+	latency := time.Since(start)
+	if err != nil {
+		b.logger.Error().Err(err).Dur("latency", latency).Msg("Мастер не смог создать опрос")
+		b.wizard.Restore(session)
+		response = handler.Response{Text: loc.T(i18n.WizardFailed, loc.Error(err)), Ephemeral: true}
+	} else {
+		b.logger.Info().Dur("latency", latency).Msg("Опрос создан мастером")
+	}
+	b.finishCreate(ctx, &mmclient.Post{UserID: post.UserID, ChannelID: session.ChannelID}, pending, response)
+}
+
+// sendWizardPrompt показывает вопрос мастера: в канале - только автору.
+func (b *Bot) sendWizardPrompt(userID, channelID string, direct bool, text string) {
+	if !direct && b.sendEphemeral(userID, channelID, text) {
+		return
+	}
+	b.sendResponse(channelID, text)
+}
@@ -0,0 +1,196 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/handler"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+	"polling_bot/internal/wizard"
+
+	"github.com/rs/zerolog"
+)
+
+// wizardEvent - сообщение пользователя user в канале channelID; в канале
+// без упоминания бота.
+func wizardEvent(postID, channelID, userID, message string, direct bool) *mmclient.WSEvent {
+	postBytes, _ := json.Marshal(&mmclient.Post{ID: postID, ChannelID: channelID, UserID: userID, Message: message})
+	channelType := mmclient.ChannelOpen
+	if direct {
+		channelType = mmclient.ChannelDirect
+	}
+	return &mmclient.WSEvent{
+		Type:      mmclient.EventPosted,
+		ChannelID: channelID,
+		Data:      map[string]interface{}{"post": string(postBytes), "channel_type": channelType},
+	}
+}
+
+// wizardBot - бот с мастером и настоящим обработчиком команд поверх
+// хранилища в памяти; posts собирает опубликованные и исправленные сообщения.
+type wizardBot struct {
+	*Bot
+	repo *repository.MemoryPollRepo
+
+	mu    sync.Mutex
+	posts []*mmclient.Post
+}
+
+func newWizardBot(cfg config.Config) *wizardBot {
+	repo := repository.NewMemoryPollRepo()
+	polls := service.NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	polls.SetAnonymousSecret("bot-secret")
+	wb := &wizardBot{repo: repo}
+	wb.Bot = &Bot{
+		cfg:            cfg,
+		logger:         zerolog.New(io.Discard),
+		botUser:        &mmclient.User{ID: "bot123"},
+		commandHandler: handler.NewPollCommandHandler(polls, i18n.New("ru"), handler.DefaultCommandPrefix),
+		localizer:      i18n.New("ru"),
+		filter:         newChannelFilter(cfg),
+		client: &fakeClient{createPostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			wb.mu.Lock()
+			defer wb.mu.Unlock()
+			wb.posts = append(wb.posts, post)
+			return &mmclient.Post{ID: "reply", ChannelID: post.ChannelID, Message: post.Message}, nil
+		}, updatePostFunc: func(post *mmclient.Post) (*mmclient.Post, error) {
+			wb.mu.Lock()
+			defer wb.mu.Unlock()
+			wb.posts = append(wb.posts, post)
+			return post, nil
+		}},
+		after: time.After,
+	}
+	wb.SetWizard(wizard.New(time.Minute))
+	return wb
+}
+
+// last возвращает последнее опубликованное сообщение.
+func (wb *wizardBot) last(t *testing.T) *mmclient.Post {
+	t.Helper()
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	if len(wb.posts) == 0 {
+		t.Fatal("Бот ничего не опубликовал")
+	}
+	return wb.posts[len(wb.posts)-1]
+}
+
+// TestWizard_DirectMessage проверяет сессию мастера в личных сообщениях:
+// от create без аргументов до опроса, созданного в том же канале.
+func TestWizard_DirectMessage(t *testing.T) {
+	wb := newWizardBot(config.Config{})
+	ctx := context.Background()
+	loc := i18n.New("ru")
+
+	steps := []struct {
+		message string
+		want    string
+	}{
+		{message: "!poll create", want: loc.T(i18n.WizardQuestion)},
+		{message: "Где обедаем?", want: loc.T(i18n.WizardFirstOption)},
+		{message: "Пицца", want: loc.T(i18n.WizardNextOption, 1)},
+		{message: "Суши", want: loc.T(i18n.WizardNextOption, 2)},
+		{message: "готово", want: loc.T(i18n.WizardFlags, 2)},
+	}
+	for i, step := range steps {
+		wb.handleWebSocketEvent(ctx, wizardEvent(string(rune('a'+i)), "dm1", "user1", step.message, true))
+		if got := wb.last(t); got.Message != step.want || got.ChannelID != "dm1" {
+			t.Errorf("На %q ответ %q в канале %s, ожидался %q", step.message, got.Message, got.ChannelID, step.want)
+		}
+	}
+
+	wb.handleWebSocketEvent(ctx, wizardEvent("flags", "dm1", "user1", "--expires soon", true))
+	if got := wb.last(t).Message; got != loc.T(i18n.WizardFailed, loc.Error(i18n.NewError(i18n.ExpiresInvalid))) {
+		t.Errorf("Ожидалась ошибка флагов, получено: %q", got)
+	}
+
+	wb.handleWebSocketEvent(ctx, wizardEvent("skip", "dm1", "user1", "пропустить", true))
+	polls, _, err := wb.repo.ListPolls(ctx, repository.ListFilter{})
+	if err != nil {
+		t.Fatalf("Ошибка чтения опросов: %v", err)
+	}
+	if len(polls) != 1 {
+		t.Fatalf("Ожидался один опрос, получено: %d", len(polls))
+	}
+	if poll := polls[0]; poll.Question != "Где обедаем?" || len(poll.Options) != 2 || poll.ChannelID != "dm1" || poll.Creator != "user1" {
+		t.Errorf("Создан не тот опрос: %+v", poll)
+	}
+
+	// Сессия закончена: следующее сообщение - не ответ мастеру
+	before := len(wb.posts)
+	wb.handleWebSocketEvent(ctx, wizardEvent("after", "dm1", "user1", "Борщ", true))
+	if len(wb.posts) != before {
+		t.Errorf("Сообщение после мастера получило ответ: %q", wb.last(t).Message)
+	}
+}
+
+// TestWizard_Channel проверяет мастер, запущенный в канале флагом --wizard:
+// ответы принимаются без упоминания бота только от автора сессии, а команды
+// посреди мастера выполняются как обычно.
+func TestWizard_Channel(t *testing.T) {
+	wb := newWizardBot(config.Config{RequireMention: true})
+	ctx := context.Background()
+
+	send := func(postID, userID, message string) {
+		wb.handleWebSocketEvent(ctx, wizardEvent(postID, "c1", userID, message, false))
+	}
+	send("p0", "user1", "!poll create")
+	if len(wb.posts) != 0 {
+		t.Fatalf("Без упоминания бот не должен отвечать, ответы: %d", len(wb.posts))
+	}
+
+	mention := wizardEvent("p1", "c1", "user1", "!poll create --wizard", false)
+	mention.Data["mentions"] = `["bot123"]`
+	wb.handleWebSocketEvent(ctx, mention)
+	send("p2", "user2", "Чужой вопрос")
+	send("p3", "user1", "Где обедаем?")
+	send("p4", "user1", "Пицца")
+	send("p5", "user1", "!poll results nope")
+	send("p6", "user1", "done")
+	send("p7", "user1", "--anonymous")
+
+	polls, _, err := wb.repo.ListPolls(ctx, repository.ListFilter{ChannelID: "c1"})
+	if err != nil {
+		t.Fatalf("Ошибка чтения опросов: %v", err)
+	}
+	if len(polls) != 1 {
+		t.Fatalf("Ожидался один опрос в канале, получено: %d", len(polls))
+	}
+	if poll := polls[0]; poll.Question != "Где обедаем?" || !poll.Anonymous() || poll.Creator != "user1" {
+		t.Errorf("Создан не тот опрос: %+v", poll)
+	}
+	for _, post := range wb.posts {
+		if post.Message == i18n.New("ru").T(i18n.WizardFirstOption) && post.ChannelID != "c1" {
+			t.Errorf("Вопрос мастера ушёл в канал %s", post.ChannelID)
+		}
+	}
+}
+
+// TestWizard_Timeout проверяет, что на ответ после таймаута бот сообщает
+// о закрытом мастере и больше не принимает ответы.
+func TestWizard_Timeout(t *testing.T) {
+	wb := newWizardBot(config.Config{})
+	ctx := context.Background()
+	wb.SetWizard(wizard.New(time.Nanosecond))
+
+	wb.handleWebSocketEvent(ctx, wizardEvent("p1", "dm1", "user1", "!poll create", true))
+	time.Sleep(time.Millisecond)
+	wb.handleWebSocketEvent(ctx, wizardEvent("p2", "dm1", "user1", "Где обедаем?", true))
+	if got, want := wb.last(t).Message, i18n.New("ru").T(i18n.WizardExpired); got != want {
+		t.Errorf("Ожидалось %q, получено: %q", want, got)
+	}
+	before := len(wb.posts)
+	wb.handleWebSocketEvent(ctx, wizardEvent("p3", "dm1", "user1", "Пицца", true))
+	if len(wb.posts) != before {
+		t.Errorf("Закрытый мастер ответил: %q", wb.last(t).Message)
+	}
+}
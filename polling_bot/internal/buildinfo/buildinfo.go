@@ -0,0 +1,57 @@
+// Package buildinfo - сведения о сборке бота: версия, коммит и дата.
+// Они задаются при сборке:
+//
+//	go build -ldflags "-X polling_bot/internal/buildinfo.Version=v1.4.0 \
+//	  -X polling_bot/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X polling_bot/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Сборка без -ldflags, например go run или go test, показывается как dev.
+package buildinfo
+
+// Значения из -ldflags; пустые, если их не передали
+var (
+	Version string
+	Commit  string
+	Date    string
+)
+
+// Dev - версия, коммит и дата сборки без -ldflags.
+const Dev = "dev"
+
+// Info - сведения о сборке. Теги JSON - поля тела GET /readyz.
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// Get возвращает сведения о текущей сборке.
+func Get() Info {
+	return New(Version, Commit, Date)
+}
+
+// New собирает сведения о сборке; не заданные значения становятся Dev.
+func New(version, commit, date string) Info {
+	return Info{Version: orDev(version), Commit: orDev(commit), Date: orDev(date)}
+}
+
+// IsDev сообщает, что версия сборки не задана.
+func (i Info) IsDev() bool {
+	return i.Version == Dev
+}
+
+// String показывает сборку одной строкой: v1.4.0 (abc1234, 2025-03-01T10:00:00Z);
+// сборка без коммита и даты - только версией.
+func (i Info) String() string {
+	if i.Commit == Dev && i.Date == Dev {
+		return i.Version
+	}
+	return i.Version + " (" + i.Commit + ", " + i.Date + ")"
+}
+
+func orDev(value string) string {
+	if value == "" {
+		return Dev
+	}
+	return value
+}
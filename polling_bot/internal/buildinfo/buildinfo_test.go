@@ -0,0 +1,58 @@
+package buildinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест проверяет сведения о сборке: значения, не переданные через -ldflags,
+// показываются как dev
+func TestInfo(t *testing.T) {
+	tests := []struct {
+		name                  string
+		version, commit, date string
+		want                  Info
+		wantString            string
+	}{
+		{
+			name:       "release",
+			version:    "v1.4.0",
+			commit:     "abc1234",
+			date:       "2025-03-01T10:00:00Z",
+			want:       Info{Version: "v1.4.0", Commit: "abc1234", Date: "2025-03-01T10:00:00Z"},
+			wantString: "v1.4.0 (abc1234, 2025-03-01T10:00:00Z)",
+		},
+		{
+			name:       "dev build",
+			want:       Info{Version: Dev, Commit: Dev, Date: Dev},
+			wantString: "dev",
+		},
+		{
+			name:       "version only",
+			version:    "v1.4.0",
+			want:       Info{Version: "v1.4.0", Commit: Dev, Date: Dev},
+			wantString: "v1.4.0",
+		},
+		{
+			name:       "commit without version",
+			commit:     "abc1234",
+			want:       Info{Version: Dev, Commit: "abc1234", Date: Dev},
+			wantString: "dev (abc1234, dev)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := New(tt.version, tt.commit, tt.date)
+			assert.Equal(t, tt.want, info)
+			assert.Equal(t, tt.wantString, info.String())
+			assert.Equal(t, tt.version == "", info.IsDev())
+		})
+	}
+}
+
+// Тест проверяет, что сборка тестов без -ldflags - dev
+func TestGet(t *testing.T) {
+	assert.Equal(t, Info{Version: Dev, Commit: Dev, Date: Dev}, Get())
+}
@@ -0,0 +1,79 @@
+// Package cmdparse делит текст команды бота на аргументы по правилам,
+// похожим на shell: кавычки объединяют слова, соседние части в кавычках
+// и без них склеиваются в один аргумент.
+package cmdparse
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Ошибки записи аргументов
+var (
+	ErrUnclosedQuote  = errors.New("незакрытая кавычка")
+	ErrTrailingEscape = errors.New("обратная косая черта в конце кавычек")
+)
+
+// Split делит input на аргументы. Аргументы разделяются пробельными
+// символами; двойные и одинарные кавычки объединяют слова, пустые
+// кавычки дают пустой аргумент, а части в кавычках и без них подряд, как в
+// foo"bar baz", образуют один аргумент. Внутри кавычек \ экранирует
+// следующий символ, кроме \n: он остаётся как есть, его переводит в
+// перенос строки команда. Вне кавычек \ - обычный символ. У незакрытой
+// кавычки возвращаются аргументы до неё и ошибка.
+func Split(input string) ([]string, error) {
+	var args []string
+	var buf strings.Builder
+	// Аргумент начат: пустые кавычки тоже начинают аргумент
+	inArg := false
+	var quote rune
+	escape := false
+
+	for _, r := range input {
+		switch {
+		case escape:
+			if r == 'n' {
+				buf.WriteRune('\\')
+			}
+			buf.WriteRune(r)
+			escape = false
+
+		case quote != 0:
+			switch r {
+			case '\\':
+				escape = true
+			case quote:
+				quote = 0
+			default:
+				buf.WriteRune(r)
+			}
+
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, buf.String())
+				buf.Reset()
+				inArg = false
+			}
+
+		default:
+			buf.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	switch {
+	case escape:
+		return args, ErrTrailingEscape
+	case quote != 0:
+		return args, ErrUnclosedQuote
+	}
+	if inArg {
+		args = append(args, buf.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,65 @@
+package cmdparse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест проверяет деление команды на аргументы
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "Пустая строка", input: "", want: nil},
+		{name: "Только пробелы", input: " \t\n ", want: nil},
+		{name: "Слова", input: `!poll vote p1 A`, want: []string{"!poll", "vote", "p1", "A"}},
+		{name: "Лишние пробелы", input: "  a \t b  ", want: []string{"a", "b"}},
+		{name: "Кавычки объединяют слова", input: `create "Где обедаем?" 'Суши бар'`, want: []string{"create", "Где обедаем?", "Суши бар"}},
+		{name: "Кавычки другого вида внутри", input: `"arg'1" 'arg"2'`, want: []string{`arg'1`, `arg"2`}},
+		{name: "Пустые кавычки", input: `a "" '' b`, want: []string{"a", "", "", "b"}},
+		{name: "Пустые кавычки в конце", input: `a ""`, want: []string{"a", ""}},
+		{name: "Кавычки после текста", input: `foo"bar baz"`, want: []string{"foobar baz"}},
+		{name: "Текст после кавычек", input: `"bar baz"qux next`, want: []string{"bar bazqux", "next"}},
+		{name: "Несколько частей подряд", input: `a"b"'c'd`, want: []string{"abcd"}},
+		{name: "Экранирование в кавычках", input: `"arg\"1" "arg\\2"`, want: []string{`arg"1`, `arg\2`}},
+		{name: "Перенос строки остаётся", input: `"Строка 1\nстрока 2" \n`, want: []string{`Строка 1\nстрока 2`, `\n`}},
+		{name: "Обратная косая черта вне кавычек", input: `a\b c\`, want: []string{`a\b`, `c\`}},
+		{name: "Последнее слово многобайтное", input: `!poll vote id Да`, want: []string{"!poll", "vote", "id", "Да"}},
+		{name: "Последний символ многобайтный в кавычках", input: `vote "Пицца"`, want: []string{"vote", "Пицца"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Split(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Тест проверяет ошибки записи: незакрытую кавычку и обратную косую
+// черту в конце кавычек
+func TestSplit_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{name: "Незакрытая кавычка", input: `create "Q "A"`, want: []string{"create"}, wantErr: ErrUnclosedQuote},
+		{name: "Незакрытая одинарная кавычка", input: `vote p1 'Пицца`, want: []string{"vote", "p1"}, wantErr: ErrUnclosedQuote},
+		{name: "Экранированная закрывающая кавычка", input: `vote "A\"`, want: []string{"vote"}, wantErr: ErrUnclosedQuote},
+		{name: "Обратная косая черта в конце", input: `vote "A\`, want: []string{"vote"}, wantErr: ErrTrailingEscape},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Split(tt.input)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
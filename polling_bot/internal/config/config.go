@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -9,15 +12,225 @@ type Config struct {
 	MattermostURL string
 	BotToken      string
 	HTTPTimeout   time.Duration
+	// Язык ответов бота: "ru" или "en"
+	Language string
+	// Отвечать пользователю на языке из его профиля Mattermost, если он поддерживается
+	UserLocale bool
+	// Основной префикс команд и дополнительные префиксы-псевдонимы
+	CommandPrefix  string
+	CommandAliases []string
+	// Сколько недавних сообщений и как долго помнить: для защиты
+	// от повторной доставки событий и для обработки правок
+	RecentPostsSize int
+	RecentPostsTTL  time.Duration
+	// Сколько после голоса узнавать повторную доставку его сообщения и
+	// отвечать на неё как на первую; 0 - повтор отклоняется как повторный голос
+	VoteKeyTTL time.Duration
+	// Не больше одного открытого опроса в канале
+	OnePollPerChannel bool
+	// Сколько опросов за сутки можно создать в одном канале; 0 - без
+	// ограничения. Сутки начинаются в полночь по часовому поясу
+	// PollLimitTimezone (имя из базы IANA или Local - время сервера бота)
+	MaxPollsPerChannelPerDay int
+	PollLimitTimezone        string
+	// ID пользователей Mattermost, которым доступны команды администратора
+	Admins []string
+	// ID учётных записей ботов и интеграций, команды которых бот выполняет;
+	// сообщения остальных ботов и webhook игнорируются
+	AllowedIntegrationIDs []string
+	// Сколько команд в минуту может отправить один участник; 0 - без ограничения
+	CommandRateLimit int
+	// Каналы и команды Mattermost, в которых бот обрабатывает сообщения;
+	// пусто - все. Запрещённые каналы не обрабатываются никогда, личные
+	// сообщения боту - всегда
+	AllowedChannelIDs []string
+	BlockedChannelIDs []string
+	AllowedTeamIDs    []string
+	// В каналах обрабатывать только сообщения с упоминанием бота
+	RequireMention bool
+	// Адрес HTTP-сервера проверки работоспособности, метрик и API опросов
+	HTTPAddr string
+	// Адрес отладочного сервера с pprof и счётчиками /debug/stats; пусто -
+	// сервер выключен, адрес без хоста слушается только на localhost
+	DebugAddr string
+	// Bearer-токен чтения API опросов
+	APIToken string
+	// Bearer-токен чтения и создания опросов; пусто - создание выключено
+	APIWriteToken string
+	// Создатель опросов, созданных через API без user_id
+	APIServiceUser string
+
+	// Источник событий Mattermost: ModeWebSocket или ModeWebhook
+	Mode string
+	// Адрес, на который Mattermost отправляет исходящие webhook
+	WebhookAddr string
+	// Токены исходящих webhook, запросы с другим токеном отклоняются
+	WebhookTokens []string
+	// Отвечать отдельным сообщением через API вместо ответа на webhook
+	WebhookReplyPost bool
+
+	// Сколько мастер пошагового создания опроса ждёт ответа, прежде чем
+	// закрыть сессию; в режиме webhook мастер выключен
+	WizardTimeout time.Duration
+
+	// Отмечать сообщения с командами реакцией: выполнена или нет
+	Reactions bool
+	// На голос отвечать только реакцией, без сообщения
+	ReactionsOnly bool
+	// Показывать итоги опроса вложением с цветной полосой вместо текста
+	RichResults bool
+	// Показывать итоги таблицей Markdown, а не только с results --table;
+	// таблица важнее вложения
+	ResultsTable bool
+	// Сколько символов варианта помещается в таблицу итогов
+	ResultsTableWidth int
+
+	// Рассылать итоги участникам во всех опросах, а не только с --notify-voters
+	NotifyVoters bool
+	// Сколько личных сообщений с итогами отправлять одновременно и не чаще
+	// какого интервала, чтобы большой опрос не перегружал API Mattermost
+	NotifyConcurrency int
+	NotifyInterval    time.Duration
+	// Присылать квитанции о голосе во всех опросах, а не только с --receipts
+	VoteReceipts bool
+	// Запретить создателю голосовать во всех опросах, а не только с
+	// --no-self-vote
+	DisallowSelfVoteDefault bool
+	// Ключ HMAC для ключей участников анонимных опросов; хранится вне базы.
+	// Пусто - анонимные опросы не создаются
+	AnonymousSecret string
+	// Опросы с --members-only: сколько помнить подтверждённое членство в
+	// канале и принимать ли голос, если Mattermost не ответил
+	MembershipCacheTTL  time.Duration
+	MembersOnlyFailOpen bool
+
+	// Очередь исходящих запросов к Mattermost: сколько запросов в секунду
+	// и сколько подряд без паузы отправлять (0 - без ограничения частоты)
+	// и сколько при остановке ждать отправки уже поставленных в очередь
+	OutboxRate         float64
+	OutboxBurst        int
+	OutboxDrainTimeout time.Duration
+
+	// Срок опроса, созданного без --expires; 0 - такие опросы без срока
+	DefaultPollTTL time.Duration
+	// Разрешить создавать опросы без срока флагом --no-expire
+	AllowNoExpire bool
+
+	// Сколько символов может занимать пояснение к опросу (--desc)
+	MaxDescriptionLength int
+	// Запретить ссылки в вопросе и опциях опроса
+	BlockLinksInPolls bool
+
+	// Час (по времени сервера бота), в который каналы с digest on получают
+	// сводку открытых опросов
+	DigestHour int
+
+	// Как часто записывать накопленную статистику команд в хранилище
+	UsageFlushInterval time.Duration
+
+	// Опросы, голоса за варианты которых отдаются метрикой poll_votes в
+	// /metrics HTTP-сервера; не больше MaxTrackedPolls. Метрики сверяются с
+	// хранилищем раз в TrackedPollsSyncInterval, а у закрытого опроса
+	// убираются через TrackedPollsGrace
+	TrackedPolls             []string
+	TrackedPollsSyncInterval time.Duration
+	TrackedPollsGrace        time.Duration
+}
+
+// Сколько опросов можно отслеживать: у каждого варианта свой ряд метрики,
+// и сборщик метрик не должен получать их без ограничения
+const MaxTrackedPolls = 50
+
+// Источник событий Mattermost: WebSocket (по умолчанию) или исходящие
+// webhook для установок, где боту запрещено WebSocket-соединение
+const (
+	ModeWebSocket = "websocket"
+	ModeWebhook   = "webhook"
+)
+
+// Validate проверяет настройки, без которых бот не запустится.
+func (c Config) Validate() error {
+	if c.MattermostURL == "" || c.BotToken == "" {
+		return fmt.Errorf("mattermost URL и токен обязательны для настройки")
+	}
+	switch c.Mode {
+	case "", ModeWebSocket:
+	case ModeWebhook:
+		if len(c.WebhookTokens) == 0 {
+			return fmt.Errorf("для режима webhook нужен хотя бы один токен исходящего webhook")
+		}
+	default:
+		return fmt.Errorf("неизвестный режим бота %q", c.Mode)
+	}
+	if c.DigestHour < 0 || c.DigestHour > 23 {
+		return fmt.Errorf("час сводки опросов должен быть от 0 до 23, указан %d", c.DigestHour)
+	}
+	if _, err := time.LoadLocation(c.PollLimitTimezone); err != nil {
+		return fmt.Errorf("неизвестный часовой пояс лимита опросов %q", c.PollLimitTimezone)
+	}
+	if len(c.TrackedPolls) > MaxTrackedPolls {
+		return fmt.Errorf("отслеживать можно не больше %d опросов, указано %d", MaxTrackedPolls, len(c.TrackedPolls))
+	}
+	return nil
 }
 
 type TarantoolConfig struct {
-	Address  string
-	User     string
-	Password string
-	Database string
-	Retries  int
-	Timeout  time.Duration
+	// Адреса экземпляров набора реплик; бот выбирает доступный на запись
+	// и переключается на следующий, если он перестал отвечать
+	Addresses []string
+	User      string
+	Password  string
+	Database  string
+	Retries   int
+	Timeout   time.Duration
+	// Сколько соединений открывать к экземпляру: запросы распределяются
+	// между ними по кругу
+	PoolSize int
+	// Пауза между попытками переподключения драйвера после обрыва связи
+	ReconnectInterval time.Duration
+	// Ограничение числа переподключений, 0 - переподключаться бесконечно
+	MaxReconnects uint
+	// Число попыток записи голоса при конфликте транзакций
+	VoteRetries int
+	// Базовая пауза экспоненциального backoff между попытками записи голоса
+	VoteRetryDelay time.Duration
+	// Space расписаний повторяющихся опросов
+	Schedules string
+	// Space шаблонов опросов
+	Templates string
+	// Space журнала событий опросов
+	Audit string
+	// Space голосов участников
+	Votes string
+	// Space настроек каналов
+	ChannelSettings string
+	// Space статистики команд по дням
+	Usage string
+}
+
+// Хранилище опросов: "tarantool" (по умолчанию) или "postgres"
+const (
+	StorageTarantool = "tarantool"
+	StoragePostgres  = "postgres"
+)
+
+type StorageConfig struct {
+	Backend     string
+	PostgresDSN string
+	// Время жизни опроса в кэше чтения
+	CacheTTL time.Duration
+	// Сколько опросов держать в кэше, 0 - кэш выключен
+	CacheSize int
+}
+
+// Validate проверяет, что выбрано известное хранилище.
+func (c StorageConfig) Validate() error {
+	switch c.Backend {
+	case StorageTarantool, StoragePostgres:
+		return nil
+	default:
+		return fmt.Errorf("неизвестное хранилище STORAGE_BACKEND=%q", c.Backend)
+	}
 }
 
 func Load() Config {
@@ -25,16 +238,162 @@ func Load() Config {
 		MattermostURL: os.Getenv("MATTERMOST_URL"),
 		BotToken:      os.Getenv("BOT_TOKEN"),
 		HTTPTimeout:   10 * time.Second,
+		Language:      getEnv("BOT_LANGUAGE", "ru"),
+		UserLocale:    getEnvBool("BOT_USER_LOCALE", false),
+
+		CommandPrefix:  getEnv("COMMAND_PREFIX", "!poll"),
+		CommandAliases: getEnvList("COMMAND_ALIASES"),
+
+		RecentPostsSize: getEnvInt("BOT_RECENT_POSTS_SIZE", 1000),
+		RecentPostsTTL:  getEnvDuration("BOT_RECENT_POSTS_TTL", 10*time.Minute),
+		VoteKeyTTL:      getEnvDuration("BOT_VOTE_KEY_TTL", 10*time.Minute),
+
+		OnePollPerChannel: getEnvBool("BOT_ONE_POLL_PER_CHANNEL", false),
+		Admins:            getEnvList("BOT_ADMINS"),
+		CommandRateLimit:  getEnvInt("BOT_COMMAND_RATE_LIMIT", 0),
+
+		AllowedIntegrationIDs: getEnvList("BOT_ALLOWED_INTEGRATIONS"),
+
+		MaxPollsPerChannelPerDay: getEnvInt("BOT_MAX_POLLS_PER_CHANNEL_PER_DAY", 0),
+		PollLimitTimezone:        getEnv("BOT_POLL_LIMIT_TIMEZONE", "Local"),
+
+		AllowedChannelIDs: getEnvList("BOT_ALLOWED_CHANNELS"),
+		BlockedChannelIDs: getEnvList("BOT_BLOCKED_CHANNELS"),
+		AllowedTeamIDs:    getEnvList("BOT_ALLOWED_TEAMS"),
+		RequireMention:    getEnvBool("BOT_REQUIRE_MENTION", false),
+
+		HTTPAddr:       getEnv("HTTP_ADDR", ":8080"),
+		DebugAddr:      os.Getenv("DEBUG_ADDR"),
+		APIToken:       os.Getenv("API_TOKEN"),
+		APIWriteToken:  os.Getenv("API_WRITE_TOKEN"),
+		APIServiceUser: getEnv("API_SERVICE_USER", "api"),
+
+		Mode:             getEnv("BOT_MODE", ModeWebSocket),
+		WebhookAddr:      getEnv("BOT_WEBHOOK_ADDR", ":8081"),
+		WebhookTokens:    getEnvList("BOT_WEBHOOK_TOKENS"),
+		WebhookReplyPost: getEnvBool("BOT_WEBHOOK_REPLY_POST", false),
+
+		WizardTimeout: getEnvDuration("BOT_WIZARD_TIMEOUT", 10*time.Minute),
+
+		Reactions:     getEnvBool("BOT_REACTIONS", true),
+		ReactionsOnly: getEnvBool("BOT_REACTIONS_ONLY", false),
+		RichResults:   getEnvBool("BOT_RICH_RESULTS", false),
+
+		ResultsTable:      getEnvBool("BOT_RESULTS_TABLE", false),
+		ResultsTableWidth: getEnvInt("BOT_RESULTS_TABLE_WIDTH", 40),
+
+		NotifyVoters:      getEnvBool("BOT_NOTIFY_VOTERS", false),
+		NotifyConcurrency: getEnvInt("BOT_NOTIFY_CONCURRENCY", 5),
+		NotifyInterval:    getEnvDuration("BOT_NOTIFY_INTERVAL", 100*time.Millisecond),
+		VoteReceipts:      getEnvBool("BOT_VOTE_RECEIPTS", false),
+
+		DisallowSelfVoteDefault: getEnvBool("BOT_DISALLOW_SELF_VOTE", false),
+		AnonymousSecret:         os.Getenv("BOT_ANONYMOUS_SECRET"),
+
+		MembershipCacheTTL:  getEnvDuration("BOT_MEMBERSHIP_CACHE_TTL", time.Minute),
+		MembersOnlyFailOpen: getEnvBool("BOT_MEMBERS_ONLY_FAIL_OPEN", false),
+
+		OutboxRate:         getEnvFloat("BOT_OUTBOX_RATE", 10),
+		OutboxBurst:        getEnvInt("BOT_OUTBOX_BURST", 20),
+		OutboxDrainTimeout: getEnvDuration("BOT_OUTBOX_DRAIN_TIMEOUT", 10*time.Second),
+
+		DefaultPollTTL: getEnvDuration("BOT_DEFAULT_POLL_TTL", 0),
+		AllowNoExpire:  getEnvBool("BOT_ALLOW_NO_EXPIRE", false),
+
+		MaxDescriptionLength: getEnvInt("BOT_MAX_DESCRIPTION_LENGTH", 1000),
+		BlockLinksInPolls:    getEnvBool("BOT_BLOCK_LINKS_IN_POLLS", false),
+
+		DigestHour: getEnvInt("BOT_DIGEST_HOUR", 9),
+
+		UsageFlushInterval: getEnvDuration("BOT_USAGE_FLUSH_INTERVAL", time.Minute),
+
+		TrackedPolls:             getEnvList("BOT_TRACKED_POLLS"),
+		TrackedPollsSyncInterval: getEnvDuration("BOT_TRACKED_POLLS_SYNC_INTERVAL", time.Minute),
+		TrackedPollsGrace:        getEnvDuration("BOT_TRACKED_POLLS_GRACE", time.Hour),
 	}
 }
 
 func TarantoolConfigLoad() TarantoolConfig {
 	return TarantoolConfig{
-		Address:  os.Getenv("TARANTOOL_ADDR"),
-		User:     os.Getenv("TARANTOOL_USER"),
-		Password: os.Getenv("TARANTOOL_PASSWORD"),
-		Database: os.Getenv("TARANTOOL_DATABASE"),
-		Retries:  5,
-		Timeout:  5 * time.Second,
-	}
-}
\ No newline at end of file
+		Addresses: getEnvList("TARANTOOL_ADDR"),
+		User:      os.Getenv("TARANTOOL_USER"),
+		Password:  os.Getenv("TARANTOOL_PASSWORD"),
+		Database:  os.Getenv("TARANTOOL_DATABASE"),
+		Retries:   5,
+		Timeout:   5 * time.Second,
+
+		PoolSize:          getEnvInt("TARANTOOL_POOL_SIZE", 1),
+		ReconnectInterval: getEnvDuration("TARANTOOL_RECONNECT_INTERVAL", time.Second),
+		MaxReconnects:     uint(getEnvInt("TARANTOOL_MAX_RECONNECTS", 0)),
+
+		VoteRetries:    getEnvInt("TARANTOOL_VOTE_RETRIES", 5),
+		VoteRetryDelay: getEnvDuration("TARANTOOL_VOTE_RETRY_DELAY", 20*time.Millisecond),
+
+		Schedules:       getEnv("TARANTOOL_SCHEDULES", "poll_schedules"),
+		Templates:       getEnv("TARANTOOL_TEMPLATES", "poll_templates"),
+		ChannelSettings: getEnv("TARANTOOL_CHANNEL_SETTINGS", "poll_channel_settings"),
+		Usage:           getEnv("TARANTOOL_USAGE", "poll_usage"),
+		Audit:           getEnv("TARANTOOL_AUDIT", "poll_audit"),
+		Votes:           getEnv("TARANTOOL_VOTES", "poll_votes"),
+	}
+}
+
+func StorageConfigLoad() StorageConfig {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = StorageTarantool
+	}
+	return StorageConfig{
+		Backend:     backend,
+		PostgresDSN: os.Getenv("POSTGRES_DSN"),
+
+		CacheTTL:  getEnvDuration("POLL_CACHE_TTL", 2*time.Second),
+		CacheSize: getEnvInt("POLL_CACHE_SIZE", 1000),
+	}
+}
+
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getEnvList разбирает список через запятую, пропуская пустые элементы.
+func getEnvList(key string) []string {
+	var out []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func getEnvBool(key string, def bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+func getEnvFloat(key string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+func getEnvInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
@@ -1,7 +1,10 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"polling_bot/internal/config"
@@ -10,35 +13,76 @@ import (
 	"github.com/tarantool/go-tarantool"
 )
 
-type TarantoolConnection struct {
-	conn *tarantool.Connection
+// conn - методы соединения go-tarantool, которыми пользуется Pool;
+// в тестах подменяется. Запросы отправляются объектами запросов через Do:
+// так у каждого запроса свой контекст.
+type conn interface {
+	ConnectedNow() bool
+	Do(req tarantool.Request) *tarantool.Future
+	Close() error
 }
 
-func ConnectWithRetry(cfg config.TarantoolConfig, logger zerolog.Logger) (*TarantoolConnection, error) {
-	opts := tarantool.Opts{
-		User:          cfg.User,
-		Pass:          cfg.Password,
-		Timeout:       cfg.Timeout,
-		Reconnect:     0,              
-		MaxReconnects: 0,              
+// dialFunc открывает соединение с одним адресом Tarantool.
+type dialFunc func(addr string, opts tarantool.Opts) (conn, error)
+
+func dialTarantool(addr string, opts tarantool.Opts) (conn, error) {
+	c, err := tarantool.Connect(addr, opts)
+	if err != nil {
+		return nil, err
 	}
+	return c, nil
+}
 
-	var conn *tarantool.Connection
-	var err error
+// Как часто пул проверяет соединения и заменяет закрытые
+const poolCheckInterval = 5 * time.Second
 
-	logger.Debug().Str("address", cfg.Address).Msg("Connecting to Tarantool")
-	
+// Pool - соединения с одним из экземпляров набора реплик Tarantool. Каждый
+// запрос репозитория выполняется на следующем по кругу живом соединении:
+// go-tarantool отправляет запросы одного соединения через один сокет,
+// и при всплеске голосов они ждут друг друга. Соединения меняются при
+// переключении (Failover) и при замене закрытых, поэтому репозитории держат
+// Pool, а не *tarantool.Connection.
+type Pool struct {
+	cfg    config.TarantoolConfig
+	logger zerolog.Logger
+	dial   dialFunc
+
+	mu      sync.RWMutex
+	members []conn
+	// Индекс адреса текущих соединений в cfg.Addresses
+	current int
+	next    atomic.Uint64
+
+	// Переключения выполняются по одному
+	failoverMu sync.Mutex
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// ConnectWithRetry подключается к первому доступному на запись адресу из
+// cfg.Addresses, перебирая их по порядку, и повторяет перебор cfg.Retries раз.
+// К выбранному экземпляру открывается cfg.PoolSize соединений.
+func ConnectWithRetry(cfg config.TarantoolConfig, logger zerolog.Logger) (*Pool, error) {
+	return connectWithRetry(cfg, logger, dialTarantool)
+}
+
+func connectWithRetry(cfg config.TarantoolConfig, logger zerolog.Logger, dial dialFunc) (*Pool, error) {
+	if len(cfg.Addresses) == 0 {
+		return nil, fmt.Errorf("не задан адрес Tarantool")
+	}
+	cfg.PoolSize = max(cfg.PoolSize, 1)
+	p := &Pool{cfg: cfg, logger: logger, dial: dial, stop: make(chan struct{}), done: make(chan struct{})}
+
+	logger.Debug().Strs("addresses", cfg.Addresses).Int("pool_size", cfg.PoolSize).Msg("Connecting to Tarantool")
+
+	var err error
 	for attempt := 1; attempt <= cfg.Retries; attempt++ {
-		conn, err = tarantool.Connect(cfg.Address, opts)
-		if err == nil {
-			_, pingErr := conn.Ping()
-			if pingErr == nil {
-				logger.Info().Msg("Успешное подключение к Tarantool")
-				return &TarantoolConnection{conn: conn}, nil
-			}
-			
-			conn.Close()
-			err = fmt.Errorf("ping failed: %w", pingErr)
+		if err = p.connectFrom(0); err == nil {
+			logger.Info().Str("address", p.address()).Msg("Успешное подключение к Tarantool")
+			go p.maintain(poolCheckInterval)
+			return p, nil
 		}
 
 		logger.Error().
@@ -57,13 +101,407 @@ func ConnectWithRetry(cfg config.TarantoolConfig, logger zerolog.Logger) (*Taran
 	return nil, fmt.Errorf("не удалось подключиться после %d попыток, последняя ошибка: %w", cfg.Retries, err)
 }
 
-func (t *TarantoolConnection) Close() error {
-	if t.conn != nil {
-		return t.conn.Close()
+// connectFrom перебирает адреса по кругу начиная со start и делает текущим
+// первый экземпляр, доступный на запись. Если все доступные экземпляры
+// только для чтения, текущим становится первый из них: чтение продолжит
+// работать, пока реплики выбирают новый master.
+func (p *Pool) connectFrom(start int) error {
+	var (
+		fallback      conn
+		fallbackIndex int
+		lastErr       error
+	)
+	n := len(p.cfg.Addresses)
+	for i := 0; i < n; i++ {
+		index := (start + i) % n
+		c, err := p.open(p.cfg.Addresses[index])
+		if err != nil {
+			p.logger.Debug().Str("address", p.cfg.Addresses[index]).Err(err).Msg("Экземпляр Tarantool недоступен")
+			lastErr = err
+			continue
+		}
+		if !p.readOnly(c) {
+			if fallback != nil {
+				fallback.Close()
+			}
+			p.swap(p.fill(c, index), index)
+			return nil
+		}
+		if fallback == nil {
+			fallback, fallbackIndex = c, index
+		} else {
+			c.Close()
+		}
+	}
+
+	if fallback != nil {
+		p.logger.Warn().Str("address", p.cfg.Addresses[fallbackIndex]).Msg("Все доступные экземпляры Tarantool только для чтения")
+		p.swap(p.fill(fallback, fallbackIndex), fallbackIndex)
+		return nil
+	}
+	return lastErr
+}
+
+// fill дополняет проверенное соединение first до cfg.PoolSize соединений
+// с тем же адресом. Соединения, которые не удалось открыть, остаются
+// закрытыми: их заменит maintain.
+func (p *Pool) fill(first conn, index int) []conn {
+	members := make([]conn, p.cfg.PoolSize)
+	members[0] = first
+	for i := 1; i < len(members); i++ {
+		c, err := p.open(p.cfg.Addresses[index])
+		if err != nil {
+			p.logger.Warn().Str("address", p.cfg.Addresses[index]).Err(err).Msg("Не удалось открыть соединение пула Tarantool")
+			c = closedConn{}
+		}
+		members[i] = c
+	}
+	return members
+}
+
+// open подключается к addr и проверяет соединение ping.
+func (p *Pool) open(addr string) (conn, error) {
+	// Свой канал на каждое соединение, чтобы события закрытых соединений не достались наблюдателю
+	events := make(chan tarantool.ConnEvent, 16)
+	opts := tarantool.Opts{
+		User:          p.cfg.User,
+		Pass:          p.cfg.Password,
+		Timeout:       p.cfg.Timeout,
+		Reconnect:     p.cfg.ReconnectInterval,
+		MaxReconnects: p.cfg.MaxReconnects,
+		Notify:        events,
+	}
+
+	c, err := p.dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.Do(tarantool.NewPingRequest()).Get(); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("ping failed: %w", err)
+	}
+	go watchConnection(events, addr, p.logger)
+	return c, nil
+}
+
+// readOnly спрашивает у экземпляра box.info.ro. Если ответа нет (например,
+// пользователю не разрешён eval), экземпляр считается доступным на запись:
+// с одним адресом выбирать всё равно не из чего.
+func (p *Pool) readOnly(c conn) bool {
+	resp, err := c.Do(tarantool.NewEvalRequest("return box.info.ro").Args([]interface{}{})).Get()
+	if err != nil || resp == nil || len(resp.Data) == 0 {
+		p.logger.Debug().Err(err).Msg("Не удалось узнать, доступен ли Tarantool на запись")
+		return false
+	}
+	ro, _ := resp.Data[0].(bool)
+	return ro
+}
+
+// swap делает members текущими соединениями и закрывает прежние.
+func (p *Pool) swap(members []conn, index int) {
+	p.mu.Lock()
+	old := p.members
+	p.members, p.current = members, index
+	p.mu.Unlock()
+
+	for _, c := range old {
+		c.Close()
+	}
+}
+
+// maintain раз в interval заменяет соединения пула, которые закрылись
+// окончательно или переподключаются, новыми соединениями с тем же адресом.
+// Завершается по Close.
+func (p *Pool) maintain(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.replaceDead()
+		}
+	}
+}
+
+func (p *Pool) replaceDead() {
+	p.mu.RLock()
+	members := append([]conn(nil), p.members...)
+	index := p.current
+	p.mu.RUnlock()
+
+	for i, c := range members {
+		if c.ConnectedNow() {
+			continue
+		}
+		fresh, err := p.open(p.cfg.Addresses[index])
+		if err != nil {
+			p.logger.Debug().Str("address", p.cfg.Addresses[index]).Err(err).Msg("Не удалось заменить соединение пула Tarantool")
+			// Экземпляр недоступен: остальные соединения не откроются тоже
+			return
+		}
+
+		p.mu.Lock()
+		// Пока открывалось соединение, пул мог переключиться на другой адрес
+		replaced := p.current == index && p.members[i] == c
+		if replaced {
+			p.members[i] = fresh
+		}
+		p.mu.Unlock()
+
+		if replaced {
+			c.Close()
+			p.logger.Info().Int("member", i).Msg("Соединение пула Tarantool заменено")
+		} else {
+			fresh.Close()
+		}
+	}
+}
+
+// Failover переключается на следующий адрес, если текущее соединение
+// потеряно или экземпляр стал только для чтения. Одновременные вызовы
+// выполняются по одному: следующий застанет исправное соединение и ничего
+// не изменит.
+func (p *Pool) Failover(ctx context.Context) error {
+	p.failoverMu.Lock()
+	defer p.failoverMu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c, index := p.snapshot()
+	if c.ConnectedNow() && !p.readOnly(c) {
+		return nil
+	}
+	if err := p.connectFrom(index + 1); err != nil {
+		return fmt.Errorf("не удалось переключиться на другой экземпляр Tarantool: %w", err)
+	}
+	if addr := p.address(); addr != p.cfg.Addresses[index] {
+		p.logger.Warn().Str("from", p.cfg.Addresses[index]).Str("to", addr).Msg("Соединение с Tarantool переключено")
 	}
 	return nil
 }
 
-func (t *TarantoolConnection) Connection() *tarantool.Connection {
-	return t.conn
+// watchConnection логирует смену состояния соединения, пока драйвер
+// переподключается в фоне. Завершается, когда соединение закрыто окончательно.
+func watchConnection(events <-chan tarantool.ConnEvent, addr string, logger zerolog.Logger) {
+	logger = logger.With().Str("address", addr).Logger()
+	for event := range events {
+		switch event.Kind {
+		case tarantool.Disconnected:
+			logger.Warn().Msg("Соединение с Tarantool потеряно, переподключение")
+		case tarantool.ReconnectFailed:
+			logger.Debug().Msg("Попытка переподключения к Tarantool не удалась")
+		case tarantool.Connected:
+			logger.Info().Msg("Соединение с Tarantool восстановлено")
+		case tarantool.Closed:
+			logger.Info().Msg("Соединение с Tarantool закрыто")
+			return
+		}
+	}
+}
+
+// snapshot возвращает следующее по кругу живое соединение и индекс его
+// адреса. Если живых нет, возвращается любое: запрос на нём вернёт
+// ошибку драйвера, и репозиторий попробует переключиться.
+func (p *Pool) snapshot() (conn, int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	n := uint64(len(p.members))
+	if n == 0 {
+		return closedConn{}, p.current
+	}
+	start := p.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if c := p.members[(start+i)%n]; c.ConnectedNow() {
+			return c, p.current
+		}
+	}
+	return p.members[start%n], p.current
+}
+
+func (p *Pool) get() conn {
+	c, _ := p.snapshot()
+	return c
+}
+
+// address возвращает адрес текущего соединения.
+func (p *Pool) address() string {
+	_, index := p.snapshot()
+	return p.cfg.Addresses[index]
+}
+
+// Healthy сообщает, есть ли в пуле хотя бы одно установленное соединение.
+func (p *Pool) Healthy() bool {
+	return p.get().ConnectedNow()
+}
+
+// Ping проверяет, что Tarantool отвечает на запросы, и возвращает время
+// ответа текущего соединения.
+func (p *Pool) Ping(ctx context.Context) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	c := p.get()
+	if c == nil {
+		return 0, fmt.Errorf("нет соединения с Tarantool")
+	}
+	ctx, cancel := p.requestContext(ctx)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Do(tarantool.NewPingRequest().Context(ctx)).Get(); err != nil {
+		return 0, fmt.Errorf("Tarantool не отвечает: %w", err)
+	}
+	return time.Since(start), nil
+}
+
+// Close закрывает все соединения пула и останавливает их проверку.
+func (p *Pool) Close() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+
+	p.mu.Lock()
+	members := p.members
+	p.members = nil
+	p.mu.Unlock()
+
+	var firstErr error
+	for _, c := range members {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Методы repository.Connector выполняются на следующем соединении пула.
+
+func (p *Pool) ConnectedNow() bool {
+	return p.Healthy()
+}
+
+func (p *Pool) Insert(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	resp, err := p.get().Do(tarantool.NewInsertRequest(space).Tuple(tuple).Context(reqCtx)).Get()
+	return resp, requestError(ctx, reqCtx, err)
+}
+
+func (p *Pool) Replace(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	resp, err := p.get().Do(tarantool.NewReplaceRequest(space).Tuple(tuple).Context(reqCtx)).Get()
+	return resp, requestError(ctx, reqCtx, err)
+}
+
+func (p *Pool) Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error) {
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	operations, err := updateOperations(ops)
+	if err != nil {
+		return nil, err
+	}
+	req := tarantool.NewUpdateRequest(space).Index(index).Key(key).Operations(operations).Context(reqCtx)
+	resp, err := p.get().Do(req).Get()
+	return resp, requestError(ctx, reqCtx, err)
+}
+
+func (p *Pool) Delete(ctx context.Context, space, index interface{}, key interface{}) (*tarantool.Response, error) {
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	resp, err := p.get().Do(tarantool.NewDeleteRequest(space).Index(index).Key(key).Context(reqCtx)).Get()
+	return resp, requestError(ctx, reqCtx, err)
+}
+
+func (p *Pool) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	req := tarantool.NewSelectRequest(space).
+		Index(index).
+		Offset(offset).
+		Limit(limit).
+		Iterator(iterator).
+		Key(key).
+		Context(reqCtx)
+	return requestError(ctx, reqCtx, p.get().Do(req).GetTyped(result))
+}
+
+func (p *Pool) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	reqCtx, cancel := p.requestContext(ctx)
+	defer cancel()
+	resp, err := p.get().Do(tarantool.NewCall17Request(functionName).Args(args).Context(reqCtx)).Get()
+	return resp, requestError(ctx, reqCtx, err)
+}
+
+// updateOperations переводит операции обновления в формате протокола
+// ({"=", поле, значение}), в котором их собирают репозитории, в Operations.
+func updateOperations(ops interface{}) (*tarantool.Operations, error) {
+	list, ok := ops.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("операции обновления должны быть списком, получено %T", ops)
+	}
+	operations := tarantool.NewOperations()
+	for _, raw := range list {
+		op, ok := raw.([]interface{})
+		if !ok || len(op) != 3 {
+			return nil, fmt.Errorf("некорректная операция обновления: %v", raw)
+		}
+		name, _ := op[0].(string)
+		field, ok := op[1].(int)
+		if !ok {
+			return nil, fmt.Errorf("некорректное поле операции обновления: %v", op[1])
+		}
+		switch name {
+		case "=":
+			operations.Assign(field, op[2])
+		case "+":
+			operations.Add(field, op[2])
+		case "-":
+			operations.Subtract(field, op[2])
+		case "!":
+			operations.Insert(field, op[2])
+		case "#":
+			operations.Delete(field, op[2])
+		default:
+			return nil, fmt.Errorf("неподдерживаемая операция обновления: %q", name)
+		}
+	}
+	return operations, nil
+}
+
+// requestContext ограничивает запрос таймаутом cfg.Timeout: драйвер не
+// применяет таймаут соединения к запросам с контекстом.
+func (p *Pool) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.cfg.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.cfg.Timeout)
+}
+
+// requestError переводит ошибку драйвера об отменённом контексте запроса:
+// отмена вызывающим возвращается как ошибка его контекста, таймаут пула -
+// как таймаут драйвера, чтобы репозиторий классифицировал его по-прежнему.
+func requestError(ctx, reqCtx context.Context, err error) error {
+	if err == nil || reqCtx.Err() == nil {
+		return err
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return tarantool.ClientError{Code: tarantool.ErrTimeouted, Msg: "истёк таймаут запроса к Tarantool"}
+}
+
+// closedConn - место в пуле, соединение для которого не удалось открыть.
+type closedConn struct{}
+
+var errNoConnection = tarantool.ClientError{Code: tarantool.ErrConnectionClosed, Msg: "нет соединения с Tarantool"}
+
+func (closedConn) ConnectedNow() bool { return false }
+func (closedConn) Do(tarantool.Request) *tarantool.Future {
+	fut := tarantool.NewFuture()
+	fut.SetError(errNoConnection)
+	return fut
 }
+func (closedConn) Close() error { return nil }
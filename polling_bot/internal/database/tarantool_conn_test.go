@@ -0,0 +1,447 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
+
+	"polling_bot/internal/config"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// fakeEndpoint - экземпляр Tarantool, который тест может «выключить»
+// или сделать только для чтения
+type fakeEndpoint struct {
+	// Сколько соединение обрабатывает один запрос; запросы одного
+	// соединения обрабатываются по одному
+	latency time.Duration
+
+	mu     sync.Mutex
+	down   bool
+	ro     bool
+	writes []string
+	dials  int
+}
+
+func (e *fakeEndpoint) set(down, ro bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.down, e.ro = down, ro
+}
+
+func (e *fakeEndpoint) state() (down, ro bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.down, e.ro
+}
+
+func (e *fakeEndpoint) written() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.writes...)
+}
+
+// write записывает операцию op, как это сделал бы экземпляр в текущем состоянии
+func (e *fakeEndpoint) write(op string) (*tarantool.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch {
+	case e.down:
+		return nil, tarantool.ClientError{Code: tarantool.ErrConnectionNotReady, Msg: "client connection is not ready"}
+	case e.ro:
+		return nil, tarantool.Error{Code: tarantool.ErrReadonly, Msg: "Can't modify data because this instance is in read-only mode."}
+	}
+	e.writes = append(e.writes, op)
+	return &tarantool.Response{Data: []interface{}{[]interface{}{"p1"}}}, nil
+}
+
+type fakeConn struct {
+	endpoint *fakeEndpoint
+	notify   chan<- tarantool.ConnEvent
+	requests atomic.Int64
+	serial   sync.Mutex
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *fakeConn) write(op string) (*tarantool.Response, error) {
+	c.requests.Add(1)
+	if c.endpoint.latency > 0 {
+		c.serial.Lock()
+		time.Sleep(c.endpoint.latency)
+		c.serial.Unlock()
+	}
+	return c.endpoint.write(op)
+}
+
+func (c *fakeConn) ConnectedNow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	down, _ := c.endpoint.state()
+	return !c.closed && !down
+}
+
+func (c *fakeConn) ping() (*tarantool.Response, error) {
+	if c.endpoint.latency > 0 {
+		time.Sleep(c.endpoint.latency)
+	}
+	if !c.ConnectedNow() {
+		return nil, tarantool.ClientError{Code: tarantool.ErrConnectionNotReady, Msg: "client connection is not ready"}
+	}
+	return &tarantool.Response{}, nil
+}
+
+// Do выполняет запрос по его типу. Как и драйвер, возвращает ошибку, если
+// контекст запроса отменён, пока запрос выполнялся.
+func (c *fakeConn) Do(req tarantool.Request) *tarantool.Future {
+	var (
+		resp *tarantool.Response
+		err  error
+	)
+	switch req.(type) {
+	case *tarantool.PingRequest:
+		resp, err = c.ping()
+	case *tarantool.EvalRequest:
+		if resp, err = c.ping(); err == nil {
+			_, ro := c.endpoint.state()
+			resp = &tarantool.Response{Data: []interface{}{ro}}
+		}
+	case *tarantool.InsertRequest:
+		resp, err = c.write("insert")
+	case *tarantool.ReplaceRequest:
+		resp, err = c.write("replace")
+	case *tarantool.UpdateRequest:
+		resp, err = c.write("update")
+	case *tarantool.DeleteRequest:
+		resp, err = c.write("delete")
+	case *tarantool.SelectRequest:
+		resp = &tarantool.Response{}
+	case *tarantool.CallRequest:
+		if _, err = c.write("call"); err == nil {
+			resp = &tarantool.Response{Data: []interface{}{"ok"}}
+		}
+	default:
+		err = fmt.Errorf("fakeConn: неизвестный запрос %T", req)
+	}
+	if ctx := req.Ctx(); ctx != nil && ctx.Err() != nil {
+		resp, err = nil, fmt.Errorf("context is done")
+	}
+
+	fut := tarantool.NewFuture()
+	if err != nil {
+		fut.SetError(err)
+	} else {
+		fut.SetResponse(resp)
+	}
+	return fut
+}
+
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.notify <- tarantool.ConnEvent{Kind: tarantool.Closed}
+	}
+	return nil
+}
+
+// fakeDial подключается к endpoints по адресу
+func fakeDial(endpoints map[string]*fakeEndpoint) dialFunc {
+	return func(addr string, opts tarantool.Opts) (conn, error) {
+		endpoint := endpoints[addr]
+		endpoint.mu.Lock()
+		endpoint.dials++
+		endpoint.mu.Unlock()
+		if down, _ := endpoint.state(); down {
+			return nil, tarantool.ClientError{Code: tarantool.ErrConnectionNotReady, Msg: "dial tcp " + addr + ": connection refused"}
+		}
+		return &fakeConn{endpoint: endpoint, notify: opts.Notify}, nil
+	}
+}
+
+func testTarantoolConfig(addresses ...string) config.TarantoolConfig {
+	return config.TarantoolConfig{Addresses: addresses, Retries: 1}
+}
+
+// Тест проверяет выбор экземпляра при подключении: первый доступный
+// на запись, иначе первый доступный только для чтения
+func TestConnectWithRetry_Addresses(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    [2]bool // down, ro
+		want    string
+		wantErr bool
+	}{
+		{name: "first writable", want: "a:3301"},
+		{name: "first read-only", a: [2]bool{false, true}, want: "b:3301"},
+		{name: "first down", a: [2]bool{true, false}, want: "b:3301"},
+		{name: "all read-only", a: [2]bool{false, true}, b: [2]bool{false, true}, want: "a:3301"},
+		{name: "all down", a: [2]bool{true, false}, b: [2]bool{true, false}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := map[string]*fakeEndpoint{"a:3301": {}, "b:3301": {}}
+			endpoints["a:3301"].set(tt.a[0], tt.a[1])
+			endpoints["b:3301"].set(tt.b[0], tt.b[1])
+
+			pool, err := connectWithRetry(testTarantoolConfig("a:3301", "b:3301"), zerolog.Nop(), fakeDial(endpoints))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer pool.Close()
+			assert.Equal(t, tt.want, pool.address())
+		})
+	}
+}
+
+// Тест проверяет, что репозиторий переключается на второй экземпляр,
+// когда первый перестаёт отвечать посреди работы, и на запись -
+// когда первый становится только для чтения
+func TestFailover_FirstGoesDown(t *testing.T) {
+	a, b := &fakeEndpoint{}, &fakeEndpoint{}
+	pool, err := connectWithRetry(testTarantoolConfig("a:3301", "b:3301"), zerolog.Nop(), fakeDial(map[string]*fakeEndpoint{"a:3301": a, "b:3301": b}))
+	require.NoError(t, err)
+	defer pool.Close()
+
+	retry := repository.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond}
+	repo := repository.NewTarantoolPollRepo(pool, "polls", retry, zerolog.Nop())
+	ctx := context.Background()
+
+	require.NoError(t, repo.SavePoll(ctx, models.Poll{ID: "p1"}))
+	assert.Equal(t, []string{"replace"}, a.written())
+
+	a.set(true, false)
+	require.NoError(t, repo.ClosePoll(ctx, "p1"))
+	assert.Equal(t, "b:3301", pool.address())
+	// Фейк отвечает кортежем без версии схемы, поэтому за update следует
+	// его перевод в текущую версию - тоже на второй экземпляр
+	assert.Equal(t, []string{"update", "call"}, b.written())
+
+	// Первый вернулся, второй стал репликой: запись уходит на первый
+	a.set(false, false)
+	b.set(false, true)
+	require.NoError(t, repo.DeletePoll(ctx, "p1"))
+	assert.Equal(t, "a:3301", pool.address())
+	assert.Equal(t, []string{"replace", "delete"}, a.written())
+}
+
+// Тест проверяет, что без доступных экземпляров репозиторий возвращает
+// ErrUnavailable после всех попыток
+func TestFailover_AllDown(t *testing.T) {
+	a, b := &fakeEndpoint{}, &fakeEndpoint{}
+	pool, err := connectWithRetry(testTarantoolConfig("a:3301", "b:3301"), zerolog.Nop(), fakeDial(map[string]*fakeEndpoint{"a:3301": a, "b:3301": b}))
+	require.NoError(t, err)
+	defer pool.Close()
+
+	repo := repository.NewTarantoolPollRepo(pool, "polls", repository.RetryPolicy{Attempts: 2, BaseDelay: time.Millisecond}, zerolog.Nop())
+	a.set(true, false)
+	b.set(true, false)
+
+	err = repo.SavePoll(context.Background(), models.Poll{ID: "p1"})
+	assert.ErrorIs(t, err, repository.ErrUnavailable)
+	assert.Equal(t, "a:3301", pool.address(), "без доступных экземпляров соединение не меняется")
+}
+
+// Тест проверяет, что Failover не трогает исправное соединение: так
+// одновременные ошибки нескольких запросов дают одно переключение
+func TestFailover_HealthyNoop(t *testing.T) {
+	pool, err := connectWithRetry(testTarantoolConfig("a:3301", "b:3301"), zerolog.Nop(), fakeDial(map[string]*fakeEndpoint{"a:3301": {}, "b:3301": {}}))
+	require.NoError(t, err)
+	defer pool.Close()
+
+	require.NoError(t, pool.Failover(context.Background()))
+	assert.Equal(t, "a:3301", pool.address())
+}
+
+func newTestPool(t testing.TB, size int, endpoint *fakeEndpoint) *Pool {
+	t.Helper()
+	cfg := testTarantoolConfig("a:3301")
+	cfg.PoolSize = size
+	pool, err := connectWithRetry(cfg, zerolog.Nop(), fakeDial(map[string]*fakeEndpoint{"a:3301": endpoint}))
+	require.NoError(t, err)
+	return pool
+}
+
+func poolRequests(p *Pool) []int64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make([]int64, len(p.members))
+	for i, c := range p.members {
+		out[i] = c.(*fakeConn).requests.Load()
+	}
+	return out
+}
+
+// Тест проверяет, что запросы распределяются по соединениям пула по кругу
+func TestPool_RoundRobin(t *testing.T) {
+	pool := newTestPool(t, 3, &fakeEndpoint{})
+	defer pool.Close()
+
+	for i := 0; i < 6; i++ {
+		_, err := pool.Replace(context.Background(), "polls", []interface{}{"p1"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int64{2, 2, 2}, poolRequests(pool))
+}
+
+// Тест проверяет, что закрытое соединение пропускается, пока проверка
+// пула не заменит его новым
+func TestPool_ReplaceDead(t *testing.T) {
+	endpoint := &fakeEndpoint{}
+	pool := newTestPool(t, 2, endpoint)
+	defer pool.Close()
+
+	pool.mu.RLock()
+	dead := pool.members[0]
+	pool.mu.RUnlock()
+	dead.Close()
+
+	for i := 0; i < 4; i++ {
+		_, err := pool.Replace(context.Background(), "polls", []interface{}{"p1"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int64{0, 4}, poolRequests(pool), "закрытое соединение не получает запросов")
+
+	pool.replaceDead()
+	pool.mu.RLock()
+	replaced := pool.members[0]
+	pool.mu.RUnlock()
+	assert.NotSame(t, dead, replaced)
+	assert.True(t, replaced.ConnectedNow())
+	assert.Equal(t, 3, endpoint.dials)
+}
+
+// Тест проверяет, что Close закрывает все соединения пула
+func TestPool_Close(t *testing.T) {
+	pool := newTestPool(t, 3, &fakeEndpoint{})
+	pool.mu.RLock()
+	members := append([]conn(nil), pool.members...)
+	pool.mu.RUnlock()
+
+	require.NoError(t, pool.Close())
+	for i, c := range members {
+		assert.False(t, c.ConnectedNow(), "соединение %d не закрыто", i)
+	}
+	assert.False(t, pool.Healthy())
+}
+
+// Тест проверяет, что Ping возвращает время ответа Tarantool, а при
+// недоступном экземпляре - ошибку
+func TestPool_Ping(t *testing.T) {
+	endpoint := &fakeEndpoint{latency: 2 * time.Millisecond}
+	pool := newTestPool(t, 1, endpoint)
+	defer pool.Close()
+
+	took, err := pool.Ping(context.Background())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, took, endpoint.latency)
+
+	endpoint.set(true, false)
+	_, err = pool.Ping(context.Background())
+	assert.ErrorContains(t, err, "Tarantool не отвечает")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = pool.Ping(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// Тест проверяет, что запрос отменяется вместе с контекстом вызывающего,
+// а таймаут пула возвращается как таймаут драйвера
+func TestPool_RequestContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		deadline time.Duration
+		wantErr  error
+		wantCode uint32
+	}{
+		{name: "in time"},
+		{name: "caller deadline", timeout: time.Second, deadline: time.Millisecond, wantErr: context.DeadlineExceeded},
+		{name: "pool timeout", timeout: time.Millisecond, wantCode: tarantool.ErrTimeouted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newTestPool(t, 1, &fakeEndpoint{latency: 20 * time.Millisecond})
+			defer pool.Close()
+			pool.cfg.Timeout = tt.timeout
+
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+			_, err := pool.Replace(ctx, "polls", []interface{}{"p1"})
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantCode != 0:
+				var cliErr tarantool.ClientError
+				require.ErrorAs(t, err, &cliErr)
+				assert.Equal(t, tt.wantCode, cliErr.Code)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// Тест проверяет перевод операций обновления репозитория в Operations
+func TestUpdateOperations(t *testing.T) {
+	ops, err := updateOperations([]interface{}{
+		[]interface{}{"=", 5, true},
+		[]interface{}{"+", 4, 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, tarantool.NewOperations().Assign(5, true).Add(4, 1), ops)
+
+	_, err = updateOperations([]interface{}{[]interface{}{"?", 5, true}})
+	assert.Error(t, err)
+	_, err = updateOperations([]interface{}{[]interface{}{"=", "closed", true}})
+	assert.Error(t, err)
+}
+
+// BenchmarkPool_ConcurrentVotes сравнивает 1 и 4 соединения при 200
+// одновременных голосах. Поддельное соединение обрабатывает запросы
+// по одному с задержкой, как соединение go-tarantool под нагрузкой.
+func BenchmarkPool_ConcurrentVotes(b *testing.B) {
+	const voters = 200
+	for _, size := range []int{1, 4} {
+		b.Run(fmt.Sprintf("conns=%d", size), func(b *testing.B) {
+			pool := newTestPool(b, size, &fakeEndpoint{latency: 20 * time.Microsecond})
+			defer pool.Close()
+			repo := repository.NewTarantoolVoteRepo(pool, "polls", "poll_votes", repository.DefaultRetryPolicy(), zerolog.Nop())
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				for v := 0; v < voters; v++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						vote := models.Vote{PollID: "p1", UserID: fmt.Sprintf("u%d", v), Choices: []string{"Пицца"}}
+						if _, err := repo.AddVote(ctx, vote); err != nil {
+							b.Error(err)
+						}
+					}()
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
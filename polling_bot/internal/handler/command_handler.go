@@ -2,145 +2,378 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
+	"unicode/utf8"
 
+	"polling_bot/internal/cmdparse"
+	"polling_bot/internal/i18n"
 	"polling_bot/internal/service"
 )
 
 type CommandHandler interface {
-    ParseCommand(input string) (command string, args []string, isValid bool)
-    HandleCommand(ctx context.Context, command string, args []string, userID string) (string, error)
-    GetHelpText() string
+	// ParseCommand разбирает сообщение; isValid сообщает, что оно адресовано
+	// боту. err - команда записана с ошибкой, например с незакрытой кавычкой:
+	// на неё бот отвечает ошибкой, не выполняя команду.
+	ParseCommand(input string) (command string, args []string, isValid bool, err error)
+	HandleCommand(ctx context.Context, command string, args []string, userID string) (Response, error)
+	GetHelpText() string
 }
 
+// Response - ответ на команду. Ошибку команды бот, как и ответ
+// с Ephemeral, показывает только её автору.
+type Response struct {
+	Text string
+	// Показать ответ только автору команды, а не всему каналу:
+	// подсказки о формате, справка и личная информация
+	Ephemeral bool
+	// Ответ лишь подтверждает выполнение, и бот может заменить его реакцией
+	Quiet bool
+	// Итоги опроса, если ответ - итоги: по ним бот может показать их
+	// вложением, а Text остаётся запасным вариантом
+	Results *service.Results
+	// ID опроса, созданного командой: если бот не смог показать ответ,
+	// опрос удаляется, чтобы не остаться без объявления
+	PollID string
+}
+
+// reply - публичный ответ: результаты и созданные опросы видит весь канал.
+func reply(text string, err error) (Response, error) {
+	return Response{Text: text}, err
+}
+
+// privateReply - ответ только автору команды.
+func privateReply(text string, err error) (Response, error) {
+	return Response{Text: text, Ephemeral: true}, err
+}
+
+// ResultsReporter реализуют сервисы, которые вместе с текстом итогов
+// отдают сами итоги.
+type ResultsReporter interface {
+	ResultsReport(ctx context.Context, userID, pollID string) (string, service.Results, error)
+}
+
+// RecentResultsReporter реализуют сервисы, которые показывают вместе с
+// итогами изменения за последний период: results --since.
+type RecentResultsReporter interface {
+	ResultsSince(ctx context.Context, userID, pollID string, window time.Duration) (string, service.Results, error)
+}
+
+// ActiveResultsReporter реализуют сервисы, которые показывают вместе с
+// итогами итоги без деактивированных участников: results --active-only.
+type ActiveResultsReporter interface {
+	ResultsActiveOnly(ctx context.Context, userID, pollID string) (string, service.Results, error)
+}
+
+// PollCreator реализуют сервисы, которые вместе с ответом на create
+// отдают ID нового опроса.
+type PollCreator interface {
+	CreatePollWithID(ctx context.Context, userID, question string, options []string, opts service.CreateOptions) (service.CreatedPoll, error)
+}
+
+// PollPreviewer реализуют сервисы, которые показывают опрос create
+// --dry-run, проверив его, но не создавая.
+type PollPreviewer interface {
+	PreviewPoll(ctx context.Context, userID, question string, options []string, opts service.CreateOptions) (string, error)
+}
+
+// PollStatsReporter реализуют сервисы, которые показывают создателю
+// просмотры опроса и конверсию в голоса.
+type PollStatsReporter interface {
+	PollStats(ctx context.Context, userID, pollID string) (string, error)
+}
+
+// PollProgressReporter реализуют сервисы, которые показывают создателю
+// опроса со списком участников или кворумом, кого опрос ещё ждёт.
+type PollProgressReporter interface {
+	PollProgress(ctx context.Context, userID, pollID string, page int) (string, error)
+}
+
+// QuestionEditor реализуют сервисы, которые исправляют вопрос опроса без
+// голосов.
+type QuestionEditor interface {
+	EditQuestion(ctx context.Context, userID, pollID, question string) (string, error)
+}
+
+// BotIdentityAware реализуют обработчики, которые принимают команды
+// через упоминание бота. Бот сообщает своё имя после аутентификации.
+type BotIdentityAware interface {
+	SetBotIdentity(username string, displayNames ...string)
+}
+
+// Introducer реализуют обработчики, которые могут представить бота
+// каналу, в который его добавили.
+type Introducer interface {
+	IntroText(loc *i18n.Localizer) string
+}
+
+// CommandChecker реализуют обработчики, которые могут заранее сказать,
+// будет ли команда выполнена, а не получит ответ о неверном формате.
+type CommandChecker interface {
+	IsExecutable(command string, args []string) bool
+}
+
+// DefaultCommandPrefix используется, если префикс не задан в конфигурации.
+const DefaultCommandPrefix = "!poll"
+
 type PollCommandHandler struct {
-	service service.PollService
+	service   service.PollService
+	schedules service.ScheduleService
+	templates service.TemplateService
+	digests   service.DigestService
+	// Статистика команд для usage; поле не usage - так называется метод
+	commandUsage service.UsageService
+	localizer    *i18n.Localizer
+	// Основной префикс идёт первым: он выводится в справке
+	prefixes []string
+
+	commands registry
+	// Пользователи, которым доступны команды с RoleAdmin
+	admins map[string]bool
+	// Итоги таблицей: по умолчанию и ширина варианта
+	table service.ResultsTableOptions
+	// Middleware вокруг выполнения команд, в порядке Use
+	middlewares []Middleware
+
+	mu sync.RWMutex
+	// Имена, упоминание которых в начале сообщения считается командой
+	mentionNames []string
 }
 
-func NewPollCommandHandler(service service.PollService) *PollCommandHandler {
-	return &PollCommandHandler{
-		service: service,
+// NewPollCommandHandler создаёт обработчик; localizer задаёт язык ответов,
+// если вызывающий не передал свой Localizer через контекст. Команда
+// распознаётся по prefix или любому из aliases без учёта регистра.
+func NewPollCommandHandler(service service.PollService, localizer *i18n.Localizer, prefix string, aliases ...string) *PollCommandHandler {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		prefix = DefaultCommandPrefix
 	}
-}
 
-func (h *PollCommandHandler) ParseCommand(input string) (command string, args []string, isValid bool) {
-	parts := h.parseCommandArgs(input)
-	if len(parts) < 1 || parts[0] != "!poll" {
-		return "", nil, false
+	prefixes := []string{prefix}
+	for _, alias := range aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			prefixes = append(prefixes, alias)
+		}
 	}
 
-	if len(parts) < 2 {
-		return "help", nil, true
+	h := &PollCommandHandler{
+		service:   service,
+		localizer: localizer,
+		prefixes:  prefixes,
 	}
+	h.registerCommands()
+	return h
+}
 
-	return strings.ToLower(parts[1]), parts[2:], true
+// SetResultsTable задаёт ширину варианта в таблице итогов results --table
+// и может включить таблицу для всех итогов, а не только с флагом.
+func (h *PollCommandHandler) SetResultsTable(opts service.ResultsTableOptions) {
+	h.table = opts
 }
 
-func (h *PollCommandHandler) HandleCommand(ctx context.Context, command string, args []string, userID string) (string, error) {
-	switch command {
-	case "help":
-		return h.GetHelpText(), nil
+// SetScheduleService включает команду schedule; без него команда отвечает,
+// что расписания не настроены.
+func (h *PollCommandHandler) SetScheduleService(schedules service.ScheduleService) {
+	h.schedules = schedules
+}
 
-	case "create":
-		if len(args) < 2 {
-			return "Недостаточно аргументов. Нужен вопрос и хотя бы одна опция", nil
-		}
-		return h.service.CreatePoll(ctx, userID, args[0], args[1:])
+// SetTemplateService включает команды template и create-from; без него
+// они отвечают, что шаблоны не настроены.
+func (h *PollCommandHandler) SetTemplateService(templates service.TemplateService) {
+	h.templates = templates
+}
 
-	case "vote":
-		if len(args) != 2 {
-			return "Формат: !poll vote \"ID опроса\" \"Ваш выбор\"", nil
-		}
-		return h.service.AddVote(ctx, userID, args[0], args[1])
+// SetDigestService включает команду digest; без него команда отвечает,
+// что сводки не настроены.
+func (h *PollCommandHandler) SetDigestService(digests service.DigestService) {
+	h.digests = digests
+}
+
+// SetUsageService включает команду usage; без него команда отвечает, что
+// статистика команд не настроена.
+func (h *PollCommandHandler) SetUsageService(usage service.UsageService) {
+	h.commandUsage = usage
+}
 
-	case "results":
-		if len(args) != 1 {
-			return "Формат: !poll results \"ID опроса\"", nil
+// SetAdmins задаёт ID пользователей Mattermost, которым доступны команды
+// администратора; без них такие команды не доступны никому.
+func (h *PollCommandHandler) SetAdmins(userIDs ...string) {
+	admins := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			admins[id] = true
 		}
-		return h.service.GetResults(ctx, userID, args[0])
+	}
+	h.admins = admins
+}
+
+// Prefix возвращает основной префикс команд.
+func (h *PollCommandHandler) Prefix() string {
+	return h.prefixes[0]
+}
 
-	case "end":
-		if len(args) != 1 {
-			return "Формат: !poll end \"ID опроса\"", nil
+// SetBotIdentity задаёт имя пользователя бота и его отображаемые имена
+// для команд вида "@pollbot create ...".
+func (h *PollCommandHandler) SetBotIdentity(username string, displayNames ...string) {
+	var names []string
+	for _, name := range append([]string{username}, displayNames...) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
 		}
-		return h.service.EndPoll(ctx, userID, args[0])
+	}
 
-	case "delete":
-		if len(args) != 1 {
-			return "Формат: !poll delete \"ID опроса\"", nil
+	h.mu.Lock()
+	h.mentionNames = names
+	h.mu.Unlock()
+}
+
+func (h *PollCommandHandler) ParseCommand(input string) (command string, args []string, isValid bool, err error) {
+	if rest, ok := h.stripMention(input); ok {
+		parts, err := cmdparse.Split(rest)
+		// Допускаем и "@pollbot !poll create ..."
+		if len(parts) > 0 && h.isPrefix(parts[0]) {
+			parts = parts[1:]
+		}
+		if len(parts) < 1 {
+			return "help", nil, true, h.syntaxError("help", err)
 		}
-		return h.service.DeletePoll(ctx, userID, args[0])
+		command = strings.ToLower(parts[0])
+		return command, parts[1:], true, h.syntaxError(command, err)
+	}
 
-	default:
-		return "Неизвестная команда. Введите !poll help для справки", nil
+	parts, err := cmdparse.Split(input)
+	if len(parts) < 1 || !h.isPrefix(parts[0]) {
+		return "", nil, false, nil
 	}
+
+	if len(parts) < 2 {
+		return "help", nil, true, h.syntaxError("help", err)
+	}
+
+	command = strings.ToLower(parts[1])
+	return command, parts[2:], true, h.syntaxError(command, err)
+}
+
+// syntaxError переводит ошибку разбора аргументов в ответ с форматом
+// команды; у неизвестной команды - с форматом help.
+func (h *PollCommandHandler) syntaxError(command string, err error) error {
+	if err == nil {
+		return nil
+	}
+	cmd, ok := h.commands.lookup(command)
+	if !ok {
+		cmd, _ = h.commands.lookup("help")
+	}
+	usage := usageLine{details: cmd.details, prefix: h.Prefix()}
+	if errors.Is(err, cmdparse.ErrTrailingEscape) {
+		return i18n.NewError(i18n.TrailingEscape, usage)
+	}
+	return i18n.NewError(i18n.UnclosedQuote, usage)
 }
 
-func (h *PollCommandHandler) GetHelpText() string {
-	return `**Команды опросов:**
-    !poll create "Вопрос" "Опция 1" "Опция 2"... - Создать опрос
-    !poll vote "ID опроса" "Выбор" - Проголосовать
-    !poll results "ID опроса" - Показать результаты
-    !poll end "ID опроса" - Завершить опрос
-    !poll delete "ID опроса" - Удалить опрос
-    !poll help - Показать эту справку`
+// usageLine - формат команды: первая строка её подробной справки на языке
+// ответа.
+type usageLine struct {
+	details i18n.Key
+	prefix  string
 }
 
-func (h *PollCommandHandler) parseCommandArgs(input string) []string {
-	var args []string
-	var buf strings.Builder
-	inQuotes := false
-	var quoteChar rune
-	escape := false
+func (u usageLine) Localize(loc *i18n.Localizer) string {
+	line, _, _ := strings.Cut(loc.T(u.details, u.prefix), "\n")
+	return strings.Trim(line, "*")
+}
+
+// stripMention отрезает упоминание бота в самом начале сообщения:
+// "@pollbot", "@pollbot:" или "@Poll Bot,". Упоминания дальше в тексте
+// командой не считаются.
+func (h *PollCommandHandler) stripMention(input string) (string, bool) {
+	input = strings.TrimLeftFunc(input, unicode.IsSpace)
+	if !strings.HasPrefix(input, "@") {
+		return "", false
+	}
+	input = input[1:]
 
-	for i, r := range input {
-		if escape {
-			buf.WriteRune(r)
-			escape = false
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, name := range h.mentionNames {
+		head, rest, ok := cutRunes(input, utf8.RuneCountInString(name))
+		if !ok || !strings.EqualFold(head, name) {
 			continue
 		}
+		// Имя должно заканчиваться на границе слова, иначе "@pollbot2" совпал бы с "pollbot"
+		if next, _ := utf8.DecodeRuneInString(rest); rest != "" && !unicode.IsSpace(next) && next != ':' && next != ',' {
+			continue
+		}
+		return strings.TrimLeft(rest, ":,"), true
+	}
+	return "", false
+}
 
-		switch {
-		case r == '\\':
-			if inQuotes {
-				escape = true
-			} else {
-				buf.WriteRune(r)
-			}
-
-		case r == '"' || r == '\'':
-			if inQuotes {
-				if r == quoteChar {
-					inQuotes = false
-					args = append(args, buf.String())
-					buf.Reset()
-				} else {
-					buf.WriteRune(r)
-				}
-			} else {
-				inQuotes = true
-				quoteChar = r
-			}
-
-		case unicode.IsSpace(r):
-			if inQuotes {
-				buf.WriteRune(r)
-			} else {
-				if buf.Len() > 0 {
-					args = append(args, buf.String())
-					buf.Reset()
-				}
-			}
-
-		default:
-			buf.WriteRune(r)
+// cutRunes делит s после n-го символа.
+func cutRunes(s string, n int) (string, string, bool) {
+	i := 0
+	for ; n > 0; n-- {
+		if i >= len(s) {
+			return "", "", false
 		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return s[:i], s[i:], true
+}
 
-		if i == len(input)-1 && buf.Len() > 0 {
-			args = append(args, buf.String())
+func (h *PollCommandHandler) HandleCommand(ctx context.Context, command string, args []string, userID string) (Response, error) {
+	ctx, _ = h.withLocalizer(ctx)
+	return h.chain(h.run)(ctx, h.commandContext(ctx, command, args, userID))
+}
+
+// run выполняет команду после всех middleware.
+func (h *PollCommandHandler) run(ctx context.Context, cc CommandContext) (Response, error) {
+	cmd, ok := h.commands.lookup(cc.Command)
+	if !ok {
+		return privateReply(i18n.FromContext(ctx).T(i18n.UnknownCommand, h.Prefix()), nil)
+	}
+	// Права проверяются до аргументов, чтобы не подсказывать формат команды
+	if cmd.role == RoleAdmin && !h.admins[cc.UserID] {
+		return Response{}, i18n.NewError(i18n.AdminOnly)
+	}
+	if !cmd.acceptsArgs(len(cc.Args)) {
+		return h.usage(ctx, cmd.usage)
+	}
+	return cmd.run(ctx, cc.UserID, cc.Args)
+}
+
+// usage - подсказка о формате команды, только автору.
+func (h *PollCommandHandler) usage(ctx context.Context, key i18n.Key) (Response, error) {
+	return privateReply(i18n.FromContext(ctx).T(key, h.Prefix()), nil)
+}
+
+func (h *PollCommandHandler) IsExecutable(command string, args []string) bool {
+	cmd, ok := h.commands.lookup(command)
+	return ok && cmd.acceptsArgs(len(args))
+}
+
+func (h *PollCommandHandler) isPrefix(word string) bool {
+	for _, prefix := range h.prefixes {
+		if strings.EqualFold(word, prefix) {
+			return true
 		}
 	}
+	return false
+}
 
-	return args
-}
\ No newline at end of file
+// withLocalizer возвращает Localizer запроса (например, по локали пользователя),
+// а если его нет - кладёт в контекст Localizer обработчика для сервиса.
+func (h *PollCommandHandler) withLocalizer(ctx context.Context) (context.Context, *i18n.Localizer) {
+	if loc, ok := i18n.LocalizerFrom(ctx); ok {
+		return ctx, loc
+	}
+	loc := h.localizer
+	if loc == nil {
+		loc = i18n.Default()
+	}
+	return i18n.WithLocalizer(ctx, loc), loc
+}
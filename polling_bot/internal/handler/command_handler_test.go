@@ -2,9 +2,17 @@ package handler
 
 import (
 	"context"
-	"testing"
 	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
 
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -13,13 +21,38 @@ type MockPollService struct {
 	mock.Mock
 }
 
-func (m *MockPollService) CreatePoll(ctx context.Context, userID, question string, options []string) (string, error) {
-	args := m.Called(ctx, userID, question, options)
+func (m *MockPollService) CreatePoll(ctx context.Context, userID, question string, options []string, opts service.CreateOptions) (string, error) {
+	args := m.Called(ctx, userID, question, options, opts)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) PickWinner(ctx context.Context, userID, pollID, option string, again bool) (string, error) {
+	args := m.Called(ctx, userID, pollID, option, again)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) ClonePoll(ctx context.Context, userID, sourceID string, overrides service.CloneOverrides) (string, error) {
+	args := m.Called(ctx, userID, sourceID, overrides)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) TransferPoll(ctx context.Context, userID, pollID, username string) (string, error) {
+	args := m.Called(ctx, userID, pollID, username)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) PollSettings(ctx context.Context, userID, pollID string) (string, error) {
+	args := m.Called(ctx, userID, pollID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) UpdateSetting(ctx context.Context, userID, pollID string, change service.SettingChange) (string, error) {
+	args := m.Called(ctx, userID, pollID, change)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockPollService) AddVote(ctx context.Context, userID, pollID, option string) (string, error) {
-	args := m.Called(ctx, userID, pollID, option)
+func (m *MockPollService) AddVote(ctx context.Context, userID, pollID string, choices []string) (string, error) {
+	args := m.Called(ctx, userID, pollID, choices)
 	return args.String(0), args.Error(1)
 }
 
@@ -28,11 +61,51 @@ func (m *MockPollService) GetResults(ctx context.Context, userID, pollID string)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockPollService) MyVote(ctx context.Context, userID, pollID string) (string, error) {
+	args := m.Called(ctx, userID, pollID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) FeaturePoll(ctx context.Context, userID, pollID string, featured bool) (string, error) {
+	args := m.Called(ctx, userID, pollID, featured)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) AuditLog(ctx context.Context, pollID string, limit int) (string, error) {
+	args := m.Called(ctx, pollID, limit)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) ListOpenPolls(ctx context.Context, userID string, opts service.ListOptions) (string, error) {
+	args := m.Called(ctx, userID, opts)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) SearchPolls(ctx context.Context, userID, query string) (string, error) {
+	args := m.Called(ctx, userID, query)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) Ping(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPollService) Version(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockPollService) EndPoll(ctx context.Context, userID, pollID string) (string, error) {
 	args := m.Called(ctx, userID, pollID)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockPollService) EndPolls(ctx context.Context, userID string, opts service.EndAllOptions) (string, error) {
+	args := m.Called(ctx, userID, opts)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockPollService) DeletePoll(ctx context.Context, userID, pollID string) (string, error) {
 	args := m.Called(ctx, userID, pollID)
 	return args.String(0), args.Error(1)
@@ -77,11 +150,11 @@ func TestPollCommandHandler_ParseCommand(t *testing.T) {
 		},
 	}
 
-	h := NewPollCommandHandler(nil)
+	h := NewPollCommandHandler(nil, i18n.New("ru"), DefaultCommandPrefix)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cmd, args, valid := h.ParseCommand(tt.input)
+			cmd, args, valid, _ := h.ParseCommand(tt.input)
 			assert.Equal(t, tt.wantCmd, cmd)
 			assert.Equal(t, tt.wantArgs, args)
 			assert.Equal(t, tt.wantValid, valid)
@@ -91,9 +164,9 @@ func TestPollCommandHandler_ParseCommand(t *testing.T) {
 
 // Тесты для функции HandleCommand
 func TestPollCommandHandler_HandleCommand(t *testing.T) {
-	ctx := context.Background()
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
 	mockService := new(MockPollService)
-	h := NewPollCommandHandler(mockService)
+	h := NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix)
 
 	tests := []struct {
 		name        string
@@ -108,7 +181,7 @@ func TestPollCommandHandler_HandleCommand(t *testing.T) {
 			command: "create",
 			args:    []string{"Question?", "Option1", "Option2"},
 			mockSetup: func() {
-				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1", "Option2"}).
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1", "Option2"}, service.CreateOptions{}).
 					Return("poll123", nil)
 			},
 			wantMessage: "poll123",
@@ -120,12 +193,293 @@ func TestPollCommandHandler_HandleCommand(t *testing.T) {
 			mockSetup:   func() {},
 			wantMessage: "Недостаточно аргументов. Нужен вопрос и хотя бы одна опция",
 		},
+		{
+			name:    "Create channel-only poll",
+			command: "create",
+			args:    []string{"Question?", "--channel-only", "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{RestrictToChannel: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll with quorum",
+			command: "create",
+			args:    []string{"Standup?", "Да", "--quorum", "3", "Нет"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Standup?", []string{"Да", "Нет"}, service.CreateOptions{Quorum: 3}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll with inline quorum value",
+			command: "create",
+			args:    []string{"Standup?", "--quorum=2", "Да"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Standup?", []string{"Да"}, service.CreateOptions{Quorum: 2}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:      "Create poll with zero quorum",
+			command:   "create",
+			args:      []string{"Standup?", "Да", "--quorum", "0"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:      "Create poll with quorum value missing",
+			command:   "create",
+			args:      []string{"Standup?", "Да", "--quorum"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:    "Create poll with vote limit",
+			command: "create",
+			args:    []string{"Слоты?", "--max-votes=50", "Да"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Слоты?", []string{"Да"}, service.CreateOptions{MaxVotes: 50}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:      "Create poll with zero vote limit",
+			command:   "create",
+			args:      []string{"Слоты?", "Да", "--max-votes", "0"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:    "Create ranked poll",
+			command: "create",
+			args:    []string{"Question?", "--ranked", "Option1", "Option2"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1", "Option2"}, service.CreateOptions{Ranked: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create pinned poll with voter notifications",
+			command: "create",
+			args:    []string{"Question?", "Option1", "--PIN", "--ranked", "--notify-voters"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{Ranked: true, Pin: true, NotifyVoters: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll with vote receipts",
+			command: "create",
+			args:    []string{"Question?", "--receipts", "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{VoteReceipts: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll without creator vote",
+			command: "create",
+			args:    []string{"Question?", "--no-self-vote", "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{NoSelfVote: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create anonymous poll",
+			command: "create",
+			args:    []string{"Question?", "--anonymous", "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{Anonymous: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create members-only poll",
+			command: "create",
+			args:    []string{"Question?", "--members-only", "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{MembersOnly: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll with lifetime in days",
+			command: "create",
+			args:    []string{"Question?", "--expires", "3d", "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{Expires: 72 * time.Hour}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll with inline lifetime and no-expire",
+			command: "create",
+			args:    []string{"Question?", "--expires=90m", "--no-expire", "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{Expires: 90 * time.Minute, NoExpire: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:      "Create poll with invalid lifetime",
+			command:   "create",
+			args:      []string{"Question?", "Option1", "--expires", "30s"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:    "Create poll with multi-line description",
+			command: "create",
+			args:    []string{`Где\nобедаем?`, "--desc", `Первая строка\nвторая`, "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Где\nобедаем?", []string{"Option1"}, service.CreateOptions{Description: "Первая строка\nвторая"}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll with inline description",
+			command: "create",
+			args:    []string{"Question?", "Option1", "--DESC=Пояснение"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{Description: "Пояснение"}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:      "Create poll with description value missing",
+			command:   "create",
+			args:      []string{"Question?", "Option1", "--desc"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:    "Create poll with tags",
+			command: "create",
+			args:    []string{"Question?", "--tags", "release,Team-A", "Option1", "--tags=q3"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"},
+					service.CreateOptions{Tags: []string{"release", "Team-A", "q3"}}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:      "Create poll with tags value missing",
+			command:   "create",
+			args:      []string{"Question?", "Option1", "--tags"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:    "Create poll with weights",
+			command: "create",
+			args:    []string{"Question?", "--weights", "@alice=2, bob=3", "Option1"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"},
+					service.CreateOptions{Weights: map[string]int{"alice": 2, "bob": 3}}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll with voters",
+			command: "create",
+			args:    []string{"Question?", "Option1", "--voters=@carol,@alice"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"},
+					service.CreateOptions{Voters: []string{"carol", "alice"}}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:    "Create poll with voters before options",
+			command: "create",
+			args:    []string{"Question?", "--voters", "@alice", "@bob,@alice", "Option1", "Option2"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1", "Option2"},
+					service.CreateOptions{Voters: []string{"alice", "bob"}}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:      "Create poll with voters missing",
+			command:   "create",
+			args:      []string{"Question?", "--voters", "Option1"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:    "Create poll with abstain",
+			command: "create",
+			args:    []string{"Question?", "Option1", "--Allow-Abstain"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{AllowAbstain: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:      "Create poll with malformed weights",
+			command:   "create",
+			args:      []string{"Question?", "Option1", "--weights=@alice:2"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:      "Create poll with a repeated weight",
+			command:   "create",
+			args:      []string{"Question?", "Option1", "--weights", "@alice=2,@alice=3"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:      "Create poll with lifetime value missing",
+			command:   "create",
+			args:      []string{"Question?", "Option1", "--expires"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:    "Create exclusive poll",
+			command: "create",
+			args:    []string{"Question?", "Option1", "--exclusive"},
+			mockSetup: func() {
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{Exclusive: true}).
+					Return("poll123", nil)
+			},
+			wantMessage: "poll123",
+		},
+		{
+			name:        "Create poll with a flag instead of an option",
+			command:     "create",
+			args:        []string{"Question?", "--channel-only"},
+			mockSetup:   func() {},
+			wantMessage: "Недостаточно аргументов. Нужен вопрос и хотя бы одна опция",
+		},
 		{
 			name:    "Vote success",
 			command: "vote",
 			args:    []string{"poll123", "Option1"},
 			mockSetup: func() {
-				mockService.On("AddVote", ctx, "user1", "poll123", "Option1").
+				mockService.On("AddVote", ctx, "user1", "poll123", []string{"Option1"}).
 					Return("Vote added", nil)
 			},
 			wantMessage: "Vote added",
@@ -157,7 +511,7 @@ func TestPollCommandHandler_HandleCommand(t *testing.T) {
 			command: "create",
 			args:    []string{"Question?", "Option1"},
 			mockSetup: func() {
-				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}).
+				mockService.On("CreatePoll", ctx, "user1", "Question?", []string{"Option1"}, service.CreateOptions{}).
 					Return("poll456", nil)
 			},
 			wantMessage: "poll456",
@@ -167,75 +521,275 @@ func TestPollCommandHandler_HandleCommand(t *testing.T) {
 			command: "create",
 			args:    []string{"Q", "O1"},
 			mockSetup: func() {
-				mockService.On("CreatePoll", ctx, "user1", "Q", []string{"O1"}).
+				mockService.On("CreatePoll", ctx, "user1", "Q", []string{"O1"}, service.CreateOptions{}).
 					Return("", errors.New("service error"))
 			},
 			wantError: true,
 		},
 		{
-			name:        "Vote too many args",
-			command:     "vote",
-			args:        []string{"poll123", "Option1", "extra"},
-			mockSetup:   func() {},
-			wantMessage: "Формат: !poll vote \"ID опроса\" \"Ваш выбор\"",
+			name:    "Vote with a ranking",
+			command: "vote",
+			args:    []string{"poll123", "Option1", "Option2"},
+			mockSetup: func() {
+				mockService.On("AddVote", ctx, "user1", "poll123", []string{"Option1", "Option2"}).
+					Return("Vote added", nil)
+			},
+			wantMessage: "Vote added",
 		},
 		{
 			name:    "Vote service error",
 			command: "vote",
 			args:    []string{"poll123", "Option1"},
 			mockSetup: func() {
-				mockService.On("AddVote", ctx, "user1", "poll123", "Option1").
+				mockService.On("AddVote", ctx, "user1", "poll123", []string{"Option1"}).
 					Return("", errors.New("invalid vote"))
 			},
 			wantError: true,
 		},
 		{
-			name:        "Results no args",
-			command:     "results",
-			args:        []string{},
-			mockSetup:   func() {},
-			wantMessage: "Формат: !poll results \"ID опроса\"",
+			name:    "Winner among all voters",
+			command: "winner",
+			args:    []string{"poll123"},
+			mockSetup: func() {
+				mockService.On("PickWinner", ctx, "user1", "poll123", "", false).Return("winner", nil)
+			},
+			wantMessage: "winner",
 		},
 		{
-			name:        "Results too many args",
-			command:     "results",
-			args:        []string{"poll123", "extra"},
+			name:    "Winner among option voters again",
+			command: "winner",
+			args:    []string{"poll123", "--again", "Option1"},
+			mockSetup: func() {
+				mockService.On("PickWinner", ctx, "user1", "poll123", "Option1", true).Return("winner", nil)
+			},
+			wantMessage: "winner",
+		},
+		{
+			name:        "Winner with two options",
+			command:     "winner",
+			args:        []string{"poll123", "Option1", "Option2"},
 			mockSetup:   func() {},
-			wantMessage: "Формат: !poll results \"ID опроса\"",
+			wantMessage: "Формат: !poll winner",
 		},
 		{
-			name:    "Results success",
-			command: "results",
+			name:    "Clone poll",
+			command: "clone",
 			args:    []string{"poll123"},
 			mockSetup: func() {
-				mockService.On("GetResults", ctx, "user1", "poll123").
-					Return("Results: OK", nil)
+				mockService.On("ClonePoll", ctx, "user1", "poll123", service.CloneOverrides{}).Return("cloned", nil)
 			},
-			wantMessage: "Results: OK",
+			wantMessage: "cloned",
 		},
 		{
-			name:    "Results service error",
-			command: "results",
-			args:    []string{"poll123"},
+			name:    "Clone poll with a new question",
+			command: "clone",
+			args:    []string{"poll123", "New question?"},
 			mockSetup: func() {
-				mockService.On("GetResults", ctx, "user1", "poll123").
-					Return("", errors.New("poll not found"))
+				mockService.On("ClonePoll", ctx, "user1", "poll123", service.CloneOverrides{Question: "New question?"}).Return("cloned", nil)
 			},
-			wantError: true,
+			wantMessage: "cloned",
 		},
 		{
-			name:        "End poll no args",
-			command:     "end",
-			args:        []string{},
-			mockSetup:   func() {},
-			wantMessage: "Формат: !poll end \"ID опроса\"",
+			name:    "Transfer poll",
+			command: "transfer",
+			args:    []string{"poll123", "@ivan"},
+			mockSetup: func() {
+				mockService.On("TransferPoll", ctx, "user1", "poll123", "@ivan").Return("transferred", nil)
+			},
+			wantMessage: "transferred",
+		},
+		{
+			name:        "Transfer without user",
+			command:     "transfer",
+			args:        []string{"poll123"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll transfer",
+		},
+		{
+			name:    "Show poll settings",
+			command: "set",
+			args:    []string{"poll123"},
+			mockSetup: func() {
+				mockService.On("PollSettings", ctx, "user1", "poll123").Return("settings", nil)
+			},
+			wantMessage: "settings",
+		},
+		{
+			name:    "Set channel-only",
+			command: "set",
+			args:    []string{"poll123", "Channel-Only", "on"},
+			mockSetup: func() {
+				mockService.On("UpdateSetting", ctx, "user1", "poll123",
+					service.SettingChange{Setting: service.SettingChannelOnly, Enabled: true}).Return("changed", nil)
+			},
+			wantMessage: "changed",
+		},
+		{
+			name:    "Set quorum",
+			command: "set",
+			args:    []string{"poll123", "quorum", "10"},
+			mockSetup: func() {
+				mockService.On("UpdateSetting", ctx, "user1", "poll123",
+					service.SettingChange{Setting: service.SettingQuorum, Number: 10}).Return("changed", nil)
+			},
+			wantMessage: "changed",
+		},
+		{
+			name:    "Remove max votes",
+			command: "set",
+			args:    []string{"poll123", "max-votes", "off"},
+			mockSetup: func() {
+				mockService.On("UpdateSetting", ctx, "user1", "poll123",
+					service.SettingChange{Setting: service.SettingMaxVotes}).Return("changed", nil)
+			},
+			wantMessage: "changed",
+		},
+		{
+			name:    "Set expiry",
+			command: "set",
+			args:    []string{"poll123", "expires", "3d"},
+			mockSetup: func() {
+				mockService.On("UpdateSetting", ctx, "user1", "poll123",
+					service.SettingChange{Setting: service.SettingExpires, Expires: 72 * time.Hour}).Return("changed", nil)
+			},
+			wantMessage: "changed",
+		},
+		{
+			name:    "Set voters",
+			command: "set",
+			args:    []string{"poll123", "voters", "@alice", "@bob"},
+			mockSetup: func() {
+				mockService.On("UpdateSetting", ctx, "user1", "poll123",
+					service.SettingChange{Setting: service.SettingVoters, Usernames: []string{"alice", "bob"}}).Return("changed", nil)
+			},
+			wantMessage: "changed",
+		},
+		{
+			name:    "Set voters as one list",
+			command: "set",
+			args:    []string{"poll123", "Voters", "@carol,@dave"},
+			mockSetup: func() {
+				mockService.On("UpdateSetting", ctx, "user1", "poll123",
+					service.SettingChange{Setting: service.SettingVoters, Usernames: []string{"carol", "dave"}}).Return("changed", nil)
+			},
+			wantMessage: "changed",
+		},
+		{
+			name:    "Remove voters",
+			command: "set",
+			args:    []string{"poll123", "voters", "off"},
+			mockSetup: func() {
+				mockService.On("UpdateSetting", ctx, "user1", "poll123",
+					service.SettingChange{Setting: service.SettingVoters}).Return("changed", nil)
+			},
+			wantMessage: "changed",
+		},
+		{
+			name:        "Set quorum with extra arguments",
+			command:     "set",
+			args:        []string{"poll123", "quorum", "10", "20"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll set",
+		},
+		{
+			name:      "Set invalid quorum",
+			command:   "set",
+			args:      []string{"poll123", "quorum", "0"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:      "Set invalid switch",
+			command:   "set",
+			args:      []string{"poll123", "channel-only", "maybe"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:      "Set unknown setting",
+			command:   "set",
+			args:      []string{"poll123", "ranked", "on"},
+			mockSetup: func() {},
+			wantError: true,
+		},
+		{
+			name:        "Set without value",
+			command:     "set",
+			args:        []string{"poll123", "quorum"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll set",
+		},
+		{
+			name:        "Clone no args",
+			command:     "clone",
+			args:        []string{},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll clone",
+		},
+		{
+			name:        "Results no args",
+			command:     "results",
+			args:        []string{},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll results \"ID опроса\" [--since 1h | --active-only] [--table] [--page 2]",
+		},
+		{
+			name:        "Results too many args",
+			command:     "results",
+			args:        []string{"poll123", "extra"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll results \"ID опроса\" [--since 1h | --active-only] [--table] [--page 2]",
+		},
+		{
+			name:    "Results success",
+			command: "results",
+			args:    []string{"poll123"},
+			mockSetup: func() {
+				mockService.On("GetResults", ctx, "user1", "poll123").
+					Return("Results: OK", nil)
+			},
+			wantMessage: "Results: OK",
+		},
+		{
+			name:    "Results service error",
+			command: "results",
+			args:    []string{"poll123"},
+			mockSetup: func() {
+				mockService.On("GetResults", ctx, "user1", "poll123").
+					Return("", errors.New("poll not found"))
+			},
+			wantError: true,
+		},
+		{
+			name:    "My vote success",
+			command: "myvote",
+			args:    []string{"poll123"},
+			mockSetup: func() {
+				mockService.On("MyVote", ctx, "user1", "poll123").
+					Return("Ваш голос в опросе poll123: Пицца", nil)
+			},
+			wantMessage: "Ваш голос в опросе poll123: Пицца",
+		},
+		{
+			name:        "My vote no args",
+			command:     "myvote",
+			args:        []string{},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll myvote \"ID опроса\"",
+		},
+		{
+			name:        "End poll no args",
+			command:     "end",
+			args:        []string{},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll end \"ID опроса\" | --all [--channel]",
 		},
 		{
 			name:        "End poll too many args",
 			command:     "end",
 			args:        []string{"poll123", "extra"},
 			mockSetup:   func() {},
-			wantMessage: "Формат: !poll end \"ID опроса\"",
+			wantMessage: "Формат: !poll end \"ID опроса\" | --all [--channel]",
 		},
 		{
 			name:    "End poll success",
@@ -291,6 +845,126 @@ func TestPollCommandHandler_HandleCommand(t *testing.T) {
 			},
 			wantError: true,
 		},
+		{
+			name:    "List polls",
+			command: "list",
+			args:    []string{},
+			mockSetup: func() {
+				mockService.On("ListOpenPolls", ctx, "user1", service.ListOptions{}).
+					Return("Открытые опросы", nil)
+			},
+			wantMessage: "Открытые опросы",
+		},
+		{
+			name:    "List polls by tag",
+			command: "list",
+			args:    []string{"--tag", "Release"},
+			mockSetup: func() {
+				mockService.On("ListOpenPolls", ctx, "user1", service.ListOptions{Tag: "Release"}).
+					Return("Открытые опросы с меткой", nil)
+			},
+			wantMessage: "Открытые опросы с меткой",
+		},
+		{
+			name:    "List polls by inline tag",
+			command: "list",
+			args:    []string{"--TAG=release"},
+			mockSetup: func() {
+				mockService.On("ListOpenPolls", ctx, "user1", service.ListOptions{Tag: "release"}).
+					Return("Открытые опросы с меткой", nil)
+			},
+			wantMessage: "Открытые опросы с меткой",
+		},
+		{
+			name:    "List polls of all channels",
+			command: "list",
+			args:    []string{"--all"},
+			mockSetup: func() {
+				mockService.On("ListOpenPolls", ctx, "user1", service.ListOptions{All: true}).
+					Return("Открытые опросы всех каналов", nil)
+			},
+			wantMessage: "Открытые опросы всех каналов",
+		},
+		{
+			name:    "List polls of all channels by tag",
+			command: "list",
+			args:    []string{"--tag", "team-a", "--GLOBAL"},
+			mockSetup: func() {
+				mockService.On("ListOpenPolls", ctx, "user1", service.ListOptions{Tag: "team-a", All: true}).
+					Return("Открытые опросы всех каналов с меткой", nil)
+			},
+			wantMessage: "Открытые опросы всех каналов с меткой",
+		},
+		{
+			name:        "List polls with repeated flag",
+			command:     "list",
+			args:        []string{"--all", "--global"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll list [--tag метка] [--all]",
+		},
+		{
+			name:        "List polls without tag value",
+			command:     "list",
+			args:        []string{"--tag"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll list [--tag метка]",
+		},
+		{
+			name:        "List polls with unknown argument",
+			command:     "list",
+			args:        []string{"release"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll list [--tag метка]",
+		},
+		{
+			name:    "Search polls",
+			command: "search",
+			args:    []string{"обед", "пятн"},
+			mockSetup: func() {
+				mockService.On("SearchPolls", ctx, "user1", "обед пятн").
+					Return("Опросы по запросу", nil)
+			},
+			wantMessage: "Опросы по запросу",
+		},
+		{
+			name:        "Search polls without query",
+			command:     "search",
+			args:        []string{},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll search \"текст вопроса\"",
+		},
+		{
+			name:    "Ping",
+			command: "ping",
+			args:    []string{},
+			mockSetup: func() {
+				mockService.On("Ping", ctx).Return("pong — tarantool: 3ms, uptime: 4h12m, версия: v1.4.0", nil)
+			},
+			wantMessage: "pong — tarantool: 3ms, uptime: 4h12m, версия: v1.4.0",
+		},
+		{
+			name:        "Ping with arguments",
+			command:     "ping",
+			args:        []string{"tarantool"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll ping",
+		},
+		{
+			name:    "Version",
+			command: "version",
+			args:    []string{},
+			mockSetup: func() {
+				mockService.On("Version", ctx).Return("**Бот опросов v1.4.0**\n", nil)
+			},
+			wantMessage: "**Бот опросов v1.4.0**\n",
+		},
+		{
+			name:        "Version with arguments",
+			command:     "version",
+			args:        []string{"full"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll version",
+		},
 		{
 			name:        "Uppercase command treated as unknown",
 			command:     "CREATE",
@@ -312,66 +986,1390 @@ func TestPollCommandHandler_HandleCommand(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
-			assert.Contains(t, msg, tt.wantMessage)
+			assert.Contains(t, msg.Text, tt.wantMessage)
 			mockService.AssertExpectations(t)
 		})
 	}
 }
 
-// Тесты для функции парсинга аргументов, переданных пользователем
-func TestParseCommandArgs(t *testing.T) {
-    mockService := new(MockPollService)
-    h := NewPollCommandHandler(mockService)
+type MockScheduleService struct {
+	mock.Mock
+}
 
-    tests := []struct {
-        name  string
-        input string
-        want  []string
-    }{
-        {
-            name:  "Simple args",
-            input: `!poll test arg1 arg2 arg3`,
-            want:  []string{"arg1", "arg2", "arg3"},
-        },
-        {
-            name:  "Quoted args",
-            input: `!poll create "arg 1" 'arg 2' "arg'3"`,
-            want:  []string{"arg 1", "arg 2", "arg'3"},
-        },
-        {
-            name:  "Escaped characters",
-            input: `!poll vote "arg\"1" "arg\\2"`,
-            want:  []string{`arg"1`, `arg\2`},
-        },
-        {
-            name:  "Mixed quotes",
-            input: `!poll results 'arg"1' "arg'2"`,
-            want:  []string{`arg"1`, `arg'2`},
-        },
-        {
-            name:  "Trailing space",
-            input: `!poll end  arg1  arg2  `,
-            want:  []string{"arg1", "arg2"},
-        },
-    }
+func (m *MockScheduleService) CreateSchedule(ctx context.Context, userID, cron, question string, options []string, opts service.CreateOptions) (string, error) {
+	args := m.Called(ctx, userID, cron, question, options, opts)
+	return args.String(0), args.Error(1)
+}
 
-    for _, tt := range tests {
-        t.Run(tt.name, func(t *testing.T) {
-            _, args, _ := h.ParseCommand(tt.input)
-            assert.Equal(t, tt.want, args)
-        })
-    }
+func (m *MockScheduleService) ListSchedules(ctx context.Context, userID string) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
 }
 
-// Тесты для функции, генерирующей сообщения о командах, доступных в боте
-func TestGetHelpText(t *testing.T) {
-	h := NewPollCommandHandler(nil)
-	helpText := h.GetHelpText()
+func (m *MockScheduleService) DeleteSchedule(ctx context.Context, userID, scheduleID string) (string, error) {
+	args := m.Called(ctx, userID, scheduleID)
+	return args.String(0), args.Error(1)
+}
 
-	assert.Contains(t, helpText, "!poll create")
-	assert.Contains(t, helpText, "!poll vote")
-	assert.Contains(t, helpText, "!poll results")
-	assert.Contains(t, helpText, "!poll end")
-	assert.Contains(t, helpText, "!poll delete")
-	assert.Contains(t, helpText, "!poll help")
-}
\ No newline at end of file
+// Тест проверяет подкоманды schedule
+func TestPollCommandHandler_Schedule(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	schedules := new(MockScheduleService)
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	h.SetScheduleService(schedules)
+
+	tests := []struct {
+		name        string
+		args        []string
+		mockSetup   func()
+		wantMessage string
+	}{
+		{
+			name: "Create with flags",
+			args: []string{"create", "0 10 * * 1", "Обед?", "--quorum", "3", "Пицца", "Суши"},
+			mockSetup: func() {
+				schedules.On("CreateSchedule", ctx, "user1", "0 10 * * 1", "Обед?", []string{"Пицца", "Суши"},
+					service.CreateOptions{Quorum: 3}).Return("created", nil)
+			},
+			wantMessage: "created",
+		},
+		{
+			name:        "Create without options",
+			args:        []string{"create", "0 10 * * 1", "Обед?"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll schedule create",
+		},
+		{
+			name: "List",
+			args: []string{"LIST"},
+			mockSetup: func() {
+				schedules.On("ListSchedules", ctx, "user1").Return("list", nil)
+			},
+			wantMessage: "list",
+		},
+		{
+			name: "Delete",
+			args: []string{"delete", "sched1"},
+			mockSetup: func() {
+				schedules.On("DeleteSchedule", ctx, "user1", "sched1").Return("deleted", nil)
+			},
+			wantMessage: "deleted",
+		},
+		{
+			name:        "Delete without ID",
+			args:        []string{"delete"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll schedule",
+		},
+		{
+			name:        "Unknown subcommand",
+			args:        []string{"pause", "sched1"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll schedule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedules.ExpectedCalls = nil
+			tt.mockSetup()
+
+			msg, err := h.HandleCommand(ctx, "schedule", tt.args, "user1")
+			assert.NoError(t, err)
+			assert.Contains(t, msg.Text, tt.wantMessage)
+			schedules.AssertExpectations(t)
+		})
+	}
+}
+
+// Тест проверяет ответ schedule, когда расписания не настроены
+func TestPollCommandHandler_ScheduleDisabled(t *testing.T) {
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+
+	_, err := h.HandleCommand(context.Background(), "schedule", []string{"list"}, "user1")
+	assert.EqualError(t, err, "расписания не настроены")
+}
+
+type MockTemplateService struct {
+	mock.Mock
+}
+
+func (m *MockTemplateService) SaveTemplate(ctx context.Context, userID, name, question string, options []string, opts service.CreateOptions, shared bool) (string, error) {
+	args := m.Called(ctx, userID, name, question, options, opts, shared)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTemplateService) ListTemplates(ctx context.Context, userID string) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTemplateService) DeleteTemplate(ctx context.Context, userID, name string, shared bool) (string, error) {
+	args := m.Called(ctx, userID, name, shared)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTemplateService) CreateFromTemplate(ctx context.Context, userID, name string) (string, error) {
+	args := m.Called(ctx, userID, name)
+	return args.String(0), args.Error(1)
+}
+
+// Тест проверяет подкоманды template и команду create-from
+func TestPollCommandHandler_Template(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	templates := new(MockTemplateService)
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	h.SetTemplateService(templates)
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		mockSetup   func()
+		wantMessage string
+	}{
+		{
+			name:    "Save with flags",
+			command: "template",
+			args:    []string{"save", "lunch", `Где\nобедаем?`, "--quorum", "3", "Пицца", "--tags", "food", "Суши"},
+			mockSetup: func() {
+				templates.On("SaveTemplate", ctx, "user1", "lunch", "Где\nобедаем?", []string{"Пицца", "Суши"},
+					service.CreateOptions{Quorum: 3, Tags: []string{"food"}}, false).Return("saved", nil)
+			},
+			wantMessage: "saved",
+		},
+		{
+			name:    "Save for the channel",
+			command: "template",
+			args:    []string{"SAVE", "--channel", "lunch", "Обед?", "Пицца"},
+			mockSetup: func() {
+				templates.On("SaveTemplate", ctx, "user1", "lunch", "Обед?", []string{"Пицца"},
+					service.CreateOptions{}, true).Return("saved", nil)
+			},
+			wantMessage: "saved",
+		},
+		{
+			name:        "Save without options",
+			command:     "template",
+			args:        []string{"save", "lunch", "Обед?"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll template save",
+		},
+		{
+			name:    "List",
+			command: "template",
+			args:    []string{"list"},
+			mockSetup: func() {
+				templates.On("ListTemplates", ctx, "user1").Return("list", nil)
+			},
+			wantMessage: "list",
+		},
+		{
+			name:    "Delete channel template",
+			command: "template",
+			args:    []string{"delete", "lunch", "--CHANNEL"},
+			mockSetup: func() {
+				templates.On("DeleteTemplate", ctx, "user1", "lunch", true).Return("deleted", nil)
+			},
+			wantMessage: "deleted",
+		},
+		{
+			name:        "Delete without name",
+			command:     "template",
+			args:        []string{"delete", "--channel"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll template",
+		},
+		{
+			name:        "Unknown subcommand",
+			command:     "template",
+			args:        []string{"rename", "lunch"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll template",
+		},
+		{
+			name:    "Create from template",
+			command: "create-from",
+			args:    []string{"lunch"},
+			mockSetup: func() {
+				templates.On("CreateFromTemplate", ctx, "user1", "lunch").Return("created", nil)
+			},
+			wantMessage: "created",
+		},
+		{
+			name:        "Create from without name",
+			command:     "create-from",
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll create-from",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates.ExpectedCalls = nil
+			tt.mockSetup()
+
+			msg, err := h.HandleCommand(ctx, tt.command, tt.args, "user1")
+			assert.NoError(t, err)
+			assert.Contains(t, msg.Text, tt.wantMessage)
+			templates.AssertExpectations(t)
+		})
+	}
+}
+
+// Тест проверяет ответ template и create-from, когда шаблоны не настроены
+func TestPollCommandHandler_TemplatesDisabled(t *testing.T) {
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+
+	_, err := h.HandleCommand(context.Background(), "template", []string{"list"}, "user1")
+	assert.EqualError(t, err, "шаблоны не настроены")
+	_, err = h.HandleCommand(context.Background(), "create-from", []string{"lunch"}, "user1")
+	assert.EqualError(t, err, "шаблоны не настроены")
+}
+
+type MockDigestService struct {
+	mock.Mock
+}
+
+func (m *MockDigestService) SetDigest(ctx context.Context, userID string, enabled bool) (string, error) {
+	args := m.Called(ctx, userID, enabled)
+	return args.String(0), args.Error(1)
+}
+
+// Тест проверяет включение и выключение сводки командой digest
+func TestPollCommandHandler_Digest(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	digests := new(MockDigestService)
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	h.SetDigestService(digests)
+
+	tests := []struct {
+		name        string
+		args        []string
+		mockSetup   func()
+		wantMessage string
+	}{
+		{
+			name: "On",
+			args: []string{"ON"},
+			mockSetup: func() {
+				digests.On("SetDigest", ctx, "user1", true).Return("enabled", nil)
+			},
+			wantMessage: "enabled",
+		},
+		{
+			name: "Off",
+			args: []string{"off"},
+			mockSetup: func() {
+				digests.On("SetDigest", ctx, "user1", false).Return("disabled", nil)
+			},
+			wantMessage: "disabled",
+		},
+		{
+			name:        "Unknown value",
+			args:        []string{"weekly"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll digest on",
+		},
+		{
+			name:        "Without value",
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll digest on",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digests.ExpectedCalls = nil
+			tt.mockSetup()
+
+			msg, err := h.HandleCommand(ctx, "digest", tt.args, "user1")
+			assert.NoError(t, err)
+			assert.Contains(t, msg.Text, tt.wantMessage)
+			digests.AssertExpectations(t)
+		})
+	}
+}
+
+// Тест проверяет ответ digest, когда сводки не настроены
+func TestPollCommandHandler_DigestsDisabled(t *testing.T) {
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+
+	_, err := h.HandleCommand(context.Background(), "digest", []string{"on"}, "user1")
+	assert.EqualError(t, err, "сводки опросов не настроены")
+}
+
+// Тесты для функции парсинга аргументов, переданных пользователем
+func TestParseCommandArgs(t *testing.T) {
+    mockService := new(MockPollService)
+    h := NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix)
+
+    tests := []struct {
+        name  string
+        input string
+        want  []string
+    }{
+        {
+            name:  "Simple args",
+            input: `!poll test arg1 arg2 arg3`,
+            want:  []string{"arg1", "arg2", "arg3"},
+        },
+        {
+            name:  "Quoted args",
+            input: `!poll create "arg 1" 'arg 2' "arg'3"`,
+            want:  []string{"arg 1", "arg 2", "arg'3"},
+        },
+        {
+            name:  "Escaped characters",
+            input: `!poll vote "arg\"1" "arg\\2"`,
+            want:  []string{`arg"1`, `arg\2`},
+        },
+        {
+            name:  "Line break sequence kept for the command",
+            input: `!poll create "Строка 1\nстрока 2" \n`,
+            want:  []string{`Строка 1\nстрока 2`, `\n`},
+        },
+        {
+            name:  "Mixed quotes",
+            input: `!poll results 'arg"1' "arg'2"`,
+            want:  []string{`arg"1`, `arg'2`},
+        },
+        {
+            name:  "Trailing space",
+            input: `!poll end  arg1  arg2  `,
+            want:  []string{"arg1", "arg2"},
+        },
+        {
+            name:  "Unquoted last word in Cyrillic",
+            input: `!poll vote p1 Пицца`,
+            want:  []string{"p1", "Пицца"},
+        },
+        {
+            name:  "Quote adjacent to text",
+            input: `!poll vote foo"bar baz" Да`,
+            want:  []string{"foobar baz", "Да"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, args, _, _ := h.ParseCommand(tt.input)
+            assert.Equal(t, tt.want, args)
+        })
+    }
+}
+
+// Тест проверяет, что ошибки записи команды - незакрытая кавычка и
+// обратная косая черта в конце - возвращаются с форматом команды
+func TestPollCommandHandler_ParseCommandErrors(t *testing.T) {
+	h := NewPollCommandHandler(nil, i18n.New("ru"), DefaultCommandPrefix)
+
+	tests := []struct {
+		name      string
+		input     string
+		wantCmd   string
+		wantValid bool
+		wantErr   string
+	}{
+		{
+			name:      "Unbalanced quotes",
+			input:     `!poll create "Q "A"`,
+			wantCmd:   "create",
+			wantValid: true,
+			wantErr:   `незакрытая кавычка в команде. Формат: !poll create "Вопрос" "Опция 1" "Опция 2"...`,
+		},
+		{
+			name:      "Unclosed single quote",
+			input:     `!poll vote p1 'Пицца`,
+			wantCmd:   "vote",
+			wantValid: true,
+			wantErr:   `незакрытая кавычка в команде. Формат: !poll vote "ID опроса" "Выбор"`,
+		},
+		{
+			name:      "Lone trailing backslash",
+			input:     `!poll vote p1 "Пицца\`,
+			wantCmd:   "vote",
+			wantValid: true,
+			wantErr:   `незакрытая кавычка в команде: после \ нет символа. Формат: !poll vote "ID опроса" "Выбор"`,
+		},
+		{
+			name:      "Unknown command gets help usage",
+			input:     `!poll frobnicate "x`,
+			wantCmd:   "frobnicate",
+			wantValid: true,
+			wantErr:   `незакрытая кавычка в команде. Формат: !poll help [команда]`,
+		},
+		{
+			name:      "Mention with unclosed quote",
+			input:     `@pollbot results "p1`,
+			wantCmd:   "results",
+			wantValid: true,
+			wantErr:   `незакрытая кавычка в команде. Формат: !poll results "ID опроса" [--since 1h | --active-only] [--table] [--page 2]`,
+		},
+		{
+			name:      "Not a command",
+			input:     `привет "мир`,
+			wantValid: false,
+		},
+		{
+			name:      "Backslash outside quotes is literal",
+			input:     `!poll vote p1 A\`,
+			wantCmd:   "vote",
+			wantValid: true,
+		},
+	}
+
+	h.SetBotIdentity("pollbot")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, _, valid, err := h.ParseCommand(tt.input)
+			assert.Equal(t, tt.wantValid, valid)
+			assert.Equal(t, tt.wantCmd, cmd)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, tt.wantErr, i18n.New("ru").Error(err))
+			}
+		})
+	}
+}
+
+// Тесты для функции, генерирующей сообщения о командах, доступных в боте
+func TestGetHelpText(t *testing.T) {
+	h := NewPollCommandHandler(nil, i18n.New("ru"), DefaultCommandPrefix)
+	helpText := h.GetHelpText()
+
+	assert.Contains(t, helpText, "!poll create")
+	assert.Contains(t, helpText, "!poll vote")
+	assert.Contains(t, helpText, "!poll results")
+	assert.Contains(t, helpText, "!poll end")
+	assert.Contains(t, helpText, "!poll delete")
+	assert.Contains(t, helpText, "!poll help")
+}
+// Тест проверяет выбор языка: Localizer из контекста важнее Localizer обработчика
+func TestPollCommandHandler_Localization(t *testing.T) {
+	mockService := new(MockPollService)
+	h := NewPollCommandHandler(mockService, i18n.New("en"), DefaultCommandPrefix)
+
+	msg, err := h.HandleCommand(context.Background(), "vote", nil, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, `Usage: !poll vote "Poll ID" "Your choice"`, msg.Text)
+	assert.Contains(t, h.GetHelpText(), "Poll commands")
+
+	ruCtx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	msg, err = h.HandleCommand(ruCtx, "unknown", nil, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Неизвестная команда. Введите !poll help для справки", msg.Text)
+
+	// Сервис получает язык обработчика через контекст
+	mockService.On("GetResults", mock.MatchedBy(func(ctx context.Context) bool {
+		return i18n.FromContext(ctx).Lang() == i18n.English
+	}), "user1", "poll1").Return("ok", nil)
+	_, err = h.HandleCommand(context.Background(), "results", []string{"poll1"}, "user1")
+	assert.NoError(t, err)
+	mockService.AssertExpectations(t)
+}
+
+// Тест проверяет настраиваемый префикс и псевдонимы, в том числе в Юникоде
+func TestPollCommandHandler_Prefixes(t *testing.T) {
+	h := NewPollCommandHandler(nil, i18n.New("ru"), "!survey", "!опрос", " !vote ", "")
+
+	tests := []struct {
+		input     string
+		wantCmd   string
+		wantValid bool
+	}{
+		{input: `!survey create "Q" "A"`, wantCmd: "create", wantValid: true},
+		{input: `!SURVEY results p1`, wantCmd: "results", wantValid: true},
+		{input: `!опрос vote p1 "A"`, wantCmd: "vote", wantValid: true},
+		{input: `!ОПРОС end p1`, wantCmd: "end", wantValid: true},
+		{input: `!Vote`, wantCmd: "help", wantValid: true},
+		{input: `!poll create "Q" "A"`, wantValid: false},
+		{input: `!опросы create "Q" "A"`, wantValid: false},
+		{input: `text !survey create`, wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			cmd, _, valid, _ := h.ParseCommand(tt.input)
+			assert.Equal(t, tt.wantValid, valid)
+			assert.Equal(t, tt.wantCmd, cmd)
+		})
+	}
+
+	help := h.GetHelpText()
+	assert.Contains(t, help, `!survey create`)
+	assert.NotContains(t, help, "!poll")
+	assert.NotContains(t, help, "!опрос")
+
+	msg, err := h.HandleCommand(context.Background(), "unknown", nil, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Неизвестная команда. Введите !survey help для справки", msg.Text)
+}
+
+// Тест проверяет команды через упоминание бота
+func TestPollCommandHandler_Mentions(t *testing.T) {
+	h := NewPollCommandHandler(nil, i18n.New("ru"), DefaultCommandPrefix)
+	h.SetBotIdentity("pollbot", "Poll Bot", "", "Опросник")
+
+	tests := []struct {
+		name      string
+		input     string
+		wantCmd   string
+		wantArgs  []string
+		wantValid bool
+	}{
+		{
+			name:      "username",
+			input:     `@pollbot create "Q" "A" "B"`,
+			wantCmd:   "create",
+			wantArgs:  []string{"Q", "A", "B"},
+			wantValid: true,
+		},
+		{
+			name:      "trailing colon",
+			input:     `@pollbot: vote p1 "A"`,
+			wantCmd:   "vote",
+			wantArgs:  []string{"p1", "A"},
+			wantValid: true,
+		},
+		{
+			name:      "colon without space",
+			input:     `@PollBot:results p1`,
+			wantCmd:   "results",
+			wantArgs:  []string{"p1"},
+			wantValid: true,
+		},
+		{
+			name:      "display name with space",
+			input:     `@Poll Bot, end p1`,
+			wantCmd:   "end",
+			wantArgs:  []string{"p1"},
+			wantValid: true,
+		},
+		{
+			name:      "cyrillic display name",
+			input:     `@опросник delete p1`,
+			wantCmd:   "delete",
+			wantArgs:  []string{"p1"},
+			wantValid: true,
+		},
+		{
+			name:      "mention with prefix",
+			input:     `@pollbot !poll results p1`,
+			wantCmd:   "results",
+			wantArgs:  []string{"p1"},
+			wantValid: true,
+		},
+		{
+			name:      "bare mention shows help",
+			input:     `  @pollbot`,
+			wantCmd:   "help",
+			wantValid: true,
+		},
+		{
+			name:      "prefix still works",
+			input:     `!poll results p1`,
+			wantCmd:   "results",
+			wantArgs:  []string{"p1"},
+			wantValid: true,
+		},
+		{
+			name:  "mention in the middle",
+			input: `спасибо @pollbot create "Q" "A"`,
+		},
+		{
+			name:  "other user with same prefix",
+			input: `@pollbot2 create "Q" "A"`,
+		},
+		{
+			name:  "other user",
+			input: `@alice create "Q" "A"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args, valid, _ := h.ParseCommand(tt.input)
+			assert.Equal(t, tt.wantValid, valid)
+			assert.Equal(t, tt.wantCmd, cmd)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+// Тест проверяет, что до аутентификации упоминания не распознаются
+func TestPollCommandHandler_MentionsWithoutIdentity(t *testing.T) {
+	h := NewPollCommandHandler(nil, i18n.New("ru"), DefaultCommandPrefix)
+
+	_, _, valid, _ := h.ParseCommand(`@pollbot create "Q" "A"`)
+	assert.False(t, valid)
+}
+
+// Тест проверяет подробную справку по каждой команде и справку по неизвестной команде
+func TestPollCommandHandler_CommandHelp(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	// Команды list, ping и version выполняются и без аргументов
+	mockService := new(MockPollService)
+	mockService.On("ListOpenPolls", ctx, "user1", service.ListOptions{}).Return("Открытых опросов нет", nil)
+	mockService.On("Ping", ctx).Return("pong", nil)
+	mockService.On("Version", ctx).Return("dev", nil)
+	h := NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix)
+	summary := h.GetHelpText()
+
+	for _, entry := range h.commands.commands {
+		t.Run(entry.name, func(t *testing.T) {
+			details, err := h.HandleCommand(ctx, "help", []string{entry.name}, "user1")
+			assert.NoError(t, err)
+			assert.Contains(t, details.Text, "!poll "+entry.name)
+			assert.NotEqual(t, summary, details.Text)
+			assert.Equal(t, details.Text, h.GetCommandHelp(strings.ToUpper(entry.name)))
+
+			// Каждая команда из справки должна обрабатываться, а не считаться неизвестной
+			msg, _ := h.HandleCommand(ctx, entry.name, nil, "user1")
+			assert.NotEqual(t, "Неизвестная команда. Введите !poll help для справки", msg.Text)
+		})
+	}
+
+	msg, err := h.HandleCommand(ctx, "help", []string{"launch"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Нет справки по команде 'launch'. Доступные команды: create, vote, results, myvote, list, search, end, delete, winner, clone, transfer, set, edit, stats, progress, schedule, template, create-from, digest, audit, usage, feature, unfeature, ping, version, help", msg.Text)
+
+	assert.Len(t, strings.Split(summary, "\n"), len(h.commands.commands)+2, "заголовок, по строке на команду и подсказка")
+}
+
+// Тест проверяет, что команда audit доступна только администраторам
+func TestPollCommandHandler_AuditAdminOnly(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	mockService := new(MockPollService)
+	h := NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix)
+	h.SetAdmins("admin1", " admin2 ")
+
+	mockService.On("AuditLog", ctx, "poll123", 0).Return("Журнал", nil)
+	mockService.On("AuditLog", ctx, "poll123", 50).Return("Журнал 50", nil)
+
+	tests := []struct {
+		name    string
+		userID  string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "admin", userID: "admin1", args: []string{"poll123"}, want: "Журнал"},
+		{name: "admin with limit", userID: "admin2", args: []string{"poll123", "50"}, want: "Журнал 50"},
+		{name: "bad limit", userID: "admin1", args: []string{"poll123", "все"}, want: "Формат: !poll audit \"ID опроса\" [число событий]"},
+		{name: "not admin", userID: "user1", args: []string{"poll123"}, wantErr: "команда доступна только администраторам"},
+		// Формат команды не подсказывается тем, кому она недоступна
+		{name: "not admin without args", userID: "user1", args: []string{}, wantErr: "команда доступна только администраторам"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := h.HandleCommand(ctx, "audit", tt.args, tt.userID)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, msg.Text)
+		})
+	}
+	mockService.AssertNumberOfCalls(t, "AuditLog", 2)
+}
+
+// Тест проверяет, что отметку опроса ставят и снимают только администраторы
+func TestPollCommandHandler_FeatureAdminOnly(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	mockService := new(MockPollService)
+	h := NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix)
+	h.SetAdmins("admin1")
+
+	mockService.On("FeaturePoll", ctx, "admin1", "poll123", true).Return("Отмечен", nil)
+	mockService.On("FeaturePoll", ctx, "admin1", "poll123", false).Return("Снята", nil)
+
+	tests := []struct {
+		name    string
+		command string
+		userID  string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "admin features", command: "feature", userID: "admin1", args: []string{"poll123"}, want: "Отмечен"},
+		{name: "admin unfeatures", command: "unfeature", userID: "admin1", args: []string{"poll123"}, want: "Снята"},
+		{name: "extra args", command: "feature", userID: "admin1", args: []string{"poll123", "poll456"}, want: "Формат: !poll feature \"ID опроса\""},
+		{name: "not admin", command: "feature", userID: "user1", args: []string{"poll123"}, wantErr: "команда доступна только администраторам"},
+		{name: "not admin unfeatures", command: "unfeature", userID: "user1", args: []string{"poll123"}, wantErr: "команда доступна только администраторам"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := h.HandleCommand(ctx, tt.command, tt.args, tt.userID)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, msg.Text)
+		})
+	}
+	mockService.AssertNumberOfCalls(t, "FeaturePoll", 2)
+}
+
+// Тест проверяет end --all: свои опросы закрывает любой, опросы канала -
+// только администратор, лишние аргументы дают подсказку формата
+func TestPollCommandHandler_EndAll(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	mockService := new(MockPollService)
+	h := NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix)
+	h.SetAdmins("admin1")
+
+	mockService.On("EndPolls", ctx, "user1", service.EndAllOptions{}).Return("Закрыто опросов: 2, ошибок: 0", nil)
+	mockService.On("EndPolls", ctx, "admin1", service.EndAllOptions{Channel: true}).Return("Закрыто опросов: 5, ошибок: 0", nil)
+
+	usage := "Формат: !poll end \"ID опроса\" | --all [--channel]"
+	tests := []struct {
+		name    string
+		userID  string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "own polls", userID: "user1", args: []string{"--all"}, want: "Закрыто опросов: 2, ошибок: 0"},
+		{name: "channel polls by admin", userID: "admin1", args: []string{"--all", "--channel"}, want: "Закрыто опросов: 5, ошибок: 0"},
+		{name: "channel polls by user", userID: "user1", args: []string{"--all", "--channel"}, wantErr: "команда доступна только администраторам"},
+		{name: "unknown flag", userID: "admin1", args: []string{"--all", "--everything"}, want: usage},
+		{name: "poll ID with flag", userID: "user1", args: []string{"poll123", "--channel"}, want: usage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := h.HandleCommand(ctx, "end", tt.args, tt.userID)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, msg.Text)
+		})
+	}
+	mockService.AssertNumberOfCalls(t, "EndPolls", 2)
+}
+
+// Тест проверяет, что подсказки, справка и свой голос видит только автор
+// команды, а результаты и созданные опросы - весь канал
+func TestPollCommandHandler_Ephemeral(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	mockService := new(MockPollService)
+	h := NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix)
+	mockService.On("CreatePoll", ctx, "user1", "Q", []string{"A"}, service.CreateOptions{}).Return("poll123", nil)
+	mockService.On("GetResults", ctx, "user1", "poll123").Return("Итоги", nil)
+	mockService.On("MyVote", ctx, "user1", "poll123").Return("Ваш голос", nil)
+	mockService.On("Ping", ctx).Return("pong", nil)
+	mockService.On("Version", ctx).Return("dev", nil)
+
+	tests := []struct {
+		name          string
+		command       string
+		args          []string
+		wantEphemeral bool
+	}{
+		{name: "create", command: "create", args: []string{"Q", "A"}},
+		{name: "results", command: "results", args: []string{"poll123"}},
+		{name: "myvote", command: "myvote", args: []string{"poll123"}, wantEphemeral: true},
+		{name: "usage", command: "vote", args: nil, wantEphemeral: true},
+		{name: "flag usage", command: "create", args: []string{"Q", "--ranked"}, wantEphemeral: true},
+		{name: "schedule usage", command: "schedule", args: []string{"pause"}, wantEphemeral: true},
+		{name: "unknown command", command: "launch", wantEphemeral: true},
+		{name: "ping", command: "ping", wantEphemeral: true},
+		{name: "version", command: "version", wantEphemeral: true},
+		{name: "help", command: "help", wantEphemeral: true},
+		{name: "command help", command: "help", args: []string{"vote"}, wantEphemeral: true},
+	}
+
+	h.SetScheduleService(new(MockScheduleService))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := h.HandleCommand(ctx, tt.command, tt.args, "user1")
+			assert.NoError(t, err)
+			assert.NotEmpty(t, resp.Text)
+			assert.Equal(t, tt.wantEphemeral, resp.Ephemeral)
+		})
+	}
+}
+
+// Тест проверяет, что подтверждение голоса можно заменить реакцией,
+// а голос, закрывший опрос по кворуму, - нельзя
+func TestPollCommandHandler_QuietVote(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	mockService := new(MockPollService)
+	h := NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix)
+	mockService.On("AddVote", ctx, "user1", "poll1", []string{"A"}).Return("Голос учтён", nil)
+	mockService.On("AddVote", ctx, "user1", "poll2", []string{"A"}).Return("Голос учтён\nКворум набран\nA: 3", nil)
+
+	resp, err := h.HandleCommand(ctx, "vote", []string{"poll1", "A"}, "user1")
+	assert.NoError(t, err)
+	assert.True(t, resp.Quiet)
+
+	resp, err = h.HandleCommand(ctx, "vote", []string{"poll2", "A"}, "user1")
+	assert.NoError(t, err)
+	assert.False(t, resp.Quiet)
+
+	resp, err = h.HandleCommand(ctx, "results", nil, "user1")
+	assert.NoError(t, err)
+	assert.False(t, resp.Quiet)
+}
+
+// reportingPollService - сервис, который отдаёт итоги вместе с текстом
+type reportingPollService struct {
+	*MockPollService
+	results service.Results
+}
+
+func (s *reportingPollService) ResultsReport(ctx context.Context, userID, pollID string) (string, service.Results, error) {
+	return "Итоги", s.results, nil
+}
+
+// Тест проверяет, что ответ на results несёт итоги для вложения, если
+// сервис их отдаёт, и остаётся текстом, если нет
+func TestPollCommandHandler_ResultsReport(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	want := service.Results{PollID: "poll123", Question: "Q"}
+	h := NewPollCommandHandler(&reportingPollService{MockPollService: new(MockPollService), results: want}, i18n.New("ru"), DefaultCommandPrefix)
+
+	resp, err := h.HandleCommand(ctx, "results", []string{"poll123"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Итоги", resp.Text)
+	assert.Equal(t, &want, resp.Results)
+
+	mockService := new(MockPollService)
+	mockService.On("GetResults", ctx, "user1", "poll123").Return("Итоги", nil)
+	resp, err = NewPollCommandHandler(mockService, i18n.New("ru"), DefaultCommandPrefix).HandleCommand(ctx, "results", []string{"poll123"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Итоги", resp.Text)
+	assert.Nil(t, resp.Results)
+}
+
+// recentPollService - сервис, который показывает изменения итогов за период
+type recentPollService struct {
+	*reportingPollService
+	window time.Duration
+}
+
+func (s *recentPollService) ResultsSince(ctx context.Context, userID, pollID string, window time.Duration) (string, service.Results, error) {
+	s.window = window
+	return "Итоги с изменениями", s.results, nil
+}
+
+// Тест проверяет разбор results --since: срок следующим аргументом или
+// через "=", неверный срок, лишние аргументы и сервис без итогов за период
+func TestPollCommandHandler_ResultsSince(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	tests := []struct {
+		name       string
+		args       []string
+		wantWindow time.Duration
+		wantText   string
+		wantErr    string
+	}{
+		{name: "hours", args: []string{"poll123", "--since", "1h"}, wantWindow: time.Hour, wantText: "Итоги с изменениями"},
+		{name: "with equals", args: []string{"poll123", "--SINCE=90m"}, wantWindow: 90 * time.Minute, wantText: "Итоги с изменениями"},
+		{name: "days", args: []string{"poll123", "--since", "3d"}, wantWindow: 72 * time.Hour, wantText: "Итоги с изменениями"},
+		{name: "invalid", args: []string{"poll123", "--since", "вчера"}, wantErr: "период --since задаётся как 90m, 12h или 3d и не может быть меньше минуты"},
+		{name: "too short", args: []string{"poll123", "--since=30s"}, wantErr: "период --since задаётся как 90m, 12h или 3d и не может быть меньше минуты"},
+		{name: "no value", args: []string{"poll123", "--since"}, wantText: "Формат: !poll results \"ID опроса\" [--since 1h | --active-only] [--table] [--page 2]"},
+		{name: "extra argument", args: []string{"poll123", "--since=1h", "extra"}, wantText: "Формат: !poll results \"ID опроса\" [--since 1h | --active-only] [--table] [--page 2]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &recentPollService{reportingPollService: &reportingPollService{MockPollService: new(MockPollService)}}
+			h := NewPollCommandHandler(svc, i18n.New("ru"), DefaultCommandPrefix)
+
+			resp, err := h.HandleCommand(ctx, "results", tt.args, "user1")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantText, resp.Text)
+			assert.Equal(t, tt.wantWindow, svc.window)
+			assert.Nil(t, resp.Results, "изменений за период во вложении нет")
+		})
+	}
+
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	_, err := h.HandleCommand(ctx, "results", []string{"poll123", "--since", "1h"}, "user1")
+	assert.EqualError(t, err, "итоги за период --since здесь недоступны")
+}
+
+// activePollService - сервис с итогами без деактивированных участников.
+type activePollService struct {
+	*reportingPollService
+	calls int
+}
+
+func (s *activePollService) ResultsActiveOnly(ctx context.Context, userID, pollID string) (string, service.Results, error) {
+	s.calls++
+	return "Итоги без деактивированных", s.results, nil
+}
+
+// Тест проверяет разбор results --active-only: флаг в любой позиции и
+// с таблицей, несовместимость с --since и сервис без такой проверки
+func TestPollCommandHandler_ResultsActiveOnly(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	results := service.Results{PollID: "poll123", Question: "Обед?", Options: []service.OptionVotes{{Option: "Кафе", Votes: 1}}, VoterCount: 1}
+	usage := "Формат: !poll results \"ID опроса\" [--since 1h | --active-only] [--table] [--page 2]"
+	tests := []struct {
+		name      string
+		args      []string
+		wantText  string
+		wantCalls int
+	}{
+		{name: "flag", args: []string{"poll123", "--active-only"}, wantText: "Итоги без деактивированных", wantCalls: 1},
+		{name: "flag first", args: []string{"--ACTIVE-ONLY", "poll123"}, wantText: "Итоги без деактивированных", wantCalls: 1},
+		{name: "table", args: []string{"poll123", "--active-only", "--table"}, wantText: service.RenderResultsTable(i18n.New("ru"), results, 0), wantCalls: 1},
+		{name: "with since", args: []string{"poll123", "--active-only", "--since", "1h"}, wantText: usage},
+		{name: "flag only", args: []string{"--active-only"}, wantText: usage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &activePollService{reportingPollService: &reportingPollService{MockPollService: new(MockPollService), results: results}}
+			h := NewPollCommandHandler(svc, i18n.New("ru"), DefaultCommandPrefix)
+
+			resp, err := h.HandleCommand(ctx, "results", tt.args, "user1")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantText, resp.Text)
+			assert.Nil(t, resp.Results, "итогов без деактивированных во вложении нет")
+			assert.Equal(t, tt.wantCalls, svc.calls)
+		})
+	}
+
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	_, err := h.HandleCommand(ctx, "results", []string{"poll123", "--active-only"}, "user1")
+	assert.EqualError(t, err, "итоги без деактивированных участников --active-only здесь недоступны")
+}
+
+// Тест проверяет results --table: флаг в любой позиции, вместе с --since и
+// таблица по умолчанию; таблица заменяет вложение, а ширина варианта
+// берётся из настроек обработчика
+func TestPollCommandHandler_ResultsTable(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	results := service.Results{
+		PollID:     "poll123",
+		Question:   "Обед?",
+		Options:    []service.OptionVotes{{Option: "Столовая на первом этаже", Votes: 1}},
+		VoterCount: 1,
+	}
+	loc := i18n.New("ru")
+	table := service.RenderResultsTable(loc, results, 10)
+	tests := []struct {
+		name      string
+		args      []string
+		byDefault bool
+		wantText  string
+		wantCard  bool
+	}{
+		{name: "flag", args: []string{"poll123", "--table"}, wantText: table},
+		{name: "flag first", args: []string{"--TABLE", "poll123"}, wantText: table},
+		{name: "default", args: []string{"poll123"}, byDefault: true, wantText: table},
+		{name: "with since", args: []string{"poll123", "--table", "--since", "1h"}, wantText: table},
+		{name: "without flag", args: []string{"poll123"}, wantText: "Итоги", wantCard: true},
+		{name: "flag only", args: []string{"--table"}, wantText: "Формат: !poll results \"ID опроса\" [--since 1h | --active-only] [--table] [--page 2]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &recentPollService{reportingPollService: &reportingPollService{MockPollService: new(MockPollService), results: results}}
+			h := NewPollCommandHandler(svc, loc, DefaultCommandPrefix)
+			h.SetResultsTable(service.ResultsTableOptions{Default: tt.byDefault, OptionWidth: 10})
+
+			resp, err := h.HandleCommand(ctx, "results", tt.args, "user1")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantText, resp.Text)
+			assert.Equal(t, tt.wantCard, resp.Results != nil)
+		})
+	}
+	assert.Contains(t, table, "| Столовая… | 1 | 100% | ██████████ |")
+}
+
+// Тест проверяет results --page: номер следующим аргументом или через "=",
+// страницу вложения, таблицу страницы и неверные номера
+func TestPollCommandHandler_ResultsPage(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	loc := i18n.New("ru")
+	results := service.Results{PollID: "poll123", Question: "Обед?"}
+	for i := 1; i <= 30; i++ {
+		results.Options = append(results.Options, service.OptionVotes{Option: fmt.Sprintf("Вариант %02d", i), Votes: 1})
+	}
+	results.VoterCount = 30
+	second := results
+	second.Page = 2
+	tests := []struct {
+		name     string
+		args     []string
+		wantText string
+		wantPage int
+		wantCard bool
+		wantErr  string
+	}{
+		{name: "first page", args: []string{"poll123"}, wantText: "Итоги", wantCard: true},
+		{name: "page", args: []string{"poll123", "--page", "2"}, wantText: service.RenderResults(loc, second), wantPage: 2, wantCard: true},
+		{name: "with equals", args: []string{"--PAGE=2", "poll123"}, wantText: service.RenderResults(loc, second), wantPage: 2, wantCard: true},
+		{name: "page one", args: []string{"poll123", "--page=1"}, wantText: "Итоги", wantPage: 1, wantCard: true},
+		{name: "table", args: []string{"poll123", "--table", "--page", "2"}, wantText: service.RenderResultsTable(loc, second, 0)},
+		{name: "since", args: []string{"poll123", "--page", "2", "--since", "1h"}, wantText: service.RenderResults(loc, second)},
+		{name: "no value", args: []string{"poll123", "--page"}, wantText: "Формат: !poll results \"ID опроса\" [--since 1h | --active-only] [--table] [--page 2]"},
+		{name: "zero", args: []string{"poll123", "--page", "0"}, wantErr: "страница --page задаётся числом от 1"},
+		{name: "not a number", args: []string{"poll123", "--page=два"}, wantErr: "страница --page задаётся числом от 1"},
+		{name: "past last", args: []string{"poll123", "--page", "3"}, wantErr: "в итогах опроса страниц вариантов: 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &recentPollService{reportingPollService: &reportingPollService{MockPollService: new(MockPollService), results: results}}
+			h := NewPollCommandHandler(svc, loc, DefaultCommandPrefix)
+
+			resp, err := h.HandleCommand(ctx, "results", tt.args, "user1")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantText, resp.Text)
+			assert.Equal(t, tt.wantCard, resp.Results != nil)
+			if resp.Results != nil {
+				assert.Equal(t, tt.wantPage, resp.Results.Page)
+			}
+		})
+	}
+	assert.Contains(t, service.RenderResults(loc, second), "- Вариант 26: 1 голосов\n")
+}
+
+// creatingPollService - сервис, который отдаёт ID созданного опроса
+type creatingPollService struct {
+	*MockPollService
+	created service.CreatedPoll
+}
+
+func (s *creatingPollService) CreatePollWithID(ctx context.Context, userID, question string, options []string, opts service.CreateOptions) (service.CreatedPoll, error) {
+	return s.created, nil
+}
+
+// Тест проверяет, что ответ на create несёт ID нового опроса, но не
+// повтора и не закреплённого опроса, который уже опубликован
+func TestPollCommandHandler_CreatePollID(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	tests := []struct {
+		name    string
+		args    []string
+		created service.CreatedPoll
+		want    string
+	}{
+		{name: "new poll", args: []string{"Q", "A", "B"}, created: service.CreatedPoll{ID: "poll123", Message: "Создан"}, want: "poll123"},
+		{name: "duplicate", args: []string{"Q", "A", "B"}, created: service.CreatedPoll{ID: "poll123", Message: "Создан", Duplicate: true}},
+		{name: "pinned", args: []string{"--pin", "Q", "A", "B"}, created: service.CreatedPoll{ID: "poll123", Message: "Закреплён"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewPollCommandHandler(&creatingPollService{MockPollService: new(MockPollService), created: tt.created}, i18n.New("ru"), DefaultCommandPrefix)
+
+			resp, err := h.HandleCommand(ctx, "create", tt.args, "user1")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.created.Message, resp.Text)
+			assert.Equal(t, tt.want, resp.PollID)
+		})
+	}
+}
+
+// previewingPollService - сервис, который умеет показывать предпросмотр
+type previewingPollService struct {
+	*MockPollService
+	preview string
+}
+
+func (s *previewingPollService) PreviewPoll(ctx context.Context, userID, question string, options []string, opts service.CreateOptions) (string, error) {
+	return s.preview + ": " + question + " " + strings.Join(options, ", "), nil
+}
+
+// Тест проверяет, что create --dry-run отвечает предпросмотром только автору
+// и не создаёт опрос
+func TestPollCommandHandler_CreateDryRun(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+
+	t.Run("preview", func(t *testing.T) {
+		mockService := new(MockPollService)
+		h := NewPollCommandHandler(&previewingPollService{MockPollService: mockService, preview: "Предпросмотр"}, i18n.New("ru"), DefaultCommandPrefix)
+
+		resp, err := h.HandleCommand(ctx, "create", []string{"Q", "--dry-run", "A", "B"}, "user1")
+		assert.NoError(t, err)
+		assert.Equal(t, "Предпросмотр: Q A, B", resp.Text)
+		assert.True(t, resp.Ephemeral)
+		mockService.AssertNotCalled(t, "CreatePoll", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+
+		_, err := h.HandleCommand(ctx, "create", []string{"Q", "A", "--dry-run"}, "user1")
+		assert.EqualError(t, err, "предпросмотр опроса не поддерживается")
+	})
+}
+
+// statsPollService - сервис, который показывает статистику опроса
+type statsPollService struct {
+	*MockPollService
+}
+
+func (s *statsPollService) PollStats(ctx context.Context, userID, pollID string) (string, error) {
+	return "Опрос " + pollID + ": просмотров: 4, проголосовало: 1 (конверсия 25%)", nil
+}
+
+// Тест проверяет, что stats отвечает статистикой только автору команды, а
+// без поддержки в сервисе - ошибкой
+func TestPollCommandHandler_Stats(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+
+	h := NewPollCommandHandler(&statsPollService{MockPollService: new(MockPollService)}, i18n.New("ru"), DefaultCommandPrefix)
+	resp, err := h.HandleCommand(ctx, "stats", []string{"poll123"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Опрос poll123: просмотров: 4, проголосовало: 1 (конверсия 25%)", resp.Text)
+	assert.True(t, resp.Ephemeral)
+
+	h = NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	_, err = h.HandleCommand(ctx, "stats", []string{"poll123"}, "user1")
+	assert.EqualError(t, err, "статистика опроса не поддерживается")
+}
+
+// progressPollService - сервис, который показывает ход голосования
+type progressPollService struct {
+	*MockPollService
+	pages []int
+}
+
+func (s *progressPollService) PollProgress(ctx context.Context, userID, pollID string, page int) (string, error) {
+	s.pages = append(s.pages, page)
+	return "Опрос " + pollID + ": 7 из 10 голосов\n", nil
+}
+
+// Тест проверяет, что progress отвечает только автору команды и передаёт
+// сервису страницу --page, а без поддержки в сервисе отвечает ошибкой
+func TestPollCommandHandler_Progress(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+
+	progress := &progressPollService{MockPollService: new(MockPollService)}
+	h := NewPollCommandHandler(progress, i18n.New("ru"), DefaultCommandPrefix)
+	resp, err := h.HandleCommand(ctx, "progress", []string{"poll123"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Опрос poll123: 7 из 10 голосов\n", resp.Text)
+	assert.True(t, resp.Ephemeral)
+
+	_, err = h.HandleCommand(ctx, "progress", []string{"poll123", "--page", "2"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, progress.pages)
+
+	resp, err = h.HandleCommand(ctx, "progress", []string{"poll123", "--page"}, "user1")
+	assert.NoError(t, err)
+	assert.Contains(t, resp.Text, "Формат: !poll progress")
+	_, err = h.HandleCommand(ctx, "progress", []string{"poll123", "--page=0"}, "user1")
+	assert.EqualError(t, err, "страница --page задаётся числом от 1")
+
+	h = NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	_, err = h.HandleCommand(ctx, "progress", []string{"poll123"}, "user1")
+	assert.EqualError(t, err, "ход голосования не поддерживается")
+}
+
+// editPollService - сервис, который исправляет вопрос опроса
+type editPollService struct {
+	*MockPollService
+	edited []string
+}
+
+func (s *editPollService) EditQuestion(ctx context.Context, userID, pollID, question string) (string, error) {
+	s.edited = append(s.edited, userID+" "+pollID+" "+question)
+	return "Вопрос опроса " + pollID + " исправлен: " + question, nil
+}
+
+// Тест проверяет, что edit передаёт сервису ID и новый вопрос одним
+// аргументом, а без вопроса или без поддержки в сервисе отвечает ошибкой
+func TestPollCommandHandler_Edit(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+
+	service := &editPollService{MockPollService: new(MockPollService)}
+	h := NewPollCommandHandler(service, i18n.New("ru"), DefaultCommandPrefix)
+	resp, err := h.HandleCommand(ctx, "edit", []string{"poll123", "Где обедаем?"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Вопрос опроса poll123 исправлен: Где обедаем?", resp.Text)
+	assert.False(t, resp.Ephemeral)
+
+	resp, err = h.HandleCommand(ctx, "edit", []string{"poll123"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Формат: !poll edit \"ID опроса\" \"Новый вопрос\"", resp.Text)
+	assert.Equal(t, []string{"user1 poll123 Где обедаем?"}, service.edited)
+
+	h = NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	_, err = h.HandleCommand(ctx, "edit", []string{"poll123", "Где обедаем?"}, "user1")
+	assert.EqualError(t, err, "исправление вопроса не поддерживается")
+}
+
+type MockUsageService struct {
+	mock.Mock
+}
+
+func (m *MockUsageService) Usage(ctx context.Context, days int) (string, error) {
+	args := m.Called(ctx, days)
+	return args.String(0), args.Error(1)
+}
+
+// Тест проверяет команду usage: только для администраторов, число дней
+// необязательно и должно быть положительным, ответ виден только автору
+func TestPollCommandHandler_Usage(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	usage := new(MockUsageService)
+	h := NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	h.SetAdmins("admin1")
+	h.SetUsageService(usage)
+
+	tests := []struct {
+		name        string
+		userID      string
+		args        []string
+		mockSetup   func()
+		wantMessage string
+		wantErr     string
+	}{
+		{
+			name:   "Default days",
+			userID: "admin1",
+			mockSetup: func() {
+				usage.On("Usage", ctx, 0).Return("table", nil)
+			},
+			wantMessage: "table",
+		},
+		{
+			name:   "Days",
+			userID: "admin1",
+			args:   []string{"30"},
+			mockSetup: func() {
+				usage.On("Usage", ctx, 30).Return("table 30", nil)
+			},
+			wantMessage: "table 30",
+		},
+		{
+			name:        "Bad days",
+			userID:      "admin1",
+			args:        []string{"-1"},
+			mockSetup:   func() {},
+			wantMessage: "Формат: !poll usage [число дней]",
+		},
+		{
+			name:      "Not admin",
+			userID:    "user1",
+			mockSetup: func() {},
+			wantErr:   "команда доступна только администраторам",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage.ExpectedCalls = nil
+			tt.mockSetup()
+
+			resp, err := h.HandleCommand(ctx, "usage", tt.args, tt.userID)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantMessage, resp.Text)
+			assert.True(t, resp.Ephemeral)
+			usage.AssertExpectations(t)
+		})
+	}
+
+	h = NewPollCommandHandler(new(MockPollService), i18n.New("ru"), DefaultCommandPrefix)
+	h.SetAdmins("admin1")
+	_, err := h.HandleCommand(ctx, "usage", nil, "admin1")
+	assert.EqualError(t, err, "статистика команд не настроена")
+}
+
+// Тест проверяет, что vote принимает вариант из нескольких слов без
+// кавычек, а лишние слова после ID опроса в results, end и delete
+// по-прежнему дают подсказку о формате
+func TestPollCommandHandler_VoteUnquotedOption(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryPollRepo()
+	polls := service.NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	h := NewPollCommandHandler(polls, i18n.New("ru"), DefaultCommandPrefix)
+	single, err := polls.CreatePollWithID(ctx, "creator", "Где обедаем?", []string{"Пицца", "Суши бар"}, service.CreateOptions{})
+	assert.NoError(t, err)
+	ranked, err := polls.CreatePollWithID(ctx, "creator", "Куда едем?", []string{"Нью", "Йорк", "Нью Йорк"}, service.CreateOptions{Ranked: true})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		userID      string
+		wantMessage string
+		wantErr     string
+	}{
+		{
+			name:        "joined option",
+			command:     "vote",
+			args:        []string{single.ID, "суши", "бар"},
+			userID:      "user1",
+			wantMessage: "Суши бар",
+		},
+		{
+			name:    "wrong words",
+			command: "vote",
+			args:    []string{single.ID, "Бургер", "кинг"},
+			userID:  "user2",
+			wantErr: "вариант 'Бургер кинг' не существует",
+		},
+		{
+			name:    "ambiguous join",
+			command: "vote",
+			args:    []string{ranked.ID, "Нью", "Йорк"},
+			userID:  "user2",
+			wantErr: "непонятно, голос за вариант 'Нью Йорк' или за варианты по порядку Нью > Йорк; вариант из нескольких слов возьмите в кавычки",
+		},
+		{
+			name:        "results with extra words",
+			command:     "results",
+			args:        []string{single.ID, "Суши", "бар"},
+			wantMessage: "Формат: !poll results",
+		},
+		{
+			name:        "end with extra words",
+			command:     "end",
+			args:        []string{single.ID, "Суши"},
+			userID:      "creator",
+			wantMessage: "Формат: !poll end",
+		},
+		{
+			name:        "delete with extra words",
+			command:     "delete",
+			args:        []string{single.ID, "Суши"},
+			userID:      "creator",
+			wantMessage: "Формат: !poll delete",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := h.HandleCommand(ctx, tt.command, tt.args, tt.userID)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, resp.Text, tt.wantMessage)
+		})
+	}
+}
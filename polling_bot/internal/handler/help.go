@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"strings"
+
+	"polling_bot/internal/i18n"
+)
+
+// Справка собирается из строк summary и details команд в порядке их
+// регистрации, поэтому одинаковый набор команд даёт одинаковый текст;
+// это проверяют эталоны testdata/help.
+
+// GetHelpText возвращает краткую справку: по строке на команду.
+func (h *PollCommandHandler) GetHelpText() string {
+	return h.helpText(h.localizer)
+}
+
+// GetCommandHelp возвращает подробную справку по команде с примерами.
+func (h *PollCommandHandler) GetCommandHelp(command string) string {
+	return h.commandHelp(h.localizer, command)
+}
+
+// introCommands - команды, с которых бот начинает знакомство с каналом.
+var introCommands = []string{"create", "vote", "results"}
+
+// IntroText возвращает знакомство с каналом: основные команды и ссылку
+// на полную справку.
+func (h *PollCommandHandler) IntroText(loc *i18n.Localizer) string {
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.IntroHeader))
+	for _, name := range introCommands {
+		if cmd, ok := h.commands.lookup(name); ok {
+			sb.WriteString("\n    ")
+			sb.WriteString(loc.T(cmd.summary, h.Prefix()))
+		}
+	}
+	sb.WriteString("\n")
+	sb.WriteString(loc.T(i18n.IntroFooter, h.Prefix()))
+	return sb.String()
+}
+
+func (h *PollCommandHandler) helpText(loc *i18n.Localizer) string {
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.HelpHeader))
+	for _, cmd := range h.commands.commands {
+		sb.WriteString("\n    ")
+		sb.WriteString(loc.T(cmd.summary, h.Prefix()))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(loc.T(i18n.HelpFooter, h.Prefix()))
+	return sb.String()
+}
+
+func (h *PollCommandHandler) commandHelp(loc *i18n.Localizer, command string) string {
+	command = strings.ToLower(command)
+	if cmd, ok := h.commands.lookup(command); ok {
+		return loc.T(cmd.details, h.Prefix())
+	}
+	return loc.T(i18n.HelpUnknown, command, h.commands.names())
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталоны в testdata")
+
+// Тест проверяет справку по эталонам testdata/help/<name>.<lang>.golden:
+// порядок команд не меняется от запуска к запуску; go test -update
+// перезаписывает эталоны
+func TestHelpGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func(h *PollCommandHandler) string
+	}{
+		{"help", (*PollCommandHandler).GetHelpText},
+		{"help_create", func(h *PollCommandHandler) string { return h.GetCommandHelp("create") }},
+		{"help_vote", func(h *PollCommandHandler) string { return h.GetCommandHelp("VOTE") }},
+		{"help_set", func(h *PollCommandHandler) string { return h.GetCommandHelp("set") }},
+		{"help_unknown", func(h *PollCommandHandler) string { return h.GetCommandHelp("ranked") }},
+		{"intro", func(h *PollCommandHandler) string { return h.IntroText(h.localizer) }},
+	}
+
+	for _, tt := range tests {
+		for _, lang := range []string{"ru", "en"} {
+			t.Run(tt.name+"/"+lang, func(t *testing.T) {
+				h := NewPollCommandHandler(nil, i18n.New(lang), DefaultCommandPrefix)
+				got := tt.render(h)
+				for i := 0; i < 10; i++ {
+					require.Equal(t, got, tt.render(NewPollCommandHandler(nil, i18n.New(lang), DefaultCommandPrefix)))
+				}
+
+				path := filepath.Join("testdata", "help", tt.name+"."+lang+".golden")
+				if *update {
+					require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+					require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+				}
+				want, err := os.ReadFile(path)
+				require.NoError(t, err, "нет эталона, запустите go test -update")
+				assert.Equal(t, string(want), got, "справка не совпадает с эталоном %s", path)
+			})
+		}
+	}
+}
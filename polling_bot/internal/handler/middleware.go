@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/service"
+)
+
+// CommandContext - команда, которую выполняет обработчик, и откуда она
+// пришла. ChannelID и PostID пусты, если команда пришла не из сообщения,
+// например из теста или своего кода встроившего бота сервиса.
+type CommandContext struct {
+	// Имя команды; у известной команды псевдоним заменён основным именем
+	Command string
+	Args    []string
+	UserID  string
+	// ChannelID и PostID - канал и сообщение с командой
+	ChannelID string
+	PostID    string
+	// Known - команда есть в обработчике; неизвестная получит подсказку
+	Known bool
+}
+
+// HandlerFunc выполняет команду и возвращает ответ на неё.
+type HandlerFunc func(ctx context.Context, cmd CommandContext) (Response, error)
+
+// Middleware оборачивает выполнение команды: может изменить команду
+// перед next, ответ или ошибку после, или ответить сам, не вызывая next.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Use добавляет middleware вокруг выполнения команд. Первое добавленное
+// выполняется первым и видит ответ последним; Use вызывается при сборке
+// бота, до первой команды.
+func (h *PollCommandHandler) Use(middlewares ...Middleware) {
+	h.middlewares = append(h.middlewares, middlewares...)
+}
+
+// chain оборачивает run всеми middleware обработчика.
+func (h *PollCommandHandler) chain(run HandlerFunc) HandlerFunc {
+	for i := len(h.middlewares) - 1; i >= 0; i-- {
+		run = h.middlewares[i](run)
+	}
+	return run
+}
+
+// commandContext собирает CommandContext команды из сообщения, о котором
+// бот сообщил через service.WithOrigin.
+func (h *PollCommandHandler) commandContext(ctx context.Context, command string, args []string, userID string) CommandContext {
+	origin := service.OriginFrom(ctx)
+	cc := CommandContext{Command: command, Args: args, UserID: userID, ChannelID: origin.ChannelID, PostID: origin.PostID}
+	if cmd, ok := h.commands.lookup(command); ok {
+		cc.Command, cc.Known = cmd.name, true
+	}
+	return cc
+}
+
+// unknownCommandMetric - имя неизвестных команд в счётчиках: имена,
+// которые пишут пользователи, не заводят по счётчику на каждую опечатку.
+const unknownCommandMetric = "unknown"
+
+// CountCommands считает команды и их ошибки по именам: счётчики
+// commands_total.<команда> и command_errors_total.<команда> реестра stats.
+func CountCommands(stats *metrics.Registry) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd CommandContext) (Response, error) {
+			name := cmd.Command
+			if !cmd.Known {
+				name = unknownCommandMetric
+			}
+			resp, err := next(ctx, cmd)
+			stats.Counter("commands_total." + name).Add(1)
+			if err != nil {
+				stats.Counter("command_errors_total." + name).Add(1)
+			}
+			return resp, err
+		}
+	}
+}
+
+// UsageRecorder учитывает выполненную команду; вызов не должен ждать
+// хранилища.
+type UsageRecorder interface {
+	Record(command string)
+}
+
+// RecordUsage передаёт recorder имя каждой выполненной команды, в том
+// числе завершившейся ошибкой; неизвестные команды учитываются под одним
+// именем, как в CountCommands.
+func RecordUsage(recorder UsageRecorder) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd CommandContext) (Response, error) {
+			resp, err := next(ctx, cmd)
+			name := cmd.Command
+			if !cmd.Known {
+				name = unknownCommandMetric
+			}
+			recorder.Record(name)
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/service"
+)
+
+var errEcho = errors.New("echo не удался")
+
+// newEchoHandler - обработчик с командой echo (псевдоним repeat): она
+// отвечает своим аргументом, а на "fail" - ошибкой errEcho; calls
+// считает выполнения команды.
+func newEchoHandler() (*PollCommandHandler, *int) {
+	h := NewPollCommandHandler(nil, i18n.New("ru"), DefaultCommandPrefix)
+	calls := new(int)
+	h.commands.register(&command{
+		name:    "echo",
+		aliases: []string{"repeat"},
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.ResultsUsage,
+		summary: i18n.HelpResultsSummary,
+		details: i18n.HelpResultsDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			*calls++
+			if args[0] == "fail" {
+				return Response{}, errEcho
+			}
+			return reply("echo "+args[0], nil)
+		},
+	})
+	return h, calls
+}
+
+// recordingMiddleware записывает в calls вход в middleware и выход из него.
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd CommandContext) (Response, error) {
+			*calls = append(*calls, name+">")
+			resp, err := next(ctx, cmd)
+			*calls = append(*calls, "<"+name)
+			return resp, err
+		}
+	}
+}
+
+// Тест проверяет порядок middleware и команду, которую они получают:
+// основное имя вместо псевдонима, канал и сообщение из контекста
+func TestMiddleware_Order(t *testing.T) {
+	h, _ := newEchoHandler()
+	var calls []string
+	var got CommandContext
+	h.Use(recordingMiddleware("first", &calls), recordingMiddleware("second", &calls))
+	h.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd CommandContext) (Response, error) {
+			got = cmd
+			return next(ctx, cmd)
+		}
+	})
+
+	ctx := service.WithOrigin(context.Background(), service.Origin{ChannelID: "channel1", PostID: "post1"})
+	resp, err := h.HandleCommand(ctx, "repeat", []string{"hi"}, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, "echo hi", resp.Text)
+	assert.Equal(t, []string{"first>", "second>", "<second", "<first"}, calls)
+	assert.Equal(t, CommandContext{
+		Command:   "echo",
+		Args:      []string{"hi"},
+		UserID:    "user1",
+		ChannelID: "channel1",
+		PostID:    "post1",
+		Known:     true,
+	}, got)
+}
+
+// Тест проверяет, что middleware может ответить сам, не выполняя команду,
+// изменить команду перед выполнением и получить ошибку команды
+func TestMiddleware_ShortCircuitAndErrors(t *testing.T) {
+	errGated := errors.New("команда отключена")
+	tests := []struct {
+		name       string
+		middleware Middleware
+		command    string
+		arg        string
+		wantText   string
+		wantErr    error
+		wantCalled bool
+	}{
+		{
+			name: "reply",
+			middleware: func(next HandlerFunc) HandlerFunc {
+				return func(ctx context.Context, cmd CommandContext) (Response, error) {
+					return privateReply("Команда "+cmd.Command+" отключена", nil)
+				}
+			},
+			command:  "repeat",
+			arg:      "hi",
+			wantText: "Команда echo отключена",
+		},
+		{
+			name: "error",
+			middleware: func(next HandlerFunc) HandlerFunc {
+				return func(ctx context.Context, cmd CommandContext) (Response, error) {
+					return Response{}, errGated
+				}
+			},
+			command: "echo",
+			arg:     "hi",
+			wantErr: errGated,
+		},
+		{
+			name: "rewrite",
+			middleware: func(next HandlerFunc) HandlerFunc {
+				return func(ctx context.Context, cmd CommandContext) (Response, error) {
+					cmd.Command, cmd.Args = "echo", []string{"bye"}
+					return next(ctx, cmd)
+				}
+			},
+			command:    "say",
+			arg:        "hi",
+			wantText:   "echo bye",
+			wantCalled: true,
+		},
+		{
+			name: "command error",
+			middleware: func(next HandlerFunc) HandlerFunc {
+				return func(ctx context.Context, cmd CommandContext) (Response, error) {
+					resp, err := next(ctx, cmd)
+					if err != nil {
+						err = fmt.Errorf("%s: %w", cmd.Command, err)
+					}
+					return resp, err
+				}
+			},
+			command:    "echo",
+			arg:        "fail",
+			wantErr:    errEcho,
+			wantCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, calls := newEchoHandler()
+			h.Use(tt.middleware)
+
+			resp, err := h.HandleCommand(context.Background(), tt.command, []string{tt.arg}, "user1")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantText, resp.Text)
+			assert.Equal(t, tt.wantCalled, *calls == 1)
+		})
+	}
+}
+
+// Тест проверяет счётчики команд: по основному имени, ошибки отдельно,
+// неизвестные команды - одним счётчиком
+func TestCountCommands(t *testing.T) {
+	h, _ := newEchoHandler()
+	stats := metrics.NewRegistry()
+	h.Use(CountCommands(stats))
+
+	ctx := context.Background()
+	_, err := h.HandleCommand(ctx, "repeat", []string{"hi"}, "user1")
+	require.NoError(t, err)
+	_, err = h.HandleCommand(ctx, "echo", []string{"fail"}, "user1")
+	require.ErrorIs(t, err, errEcho)
+	_, err = h.HandleCommand(ctx, "nosuchcommand", nil, "user1")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int64{
+		"commands_total.echo":       2,
+		"command_errors_total.echo": 1,
+		"commands_total.unknown":    1,
+	}, stats.Snapshot())
+}
+
+type usageNames []string
+
+func (u *usageNames) Record(command string) { *u = append(*u, command) }
+
+// Тест проверяет, что статистика получает основное имя каждой выполненной
+// команды, в том числе с ошибкой, а неизвестные команды - под одним именем
+func TestRecordUsage(t *testing.T) {
+	h, _ := newEchoHandler()
+	var recorded usageNames
+	h.Use(RecordUsage(&recorded))
+
+	ctx := context.Background()
+	_, err := h.HandleCommand(ctx, "repeat", []string{"hi"}, "user1")
+	require.NoError(t, err)
+	_, err = h.HandleCommand(ctx, "echo", []string{"fail"}, "user1")
+	require.ErrorIs(t, err, errEcho)
+	_, err = h.HandleCommand(ctx, "nosuchcommand", nil, "user1")
+	require.NoError(t, err)
+
+	assert.Equal(t, usageNames{"echo", "echo", "unknown"}, recorded)
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"polling_bot/internal/i18n"
+)
+
+// rateLimitPrune - сколько участников помнит ограничитель, прежде чем
+// забыть тех, чей запас команд уже восстановился.
+const rateLimitPrune = 1024
+
+// rateLimiter - запас команд каждого участника: perMinute команд подряд,
+// дальше по одной каждые 60/perMinute секунд.
+type rateLimiter struct {
+	perMinute float64
+	now       func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimit ограничивает число команд одного участника: не больше
+// perMinute подряд, а дальше по мере восстановления запаса. Команду сверх
+// запаса ограничитель отклоняет ошибкой со временем до следующей попытки,
+// не выполняя. perMinute не больше 0 - без ограничения.
+func RateLimit(perMinute int) Middleware {
+	return newRateLimiter(perMinute, time.Now).middleware
+}
+
+func newRateLimiter(perMinute int, now func() time.Time) *rateLimiter {
+	return &rateLimiter{perMinute: float64(perMinute), now: now, buckets: make(map[string]*rateBucket)}
+}
+
+func (l *rateLimiter) middleware(next HandlerFunc) HandlerFunc {
+	if l.perMinute <= 0 {
+		return next
+	}
+	return func(ctx context.Context, cmd CommandContext) (Response, error) {
+		if wait := l.take(cmd.UserID); wait > 0 {
+			return Response{}, i18n.NewError(i18n.CommandRateLimited, int(math.Ceil(wait.Seconds())))
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// take расходует команду из запаса userID; если запас исчерпан, команда
+// не расходуется, а take возвращает, сколько ждать следующей.
+func (l *rateLimiter) take(userID string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.buckets) >= rateLimitPrune {
+		l.prune(now)
+	}
+	bucket, ok := l.buckets[userID]
+	if !ok {
+		bucket = &rateBucket{tokens: l.perMinute, last: now}
+		l.buckets[userID] = bucket
+	}
+	bucket.tokens = math.Min(l.perMinute, bucket.tokens+now.Sub(bucket.last).Minutes()*l.perMinute)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / l.perMinute * float64(time.Minute))
+	}
+	bucket.tokens--
+	return 0
+}
+
+// prune забывает участников, чей запас восстановился: новая запись
+// получит тот же полный запас.
+func (l *rateLimiter) prune(now time.Time) {
+	for userID, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Minutes()*l.perMinute >= l.perMinute {
+			delete(l.buckets, userID)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+)
+
+// Тест проверяет ограничение команд: запас на участника, отказ со
+// временем до следующей команды без выполнения и восстановление запаса
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2025, 3, 6, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(2, func() time.Time { return now })
+	h, calls := newEchoHandler()
+	h.Use(limiter.middleware)
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := h.HandleCommand(ctx, "echo", []string{"hi"}, "user1")
+		require.NoError(t, err)
+	}
+	_, err := h.HandleCommand(ctx, "echo", []string{"hi"}, "user1")
+	var localized *i18n.Error
+	require.ErrorAs(t, err, &localized)
+	assert.Equal(t, i18n.CommandRateLimited, localized.Key)
+	assert.Equal(t, []interface{}{30}, localized.Args)
+	assert.Equal(t, 2, *calls, "команда сверх запаса не выполняется")
+
+	_, err = h.HandleCommand(ctx, "echo", []string{"hi"}, "user2")
+	assert.NoError(t, err, "у другого участника свой запас")
+
+	now = now.Add(20 * time.Second)
+	_, err = h.HandleCommand(ctx, "echo", []string{"hi"}, "user1")
+	require.ErrorAs(t, err, &localized)
+	assert.Equal(t, []interface{}{10}, localized.Args, "отказ не расходует запас")
+
+	now = now.Add(10 * time.Second)
+	_, err = h.HandleCommand(ctx, "echo", []string{"hi"}, "user1")
+	assert.NoError(t, err)
+}
+
+// Тест проверяет, что RateLimit с 0 не ограничивает команды, а
+// ограничитель забывает участников с восстановленным запасом
+func TestRateLimit_OffAndPrune(t *testing.T) {
+	h, calls := newEchoHandler()
+	h.Use(RateLimit(0))
+	for range 100 {
+		_, err := h.HandleCommand(context.Background(), "echo", []string{"hi"}, "user1")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 100, *calls)
+
+	now := time.Date(2025, 3, 6, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(1, func() time.Time { return now })
+	for i := range rateLimitPrune {
+		limiter.take(fmt.Sprint("user", i))
+	}
+	now = now.Add(time.Minute)
+	limiter.take("user1")
+	assert.Len(t, limiter.buckets, 1)
+}
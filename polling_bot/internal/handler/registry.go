@@ -0,0 +1,1048 @@
+package handler
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/service"
+)
+
+// Role - кто может выполнять команду.
+type Role int
+
+const (
+	RoleAnyone Role = iota
+	// Права создателя проверяет сервис: только он знает, кто создал опрос
+	RoleCreator
+	RoleAdmin
+)
+
+// Без ограничения на число аргументов
+const unlimitedArgs = -1
+
+// command описывает команду бота: HandleCommand проверяет число аргументов
+// и при ошибке отвечает usage, справка собирается из summary и details.
+type command struct {
+	name    string
+	aliases []string
+	minArgs int
+	maxArgs int
+	usage   i18n.Key
+	summary i18n.Key
+	details i18n.Key
+	role    Role
+	run     func(ctx context.Context, userID string, args []string) (Response, error)
+}
+
+func (c *command) acceptsArgs(n int) bool {
+	return n >= c.minArgs && (c.maxArgs == unlimitedArgs || n <= c.maxArgs)
+}
+
+// registry хранит команды в порядке справки и индекс по именам и псевдонимам.
+type registry struct {
+	commands []*command
+	byName   map[string]*command
+}
+
+func (r *registry) register(c *command) {
+	if r.byName == nil {
+		r.byName = make(map[string]*command)
+	}
+	r.commands = append(r.commands, c)
+	for _, name := range append([]string{c.name}, c.aliases...) {
+		if _, dup := r.byName[name]; dup {
+			panic("handler: команда " + name + " зарегистрирована дважды")
+		}
+		r.byName[name] = c
+	}
+}
+
+func (r *registry) lookup(name string) (*command, bool) {
+	c, ok := r.byName[name]
+	return c, ok
+}
+
+func (r *registry) names() string {
+	names := make([]string, 0, len(r.commands))
+	for _, c := range r.commands {
+		names = append(names, c.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// registerCommands задаёт набор команд обработчика; порядок регистрации
+// определяет порядок строк в справке.
+func (h *PollCommandHandler) registerCommands() {
+	h.commands.register(&command{
+		name:    "create",
+		minArgs: 2,
+		maxArgs: unlimitedArgs,
+		usage:   i18n.CreateUsage,
+		summary: i18n.HelpCreateSummary,
+		details: i18n.HelpCreateDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			args, dryRun := cutFlag(args, flagDryRun)
+			args, opts, err := parseCreateFlags(args)
+			if err != nil {
+				return Response{}, err
+			}
+			if len(args) < 2 {
+				return h.usage(ctx, i18n.CreateUsage)
+			}
+			if dryRun {
+				previewer, ok := h.service.(PollPreviewer)
+				if !ok {
+					return Response{}, i18n.NewError(i18n.PreviewUnsupported)
+				}
+				return privateReply(previewer.PreviewPoll(ctx, userID, multiline(args[0]), args[1:], opts))
+			}
+			creator, ok := h.service.(PollCreator)
+			if !ok {
+				return reply(h.service.CreatePoll(ctx, userID, multiline(args[0]), args[1:], opts))
+			}
+			created, err := creator.CreatePollWithID(ctx, userID, multiline(args[0]), args[1:], opts)
+			if err != nil {
+				return Response{}, err
+			}
+			resp := Response{Text: created.Message}
+			// Повтор уже объявлен первым ответом, а закреплённый опрос
+			// опубликован сервисом ещё до ответа
+			if !created.Duplicate && !opts.Pin {
+				resp.PollID = created.ID
+			}
+			return resp, nil
+		},
+	})
+	h.commands.register(&command{
+		name:    "vote",
+		minArgs: 2,
+		// В рейтинговом опросе голос - список вариантов
+		maxArgs: unlimitedArgs,
+		usage:   i18n.VoteUsage,
+		summary: i18n.HelpVoteSummary,
+		details: i18n.HelpVoteDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			resp, err := reply(h.service.AddVote(ctx, userID, args[0], args[1:]))
+			// Голос, закрывший опрос по кворуму, несёт итоги и подтверждением не является
+			resp.Quiet = err == nil && !strings.Contains(resp.Text, "\n")
+			return resp, err
+		},
+	})
+	h.commands.register(&command{
+		name:    "results",
+		minArgs: 1,
+		maxArgs: 6,
+		usage:   i18n.ResultsUsage,
+		summary: i18n.HelpResultsSummary,
+		details: i18n.HelpResultsDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			args, table := cutFlag(args, flagTable)
+			args, activeOnly := cutFlag(args, flagActiveOnly)
+			args, page, ok, err := cutPage(args)
+			if !ok || len(args) == 0 || activeOnly && len(args) > 1 {
+				return h.usage(ctx, i18n.ResultsUsage)
+			}
+			if err != nil {
+				return Response{}, err
+			}
+			table = table || h.table.Default
+			if activeOnly {
+				reporter, ok := h.service.(ActiveResultsReporter)
+				if !ok {
+					return Response{}, i18n.NewError(i18n.ActiveUnsupported)
+				}
+				// Итогов без деактивированных во вложении нет, как и изменений за период
+				text, results, err := reporter.ResultsActiveOnly(ctx, userID, args[0])
+				if err != nil {
+					return Response{}, err
+				}
+				return reply(h.resultsPage(ctx, text, results, page, table))
+			}
+			if len(args) > 1 {
+				window, ok, err := parseResultsFlags(args[1:])
+				if !ok {
+					return h.usage(ctx, i18n.ResultsUsage)
+				}
+				if err != nil {
+					return Response{}, err
+				}
+				recent, ok := h.service.(RecentResultsReporter)
+				if !ok {
+					return Response{}, i18n.NewError(i18n.SinceUnsupported)
+				}
+				// Изменений за период во вложении нет, поэтому ответ - только текст
+				text, results, err := recent.ResultsSince(ctx, userID, args[0], window)
+				if err != nil {
+					return Response{}, err
+				}
+				return reply(h.resultsPage(ctx, text, results, page, table))
+			}
+			reporter, ok := h.service.(ResultsReporter)
+			if !ok {
+				return reply(h.service.GetResults(ctx, userID, args[0]))
+			}
+			text, results, err := reporter.ResultsReport(ctx, userID, args[0])
+			if err != nil {
+				return Response{}, err
+			}
+			text, err = h.resultsPage(ctx, text, results, page, table)
+			if err != nil {
+				return Response{}, err
+			}
+			// Таблица - уже выбранный вид итогов, вложение её бы заменило
+			if table {
+				return reply(text, nil)
+			}
+			results.Page = page
+			return Response{Text: text, Results: &results}, nil
+		},
+	})
+	h.commands.register(&command{
+		name:    "myvote",
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.MyVoteUsage,
+		summary: i18n.HelpMyVoteSummary,
+		details: i18n.HelpMyVoteDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			// Свой голос - личная информация
+			return privateReply(h.service.MyVote(ctx, userID, args[0]))
+		},
+	})
+	h.commands.register(&command{
+		name:    "list",
+		minArgs: 0,
+		maxArgs: 3,
+		usage:   i18n.ListUsage,
+		summary: i18n.HelpListSummary,
+		details: i18n.HelpListDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			opts, ok := parseListFlags(args)
+			if !ok {
+				return h.usage(ctx, i18n.ListUsage)
+			}
+			return reply(h.service.ListOpenPolls(ctx, userID, opts))
+		},
+	})
+	h.commands.register(&command{
+		name:    "search",
+		minArgs: 1,
+		// Запрос из нескольких слов можно не брать в кавычки
+		maxArgs: unlimitedArgs,
+		usage:   i18n.SearchUsage,
+		summary: i18n.HelpSearchSummary,
+		details: i18n.HelpSearchDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			return reply(h.service.SearchPolls(ctx, userID, strings.Join(args, " ")))
+		},
+	})
+	h.commands.register(&command{
+		name:    "end",
+		minArgs: 1,
+		maxArgs: 2,
+		usage:   i18n.EndUsage,
+		summary: i18n.HelpEndSummary,
+		details: i18n.HelpEndDetails,
+		role:    RoleCreator,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			if !strings.EqualFold(args[0], flagAll) {
+				if len(args) > 1 {
+					return h.usage(ctx, i18n.EndUsage)
+				}
+				return reply(h.service.EndPoll(ctx, userID, args[0]))
+			}
+			var opts service.EndAllOptions
+			if len(args) > 1 {
+				if !strings.EqualFold(args[1], flagChannel) {
+					return h.usage(ctx, i18n.EndUsage)
+				}
+				// Чужие опросы канала закрывают только администраторы
+				if !h.admins[userID] {
+					return Response{}, i18n.NewError(i18n.AdminOnly)
+				}
+				opts.Channel = true
+			}
+			return reply(h.service.EndPolls(ctx, userID, opts))
+		},
+	})
+	h.commands.register(&command{
+		name:    "delete",
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.DeleteUsage,
+		summary: i18n.HelpDeleteSummary,
+		details: i18n.HelpDeleteDetails,
+		role:    RoleCreator,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			return reply(h.service.DeletePoll(ctx, userID, args[0]))
+		},
+	})
+	h.commands.register(&command{
+		name:    "winner",
+		minArgs: 1,
+		maxArgs: 3,
+		usage:   i18n.WinnerUsage,
+		summary: i18n.HelpWinnerSummary,
+		details: i18n.HelpWinnerDetails,
+		role:    RoleCreator,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			var option string
+			var again bool
+			for _, arg := range args[1:] {
+				switch {
+				case strings.EqualFold(arg, flagAgain):
+					again = true
+				case option == "":
+					option = arg
+				default:
+					return h.usage(ctx, i18n.WinnerUsage)
+				}
+			}
+			return reply(h.service.PickWinner(ctx, userID, args[0], option, again))
+		},
+	})
+	h.commands.register(&command{
+		name:    "clone",
+		minArgs: 1,
+		maxArgs: 2,
+		usage:   i18n.CloneUsage,
+		summary: i18n.HelpCloneSummary,
+		details: i18n.HelpCloneDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			var overrides service.CloneOverrides
+			if len(args) > 1 {
+				overrides.Question = args[1]
+			}
+			return reply(h.service.ClonePoll(ctx, userID, args[0], overrides))
+		},
+	})
+	h.commands.register(&command{
+		name:    "transfer",
+		minArgs: 2,
+		maxArgs: 2,
+		usage:   i18n.TransferUsage,
+		summary: i18n.HelpTransferSummary,
+		details: i18n.HelpTransferDetails,
+		role:    RoleCreator,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			return reply(h.service.TransferPoll(ctx, userID, args[0], args[1]))
+		},
+	})
+	h.commands.register(&command{
+		name:    "set",
+		minArgs: 1,
+		maxArgs: unlimitedArgs,
+		usage:   i18n.SetUsage,
+		summary: i18n.HelpSetSummary,
+		details: i18n.HelpSetDetails,
+		role:    RoleCreator,
+		run:     h.runSet,
+	})
+	h.commands.register(&command{
+		name:    "edit",
+		minArgs: 2,
+		maxArgs: 2,
+		usage:   i18n.EditUsage,
+		summary: i18n.HelpEditSummary,
+		details: i18n.HelpEditDetails,
+		role:    RoleCreator,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			editor, ok := h.service.(QuestionEditor)
+			if !ok {
+				return Response{}, i18n.NewError(i18n.EditUnsupported)
+			}
+			return reply(editor.EditQuestion(ctx, userID, args[0], args[1]))
+		},
+	})
+	h.commands.register(&command{
+		name:    "stats",
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.StatsUsage,
+		summary: i18n.HelpStatsSummary,
+		details: i18n.HelpStatsDetails,
+		role:    RoleCreator,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			reporter, ok := h.service.(PollStatsReporter)
+			if !ok {
+				return Response{}, i18n.NewError(i18n.StatsUnsupported)
+			}
+			return privateReply(reporter.PollStats(ctx, userID, args[0]))
+		},
+	})
+	h.commands.register(&command{
+		name:    "progress",
+		minArgs: 1,
+		maxArgs: 3,
+		usage:   i18n.ProgressUsage,
+		summary: i18n.HelpProgressSummary,
+		details: i18n.HelpProgressDetails,
+		role:    RoleCreator,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			args, page, ok, err := cutPage(args)
+			if !ok || len(args) != 1 {
+				return h.usage(ctx, i18n.ProgressUsage)
+			}
+			if err != nil {
+				return Response{}, err
+			}
+			reporter, ok := h.service.(PollProgressReporter)
+			if !ok {
+				return Response{}, i18n.NewError(i18n.ProgressUnsupported)
+			}
+			// Кто не проголосовал - не для всего канала
+			return privateReply(reporter.PollProgress(ctx, userID, args[0], page))
+		},
+	})
+	h.commands.register(&command{
+		name:    "schedule",
+		minArgs: 1,
+		maxArgs: unlimitedArgs,
+		usage:   i18n.ScheduleUsage,
+		summary: i18n.HelpScheduleSummary,
+		details: i18n.HelpScheduleDetails,
+		run:     h.runSchedule,
+	})
+	h.commands.register(&command{
+		name:    "template",
+		minArgs: 1,
+		maxArgs: unlimitedArgs,
+		usage:   i18n.TemplateUsage,
+		summary: i18n.HelpTemplateSummary,
+		details: i18n.HelpTemplateDetails,
+		run:     h.runTemplate,
+	})
+	h.commands.register(&command{
+		name:    "create-from",
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.CreateFromUsage,
+		summary: i18n.HelpCreateFromSummary,
+		details: i18n.HelpCreateFromDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			if h.templates == nil {
+				return Response{}, i18n.NewError(i18n.TemplatesDisabled)
+			}
+			return reply(h.templates.CreateFromTemplate(ctx, userID, args[0]))
+		},
+	})
+	h.commands.register(&command{
+		name:    "digest",
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.DigestUsage,
+		summary: i18n.HelpDigestSummary,
+		details: i18n.HelpDigestDetails,
+		run:     h.runDigest,
+	})
+	h.commands.register(&command{
+		name:    "audit",
+		minArgs: 1,
+		maxArgs: 2,
+		usage:   i18n.AuditUsage,
+		summary: i18n.HelpAuditSummary,
+		details: i18n.HelpAuditDetails,
+		role:    RoleAdmin,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			var limit int
+			if len(args) > 1 {
+				n, err := strconv.Atoi(args[1])
+				if err != nil || n <= 0 {
+					return h.usage(ctx, i18n.AuditUsage)
+				}
+				limit = n
+			}
+			return reply(h.service.AuditLog(ctx, args[0], limit))
+		},
+	})
+	h.commands.register(&command{
+		name:    "usage",
+		minArgs: 0,
+		maxArgs: 1,
+		usage:   i18n.UsageUsage,
+		summary: i18n.HelpUsageSummary,
+		details: i18n.HelpUsageDetails,
+		role:    RoleAdmin,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			if h.commandUsage == nil {
+				return Response{}, i18n.NewError(i18n.UsageDisabled)
+			}
+			var days int
+			if len(args) > 0 {
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n <= 0 {
+					return h.usage(ctx, i18n.UsageUsage)
+				}
+				days = n
+			}
+			return privateReply(h.commandUsage.Usage(ctx, days))
+		},
+	})
+	h.commands.register(&command{
+		name:    "feature",
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.FeatureUsage,
+		summary: i18n.HelpFeatureSummary,
+		details: i18n.HelpFeatureDetails,
+		role:    RoleAdmin,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			return reply(h.service.FeaturePoll(ctx, userID, args[0], true))
+		},
+	})
+	h.commands.register(&command{
+		name:    "unfeature",
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.UnfeatureUsage,
+		summary: i18n.HelpUnfeatureSummary,
+		details: i18n.HelpUnfeatureDetails,
+		role:    RoleAdmin,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			return reply(h.service.FeaturePoll(ctx, userID, args[0], false))
+		},
+	})
+	h.commands.register(&command{
+		name:    "ping",
+		minArgs: 0,
+		maxArgs: 0,
+		usage:   i18n.PingUsage,
+		summary: i18n.HelpPingSummary,
+		details: i18n.HelpPingDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			return privateReply(h.service.Ping(ctx))
+		},
+	})
+	h.commands.register(&command{
+		name:    "version",
+		minArgs: 0,
+		maxArgs: 0,
+		usage:   i18n.VersionUsage,
+		summary: i18n.HelpVersionSummary,
+		details: i18n.HelpVersionDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			return privateReply(h.service.Version(ctx))
+		},
+	})
+	h.commands.register(&command{
+		name:    "help",
+		minArgs: 0,
+		maxArgs: unlimitedArgs,
+		summary: i18n.HelpHelpSummary,
+		details: i18n.HelpHelpDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			loc := i18n.FromContext(ctx)
+			if len(args) > 0 {
+				return privateReply(h.commandHelp(loc, args[0]), nil)
+			}
+			return privateReply(h.helpText(loc), nil)
+		},
+	})
+}
+
+// runSchedule выполняет подкоманды schedule: create, list и delete.
+func (h *PollCommandHandler) runSchedule(ctx context.Context, userID string, args []string) (Response, error) {
+	if h.schedules == nil {
+		return Response{}, i18n.NewError(i18n.SchedulesDisabled)
+	}
+
+	switch sub, rest := strings.ToLower(args[0]), args[1:]; sub {
+	case "create":
+		rest, opts, err := parseCreateFlags(rest)
+		if err != nil {
+			return Response{}, err
+		}
+		if len(rest) < 3 {
+			return h.usage(ctx, i18n.ScheduleUsage)
+		}
+		return reply(h.schedules.CreateSchedule(ctx, userID, rest[0], rest[1], rest[2:], opts))
+	case "list":
+		if len(rest) > 0 {
+			return h.usage(ctx, i18n.ScheduleUsage)
+		}
+		return reply(h.schedules.ListSchedules(ctx, userID))
+	case "delete":
+		if len(rest) != 1 {
+			return h.usage(ctx, i18n.ScheduleUsage)
+		}
+		return reply(h.schedules.DeleteSchedule(ctx, userID, rest[0]))
+	default:
+		return h.usage(ctx, i18n.ScheduleUsage)
+	}
+}
+
+// runDigest включает или выключает сводку опросов канала.
+func (h *PollCommandHandler) runDigest(ctx context.Context, userID string, args []string) (Response, error) {
+	if h.digests == nil {
+		return Response{}, i18n.NewError(i18n.DigestsDisabled)
+	}
+	switch strings.ToLower(args[0]) {
+	case "on", "true", "yes":
+		return reply(h.digests.SetDigest(ctx, userID, true))
+	case "off", "false", "no":
+		return reply(h.digests.SetDigest(ctx, userID, false))
+	default:
+		return h.usage(ctx, i18n.DigestUsage)
+	}
+}
+
+// runTemplate выполняет подкоманды template: save, list и delete.
+func (h *PollCommandHandler) runTemplate(ctx context.Context, userID string, args []string) (Response, error) {
+	if h.templates == nil {
+		return Response{}, i18n.NewError(i18n.TemplatesDisabled)
+	}
+
+	sub := strings.ToLower(args[0])
+	rest, shared := cutFlag(args[1:], flagChannel)
+	switch sub {
+	case "save":
+		rest, opts, err := parseCreateFlags(rest)
+		if err != nil {
+			return Response{}, err
+		}
+		if len(rest) < 3 {
+			return h.usage(ctx, i18n.TemplateUsage)
+		}
+		return reply(h.templates.SaveTemplate(ctx, userID, rest[0], multiline(rest[1]), rest[2:], opts, shared))
+	case "list":
+		if len(rest) > 0 || shared {
+			return h.usage(ctx, i18n.TemplateUsage)
+		}
+		return reply(h.templates.ListTemplates(ctx, userID))
+	case "delete":
+		if len(rest) != 1 {
+			return h.usage(ctx, i18n.TemplateUsage)
+		}
+		return reply(h.templates.DeleteTemplate(ctx, userID, rest[0], shared))
+	default:
+		return h.usage(ctx, i18n.TemplateUsage)
+	}
+}
+
+// runSet показывает настройки опроса или меняет одну из них:
+// set "ID опроса" или set "ID опроса" настройка значение.
+func (h *PollCommandHandler) runSet(ctx context.Context, userID string, args []string) (Response, error) {
+	switch len(args) {
+	case 1:
+		return reply(h.service.PollSettings(ctx, userID, args[0]))
+	case 3:
+		change, err := parseSetting(ctx, args[1], args[2])
+		if err != nil {
+			return Response{}, err
+		}
+		return reply(h.service.UpdateSetting(ctx, userID, args[0], change))
+	default:
+		// Только список участников занимает несколько аргументов
+		if len(args) > 3 && strings.EqualFold(args[1], service.SettingVoters) {
+			return reply(h.service.UpdateSetting(ctx, userID, args[0], votersChange(args[2:])))
+		}
+		return h.usage(ctx, i18n.SetUsage)
+	}
+}
+
+// votersChange - новый список участников команды set: имена через пробел
+// или запятую; "off" снимает ограничение.
+func votersChange(values []string) service.SettingChange {
+	change := service.SettingChange{Setting: service.SettingVoters}
+	if len(values) == 1 && strings.EqualFold(values[0], "off") {
+		return change
+	}
+	change.Usernames = parseVoters(values)
+	return change
+}
+
+// parseSetting разбирает значение настройки команды set. "off" снимает
+// кворум, максимум голосов и срок; допустимость остального проверяет сервис.
+func parseSetting(ctx context.Context, setting, value string) (service.SettingChange, error) {
+	change := service.SettingChange{Setting: strings.ToLower(setting)}
+	value = strings.ToLower(value)
+	invalid := func(hint i18n.Key) error {
+		return i18n.NewError(i18n.SettingValueInvalid, change.Setting, i18n.FromContext(ctx).T(hint))
+	}
+	switch change.Setting {
+	case service.SettingChannelOnly:
+		switch value {
+		case "on", "true", "yes":
+			change.Enabled = true
+		case "off", "false", "no":
+		default:
+			return change, invalid(i18n.SettingHintSwitch)
+		}
+	case service.SettingQuorum, service.SettingMaxVotes:
+		if value == "off" {
+			return change, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return change, invalid(i18n.SettingHintNumber)
+		}
+		change.Number = n
+	case service.SettingExpires:
+		if value == "off" || value == "none" {
+			return change, nil
+		}
+		lifetime, ok := parseLifetime(value)
+		if !ok {
+			return change, invalid(i18n.SettingHintExpires)
+		}
+		change.Expires = lifetime
+	case service.SettingVoters:
+		return votersChange([]string{value}), nil
+	case service.SettingNoSelfVote, service.SettingAnonymous, service.SettingMembersOnly:
+		// Задаётся только при создании: отказ объясняет сервис
+	default:
+		return change, i18n.NewError(i18n.SettingUnknown, setting, strings.Join(service.Settings, ", "))
+	}
+	return change, nil
+}
+
+// cutFlag убирает из аргументов флаг без значения и сообщает, был ли он.
+func cutFlag(args []string, flag string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	var found bool
+	for _, arg := range args {
+		if strings.EqualFold(arg, flag) {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// IsDryRun сообщает, что аргументы create просят только предпросмотр:
+// такая команда опрос не создаёт.
+func IsDryRun(args []string) bool {
+	_, found := cutFlag(args, flagDryRun)
+	return found
+}
+
+// Флаги команды create
+const (
+	flagChannelOnly = "--channel-only"
+	flagQuorum      = "--quorum"
+	flagExclusive   = "--exclusive"
+	flagRanked      = "--ranked"
+	flagPin         = "--pin"
+	flagNotify      = "--notify-voters"
+	flagExpires     = "--expires"
+	flagNoExpire    = "--no-expire"
+	flagDesc        = "--desc"
+	flagTags        = "--tags"
+	flagWeights     = "--weights"
+	flagAbstain     = "--allow-abstain"
+	flagMaxVotes    = "--max-votes"
+	flagReceipts    = "--receipts"
+	flagNoSelfVote  = "--no-self-vote"
+	flagAnonymous   = "--anonymous"
+	flagMembersOnly = "--members-only"
+	flagVoters      = "--voters"
+	// Только показать, какой опрос был бы создан
+	flagDryRun = "--dry-run"
+)
+
+// Флаги команды list
+const (
+	// Только опросы с меткой
+	flagTag = "--tag"
+	// Опросы всех каналов, а не только канала команды
+	flagAll    = "--all"
+	flagGlobal = "--global"
+)
+
+// parseListFlags разбирает флаги list: --tag со значением следующим
+// аргументом или через "=" и --all. Каждый флаг - не больше раза.
+func parseListFlags(args []string) (service.ListOptions, bool) {
+	var opts service.ListOptions
+	var hasTag bool
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch {
+		case !hasValue && (strings.EqualFold(name, flagAll) || strings.EqualFold(name, flagGlobal)) && !opts.All:
+			opts.All = true
+		case strings.EqualFold(name, flagTag) && !hasTag && hasValue:
+			opts.Tag, hasTag = value, true
+		case strings.EqualFold(name, flagTag) && !hasTag && i+1 < len(args):
+			i++
+			opts.Tag, hasTag = args[i], true
+		default:
+			return service.ListOptions{}, false
+		}
+	}
+	return opts, true
+}
+
+// Флаги команды results: изменения итогов за последний период и итоги
+// таблицей
+const (
+	flagSince = "--since"
+	flagTable = "--table"
+	// results --active-only: итоги без деактивированных участников
+	flagActiveOnly = "--active-only"
+)
+
+// parseResultsFlags разбирает флаг results --since со значением следующим
+// аргументом или через "=". ok = false - аргументы не флаг --since;
+// err - срок записан неверно.
+func parseResultsFlags(args []string) (window time.Duration, ok bool, err error) {
+	name, value, hasValue := strings.Cut(args[0], "=")
+	if !strings.EqualFold(name, flagSince) {
+		return 0, false, nil
+	}
+	switch {
+	case hasValue && len(args) == 1:
+	case !hasValue && len(args) == 2:
+		value = args[1]
+	default:
+		return 0, false, nil
+	}
+	window, valid := parseLifetime(value)
+	if !valid {
+		return 0, true, i18n.NewError(i18n.SinceInvalid)
+	}
+	return window, true, nil
+}
+
+// Флаг команды results: страница вариантов, если они не умещаются на одну
+const flagPage = "--page"
+
+// cutPage отделяет флаг results --page с номером страницы следующим
+// аргументом или через "=". Без флага страница - 0, первая. ok = false -
+// у флага нет номера; err - номер записан неверно.
+func cutPage(args []string) (rest []string, page int, ok bool, err error) {
+	rest = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if !strings.EqualFold(name, flagPage) {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return nil, 0, false, nil
+			}
+			i++
+			value = args[i]
+		}
+		n, convErr := strconv.Atoi(value)
+		if convErr != nil || n < 1 {
+			err = i18n.NewError(i18n.PageInvalid)
+		}
+		page = n
+	}
+	return rest, page, true, err
+}
+
+// resultsPage показывает страницу page итогов, текстом или таблицей.
+// Первая страница без таблицы - уже готовый ответ сервиса text.
+func (h *PollCommandHandler) resultsPage(ctx context.Context, text string, results service.Results, page int, table bool) (string, error) {
+	if pages := results.Pages(); page > pages {
+		return "", i18n.NewError(i18n.PageOutOfRange, pages)
+	}
+	results.Page = page
+	loc := i18n.FromContext(ctx)
+	if table {
+		return service.RenderResultsTable(loc, results, h.table.OptionWidth), nil
+	}
+	if page > 1 {
+		return service.RenderResults(loc, results), nil
+	}
+	return text, nil
+}
+
+// Флаг команды template: шаблон канала, а не личный
+const flagChannel = "--channel"
+
+// Флаг команды winner: выбрать победителя повторно
+const flagAgain = "--again"
+
+// parseCreateFlags отделяет флаги create от вопроса и вариантов ответа.
+// Флаг распознаётся в любой позиции, чтобы его не приходилось ставить
+// строго после вопроса. Значение флага идёт следующим аргументом
+// или через "=": --quorum 5, --quorum=5.
+func parseCreateFlags(args []string) ([]string, service.CreateOptions, error) {
+	var opts service.CreateOptions
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case strings.EqualFold(arg, flagChannelOnly):
+			opts.RestrictToChannel = true
+		case strings.EqualFold(arg, flagExclusive):
+			opts.Exclusive = true
+		case strings.EqualFold(arg, flagRanked):
+			opts.Ranked = true
+		case strings.EqualFold(arg, flagPin):
+			opts.Pin = true
+		case strings.EqualFold(arg, flagNotify):
+			opts.NotifyVoters = true
+		case strings.EqualFold(arg, flagNoExpire):
+			opts.NoExpire = true
+		case strings.EqualFold(arg, flagAbstain):
+			opts.AllowAbstain = true
+		case strings.EqualFold(arg, flagReceipts):
+			opts.VoteReceipts = true
+		case strings.EqualFold(arg, flagNoSelfVote):
+			opts.NoSelfVote = true
+		case strings.EqualFold(arg, flagAnonymous):
+			opts.Anonymous = true
+		case strings.EqualFold(arg, flagMembersOnly):
+			opts.MembersOnly = true
+		case strings.EqualFold(name, flagExpires):
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, opts, i18n.NewError(i18n.ExpiresInvalid)
+				}
+				i++
+				value = args[i]
+			}
+			lifetime, ok := parseLifetime(value)
+			if !ok {
+				return nil, opts, i18n.NewError(i18n.ExpiresInvalid)
+			}
+			opts.Expires = lifetime
+		case strings.EqualFold(name, flagDesc):
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, opts, i18n.NewError(i18n.DescriptionMissing)
+				}
+				i++
+				value = args[i]
+			}
+			opts.Description = multiline(value)
+		case strings.EqualFold(name, flagTags):
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, opts, i18n.NewError(i18n.TagsMissing)
+				}
+				i++
+				value = args[i]
+			}
+			opts.Tags = append(opts.Tags, strings.Split(value, ",")...)
+		case strings.EqualFold(name, flagWeights):
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, opts, i18n.NewError(i18n.WeightsInvalid)
+				}
+				i++
+				value = args[i]
+			}
+			weights, ok := parseWeights(value)
+			if !ok {
+				return nil, opts, i18n.NewError(i18n.WeightsInvalid)
+			}
+			opts.Weights = weights
+		case strings.EqualFold(name, flagVoters):
+			// Участники идут следующими аргументами до первого без "@":
+			// --voters @alice @bob или --voters=@alice,@bob
+			values := []string{value}
+			if !hasValue {
+				values = nil
+				for i+1 < len(args) && strings.HasPrefix(args[i+1], "@") {
+					i++
+					values = append(values, args[i])
+				}
+			}
+			opts.Voters = parseVoters(values)
+			if len(opts.Voters) == 0 {
+				return nil, opts, i18n.NewError(i18n.VotersMissing)
+			}
+		case strings.EqualFold(name, flagQuorum):
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, opts, i18n.NewError(i18n.QuorumInvalid)
+				}
+				i++
+				value = args[i]
+			}
+			quorum, err := strconv.Atoi(value)
+			if err != nil || quorum < 1 {
+				return nil, opts, i18n.NewError(i18n.QuorumInvalid)
+			}
+			opts.Quorum = quorum
+		case strings.EqualFold(name, flagMaxVotes):
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, opts, i18n.NewError(i18n.MaxVotesInvalid)
+				}
+				i++
+				value = args[i]
+			}
+			maxVotes, err := strconv.Atoi(value)
+			if err != nil || maxVotes < 1 {
+				return nil, opts, i18n.NewError(i18n.MaxVotesInvalid)
+			}
+			opts.MaxVotes = maxVotes
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, opts, nil
+}
+
+// parseWeights разбирает веса голосов "@alice=2,@bob=3" в веса по именам
+// пользователей без "@". Допустимость весов проверяет сервис.
+func parseWeights(value string) (map[string]int, bool) {
+	weights := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		username, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+		if !ok || username == "" {
+			return nil, false
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil {
+			return nil, false
+		}
+		if _, ok := weights[username]; ok {
+			return nil, false
+		}
+		weights[username] = n
+	}
+	return weights, true
+}
+
+// parseVoters разбирает имена участников "@alice", "@bob,@carol" в имена
+// без "@" без повторов. Найдутся ли пользователи, проверяет сервис.
+func parseVoters(values []string) []string {
+	var usernames []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			username := strings.TrimPrefix(strings.TrimSpace(part), "@")
+			if username == "" || slices.Contains(usernames, username) {
+				continue
+			}
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// multiline переводит строки по \n: команда приходит одним сообщением,
+// и перенос строки в вопросе или пояснении записывается так.
+func multiline(text string) string {
+	return strings.ReplaceAll(text, `\n`, "\n")
+}
+
+// parseLifetime разбирает срок опроса: длительность Go (90m, 12h) или
+// число дней (3d). Срок меньше минуты не имеет смысла: опросы
+// закрываются по сроку раз в минуту.
+func parseLifetime(value string) (time.Duration, bool) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return 0, false
+		}
+		return time.Duration(n) * 24 * time.Hour, true
+	}
+	lifetime, err := time.ParseDuration(value)
+	if err != nil || lifetime < time.Minute {
+		return 0, false
+	}
+	return lifetime, true
+}
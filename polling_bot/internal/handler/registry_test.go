@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"polling_bot/internal/i18n"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест проверяет общую проверку числа аргументов
+func TestCommand_AcceptsArgs(t *testing.T) {
+	exact := &command{minArgs: 1, maxArgs: 1}
+	assert.False(t, exact.acceptsArgs(0))
+	assert.True(t, exact.acceptsArgs(1))
+	assert.False(t, exact.acceptsArgs(2))
+
+	open := &command{minArgs: 2, maxArgs: unlimitedArgs}
+	assert.False(t, open.acceptsArgs(1))
+	assert.True(t, open.acceptsArgs(2))
+	assert.True(t, open.acceptsArgs(50))
+}
+
+// Тест проверяет поиск по псевдонимам и запрет повторной регистрации
+func TestRegistry_Aliases(t *testing.T) {
+	var r registry
+	r.register(&command{name: "end", aliases: []string{"close", "stop"}})
+	r.register(&command{name: "delete"})
+
+	for _, name := range []string{"end", "close", "stop"} {
+		c, ok := r.lookup(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, "end", c.name)
+	}
+	_, ok := r.lookup("remove")
+	assert.False(t, ok)
+	assert.Equal(t, "end, delete", r.names())
+
+	assert.Panics(t, func() { r.register(&command{name: "finish", aliases: []string{"close"}}) })
+}
+
+// Тест проверяет, что новая команда получает проверку аргументов и справку без правок HandleCommand
+func TestHandleCommand_RegisteredCommand(t *testing.T) {
+	h := NewPollCommandHandler(nil, i18n.New("ru"), DefaultCommandPrefix)
+	calls := 0
+	h.commands.register(&command{
+		name:    "echo",
+		aliases: []string{"repeat"},
+		minArgs: 1,
+		maxArgs: 1,
+		usage:   i18n.ResultsUsage,
+		summary: i18n.HelpResultsSummary,
+		details: i18n.HelpResultsDetails,
+		run: func(ctx context.Context, userID string, args []string) (Response, error) {
+			calls++
+			return reply("echo "+args[0], nil)
+		},
+	})
+
+	msg, err := h.HandleCommand(context.Background(), "repeat", []string{"p1"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, Response{Text: "echo p1"}, msg)
+
+	msg, err = h.HandleCommand(context.Background(), "echo", nil, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, Response{Text: `Формат: !poll results "ID опроса" [--since 1h | --active-only] [--table] [--page 2]`, Ephemeral: true}, msg)
+	assert.Equal(t, 1, calls)
+
+	assert.Contains(t, h.GetHelpText(), "results")
+	assert.Equal(t, h.GetCommandHelp("results"), h.GetCommandHelp("echo"))
+}
@@ -0,0 +1,81 @@
+// Package health - проверки готовности бота: их выполняет команда
+// запуска с флагом --check и проверка готовности GET /readyz.
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Check - один шаг проверки. Run возвращает ошибку, если шаг не прошёл.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result - итог одного шага. Skipped - шаг не выполнялся, потому что
+// не прошёл один из предыдущих.
+type Result struct {
+	Name    string
+	Err     error
+	Skipped bool
+}
+
+// Run выполняет проверки по порядку. Каждый шаг опирается на предыдущие
+// (без соединения нечего пинговать), поэтому после первой ошибки
+// остальные шаги пропускаются.
+func Run(ctx context.Context, checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	failed := false
+	for _, check := range checks {
+		if failed {
+			results = append(results, Result{Name: check.Name, Skipped: true})
+			continue
+		}
+		err := check.Run(ctx)
+		failed = err != nil
+		results = append(results, Result{Name: check.Name, Err: err})
+	}
+	return results
+}
+
+// Skip возвращает checks пропущенными: например, когда не прошла
+// проверка, от которой они зависят.
+func Skip(checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, Result{Name: check.Name, Skipped: true})
+	}
+	return results
+}
+
+// OK сообщает, что все шаги прошли.
+func OK(results []Result) bool {
+	for _, result := range results {
+		if result.Err != nil || result.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// Render печатает отчёт: ✔ или ✘ с ошибкой для каждого шага и «-» для
+// пропущенных.
+func Render(w io.Writer, results []Result) error {
+	for _, result := range results {
+		var err error
+		switch {
+		case result.Skipped:
+			_, err = fmt.Fprintf(w, "- %s: пропущено\n", result.Name)
+		case result.Err != nil:
+			_, err = fmt.Fprintf(w, "✘ %s: %v\n", result.Name, result.Err)
+		default:
+			_, err = fmt.Fprintf(w, "✔ %s\n", result.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
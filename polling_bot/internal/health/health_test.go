@@ -0,0 +1,85 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func step(name string, err error, calls *[]string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		*calls = append(*calls, name)
+		return err
+	}}
+}
+
+// Тест проверяет отчёт самопроверки: успешные шаги, ошибку и пропуск
+// шагов после неё
+func TestRunAndRender(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      map[string]error
+		wantCalls []string
+		wantOK    bool
+		want      string
+	}{
+		{
+			name:      "all ok",
+			wantCalls: []string{"Конфигурация", "Tarantool: ping", "Mattermost: аутентификация"},
+			wantOK:    true,
+			want:      "✔ Конфигурация\n✔ Tarantool: ping\n✔ Mattermost: аутентификация\n",
+		},
+		{
+			name:      "storage down",
+			fail:      map[string]error{"Tarantool: ping": errors.New("connection refused")},
+			wantCalls: []string{"Конфигурация", "Tarantool: ping"},
+			want:      "✔ Конфигурация\n✘ Tarantool: ping: connection refused\n- Mattermost: аутентификация: пропущено\n",
+		},
+		{
+			name:      "bad token",
+			fail:      map[string]error{"Mattermost: аутентификация": errors.New("401 Unauthorized")},
+			wantCalls: []string{"Конфигурация", "Tarantool: ping", "Mattermost: аутентификация"},
+			want:      "✔ Конфигурация\n✔ Tarantool: ping\n✘ Mattermost: аутентификация: 401 Unauthorized\n",
+		},
+		{
+			name:      "bad config",
+			fail:      map[string]error{"Конфигурация": errors.New("не задан BOT_TOKEN")},
+			wantCalls: []string{"Конфигурация"},
+			want:      "✘ Конфигурация: не задан BOT_TOKEN\n- Tarantool: ping: пропущено\n- Mattermost: аутентификация: пропущено\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			var checks []Check
+			for _, name := range []string{"Конфигурация", "Tarantool: ping", "Mattermost: аутентификация"} {
+				checks = append(checks, step(name, tt.fail[name], &calls))
+			}
+
+			results := Run(context.Background(), checks)
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.wantOK, OK(results))
+
+			var out bytes.Buffer
+			require.NoError(t, Render(&out, results))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+// Тест проверяет, что пропущенные шаги отмечаются в отчёте и делают
+// проверку неуспешной
+func TestSkip(t *testing.T) {
+	var calls []string
+	results := Skip([]Check{step("Mattermost: аутентификация", nil, &calls)})
+	assert.Empty(t, calls)
+	assert.False(t, OK(results))
+
+	var out bytes.Buffer
+	require.NoError(t, Render(&out, results))
+	assert.Equal(t, "- Mattermost: аутентификация: пропущено\n", out.String())
+}
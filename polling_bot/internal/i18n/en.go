@@ -0,0 +1,585 @@
+package i18n
+
+var english = map[Key]string{
+	HelpHeader:  "**Poll commands:**",
+	HelpFooter:  "Command details: %s help <command>",
+	HelpUnknown: "No help for command '%s'. Available commands: %s",
+	IntroHeader: "Hi! I'm the poll bot. Main commands:",
+	IntroFooter: "All commands: %s help",
+
+	HelpCreateSummary: `%s create "Question" "Option 1" "Option 2"... - Create a poll`,
+	HelpCreateDetails: `**%[1]s create "Question" "Option 1" "Option 2"...**
+Creates a poll and returns its ID. A question and at least one option are required.
+Example: %[1]s create "Where do we have lunch?" "Pizza" "Sushi"
+An option can start with an emoji and a colon: "🍕:Pizza" or ":pizza::Pizza".
+With the --channel-only flag, voting and results are only available in the poll's channel.
+With the --members-only flag, only members of the poll's channel can vote, even if others get the poll ID.
+With the --quorum N flag, the poll closes itself once N participants have voted.
+With the --exclusive flag, the poll is not created if the channel already has an open one.
+With the --ranked flag, votes rank the options and the winner is decided by instant runoff.
+With the --pin flag, the bot posts the poll as a separate message and pins it in the channel until it ends.
+With the --notify-voters flag, voters get the results in a direct message when the poll closes.
+With the --receipts flag, each voter gets a direct message receipt with the question, their choice and the time.
+With the --no-self-vote flag, the poll creator cannot vote in their own poll.
+With the --anonymous flag, the bot stores votes without voter IDs: neither the creator nor any bot command can show who voted for what.
+With the --expires 3d flag, the poll closes itself after the given lifetime (90m, 12h, 3d); --no-expire turns off the default lifetime where allowed.
+With the --desc "Explanation" flag, the explanation is shown under the question; \n in the question and the description starts a new line.
+With the --tags release,team-a flag, the poll gets tags; the list command finds polls by them.
+With the --allow-abstain flag, participants can vote "Abstain": they count toward the quorum but not as votes, and can vote for an option later.
+With the --max-votes N flag, the poll closes itself after the Nth vote and rejects the rest; abstentions count too.
+With the --weights "@alice=2,@bob=3" flag, these participants' votes weigh more; results show both the votes and the weighted totals.
+With the --voters @alice @bob @carol flag, only the listed participants can vote; the quorum counts against this list, and results show how many votes the poll is still waiting for.
+With the --dry-run flag, no poll is created: the bot checks the command and shows only you the question, options and flags the poll would be created with.
+Without arguments in a direct message to the bot, or with the single --wizard flag, the bot asks for the question, options and flags one at a time; "cancel" quits the wizard.
+Common errors:
+- options must be unique
+- the question is limited to 255 characters, an option to 100
+- at most 5 tags of up to 30 characters: letters, digits, - and _
+- a vote weight is from 1 to 100; ranked polls do not support weights
+- the quorum of a poll with a voter list cannot exceed the list
+- text with spaces must be quoted`,
+	HelpVoteSummary: `%s vote "Poll ID" "Choice" - Vote`,
+	HelpVoteDetails: `**%[1]s vote "Poll ID" "Choice"**
+Records your vote. The option is case-insensitive, and the bot suggests the closest option on a typo.
+Instead of the option text you can give its number from the poll creation message: %[1]s vote <ID> 2
+Example: %[1]s vote 123e4567-e89b-12d3-a456-426614174000 "Pizza"
+In a ranked poll, list options from most to least preferred: %[1]s vote <ID> "Pizza" "Sushi"
+A multi-word option does not need quotes: %[1]s vote <ID> Sushi bar. If in a ranked poll the same words also name options in order, the bot asks for quotes.
+Common errors:
+- you can vote only once; after abstaining you can replace the abstention with a vote once
+- a closed poll does not accept votes`,
+	HelpResultsSummary: `%s results "Poll ID" [--since 1h | --active-only] [--table] [--page 2] - Show results`,
+	HelpResultsDetails: `**%[1]s results "Poll ID" [--since 1h | --active-only] [--table] [--page 2]**
+Shows the number of votes for each option.
+With the --since 1h flag, the results also show how many participants voted in the last hour and how many votes each option got in that time (90m, 12h, 3d).
+With the --table flag, the results are shown as a table: option, votes, share and a bar, with a totals row at the bottom. A ranked poll is still shown by rounds.
+With the --active-only flag, the creator also sees what the results would be without the votes of users deactivated in Mattermost.
+If the poll has more than 25 options, they are shown 25 at a time and the --page 2 flag shows the next ones.
+Example: %[1]s results 123e4567-e89b-12d3-a456-426614174000 --since 1d`,
+	HelpMyVoteSummary: `%s myvote "Poll ID" - Show your vote`,
+	HelpMyVoteDetails: `**%[1]s myvote "Poll ID"**
+Shows what you voted for, in an open or a closed poll. In a ranked poll it lists your whole ranking in order.
+Example: %[1]s myvote 123e4567-e89b-12d3-a456-426614174000`,
+	HelpListSummary: `%s list [--tag tag] [--all] - Show open polls`,
+	HelpListDetails: `**%[1]s list [--tag tag] [--all]**
+Shows the open polls of the channel, or your own open polls in direct messages, at most 20.
+With the --all (or --global) flag, shows open polls of all channels except those restricted to another channel.
+With the --tag flag, only polls with this tag are shown.
+Example: %[1]s list --tag release`,
+	HelpSearchSummary: `%s search "text" - Find open polls by question`,
+	HelpSearchDetails: `**%[1]s search "text"**
+Searches the open polls of the channel (your own polls in direct messages) by question text, ignoring case.
+A poll matches if its question contains the whole text or every word of the query starts a word of the question. The first 10 matches are shown.
+Example: %[1]s search "lunch fri"`,
+	HelpEndSummary: `%s end "Poll ID" | --all [--channel] - Close the poll or all your polls`,
+	HelpEndDetails: `**%[1]s end "Poll ID" | --all [--channel]**
+Closes the poll: results stay available, new votes are rejected.
+With the --all flag, closes all your open polls in every channel, at most 50 at a time, and posts the results of each in its channel.
+With the --all --channel flags, closes all open polls of the channel, whoever created them; only bot administrators can do this.
+Example: %[1]s end 123e4567-e89b-12d3-a456-426614174000
+Common errors:
+- only the creator can close the poll`,
+	HelpDeleteSummary: `%s delete "Poll ID" - Delete the poll`,
+	HelpDeleteDetails: `**%[1]s delete "Poll ID"**
+Deletes the poll with all votes; it cannot be restored.
+Example: %[1]s delete 123e4567-e89b-12d3-a456-426614174000
+Common errors:
+- only the creator can delete the poll`,
+	HelpWinnerSummary: `%s winner "Poll ID" ["Option"] - Pick a random winner`,
+	HelpWinnerDetails: `**%[1]s winner "Poll ID" ["Option"] [--again]**
+Picks a random participant of a closed poll and announces them in the poll's channel.
+With an option, the winner is picked among those who voted for it.
+Example: %[1]s winner 123e4567-e89b-12d3-a456-426614174000 "Pizza"
+Common errors:
+- only the poll creator can pick a winner
+- the poll must be closed
+- picking again requires the --again flag`,
+	HelpCloneSummary: `%s clone "Poll ID" ["Question"] - Copy a poll`,
+	HelpCloneDetails: `**%[1]s clone "Poll ID" ["Question"]**
+Creates a new open poll with the same question, options and flags, without votes.
+You become the creator of the copy; a second argument replaces the question.
+Example: %[1]s clone 123e4567-e89b-12d3-a456-426614174000 "Where do we have lunch on Friday?"
+Common errors:
+- a channel-only poll can only be copied from its channel`,
+	HelpTransferSummary: `%s transfer "Poll ID" @user - Hand a poll over to another user`,
+	HelpTransferDetails: `**%[1]s transfer "Poll ID" @user**
+Makes the user the poll's creator: they can close or delete it and pick a winner. The new owner gets a direct message.
+Example: %[1]s transfer 123e4567-e89b-12d3-a456-426614174000 @ivan
+Common errors:
+- only the creator can transfer the poll
+- a closed poll cannot be transferred
+- a channel-only poll can only go to a member of its channel`,
+	HelpSetSummary: `%s set "Poll ID" [setting value] - Change poll settings`,
+	HelpSetDetails: `**%[1]s set "Poll ID" [setting value]**
+Without a setting shows the poll's current settings; with one changes it and replies with the old and new value.
+Settings: channel-only on|off, quorum N|off, max-votes N|off, expires 90m|12h|3d|off, voters @alice @bob|off; a new deadline counts from now.
+Example: %[1]s set 123e4567-e89b-12d3-a456-426614174000 quorum 10
+Common errors:
+- only the creator can change settings, and only while the poll is open
+- the quorum and the vote limit must exceed the votes already cast
+- ranked voting, weights and abstention cannot change after creation`,
+	HelpStatsSummary: `%s stats "Poll ID" - Show poll views and conversion`,
+	HelpStatsDetails: `**%[1]s stats "Poll ID"**
+Shows how many times the poll results were viewed and what share of viewers voted. Repeat views by the same user within an hour are not counted; anonymous polls count views the same way.
+Example: %[1]s stats 123e4567-e89b-12d3-a456-426614174000
+Common errors:
+- only the poll creator can see the stats
+- views are counted since the bot was updated; earlier views are not restored`,
+	HelpProgressSummary: `%s progress "Poll ID" - Show who has voted and who the poll is still waiting for`,
+	HelpProgressDetails: `**%[1]s progress "Poll ID" [--page N]**
+Shows the creator how many votes a poll with a voter list or a quorum has: "7 of 10 votes". For a poll with a voter list it names who has not voted yet and who already has, 50 voters per page; --page N shows the next ones.
+Example: %[1]s progress 123e4567-e89b-12d3-a456-426614174000
+Common errors:
+- only the poll creator can see the progress
+- an anonymous poll shows only the vote count, without names
+- a poll with no voter list and no quorum has nobody to wait for: the command refuses`,
+	HelpEditSummary: `%s edit "Poll ID" "New question" - Fix the poll question while it has no votes`,
+	HelpEditDetails: `**%[1]s edit "Poll ID" "New question"**
+Fixes a typo in the question without deleting the poll. The new question is checked the same way as on creation; the pinned poll message is updated.
+Example: %[1]s edit 123e4567-e89b-12d3-a456-426614174000 "Where do we have lunch on Friday?"
+Common errors:
+- only the creator can edit the question, and only while the poll is open
+- after the first vote, abstentions included, the question cannot change: participants voted on the old one`,
+	HelpScheduleSummary: `%s schedule create "Cron" "Question" "Option 1"... - Create a poll on a schedule`,
+	HelpScheduleDetails: `**%[1]s schedule create "Cron" "Question" "Option 1"...**
+Creates a schedule: the bot posts the poll in this channel whenever the cron expression fires
+(minute, hour, day of month, month, day of week; in the bot server's time). Flags are the same as for create.
+Example: %[1]s schedule create "0 10 * * 1" "Where do we have lunch?" "Pizza" "Sushi" - every Monday at 10:00
+%[1]s schedule list lists the channel's schedules, %[1]s schedule delete "Schedule ID" deletes one.
+Common errors:
+- the whole cron expression must be quoted
+- only the creator can delete a schedule
+- polls missed while the bot was down are not created afterwards`,
+	HelpTemplateSummary: `%s template save "Name" "Question" "Option 1"... - Save a poll template`,
+	HelpTemplateDetails: `**%[1]s template save "Name" "Question" "Option 1"... [--channel]**
+Saves the question, options and flags as a template to create polls from with create-from. Flags are the same as for create.
+The template is personal; with --channel every member of the channel can use it.
+Example: %[1]s template save standup "How are you?" "Fine" "Blocked" --tags team-a
+%[1]s template list lists your and the channel's templates, %[1]s template delete "Name" [--channel] deletes one.
+Common errors:
+- a template name is up to 30 characters: letters, digits, - and _
+- the name must be unique among your templates or the channel's templates
+- only the creator can delete a channel template`,
+	HelpCreateFromSummary: `%s create-from "Name" - Create a poll from a template`,
+	HelpCreateFromDetails: `**%[1]s create-from "Name"**
+Creates a poll from your template or, if you have none with that name, from the channel's template. The poll does not depend on the template: changing or deleting the template leaves it alone.
+Example: %[1]s create-from standup`,
+	HelpDigestSummary: `%s digest on|off - Daily digest of the channel's open polls`,
+	HelpDigestDetails: `**%[1]s digest on|off**
+Turns the daily digest on or off in this channel: once a day the bot posts the channel's open polls with their vote counts and the time left until they close.
+No digest is posted when there are no open polls.
+Example: %[1]s digest on
+Common errors:
+- the digest can only be turned on in a channel, not in direct messages`,
+	HelpAuditSummary: `%s audit "Poll ID" [N] - Show the poll's event log (admins only)`,
+	HelpAuditDetails: `**%[1]s audit "Poll ID" [N]**
+Shows the last N poll events (20 by default, at most 100): who created, voted in, closed, transferred or deleted the poll and when.
+The log is kept after the poll is deleted.
+Example: %[1]s audit 123e4567-e89b-12d3-a456-426614174000 50
+Common errors:
+- only bot administrators (BOT_ADMINS) can use this command`,
+	HelpUsageSummary: `%s usage [N] - Show how often bot commands were run per day (admins only)`,
+	HelpUsageDetails: `**%[1]s usage [N]**
+Shows a table for the last N days (7 by default, at most 90): how many commands were run each day and which ones most often. Days are counted in UTC.
+Counters are written to storage in batches every BOT_USAGE_FLUSH_INTERVAL (a minute by default), so the latest commands show up with a delay.
+Example: %[1]s usage 30
+Common errors:
+- only bot administrators (BOT_ADMINS) can use this command`,
+	HelpFeatureSummary: `%s feature "Poll ID" - List the poll first (admins only)`,
+	HelpFeatureDetails: `**%[1]s feature "Poll ID"**
+Marks the poll with ⭐: every %[1]s list shows it first, in any channel where it can be seen. A closed poll can be featured too: it stays in the list, marked as closed, until it is unfeatured.
+Example: %[1]s feature 123e4567-e89b-12d3-a456-426614174000
+Common errors:
+- only bot administrators (BOT_ADMINS) can use this command`,
+	HelpUnfeatureSummary: `%s unfeature "Poll ID" - Remove the ⭐ mark from a poll (admins only)`,
+	HelpUnfeatureDetails: `**%[1]s unfeature "Poll ID"**
+Removes the mark set by feature: the poll is listed like any other again, and a closed one disappears from the list.
+Example: %[1]s unfeature 123e4567-e89b-12d3-a456-426614174000
+Common errors:
+- only bot administrators (BOT_ADMINS) can use this command`,
+	HelpPingSummary: `%s ping - Check that the bot and its storage respond`,
+	HelpPingDetails: `**%[1]s ping**
+Replies pong with the storage response time, the bot's uptime and its version. If the storage does not respond, the bot still replies and shows it as unavailable.
+Example: %[1]s ping`,
+	HelpVersionSummary: `%s version - Show the bot's build and enabled features`,
+	HelpVersionDetails: `**%[1]s version**
+Shows the bot's version, commit and build date, uptime, storage and the features enabled in its settings. Useful when reporting a problem.
+Example: %[1]s version`,
+	HelpHelpSummary: `%s help [command] - Show this help`,
+	HelpHelpDetails: `**%[1]s help [command]**
+Without an argument lists the commands, with a command name shows its details.
+Example: %[1]s help vote`,
+
+	CreateUsage:         "Not enough arguments. A question and at least one option are required. Usage: %s create \"Question\" \"Option 1\"...",
+	VoteUsage:           "Usage: %s vote \"Poll ID\" \"Your choice\"",
+	ResultsUsage:        "Usage: %s results \"Poll ID\" [--since 1h | --active-only] [--table] [--page 2]",
+	MyVoteUsage:         "Usage: %s myvote \"Poll ID\"",
+	ListUsage:           "Usage: %s list [--tag tag] [--all]",
+	SearchUsage:         "Usage: %s search \"question text\"",
+	EndUsage:            "Usage: %s end \"Poll ID\" | --all [--channel]",
+	DeleteUsage:         "Usage: %s delete \"Poll ID\"",
+	WinnerUsage:         "Usage: %s winner \"Poll ID\" [\"Option\"] [--again]",
+	ScheduleUsage:       "Usage: %[1]s schedule create \"0 10 * * 1\" \"Question\" \"Option 1\"..., %[1]s schedule list or %[1]s schedule delete \"Schedule ID\"",
+	TemplateUsage:       "Usage: %[1]s template save \"Name\" \"Question\" \"Option 1\"... [--channel], %[1]s template list or %[1]s template delete \"Name\" [--channel]",
+	CreateFromUsage:     "Usage: %s create-from \"Template name\"",
+	DigestUsage:         "Usage: %[1]s digest on or %[1]s digest off",
+	TransferUsage:       "Usage: %s transfer \"Poll ID\" @user",
+	SetUsage:            "Usage: %s set \"Poll ID\" [setting value]",
+	StatsUsage:          "Usage: %s stats \"Poll ID\"",
+	ProgressUsage:       "Usage: %s progress \"Poll ID\" [--page N]",
+	EditUsage:           "Usage: %s edit \"Poll ID\" \"New question\"",
+	CloneUsage:          "Usage: %s clone \"Poll ID\" [\"Question\"]",
+	AuditUsage:          "Usage: %s audit \"Poll ID\" [number of events]",
+	UsageUsage:          "Usage: %s usage [number of days]",
+	FeatureUsage:        "Usage: %s feature \"Poll ID\"",
+	UnfeatureUsage:      "Usage: %s unfeature \"Poll ID\"",
+	PingUsage:           "Usage: %s ping",
+	VersionUsage:        "Usage: %s version",
+	AdminOnly:           "this command is for administrators only",
+	UnknownCommand:      "Unknown command. Type %s help for help",
+	CommandRateLimited:  "too many commands in a row, try again in %d s",
+	UnclosedQuote:       "unclosed quote in the command. Usage: %s",
+	TrailingEscape:      "unclosed quote in the command: nothing follows \\. Usage: %s",
+	PreviewUnsupported:  "poll preview is not supported",
+	StatsUnsupported:    "poll stats are not supported",
+	ProgressUnsupported: "poll progress is not supported",
+	EditUnsupported:     "editing the question is not supported",
+	CommandFailed:       "Command failed: %s",
+	EditedReply:         "_Reply to an edited message_\n%s",
+	CreatingPoll:        "_Creating the poll…_",
+	UnexpectedError:     "internal error",
+
+	ResultsCardVotes:     "%d (%d%%)",
+	ResultsCardWeighted:  "%d (%d%%), weighted: %d",
+	ResultsCardWinner:    "Winner",
+	ResultsCardAbstained: "Abstained",
+	ResultsCardFooter:    "Poll %s · created by @%s",
+
+	NoOptions:            "at least one option is required",
+	QuestionTooLong:      "the question is too long",
+	DescriptionTooLong:   "the description is longer than %d characters",
+	OptionTooLong:        "an option is too long",
+	OptionBlank:          "an option cannot be empty",
+	DuplicateOptions:     "all poll options must be unique",
+	MentionInPoll:        "the question and options cannot mention %s: it would notify everyone on every results view",
+	LinkInPoll:           "links are not allowed in the question and options: %s",
+	ControlCharInPoll:    "“%s” contains control characters",
+	InvisibleInPoll:      "“%s” contains invisible characters that make options look identical",
+	PollCreated:          "Poll created! ID: `%s`\nQuestion: %s\n%sOptions:\n",
+	PollCreatedOption:    "%d. %s\n",
+	PollPreview:          "**Preview: the poll is not created**\nQuestion: %s\n%sOptions:\n",
+	PreviewPin:           "The poll will be posted as a separate message and pinned in the channel\n",
+	PreviewExclusive:     "The poll will not be created if the channel already has an open one\n",
+	PreviewFooter:        "To create the poll, repeat the command without --dry-run",
+	CreatedChannelOnly:   "Voting and results are only available in this channel\n",
+	CreatedMembersOnly:   "Only members of this channel can vote\n",
+	CreatedVoters:        "Only these users can vote: %s\n",
+	CreatedQuorum:        "The poll closes once %d participants have voted\n",
+	InvalidPollID:        "invalid poll ID format",
+	PollClosed:           "the poll is closed",
+	AlreadyVoted:         "you have already voted in this poll",
+	SelfVoteForbidden:    "the poll creator cannot vote in this poll",
+	ChannelOnly:          "this poll is only available in its own channel",
+	MembersOnlyRejected:  "only members of the poll's channel can vote in this poll",
+	VoterNotAllowed:      "you are not on the voter list of this poll",
+	MembersUnavailable:   "could not check that you are a member of the poll's channel, try again later",
+	MembersOnlyNoChannel: "the --members-only flag only works in a channel, not in direct messages",
+	QuorumInvalid:        "the quorum must be a whole number of at least 1",
+	QuorumReached:        "Quorum reached, poll %s is closed\n",
+	ChannelHasPoll:       "this channel already has an open poll `%s`: %s. Close it before creating a new one",
+	ChannelPollLimit:     "this channel has reached its limit of %d polls per day. The next poll can be created after %s",
+	OptionNotFound:       "option '%s' does not exist",
+	OptionSuggestion:     "option '%s' not found, did you mean '%s'?",
+	VoteRecorded:         "Your vote in poll %s has been recorded: %s",
+	SelfVoteNote:         "_This is your own poll: your vote counts the same as everyone else's._",
+	VoteReceipt:          "**Your vote has been counted**\nPoll: `%s`\nQuestion: %s\nChoice: %s\nTime: %s",
+	ResultsHeader:        "**Results of poll %s**\n%s\n%s",
+	ResultsLine:          "- %s: %d votes\n",
+	ResultsTableOption:   "Option",
+	ResultsTableVotes:    "Votes",
+	ResultsTableWeighted: "Weighted",
+	ResultsTableBar:      "Bar",
+	ResultsTableTotal:    "Total",
+	MyVoteNone:           "You have not voted in poll %s yet",
+	MyVoteChoice:         "Your vote in poll %s: %s",
+	MyVoteRankedHeader:   "Your ranking in poll %s:\n",
+	MyVoteRankedLine:     "%d. %s\n",
+	MyVoteUnknown:        "You voted in poll %s, but the choice was not stored: the vote predates a bot update",
+	SingleChoiceOnly:     "this poll accepts only one option",
+	DuplicateRanking:     "option '%s' appears in the ballot twice",
+	AmbiguousVote:        "unclear whether the vote is for option '%s' or for options %s in order; put a multi-word option in quotes",
+	CreatedRanked:        "Ranked poll: list options from most to least preferred, a partial ranking is fine\n",
+	PollPinned:           "Poll %s is published and pinned in the channel",
+	PinFailed:            "Poll %s is published but could not be pinned: check that the bot has permissions in the channel",
+	PinUnavailable:       "The poll is not pinned: pin this message manually\n",
+	CreatedNotify:        "When the poll closes, voters will get the results in a direct message\n",
+	CreatedReceipts:      "Every voter will get a receipt for their vote in a direct message\n",
+	CreatedNoSelfVote:    "The poll creator cannot vote in it\n",
+	CreatedAnonymous:     "The poll is anonymous: the bot stores votes without voter IDs\n",
+	NotifyResults:        "Poll %s you voted in has closed.\n",
+	NotifySummary:        "Results of poll %s were sent to voters: %d of %d",
+	CreatedExpires:       "The poll closes automatically at %s\n",
+	ExpiresInvalid:       "the poll lifetime is given as 90m, 12h or 3d and must be at least a minute",
+	SinceInvalid:         "the --since period is given as 90m, 12h or 3d and must be at least a minute",
+	SinceUnsupported:     "results for a --since period are not available here",
+	ActiveUnsupported:    "results without deactivated users (--active-only) are not available here",
+	DescriptionMissing:   "--desc needs the description text",
+	TagsMissing:          "--tags needs comma-separated tags",
+	TagsTooMany:          "a poll can have at most %d tags",
+	TagTooLong:           "tag '%s' is longer than %d characters",
+	TagInvalid:           "tag '%s' may contain only letters, digits, - and _",
+	CreatedTags:          "Tags: %s\n",
+	ResultsTags:          "Tags: %s\n",
+	ListHeader:           "**Open polls**\n",
+	ListHeaderTag:        "**Open polls tagged %s**\n",
+	ListLine:             "- `%s` %s, votes: %d%s\n",
+	ListFeatured:         "⭐ %s",
+	ListFeaturedClosed:   "⭐ %s _(closed)_",
+	ListEmpty:            "There are no open polls",
+	ListEmptyTag:         "There are no open polls tagged %s",
+	ListMore:             "Showing the first %d of %d polls, narrow the list with --tag\n",
+	ListScopeChannel:     "_Only polls of this channel, use --all for polls of all channels_\n",
+	ListScopeMine:        "_Your own polls in direct messages, use --all for polls of all channels_\n",
+	SearchQueryEmpty:     "enter the text to search poll questions for",
+	SearchHeader:         "**Polls matching “%s”**\n",
+	SearchNoMatches:      "No open polls match “%s”",
+	SearchMore:           "Showing the first %d matches, refine the query\n",
+	WeightsInvalid:       "weights are given as --weights \"@alice=2,@bob=3\", each participant once",
+	WeightInvalid:        "the weight of %s must be from 1 to %d",
+	WeightsRanked:        "vote weights are not supported in a ranked poll",
+	AnonymousWeights:     "vote weights are not supported in an anonymous poll: a weight identifies the voter",
+	AnonymousNotify:      "an anonymous poll cannot send the results to voters: the bot does not store who voted",
+	AnonymousVoters:      "an anonymous poll cannot have a voter list: the list stores voter IDs",
+	AnonymousUnavailable: "anonymous polls are not configured: BOT_ANONYMOUS_SECRET is not set",
+	WeightUserNotFound:   "user %s from --weights not found",
+	WeightsUnavailable:   "vote weights are not configured: the bot cannot look up users",
+	WeightsCreateOnly:    "vote weights can only be set with the create command",
+	VotersNotFound:       "users from --voters not found: %s",
+	VotersUnavailable:    "voter lists are not configured: the bot cannot look up users",
+	VotersMissing:        "list the voters after --voters: --voters @alice @bob",
+	VotersQuorum:         "quorum %d is larger than the voter list (%d): the poll could never reach it",
+	VotersCreateOnly:     "the voter list can only be set by the create and set commands",
+	CreatedWeights:       "Vote weights: %s, everyone else has weight 1\n",
+	ResultsLineWeighted:  "- %s: %d votes, weighted: %d\n",
+	AbstainOption:        "Abstain",
+	AbstainReserved:      "option '%s' is reserved for abstaining",
+	AbstainExclusive:     "abstaining cannot be combined with other options",
+	VoteAbstained:        "You abstained in poll %s. If you change your mind, vote for an option and it will replace the abstention",
+	CreatedAbstain:       "You can abstain with «%s»: abstentions count toward the quorum but not as votes\n",
+	ResultsAbstained:     "Abstained: %d\n",
+	ResultsWaiting:       "%d of %d have voted, waiting for %d more\n",
+	ResultsPage:          "Showing options %d-%d of %d, use --page %d\n",
+	ResultsPageLast:      "Showing options %d-%d of %d\n",
+	PageInvalid:          "the --page number must be 1 or greater",
+	PageOutOfRange:       "the poll results have %d page(s) of options",
+	RecentHeader:         "\n**Changes since %s**\n",
+	RecentHeaderWhole:    "\n**Changes since the poll was created (%s)**: the period is longer than the poll's age\n",
+	RecentVoters:         "New voters: %d\n",
+	RecentLine:           "- %s: +%d\n",
+	RecentAbstained:      "Abstained: +%d\n",
+	ActiveHeader:         "\n**Without deactivated users**: votes excluded - %d\n",
+	ActiveNone:           "\nNo deactivated users among the voters\n",
+	ActiveUnverified:     "\n⚠️ Could not check the voters in Mattermost, all votes are shown\n",
+	MyVoteAbstained:      "You abstained in poll %s",
+	AbstainCreateOnly:    "the --allow-abstain flag only works with the create command",
+	MaxVotesInvalid:      "the vote limit must be a whole number of at least 1",
+	MaxVotesReached:      "the poll has already reached its vote limit",
+	MaxVotesClosed:       "Poll %s reached its vote limit and is closed\n",
+	MaxVotesCreateOnly:   "the --max-votes flag only works with the create command",
+	ReceiptsCreateOnly:   "the --receipts flag only works with the create command",
+	NoSelfVoteCreateOnly: "the --no-self-vote flag only works with the create command",
+	AnonymousCreateOnly:  "the --anonymous flag only works with the create command",
+	MembersCreateOnly:    "the --members-only flag only works with the create command",
+	CreatedMaxVotes:      "The poll closes after %d votes\n",
+	ExpiresConflict:      "--expires and --no-expire cannot be used together",
+	NoExpireForbidden:    "polls without a deadline are not allowed: set one with --expires",
+	ExpiryWarning:        "Poll `%s` \"%s\" closes automatically at %s. Vote while you can!",
+	PollExpired:          "Poll %s has expired, voting is over\n",
+	RankedRound:          "Round %d: %s\n",
+	RankedEliminated:     "Eliminated: %s\n",
+	RankedWinner:         "**Winner: %s**\n",
+	RankedNoWinner:       "No winner: there are no ballots\n",
+	OnlyCreatorCanEnd:    "only the creator can close the poll",
+	PollEnded:            "Poll %s is closed",
+	PollAlreadyClosed:    "poll %s is already closed",
+	EndAllNone:           "There are no open polls to close",
+	EndAllSummary:        "Polls closed: %d, errors: %d\n",
+	EndAllFailure:        "- %s - %s\n",
+	EndAllTruncated:      "Only the first %d of %d polls were processed: repeat the command to close the rest\n",
+	EndChannelOnly:       "the --channel flag only works in a channel, not in direct messages",
+	OnlyCreatorDelete:    "only the creator can delete the poll",
+	PollDeleted:          "Poll %s has been deleted",
+	OnlyCreatorWinner:    "only the creator can pick a winner",
+	OnlyCreatorActive:    "only the creator can exclude votes of deactivated users",
+	WinnerPollOpen:       "a winner can only be picked in a closed poll",
+	WinnerChosen:         "a winner has already been picked: %s. Add --again to pick again",
+	WinnerNoVoters:       "the poll has no matching participants",
+	WinnerAnonymous:      "cannot pick a winner in an anonymous poll: the bot does not store who voted",
+	ActiveAnonymous:      "deactivated users can't be excluded in an anonymous poll: the bot doesn't store who voted",
+	WinnerAnnounce:       "The winner of poll %s is %s 🎉",
+	ClonedFrom:           "Copy of poll `%s`\n",
+	OnlyCreatorTransfer:  "only the creator can transfer the poll",
+	TransferPollClosed:   "a closed poll cannot be transferred",
+	TransferToSelf:       "you already own this poll",
+	TransferToBot:        "a poll cannot be transferred to a bot",
+	TransferUserNotFound: "user %s not found",
+	TransferNotMember:    "%s is not a member of the poll's channel",
+	TransferUnavailable:  "poll transfer is not configured",
+	PollTransferred:      "Poll %s now belongs to %s",
+	PollFeatured:         "Poll %s is featured ⭐ and listed first",
+	PollFeaturedClosed:   "Poll %s is closed but featured ⭐: it is listed first and marked as closed",
+	PollUnfeatured:       "Poll %s is no longer featured",
+	TransferNotice:       "%s handed poll `%s` over to you: %s\nYou can now close it with the end command",
+	OnlyCreatorSettings:  "only the creator can change the poll settings",
+	OnlyCreatorStats:     "only the creator can view the poll stats",
+	PollStats:            "Poll %s: views: %d, voted: %d (conversion %d%%)",
+	OnlyCreatorProgress:  "only the creator can view who has voted",
+	ProgressUnrestricted: "poll %s has no voter list and no quorum: nobody to wait for",
+	PollProgress:         "Poll %s: %d of %d votes\n",
+	ProgressWaiting:      "Not voted yet (%d): %s\n",
+	ProgressVoted:        "Voted (%d): %s\n",
+	ProgressPage:         "Showing voters %d-%d of %d, use --page %d\n",
+	ProgressPageLast:     "Showing voters %d-%d of %d\n",
+	ProgressOutOfRange:   "the poll voter list has %d page(s)",
+	OnlyCreatorEdit:      "only the creator can edit the poll question",
+	EditPollClosed:       "the question of a closed poll cannot be edited",
+	EditHasVotes:         "poll %s already has votes (%d), so its question cannot be edited: they were cast for the old question. End the poll and create a new one",
+	QuestionEdited:       "Poll %s question updated: %s",
+	SettingsPollClosed:   "a closed poll's settings cannot be changed",
+	SettingUnknown:       "unknown setting '%s'. Available settings: %s",
+	SettingValueInvalid:  "invalid value for %s: expected %s",
+	SettingHintSwitch:    "on or off",
+	SettingHintNumber:    "a whole number of at least 1 or off",
+	SettingHintExpires:   "a lifetime like 90m, 12h or 3d of at least a minute, or off",
+	SettingQuorumReached: "the poll already has %d votes: the quorum must be higher",
+	SettingMaxVotesMet:   "the poll already has %d votes: the limit must be higher",
+	SettingsHeader:       "**Settings of poll %s**\n",
+	SettingsLine:         "- %s: %s\n",
+	SettingsFixedLine:    "- %s: %s (set at creation)\n",
+	SettingOn:            "on",
+	SettingOff:           "off",
+	SettingNone:          "none",
+	SettingChanged:       "Setting %s of poll %s: %s → %s",
+	SettingUnchanged:     "Setting %s of poll %s is already %s",
+	SettingCreateOnly:    "setting %s can only be set when the poll is created",
+	SettingVotersChanged: "Only these users can vote in poll %s: %s",
+	SettingVotersCleared: "Anyone can vote in poll %s",
+	PollNotFound:         "poll not found",
+	Pong:                 "pong — uptime: %s, version: %s",
+	PongStorage:          "pong — %s: %s, uptime: %s, version: %s",
+	PongStorageDown:      "unavailable",
+	VersionHeader:        "**Poll bot %s**\n",
+	VersionBuild:         "Commit: %s, built: %s\n",
+	VersionUptime:        "Uptime: %s\n",
+	VersionStorage:       "Storage: %s\n",
+	VersionFeatures:      "Features:\n",
+	ServiceUnavailable:   "the service is temporarily unavailable, please try again later",
+
+	CronFieldCount:      "a cron expression needs 5 fields: minute, hour, day of month, month, day of week",
+	CronFieldInvalid:    "invalid cron field '%s': allowed values are %d to %d",
+	CronNeverFires:      "schedule '%s' never fires",
+	ScheduleNoChannel:   "a schedule can only be created in a channel",
+	ScheduleCreated:     "Schedule `%s` created: %s\nQuestion: %s\nNext poll: %s",
+	ScheduleListHeader:  "**Channel schedules**\n",
+	ScheduleListLine:    "- `%s` %s: %s\n",
+	ScheduleListEmpty:   "This channel has no schedules",
+	OnlyCreatorSchedule: "only the creator can delete the schedule",
+	ScheduleDeleted:     "Schedule %s has been deleted",
+	ScheduleNotFound:    "schedule not found",
+	SchedulesDisabled:   "schedules are not configured",
+	ScheduledPoll:       "_Scheduled poll `%s`_\n%s",
+	ScheduleUnreachable: "Schedule `%s` (“%s”) has been stopped: the bot can no longer post in its channel. Add the bot back to the channel, create the schedule again and delete this one",
+
+	TemplateNameInvalid:  "invalid template name '%s': use letters, digits, - and _",
+	TemplateNameTooLong:  "a template name is longer than %d characters",
+	TemplateNoChannel:    "a channel template can only be saved or deleted in a channel",
+	TemplateExists:       "template %s already exists",
+	TemplateNotFound:     "template %s not found",
+	TemplateSaved:        "Template `%s` saved: %s",
+	TemplateSavedChannel: "Channel template `%s` saved: %s",
+	TemplateListHeader:   "**Poll templates**\n",
+	TemplateListLine:     "- `%s` %s%s\n",
+	TemplateChannelMark:  " (channel)",
+	TemplateListEmpty:    "There are no templates yet",
+	OnlyCreatorTemplate:  "only the creator can delete the template",
+	TemplateDeleted:      "Template %s has been deleted",
+	TemplatesDisabled:    "templates are not configured",
+	CreatedFromTemplate:  "Poll from template `%s`\n",
+
+	DigestHeader:      "**Open polls in this channel**\n",
+	DigestLine:        "- `%s` %s, votes: %d\n",
+	DigestLineExpires: "- `%s` %s, votes: %d, closes in: %s\n",
+	DigestMore:        "And %d more polls\n",
+	DigestEnabled:     "The open polls digest is on: the bot will post it in this channel every day at %02d:00",
+	DigestDisabled:    "The open polls digest is off in this channel",
+	DigestNoChannel:   "the polls digest can only be turned on in a channel",
+	DigestsDisabled:   "poll digests are not configured",
+
+	UsageHeader:        "**Bot commands over %d days (UTC)**\n",
+	UsageTableDay:      "Day",
+	UsageTableCommands: "Commands",
+	UsageTableTop:      "Most used",
+	UsageEmpty:         "No commands were run in the last %d days",
+	UsageDisabled:      "command usage is not configured",
+
+	WizardQuestion:       "Let's create a poll step by step. Write the question.\nTo quit, send \"cancel\".",
+	WizardEmptyAnswer:    "Please send some text. To quit, send \"cancel\".",
+	WizardFirstOption:    "Now the options, one per message. Send the first option.",
+	WizardNextOption:     "Option %d added. Send the next one, or \"done\" if that's all.",
+	WizardNeedOption:     "At least one option is required. Send it as a separate message.",
+	WizardOptionFlag:     "An option cannot start with --: that is how flags are written. You can add flags after the options.",
+	WizardTooManyOptions: "No more than %d options can be added. Send \"done\".",
+	WizardFlags:          "Options: %d. Send the poll flags, for example --anonymous --expires 2h (all flags are described in the create help), or \"skip\" to create the poll without them.",
+	WizardFlagsInvalid:   "Could not parse the flags: they start with --, and text with spaces must be quoted. Send the flags again or \"skip\".",
+	WizardCancelled:      "Poll creation cancelled.",
+	WizardExpired:        "The poll creation wizard was closed after waiting too long for an answer. To start over, send the create command again.",
+	WizardFailed:         "The poll was not created: %s\nFix the flags and send them again, \"skip\" to go without flags, or \"cancel\" to quit.",
+
+	AuditHeader:       "**Event log of poll %s**\n",
+	AuditLine:         "- `%s` %s %s\n",
+	AuditEmpty:        "The event log of poll %s is empty",
+	AuditAnonymous:    "the event log of an anonymous poll is not available",
+	AuditUnavailable:  "the event log is not configured",
+	AuditCreated:      "created the poll: %s",
+	AuditCloned:       "created the poll as a copy of %s",
+	AuditVoted:        "voted: %s",
+	AuditQuorumClosed: "voted: %s, the poll closed on quorum",
+	AuditMaxVotes:     "voted: %s, the poll closed at its vote limit",
+	AuditEnded:        "closed the poll",
+	AuditDeleted:      "deleted the poll: %s",
+	AuditWinner:       "picked the winner %s",
+	AuditTransferred:  "transferred the poll to %s",
+	AuditSetting:      "changed setting %s",
+	AuditEdited:       "edited the question: %s",
+	AuditExpired:      "the poll expired and was closed",
+	AuditDiscarded:    "the poll was deleted: the bot could not announce it",
+	AuditFeatured:     "featured the poll",
+	AuditUnfeatured:   "unfeatured the poll",
+	AuditUnknown:      "%s: %s",
+
+	OpSavePoll:       "failed to save the poll",
+	OpSaveVote:       "failed to save the vote",
+	OpGetPoll:        "failed to load the poll",
+	OpEndPoll:        "failed to close the poll",
+	OpDeletePoll:     "failed to delete the poll",
+	OpSaveWinner:     "failed to save the winner",
+	OpListPolls:      "failed to list polls",
+	OpTransferPoll:   "failed to transfer the poll",
+	OpUpdateSettings: "failed to change the poll settings",
+	OpEditQuestion:   "failed to edit the question",
+	OpFindUser:       "failed to look up the user",
+	OpSaveSchedule:   "failed to save the schedule",
+	OpGetSchedule:    "failed to load the schedule",
+	OpDeleteSchedule: "failed to delete the schedule",
+	OpListSchedules:  "failed to list schedules",
+	OpListAudit:      "failed to read the event log",
+	OpGetVote:        "failed to read the vote",
+	OpListVotes:      "failed to read the votes",
+	OpSaveTemplate:   "failed to save the template",
+	OpGetTemplate:    "failed to load the template",
+	OpDeleteTemplate: "failed to delete the template",
+	OpListTemplates:  "failed to list templates",
+	OpSaveDigest:     "failed to save the digest setting",
+	OpListUsage:      "failed to read command usage",
+	OpFeaturePoll:    "failed to feature the poll",
+}
@@ -0,0 +1,155 @@
+// Package i18n содержит каталоги пользовательских сообщений бота
+// и Localizer, который выбирает каталог по языку.
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Lang string
+
+const (
+	Russian Lang = "ru"
+	English Lang = "en"
+)
+
+// DefaultLang используется, когда язык не задан или не поддерживается.
+const DefaultLang = Russian
+
+var catalogs = map[Lang]map[Key]string{
+	Russian: russian,
+	English: english,
+}
+
+// ParseLang сопоставляет код языка или локаль Mattermost ("en", "pt-br",
+// "ru_RU") с поддерживаемым каталогом.
+func ParseLang(locale string) (Lang, bool) {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	lang := Lang(locale)
+	_, ok := catalogs[lang]
+	return lang, ok
+}
+
+type Localizer struct {
+	lang     Lang
+	messages map[Key]string
+}
+
+// New возвращает Localizer для языка; неизвестный язык заменяется на DefaultLang.
+func New(lang string) *Localizer {
+	parsed, ok := ParseLang(lang)
+	if !ok {
+		parsed = DefaultLang
+	}
+	return &Localizer{lang: parsed, messages: catalogs[parsed]}
+}
+
+// Default - Localizer языка по умолчанию.
+func Default() *Localizer {
+	return New(string(DefaultLang))
+}
+
+func (l *Localizer) Lang() Lang {
+	if l == nil {
+		return DefaultLang
+	}
+	return l.lang
+}
+
+// Message - аргумент сообщения, который переводится тем же Localizer,
+// что и само сообщение.
+type Message interface {
+	Localize(l *Localizer) string
+}
+
+// T возвращает сообщение по ключу, подставляя аргументы через fmt.Sprintf.
+// Аргументы Message переводятся на язык сообщения. Nil-Localizer работает
+// как Default().
+func (l *Localizer) T(key Key, args ...interface{}) string {
+	msg, ok := "", false
+	if l != nil {
+		msg, ok = l.messages[key]
+	}
+	if !ok {
+		msg, ok = catalogs[DefaultLang][key]
+	}
+	if !ok {
+		return string(key)
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	localized := make([]interface{}, len(args))
+	for i, arg := range args {
+		if m, ok := arg.(Message); ok {
+			arg = m.Localize(l)
+		}
+		localized[i] = arg
+	}
+	return fmt.Sprintf(msg, localized...)
+}
+
+// Error переводит ошибку для ответа пользователю. Причина, обёрнутая
+// в *Error, не показывается: она предназначена для логов.
+func (l *Localizer) Error(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return l.T(e.Key, e.Args...)
+	}
+	return l.T(UnexpectedError)
+}
+
+type ctxKey struct{}
+
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// LocalizerFrom возвращает Localizer, положенный в контекст WithLocalizer.
+func LocalizerFrom(ctx context.Context) (*Localizer, bool) {
+	l, ok := ctx.Value(ctxKey{}).(*Localizer)
+	return l, ok && l != nil
+}
+
+// FromContext возвращает Localizer запроса или Default(), если он не задан.
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := LocalizerFrom(ctx); ok {
+		return l
+	}
+	return Default()
+}
+
+// Error - ошибка с текстом из каталога. Error() возвращает текст на языке
+// по умолчанию вместе с причиной, для пользователя её переводит Localizer.Error.
+type Error struct {
+	Key  Key
+	Args []interface{}
+	Err  error
+}
+
+func NewError(key Key, args ...interface{}) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+// Wrap оборачивает причину err описанием операции key.
+func Wrap(err error, key Key) *Error {
+	return &Error{Key: key, Err: err}
+}
+
+func (e *Error) Error() string {
+	msg := Default().T(e.Key, e.Args...)
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,133 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// declaredKeys собирает значения всех констант из keys.go, чтобы новый
+// ключ нельзя было забыть добавить в каталог.
+func declaredKeys(t *testing.T) []Key {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "keys.go", nil, 0)
+	require.NoError(t, err)
+
+	var keys []Key
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, value := range spec.(*ast.ValueSpec).Values {
+				lit, ok := value.(*ast.BasicLit)
+				require.True(t, ok, "значение ключа должно быть строковым литералом")
+				keys = append(keys, Key(lit.Value[1:len(lit.Value)-1]))
+			}
+		}
+	}
+	return keys
+}
+
+var verbRe = regexp.MustCompile(`%(\[[0-9]+\])?[-+# 0]*[0-9]*[a-zA-Z%]`)
+
+// Тест проверяет, что в каждом каталоге есть все ключи и нет лишних,
+// а глаголы форматирования совпадают с русским каталогом
+func TestCatalogsComplete(t *testing.T) {
+	keys := declaredKeys(t)
+	require.NotEmpty(t, keys)
+
+	for lang, catalog := range catalogs {
+		t.Run(string(lang), func(t *testing.T) {
+			for _, key := range keys {
+				msg, ok := catalog[key]
+				if assert.True(t, ok, "нет перевода для %s", key) {
+					assert.NotEmpty(t, msg, key)
+					assert.Equal(t, verbRe.FindAllString(russian[key], -1), verbRe.FindAllString(msg, -1),
+						"аргументы %s не совпадают с русским каталогом", key)
+				}
+			}
+			assert.Len(t, catalog, len(keys), "в каталоге есть ключи, не объявленные в keys.go")
+		})
+	}
+}
+
+// Тест проверяет разбор языков и локалей Mattermost
+func TestParseLang(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   Lang
+		ok     bool
+	}{
+		{"ru", Russian, true},
+		{"EN", English, true},
+		{"en-US", English, true},
+		{"ru_RU", Russian, true},
+		{" en ", English, true},
+		{"pt-br", "pt", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			got, ok := ParseLang(tt.locale)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.Equal(t, DefaultLang, New("fr").Lang())
+}
+
+// Тест проверяет подстановку аргументов и запасные варианты
+func TestLocalizer_T(t *testing.T) {
+	en := New("en")
+	assert.Equal(t, "Poll p1 is closed", en.T(PollEnded, "p1"))
+	assert.Equal(t, "Голосование p1 окончено", New("ru").T(PollEnded, "p1"))
+
+	var nilLocalizer *Localizer
+	assert.Equal(t, russian[PollNotFound], nilLocalizer.T(PollNotFound))
+	assert.Equal(t, "no.such.key", en.T("no.such.key"))
+}
+
+// langName - аргумент-Message для теста: название языка сообщения
+type langName struct{}
+
+func (langName) Localize(l *Localizer) string { return string(l.Lang()) }
+
+// Тест проверяет, что аргументы Message переводятся на язык сообщения
+func TestLocalizer_TMessage(t *testing.T) {
+	assert.Equal(t, "Command failed: en", New("en").T(CommandFailed, langName{}))
+	assert.Equal(t, "Ошибка при выполнении команды: ru", NewError(CommandFailed, langName{}).Error())
+}
+
+// Тест проверяет перевод ошибок для пользователя и текст для логов
+func TestLocalizer_Error(t *testing.T) {
+	en := New("en")
+	cause := errors.New("db error")
+
+	wrapped := fmt.Errorf("vote: %w", Wrap(cause, OpSaveVote))
+	assert.Equal(t, "failed to save the vote", en.Error(wrapped))
+	assert.Equal(t, "ошибка сохранения голоса: db error", Wrap(cause, OpSaveVote).Error())
+	assert.ErrorIs(t, wrapped, cause)
+
+	assert.Equal(t, "option 'X' does not exist", en.Error(NewError(OptionNotFound, "X")))
+	assert.Equal(t, "internal error", en.Error(cause))
+}
+
+// Тест проверяет передачу Localizer через контекст
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, DefaultLang, FromContext(context.Background()).Lang())
+
+	ctx := WithLocalizer(context.Background(), New("en"))
+	assert.Equal(t, English, FromContext(ctx).Lang())
+}
@@ -0,0 +1,466 @@
+package i18n
+
+// Key - идентификатор сообщения в каталогах. Каждый ключ обязан быть
+// во всех каталогах, это проверяет TestCatalogsComplete.
+type Key string
+
+// Ответы обработчика команд
+const (
+	HelpHeader          Key = "handler.help_header"
+	HelpFooter          Key = "handler.help_footer"
+	HelpUnknown         Key = "handler.help_unknown"
+	IntroHeader         Key = "handler.intro_header"
+	IntroFooter         Key = "handler.intro_footer"
+	CreateUsage         Key = "handler.create_usage"
+	VoteUsage           Key = "handler.vote_usage"
+	ResultsUsage        Key = "handler.results_usage"
+	MyVoteUsage         Key = "handler.myvote_usage"
+	ListUsage           Key = "handler.list_usage"
+	SearchUsage         Key = "handler.search_usage"
+	EndUsage            Key = "handler.end_usage"
+	DeleteUsage         Key = "handler.delete_usage"
+	WinnerUsage         Key = "handler.winner_usage"
+	CloneUsage          Key = "handler.clone_usage"
+	TransferUsage       Key = "handler.transfer_usage"
+	SetUsage            Key = "handler.set_usage"
+	StatsUsage          Key = "handler.stats_usage"
+	ProgressUsage       Key = "handler.progress_usage"
+	EditUsage           Key = "handler.edit_usage"
+	ScheduleUsage       Key = "handler.schedule_usage"
+	TemplateUsage       Key = "handler.template_usage"
+	CreateFromUsage     Key = "handler.create_from_usage"
+	DigestUsage         Key = "handler.digest_usage"
+	AuditUsage          Key = "handler.audit_usage"
+	UsageUsage          Key = "handler.usage_usage"
+	FeatureUsage        Key = "handler.feature_usage"
+	UnfeatureUsage      Key = "handler.unfeature_usage"
+	PingUsage           Key = "handler.ping_usage"
+	VersionUsage        Key = "handler.version_usage"
+	AdminOnly           Key = "handler.admin_only"
+	UnknownCommand      Key = "handler.unknown_command"
+	CommandRateLimited  Key = "handler.command_rate_limited"
+	UnclosedQuote       Key = "handler.unclosed_quote"
+	TrailingEscape      Key = "handler.trailing_escape"
+	PreviewUnsupported  Key = "handler.preview_unsupported"
+	StatsUnsupported    Key = "handler.stats_unsupported"
+	ProgressUnsupported Key = "handler.progress_unsupported"
+	EditUnsupported     Key = "handler.edit_unsupported"
+	CommandFailed       Key = "bot.command_failed"
+	EditedReply         Key = "bot.edited_reply"
+	CreatingPoll        Key = "bot.creating_poll"
+	UnexpectedError     Key = "bot.unexpected_error"
+	// Вложение с итогами опроса
+	ResultsCardVotes     Key = "bot.results_card_votes"
+	ResultsCardWeighted  Key = "bot.results_card_weighted"
+	ResultsCardWinner    Key = "bot.results_card_winner"
+	ResultsCardAbstained Key = "bot.results_card_abstained"
+	ResultsCardFooter    Key = "bot.results_card_footer"
+)
+
+// Справка по командам: краткая строка и подробное описание
+const (
+	HelpCreateSummary     Key = "help.create.summary"
+	HelpCreateDetails     Key = "help.create.details"
+	HelpVoteSummary       Key = "help.vote.summary"
+	HelpVoteDetails       Key = "help.vote.details"
+	HelpResultsSummary    Key = "help.results.summary"
+	HelpResultsDetails    Key = "help.results.details"
+	HelpMyVoteSummary     Key = "help.myvote.summary"
+	HelpMyVoteDetails     Key = "help.myvote.details"
+	HelpListSummary       Key = "help.list.summary"
+	HelpListDetails       Key = "help.list.details"
+	HelpSearchSummary     Key = "help.search.summary"
+	HelpSearchDetails     Key = "help.search.details"
+	HelpEndSummary        Key = "help.end.summary"
+	HelpEndDetails        Key = "help.end.details"
+	HelpDeleteSummary     Key = "help.delete.summary"
+	HelpDeleteDetails     Key = "help.delete.details"
+	HelpWinnerSummary     Key = "help.winner.summary"
+	HelpWinnerDetails     Key = "help.winner.details"
+	HelpCloneSummary      Key = "help.clone.summary"
+	HelpCloneDetails      Key = "help.clone.details"
+	HelpTransferSummary   Key = "help.transfer.summary"
+	HelpTransferDetails   Key = "help.transfer.details"
+	HelpSetSummary        Key = "help.set.summary"
+	HelpSetDetails        Key = "help.set.details"
+	HelpStatsSummary      Key = "help.stats.summary"
+	HelpStatsDetails      Key = "help.stats.details"
+	HelpProgressSummary   Key = "help.progress.summary"
+	HelpProgressDetails   Key = "help.progress.details"
+	HelpEditSummary       Key = "help.edit.summary"
+	HelpEditDetails       Key = "help.edit.details"
+	HelpScheduleSummary   Key = "help.schedule.summary"
+	HelpScheduleDetails   Key = "help.schedule.details"
+	HelpTemplateSummary   Key = "help.template.summary"
+	HelpTemplateDetails   Key = "help.template.details"
+	HelpCreateFromSummary Key = "help.create_from.summary"
+	HelpCreateFromDetails Key = "help.create_from.details"
+	HelpDigestSummary     Key = "help.digest.summary"
+	HelpDigestDetails     Key = "help.digest.details"
+	HelpAuditSummary      Key = "help.audit.summary"
+	HelpAuditDetails      Key = "help.audit.details"
+	HelpUsageSummary      Key = "help.usage.summary"
+	HelpUsageDetails      Key = "help.usage.details"
+	HelpFeatureSummary    Key = "help.feature.summary"
+	HelpFeatureDetails    Key = "help.feature.details"
+	HelpUnfeatureSummary  Key = "help.unfeature.summary"
+	HelpUnfeatureDetails  Key = "help.unfeature.details"
+	HelpPingSummary       Key = "help.ping.summary"
+	HelpPingDetails       Key = "help.ping.details"
+	HelpVersionSummary    Key = "help.version.summary"
+	HelpVersionDetails    Key = "help.version.details"
+	HelpHelpSummary       Key = "help.help.summary"
+	HelpHelpDetails       Key = "help.help.details"
+)
+
+// Ответы и ошибки сервиса опросов
+const (
+	NoOptions            Key = "poll.no_options"
+	QuestionTooLong      Key = "poll.question_too_long"
+	DescriptionTooLong   Key = "poll.description_too_long"
+	OptionTooLong        Key = "poll.option_too_long"
+	OptionBlank          Key = "poll.option_blank"
+	DuplicateOptions     Key = "poll.duplicate_options"
+	MentionInPoll        Key = "poll.mention_in_poll"
+	LinkInPoll           Key = "poll.link_in_poll"
+	ControlCharInPoll    Key = "poll.control_char_in_poll"
+	InvisibleInPoll      Key = "poll.invisible_in_poll"
+	PollCreated          Key = "poll.created"
+	PollCreatedOption    Key = "poll.created_option"
+	PollPreview          Key = "poll.preview"
+	PreviewPin           Key = "poll.preview_pin"
+	PreviewExclusive     Key = "poll.preview_exclusive"
+	PreviewFooter        Key = "poll.preview_footer"
+	CreatedChannelOnly   Key = "poll.created_channel_only"
+	CreatedMembersOnly   Key = "poll.created_members_only"
+	CreatedVoters        Key = "poll.created_voters"
+	CreatedQuorum        Key = "poll.created_quorum"
+	InvalidPollID        Key = "poll.invalid_id"
+	PollClosed           Key = "poll.closed"
+	AlreadyVoted         Key = "poll.already_voted"
+	SelfVoteForbidden    Key = "poll.self_vote_forbidden"
+	ChannelOnly          Key = "poll.channel_only"
+	MembersOnlyRejected  Key = "poll.members_only_rejected"
+	VoterNotAllowed      Key = "poll.voter_not_allowed"
+	MembersUnavailable   Key = "poll.members_only_unavailable"
+	MembersOnlyNoChannel Key = "poll.members_only_no_channel"
+	QuorumInvalid        Key = "poll.quorum_invalid"
+	QuorumReached        Key = "poll.quorum_reached"
+	ChannelHasPoll       Key = "poll.channel_has_poll"
+	ChannelPollLimit     Key = "poll.channel_poll_limit"
+	OptionNotFound       Key = "poll.option_not_found"
+	OptionSuggestion     Key = "poll.option_suggestion"
+	VoteRecorded         Key = "poll.vote_recorded"
+	SelfVoteNote         Key = "poll.self_vote_note"
+	VoteReceipt          Key = "poll.vote_receipt"
+	ResultsHeader        Key = "poll.results_header"
+	ResultsLine          Key = "poll.results_line"
+	ResultsTableOption   Key = "results.table_option"
+	ResultsTableVotes    Key = "results.table_votes"
+	ResultsTableWeighted Key = "results.table_weighted"
+	ResultsTableBar      Key = "results.table_bar"
+	ResultsTableTotal    Key = "results.table_total"
+	MyVoteNone           Key = "poll.myvote_none"
+	MyVoteChoice         Key = "poll.myvote_choice"
+	MyVoteRankedHeader   Key = "poll.myvote_ranked_header"
+	MyVoteRankedLine     Key = "poll.myvote_ranked_line"
+	MyVoteUnknown        Key = "poll.myvote_unknown"
+	SingleChoiceOnly     Key = "poll.single_choice_only"
+	DuplicateRanking     Key = "poll.duplicate_ranking"
+	AmbiguousVote        Key = "poll.ambiguous_vote"
+	CreatedRanked        Key = "poll.created_ranked"
+	PollPinned           Key = "poll.pinned"
+	PinFailed            Key = "poll.pin_failed"
+	PinUnavailable       Key = "poll.pin_unavailable"
+	CreatedNotify        Key = "poll.created_notify"
+	CreatedReceipts      Key = "poll.created_receipts"
+	CreatedNoSelfVote    Key = "poll.created_no_self_vote"
+	CreatedAnonymous     Key = "poll.created_anonymous"
+	NotifyResults        Key = "poll.notify_results"
+	NotifySummary        Key = "poll.notify_summary"
+	CreatedExpires       Key = "poll.created_expires"
+	ExpiresInvalid       Key = "poll.expires_invalid"
+	SinceInvalid         Key = "poll.since_invalid"
+	SinceUnsupported     Key = "poll.since_unsupported"
+	ActiveUnsupported    Key = "poll.active_unsupported"
+	DescriptionMissing   Key = "poll.description_missing"
+	TagsMissing          Key = "poll.tags_missing"
+	TagsTooMany          Key = "poll.tags_too_many"
+	TagTooLong           Key = "poll.tag_too_long"
+	TagInvalid           Key = "poll.tag_invalid"
+	CreatedTags          Key = "poll.created_tags"
+	ResultsTags          Key = "poll.results_tags"
+	ListHeader           Key = "poll.list_header"
+	ListHeaderTag        Key = "poll.list_header_tag"
+	ListLine             Key = "poll.list_line"
+	ListFeatured         Key = "poll.list_featured"
+	ListFeaturedClosed   Key = "poll.list_featured_closed"
+	ListEmpty            Key = "poll.list_empty"
+	ListEmptyTag         Key = "poll.list_empty_tag"
+	ListMore             Key = "poll.list_more"
+	ListScopeChannel     Key = "poll.list_scope_channel"
+	ListScopeMine        Key = "poll.list_scope_mine"
+	SearchQueryEmpty     Key = "poll.search_query_empty"
+	SearchHeader         Key = "poll.search_header"
+	SearchNoMatches      Key = "poll.search_no_matches"
+	SearchMore           Key = "poll.search_more"
+	WeightsInvalid       Key = "poll.weights_invalid"
+	WeightInvalid        Key = "poll.weight_invalid"
+	WeightsRanked        Key = "poll.weights_ranked"
+	AnonymousWeights     Key = "poll.anonymous_weights"
+	AnonymousNotify      Key = "poll.anonymous_notify"
+	AnonymousVoters      Key = "poll.anonymous_voters"
+	AnonymousUnavailable Key = "poll.anonymous_unavailable"
+	WeightUserNotFound   Key = "poll.weight_user_not_found"
+	WeightsUnavailable   Key = "poll.weights_unavailable"
+	WeightsCreateOnly    Key = "poll.weights_create_only"
+	VotersNotFound       Key = "poll.voters_not_found"
+	VotersUnavailable    Key = "poll.voters_unavailable"
+	VotersMissing        Key = "poll.voters_missing"
+	VotersQuorum         Key = "poll.voters_quorum"
+	VotersCreateOnly     Key = "poll.voters_create_only"
+	CreatedWeights       Key = "poll.created_weights"
+	ResultsLineWeighted  Key = "poll.results_line_weighted"
+	AbstainOption        Key = "poll.abstain_option"
+	AbstainReserved      Key = "poll.abstain_reserved"
+	AbstainExclusive     Key = "poll.abstain_exclusive"
+	VoteAbstained        Key = "poll.vote_abstained"
+	CreatedAbstain       Key = "poll.created_abstain"
+	ResultsAbstained     Key = "poll.results_abstained"
+	ResultsWaiting       Key = "poll.results_waiting"
+	ResultsPage          Key = "poll.results_page"
+	ResultsPageLast      Key = "poll.results_page_last"
+	PageInvalid          Key = "poll.page_invalid"
+	PageOutOfRange       Key = "poll.page_out_of_range"
+	RecentHeader         Key = "poll.recent_header"
+	RecentHeaderWhole    Key = "poll.recent_header_whole"
+	RecentVoters         Key = "poll.recent_voters"
+	RecentLine           Key = "poll.recent_line"
+	RecentAbstained      Key = "poll.recent_abstained"
+	ActiveHeader         Key = "poll.active_header"
+	ActiveNone           Key = "poll.active_none"
+	ActiveUnverified     Key = "poll.active_unverified"
+	MyVoteAbstained      Key = "poll.myvote_abstained"
+	AbstainCreateOnly    Key = "poll.abstain_create_only"
+	MaxVotesInvalid      Key = "poll.max_votes_invalid"
+	MaxVotesReached      Key = "poll.max_votes_reached"
+	MaxVotesClosed       Key = "poll.max_votes_closed"
+	MaxVotesCreateOnly   Key = "poll.max_votes_create_only"
+	ReceiptsCreateOnly   Key = "poll.receipts_create_only"
+	NoSelfVoteCreateOnly Key = "poll.no_self_vote_create_only"
+	AnonymousCreateOnly  Key = "poll.anonymous_create_only"
+	MembersCreateOnly    Key = "poll.members_only_create_only"
+	CreatedMaxVotes      Key = "poll.created_max_votes"
+	ExpiresConflict      Key = "poll.expires_conflict"
+	NoExpireForbidden    Key = "poll.no_expire_forbidden"
+	ExpiryWarning        Key = "poll.expiry_warning"
+	PollExpired          Key = "poll.expired"
+	RankedRound          Key = "poll.ranked_round"
+	RankedEliminated     Key = "poll.ranked_eliminated"
+	RankedWinner         Key = "poll.ranked_winner"
+	RankedNoWinner       Key = "poll.ranked_no_winner"
+	OnlyCreatorCanEnd    Key = "poll.only_creator_end"
+	PollEnded            Key = "poll.ended"
+	PollAlreadyClosed    Key = "poll.already_closed"
+	EndAllNone           Key = "poll.end_all_none"
+	EndAllSummary        Key = "poll.end_all_summary"
+	EndAllFailure        Key = "poll.end_all_failure"
+	EndAllTruncated      Key = "poll.end_all_truncated"
+	EndChannelOnly       Key = "poll.end_channel_only"
+	OnlyCreatorDelete    Key = "poll.only_creator_delete"
+	PollDeleted          Key = "poll.deleted"
+	OnlyCreatorWinner    Key = "poll.only_creator_winner"
+	OnlyCreatorActive    Key = "poll.only_creator_active"
+	WinnerPollOpen       Key = "poll.winner_poll_open"
+	WinnerChosen         Key = "poll.winner_chosen"
+	WinnerNoVoters       Key = "poll.winner_no_voters"
+	WinnerAnonymous      Key = "poll.winner_anonymous"
+	ActiveAnonymous      Key = "poll.active_anonymous"
+	WinnerAnnounce       Key = "poll.winner_announce"
+	ClonedFrom           Key = "poll.cloned_from"
+	OnlyCreatorTransfer  Key = "poll.only_creator_transfer"
+	TransferPollClosed   Key = "poll.transfer_poll_closed"
+	TransferToSelf       Key = "poll.transfer_to_self"
+	TransferToBot        Key = "poll.transfer_to_bot"
+	TransferUserNotFound Key = "poll.transfer_user_not_found"
+	TransferNotMember    Key = "poll.transfer_not_member"
+	TransferUnavailable  Key = "poll.transfer_unavailable"
+	PollTransferred      Key = "poll.transferred"
+	PollFeatured         Key = "poll.featured"
+	PollFeaturedClosed   Key = "poll.featured_closed"
+	PollUnfeatured       Key = "poll.unfeatured"
+	TransferNotice       Key = "poll.transfer_notice"
+	OnlyCreatorSettings  Key = "poll.only_creator_settings"
+	OnlyCreatorStats     Key = "poll.only_creator_stats"
+	PollStats            Key = "poll.stats"
+	OnlyCreatorProgress  Key = "poll.only_creator_progress"
+	ProgressUnrestricted Key = "poll.progress_unrestricted"
+	PollProgress         Key = "poll.progress"
+	ProgressWaiting      Key = "poll.progress_waiting"
+	ProgressVoted        Key = "poll.progress_voted"
+	ProgressPage         Key = "poll.progress_page"
+	ProgressPageLast     Key = "poll.progress_page_last"
+	ProgressOutOfRange   Key = "poll.progress_out_of_range"
+	OnlyCreatorEdit      Key = "poll.only_creator_edit"
+	EditPollClosed       Key = "poll.edit_poll_closed"
+	EditHasVotes         Key = "poll.edit_has_votes"
+	QuestionEdited       Key = "poll.question_edited"
+	SettingsPollClosed   Key = "poll.settings_poll_closed"
+	SettingUnknown       Key = "poll.setting_unknown"
+	SettingValueInvalid  Key = "poll.setting_value_invalid"
+	SettingHintSwitch    Key = "poll.setting_hint_switch"
+	SettingHintNumber    Key = "poll.setting_hint_number"
+	SettingHintExpires   Key = "poll.setting_hint_expires"
+	SettingQuorumReached Key = "poll.setting_quorum_reached"
+	SettingMaxVotesMet   Key = "poll.setting_max_votes_met"
+	SettingsHeader       Key = "poll.settings_header"
+	SettingsLine         Key = "poll.settings_line"
+	SettingsFixedLine    Key = "poll.settings_fixed_line"
+	SettingOn            Key = "poll.setting_on"
+	SettingOff           Key = "poll.setting_off"
+	SettingNone          Key = "poll.setting_none"
+	SettingChanged       Key = "poll.setting_changed"
+	SettingUnchanged     Key = "poll.setting_unchanged"
+	SettingCreateOnly    Key = "poll.setting_create_only"
+	SettingVotersChanged Key = "poll.setting_voters_changed"
+	SettingVotersCleared Key = "poll.setting_voters_cleared"
+	PollNotFound         Key = "poll.not_found"
+	Pong                 Key = "poll.pong"
+	PongStorage          Key = "poll.pong_storage"
+	PongStorageDown      Key = "poll.pong_storage_down"
+	VersionHeader        Key = "poll.version_header"
+	VersionBuild         Key = "poll.version_build"
+	VersionUptime        Key = "poll.version_uptime"
+	VersionStorage       Key = "poll.version_storage"
+	VersionFeatures      Key = "poll.version_features"
+	ServiceUnavailable   Key = "poll.service_unavailable"
+)
+
+// Ответы и ошибки расписаний повторяющихся опросов
+const (
+	CronFieldCount      Key = "schedule.cron_field_count"
+	CronFieldInvalid    Key = "schedule.cron_field_invalid"
+	CronNeverFires      Key = "schedule.cron_never_fires"
+	ScheduleNoChannel   Key = "schedule.no_channel"
+	ScheduleCreated     Key = "schedule.created"
+	ScheduleListHeader  Key = "schedule.list_header"
+	ScheduleListLine    Key = "schedule.list_line"
+	ScheduleListEmpty   Key = "schedule.list_empty"
+	OnlyCreatorSchedule Key = "schedule.only_creator_delete"
+	ScheduleDeleted     Key = "schedule.deleted"
+	ScheduleNotFound    Key = "schedule.not_found"
+	SchedulesDisabled   Key = "schedule.disabled"
+	ScheduledPoll       Key = "schedule.scheduled_poll"
+	ScheduleUnreachable Key = "schedule.unreachable"
+)
+
+// Ответы и ошибки шаблонов опросов
+const (
+	TemplateNameInvalid  Key = "template.name_invalid"
+	TemplateNameTooLong  Key = "template.name_too_long"
+	TemplateNoChannel    Key = "template.no_channel"
+	TemplateExists       Key = "template.exists"
+	TemplateNotFound     Key = "template.not_found"
+	TemplateSaved        Key = "template.saved"
+	TemplateSavedChannel Key = "template.saved_channel"
+	TemplateListHeader   Key = "template.list_header"
+	TemplateListLine     Key = "template.list_line"
+	TemplateChannelMark  Key = "template.channel_mark"
+	TemplateListEmpty    Key = "template.list_empty"
+	OnlyCreatorTemplate  Key = "template.only_creator_delete"
+	TemplateDeleted      Key = "template.deleted"
+	TemplatesDisabled    Key = "template.disabled"
+	CreatedFromTemplate  Key = "template.created_from"
+)
+
+// Ежедневная сводка открытых опросов канала
+const (
+	DigestHeader      Key = "digest.header"
+	DigestLine        Key = "digest.line"
+	DigestLineExpires Key = "digest.line_expires"
+	DigestMore        Key = "digest.more"
+	DigestEnabled     Key = "digest.enabled"
+	DigestDisabled    Key = "digest.disabled"
+	DigestNoChannel   Key = "digest.no_channel"
+	DigestsDisabled   Key = "digest.not_configured"
+)
+
+// Статистика выполненных команд бота
+const (
+	UsageHeader        Key = "usage.header"
+	UsageTableDay      Key = "usage.table_day"
+	UsageTableCommands Key = "usage.table_commands"
+	UsageTableTop      Key = "usage.table_top"
+	UsageEmpty         Key = "usage.empty"
+	UsageDisabled      Key = "usage.not_configured"
+)
+
+// Мастер пошагового создания опроса
+const (
+	WizardQuestion       Key = "wizard.question"
+	WizardEmptyAnswer    Key = "wizard.empty_answer"
+	WizardFirstOption    Key = "wizard.first_option"
+	WizardNextOption     Key = "wizard.next_option"
+	WizardNeedOption     Key = "wizard.need_option"
+	WizardOptionFlag     Key = "wizard.option_flag"
+	WizardTooManyOptions Key = "wizard.too_many_options"
+	WizardFlags          Key = "wizard.flags"
+	WizardFlagsInvalid   Key = "wizard.flags_invalid"
+	WizardCancelled      Key = "wizard.cancelled"
+	WizardExpired        Key = "wizard.expired"
+	WizardFailed         Key = "wizard.failed"
+)
+
+// Журнал событий опросов
+const (
+	AuditHeader       Key = "audit.header"
+	AuditLine         Key = "audit.line"
+	AuditEmpty        Key = "audit.empty"
+	AuditUnavailable  Key = "audit.unavailable"
+	AuditAnonymous    Key = "audit.anonymous"
+	AuditCreated      Key = "audit.created"
+	AuditCloned       Key = "audit.cloned"
+	AuditVoted        Key = "audit.voted"
+	AuditQuorumClosed Key = "audit.quorum_closed"
+	AuditMaxVotes     Key = "audit.max_votes_closed"
+	AuditEnded        Key = "audit.ended"
+	AuditDeleted      Key = "audit.deleted"
+	AuditWinner       Key = "audit.winner"
+	AuditTransferred  Key = "audit.transferred"
+	AuditSetting      Key = "audit.setting_changed"
+	AuditEdited       Key = "audit.question_edited"
+	AuditExpired      Key = "audit.expired"
+	AuditDiscarded    Key = "audit.discarded"
+	AuditFeatured     Key = "audit.featured"
+	AuditUnfeatured   Key = "audit.unfeatured"
+	AuditUnknown      Key = "audit.unknown"
+)
+
+// Описания операций хранилища, которыми оборачиваются неклассифицированные ошибки
+const (
+	OpSavePoll       Key = "op.save_poll"
+	OpSaveVote       Key = "op.save_vote"
+	OpGetPoll        Key = "op.get_poll"
+	OpEndPoll        Key = "op.end_poll"
+	OpDeletePoll     Key = "op.delete_poll"
+	OpSaveWinner     Key = "op.save_winner"
+	OpListPolls      Key = "op.list_polls"
+	OpTransferPoll   Key = "op.transfer_poll"
+	OpUpdateSettings Key = "op.update_settings"
+	OpEditQuestion   Key = "op.edit_question"
+	OpFindUser       Key = "op.find_user"
+	OpSaveSchedule   Key = "op.save_schedule"
+	OpGetSchedule    Key = "op.get_schedule"
+	OpDeleteSchedule Key = "op.delete_schedule"
+	OpListSchedules  Key = "op.list_schedules"
+	OpListAudit      Key = "op.list_audit"
+	OpGetVote        Key = "op.get_vote"
+	OpListVotes      Key = "op.list_votes"
+	OpSaveTemplate   Key = "op.save_template"
+	OpGetTemplate    Key = "op.get_template"
+	OpDeleteTemplate Key = "op.delete_template"
+	OpListTemplates  Key = "op.list_templates"
+	OpSaveDigest     Key = "op.save_digest"
+	OpListUsage      Key = "op.list_usage"
+	OpFeaturePoll    Key = "op.feature_poll"
+)
@@ -0,0 +1,585 @@
+package i18n
+
+var russian = map[Key]string{
+	HelpHeader:  "**Команды опросов:**",
+	HelpFooter:  "Подробнее о команде: %s help <команда>",
+	HelpUnknown: "Нет справки по команде '%s'. Доступные команды: %s",
+	IntroHeader: "Привет! Я бот опросов. Основные команды:",
+	IntroFooter: "Все команды: %s help",
+
+	HelpCreateSummary: `%s create "Вопрос" "Опция 1" "Опция 2"... - Создать опрос`,
+	HelpCreateDetails: `**%[1]s create "Вопрос" "Опция 1" "Опция 2"...**
+Создаёт опрос и возвращает его ID. Нужен вопрос и хотя бы один вариант ответа.
+Пример: %[1]s create "Где обедаем?" "Пицца" "Суши"
+Перед вариантом можно поставить эмодзи через двоеточие: "🍕:Пицца" или ":pizza::Пицца".
+С флагом --channel-only голосовать и смотреть результаты можно только в канале опроса.
+С флагом --members-only голосовать могут только участники канала опроса, даже получив ID опроса от других.
+С флагом --quorum N опрос завершается сам, когда проголосуют N участников.
+С флагом --exclusive опрос не создаётся, если в канале уже есть открытый.
+С флагом --ranked варианты в голосе ранжируются, победитель определяется мгновенным вторым туром.
+С флагом --pin бот публикует опрос отдельным сообщением и закрепляет его в канале до завершения.
+С флагом --notify-voters участники получат итоги в личные сообщения, когда опрос закроется.
+С флагом --receipts после голоса участник получает в личные сообщения квитанцию: вопрос, свой выбор и время.
+С флагом --no-self-vote создатель не может голосовать в своём опросе.
+С флагом --anonymous бот хранит голоса без ID участников: ни создатель, ни команды бота не покажут, кто как голосовал.
+С флагом --expires 3d опрос закроется сам через заданный срок (90m, 12h, 3d); --no-expire отключает срок по умолчанию, если это разрешено.
+С флагом --desc "Пояснение" под вопросом показывается пояснение; \n в тексте вопроса и пояснения переносит строку.
+С флагом --tags release,team-a опросу задаются метки, по ним опросы находятся командой list.
+С флагом --allow-abstain можно проголосовать «Воздержался»: участник учитывается в кворуме, но не в голосах, и может позже проголосовать за вариант.
+С флагом --max-votes N опрос завершается сам после N-го голоса, следующие голоса отклоняются; воздержавшиеся тоже считаются.
+С флагом --weights "@alice=2,@bob=3" голоса этих участников весят больше, итоги показывают и голоса, и сумму весов.
+С флагом --voters @alice @bob @carol голосовать могут только перечисленные участники; кворум считается от этого списка, итоги показывают, сколько голосов ещё ждёт опрос.
+С флагом --dry-run опрос не создаётся: бот проверяет команду и показывает только вам вопрос, варианты и флаги, с которыми опрос был бы создан.
+Без аргументов в личных сообщениях боту или с единственным флагом --wizard бот спросит вопрос, варианты и флаги по очереди; «отмена» прерывает мастер.
+Частые ошибки:
+- варианты должны быть уникальными
+- вопрос не длиннее 255 символов, вариант - не длиннее 100
+- не больше 5 меток до 30 символов: буквы, цифры, - и _
+- вес голоса - от 1 до 100, в рейтинговом опросе веса не поддерживаются
+- кворум опроса со списком участников не может быть больше списка
+- текст с пробелами нужно брать в кавычки`,
+	HelpVoteSummary: `%s vote "ID опроса" "Выбор" - Проголосовать`,
+	HelpVoteDetails: `**%[1]s vote "ID опроса" "Выбор"**
+Записывает ваш голос. Регистр букв в варианте не важен, при опечатке бот подскажет ближайший вариант.
+Вместо текста варианта можно указать его номер из сообщения о создании опроса: %[1]s vote <ID> 2
+Пример: %[1]s vote 123e4567-e89b-12d3-a456-426614174000 "Пицца"
+В рейтинговом опросе перечислите варианты по убыванию предпочтения: %[1]s vote <ID> "Пицца" "Суши"
+Вариант из нескольких слов можно не брать в кавычки: %[1]s vote <ID> Суши бар. Если в рейтинговом опросе такие слова - ещё и порядок вариантов, бот попросит кавычки.
+Частые ошибки:
+- проголосовать можно только один раз; воздержавшийся может один раз заменить воздержание голосом
+- в завершённом опросе голосовать нельзя`,
+	HelpResultsSummary: `%s results "ID опроса" [--since 1h | --active-only] [--table] [--page 2] - Показать результаты`,
+	HelpResultsDetails: `**%[1]s results "ID опроса" [--since 1h | --active-only] [--table] [--page 2]**
+Показывает число голосов за каждый вариант.
+С флагом --since 1h под итогами показывается, сколько участников проголосовали за последний час и сколько голосов за это время получил каждый вариант (90m, 12h, 3d).
+С флагом --table итоги показываются таблицей: вариант, голоса, доля и шкала, внизу - строка итога. Рейтинговый опрос показывается по раундам и с флагом.
+С флагом --active-only создатель видит под итогами, какими они были бы без голосов участников, деактивированных в Mattermost.
+Если вариантов больше 25, они показываются по 25, а флаг --page 2 показывает следующие.
+Пример: %[1]s results 123e4567-e89b-12d3-a456-426614174000 --since 1d`,
+	HelpMyVoteSummary: `%s myvote "ID опроса" - Показать ваш голос`,
+	HelpMyVoteDetails: `**%[1]s myvote "ID опроса"**
+Показывает, за что вы проголосовали, в открытом и в завершённом опросе. В рейтинговом опросе - весь ваш рейтинг по порядку.
+Пример: %[1]s myvote 123e4567-e89b-12d3-a456-426614174000`,
+	HelpListSummary: `%s list [--tag метка] [--all] - Показать открытые опросы`,
+	HelpListDetails: `**%[1]s list [--tag метка] [--all]**
+Показывает открытые опросы канала, а в личных сообщениях - ваши открытые опросы, не больше 20.
+С флагом --all (или --global) - открытые опросы всех каналов, кроме ограниченных чужим каналом.
+С флагом --tag выводятся только опросы с этой меткой.
+Пример: %[1]s list --tag release`,
+	HelpSearchSummary: `%s search "текст" - Найти открытые опросы по вопросу`,
+	HelpSearchDetails: `**%[1]s search "текст"**
+Ищет открытые опросы канала (в личных сообщениях - ваши) по тексту вопроса без учёта регистра.
+Опрос находится, если вопрос содержит текст целиком или каждое слово запроса начинает слово вопроса. Показываются первые 10 совпадений.
+Пример: %[1]s search "обед пятн"`,
+	HelpEndSummary: `%s end "ID опроса" | --all [--channel] - Завершить опрос или все свои опросы`,
+	HelpEndDetails: `**%[1]s end "ID опроса" | --all [--channel]**
+Завершает опрос: результаты остаются доступны, новые голоса не принимаются.
+С флагом --all завершает все ваши открытые опросы во всех каналах, не больше 50 за раз, и публикует итоги каждого в его канале.
+С флагами --all --channel завершает все открытые опросы канала, чьи бы они ни были; это доступно только администраторам бота.
+Пример: %[1]s end 123e4567-e89b-12d3-a456-426614174000
+Частые ошибки:
+- завершить опрос может только его создатель`,
+	HelpDeleteSummary: `%s delete "ID опроса" - Удалить опрос`,
+	HelpDeleteDetails: `**%[1]s delete "ID опроса"**
+Удаляет опрос вместе с голосами, восстановить его нельзя.
+Пример: %[1]s delete 123e4567-e89b-12d3-a456-426614174000
+Частые ошибки:
+- удалить опрос может только его создатель`,
+	HelpWinnerSummary: `%s winner "ID опроса" ["Вариант"] - Выбрать случайного победителя`,
+	HelpWinnerDetails: `**%[1]s winner "ID опроса" ["Вариант"] [--again]**
+Выбирает случайного участника завершённого опроса и объявляет его в канале опроса.
+С вариантом выбор идёт среди проголосовавших за него.
+Пример: %[1]s winner 123e4567-e89b-12d3-a456-426614174000 "Пицца"
+Частые ошибки:
+- выбрать победителя может только создатель опроса
+- опрос должен быть завершён
+- повторный выбор требует флага --again`,
+	HelpCloneSummary: `%s clone "ID опроса" ["Вопрос"] - Создать копию опроса`,
+	HelpCloneDetails: `**%[1]s clone "ID опроса" ["Вопрос"]**
+Создаёт новый открытый опрос с теми же вопросом, вариантами и флагами, без голосов.
+Создателем копии становитесь вы, вопрос можно заменить вторым аргументом.
+Пример: %[1]s clone 123e4567-e89b-12d3-a456-426614174000 "Где обедаем в пятницу?"
+Частые ошибки:
+- копировать опрос, ограниченный каналом, можно только из его канала`,
+	HelpTransferSummary: `%s transfer "ID опроса" @пользователь - Передать опрос другому пользователю`,
+	HelpTransferDetails: `**%[1]s transfer "ID опроса" @пользователь**
+Делает пользователя создателем опроса: он сможет завершить, удалить опрос и выбрать победителя. Новый владелец получит личное сообщение.
+Пример: %[1]s transfer 123e4567-e89b-12d3-a456-426614174000 @ivan
+Частые ошибки:
+- передать опрос может только его создатель
+- завершённый опрос передать нельзя
+- опрос, ограниченный каналом, можно передать только участнику канала`,
+	HelpSetSummary: `%s set "ID опроса" [настройка значение] - Изменить настройки опроса`,
+	HelpSetDetails: `**%[1]s set "ID опроса" [настройка значение]**
+Без настройки показывает текущие настройки опроса, с настройкой - меняет её и отвечает прежним и новым значением.
+Настройки: channel-only on|off, quorum N|off, max-votes N|off, expires 90m|12h|3d|off, voters @alice @bob|off; новый срок отсчитывается от текущего момента.
+Пример: %[1]s set 123e4567-e89b-12d3-a456-426614174000 quorum 10
+Частые ошибки:
+- менять настройки может только создатель, и только пока опрос открыт
+- кворум и максимум голосов должны быть больше уже отданных голосов
+- рейтинговое голосование, веса и воздержание после создания не меняются`,
+	HelpStatsSummary: `%s stats "ID опроса" - Показать просмотры и конверсию опроса`,
+	HelpStatsDetails: `**%[1]s stats "ID опроса"**
+Показывает, сколько раз смотрели итоги опроса и какая доля смотревших проголосовала. Повторный просмотр одного участника в течение часа не учитывается, в анонимных опросах просмотры считаются так же.
+Пример: %[1]s stats 123e4567-e89b-12d3-a456-426614174000
+Частые ошибки:
+- статистику видит только создатель опроса
+- просмотры считаются с момента обновления бота, старые просмотры не восстанавливаются`,
+	HelpProgressSummary: `%s progress "ID опроса" - Показать, кто проголосовал и кого опрос ещё ждёт`,
+	HelpProgressDetails: `**%[1]s progress "ID опроса" [--page N]**
+Показывает создателю, сколько голосов набрал опрос со списком участников или кворумом: «7 из 10 голосов». В опросе со списком перечисляет, кто ещё не проголосовал и кто уже проголосовал, по 50 участников на странице; --page N показывает следующие.
+Пример: %[1]s progress 123e4567-e89b-12d3-a456-426614174000
+Частые ошибки:
+- ход голосования видит только создатель опроса
+- в анонимном опросе видно только число голосов, без имён
+- опросу без списка участников и кворума ждать некого: команда отказывает`,
+	HelpEditSummary: `%s edit "ID опроса" "Новый вопрос" - Исправить вопрос опроса, пока нет голосов`,
+	HelpEditDetails: `**%[1]s edit "ID опроса" "Новый вопрос"**
+Исправляет опечатку в вопросе без удаления опроса. Новый вопрос проверяется так же, как при создании; закреплённое сообщение опроса обновляется.
+Пример: %[1]s edit 123e4567-e89b-12d3-a456-426614174000 "Где обедаем в пятницу?"
+Частые ошибки:
+- исправлять вопрос может только создатель, и только пока опрос открыт
+- после первого голоса, в том числе воздержания, вопрос не меняется: участники голосовали за прежний`,
+	HelpScheduleSummary: `%s schedule create "Cron" "Вопрос" "Опция 1"... - Создавать опрос по расписанию`,
+	HelpScheduleDetails: `**%[1]s schedule create "Cron" "Вопрос" "Опция 1"...**
+Создаёт расписание: бот сам публикует опрос в этом канале, когда наступает время из cron-выражения
+(минута, час, день месяца, месяц, день недели; время сервера бота). Флаги те же, что у create.
+Пример: %[1]s schedule create "0 10 * * 1" "Где обедаем?" "Пицца" "Суши" - каждый понедельник в 10:00
+%[1]s schedule list - расписания канала, %[1]s schedule delete "ID расписания" - удалить расписание.
+Частые ошибки:
+- cron-выражение берётся в кавычки целиком
+- удалить расписание может только его создатель
+- пока бот не работал, опросы не создаются и потом не досоздаются`,
+	HelpTemplateSummary: `%s template save "Имя" "Вопрос" "Опция 1"... - Сохранить шаблон опроса`,
+	HelpTemplateDetails: `**%[1]s template save "Имя" "Вопрос" "Опция 1"... [--channel]**
+Сохраняет вопрос, варианты и флаги как шаблон, по которому потом создаются опросы командой create-from. Флаги те же, что у create.
+Шаблон личный, а с флагом --channel им могут пользоваться все участники канала.
+Пример: %[1]s template save standup "Как дела?" "Хорошо" "Есть блокеры" --tags team-a
+%[1]s template list - ваши шаблоны и шаблоны канала, %[1]s template delete "Имя" [--channel] - удалить шаблон.
+Частые ошибки:
+- имя шаблона до 30 символов: буквы, цифры, - и _
+- имя должно быть уникальным среди ваших шаблонов или шаблонов канала
+- удалить шаблон канала может только его создатель`,
+	HelpCreateFromSummary: `%s create-from "Имя" - Создать опрос по шаблону`,
+	HelpCreateFromDetails: `**%[1]s create-from "Имя"**
+Создаёт опрос по вашему шаблону, а если его нет - по шаблону канала. Опрос не зависит от шаблона: изменение или удаление шаблона его не затронет.
+Пример: %[1]s create-from standup`,
+	HelpDigestSummary: `%s digest on|off - Ежедневная сводка открытых опросов канала`,
+	HelpDigestDetails: `**%[1]s digest on|off**
+Включает или выключает ежедневную сводку в этом канале: раз в день бот публикует открытые опросы канала с числом голосов и временем до закрытия.
+Если открытых опросов нет, сводка не публикуется.
+Пример: %[1]s digest on
+Частые ошибки:
+- сводка включается только в канале, не в личных сообщениях`,
+	HelpAuditSummary: `%s audit "ID опроса" [N] - Журнал событий опроса (для администраторов)`,
+	HelpAuditDetails: `**%[1]s audit "ID опроса" [N]**
+Показывает последние N событий опроса (по умолчанию 20, не больше 100): кто и когда создал, голосовал, завершил, передал или удалил опрос.
+Журнал сохраняется и после удаления опроса.
+Пример: %[1]s audit 123e4567-e89b-12d3-a456-426614174000 50
+Частые ошибки:
+- команда доступна только администраторам бота (BOT_ADMINS)`,
+	HelpUsageSummary: `%s usage [N] - Сколько раз выполнялись команды бота по дням (для администраторов)`,
+	HelpUsageDetails: `**%[1]s usage [N]**
+Показывает таблицу за последние N дней (по умолчанию 7, не больше 90): сколько команд выполнено за день и какие чаще всего. Дни считаются по UTC.
+Счётчики записываются в хранилище пачками раз в BOT_USAGE_FLUSH_INTERVAL (по умолчанию минуту), поэтому последние команды появляются в таблице не сразу.
+Пример: %[1]s usage 30
+Частые ошибки:
+- команда доступна только администраторам бота (BOT_ADMINS)`,
+	HelpFeatureSummary: `%s feature "ID опроса" - Показывать опрос первым в списках (для администраторов)`,
+	HelpFeatureDetails: `**%[1]s feature "ID опроса"**
+Отмечает опрос ⭐: в каждом списке %[1]s list он показывается первым, в любом канале, если его там можно видеть. Отметить можно и закрытый опрос: в списке он останется с пометкой о закрытии, пока отметку не снимут.
+Пример: %[1]s feature 123e4567-e89b-12d3-a456-426614174000
+Частые ошибки:
+- команда доступна только администраторам бота (BOT_ADMINS)`,
+	HelpUnfeatureSummary: `%s unfeature "ID опроса" - Снять с опроса отметку ⭐ (для администраторов)`,
+	HelpUnfeatureDetails: `**%[1]s unfeature "ID опроса"**
+Снимает отметку, поставленную командой feature: опрос снова показывается в списках на общих основаниях, а закрытый пропадает из них.
+Пример: %[1]s unfeature 123e4567-e89b-12d3-a456-426614174000
+Частые ошибки:
+- команда доступна только администраторам бота (BOT_ADMINS)`,
+	HelpPingSummary: `%s ping - Проверить, что бот и хранилище отвечают`,
+	HelpPingDetails: `**%[1]s ping**
+Отвечает pong с временем ответа хранилища, временем работы бота и его версией. Если хранилище не отвечает, бот всё равно ответит и покажет, что оно недоступно.
+Пример: %[1]s ping`,
+	HelpVersionSummary: `%s version - Показать сборку и включённые возможности бота`,
+	HelpVersionDetails: `**%[1]s version**
+Показывает версию, коммит и дату сборки бота, время его работы, хранилище и возможности, включённые настройками. Пригодится, чтобы сообщить о проблеме.
+Пример: %[1]s version`,
+	HelpHelpSummary: `%s help [команда] - Показать эту справку`,
+	HelpHelpDetails: `**%[1]s help [команда]**
+Без аргумента показывает список команд, с именем команды - подробную справку.
+Пример: %[1]s help vote`,
+
+	CreateUsage:         "Недостаточно аргументов. Нужен вопрос и хотя бы одна опция. Формат: %s create \"Вопрос\" \"Опция 1\"...",
+	VoteUsage:           "Формат: %s vote \"ID опроса\" \"Ваш выбор\"",
+	ResultsUsage:        "Формат: %s results \"ID опроса\" [--since 1h | --active-only] [--table] [--page 2]",
+	MyVoteUsage:         "Формат: %s myvote \"ID опроса\"",
+	ListUsage:           "Формат: %s list [--tag метка] [--all]",
+	SearchUsage:         "Формат: %s search \"текст вопроса\"",
+	EndUsage:            "Формат: %s end \"ID опроса\" | --all [--channel]",
+	DeleteUsage:         "Формат: %s delete \"ID опроса\"",
+	WinnerUsage:         "Формат: %s winner \"ID опроса\" [\"Вариант\"] [--again]",
+	ScheduleUsage:       "Формат: %[1]s schedule create \"0 10 * * 1\" \"Вопрос\" \"Опция 1\"..., %[1]s schedule list или %[1]s schedule delete \"ID расписания\"",
+	TemplateUsage:       "Формат: %[1]s template save \"Имя\" \"Вопрос\" \"Опция 1\"... [--channel], %[1]s template list или %[1]s template delete \"Имя\" [--channel]",
+	CreateFromUsage:     "Формат: %s create-from \"Имя шаблона\"",
+	DigestUsage:         "Формат: %[1]s digest on или %[1]s digest off",
+	TransferUsage:       "Формат: %s transfer \"ID опроса\" @пользователь",
+	SetUsage:            "Формат: %s set \"ID опроса\" [настройка значение]",
+	StatsUsage:          "Формат: %s stats \"ID опроса\"",
+	ProgressUsage:       "Формат: %s progress \"ID опроса\" [--page N]",
+	EditUsage:           "Формат: %s edit \"ID опроса\" \"Новый вопрос\"",
+	CloneUsage:          "Формат: %s clone \"ID опроса\" [\"Вопрос\"]",
+	AuditUsage:          "Формат: %s audit \"ID опроса\" [число событий]",
+	UsageUsage:          "Формат: %s usage [число дней]",
+	FeatureUsage:        "Формат: %s feature \"ID опроса\"",
+	UnfeatureUsage:      "Формат: %s unfeature \"ID опроса\"",
+	PingUsage:           "Формат: %s ping",
+	VersionUsage:        "Формат: %s version",
+	AdminOnly:           "команда доступна только администраторам",
+	UnknownCommand:      "Неизвестная команда. Введите %s help для справки",
+	CommandRateLimited:  "слишком много команд подряд, повторите через %d с",
+	UnclosedQuote:       "незакрытая кавычка в команде. Формат: %s",
+	TrailingEscape:      "незакрытая кавычка в команде: после \\ нет символа. Формат: %s",
+	PreviewUnsupported:  "предпросмотр опроса не поддерживается",
+	StatsUnsupported:    "статистика опроса не поддерживается",
+	ProgressUnsupported: "ход голосования не поддерживается",
+	EditUnsupported:     "исправление вопроса не поддерживается",
+	CommandFailed:       "Ошибка при выполнении команды: %s",
+	EditedReply:         "_Ответ на отредактированное сообщение_\n%s",
+	CreatingPoll:        "_Создаю опрос…_",
+	UnexpectedError:     "внутренняя ошибка",
+
+	ResultsCardVotes:     "%d (%d%%)",
+	ResultsCardWeighted:  "%d (%d%%), с учётом весов: %d",
+	ResultsCardWinner:    "Победитель",
+	ResultsCardAbstained: "Воздержались",
+	ResultsCardFooter:    "Опрос %s · создатель @%s",
+
+	NoOptions:            "должна быть хотя бы одна опция",
+	QuestionTooLong:      "вопрос слишком длинный",
+	DescriptionTooLong:   "пояснение длиннее %d символов",
+	OptionTooLong:        "вариант ответа слишком длинный",
+	OptionBlank:          "вариант ответа не может быть пустым",
+	DuplicateOptions:     "все опции в голосовании должны быть уникальными",
+	MentionInPoll:        "вопрос и опции не могут упоминать %s: упоминание срабатывало бы при каждом показе итогов",
+	LinkInPoll:           "ссылки в вопросе и опциях запрещены: %s",
+	ControlCharInPoll:    "в «%s» есть управляющие символы",
+	InvisibleInPoll:      "в «%s» есть невидимые символы, из-за которых опции выглядят одинаково",
+	PollCreated:          "Голосование создано успешно! ID: `%s`\nВопрос: %s\n%sВарианты:\n",
+	PollCreatedOption:    "%d. %s\n",
+	PollPreview:          "**Предпросмотр: опрос не создан**\nВопрос: %s\n%sВарианты:\n",
+	PreviewPin:           "Опрос будет опубликован отдельным сообщением и закреплён в канале\n",
+	PreviewExclusive:     "Опрос не создастся, если в канале уже есть открытый\n",
+	PreviewFooter:        "Чтобы создать опрос, повторите команду без --dry-run",
+	CreatedChannelOnly:   "Голосовать и смотреть результаты можно только в этом канале\n",
+	CreatedMembersOnly:   "Голосовать могут только участники этого канала\n",
+	CreatedVoters:        "Голосовать могут только: %s\n",
+	CreatedQuorum:        "Опрос завершится, когда проголосуют %d участников\n",
+	InvalidPollID:        "неверный формат ID опроса",
+	PollClosed:           "опрос завершен",
+	AlreadyVoted:         "вы уже голосовали в этом опросе",
+	SelfVoteForbidden:    "создатель не может голосовать в этом опросе",
+	ChannelOnly:          "этот опрос доступен только в своём канале",
+	MembersOnlyRejected:  "в этом опросе голосуют только участники его канала",
+	VoterNotAllowed:      "вы не входите в список участников этого опроса",
+	MembersUnavailable:   "не удалось проверить, что вы участник канала опроса, попробуйте позже",
+	MembersOnlyNoChannel: "флаг --members-only работает только в канале, а не в личных сообщениях",
+	QuorumInvalid:        "кворум должен быть целым числом не меньше 1",
+	QuorumReached:        "Кворум достигнут, опрос %s завершён\n",
+	ChannelHasPoll:       "в канале уже есть открытый опрос `%s`: %s. Завершите его, прежде чем создавать новый",
+	ChannelPollLimit:     "в канале уже создано опросов за сутки: %d - больше нельзя. Следующий опрос можно будет создать после %s",
+	OptionNotFound:       "вариант '%s' не существует",
+	OptionSuggestion:     "вариант '%s' не найден, возможно вы имели в виду '%s'?",
+	VoteRecorded:         "Ваш голос в голосовании %s записан: %s",
+	SelfVoteNote:         "_Это ваш опрос: голос учтён наравне с остальными._",
+	VoteReceipt:          "**Ваш голос учтён**\nОпрос: `%s`\nВопрос: %s\nВыбор: %s\nВремя: %s",
+	ResultsHeader:        "**Результаты опроса %s**\n%s\n%s",
+	ResultsLine:          "- %s: %d голосов\n",
+	ResultsTableOption:   "Вариант",
+	ResultsTableVotes:    "Голоса",
+	ResultsTableWeighted: "С учётом весов",
+	ResultsTableBar:      "Шкала",
+	ResultsTableTotal:    "Всего",
+	MyVoteNone:           "Вы ещё не голосовали в опросе %s",
+	MyVoteChoice:         "Ваш голос в опросе %s: %s",
+	MyVoteRankedHeader:   "Ваш рейтинг в опросе %s:\n",
+	MyVoteRankedLine:     "%d. %s\n",
+	MyVoteUnknown:        "Вы голосовали в опросе %s, но выбор не сохранён: голос подан до обновления бота",
+	SingleChoiceOnly:     "в этом опросе можно выбрать только один вариант",
+	DuplicateRanking:     "вариант '%s' указан в бюллетене дважды",
+	AmbiguousVote:        "непонятно, голос за вариант '%s' или за варианты по порядку %s; вариант из нескольких слов возьмите в кавычки",
+	CreatedRanked:        "Рейтинговый опрос: перечислите варианты по убыванию предпочтения, можно не все\n",
+	PollPinned:           "Опрос %s опубликован и закреплён в канале",
+	PinFailed:            "Опрос %s опубликован, но закрепить его не удалось: проверьте, что у бота есть права в канале",
+	PinUnavailable:       "Опрос не закреплён: закрепите это сообщение вручную\n",
+	CreatedNotify:        "Когда опрос закроется, участники получат итоги в личные сообщения\n",
+	CreatedReceipts:      "Каждый участник получит квитанцию о своём голосе в личные сообщения\n",
+	CreatedNoSelfVote:    "Создатель опроса не может в нём голосовать\n",
+	CreatedAnonymous:     "Опрос анонимный: бот хранит голоса без ID участников\n",
+	NotifyResults:        "Опрос %s, в котором вы голосовали, завершён.\n",
+	NotifySummary:        "Итоги опроса %s отправлены участникам: %d из %d",
+	CreatedExpires:       "Опрос закроется автоматически %s\n",
+	ExpiresInvalid:       "срок опроса задаётся как 90m, 12h или 3d и не может быть меньше минуты",
+	SinceInvalid:         "период --since задаётся как 90m, 12h или 3d и не может быть меньше минуты",
+	SinceUnsupported:     "итоги за период --since здесь недоступны",
+	ActiveUnsupported:    "итоги без деактивированных участников --active-only здесь недоступны",
+	DescriptionMissing:   "после --desc нужен текст пояснения",
+	TagsMissing:          "после --tags нужны метки через запятую",
+	TagsTooMany:          "у опроса может быть не больше %d меток",
+	TagTooLong:           "метка '%s' длиннее %d символов",
+	TagInvalid:           "метка '%s' может содержать только буквы, цифры, - и _",
+	CreatedTags:          "Метки: %s\n",
+	ResultsTags:          "Метки: %s\n",
+	ListHeader:           "**Открытые опросы**\n",
+	ListHeaderTag:        "**Открытые опросы с меткой %s**\n",
+	ListLine:             "- `%s` %s, голосов: %d%s\n",
+	ListFeatured:         "⭐ %s",
+	ListFeaturedClosed:   "⭐ %s _(закрыт)_",
+	ListEmpty:            "Открытых опросов нет",
+	ListEmptyTag:         "Открытых опросов с меткой %s нет",
+	ListMore:             "Показаны первые %d опросов из %d, уточните выбор меткой: --tag\n",
+	ListScopeChannel:     "_Только опросы этого канала, опросы всех каналов - с флагом --all_\n",
+	ListScopeMine:        "_В личных сообщениях показаны ваши опросы, опросы всех каналов - с флагом --all_\n",
+	SearchQueryEmpty:     "укажите текст для поиска по вопросам опросов",
+	SearchHeader:         "**Опросы по запросу «%s»**\n",
+	SearchNoMatches:      "Открытых опросов по запросу «%s» не найдено",
+	SearchMore:           "Показаны первые %d совпадений, уточните запрос\n",
+	WeightsInvalid:       "веса задаются как --weights \"@alice=2,@bob=3\", каждый участник один раз",
+	WeightInvalid:        "вес %s должен быть от 1 до %d",
+	WeightsRanked:        "в рейтинговом опросе веса голосов не поддерживаются",
+	AnonymousWeights:     "в анонимном опросе веса голосов не поддерживаются: по весу узнаётся участник",
+	AnonymousNotify:      "анонимный опрос не может разослать итоги участникам: бот не хранит, кто голосовал",
+	AnonymousVoters:      "в анонимном опросе нельзя задать список участников: список хранит их ID",
+	AnonymousUnavailable: "анонимные опросы не настроены: не задан ключ BOT_ANONYMOUS_SECRET",
+	WeightUserNotFound:   "пользователь %s из --weights не найден",
+	WeightsUnavailable:   "веса голосов не настроены: боту не по чему найти пользователей",
+	WeightsCreateOnly:    "веса голосов задаются только командой create",
+	VotersNotFound:       "пользователи из --voters не найдены: %s",
+	VotersUnavailable:    "список участников не настроен: боту не по чему найти пользователей",
+	VotersMissing:        "после --voters перечислите участников: --voters @alice @bob",
+	VotersQuorum:         "кворум %d больше списка участников (%d): опрос не набрал бы его",
+	VotersCreateOnly:     "список участников задаётся только командами create и set",
+	CreatedWeights:       "Веса голосов: %s, у остальных участников вес 1\n",
+	ResultsLineWeighted:  "- %s: %d голосов, с учётом весов: %d\n",
+	AbstainOption:        "Воздержался",
+	AbstainReserved:      "вариант '%s' зарезервирован для воздержавшихся",
+	AbstainExclusive:     "воздержаться можно только без других вариантов",
+	VoteAbstained:        "Вы воздержались в голосовании %s. Передумаете - проголосуйте за вариант, голос заменит воздержание",
+	CreatedAbstain:       "Можно воздержаться вариантом «%s»: воздержавшиеся учитываются в кворуме, но не в голосах\n",
+	ResultsAbstained:     "Воздержались: %d\n",
+	ResultsWaiting:       "Проголосовали %d из %d, ждём ещё %d\n",
+	ResultsPage:          "Показаны варианты %d-%d из %d, используйте --page %d\n",
+	ResultsPageLast:      "Показаны варианты %d-%d из %d\n",
+	PageInvalid:          "страница --page задаётся числом от 1",
+	PageOutOfRange:       "в итогах опроса страниц вариантов: %d",
+	RecentHeader:         "\n**Изменения с %s**\n",
+	RecentHeaderWhole:    "\n**Изменения с создания опроса (%s)**: период длиннее возраста опроса\n",
+	RecentVoters:         "Новых участников: %d\n",
+	RecentLine:           "- %s: +%d\n",
+	RecentAbstained:      "Воздержались: +%d\n",
+	ActiveHeader:         "\n**Без деактивированных участников**: не учтено голосов - %d\n",
+	ActiveNone:           "\nДеактивированных участников среди голосовавших нет\n",
+	ActiveUnverified:     "\n⚠️ Не удалось проверить участников в Mattermost, показаны все голоса\n",
+	MyVoteAbstained:      "Вы воздержались в опросе %s",
+	AbstainCreateOnly:    "флаг --allow-abstain действует только в команде create",
+	MaxVotesInvalid:      "максимум голосов должен быть целым числом не меньше 1",
+	MaxVotesReached:      "опрос уже набрал максимум голосов",
+	MaxVotesClosed:       "Опрос %s набрал максимум голосов и завершён\n",
+	MaxVotesCreateOnly:   "флаг --max-votes действует только в команде create",
+	ReceiptsCreateOnly:   "флаг --receipts действует только в команде create",
+	NoSelfVoteCreateOnly: "флаг --no-self-vote действует только в команде create",
+	AnonymousCreateOnly:  "флаг --anonymous действует только в команде create",
+	MembersCreateOnly:    "флаг --members-only действует только в команде create",
+	CreatedMaxVotes:      "Опрос завершится после %d голосов\n",
+	ExpiresConflict:      "нельзя указать --expires и --no-expire вместе",
+	NoExpireForbidden:    "опросы без срока запрещены: укажите срок флагом --expires",
+	ExpiryWarning:        "Опрос `%s` «%s» закроется автоматически %s. Успейте проголосовать!",
+	PollExpired:          "Срок опроса %s истёк, голосование окончено\n",
+	RankedRound:          "Раунд %d: %s\n",
+	RankedEliminated:     "Выбывает: %s\n",
+	RankedWinner:         "**Победитель: %s**\n",
+	RankedNoWinner:       "Победитель не определён: бюллетеней нет\n",
+	OnlyCreatorCanEnd:    "только создатель может завершить опрос",
+	PollEnded:            "Голосование %s окончено",
+	PollAlreadyClosed:    "голосование %s уже окончено",
+	EndAllNone:           "Открытых опросов нет: закрывать нечего",
+	EndAllSummary:        "Закрыто опросов: %d, ошибок: %d\n",
+	EndAllFailure:        "- %s - %s\n",
+	EndAllTruncated:      "Закрыты первые %d опросов из %d: повторите команду, чтобы закрыть остальные\n",
+	EndChannelOnly:       "флаг --channel работает только в канале, а не в личных сообщениях",
+	OnlyCreatorDelete:    "только создатель может удалить опрос",
+	PollDeleted:          "Голосование %s удалено",
+	OnlyCreatorWinner:    "только создатель может выбрать победителя",
+	OnlyCreatorActive:    "только создатель может исключить голоса деактивированных участников",
+	WinnerPollOpen:       "победителя можно выбрать только в завершённом опросе",
+	WinnerChosen:         "победитель уже выбран: %s. Чтобы выбрать заново, добавьте --again",
+	WinnerNoVoters:       "в опросе нет подходящих участников",
+	WinnerAnonymous:      "в анонимном опросе нельзя выбрать победителя: бот не хранит, кто голосовал",
+	ActiveAnonymous:      "в анонимном опросе нельзя исключить деактивированных участников: бот не хранит, кто голосовал",
+	WinnerAnnounce:       "Победитель опроса %s: %s 🎉",
+	ClonedFrom:           "Копия опроса `%s`\n",
+	OnlyCreatorTransfer:  "только создатель может передать опрос",
+	TransferPollClosed:   "завершённый опрос передать нельзя",
+	TransferToSelf:       "вы уже создатель этого опроса",
+	TransferToBot:        "нельзя передать опрос боту",
+	TransferUserNotFound: "пользователь %s не найден",
+	TransferNotMember:    "%s не состоит в канале опроса",
+	TransferUnavailable:  "передача опросов не настроена",
+	PollTransferred:      "Опрос %s передан пользователю %s",
+	PollFeatured:         "Опрос %s отмечен ⭐ и показывается первым в списках опросов",
+	PollFeaturedClosed:   "Опрос %s закрыт, но отмечен ⭐: в списках он показывается первым с пометкой о закрытии",
+	PollUnfeatured:       "С опроса %s снята отметка ⭐",
+	TransferNotice:       "%s передал(а) вам опрос `%s`: %s\nТеперь вы можете завершить его командой end",
+	OnlyCreatorSettings:  "только создатель может менять настройки опроса",
+	OnlyCreatorStats:     "только создатель может смотреть статистику опроса",
+	PollStats:            "Опрос %s: просмотров: %d, проголосовало: %d (конверсия %d%%)",
+	OnlyCreatorProgress:  "только создатель может смотреть, кто проголосовал",
+	ProgressUnrestricted: "у опроса %s нет списка участников и кворума: ждать некого",
+	PollProgress:         "Опрос %s: %d из %d голосов\n",
+	ProgressWaiting:      "Ещё не проголосовали (%d): %s\n",
+	ProgressVoted:        "Проголосовали (%d): %s\n",
+	ProgressPage:         "Показаны участники %d-%d из %d, используйте --page %d\n",
+	ProgressPageLast:     "Показаны участники %d-%d из %d\n",
+	ProgressOutOfRange:   "в списке участников опроса страниц: %d",
+	OnlyCreatorEdit:      "только создатель может исправить вопрос опроса",
+	EditPollClosed:       "вопрос завершённого опроса исправить нельзя",
+	EditHasVotes:         "в опросе %s уже проголосовали (%d), вопрос исправить нельзя: голоса отданы за прежний вопрос. Завершите опрос и создайте новый",
+	QuestionEdited:       "Вопрос опроса %s исправлен: %s",
+	SettingsPollClosed:   "настройки завершённого опроса менять нельзя",
+	SettingUnknown:       "неизвестная настройка '%s'. Доступные настройки: %s",
+	SettingValueInvalid:  "неверное значение настройки %s: ожидается %s",
+	SettingHintSwitch:    "on или off",
+	SettingHintNumber:    "целое число не меньше 1 или off",
+	SettingHintExpires:   "срок вида 90m, 12h или 3d не меньше минуты или off",
+	SettingQuorumReached: "в опросе уже %d голосов: кворум должен быть больше",
+	SettingMaxVotesMet:   "в опросе уже %d голосов: максимум должен быть больше",
+	SettingsHeader:       "**Настройки опроса %s**\n",
+	SettingsLine:         "- %s: %s\n",
+	SettingsFixedLine:    "- %s: %s (задаётся при создании)\n",
+	SettingOn:            "вкл",
+	SettingOff:           "выкл",
+	SettingNone:          "нет",
+	SettingChanged:       "Настройка %s опроса %s: %s → %s",
+	SettingUnchanged:     "Настройка %s опроса %s уже %s",
+	SettingCreateOnly:    "настройка %s задаётся только при создании опроса",
+	SettingVotersChanged: "Голосовать в опросе %s могут только: %s",
+	SettingVotersCleared: "Голосовать в опросе %s может любой",
+	PollNotFound:         "опрос не найден",
+	Pong:                 "pong — uptime: %s, версия: %s",
+	PongStorage:          "pong — %s: %s, uptime: %s, версия: %s",
+	PongStorageDown:      "недоступен",
+	VersionHeader:        "**Бот опросов %s**\n",
+	VersionBuild:         "Коммит: %s, собран: %s\n",
+	VersionUptime:        "Работает: %s\n",
+	VersionStorage:       "Хранилище: %s\n",
+	VersionFeatures:      "Возможности:\n",
+	ServiceUnavailable:   "сервис временно недоступен, попробуйте позже",
+
+	CronFieldCount:      "в cron-выражении должно быть 5 полей: минута, час, день месяца, месяц, день недели",
+	CronFieldInvalid:    "неверное поле cron-выражения '%s': допустимы значения от %d до %d",
+	CronNeverFires:      "расписание '%s' никогда не сработает",
+	ScheduleNoChannel:   "расписание можно создать только в канале",
+	ScheduleCreated:     "Расписание `%s` создано: %s\nВопрос: %s\nСледующий опрос: %s",
+	ScheduleListHeader:  "**Расписания канала**\n",
+	ScheduleListLine:    "- `%s` %s: %s\n",
+	ScheduleListEmpty:   "В этом канале нет расписаний",
+	OnlyCreatorSchedule: "только создатель может удалить расписание",
+	ScheduleDeleted:     "Расписание %s удалено",
+	ScheduleNotFound:    "расписание не найдено",
+	SchedulesDisabled:   "расписания не настроены",
+	ScheduledPoll:       "_Опрос по расписанию `%s`_\n%s",
+	ScheduleUnreachable: "Расписание `%s` («%s») остановлено: бот больше не может писать в его канал. Верните бота в канал и создайте расписание заново, а это удалите",
+
+	TemplateNameInvalid:  "неверное имя шаблона '%s': допустимы буквы, цифры, - и _",
+	TemplateNameTooLong:  "имя шаблона длиннее %d символов",
+	TemplateNoChannel:    "шаблон канала можно сохранить или удалить только в канале",
+	TemplateExists:       "шаблон %s уже существует",
+	TemplateNotFound:     "шаблон %s не найден",
+	TemplateSaved:        "Шаблон `%s` сохранён: %s",
+	TemplateSavedChannel: "Шаблон канала `%s` сохранён: %s",
+	TemplateListHeader:   "**Шаблоны опросов**\n",
+	TemplateListLine:     "- `%s` %s%s\n",
+	TemplateChannelMark:  " (канал)",
+	TemplateListEmpty:    "Шаблонов пока нет",
+	OnlyCreatorTemplate:  "только создатель может удалить шаблон",
+	TemplateDeleted:      "Шаблон %s удалён",
+	TemplatesDisabled:    "шаблоны не настроены",
+	CreatedFromTemplate:  "Опрос по шаблону `%s`\n",
+
+	DigestHeader:      "**Открытые опросы канала**\n",
+	DigestLine:        "- `%s` %s, голосов: %d\n",
+	DigestLineExpires: "- `%s` %s, голосов: %d, до закрытия: %s\n",
+	DigestMore:        "И ещё опросов: %d\n",
+	DigestEnabled:     "Сводка открытых опросов включена: бот будет публиковать её в этом канале каждый день в %02d:00",
+	DigestDisabled:    "Сводка открытых опросов в этом канале выключена",
+	DigestNoChannel:   "сводку опросов можно включить только в канале",
+	DigestsDisabled:   "сводки опросов не настроены",
+
+	UsageHeader:        "**Команды бота за %d дн. (UTC)**\n",
+	UsageTableDay:      "День",
+	UsageTableCommands: "Команд",
+	UsageTableTop:      "Чаще всего",
+	UsageEmpty:         "За последние %d дн. команд не было",
+	UsageDisabled:      "статистика команд не настроена",
+
+	WizardQuestion:       "Создаём опрос по шагам. Напишите вопрос.\nЧтобы выйти, отправьте «отмена».",
+	WizardEmptyAnswer:    "Нужен текст. Чтобы выйти, отправьте «отмена».",
+	WizardFirstOption:    "Теперь варианты ответа - по одному в сообщении. Отправьте первый вариант.",
+	WizardNextOption:     "Вариант %d добавлен. Отправьте следующий или «готово», если варианты закончились.",
+	WizardNeedOption:     "Нужен хотя бы один вариант ответа. Отправьте его отдельным сообщением.",
+	WizardOptionFlag:     "Вариант не может начинаться с --: так записываются флаги. Их можно будет указать после вариантов.",
+	WizardTooManyOptions: "Больше %d вариантов добавить нельзя. Отправьте «готово».",
+	WizardFlags:          "Вариантов: %d. Отправьте флаги опроса, например --anonymous --expires 2h (все флаги описаны в справке create), или «пропустить», чтобы создать опрос без них.",
+	WizardFlagsInvalid:   "Не удалось разобрать флаги: они начинаются с --, а текст с пробелами берётся в кавычки. Отправьте флаги снова или «пропустить».",
+	WizardCancelled:      "Создание опроса отменено.",
+	WizardExpired:        "Мастер создания опроса закрыт: ответа не было слишком долго. Чтобы начать заново, снова отправьте команду create.",
+	WizardFailed:         "Опрос не создан: %s\nИсправьте флаги и отправьте их снова, «пропустить» - без флагов, «отмена» - выйти.",
+
+	AuditHeader:       "**Журнал опроса %s**\n",
+	AuditLine:         "- `%s` %s %s\n",
+	AuditEmpty:        "В журнале опроса %s нет событий",
+	AuditAnonymous:    "журнал анонимного опроса недоступен",
+	AuditUnavailable:  "журнал событий не настроен",
+	AuditCreated:      "создал(а) опрос: %s",
+	AuditCloned:       "создал(а) опрос копированием %s",
+	AuditVoted:        "проголосовал(а): %s",
+	AuditQuorumClosed: "проголосовал(а): %s, опрос закрыт по кворуму",
+	AuditMaxVotes:     "проголосовал(а): %s, опрос закрыт по числу голосов",
+	AuditEnded:        "завершил(а) опрос",
+	AuditDeleted:      "удалил(а) опрос: %s",
+	AuditWinner:       "выбрал(а) победителя %s",
+	AuditTransferred:  "передал(а) опрос пользователю %s",
+	AuditSetting:      "изменил(а) настройку %s",
+	AuditEdited:       "исправил(а) вопрос: %s",
+	AuditExpired:      "срок опроса истёк, опрос закрыт",
+	AuditDiscarded:    "опрос удалён: бот не смог его объявить",
+	AuditFeatured:     "опрос отмечен ⭐",
+	AuditUnfeatured:   "снята отметка ⭐",
+	AuditUnknown:      "%s: %s",
+
+	OpSavePoll:       "ошибка сохранения опроса",
+	OpSaveVote:       "ошибка сохранения голоса",
+	OpGetPoll:        "ошибка получения опроса",
+	OpEndPoll:        "ошибка завершения опроса",
+	OpDeletePoll:     "ошибка удаления опроса",
+	OpSaveWinner:     "ошибка сохранения победителя",
+	OpListPolls:      "ошибка получения списка опросов",
+	OpTransferPoll:   "ошибка передачи опроса",
+	OpUpdateSettings: "ошибка изменения настроек опроса",
+	OpEditQuestion:   "ошибка исправления вопроса",
+	OpFindUser:       "ошибка поиска пользователя",
+	OpSaveSchedule:   "ошибка сохранения расписания",
+	OpGetSchedule:    "ошибка получения расписания",
+	OpDeleteSchedule: "ошибка удаления расписания",
+	OpListSchedules:  "ошибка получения списка расписаний",
+	OpListAudit:      "ошибка чтения журнала",
+	OpGetVote:        "ошибка получения голоса",
+	OpListVotes:      "ошибка получения голосов",
+	OpSaveTemplate:   "ошибка сохранения шаблона",
+	OpGetTemplate:    "ошибка получения шаблона",
+	OpDeleteTemplate: "ошибка удаления шаблона",
+	OpListTemplates:  "ошибка получения списка шаблонов",
+	OpSaveDigest:     "ошибка сохранения настройки сводки",
+	OpListUsage:      "ошибка получения статистики команд",
+	OpFeaturePoll:    "ошибка отметки опроса",
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Сколько ждать завершения текущих запросов отладочного сервера при остановке
+const debugShutdownTimeout = 5 * time.Second
+
+// debugStatsJSON - ответ /debug/stats: счётчики реестра и состояние
+// рантайма Go.
+type debugStatsJSON struct {
+	Counters   map[string]int64 `json:"counters"`
+	Goroutines int              `json:"goroutines"`
+	HeapAlloc  uint64           `json:"heap_alloc_bytes"`
+	HeapInuse  uint64           `json:"heap_inuse_bytes"`
+	NumGC      uint32           `json:"num_gc"`
+}
+
+// DebugHandler отдаёт профили net/http/pprof в /debug/pprof/ и снимок
+// счётчиков stats с состоянием рантайма в /debug/stats.
+func DebugHandler(stats *Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/stats", func(w http.ResponseWriter, r *http.Request) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(debugStatsJSON{
+			Counters:   stats.Snapshot(),
+			Goroutines: runtime.NumGoroutine(),
+			HeapAlloc:  mem.HeapAlloc,
+			HeapInuse:  mem.HeapInuse,
+			NumGC:      mem.NumGC,
+		})
+	})
+	return mux
+}
+
+// ServeDebug обслуживает DebugHandler на addr, пока не отменён ctx.
+// Адрес без хоста (":6060") слушается только на localhost: профили
+// раскрывают внутреннее состояние процесса.
+func ServeDebug(ctx context.Context, addr string, stats *Registry, logger zerolog.Logger) error {
+	addr, err := debugListenAddr(addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: addr, Handler: DebugHandler(stats), ReadHeaderTimeout: 10 * time.Second}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), debugShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Warn().Err(err).Msg("Отладочный сервер остановлен не полностью")
+		}
+	}()
+
+	logger.Info().Str("addr", addr).Msg("Отладочный сервер запущен")
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-done
+	return nil
+}
+
+// debugListenAddr подставляет localhost в адрес без хоста.
+func debugListenAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port), nil
+}
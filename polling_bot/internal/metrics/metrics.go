@@ -0,0 +1,14 @@
+package metrics
+
+import "expvar"
+
+// Счётчики публикуются через expvar и доступны в /debug/vars
+// HTTP-сервера бота (HTTP_ADDR).
+var (
+	// Голоса, которые не удалось записать после всех повторов из-за конфликтов
+	VoteRetriesExhausted = expvar.NewInt("vote_retries_exhausted_total")
+
+	// Обращения к кэшу опросов: найдено в кэше / пришлось читать из хранилища
+	PollCacheHits   = expvar.NewInt("poll_cache_hits_total")
+	PollCacheMisses = expvar.NewInt("poll_cache_misses_total")
+)
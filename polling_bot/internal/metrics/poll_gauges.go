@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PollGauges - голоса за варианты отслеживаемых опросов: метрика
+// poll_votes{poll_id,option} в текстовом формате Prometheus. У каждого
+// варианта свой ряд, поэтому число опросов ограничено limit.
+type PollGauges struct {
+	mu    sync.RWMutex
+	limit int
+	polls map[string]map[string]int
+}
+
+func NewPollGauges(limit int) *PollGauges {
+	return &PollGauges{limit: limit, polls: make(map[string]map[string]int)}
+}
+
+// Set заменяет значения опроса pollID. Новый опрос сверх limit не
+// добавляется, и Set возвращает false.
+func (g *PollGauges) Set(pollID string, votes map[string]int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.polls[pollID]; !ok && len(g.polls) >= g.limit {
+		return false
+	}
+	copied := make(map[string]int, len(votes))
+	for option, count := range votes {
+		copied[option] = count
+	}
+	g.polls[pollID] = copied
+	return true
+}
+
+// Delete убирает ряды опроса pollID.
+func (g *PollGauges) Delete(pollID string) {
+	g.mu.Lock()
+	delete(g.polls, pollID)
+	g.mu.Unlock()
+}
+
+// Snapshot возвращает текущие значения по опросам.
+func (g *PollGauges) Snapshot() map[string]map[string]int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	out := make(map[string]map[string]int, len(g.polls))
+	for pollID, votes := range g.polls {
+		copied := make(map[string]int, len(votes))
+		for option, count := range votes {
+			copied[option] = count
+		}
+		out[pollID] = copied
+	}
+	return out
+}
+
+// WriteTo пишет метрику в текстовом формате Prometheus; ряды упорядочены
+// по опросу и варианту, чтобы ответ не менялся без новых голосов.
+func (g *PollGauges) WriteTo(w io.Writer) (int64, error) {
+	polls := g.Snapshot()
+	ids := make([]string, 0, len(polls))
+	for pollID := range polls {
+		ids = append(ids, pollID)
+	}
+	sort.Strings(ids)
+
+	var sb strings.Builder
+	sb.WriteString("# HELP poll_votes Голоса за вариант отслеживаемого опроса.\n")
+	sb.WriteString("# TYPE poll_votes gauge\n")
+	for _, pollID := range ids {
+		options := make([]string, 0, len(polls[pollID]))
+		for option := range polls[pollID] {
+			options = append(options, option)
+		}
+		sort.Strings(options)
+		for _, option := range options {
+			fmt.Fprintf(&sb, "poll_votes{poll_id=\"%s\",option=\"%s\"} %d\n",
+				labelValue(pollID), labelValue(option), polls[pollID][option])
+		}
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// ServeHTTP отдаёт метрику сборщику Prometheus.
+func (g *PollGauges) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	g.WriteTo(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue экранирует значение метки: варианты опроса пишут участники,
+// и в них могут быть кавычки и переводы строк.
+func labelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
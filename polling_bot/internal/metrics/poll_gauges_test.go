@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест проверяет ограничение числа опросов: новый опрос сверх limit не
+// добавляется, а уже отслеживаемый обновляется и после удаления место
+// освобождается
+func TestPollGauges_Limit(t *testing.T) {
+	g := NewPollGauges(2)
+	assert.True(t, g.Set("poll1", map[string]int{"A": 1}))
+	assert.True(t, g.Set("poll2", map[string]int{"A": 0}))
+	assert.False(t, g.Set("poll3", map[string]int{"A": 5}))
+	assert.True(t, g.Set("poll1", map[string]int{"A": 2}))
+
+	g.Delete("poll2")
+	assert.True(t, g.Set("poll3", map[string]int{"A": 5}))
+	assert.Equal(t, map[string]map[string]int{"poll1": {"A": 2}, "poll3": {"A": 5}}, g.Snapshot())
+}
+
+// Тест проверяет текстовый формат Prometheus: ряды по порядку, значения
+// меток с кавычками и переводами строк экранированы
+func TestPollGauges_ServeHTTP(t *testing.T) {
+	g := NewPollGauges(10)
+	g.Set("poll2", map[string]int{"Нет": 1, "Да": 3})
+	g.Set("poll1", map[string]int{"say \"hi\"\nthere": 2})
+
+	rec := httptest.NewRecorder()
+	g.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP poll_votes Голоса за вариант отслеживаемого опроса.
+# TYPE poll_votes gauge
+poll_votes{poll_id="poll1",option="say \"hi\"\nthere"} 2
+poll_votes{poll_id="poll2",option="Да"} 3
+poll_votes{poll_id="poll2",option="Нет"} 1
+`, rec.Body.String())
+}
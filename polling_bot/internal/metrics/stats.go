@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Counter - счётчик реестра; Add с отрицательным n уменьшает его, поэтому
+// счётчиком можно считать и текущее число, например обработчиков в работе.
+type Counter struct {
+	v atomic.Int64
+}
+
+func (c *Counter) Add(n int64) {
+	c.v.Add(n)
+}
+
+func (c *Counter) Value() int64 {
+	return c.v.Load()
+}
+
+// Registry - потокобезопасный набор именованных счётчиков. В отличие от
+// expvar, счётчик можно получить по имени в любой момент: декораторы
+// заводят по счётчику на метод, не объявляя их заранее.
+type Registry struct {
+	mu       sync.RWMutex
+	counters map[string]*Counter
+}
+
+func NewRegistry() *Registry {
+	return &Registry{counters: make(map[string]*Counter)}
+}
+
+// Counter возвращает счётчик name, создавая его при первом обращении.
+func (r *Registry) Counter(name string) *Counter {
+	r.mu.RLock()
+	c, ok := r.counters[name]
+	r.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.counters[name]; ok {
+		return c
+	}
+	c = &Counter{}
+	r.counters[name] = c
+	return c
+}
+
+// Snapshot возвращает текущие значения всех счётчиков.
+func (r *Registry) Snapshot() map[string]int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]int64, len(r.counters))
+	for name, c := range r.counters {
+		out[name] = c.Value()
+	}
+	return out
+}
+
+// Stats - счётчики процесса, их отдаёт отладочный сервер (DEBUG_ADDR)
+// в /debug/stats.
+var Stats = NewRegistry()
+
+var (
+	// События Mattermost, принятые ботом по WebSocket или webhook
+	EventsReceived = Stats.Counter("bot_events_received_total")
+	// События из каналов, отброшенные фильтром каналов без разбора сообщения
+	EventsFiltered = Stats.Counter("bot_events_filtered_total")
+	// Команды из сообщений ботов и webhook, которые бот не выполнил
+	IntegrationPostsIgnored = Stats.Counter("bot_integration_posts_ignored_total")
+	// Обработчики событий, которые выполняются сейчас
+	HandlersInFlight = Stats.Counter("bot_handlers_in_flight")
+	// Запросы к Mattermost в очереди бота, включая отправляемые сейчас
+	OutboxDepth = Stats.Counter("bot_outbox_depth")
+	// Ответы Mattermost 429, после которых очередь приостанавливалась
+	OutboxRateLimited = Stats.Counter("bot_outbox_rate_limited_total")
+)
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест проверяет, что одновременные обращения к счётчику по имени
+// попадают в один счётчик
+func TestRegistry_Concurrent(t *testing.T) {
+	stats := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats.Counter("events").Add(1)
+			stats.Counter("in_flight").Add(1)
+			stats.Counter("in_flight").Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]int64{"events": 50, "in_flight": 0}, stats.Snapshot())
+}
+
+// Тест проверяет маршруты отладочного сервера: снимок счётчиков и pprof
+func TestDebugHandler(t *testing.T) {
+	stats := NewRegistry()
+	stats.Counter("repo_calls_total.GetPoll").Add(3)
+	h := DebugHandler(stats)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/stats", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var out debugStatsJSON
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, map[string]int64{"repo_calls_total.GetPoll": 3}, out.Counters)
+	assert.Positive(t, out.Goroutines)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine profile")
+}
+
+// Тест проверяет, что адрес без хоста слушается только на localhost
+func TestDebugListenAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{addr: ":6060", want: "localhost:6060"},
+		{addr: "0.0.0.0:6060", want: "0.0.0.0:6060"},
+		{addr: "127.0.0.1:6060", want: "127.0.0.1:6060"},
+		{addr: "6060", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			got, err := debugListenAddr(tt.addr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package mmclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// APIClient - Client поверх клиента REST API из библиотеки Mattermost.
+type APIClient struct {
+	client *model.Client4
+}
+
+func NewAPIClient(url, token string, timeout time.Duration) *APIClient {
+	client := model.NewAPIv4Client(url)
+	client.SetToken(token)
+	client.HTTPClient = &http.Client{Timeout: timeout}
+	return &APIClient{client: client}
+}
+
+// apiError переводит ошибку библиотеки в Error со статусом ответа.
+func apiError(resp *model.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+	mmErr := &Error{Err: err}
+	if resp != nil {
+		mmErr.StatusCode = resp.StatusCode
+		// Mattermost указывает паузу в секундах
+		if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+			mmErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return mmErr
+}
+
+func (c *APIClient) GetMe() (*User, error) {
+	user, resp, err := c.client.GetMe(context.Background(), "")
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return fromUser(user), nil
+}
+
+func (c *APIClient) GetUser(userID string) (*User, error) {
+	user, resp, err := c.client.GetUser(context.Background(), userID, "")
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return fromUser(user), nil
+}
+
+func (c *APIClient) GetUsersByIds(userIDs []string) ([]*User, error) {
+	users, resp, err := c.client.GetUsersByIds(context.Background(), userIDs)
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	out := make([]*User, 0, len(users))
+	for _, user := range users {
+		out = append(out, fromUser(user))
+	}
+	return out, nil
+}
+
+func (c *APIClient) GetUserByUsername(username string) (*User, error) {
+	user, resp, err := c.client.GetUserByUsername(context.Background(), username, "")
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return fromUser(user), nil
+}
+
+func (c *APIClient) CreateDirectChannel(userID1, userID2 string) (*Channel, error) {
+	channel, resp, err := c.client.CreateDirectChannel(context.Background(), userID1, userID2)
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return &Channel{ID: channel.Id, Type: string(channel.Type)}, nil
+}
+
+func (c *APIClient) GetChannelMember(channelID, userID string) (*ChannelMember, error) {
+	member, resp, err := c.client.GetChannelMember(context.Background(), channelID, userID, "")
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return &ChannelMember{ChannelID: member.ChannelId, UserID: member.UserId}, nil
+}
+
+func (c *APIClient) CreatePost(post *Post) (*Post, error) {
+	created, resp, err := c.client.CreatePost(context.Background(), toPost(post))
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return fromPost(created), nil
+}
+
+func (c *APIClient) CreatePostEphemeral(userID string, post *Post) (*Post, error) {
+	created, resp, err := c.client.CreatePostEphemeral(context.Background(), &model.PostEphemeral{UserID: userID, Post: toPost(post)})
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return fromPost(created), nil
+}
+
+func (c *APIClient) UpdatePost(post *Post) (*Post, error) {
+	updated, resp, err := c.client.UpdatePost(context.Background(), post.ID, toPost(post))
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return fromPost(updated), nil
+}
+
+func (c *APIClient) SaveReaction(reaction *Reaction) (*Reaction, error) {
+	saved, resp, err := c.client.SaveReaction(context.Background(), &model.Reaction{
+		UserId:    reaction.UserID,
+		PostId:    reaction.PostID,
+		EmojiName: reaction.EmojiName,
+	})
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return &Reaction{UserID: saved.UserId, PostID: saved.PostId, EmojiName: saved.EmojiName}, nil
+}
+
+func (c *APIClient) PinPost(postID string) error {
+	resp, err := c.client.PinPost(context.Background(), postID)
+	return apiError(resp, err)
+}
+
+func (c *APIClient) UnpinPost(postID string) error {
+	resp, err := c.client.UnpinPost(context.Background(), postID)
+	return apiError(resp, err)
+}
+
+func (c *APIClient) GetTeamsForUser(userID string) ([]*Team, error) {
+	teams, resp, err := c.client.GetTeamsForUser(context.Background(), userID, "")
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	out := make([]*Team, 0, len(teams))
+	for _, team := range teams {
+		out = append(out, &Team{ID: team.Id})
+	}
+	return out, nil
+}
+
+func fromUser(user *model.User) *User {
+	return &User{
+		ID:        user.Id,
+		Username:  user.Username,
+		Nickname:  user.Nickname,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Locale:    user.Locale,
+		IsBot:     user.IsBot,
+		// Деактивированный пользователь - с временем удаления
+		Deactivated: user.DeleteAt != 0,
+	}
+}
+
+func toPost(post *Post) *model.Post {
+	return &model.Post{
+		Id:        post.ID,
+		ChannelId: post.ChannelID,
+		UserId:    post.UserID,
+		Message:   post.Message,
+		EditAt:    post.EditAt,
+		Props:     post.Props,
+	}
+}
+
+func fromPost(post *model.Post) *Post {
+	return &Post{
+		ID:        post.Id,
+		ChannelID: post.ChannelId,
+		UserID:    post.UserId,
+		Message:   post.Message,
+		EditAt:    post.EditAt,
+		Props:     post.GetProps(),
+	}
+}
+
+// webSocket - WebSocket поверх клиента из библиотеки Mattermost.
+type webSocket struct {
+	client *model.WebSocketClient
+	events chan *WSEvent
+	// Закрывается в Close: forward больше не ждёт, пока события прочитают
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// DialWebSocket подключается к WebSocket Mattermost по адресу вида
+// ws://host[/path] и отправляет токен бота.
+func DialWebSocket(url, token string) (WebSocket, error) {
+	client, err := model.NewWebSocketClient4(url, token)
+	if err != nil {
+		return nil, err
+	}
+	return &webSocket{
+		client: client,
+		events: make(chan *WSEvent, cap(client.EventChannel)),
+		done:   make(chan struct{}),
+	}, nil
+}
+
+func (w *webSocket) Listen() {
+	w.client.Listen()
+	go w.forward()
+}
+
+func (w *webSocket) Close() {
+	w.closeOnce.Do(func() { close(w.done) })
+	w.client.Close()
+}
+
+func (w *webSocket) Events() <-chan *WSEvent {
+	return w.events
+}
+
+// forward передаёт события библиотеки в Events. Ответы на запросы и
+// сигналы пропущенного ping вычитываются и отбрасываются: библиотека
+// блокируется, если их не читать.
+func (w *webSocket) forward() {
+	defer close(w.events)
+	responses := w.client.ResponseChannel
+	for {
+		select {
+		case event, ok := <-w.client.EventChannel:
+			if !ok {
+				return
+			}
+			select {
+			case w.events <- fromEvent(event):
+			case <-w.done:
+				return
+			}
+		case _, ok := <-responses:
+			if !ok {
+				responses = nil
+			}
+		case <-w.client.PingTimeoutChannel:
+		}
+	}
+}
+
+// ParseWSEvent разбирает событие в том виде, в каком его присылает
+// сервер Mattermost.
+func ParseWSEvent(data []byte) (*WSEvent, error) {
+	event, err := model.WebSocketEventFromJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return fromEvent(event), nil
+}
+
+func fromEvent(event *model.WebSocketEvent) *WSEvent {
+	out := &WSEvent{Type: string(event.EventType()), Data: event.GetData()}
+	if broadcast := event.GetBroadcast(); broadcast != nil {
+		out.ChannelID = broadcast.ChannelId
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package mmclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест проверяет перевод ответов REST API в типы пакета: данные успешного
+// ответа, статус ответа с ошибкой и паузу из Retry-After
+func TestAPIClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.EqualFold("Bearer token1", r.Header.Get("Authorization")), "нет токена бота")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v4/users/me":
+			w.Write([]byte(`{"id":"bot123","username":"pollbot","locale":"en","is_bot":true}`))
+		case "POST /api/v4/posts":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"post1","channel_id":"c1","message":"Итоги"}`))
+		case "PUT /api/v4/posts/post1":
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"id":"api.context.rate_limit","message":"слишком много запросов","status_code":429}`))
+		case "POST /api/v4/posts/post1/pin":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"id":"api.context.permissions.app_error","message":"нет прав","status_code":403}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id":"app.user.missing_account.const","message":"не найден","status_code":404}`))
+		}
+	}))
+	defer srv.Close()
+	client := NewAPIClient(srv.URL, "token1", time.Second)
+
+	me, err := client.GetMe()
+	require.NoError(t, err)
+	assert.Equal(t, &User{ID: "bot123", Username: "pollbot", Locale: "en", IsBot: true}, me)
+
+	post, err := client.CreatePost(&Post{ChannelID: "c1", Message: "Итоги"})
+	require.NoError(t, err)
+	assert.Equal(t, &Post{ID: "post1", ChannelID: "c1", Message: "Итоги"}, post)
+
+	_, err = client.UpdatePost(&Post{ID: "post1", ChannelID: "c1", Message: "Итоги: 3"})
+	assert.Equal(t, http.StatusTooManyRequests, StatusCode(err))
+	assert.Equal(t, 3*time.Second, RetryAfter(err))
+
+	err = client.PinPost("post1")
+	assert.Equal(t, http.StatusForbidden, StatusCode(err))
+	assert.Contains(t, err.Error(), "нет прав")
+
+	_, err = client.GetUserByUsername("nobody")
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+	assert.Zero(t, RetryAfter(err))
+}
+
+// Тест проверяет, что у ошибки без ответа сервера статус 0
+func TestAPIClient_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	_, err := NewAPIClient(srv.URL, "token1", time.Second).GetMe()
+	require.Error(t, err)
+	assert.Zero(t, StatusCode(err))
+}
+
+// Тест проверяет, что события WebSocket доходят до Events, ответы сервера
+// на запросы не мешают им, а обрыв подключения закрывает Events
+func TestWebSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var challenge map[string]interface{}
+		if err := conn.ReadJSON(&challenge); err != nil {
+			return
+		}
+		assert.Equal(t, "authentication_challenge", challenge["action"])
+		for i := 0; i < 150; i++ {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"status":"OK","seq_reply":1}`))
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"event":"posted","data":{"post":"{}"},"broadcast":{"channel_id":"c1"},"seq":1}`))
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer srv.Close()
+
+	ws, err := DialWebSocket("ws"+strings.TrimPrefix(srv.URL, "http"), "token1")
+	require.NoError(t, err)
+	defer ws.Close()
+	ws.Listen()
+
+	select {
+	case event := <-ws.Events():
+		require.NotNil(t, event)
+		assert.Equal(t, &WSEvent{Type: EventPosted, ChannelID: "c1", Data: map[string]interface{}{"post": "{}"}}, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("событие не получено")
+	}
+	select {
+	case _, ok := <-ws.Events():
+		assert.False(t, ok, "после обрыва подключения событий нет")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Events не закрыт после обрыва подключения")
+	}
+}
+
+// Тест проверяет разбор события в том виде, в каком его присылает сервер
+func TestParseWSEvent(t *testing.T) {
+	event, err := ParseWSEvent([]byte(`{"event":"post_edited","data":{"post":"{}","team_id":"t1"},"broadcast":{"channel_id":"c1"},"seq":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, &WSEvent{Type: EventPostEdited, ChannelID: "c1", Data: map[string]interface{}{"post": "{}", "team_id": "t1"}}, event)
+
+	_, err = ParseWSEvent([]byte(`{"event":`))
+	assert.Error(t, err)
+}
+
+// Тест проверяет, что свойства сообщения из события разбираются и по ним
+// узнаются сообщения ботов и webhook
+func TestPost_FromIntegration(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want bool
+	}{
+		{name: "user post", json: `{"id":"p1","message":"!poll list"}`, want: false},
+		{name: "webhook", json: `{"id":"p1","props":{"from_webhook":"true","override_username":"ci"}}`, want: true},
+		{name: "bot", json: `{"id":"p1","props":{"from_bot":"true"}}`, want: true},
+		{name: "bool flag", json: `{"id":"p1","props":{"from_bot":true}}`, want: true},
+		{name: "flag off", json: `{"id":"p1","props":{"from_webhook":"false"}}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var post Post
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &post))
+			assert.Equal(t, tt.want, post.FromIntegration())
+		})
+	}
+}
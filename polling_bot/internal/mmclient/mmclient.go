@@ -0,0 +1,216 @@
+// Package mmclient описывает то, что бот использует из Mattermost: типы
+// сообщений, пользователей и событий и клиенты REST API и WebSocket.
+// Бот и его тесты зависят только от этих типов; с библиотекой Mattermost
+// работает одна реализация в mattermost.go.
+package mmclient
+
+import (
+	"errors"
+	"time"
+)
+
+// Типы событий WebSocket, которые обрабатывает бот
+const (
+	EventPosted     = "posted"
+	EventPostEdited = "post_edited"
+	// Участника добавили в канал: user_id в данных, канал - в адресатах
+	EventUserAdded = "user_added"
+)
+
+// Типы каналов
+const (
+	ChannelOpen    = "O"
+	ChannelPrivate = "P"
+	ChannelDirect  = "D"
+)
+
+// Post - сообщение в канале. Теги JSON совпадают с API Mattermost: в таком
+// виде сообщение приходит в событиях WebSocket.
+type Post struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+	Message   string `json:"message"`
+	// Время последней правки в миллисекундах; 0 - сообщение не правилось
+	EditAt int64 `json:"edit_at"`
+	// Дополнительные свойства сообщения, например вложения под PropAttachments
+	Props map[string]interface{} `json:"props,omitempty"`
+}
+
+// Свойство сообщения со списком вложений []*Attachment
+const PropAttachments = "attachments"
+
+// Свойства, которыми Mattermost отмечает сообщения входящих webhook и
+// ботов; значение - строка "true"
+const (
+	PropFromWebhook = "from_webhook"
+	PropFromBot     = "from_bot"
+)
+
+// FromIntegration сообщает, что сообщение опубликовал входящий webhook
+// или бот, а не пользователь.
+func (p *Post) FromIntegration() bool {
+	return propSet(p.Props[PropFromWebhook]) || propSet(p.Props[PropFromBot])
+}
+
+// propSet - флаг в свойствах сообщения: Mattermost пишет его строкой,
+// интеграции встречаются и с bool.
+func propSet(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+// Attachment - вложение сообщения в формате Slack, которое Mattermost
+// показывает карточкой с цветной полосой слева.
+type Attachment struct {
+	// Текст для клиентов, которые не показывают вложения
+	Fallback string             `json:"fallback,omitempty"`
+	Color    string             `json:"color,omitempty"`
+	Title    string             `json:"title,omitempty"`
+	Text     string             `json:"text,omitempty"`
+	Fields   []*AttachmentField `json:"fields,omitempty"`
+	Footer   string             `json:"footer,omitempty"`
+}
+
+// AttachmentField - поле вложения; короткие поля выводятся в две колонки.
+type AttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type User struct {
+	ID        string
+	Username  string
+	Nickname  string
+	FirstName string
+	LastName  string
+	Locale    string
+	IsBot     bool
+	// Учётная запись деактивирована администратором
+	Deactivated bool
+}
+
+type Channel struct {
+	ID   string
+	Type string
+}
+
+type ChannelMember struct {
+	ChannelID string
+	UserID    string
+}
+
+type Reaction struct {
+	UserID    string
+	PostID    string
+	EmojiName string
+}
+
+type Team struct {
+	ID string
+}
+
+// WSEvent - событие WebSocket.
+type WSEvent struct {
+	Type string
+	// Канал, участникам которого разослано событие; пусто, если событие
+	// разослано не по каналу
+	ChannelID string
+	Data      map[string]interface{}
+}
+
+// WebhookPayload - тело исходящего webhook Mattermost.
+type WebhookPayload struct {
+	Token       string `json:"token"`
+	TeamID      string `json:"team_id"`
+	TeamDomain  string `json:"team_domain"`
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	Timestamp   int64  `json:"timestamp"`
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	PostID      string `json:"post_id"`
+	Text        string `json:"text"`
+	TriggerWord string `json:"trigger_word"`
+	FileIDs     string `json:"file_ids"`
+}
+
+// WebhookResponse - ответ на исходящий webhook, Mattermost публикует его
+// в канале.
+type WebhookResponse struct {
+	Text  *string                `json:"text"`
+	Props map[string]interface{} `json:"props,omitempty"`
+}
+
+// Client - запросы бота к REST API Mattermost.
+type Client interface {
+	GetMe() (*User, error)
+	GetUser(userID string) (*User, error)
+	// Пользователи по списку ID; несуществующих в ответе нет
+	GetUsersByIds(userIDs []string) ([]*User, error)
+	GetUserByUsername(username string) (*User, error)
+	CreateDirectChannel(userID1, userID2 string) (*Channel, error)
+	GetChannelMember(channelID, userID string) (*ChannelMember, error)
+	CreatePost(post *Post) (*Post, error)
+	// Сообщение, которое видит только пользователь userID
+	CreatePostEphemeral(userID string, post *Post) (*Post, error)
+	// Заменяет текст сообщения post.ID
+	UpdatePost(post *Post) (*Post, error)
+	SaveReaction(reaction *Reaction) (*Reaction, error)
+	PinPost(postID string) error
+	UnpinPost(postID string) error
+	GetTeamsForUser(userID string) ([]*Team, error)
+}
+
+// WebSocket - подключение к WebSocket Mattermost. Events закрывается,
+// когда подключение обрывается или закрывается.
+type WebSocket interface {
+	Listen()
+	Close()
+	Events() <-chan *WSEvent
+}
+
+// Error - ошибка запроса к Mattermost.
+type Error struct {
+	// HTTP-статус ответа; 0, если ответа не было
+	StatusCode int
+	// Через сколько Mattermost разрешает повторить запрос после ответа 429;
+	// 0, если ответ не ограничивал частоту запросов
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode возвращает HTTP-статус ответа Mattermost с ошибкой err или 0,
+// если err - не ошибка запроса или ответа не было.
+func StatusCode(err error) int {
+	var mmErr *Error
+	if errors.As(err, &mmErr) {
+		return mmErr.StatusCode
+	}
+	return 0
+}
+
+// RetryAfter возвращает паузу из заголовка Retry-After ответа Mattermost
+// с ошибкой err или 0, если её нет.
+func RetryAfter(err error) time.Duration {
+	var mmErr *Error
+	if errors.As(err, &mmErr) {
+		return mmErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ChannelSettings - настройки бота в канале Mattermost, которые задают
+// участники канала.
+type ChannelSettings struct {
+	ChannelID string
+	// Публиковать в канале ежедневную сводку открытых опросов
+	Digest bool
+	// Кто и когда последним менял настройки
+	UpdatedBy string
+	UpdatedAt time.Time
+}
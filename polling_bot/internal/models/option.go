@@ -0,0 +1,52 @@
+package models
+
+import "sort"
+
+// Option - вариант опроса вместе с его метаданными. Счётчики и эмодзи
+// хранятся в опросе по тексту варианта (Options, OptionEmoji): голоса по
+// тексту атомарно учитывает хранилище, а Option собирается при чтении.
+type Option struct {
+	// Номер варианта в порядке создания, начиная с 1; по нему можно голосовать
+	ID   int
+	Text string
+	// Эмодзи перед текстом; пусто - без эмодзи
+	Emoji string
+	// Голоса за вариант; в рейтинговом опросе - первые предпочтения
+	Votes int
+}
+
+// OptionList возвращает варианты опроса в порядке создания. Варианты,
+// которых нет в OptionOrder (опросы старых версий), идут следом по алфавиту.
+func (p Poll) OptionList() []Option {
+	order := make([]string, 0, len(p.Options))
+	seen := make(map[string]bool, len(p.Options))
+	for _, text := range p.OptionOrder {
+		if _, ok := p.Options[text]; ok && !seen[text] {
+			order = append(order, text)
+			seen[text] = true
+		}
+	}
+	var rest []string
+	for text := range p.Options {
+		if !seen[text] {
+			rest = append(rest, text)
+		}
+	}
+	sort.Strings(rest)
+	order = append(order, rest...)
+
+	options := make([]Option, len(order))
+	for i, text := range order {
+		options[i] = Option{ID: i + 1, Text: text, Emoji: p.OptionEmoji[text], Votes: p.Options[text]}
+	}
+	return options
+}
+
+// OptionByID возвращает вариант с номером id.
+func (p Poll) OptionByID(id int) (Option, bool) {
+	options := p.OptionList()
+	if id < 1 || id > len(options) {
+		return Option{}, false
+	}
+	return options[id-1], true
+}
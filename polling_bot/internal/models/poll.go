@@ -1,10 +1,152 @@
 package models
 
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
 type Poll struct {
-	ID        string
-	Creator   string
-	Question  string
-	Voters    map[string]bool
+	ID       string
+	Creator  string
+	Question string
+	// Пояснение к вопросу, может занимать несколько строк; пусто - без пояснения
+	Description string
+	// Метки опроса в нижнем регистре, по ним фильтруется список опросов
+	Tags []string
+	// Число голосов за вариант; голоса участников хранятся отдельно, см. Vote
 	Options   map[string]int
 	Closed    bool
+	ChannelID string
+	CreatedAt time.Time
+	// Голосовать и смотреть результаты можно только из канала опроса
+	RestrictToChannel bool
+	// Число проголосовавших, после которого опрос закрывается; 0 - без кворума
+	Quorum int
+	// Рейтинговый опрос: голос - упорядоченный список вариантов
+	Ranked bool
+	// Варианты в порядке создания
+	OptionOrder []string
+	// Эмодзи вариантов по тексту; варианта без эмодзи здесь нет
+	OptionEmoji map[string]string
+	// Участник, выбранный командой winner
+	Winner string
+	// Закреплённое сообщение опроса в канале; пусто - опрос не закреплён
+	PinnedPostID string
+	// Разослать участникам итоги в личные сообщения, когда опрос закроется
+	NotifyVoters bool
+	// Когда опрос закроется автоматически; нулевое время - без срока
+	ExpiresAt time.Time
+	// Канал предупреждён о скором закрытии опроса по сроку
+	ExpiryWarned bool
+	// Вес голоса участника по ID; участник не из списка голосует с весом 1.
+	// Пусто - опрос без весов
+	Weights map[string]int
+	// Сумма весов голосов за вариант, только в опросе с весами
+	WeightedOptions map[string]int
+	// Участник может воздержаться: он учитывается в кворуме, но не в
+	// счётчиках вариантов
+	AllowAbstain bool
+	// Число воздержавшихся
+	Abstained int
+	// Число голосов, набрав которое опрос закрывается и больше голосов не
+	// принимает; 0 - без ограничения
+	MaxVotes int
+	// Присылать участнику квитанцию о голосе в личные сообщения
+	VoteReceipts bool
+	// Создатель не может голосовать в своём опросе
+	NoSelfVote bool
+	// Соль анонимного опроса, выбирается при создании: голоса хранятся
+	// под VoterKey, а не под ID участников. Соль не секретна, она лишь
+	// разводит ключи одного участника в разных опросах. Пусто - опрос не
+	// анонимный
+	VoterSalt string
+	// Голосовать могут только участники канала опроса
+	MembersOnly bool
+	// Сколько раз смотрели итоги опроса; повторный просмотр участника в
+	// течение часа не учитывается
+	Views int
+	// Опрос отмечен администратором: в списках он показывается первым
+	Featured bool
+	// ID участников, которым разрешено голосовать; пусто - голосовать
+	// может любой
+	AllowedVoters []string
+}
+
+// VoterCount - число проголосовавших: каждый учтён ровно в одном счётчике,
+// варианта первого выбора или воздержавшихся.
+func (p Poll) VoterCount() int {
+	n := p.Abstained
+	for _, count := range p.Options {
+		n += count
+	}
+	return n
+}
+
+// Full сообщает, что опрос набрал максимум голосов.
+func (p Poll) Full() bool {
+	return p.MaxVotes > 0 && p.VoterCount() >= p.MaxVotes
+}
+
+// Restricted сообщает, что голосовать могут только участники из списка.
+func (p Poll) Restricted() bool {
+	return len(p.AllowedVoters) > 0
+}
+
+// ExpectedVoters - сколько голосов ждёт опрос со списком участников:
+// кворум, а без него - весь список; 0 - опрос без списка.
+func (p Poll) ExpectedVoters() int {
+	if !p.Restricted() {
+		return 0
+	}
+	if p.Quorum > 0 {
+		return p.Quorum
+	}
+	return len(p.AllowedVoters)
+}
+
+// Weighted сообщает, что голоса опроса учитываются с весами.
+func (p Poll) Weighted() bool {
+	return len(p.Weights) > 0
+}
+
+// Anonymous сообщает, что голоса опроса хранятся без ID участников.
+func (p Poll) Anonymous() bool {
+	return p.VoterSalt != ""
+}
+
+// VoterKey - под каким ID хранится голос участника userID. В анонимном
+// опросе это HMAC-SHA256 с ключом бота secret от соли опроса и ID
+// участника: по нему находится повторный голос и голос самого участника.
+// Ключ бота в хранилище не попадает, поэтому перебором ID пользователей
+// по данным хранилища голос участника не найти.
+func (p Poll) VoterKey(secret, userID string) string {
+	return p.saltedKey(secret, "user", userID)
+}
+
+// PostKey - под каким ID хранится сообщение, которым подан голос: по ID
+// сообщения Mattermost называет его автора.
+func (p Poll) PostKey(secret, postID string) string {
+	if postID == "" {
+		return ""
+	}
+	return p.saltedKey(secret, "post", postID)
+}
+
+func (p Poll) saltedKey(secret, kind, id string) string {
+	if !p.Anonymous() {
+		return id
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(p.VoterSalt + "/" + kind + "/" + id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VoteWeight - вес голоса участника в опросе.
+func (p Poll) VoteWeight(userID string) int {
+	if weight, ok := p.Weights[userID]; ok {
+		return weight
+	}
+	return 1
 }
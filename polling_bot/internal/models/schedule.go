@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Schedule - повторяющийся опрос: по cron-выражению бот создаёт опрос
+// из шаблона и публикует его в канале расписания.
+type Schedule struct {
+	ID        string
+	Creator   string
+	ChannelID string
+	// Пять полей: минута, час, день месяца, месяц, день недели
+	Cron     string
+	Question string
+	// Варианты в порядке создания
+	Options []string
+	// Флаги создаваемых опросов
+	RestrictToChannel bool
+	Quorum            int
+	Ranked            bool
+	Exclusive         bool
+	CreatedAt         time.Time
+	// Минута последнего запуска; нулевое время - ещё не запускалось
+	LastRunAt time.Time
+	// Когда бот не смог опубликовать опрос, потому что не может писать в
+	// канал; такое расписание больше не запускается. Нулевое время - канал доступен
+	UnreachableAt time.Time
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Template - сохранённое описание опроса: команда create-from создаёт по
+// нему новые опросы. Имя уникально в области шаблона.
+type Template struct {
+	// Область шаблона: личная область создателя или область канала,
+	// см. UserTemplateScope и ChannelTemplateScope
+	Scope string
+	// Имя в нижнем регистре
+	Name     string
+	Creator  string
+	Question string
+	// Варианты в порядке создания
+	Options []string
+	// Настройки создаваемых опросов, как у флагов команды create
+	Description       string
+	Tags              []string
+	RestrictToChannel bool
+	Quorum            int
+	Ranked            bool
+	Exclusive         bool
+	Pin               bool
+	NotifyVoters      bool
+	// Срок создаваемых опросов; 0 - срок по умолчанию
+	Expires   time.Duration
+	NoExpire  bool
+	CreatedAt time.Time
+}
+
+// Префиксы области шаблона: ID пользователя и канала Mattermost не
+// пересекаются, но префикс делает область явной в хранилище
+const (
+	userTemplateScope    = "user:"
+	channelTemplateScope = "channel:"
+)
+
+// UserTemplateScope - область личных шаблонов пользователя.
+func UserTemplateScope(userID string) string {
+	return userTemplateScope + userID
+}
+
+// ChannelTemplateScope - область шаблонов, общих для канала.
+func ChannelTemplateScope(channelID string) string {
+	return channelTemplateScope + channelID
+}
+
+// ChannelScoped сообщает, что шаблон общий для канала.
+func (t Template) ChannelScoped() bool {
+	return strings.HasPrefix(t.Scope, channelTemplateScope)
+}
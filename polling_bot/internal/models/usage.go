@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// CommandUsage - сколько раз за день выполнялась команда бота.
+type CommandUsage struct {
+	// Начало дня по UTC
+	Day     time.Time
+	Command string
+	Count   int
+}
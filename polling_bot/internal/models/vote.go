@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Vote - голос участника опроса.
+type Vote struct {
+	PollID string
+	UserID string
+	// Выбранные варианты, в рейтинговом опросе по убыванию предпочтения.
+	// У голосов, поданных до появления бюллетеней, пуст
+	Choices []string
+	// Когда подан голос; нулевое время у голосов, перенесённых из опросов
+	VotedAt time.Time
+	// ID сообщения, которым подан голос: по нему узнаётся повторная
+	// доставка того же сообщения. Пуст у голосов не из чата
+	PostID string
+	// Вес, с которым голос учтён в счётчиках опроса с весами: отмена голоса
+	// должна вычесть столько же. 0 - голос в опросе без весов
+	Weight int
+	// Участник воздержался: Choices пуст, голос не учтён в счётчиках
+	// вариантов и может быть заменён настоящим голосом
+	Abstain bool
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/audit"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// AuditRepository хранит журнал событий опросов. Записи только добавляются.
+type AuditRepository interface {
+	AppendEvent(ctx context.Context, event audit.Event) error
+	// ListEvents возвращает не больше limit последних событий опроса
+	// в хронологическом порядке.
+	ListEvents(ctx context.Context, pollID string, limit int) ([]audit.Event, error)
+}
+
+type TarantoolAuditRepo struct {
+	conn      Connector
+	spaceName string
+}
+
+func NewTarantoolAuditRepo(conn Connector, spaceName string) *TarantoolAuditRepo {
+	return &TarantoolAuditRepo{conn: conn, spaceName: spaceName}
+}
+
+// AppendEvent использует insert, а не replace: повтор ID - ошибка,
+// а не перезапись события.
+func (r *TarantoolAuditRepo) AppendEvent(ctx context.Context, event audit.Event) error {
+	if err := connReady(ctx, r.conn); err != nil {
+		return err
+	}
+
+	if _, err := r.conn.Insert(ctx, r.spaceName, newAuditTuple(event)); err != nil {
+		return fmt.Errorf("ошибка записи в журнал: %w", classifyError(err))
+	}
+	return nil
+}
+
+// ListEvents читает индекс (poll_id, at) с конца и разворачивает выборку.
+func (r *TarantoolAuditRepo) ListEvents(ctx context.Context, pollID string, limit int) ([]audit.Event, error) {
+	if err := connReady(ctx, r.conn); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	var tuples []auditTuple
+	err := r.conn.SelectTyped(ctx, r.spaceName, "poll", 0, uint32(limit), tarantool.IterReq, []interface{}{pollID}, &tuples)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала: %w", classifyError(err))
+	}
+	out := make([]audit.Event, len(tuples))
+	for i, t := range tuples {
+		out[len(tuples)-1-i] = t.toModel()
+	}
+	return out, nil
+}
+
+// auditTuple описывает раскладку события в space Tarantool.
+// Порядок полей должен точно соответствовать формату space в init.lua.
+type auditTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	ID     string // field 1: id (string)
+	PollID string // field 2: poll_id (string)
+	Actor  string // field 3: actor (string)
+	Action string // field 4: action (string)
+	Detail string // field 5: detail (string)
+	At     int64  // field 6: at (unsigned, unix-время в наносекундах)
+}
+
+func newAuditTuple(event audit.Event) auditTuple {
+	return auditTuple{
+		ID:     event.ID,
+		PollID: event.PollID,
+		Actor:  event.Actor,
+		Action: string(event.Action),
+		Detail: event.Detail,
+		At:     event.At.UnixNano(),
+	}
+}
+
+func (t auditTuple) toModel() audit.Event {
+	return audit.Event{
+		ID:     t.ID,
+		PollID: t.PollID,
+		Actor:  t.Actor,
+		Action: audit.Action(t.Action),
+		Detail: t.Detail,
+		At:     time.Unix(0, t.At).UTC(),
+	}
+}
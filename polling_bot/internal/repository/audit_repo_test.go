@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// fakeAuditConn - упрощённый fakeConn для space журнала: поддерживает
+// insert и обратный обход индекса poll.
+type fakeAuditConn struct {
+	mu        sync.Mutex
+	connected bool
+	tuples    map[string]auditTuple
+}
+
+func newFakeAuditConn() *fakeAuditConn {
+	return &fakeAuditConn{connected: true, tuples: make(map[string]auditTuple)}
+}
+
+func (f *fakeAuditConn) ConnectedNow() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected
+}
+
+func (f *fakeAuditConn) Insert(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := msgpack.Marshal(tuple)
+	if err != nil {
+		return nil, err
+	}
+	var t auditTuple
+	if err := msgpack.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	if _, dup := f.tuples[t.ID]; dup {
+		return nil, tarantool.Error{Code: tarantool.ErrTupleFound, Msg: "Duplicate key exists"}
+	}
+	f.tuples[t.ID] = t
+	return &tarantool.Response{Data: []interface{}{t}}, nil
+}
+
+func (f *fakeAuditConn) Replace(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeAuditConn: Replace не используется")
+}
+
+func (f *fakeAuditConn) Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeAuditConn: Update не используется")
+}
+
+func (f *fakeAuditConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeAuditConn: Call17 не используется")
+}
+
+func (f *fakeAuditConn) Delete(ctx context.Context, space, index interface{}, key interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeAuditConn: Delete не используется")
+}
+
+func (f *fakeAuditConn) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if index != "poll" || iterator != tarantool.IterReq {
+		return fmt.Errorf("fakeAuditConn: выборка %v/%d не поддерживается", index, iterator)
+	}
+	pollID := key.([]interface{})[0].(string)
+
+	var matched []auditTuple
+	for _, t := range f.tuples {
+		if t.PollID == pollID {
+			matched = append(matched, t)
+		}
+	}
+	// Индекс (poll_id, at) с неявным хвостом из первичного ключа, обход с конца
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].At != matched[j].At {
+			return matched[i].At > matched[j].At
+		}
+		return matched[i].ID > matched[j].ID
+	})
+	if uint32(len(matched)) > limit {
+		matched = matched[:limit]
+	}
+	*result.(*[]auditTuple) = matched
+	return nil
+}
+
+// extraAuditRepos - аналог extraRepos для журнала.
+var extraAuditRepos = map[string]func(t *testing.T) AuditRepository{}
+
+func auditRepos() map[string]func(t *testing.T) AuditRepository {
+	repos := map[string]func(t *testing.T) AuditRepository{
+		"memory": func(*testing.T) AuditRepository { return NewMemoryAuditRepo() },
+		"tarantool": func(*testing.T) AuditRepository {
+			return NewTarantoolAuditRepo(newFakeAuditConn(), "poll_audit")
+		},
+	}
+	for name, newRepo := range extraAuditRepos {
+		repos[name] = newRepo
+	}
+	return repos
+}
+
+func testEvent(id, pollID string, action audit.Action, at time.Time) audit.Event {
+	return audit.Event{ID: id, PollID: pollID, Actor: "user1", Action: action, Detail: "Пицца", At: at}
+}
+
+// Тест проверяет запись и чтение последних событий опроса
+func TestAuditRepo_AppendAndList(t *testing.T) {
+	for name, newRepo := range auditRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+			var want []audit.Event
+			for i := 0; i < 5; i++ {
+				event := testEvent(fmt.Sprintf("e%d", i), "p1", audit.ActionVoted, start.Add(time.Duration(i)*time.Second))
+				require.NoError(t, repo.AppendEvent(ctx, event))
+				want = append(want, event)
+				// События другого опроса не должны попадать в выборку
+				require.NoError(t, repo.AppendEvent(ctx, testEvent(fmt.Sprintf("x%d", i), "p2", audit.ActionCreated, event.At)))
+			}
+
+			got, err := repo.ListEvents(ctx, "p1", 10)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			got, err = repo.ListEvents(ctx, "p1", 2)
+			require.NoError(t, err)
+			assert.Equal(t, want[3:], got)
+
+			got, err = repo.ListEvents(ctx, "p3", 10)
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
+
+// Тест проверяет, что записанное событие нельзя перезаписать
+func TestAuditRepo_Immutable(t *testing.T) {
+	for name, newRepo := range auditRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+			original := testEvent("e1", "p1", audit.ActionCreated, at)
+			require.NoError(t, repo.AppendEvent(ctx, original))
+			assert.Error(t, repo.AppendEvent(ctx, testEvent("e1", "p1", audit.ActionDeleted, at)))
+
+			got, err := repo.ListEvents(ctx, "p1", 10)
+			require.NoError(t, err)
+			assert.Equal(t, []audit.Event{original}, got)
+		})
+	}
+}
+
+// Тест проверяет, что при потере соединения с Tarantool возвращается ErrUnavailable
+func TestTarantoolAuditRepo_Unavailable(t *testing.T) {
+	conn := newFakeAuditConn()
+	conn.connected = false
+	repo := NewTarantoolAuditRepo(conn, "poll_audit")
+
+	_, err := repo.ListEvents(context.Background(), "p1", 10)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, repo.AppendEvent(context.Background(), testEvent("e1", "p1", audit.ActionCreated, time.Now())), ErrUnavailable)
+}
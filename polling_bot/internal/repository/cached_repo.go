@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/models"
+)
+
+// CachedRepo кэширует результаты GetPoll поверх другого репозитория.
+// Записи живут не дольше ttl, кэш хранит не более size опросов и вытесняет
+// давно не читавшиеся.
+//
+// Любая запись сбрасывает опрос из кэша до и после обращения к хранилищу,
+// а чтение, начавшееся до записи, не может положить в кэш старую версию:
+// за этим следит счётчик поколений незавершённых чтений ключа. Поэтому проверка "уже голосовал"
+// в этом процессе не видит устаревших данных. Изменения, сделанные другими
+// экземплярами бота, становятся видны не позже чем через ttl.
+type CachedRepo struct {
+	inner PollRepository
+	ttl   time.Duration
+	size  int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	pending map[string]*pendingRead
+
+	now func() time.Time
+}
+
+// pendingRead существует, пока хотя бы одно чтение ключа из хранилища
+// не завершилось; generation растёт при каждой записи этого ключа.
+type pendingRead struct {
+	generation uint64
+	readers    int
+}
+
+type cacheEntry struct {
+	id        string
+	poll      models.Poll
+	expiresAt time.Time
+}
+
+func NewCachedRepo(inner PollRepository, ttl time.Duration, size int) *CachedRepo {
+	return &CachedRepo{
+		inner:   inner,
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		pending: make(map[string]*pendingRead),
+		now:     time.Now,
+	}
+}
+
+func (r *CachedRepo) SavePoll(ctx context.Context, poll models.Poll) error {
+	r.invalidate(poll.ID)
+	defer r.invalidate(poll.ID)
+	return r.inner.SavePoll(ctx, poll)
+}
+
+func (r *CachedRepo) IncrementOption(ctx context.Context, pollID, option string, delta int) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.IncrementOption(ctx, pollID, option, delta)
+}
+
+func (r *CachedRepo) IncrementViews(ctx context.Context, pollID string) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.IncrementViews(ctx, pollID)
+}
+
+// Votes оборачивает репозиторий голосов так, чтобы голос, меняющий
+// счётчики опроса, сбрасывал его из кэша.
+func (r *CachedRepo) Votes(inner VoteRepository) VoteRepository {
+	return &cachedVotes{VoteRepository: inner, cache: r}
+}
+
+type cachedVotes struct {
+	VoteRepository
+	cache *CachedRepo
+}
+
+func (v *cachedVotes) AddVote(ctx context.Context, vote models.Vote) (bool, error) {
+	v.cache.invalidate(vote.PollID)
+	defer v.cache.invalidate(vote.PollID)
+	return v.VoteRepository.AddVote(ctx, vote)
+}
+
+func (r *CachedRepo) GetPoll(ctx context.Context, id string) (models.Poll, error) {
+	return r.get(ctx, id, r.inner.GetPoll)
+}
+
+// GetPollSummary делит кэш с GetPoll: опрос из сводки ничем не отличается
+// от прочитанного полностью.
+func (r *CachedRepo) GetPollSummary(ctx context.Context, id string) (models.Poll, error) {
+	return r.get(ctx, id, r.inner.GetPollSummary)
+}
+
+// get отдаёт опрос из кэша, а при промахе читает его load.
+func (r *CachedRepo) get(ctx context.Context, id string, load func(ctx context.Context, id string) (models.Poll, error)) (models.Poll, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Poll{}, err
+	}
+
+	r.mu.Lock()
+	if elem, ok := r.entries[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if r.now().Before(entry.expiresAt) {
+			r.lru.MoveToFront(elem)
+			poll := clonePoll(entry.poll)
+			r.mu.Unlock()
+			metrics.PollCacheHits.Add(1)
+			return poll, nil
+		}
+		r.removeLocked(elem)
+	}
+	read, ok := r.pending[id]
+	if !ok {
+		read = &pendingRead{}
+		r.pending[id] = read
+	}
+	read.readers++
+	generation := read.generation
+	r.mu.Unlock()
+
+	metrics.PollCacheMisses.Add(1)
+	poll, err := load(ctx, id)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if read.readers--; read.readers == 0 {
+		delete(r.pending, id)
+	}
+	if err != nil {
+		return models.Poll{}, err
+	}
+	if read.generation == generation {
+		r.storeLocked(poll)
+	}
+	return poll, nil
+}
+
+// GetPolls отдаёт из кэша то, что в нём есть, а остальные опросы читает
+// одним запросом и кладёт в кэш так же, как get.
+func (r *CachedRepo) GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	polls := make(map[string]models.Poll, len(ids))
+	var missing []string
+	generations := make(map[string]uint64)
+	r.mu.Lock()
+	for _, id := range ids {
+		if _, ok := polls[id]; ok {
+			continue
+		}
+		if _, ok := generations[id]; ok {
+			continue
+		}
+		if elem, ok := r.entries[id]; ok {
+			entry := elem.Value.(*cacheEntry)
+			if r.now().Before(entry.expiresAt) {
+				r.lru.MoveToFront(elem)
+				polls[id] = clonePoll(entry.poll)
+				continue
+			}
+			r.removeLocked(elem)
+		}
+		read, ok := r.pending[id]
+		if !ok {
+			read = &pendingRead{}
+			r.pending[id] = read
+		}
+		read.readers++
+		generations[id] = read.generation
+		missing = append(missing, id)
+	}
+	r.mu.Unlock()
+	metrics.PollCacheHits.Add(int64(len(polls)))
+	if len(missing) == 0 {
+		return polls, nil
+	}
+
+	metrics.PollCacheMisses.Add(int64(len(missing)))
+	loaded, err := r.inner.GetPolls(ctx, missing)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range missing {
+		read := r.pending[id]
+		if read.readers--; read.readers == 0 {
+			delete(r.pending, id)
+		}
+		poll, ok := loaded[id]
+		if err != nil || !ok {
+			continue
+		}
+		polls[id] = poll
+		if read.generation == generations[id] {
+			r.storeLocked(poll)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return polls, nil
+}
+
+func (r *CachedRepo) ClosePoll(ctx context.Context, pollID string) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.ClosePoll(ctx, pollID)
+}
+
+func (r *CachedRepo) SetCreator(ctx context.Context, pollID, creator string) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.SetCreator(ctx, pollID, creator)
+}
+
+func (r *CachedRepo) SetPinnedPost(ctx context.Context, pollID, postID string) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.SetPinnedPost(ctx, pollID, postID)
+}
+
+func (r *CachedRepo) SetExpiryWarned(ctx context.Context, pollID string) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.SetExpiryWarned(ctx, pollID)
+}
+
+func (r *CachedRepo) SetFeatured(ctx context.Context, pollID string, featured bool) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.SetFeatured(ctx, pollID, featured)
+}
+
+func (r *CachedRepo) SetAllowedVoters(ctx context.Context, pollID string, voters []string) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.SetAllowedVoters(ctx, pollID, voters)
+}
+
+func (r *CachedRepo) UpdateSettings(ctx context.Context, pollID string, update SettingsUpdate) error {
+	r.invalidate(pollID)
+	defer r.invalidate(pollID)
+	return r.inner.UpdateSettings(ctx, pollID, update)
+}
+
+func (r *CachedRepo) DeletePoll(ctx context.Context, id string) error {
+	r.invalidate(id)
+	defer r.invalidate(id)
+	return r.inner.DeletePoll(ctx, id)
+}
+
+// ListPolls не кэшируется: страницы зависят от фильтра и курсора.
+func (r *CachedRepo) ListPolls(ctx context.Context, filter ListFilter) ([]models.Poll, string, error) {
+	return r.inner.ListPolls(ctx, filter)
+}
+
+// CountPolls не кэшируется, как и ListPolls.
+func (r *CachedRepo) CountPolls(ctx context.Context, filter ListFilter) (int, error) {
+	return r.inner.CountPolls(ctx, filter)
+}
+
+func (r *CachedRepo) invalidate(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if read, ok := r.pending[id]; ok {
+		read.generation++
+	}
+	if elem, ok := r.entries[id]; ok {
+		r.removeLocked(elem)
+	}
+}
+
+func (r *CachedRepo) storeLocked(poll models.Poll) {
+	if r.size <= 0 || r.ttl <= 0 {
+		return
+	}
+
+	entry := &cacheEntry{id: poll.ID, poll: clonePoll(poll), expiresAt: r.now().Add(r.ttl)}
+	if elem, ok := r.entries[poll.ID]; ok {
+		elem.Value = entry
+		r.lru.MoveToFront(elem)
+		return
+	}
+	r.entries[poll.ID] = r.lru.PushFront(entry)
+
+	for r.lru.Len() > r.size {
+		r.removeLocked(r.lru.Back())
+	}
+}
+
+func (r *CachedRepo) removeLocked(elem *list.Element) {
+	entry := r.lru.Remove(elem).(*cacheEntry)
+	delete(r.entries, entry.id)
+}
@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepo считает чтения опросов и позволяет задержать GetPoll
+type countingRepo struct {
+	PollRepository
+	gets      atomic.Int64
+	summaries atomic.Int64
+	bulk      atomic.Int64
+	getHook   func()
+}
+
+func (r *countingRepo) GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error) {
+	r.bulk.Add(1)
+	return r.PollRepository.GetPolls(ctx, ids)
+}
+
+func (r *countingRepo) GetPollSummary(ctx context.Context, id string) (models.Poll, error) {
+	r.summaries.Add(1)
+	return r.PollRepository.GetPollSummary(ctx, id)
+}
+
+func (r *countingRepo) GetPoll(ctx context.Context, id string) (models.Poll, error) {
+	r.gets.Add(1)
+	if r.getHook != nil {
+		r.getHook()
+	}
+	return r.PollRepository.GetPoll(ctx, id)
+}
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newCachedTestRepo(t *testing.T, ttl time.Duration, size int) (*CachedRepo, *countingRepo, *fakeClock) {
+	t.Helper()
+	inner := &countingRepo{PollRepository: NewMemoryPollRepo()}
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	repo := NewCachedRepo(inner, ttl, size)
+	repo.now = clock.now
+	return repo, inner, clock
+}
+
+// votesOf возвращает репозиторий голосов поверх того же хранилища, что и
+// newCachedTestRepo, с его сбросом кэша.
+func votesOf(repo *CachedRepo, inner *countingRepo) VoteRepository {
+	return repo.Votes(NewMemoryVoteRepo(inner.PollRepository.(*MemoryPollRepo)))
+}
+
+// Тест проверяет попадания в кэш и истечение TTL
+func TestCachedRepo_HitAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	repo, inner, clock := newCachedTestRepo(t, time.Second, 10)
+	require.NoError(t, repo.SavePoll(ctx, testPoll("p1")))
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.GetPoll(ctx, "p1")
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, inner.gets.Load())
+
+	clock.t = clock.t.Add(time.Second)
+	_, err := repo.GetPoll(ctx, "p1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, inner.gets.Load(), "устаревшая запись перечитывается")
+}
+
+// Тест проверяет сброс кэша каждой операцией записи
+func TestCachedRepo_InvalidatesOnWrite(t *testing.T) {
+	writes := map[string]func(repo *CachedRepo, inner *countingRepo, poll models.Poll) error{
+		"SavePoll": func(repo *CachedRepo, _ *countingRepo, poll models.Poll) error {
+			poll.Question = "Изменён?"
+			return repo.SavePoll(context.Background(), poll)
+		},
+		"AddVote": func(repo *CachedRepo, inner *countingRepo, poll models.Poll) error {
+			_, err := votesOf(repo, inner).AddVote(context.Background(), testVote(poll.ID, "user2"))
+			return err
+		},
+		"IncrementOption": func(repo *CachedRepo, _ *countingRepo, poll models.Poll) error {
+			return repo.IncrementOption(context.Background(), poll.ID, "Да", 1)
+		},
+		"IncrementViews": func(repo *CachedRepo, _ *countingRepo, poll models.Poll) error {
+			return repo.IncrementViews(context.Background(), poll.ID)
+		},
+		"ClosePoll": func(repo *CachedRepo, _ *countingRepo, poll models.Poll) error {
+			return repo.ClosePoll(context.Background(), poll.ID)
+		},
+	}
+
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, inner, _ := newCachedTestRepo(t, time.Hour, 10)
+			require.NoError(t, repo.SavePoll(ctx, testPoll("p1")))
+
+			before, err := repo.GetPoll(ctx, "p1")
+			require.NoError(t, err)
+			require.NoError(t, write(repo, inner, clonePoll(before)))
+
+			after, err := repo.GetPoll(ctx, "p1")
+			require.NoError(t, err)
+			assert.NotEqual(t, before, after)
+		})
+	}
+
+	t.Run("DeletePoll", func(t *testing.T) {
+		ctx := context.Background()
+		repo, _, _ := newCachedTestRepo(t, time.Hour, 10)
+		require.NoError(t, repo.SavePoll(ctx, testPoll("p1")))
+		_, err := repo.GetPoll(ctx, "p1")
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeletePoll(ctx, "p1"))
+		_, err = repo.GetPoll(ctx, "p1")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+// Тест проверяет, что чтение, начавшееся до записи, не кладёт в кэш старую версию
+func TestCachedRepo_RacingReadDoesNotCacheStale(t *testing.T) {
+	ctx := context.Background()
+	repo, inner, _ := newCachedTestRepo(t, time.Hour, 10)
+	require.NoError(t, repo.SavePoll(ctx, testPoll("p1")))
+
+	fetched, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	inner.getHook = func() {
+		once.Do(func() {
+			close(fetched)
+			<-release
+		})
+	}
+
+	done := make(chan models.Poll)
+	go func() {
+		poll, err := repo.GetPoll(ctx, "p1")
+		assert.NoError(t, err)
+		done <- poll
+	}()
+
+	<-fetched
+	_, err := votesOf(repo, inner).AddVote(ctx, testVote("p1", "user2"))
+	require.NoError(t, err)
+	close(release)
+	<-done
+
+	poll, err := repo.GetPoll(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, poll.Options["Да"], "после записи голос должен быть виден")
+	assert.Empty(t, repo.pending, "незавершённых чтений не осталось")
+}
+
+// Тест проверяет вытеснение давно не читавшихся опросов
+func TestCachedRepo_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	repo, inner, _ := newCachedTestRepo(t, time.Hour, 2)
+	for _, id := range []string{"p1", "p2", "p3"} {
+		require.NoError(t, repo.SavePoll(ctx, testPoll(id)))
+	}
+
+	for _, id := range []string{"p1", "p2", "p1", "p3"} {
+		_, err := repo.GetPoll(ctx, id)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 3, inner.gets.Load())
+	assert.Equal(t, 2, repo.lru.Len())
+
+	_, err := repo.GetPoll(ctx, "p1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, inner.gets.Load(), "p1 читался недавно и остался в кэше")
+
+	_, err = repo.GetPoll(ctx, "p2")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, inner.gets.Load(), "p2 вытеснен")
+}
+
+// Тест проверяет, что ошибки не кэшируются, а нулевой размер отключает кэш
+func TestCachedRepo_ErrorsAndDisabled(t *testing.T) {
+	ctx := context.Background()
+	repo, inner, _ := newCachedTestRepo(t, time.Hour, 10)
+	for i := 0; i < 2; i++ {
+		_, err := repo.GetPoll(ctx, "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.EqualValues(t, 2, inner.gets.Load())
+
+	disabled, inner, _ := newCachedTestRepo(t, time.Hour, 0)
+	require.NoError(t, disabled.SavePoll(ctx, testPoll("p1")))
+	for i := 0; i < 2; i++ {
+		_, err := disabled.GetPoll(ctx, "p1")
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, inner.gets.Load())
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := repo.GetPoll(canceled, "p1")
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+// benchmarkHotPoll имитирует популярный опрос: на каждый голос приходится
+// несколько запросов результатов. Метрика repo-gets/op показывает, сколько
+// чтений доходит до хранилища.
+func benchmarkHotPoll(b *testing.B, wrap func(PollRepository, VoteRepository) (PollRepository, VoteRepository)) {
+	ctx := context.Background()
+	polls := NewMemoryPollRepo()
+	inner := &countingRepo{PollRepository: polls}
+	repo, votes := wrap(inner, NewMemoryVoteRepo(polls))
+	if err := repo.SavePoll(ctx, testPoll("hot")); err != nil {
+		b.Fatal(err)
+	}
+
+	const resultsPerVote = 10
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.GetPoll(ctx, "hot"); err != nil {
+			b.Fatal(err)
+		}
+		if i%resultsPerVote == 0 {
+			if _, err := votes.AddVote(ctx, testVote("hot", fmt.Sprintf("user%d", i))); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(inner.gets.Load())/float64(b.N), "repo-gets/op")
+}
+
+func BenchmarkHotPoll_Uncached(b *testing.B) {
+	benchmarkHotPoll(b, func(polls PollRepository, votes VoteRepository) (PollRepository, VoteRepository) {
+		return polls, votes
+	})
+}
+
+func BenchmarkHotPoll_Cached(b *testing.B) {
+	benchmarkHotPoll(b, func(polls PollRepository, votes VoteRepository) (PollRepository, VoteRepository) {
+		cached := NewCachedRepo(polls, time.Minute, 128)
+		return cached, cached.Votes(votes)
+	})
+}
+
+// Тест проверяет, что сводка и полный опрос берутся из одного кэша: опрос,
+// прочитанный сводкой, не перечитывается целиком, и наоборот
+func TestCachedRepo_SummarySharesCache(t *testing.T) {
+	ctx := context.Background()
+	repo, inner, _ := newCachedTestRepo(t, time.Hour, 10)
+	require.NoError(t, repo.SavePoll(ctx, testPoll("p1")))
+	require.NoError(t, repo.SavePoll(ctx, testPoll("p2")))
+
+	summary, err := repo.GetPollSummary(ctx, "p1")
+	require.NoError(t, err)
+	poll, err := repo.GetPoll(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, poll, summary)
+	assert.EqualValues(t, 1, inner.summaries.Load())
+	assert.EqualValues(t, 0, inner.gets.Load())
+
+	_, err = repo.GetPoll(ctx, "p2")
+	require.NoError(t, err)
+	_, err = repo.GetPollSummary(ctx, "p2")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, inner.summaries.Load())
+	assert.EqualValues(t, 1, inner.gets.Load())
+}
+
+// Тест проверяет, что GetPolls берёт из кэша то, что в нём есть, остальное
+// читает одним запросом и кладёт в кэш, а запись сбрасывает опрос и из
+// этого кэша
+func TestCachedRepo_GetPolls(t *testing.T) {
+	ctx := context.Background()
+	repo, inner, _ := newCachedTestRepo(t, time.Hour, 10)
+	for _, id := range []string{"p1", "p2", "p3"} {
+		require.NoError(t, repo.SavePoll(ctx, testPoll(id)))
+	}
+	_, err := repo.GetPoll(ctx, "p1")
+	require.NoError(t, err)
+
+	polls, err := repo.GetPolls(ctx, []string{"p1", "p2", "p3", "missing"})
+	require.NoError(t, err)
+	assert.Len(t, polls, 3)
+	assert.EqualValues(t, 1, inner.bulk.Load())
+
+	_, err = repo.GetPolls(ctx, []string{"p1", "p2", "p3"})
+	require.NoError(t, err)
+	_, err = repo.GetPoll(ctx, "p3")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, inner.bulk.Load(), "прочитанные опросы в кэше")
+	assert.EqualValues(t, 1, inner.gets.Load())
+
+	require.NoError(t, repo.ClosePoll(ctx, "p2"))
+	polls, err = repo.GetPolls(ctx, []string{"p1", "p2"})
+	require.NoError(t, err)
+	assert.True(t, polls["p2"].Closed)
+	assert.EqualValues(t, 2, inner.bulk.Load())
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// ChannelSettingsRepository хранит настройки каналов по ID канала.
+type ChannelSettingsRepository interface {
+	// GetChannelSettings возвращает ErrNotFound, если настройки канала
+	// ещё не задавались.
+	GetChannelSettings(ctx context.Context, channelID string) (models.ChannelSettings, error)
+	// SaveChannelSettings сохраняет настройки канала, заменяя прежние.
+	SaveChannelSettings(ctx context.Context, settings models.ChannelSettings) error
+	// ListDigestChannels возвращает ID каналов со включённой сводкой в
+	// порядке ID.
+	ListDigestChannels(ctx context.Context) ([]string, error)
+}
+
+type TarantoolChannelSettingsRepo struct {
+	conn      Connector
+	spaceName string
+}
+
+func NewTarantoolChannelSettingsRepo(conn Connector, spaceName string) *TarantoolChannelSettingsRepo {
+	return &TarantoolChannelSettingsRepo{conn: conn, spaceName: spaceName}
+}
+
+func (r *TarantoolChannelSettingsRepo) GetChannelSettings(ctx context.Context, channelID string) (models.ChannelSettings, error) {
+	if err := connReady(ctx, r.conn); err != nil {
+		return models.ChannelSettings{}, err
+	}
+
+	var tuples []channelSettingsTuple
+	err := r.conn.SelectTyped(ctx, r.spaceName, "primary", 0, 1, tarantool.IterEq, []interface{}{channelID}, &tuples)
+	if err != nil {
+		return models.ChannelSettings{}, fmt.Errorf("ошибка получения настроек канала: %w", classifyError(err))
+	}
+	if len(tuples) == 0 {
+		return models.ChannelSettings{}, ErrNotFound
+	}
+	return tuples[0].toModel(), nil
+}
+
+func (r *TarantoolChannelSettingsRepo) SaveChannelSettings(ctx context.Context, settings models.ChannelSettings) error {
+	if err := connReady(ctx, r.conn); err != nil {
+		return err
+	}
+
+	if _, err := r.conn.Replace(ctx, r.spaceName, newChannelSettingsTuple(settings)); err != nil {
+		return fmt.Errorf("ошибка сохранения настроек канала: %w", classifyError(err))
+	}
+	return nil
+}
+
+// ListDigestChannels читает первичный индекс пачками, как ListTemplates:
+// каналов с настройками немного, отдельный индекс по digest не нужен.
+func (r *TarantoolChannelSettingsRepo) ListDigestChannels(ctx context.Context) ([]string, error) {
+	if err := connReady(ctx, r.conn); err != nil {
+		return nil, err
+	}
+
+	var out []string
+	iterator, key := uint32(tarantool.IterAll), []interface{}{}
+	for {
+		var tuples []channelSettingsTuple
+		err := r.conn.SelectTyped(ctx, r.spaceName, "primary", 0, listScanBatch, iterator, key, &tuples)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения каналов со сводкой: %w", classifyError(err))
+		}
+		for _, t := range tuples {
+			if t.Digest {
+				out = append(out, t.ChannelID)
+			}
+		}
+		if len(tuples) < listScanBatch {
+			return out, nil
+		}
+		iterator, key = tarantool.IterGt, []interface{}{tuples[len(tuples)-1].ChannelID}
+	}
+}
+
+// channelSettingsTuple описывает раскладку настроек канала в space
+// Tarantool. Порядок полей должен точно соответствовать формату space в
+// init.lua.
+type channelSettingsTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	ChannelID string    // field 1: channel_id (string)
+	Digest    looseBool // field 2: digest (boolean)
+	UpdatedBy string    // field 3: updated_by (string)
+	UpdatedAt int64     // field 4: updated_at (unsigned, unix-время)
+}
+
+func newChannelSettingsTuple(settings models.ChannelSettings) channelSettingsTuple {
+	t := channelSettingsTuple{
+		ChannelID: settings.ChannelID,
+		Digest:    looseBool(settings.Digest),
+		UpdatedBy: settings.UpdatedBy,
+	}
+	if !settings.UpdatedAt.IsZero() {
+		t.UpdatedAt = settings.UpdatedAt.Unix()
+	}
+	return t
+}
+
+func (t channelSettingsTuple) toModel() models.ChannelSettings {
+	settings := models.ChannelSettings{
+		ChannelID: t.ChannelID,
+		Digest:    bool(t.Digest),
+		UpdatedBy: t.UpdatedBy,
+	}
+	if t.UpdatedAt > 0 {
+		settings.UpdatedAt = time.Unix(t.UpdatedAt, 0).UTC()
+	}
+	return settings
+}
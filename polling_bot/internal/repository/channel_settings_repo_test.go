@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// fakeChannelSettingsConn - упрощённый fakeConn для space настроек каналов
+// с первичным индексом channel_id.
+type fakeChannelSettingsConn struct {
+	mu        sync.Mutex
+	connected bool
+	tuples    map[string]channelSettingsTuple
+}
+
+func newFakeChannelSettingsConn() *fakeChannelSettingsConn {
+	return &fakeChannelSettingsConn{connected: true, tuples: make(map[string]channelSettingsTuple)}
+}
+
+func (f *fakeChannelSettingsConn) ConnectedNow() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected
+}
+
+func (f *fakeChannelSettingsConn) Replace(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := msgpack.Marshal(tuple)
+	if err != nil {
+		return nil, err
+	}
+	var t channelSettingsTuple
+	if err := msgpack.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	f.tuples[t.ChannelID] = t
+	return &tarantool.Response{Data: []interface{}{t}}, nil
+}
+
+func (f *fakeChannelSettingsConn) Insert(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeChannelSettingsConn: Insert не используется")
+}
+
+func (f *fakeChannelSettingsConn) Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeChannelSettingsConn: Update не используется")
+}
+
+func (f *fakeChannelSettingsConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeChannelSettingsConn: Call17 не используется")
+}
+
+func (f *fakeChannelSettingsConn) Delete(ctx context.Context, space, index interface{}, key interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeChannelSettingsConn: Delete не используется")
+}
+
+func (f *fakeChannelSettingsConn) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var want string
+	if parts := key.([]interface{}); len(parts) > 0 {
+		want = parts[0].(string)
+	}
+	ids := make([]string, 0, len(f.tuples))
+	for id := range f.tuples {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	out := result.(*[]channelSettingsTuple)
+	for _, id := range ids {
+		switch iterator {
+		case tarantool.IterAll:
+		case tarantool.IterEq:
+			if id != want {
+				continue
+			}
+		case tarantool.IterGt:
+			if id <= want {
+				continue
+			}
+		default:
+			return fmt.Errorf("fakeChannelSettingsConn: итератор %d не поддерживается", iterator)
+		}
+		if uint32(len(*out)) == limit {
+			break
+		}
+		*out = append(*out, f.tuples[id])
+	}
+	return nil
+}
+
+// extraChannelSettingsRepos - аналог extraRepos для настроек каналов.
+var extraChannelSettingsRepos = map[string]func(t *testing.T) ChannelSettingsRepository{}
+
+func channelSettingsRepos() map[string]func(t *testing.T) ChannelSettingsRepository {
+	repos := map[string]func(t *testing.T) ChannelSettingsRepository{
+		"memory": func(*testing.T) ChannelSettingsRepository { return NewMemoryChannelSettingsRepo() },
+		"tarantool": func(*testing.T) ChannelSettingsRepository {
+			return NewTarantoolChannelSettingsRepo(newFakeChannelSettingsConn(), "poll_channel_settings")
+		},
+	}
+	for name, newRepo := range extraChannelSettingsRepos {
+		repos[name] = newRepo
+	}
+	return repos
+}
+
+// Тест проверяет чтение и замену настроек канала через репозиторий
+func TestChannelSettingsRepo_Lifecycle(t *testing.T) {
+	for name, newRepo := range channelSettingsRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			_, err := repo.GetChannelSettings(ctx, "c1")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			settings := models.ChannelSettings{
+				ChannelID: "c1",
+				Digest:    true,
+				UpdatedBy: "user1",
+				UpdatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			}
+			require.NoError(t, repo.SaveChannelSettings(ctx, settings))
+			got, err := repo.GetChannelSettings(ctx, "c1")
+			require.NoError(t, err)
+			assert.Equal(t, settings, got)
+
+			settings.Digest, settings.UpdatedBy = false, "user2"
+			require.NoError(t, repo.SaveChannelSettings(ctx, settings))
+			got, err = repo.GetChannelSettings(ctx, "c1")
+			require.NoError(t, err)
+			assert.Equal(t, settings, got)
+		})
+	}
+}
+
+// Тест проверяет выборку каналов со включённой сводкой в порядке ID
+func TestChannelSettingsRepo_ListDigestChannels(t *testing.T) {
+	for name, newRepo := range channelSettingsRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			// Больше одной пачки выборки из Tarantool
+			var want []string
+			for i := 0; i < listScanBatch+20; i++ {
+				channelID := fmt.Sprintf("c%03d", i)
+				digest := i%3 == 0
+				if digest {
+					want = append(want, channelID)
+				}
+				require.NoError(t, repo.SaveChannelSettings(ctx, models.ChannelSettings{ChannelID: channelID, Digest: digest}))
+			}
+
+			got, err := repo.ListDigestChannels(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+// Тест проверяет, что при потере соединения с Tarantool возвращается ErrUnavailable
+func TestTarantoolChannelSettingsRepo_Unavailable(t *testing.T) {
+	conn := newFakeChannelSettingsConn()
+	conn.connected = false
+	repo := NewTarantoolChannelSettingsRepo(conn, "poll_channel_settings")
+
+	_, err := repo.ListDigestChannels(context.Background())
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, repo.SaveChannelSettings(context.Background(), models.ChannelSettings{ChannelID: "c1"}), ErrUnavailable)
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Классы ошибок хранилища, по которым сервис решает, что ответить пользователю.
+var (
+	ErrNotFound    = errors.New("опрос не найден")
+	ErrConflict    = errors.New("конфликт транзакции")
+	ErrUnavailable = errors.New("хранилище недоступно")
+	// Голос отклонён самим хранилищем: проверки сервиса могли устареть
+	ErrPollClosed   = errors.New("опрос закрыт")
+	ErrAlreadyVoted = errors.New("пользователь уже голосовал")
+	// Опрос закрыт, потому что набрал максимум голосов
+	ErrPollFull = fmt.Errorf("%w: набран максимум голосов", ErrPollClosed)
+	// Запись с таким ключом уже есть
+	ErrDuplicate = errors.New("запись уже существует")
+	// В хранилище нет нужного боту space или индекса
+	ErrSchema = errors.New("схема хранилища не подходит боту")
+)
+
+// classifyError оборачивает ошибку драйвера в соответствующий класс,
+// сохраняя исходную ошибку в цепочке.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if kind := errorKind(err); kind != nil {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+func errorKind(err error) error {
+	var tntErr tarantool.Error
+	if errors.As(err, &tntErr) {
+		switch tntErr.Code {
+		case tarantool.ErrTupleNotFound:
+			return ErrNotFound
+		case tarantool.ErrTupleFound:
+			return ErrDuplicate
+		case tarantool.ErrTransactionConflict:
+			return ErrConflict
+		case tarantool.ErrReadonly, tarantool.ErrNonmaster:
+			return ErrUnavailable
+		case tarantool.ErrNoSuchSpace, tarantool.ErrNoSuchIndex:
+			return ErrSchema
+		}
+		return nil
+	}
+
+	var cliErr tarantool.ClientError
+	if errors.As(err, &cliErr) {
+		switch cliErr.Code {
+		case tarantool.ErrConnectionNotReady, tarantool.ErrConnectionClosed,
+			tarantool.ErrTimeouted, tarantool.ErrRateLimited, tarantool.ErrConnectionShutdown:
+			return ErrUnavailable
+		}
+		return nil
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrUnavailable
+	}
+	return nil
+}
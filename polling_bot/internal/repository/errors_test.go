@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/tarantool/go-tarantool"
+)
+
+// Тест проверяет отнесение ошибок драйвера к классам хранилища
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"tuple not found", tarantool.Error{Code: tarantool.ErrTupleNotFound}, ErrNotFound},
+		{"duplicate key", tarantool.Error{Code: tarantool.ErrTupleFound}, ErrDuplicate},
+		{"transaction conflict", tarantool.Error{Code: tarantool.ErrTransactionConflict}, ErrConflict},
+		{"read-only instance", tarantool.Error{Code: tarantool.ErrReadonly}, ErrUnavailable},
+		{"missing space", tarantool.Error{Code: tarantool.ErrNoSuchSpace}, ErrSchema},
+		{"missing index", tarantool.Error{Code: tarantool.ErrNoSuchIndex}, ErrSchema},
+		{"connection not ready", tarantool.ClientError{Code: tarantool.ErrConnectionNotReady}, ErrUnavailable},
+		{"connection closed", tarantool.ClientError{Code: tarantool.ErrConnectionClosed}, ErrUnavailable},
+		{"request timeout", tarantool.ClientError{Code: tarantool.ErrTimeouted}, ErrUnavailable},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrUnavailable},
+		{"wrapped driver error", fmt.Errorf("select: %w", tarantool.ClientError{Code: tarantool.ErrConnectionClosed}), ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+			assert.ErrorIs(t, got, tt.want)
+			assert.ErrorIs(t, got, tt.err, "исходная ошибка должна оставаться в цепочке")
+		})
+	}
+}
+
+// Тест проверяет, что неизвестные ошибки не получают класса
+func TestClassifyError_Unclassified(t *testing.T) {
+	for _, err := range []error{
+		tarantool.Error{Code: tarantool.ErrIllegalParams},
+		tarantool.ClientError{Code: tarantool.ErrProtocolError},
+		context.Canceled,
+	} {
+		got := classifyError(err)
+		assert.Equal(t, err, got)
+		for _, kind := range []error{ErrNotFound, ErrConflict, ErrUnavailable} {
+			assert.NotErrorIs(t, got, kind)
+		}
+	}
+	assert.NoError(t, classifyError(nil))
+}
+
+// Тест проверяет отнесение ошибок database/sql и lib/pq к классам хранилища
+func TestClassifyPostgresError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"no rows", sql.ErrNoRows, ErrNotFound},
+		{"serialization failure", &pq.Error{Code: "40001"}, ErrConflict},
+		{"deadlock", &pq.Error{Code: "40P01"}, ErrConflict},
+		{"connection failure", &pq.Error{Code: "08006"}, ErrUnavailable},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, ErrUnavailable},
+		{"bad connection", driver.ErrBadConn, ErrUnavailable},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyPostgresError(tt.err)
+			assert.ErrorIs(t, got, tt.want)
+			assert.ErrorIs(t, got, tt.err, "исходная ошибка должна оставаться в цепочке")
+		})
+	}
+
+	unique := &pq.Error{Code: "23505"}
+	assert.Equal(t, unique, classifyPostgresError(unique))
+	assert.NoError(t, classifyPostgresError(nil))
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+// Failoverer - соединение, которое умеет переключиться на другой экземпляр
+// Tarantool, если текущий недоступен или стал только для чтения.
+type Failoverer interface {
+	Failover(ctx context.Context) error
+}
+
+// withFailover выполняет op, а если хранилище недоступно и соединение
+// умеет переключаться, переключает его и повторяет op по политике r.retry.
+// Повторяются только операции, которые можно безопасно выполнить ещё раз:
+// записи устанавливают значения, а повтор AddVote отклоняется
+// хранилищем как повторный голос. IncrementOption сюда не попадает.
+func (r *tarantoolRepo) withFailover(ctx context.Context, op func() error) error {
+	failover, ok := r.conn.(Failoverer)
+	if !ok {
+		return op()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if !errors.Is(err, ErrUnavailable) || attempt >= r.retry.Attempts {
+			return err
+		}
+
+		if ferr := failover.Failover(ctx); ferr != nil {
+			r.logger.Warn().Err(ferr).Int("attempt", attempt).Msg("Хранилище недоступно, переключиться не удалось")
+		}
+		if err := r.sleep(ctx, r.retry.backoff(attempt, r.jitter)); err != nil {
+			return err
+		}
+	}
+}
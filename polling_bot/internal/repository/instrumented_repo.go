@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/models"
+)
+
+// InstrumentedRepo считает обращения к другому репозиторию и их ошибки
+// по методам: счётчики repo_calls_total.<метод> и repo_errors_total.<метод>
+// реестра stats.
+type InstrumentedRepo struct {
+	inner PollRepository
+	stats *metrics.Registry
+}
+
+func NewInstrumentedRepo(inner PollRepository, stats *metrics.Registry) *InstrumentedRepo {
+	return &InstrumentedRepo{inner: inner, stats: stats}
+}
+
+func (r *InstrumentedRepo) count(method string, err error) error {
+	return countCall(r.stats, method, err)
+}
+
+// countCall учитывает вызов method и возвращает его ошибку без изменений.
+func countCall(stats *metrics.Registry, method string, err error) error {
+	stats.Counter("repo_calls_total." + method).Add(1)
+	if err != nil {
+		stats.Counter("repo_errors_total." + method).Add(1)
+	}
+	return err
+}
+
+func (r *InstrumentedRepo) SavePoll(ctx context.Context, poll models.Poll) error {
+	return r.count("SavePoll", r.inner.SavePoll(ctx, poll))
+}
+
+func (r *InstrumentedRepo) IncrementOption(ctx context.Context, pollID, option string, delta int) error {
+	return r.count("IncrementOption", r.inner.IncrementOption(ctx, pollID, option, delta))
+}
+
+func (r *InstrumentedRepo) IncrementViews(ctx context.Context, pollID string) error {
+	return r.count("IncrementViews", r.inner.IncrementViews(ctx, pollID))
+}
+
+func (r *InstrumentedRepo) GetPoll(ctx context.Context, id string) (models.Poll, error) {
+	poll, err := r.inner.GetPoll(ctx, id)
+	return poll, r.count("GetPoll", err)
+}
+
+func (r *InstrumentedRepo) GetPollSummary(ctx context.Context, id string) (models.Poll, error) {
+	poll, err := r.inner.GetPollSummary(ctx, id)
+	return poll, r.count("GetPollSummary", err)
+}
+
+func (r *InstrumentedRepo) GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error) {
+	polls, err := r.inner.GetPolls(ctx, ids)
+	return polls, r.count("GetPolls", err)
+}
+
+func (r *InstrumentedRepo) ClosePoll(ctx context.Context, pollID string) error {
+	return r.count("ClosePoll", r.inner.ClosePoll(ctx, pollID))
+}
+
+func (r *InstrumentedRepo) SetCreator(ctx context.Context, pollID, creator string) error {
+	return r.count("SetCreator", r.inner.SetCreator(ctx, pollID, creator))
+}
+
+func (r *InstrumentedRepo) SetPinnedPost(ctx context.Context, pollID, postID string) error {
+	return r.count("SetPinnedPost", r.inner.SetPinnedPost(ctx, pollID, postID))
+}
+
+func (r *InstrumentedRepo) SetExpiryWarned(ctx context.Context, pollID string) error {
+	return r.count("SetExpiryWarned", r.inner.SetExpiryWarned(ctx, pollID))
+}
+
+func (r *InstrumentedRepo) SetFeatured(ctx context.Context, pollID string, featured bool) error {
+	return r.count("SetFeatured", r.inner.SetFeatured(ctx, pollID, featured))
+}
+
+func (r *InstrumentedRepo) SetAllowedVoters(ctx context.Context, pollID string, voters []string) error {
+	return r.count("SetAllowedVoters", r.inner.SetAllowedVoters(ctx, pollID, voters))
+}
+
+func (r *InstrumentedRepo) UpdateSettings(ctx context.Context, pollID string, update SettingsUpdate) error {
+	return r.count("UpdateSettings", r.inner.UpdateSettings(ctx, pollID, update))
+}
+
+func (r *InstrumentedRepo) DeletePoll(ctx context.Context, id string) error {
+	return r.count("DeletePoll", r.inner.DeletePoll(ctx, id))
+}
+
+func (r *InstrumentedRepo) ListPolls(ctx context.Context, filter ListFilter) ([]models.Poll, string, error) {
+	polls, next, err := r.inner.ListPolls(ctx, filter)
+	return polls, next, r.count("ListPolls", err)
+}
+
+func (r *InstrumentedRepo) CountPolls(ctx context.Context, filter ListFilter) (int, error) {
+	count, err := r.inner.CountPolls(ctx, filter)
+	return count, r.count("CountPolls", err)
+}
+
+// InstrumentedVoteRepo считает обращения к репозиторию голосов в те же
+// счётчики, что и InstrumentedRepo.
+type InstrumentedVoteRepo struct {
+	inner VoteRepository
+	stats *metrics.Registry
+}
+
+func NewInstrumentedVoteRepo(inner VoteRepository, stats *metrics.Registry) *InstrumentedVoteRepo {
+	return &InstrumentedVoteRepo{inner: inner, stats: stats}
+}
+
+func (r *InstrumentedVoteRepo) AddVote(ctx context.Context, vote models.Vote) (bool, error) {
+	closed, err := r.inner.AddVote(ctx, vote)
+	return closed, countCall(r.stats, "AddVote", err)
+}
+
+func (r *InstrumentedVoteRepo) GetVote(ctx context.Context, pollID, userID string) (models.Vote, error) {
+	vote, err := r.inner.GetVote(ctx, pollID, userID)
+	return vote, countCall(r.stats, "GetVote", err)
+}
+
+func (r *InstrumentedVoteRepo) ListVotes(ctx context.Context, pollID string) ([]models.Vote, error) {
+	votes, err := r.inner.ListVotes(ctx, pollID)
+	return votes, countCall(r.stats, "ListVotes", err)
+}
+
+func (r *InstrumentedVoteRepo) CountVotes(ctx context.Context, pollID string) (int, error) {
+	count, err := r.inner.CountVotes(ctx, pollID)
+	return count, countCall(r.stats, "CountVotes", err)
+}
+
+func (r *InstrumentedVoteRepo) ImportVotes(ctx context.Context, votes []models.Vote) error {
+	return countCall(r.stats, "ImportVotes", r.inner.ImportVotes(ctx, votes))
+}
+
+func (r *InstrumentedVoteRepo) DeleteVotes(ctx context.Context, pollID string) error {
+	return countCall(r.stats, "DeleteVotes", r.inner.DeleteVotes(ctx, pollID))
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тест проверяет подсчёт обращений к хранилищу и их ошибок по методам
+func TestInstrumentedRepo_Counts(t *testing.T) {
+	stats := metrics.NewRegistry()
+	repo := NewInstrumentedRepo(NewMemoryPollRepo(), stats)
+	ctx := context.Background()
+
+	require.NoError(t, repo.SavePoll(ctx, models.Poll{ID: "p1", Options: map[string]int{"Пицца": 0}}))
+	_, err := repo.GetPoll(ctx, "p1")
+	require.NoError(t, err)
+	_, err = repo.GetPoll(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, repo.ClosePoll(ctx, "p1"))
+
+	assert.Equal(t, map[string]int64{
+		"repo_calls_total.SavePoll":  1,
+		"repo_calls_total.GetPoll":   2,
+		"repo_errors_total.GetPoll":  1,
+		"repo_calls_total.ClosePoll": 1,
+	}, stats.Snapshot())
+}
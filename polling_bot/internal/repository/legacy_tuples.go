@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Хранимые функции init.lua для кортежей опросов старых версий схемы.
+const (
+	funcUpgradePoll  = "polls_upgrade"
+	funcCountLegacy  = "polls_legacy_count"
+	legacySampleSize = 1000
+)
+
+// upgradeLegacy переводит в текущую версию схемы кортеж, который вернул
+// update-запрос, если его записала прежняя версия бота. Хранимые функции
+// голосования делают это сами, а update-запрос менять раскладку не умеет.
+// Запись уже выполнена, поэтому неудача только откладывает перевод до
+// следующей записи.
+func (r *TarantoolPollRepo) upgradeLegacy(ctx context.Context, pollID string, resp *tarantool.Response) {
+	if resp == nil || len(resp.Data) == 0 || !legacyRow(resp.Data[0]) {
+		return
+	}
+	if _, err := r.conn.Call17(ctx, funcUpgradePoll, []interface{}{r.spaceName, pollID}); err != nil {
+		r.logger.Warn().Err(classifyError(err)).Str("poll_id", pollID).Msg("Кортеж опроса не переведён в текущую версию схемы")
+	}
+}
+
+// legacyRow - кортеж из ответа Tarantool записан без версии схемы или
+// прежней её версией.
+func legacyRow(data interface{}) bool {
+	row, ok := data.([]interface{})
+	if !ok {
+		return false
+	}
+	return len(row) <= fieldSchemaVersion || toInt(row[fieldSchemaVersion]) < pollSchemaVersion
+}
+
+// LegacyTuples - сколько кортежей опросов записано старыми версиями схемы среди
+// первых Sampled кортежей space из Total.
+type LegacyTuples struct {
+	Sampled int
+	Legacy  int
+	Total   int
+}
+
+// Estimate - оценка числа кортежей старых версий во всём space по выборке.
+func (l LegacyTuples) Estimate() int {
+	if l.Sampled == 0 {
+		return 0
+	}
+	return l.Legacy * l.Total / l.Sampled
+}
+
+// ReportLegacyTuples считает кортежи старых версий схемы среди первых
+// legacySampleSize кортежей space хранимой функцией, не передавая их по
+// сети, и пишет итог в журнал. Кортежи переводятся в текущую версию при
+// записи, поэтому число со временем уменьшается само.
+func (r *TarantoolPollRepo) ReportLegacyTuples(ctx context.Context) (LegacyTuples, error) {
+	if err := r.ready(ctx); err != nil {
+		return LegacyTuples{}, err
+	}
+
+	resp, err := r.conn.Call17(ctx, funcCountLegacy, []interface{}{r.spaceName, legacySampleSize})
+	if err != nil {
+		return LegacyTuples{}, fmt.Errorf("ошибка подсчёта кортежей старой версии схемы: %w", classifyError(err))
+	}
+	if resp == nil || len(resp.Data) != 3 {
+		return LegacyTuples{}, errors.New("ошибка подсчёта кортежей старой версии схемы: хранимая функция вернула не три числа")
+	}
+	legacy := LegacyTuples{Sampled: toInt(resp.Data[0]), Legacy: toInt(resp.Data[1]), Total: toInt(resp.Data[2])}
+
+	event := r.logger.Info()
+	if legacy.Legacy == 0 {
+		event = r.logger.Debug()
+	}
+	event.Int("sampled", legacy.Sampled).Int("legacy", legacy.Legacy).Int("total", legacy.Total).
+		Int("estimate", legacy.Estimate()).Msg("Кортежи опросов старых версий схемы")
+	return legacy, nil
+}
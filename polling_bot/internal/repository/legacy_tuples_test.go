@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyPoll сохраняет опрос и делает его кортеж записанным до появления
+// версии схемы.
+func legacyPoll(t *testing.T, conn *fakeConn, repo *TarantoolPollRepo, id string) {
+	t.Helper()
+	require.NoError(t, repo.SavePoll(context.Background(), testPoll(id)))
+	tuple := conn.tuples[id]
+	tuple.SchemaVersion = pollSchemaLegacy
+	conn.tuples[id] = tuple
+}
+
+// Тест проверяет ленивый перевод кортежа в текущую версию: update-запрос
+// к старому кортежу переводит его, а к новому - нет; неудачный перевод
+// не ломает запись, и кортеж переводится следующей
+func TestTarantoolPollRepo_UpgradeOnWrite(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	repo := newTestRepo(conn)
+	legacyPoll(t, conn, repo, "poll1")
+
+	conn.callErrs = []error{errors.New("экземпляр перегружен")}
+	require.NoError(t, repo.SetPinnedPost(ctx, "poll1", "post1"))
+	assert.EqualValues(t, pollSchemaLegacy, conn.tuples["poll1"].SchemaVersion)
+
+	require.NoError(t, repo.ClosePoll(ctx, "poll1"))
+	assert.EqualValues(t, pollSchemaVersion, conn.tuples["poll1"].SchemaVersion)
+	calls := conn.calls["Call"]
+	require.NoError(t, repo.SetCreator(ctx, "poll1", "user2"))
+	assert.Equal(t, calls, conn.calls["Call"], "кортеж текущей версии не переводится повторно")
+
+	poll, err := repo.GetPoll(ctx, "poll1")
+	require.NoError(t, err)
+	assert.True(t, poll.Closed)
+	assert.Equal(t, "user2", poll.Creator)
+	assert.Equal(t, "post1", poll.PinnedPostID)
+
+	// Хранимые функции переводят кортеж сами
+	legacyPoll(t, conn, repo, "poll2")
+	require.NoError(t, repo.IncrementViews(ctx, "poll2"))
+	assert.EqualValues(t, pollSchemaVersion, conn.tuples["poll2"].SchemaVersion)
+
+	// Отметка пишется в поле версии 2, которого у старого кортежа нет
+	legacyPoll(t, conn, repo, "poll3")
+	require.NoError(t, repo.SetFeatured(ctx, "poll3", true))
+	assert.EqualValues(t, pollSchemaVersion, conn.tuples["poll3"].SchemaVersion)
+	poll, err = repo.GetPoll(ctx, "poll3")
+	require.NoError(t, err)
+	assert.True(t, poll.Featured)
+
+	// Список участников - поле версии 3
+	legacyPoll(t, conn, repo, "poll4")
+	require.NoError(t, repo.SetAllowedVoters(ctx, "poll4", []string{"user2"}))
+	assert.EqualValues(t, pollSchemaVersion, conn.tuples["poll4"].SchemaVersion)
+	poll, err = repo.GetPoll(ctx, "poll4")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user2"}, poll.AllowedVoters)
+	require.NoError(t, repo.SetAllowedVoters(ctx, "poll4", nil))
+	poll, err = repo.GetPoll(ctx, "poll4")
+	require.NoError(t, err)
+	assert.Nil(t, poll.AllowedVoters)
+}
+
+// Тест проверяет распознавание старого кортежа в ответе update-запроса
+func TestLegacyRow(t *testing.T) {
+	current := make([]interface{}, pollFieldsV3)
+	current[fieldSchemaVersion] = uint64(pollSchemaVersion)
+	v1 := make([]interface{}, pollFieldsV1)
+	v1[fieldSchemaVersion] = uint64(pollSchemaV1)
+	v2 := make([]interface{}, pollFieldsV2)
+	v2[fieldSchemaVersion] = uint64(pollSchemaV2)
+
+	assert.True(t, legacyRow([]interface{}{"poll1", "user1", "Q", map[string]interface{}{}, map[string]interface{}{}, false}))
+	assert.True(t, legacyRow(make([]interface{}, pollFieldsV1)), "поле версии есть, но пустое")
+	assert.True(t, legacyRow(v1), "кортеж версии 1 переводится в текущую")
+	assert.True(t, legacyRow(v2), "кортеж версии 2 переводится в версию 3")
+	assert.False(t, legacyRow(current))
+	assert.False(t, legacyRow("not a tuple"))
+}
+
+// Тест проверяет подсчёт старых кортежей при запуске и оценку их числа по
+// выборке
+func TestTarantoolPollRepo_ReportLegacyTuples(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	repo := newTestRepo(conn)
+	require.NoError(t, repo.SavePoll(ctx, testPoll("poll1")))
+	legacyPoll(t, conn, repo, "poll2")
+	legacyPoll(t, conn, repo, "poll3")
+
+	legacy, err := repo.ReportLegacyTuples(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, LegacyTuples{Sampled: 3, Legacy: 2, Total: 3}, legacy)
+	assert.Equal(t, 2, legacy.Estimate())
+
+	assert.Equal(t, 500, LegacyTuples{Sampled: 1000, Legacy: 100, Total: 5000}.Estimate())
+	assert.Equal(t, 0, LegacyTuples{}.Estimate())
+
+	conn.connected = false
+	_, err = repo.ReportLegacyTuples(ctx)
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"slices"
+	"time"
+
+	"polling_bot/internal/models"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListFilter ограничивает выборку ListPolls. Пустые поля не фильтруют.
+// Cursor - непрозрачная позиция, возвращённая предыдущим вызовом ListPolls.
+type ListFilter struct {
+	Creator   string
+	ChannelID string
+	Closed    *bool
+	// Только отмеченные администратором опросы или только неотмеченные
+	Featured *bool
+	// Только опросы с этой меткой; метки хранятся в нижнем регистре
+	Tag           string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	Cursor string
+	Limit  int
+}
+
+// pageLimit приводит размер страницы к допустимым границам.
+func (f ListFilter) pageLimit() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultListLimit
+	case f.Limit > MaxListLimit:
+		return MaxListLimit
+	default:
+		return f.Limit
+	}
+}
+
+// matches проверяет предикаты фильтра, кроме курсора.
+func (f ListFilter) matches(poll models.Poll) bool {
+	if f.Creator != "" && poll.Creator != f.Creator {
+		return false
+	}
+	if f.ChannelID != "" && poll.ChannelID != f.ChannelID {
+		return false
+	}
+	if f.Closed != nil && poll.Closed != *f.Closed {
+		return false
+	}
+	if f.Featured != nil && poll.Featured != *f.Featured {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && !poll.CreatedAt.After(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !poll.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(poll.Tags, f.Tag) {
+		return false
+	}
+	return true
+}
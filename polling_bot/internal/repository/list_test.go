@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var listBaseTime = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// listRepos возвращает обе реализации, на которых гоняются тесты ListPolls
+// extraRepos дополняют контрактные тесты реализациями, которым нужна
+// внешняя инфраструктура (см. postgres_repo_test.go).
+var extraRepos = map[string]func(t *testing.T) PollRepository{}
+
+func listRepos() map[string]func(t *testing.T) PollRepository {
+	repos := map[string]func(t *testing.T) PollRepository{
+		"memory":    func(*testing.T) PollRepository { return NewMemoryPollRepo() },
+		"tarantool": func(*testing.T) PollRepository { return newTestRepo(newFakeConn()) },
+		"cached": func(*testing.T) PollRepository {
+			return NewCachedRepo(NewMemoryPollRepo(), time.Minute, 100)
+		},
+	}
+	for name, newRepo := range extraRepos {
+		repos[name] = newRepo
+	}
+	return repos
+}
+
+// seedPolls создаёт n опросов: чётные - alice в канале c1, нечётные - bob в c2,
+// каждый третий закрыт, каждый четвёртый помечен release и team-a, время
+// создания растёт на час.
+func seedPolls(t *testing.T, repo PollRepository, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		poll := testPoll(fmt.Sprintf("p%03d", i))
+		poll.Creator, poll.ChannelID = "alice", "c1"
+		if i%2 == 1 {
+			poll.Creator, poll.ChannelID = "bob", "c2"
+		}
+		poll.Closed = i%3 == 0
+		poll.Featured = i%5 == 0
+		if i%4 == 0 {
+			poll.Tags = []string{"release", "team-a"}
+		}
+		poll.CreatedAt = listBaseTime.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, repo.SavePoll(context.Background(), poll))
+	}
+}
+
+// listAll проходит все страницы и возвращает ID и размеры страниц
+func listAll(t *testing.T, repo PollRepository, filter ListFilter) ([]string, []int) {
+	t.Helper()
+	var ids []string
+	var sizes []int
+	for i := 0; ; i++ {
+		require.Less(t, i, 1000, "пагинация не завершилась")
+		page, cursor, err := repo.ListPolls(context.Background(), filter)
+		require.NoError(t, err)
+		sizes = append(sizes, len(page))
+		for _, p := range page {
+			ids = append(ids, p.ID)
+		}
+		if cursor == "" {
+			return ids, sizes
+		}
+		filter.Cursor = cursor
+	}
+}
+
+func pollIDs(indexes ...int) []string {
+	ids := make([]string, len(indexes))
+	for i, idx := range indexes {
+		ids[i] = fmt.Sprintf("p%03d", idx)
+	}
+	return ids
+}
+
+// Тест проверяет пустое хранилище и курсор за последним опросом
+func TestListPolls_EmptyPages(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			page, cursor, err := repo.ListPolls(context.Background(), ListFilter{})
+			require.NoError(t, err)
+			assert.Empty(t, page)
+			assert.Empty(t, cursor)
+
+			seedPolls(t, repo, 3)
+			page, cursor, err = repo.ListPolls(context.Background(), ListFilter{Cursor: "p999"})
+			require.NoError(t, err)
+			assert.Empty(t, page)
+			assert.Empty(t, cursor)
+
+			page, cursor, err = repo.ListPolls(context.Background(), ListFilter{Creator: "nobody"})
+			require.NoError(t, err)
+			assert.Empty(t, page)
+			assert.Empty(t, cursor)
+		})
+	}
+}
+
+// Тест проверяет границы страниц: без пустой последней страницы и без потерь
+func TestListPolls_PageBoundaries(t *testing.T) {
+	tests := []struct {
+		limit int
+		sizes []int
+	}{
+		{limit: 12, sizes: []int{12}},
+		{limit: 6, sizes: []int{6, 6}},
+		{limit: 5, sizes: []int{5, 5, 2}},
+		{limit: 1, sizes: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+		{limit: 50, sizes: []int{12}},
+	}
+
+	for name, newRepo := range listRepos() {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/limit=%d", name, tt.limit), func(t *testing.T) {
+				repo := newRepo(t)
+				seedPolls(t, repo, 12)
+
+				ids, sizes := listAll(t, repo, ListFilter{Limit: tt.limit})
+				assert.Equal(t, tt.sizes, sizes)
+				assert.Equal(t, pollIDs(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11), ids)
+			})
+		}
+	}
+}
+
+// Тест проверяет ограничение размера страницы
+func TestListPolls_LimitClamp(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			seedPolls(t, repo, MaxListLimit+10)
+
+			page, cursor, err := repo.ListPolls(context.Background(), ListFilter{})
+			require.NoError(t, err)
+			assert.Len(t, page, DefaultListLimit)
+			assert.Equal(t, page[len(page)-1].ID, cursor)
+
+			page, _, err = repo.ListPolls(context.Background(), ListFilter{Limit: 10 * MaxListLimit})
+			require.NoError(t, err)
+			assert.Len(t, page, MaxListLimit)
+		})
+	}
+}
+
+// Тест проверяет одиночные и комбинированные фильтры с пагинацией, а
+// также что CountPolls считает столько же опросов, сколько отдаёт ListPolls
+func TestListPolls_Filters(t *testing.T) {
+	closed, open := true, false
+	featured, plain := true, false
+
+	tests := []struct {
+		name   string
+		filter ListFilter
+		want   []string
+	}{
+		{
+			name:   "creator",
+			filter: ListFilter{Creator: "bob", Limit: 2},
+			want:   pollIDs(1, 3, 5, 7, 9, 11),
+		},
+		{
+			name:   "channel",
+			filter: ListFilter{ChannelID: "c1", Limit: 4},
+			want:   pollIDs(0, 2, 4, 6, 8, 10),
+		},
+		{
+			name:   "closed",
+			filter: ListFilter{Closed: &closed, Limit: 3},
+			want:   pollIDs(0, 3, 6, 9),
+		},
+		{
+			name:   "created window is exclusive",
+			filter: ListFilter{CreatedAfter: listBaseTime.Add(2 * time.Hour), CreatedBefore: listBaseTime.Add(6 * time.Hour)},
+			want:   pollIDs(3, 4, 5),
+		},
+		{
+			name:   "window with fractional seconds",
+			filter: ListFilter{CreatedAfter: listBaseTime.Add(2*time.Hour + time.Millisecond), CreatedBefore: listBaseTime.Add(6*time.Hour + time.Millisecond)},
+			want:   pollIDs(3, 4, 5, 6),
+		},
+		{
+			// Так лимит опросов в канале считает опросы с начала суток
+			name:   "channel since a moment inclusive",
+			filter: ListFilter{ChannelID: "c1", CreatedAfter: listBaseTime.Add(4*time.Hour - time.Nanosecond)},
+			want:   pollIDs(4, 6, 8, 10),
+		},
+		{
+			name:   "creator and open",
+			filter: ListFilter{Creator: "alice", Closed: &open, Limit: 1},
+			want:   pollIDs(2, 4, 8, 10),
+		},
+		{
+			name:   "creator, channel and window",
+			filter: ListFilter{Creator: "bob", ChannelID: "c2", CreatedAfter: listBaseTime.Add(4 * time.Hour), Limit: 2},
+			want:   pollIDs(5, 7, 9, 11),
+		},
+		{
+			name:   "tag",
+			filter: ListFilter{Tag: "team-a", Limit: 2},
+			want:   pollIDs(0, 4, 8),
+		},
+		{
+			name:   "channel, open and tag",
+			filter: ListFilter{ChannelID: "c1", Closed: &open, Tag: "release"},
+			want:   pollIDs(4, 8),
+		},
+		{
+			name:   "featured",
+			filter: ListFilter{Featured: &featured, Limit: 2},
+			want:   pollIDs(0, 5, 10),
+		},
+		{
+			name:   "channel, open and not featured",
+			filter: ListFilter{ChannelID: "c1", Closed: &open, Featured: &plain},
+			want:   pollIDs(2, 4, 8),
+		},
+		{
+			name:   "unknown tag",
+			filter: ListFilter{Tag: "rel"},
+			want:   nil,
+		},
+		{
+			name:   "contradicting creator and channel",
+			filter: ListFilter{Creator: "alice", ChannelID: "c2"},
+			want:   nil,
+		},
+	}
+
+	for name, newRepo := range listRepos() {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				repo := newRepo(t)
+				seedPolls(t, repo, 12)
+
+				ids, _ := listAll(t, repo, tt.filter)
+				assert.Equal(t, tt.want, ids)
+
+				count, err := repo.CountPolls(context.Background(), tt.filter)
+				require.NoError(t, err)
+				assert.Equal(t, len(tt.want), count)
+			})
+		}
+	}
+}
+
+// Тест проверяет, что CountPolls не учитывает курсор и размер страницы
+func TestCountPolls_IgnoresPage(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			count, err := repo.CountPolls(context.Background(), ListFilter{})
+			require.NoError(t, err)
+			assert.Zero(t, count)
+
+			seedPolls(t, repo, 12)
+			count, err = repo.CountPolls(context.Background(), ListFilter{Cursor: "p005", Limit: 2})
+			require.NoError(t, err)
+			assert.Equal(t, 12, count)
+		})
+	}
+}
+
+// Тест проверяет, что GetPolls отдаёт опросы по ID, пропуская несуществующие
+// и повторы, и сводки не отличаются от прочитанных по одному
+func TestGetPolls(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			seedPolls(t, repo, 5)
+
+			polls, err := repo.GetPolls(ctx, nil)
+			require.NoError(t, err)
+			assert.Empty(t, polls)
+
+			polls, err = repo.GetPolls(ctx, []string{"p003", "missing", "p000", "p003"})
+			require.NoError(t, err)
+			require.Len(t, polls, 2)
+			for _, id := range []string{"p000", "p003"} {
+				want, err := repo.GetPollSummary(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, want, polls[id])
+			}
+		})
+	}
+}
+
+// Тест проверяет, что GetPolls в Tarantool делит длинный список на вызовы
+// по getPollsBatch ID
+func TestTarantoolGetPolls_Batches(t *testing.T) {
+	conn := newFakeConn()
+	repo := newTestRepo(conn)
+	seedPolls(t, repo, getPollsBatch+10)
+	ids := make([]string, 0, getPollsBatch+10)
+	for i := 0; i < getPollsBatch+10; i++ {
+		ids = append(ids, fmt.Sprintf("p%03d", i))
+	}
+
+	before := conn.calls["Call"]
+	polls, err := repo.GetPolls(context.Background(), ids)
+	require.NoError(t, err)
+	assert.Len(t, polls, len(ids))
+	assert.Equal(t, 2, conn.calls["Call"]-before)
+}
+
+// Тест проверяет, что возвращаемые опросы не разделяют карты и срезы с хранилищем
+func TestListPolls_ReturnsCopies(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			seedPolls(t, repo, 1)
+
+			page, _, err := repo.ListPolls(context.Background(), ListFilter{})
+			require.NoError(t, err)
+			page[0].Options["intruder"] = 1
+			page[0].Tags[0] = "intruder"
+
+			got, err := repo.GetPoll(context.Background(), page[0].ID)
+			require.NoError(t, err)
+			assert.NotContains(t, got.Options, "intruder")
+			assert.NotContains(t, got.Tags, "intruder")
+		})
+	}
+}
+
+// Тест проверяет выбор индекса и ограничение просмотра в Tarantool
+func TestTarantoolListPolls_IndexesAndScanLimit(t *testing.T) {
+	conn := newFakeConn()
+	repo := newTestRepo(conn)
+	seedPolls(t, repo, listScanLimit+300)
+
+	_, _, err := repo.ListPolls(context.Background(), ListFilter{Creator: "alice", Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 1, conn.calls["Select:creator"])
+
+	_, _, err = repo.ListPolls(context.Background(), ListFilter{ChannelID: "c2", Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, 1, conn.calls["Select:channel"])
+
+	// Фильтр, которому ничего не соответствует, не должен сканировать всё за один вызов
+	future := listBaseTime.Add(100 * 365 * 24 * time.Hour)
+	page, cursor, err := repo.ListPolls(context.Background(), ListFilter{CreatedAfter: future})
+	require.NoError(t, err)
+	assert.Empty(t, page)
+	assert.NotEmpty(t, cursor, "курсор должен позволять продолжить просмотр")
+	assert.Equal(t, listScanLimit/listScanBatch, conn.calls["Select:primary"])
+
+	ids, _ := listAll(t, repo, ListFilter{CreatedAfter: future})
+	assert.Empty(t, ids)
+}
+
+// Тест проверяет, что недоступность хранилища возвращается классом ошибки
+func TestTarantoolListPolls_Unavailable(t *testing.T) {
+	conn := newFakeConn()
+	repo := newTestRepo(conn)
+	conn.setConnected(false)
+
+	_, _, err := repo.ListPolls(context.Background(), ListFilter{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	_, err = repo.CountPolls(context.Background(), ListFilter{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
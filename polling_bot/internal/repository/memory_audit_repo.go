@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"polling_bot/internal/audit"
+)
+
+// MemoryAuditRepo хранит журнал в памяти процесса, как MemoryPollRepo.
+type MemoryAuditRepo struct {
+	mu     sync.RWMutex
+	events map[string][]audit.Event
+	ids    map[string]bool
+}
+
+func NewMemoryAuditRepo() *MemoryAuditRepo {
+	return &MemoryAuditRepo{events: make(map[string][]audit.Event), ids: make(map[string]bool)}
+}
+
+func (r *MemoryAuditRepo) AppendEvent(ctx context.Context, event audit.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ids[event.ID] {
+		return fmt.Errorf("событие %s уже записано в журнал", event.ID)
+	}
+	r.ids[event.ID] = true
+	r.events[event.PollID] = append(r.events[event.PollID], event)
+	return nil
+}
+
+// ListEvents полагается на порядок записи: события опроса добавляются
+// по мере того, как происходят.
+func (r *MemoryAuditRepo) ListEvents(ctx context.Context, pollID string, limit int) ([]audit.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := r.events[pollID]
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return append([]audit.Event(nil), events...), nil
+}
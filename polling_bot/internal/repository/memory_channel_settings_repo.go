@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"polling_bot/internal/models"
+)
+
+// MemoryChannelSettingsRepo хранит настройки каналов в памяти процесса.
+type MemoryChannelSettingsRepo struct {
+	mu       sync.RWMutex
+	settings map[string]models.ChannelSettings
+}
+
+func NewMemoryChannelSettingsRepo() *MemoryChannelSettingsRepo {
+	return &MemoryChannelSettingsRepo{settings: make(map[string]models.ChannelSettings)}
+}
+
+func (r *MemoryChannelSettingsRepo) GetChannelSettings(ctx context.Context, channelID string) (models.ChannelSettings, error) {
+	if err := ctx.Err(); err != nil {
+		return models.ChannelSettings{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	settings, ok := r.settings[channelID]
+	if !ok {
+		return models.ChannelSettings{}, ErrNotFound
+	}
+	return settings, nil
+}
+
+func (r *MemoryChannelSettingsRepo) SaveChannelSettings(ctx context.Context, settings models.ChannelSettings) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings[settings.ChannelID] = settings
+	return nil
+}
+
+func (r *MemoryChannelSettingsRepo) ListDigestChannels(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []string
+	for channelID, settings := range r.settings {
+		if settings.Digest {
+			out = append(out, channelID)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
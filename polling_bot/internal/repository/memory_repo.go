@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"polling_bot/internal/models"
+)
+
+// MemoryPollRepo хранит опросы в памяти процесса. Подходит для тестов
+// и локального запуска без Tarantool; данные теряются при перезапуске.
+type MemoryPollRepo struct {
+	mu    sync.RWMutex
+	polls map[string]models.Poll
+}
+
+func NewMemoryPollRepo() *MemoryPollRepo {
+	return &MemoryPollRepo{polls: make(map[string]models.Poll)}
+}
+
+func (r *MemoryPollRepo) SavePoll(ctx context.Context, poll models.Poll) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.polls[poll.ID] = clonePoll(poll)
+	return nil
+}
+
+func (r *MemoryPollRepo) IncrementOption(ctx context.Context, pollID, option string, delta int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	poll.Options[option] += delta
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) IncrementViews(ctx context.Context, pollID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	poll.Views++
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) GetPoll(ctx context.Context, id string) (models.Poll, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Poll{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	poll, ok := r.polls[id]
+	if !ok {
+		return models.Poll{}, ErrNotFound
+	}
+	return clonePoll(poll), nil
+}
+
+// GetPollSummary - то же, что GetPoll: голоса хранятся отдельно от опроса.
+func (r *MemoryPollRepo) GetPollSummary(ctx context.Context, id string) (models.Poll, error) {
+	return r.GetPoll(ctx, id)
+}
+
+func (r *MemoryPollRepo) GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	polls := make(map[string]models.Poll, len(ids))
+	for _, id := range ids {
+		if poll, ok := r.polls[id]; ok {
+			polls[id] = clonePoll(poll)
+		}
+	}
+	return polls, nil
+}
+
+func (r *MemoryPollRepo) ClosePoll(ctx context.Context, pollID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	poll.Closed = true
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) SetCreator(ctx context.Context, pollID, creator string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	poll.Creator = creator
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) SetPinnedPost(ctx context.Context, pollID, postID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	poll.PinnedPostID = postID
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) SetExpiryWarned(ctx context.Context, pollID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	poll.ExpiryWarned = true
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) SetFeatured(ctx context.Context, pollID string, featured bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	poll.Featured = featured
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) SetAllowedVoters(ctx context.Context, pollID string, voters []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	poll.AllowedVoters = nil
+	if len(voters) > 0 {
+		poll.AllowedVoters = append([]string(nil), voters...)
+	}
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) UpdateSettings(ctx context.Context, pollID string, update SettingsUpdate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	poll, ok := r.polls[pollID]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.Question != nil {
+		poll.Question = *update.Question
+	}
+	if update.RestrictToChannel != nil {
+		poll.RestrictToChannel = *update.RestrictToChannel
+	}
+	if update.Quorum != nil {
+		poll.Quorum = *update.Quorum
+	}
+	if update.MaxVotes != nil {
+		poll.MaxVotes = *update.MaxVotes
+	}
+	if update.ExpiresAt != nil {
+		poll.ExpiresAt = *update.ExpiresAt
+		poll.ExpiryWarned = false
+	}
+	r.polls[pollID] = poll
+	return nil
+}
+
+func (r *MemoryPollRepo) DeletePoll(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.polls[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.polls, id)
+	return nil
+}
+
+func (r *MemoryPollRepo) CountPolls(ctx context.Context, filter ListFilter) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	count := 0
+	for _, poll := range r.polls {
+		if filter.matches(poll) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListPolls обходит опросы в порядке ID, как первичный индекс Tarantool.
+func (r *MemoryPollRepo) ListPolls(ctx context.Context, filter ListFilter) ([]models.Poll, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.polls))
+	for id := range r.polls {
+		if id > filter.Cursor {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	limit := filter.pageLimit()
+	var page []models.Poll
+	for _, id := range ids {
+		poll := r.polls[id]
+		if !filter.matches(poll) {
+			continue
+		}
+		if len(page) == limit {
+			return page, page[len(page)-1].ID, nil
+		}
+		page = append(page, clonePoll(poll))
+	}
+	return page, "", nil
+}
+
+func clonePoll(poll models.Poll) models.Poll {
+	options := make(map[string]int, len(poll.Options))
+	for k, v := range poll.Options {
+		options[k] = v
+	}
+	poll.Options = options
+	if poll.OptionOrder != nil {
+		poll.OptionOrder = append([]string(nil), poll.OptionOrder...)
+	}
+	if poll.Tags != nil {
+		poll.Tags = append([]string(nil), poll.Tags...)
+	}
+	if poll.AllowedVoters != nil {
+		poll.AllowedVoters = append([]string(nil), poll.AllowedVoters...)
+	}
+	if poll.OptionEmoji != nil {
+		emoji := make(map[string]string, len(poll.OptionEmoji))
+		for k, v := range poll.OptionEmoji {
+			emoji[k] = v
+		}
+		poll.OptionEmoji = emoji
+	}
+	if poll.Weights != nil {
+		poll.Weights = copyCounts(poll.Weights)
+		poll.WeightedOptions = copyCounts(poll.WeightedOptions)
+	}
+	return poll
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	out := make(map[string]int, len(counts))
+	for k, v := range counts {
+		out[k] = v
+	}
+	return out
+}
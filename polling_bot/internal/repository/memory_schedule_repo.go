@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"polling_bot/internal/models"
+)
+
+// MemoryScheduleRepo хранит расписания в памяти процесса, как MemoryPollRepo.
+type MemoryScheduleRepo struct {
+	mu        sync.RWMutex
+	schedules map[string]models.Schedule
+}
+
+func NewMemoryScheduleRepo() *MemoryScheduleRepo {
+	return &MemoryScheduleRepo{schedules: make(map[string]models.Schedule)}
+}
+
+func (r *MemoryScheduleRepo) SaveSchedule(ctx context.Context, schedule models.Schedule) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schedules[schedule.ID] = cloneSchedule(schedule)
+	return nil
+}
+
+func (r *MemoryScheduleRepo) GetSchedule(ctx context.Context, id string) (models.Schedule, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Schedule{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schedule, ok := r.schedules[id]
+	if !ok {
+		return models.Schedule{}, ErrNotFound
+	}
+	return cloneSchedule(schedule), nil
+}
+
+func (r *MemoryScheduleRepo) DeleteSchedule(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.schedules[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.schedules, id)
+	return nil
+}
+
+func (r *MemoryScheduleRepo) ListSchedules(ctx context.Context, channelID string) ([]models.Schedule, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []models.Schedule
+	for _, schedule := range r.schedules {
+		if channelID == "" || schedule.ChannelID == channelID {
+			out = append(out, cloneSchedule(schedule))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func cloneSchedule(schedule models.Schedule) models.Schedule {
+	schedule.Options = append([]string(nil), schedule.Options...)
+	return schedule
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"polling_bot/internal/models"
+)
+
+// MemoryTemplateRepo хранит шаблоны в памяти процесса, как MemoryScheduleRepo.
+type MemoryTemplateRepo struct {
+	mu        sync.RWMutex
+	templates map[templateKey]models.Template
+}
+
+type templateKey struct {
+	scope, name string
+}
+
+func NewMemoryTemplateRepo() *MemoryTemplateRepo {
+	return &MemoryTemplateRepo{templates: make(map[templateKey]models.Template)}
+}
+
+func (r *MemoryTemplateRepo) CreateTemplate(ctx context.Context, template models.Template) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := templateKey{template.Scope, template.Name}
+	if _, ok := r.templates[key]; ok {
+		return ErrDuplicate
+	}
+	r.templates[key] = cloneTemplate(template)
+	return nil
+}
+
+func (r *MemoryTemplateRepo) GetTemplate(ctx context.Context, scope, name string) (models.Template, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Template{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	template, ok := r.templates[templateKey{scope, name}]
+	if !ok {
+		return models.Template{}, ErrNotFound
+	}
+	return cloneTemplate(template), nil
+}
+
+func (r *MemoryTemplateRepo) DeleteTemplate(ctx context.Context, scope, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := templateKey{scope, name}
+	if _, ok := r.templates[key]; !ok {
+		return ErrNotFound
+	}
+	delete(r.templates, key)
+	return nil
+}
+
+func (r *MemoryTemplateRepo) ListTemplates(ctx context.Context, scope string) ([]models.Template, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []models.Template
+	for key, template := range r.templates {
+		if key.scope == scope {
+			out = append(out, cloneTemplate(template))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func cloneTemplate(template models.Template) models.Template {
+	template.Options = append([]string(nil), template.Options...)
+	if template.Tags != nil {
+		template.Tags = append([]string(nil), template.Tags...)
+	}
+	return template
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"polling_bot/internal/models"
+)
+
+type usageKey struct {
+	day     int64
+	command string
+}
+
+// MemoryUsageRepo хранит счётчики команд в памяти процесса.
+type MemoryUsageRepo struct {
+	mu     sync.RWMutex
+	counts map[usageKey]int
+}
+
+func NewMemoryUsageRepo() *MemoryUsageRepo {
+	return &MemoryUsageRepo{counts: make(map[usageKey]int)}
+}
+
+func (r *MemoryUsageRepo) AddUsage(ctx context.Context, usage []models.CommandUsage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range usage {
+		r.counts[usageKey{day: usageDay(u.Day), command: u.Command}] += u.Count
+	}
+	return nil
+}
+
+func (r *MemoryUsageRepo) ListUsage(ctx context.Context, since time.Time) ([]models.CommandUsage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	from := usageDay(since)
+	var out []models.CommandUsage
+	for key, count := range r.counts {
+		if key.day >= from {
+			out = append(out, models.CommandUsage{Day: time.Unix(key.day, 0).UTC(), Command: key.command, Count: count})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Day.Equal(out[j].Day) {
+			return out[i].Day.Before(out[j].Day)
+		}
+		return out[i].Command < out[j].Command
+	})
+	return out, nil
+}
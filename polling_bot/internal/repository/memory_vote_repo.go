@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"polling_bot/internal/models"
+)
+
+// MemoryVoteRepo хранит голоса в памяти процесса рядом с опросами
+// MemoryPollRepo. Голоса защищены мьютексом репозитория опросов, поэтому
+// голос и счётчик опроса меняются вместе.
+type MemoryVoteRepo struct {
+	polls *MemoryPollRepo
+	votes map[string]map[string]models.Vote
+}
+
+func NewMemoryVoteRepo(polls *MemoryPollRepo) *MemoryVoteRepo {
+	return &MemoryVoteRepo{polls: polls, votes: make(map[string]map[string]models.Vote)}
+}
+
+func (r *MemoryVoteRepo) AddVote(ctx context.Context, vote models.Vote) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.polls.mu.Lock()
+	defer r.polls.mu.Unlock()
+	poll, ok := r.polls.polls[vote.PollID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if poll.Closed {
+		if poll.MaxVotes > 0 && len(r.votes[vote.PollID]) >= poll.MaxVotes {
+			return false, ErrPollFull
+		}
+		return false, ErrPollClosed
+	}
+	previous, voted := r.votes[vote.PollID][vote.UserID]
+	if voted && (!previous.Abstain || vote.Abstain) {
+		return false, ErrAlreadyVoted
+	}
+
+	r.putLocked(vote)
+	// SavePoll хранит копию опроса, поэтому его счётчики можно менять на месте
+	if voted {
+		// Воздержавшийся уже учтён в кворуме, меняется только его выбор
+		poll.Abstained--
+	}
+	switch {
+	case vote.Abstain:
+		poll.Abstained++
+	default:
+		poll.Options[vote.Choices[0]]++
+		if vote.Weight > 0 {
+			if poll.WeightedOptions == nil {
+				poll.WeightedOptions = make(map[string]int)
+			}
+			poll.WeightedOptions[vote.Choices[0]] += vote.Weight
+		}
+	}
+	voters := len(r.votes[vote.PollID])
+	poll.Closed = poll.Quorum > 0 && voters >= poll.Quorum || poll.MaxVotes > 0 && voters >= poll.MaxVotes
+	r.polls.polls[vote.PollID] = poll
+	return poll.Closed, nil
+}
+
+func (r *MemoryVoteRepo) GetVote(ctx context.Context, pollID, userID string) (models.Vote, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Vote{}, err
+	}
+
+	r.polls.mu.RLock()
+	defer r.polls.mu.RUnlock()
+	vote, ok := r.votes[pollID][userID]
+	if !ok {
+		return models.Vote{}, ErrNotFound
+	}
+	return cloneVote(vote), nil
+}
+
+func (r *MemoryVoteRepo) ListVotes(ctx context.Context, pollID string) ([]models.Vote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.polls.mu.RLock()
+	defer r.polls.mu.RUnlock()
+	votes := make([]models.Vote, 0, len(r.votes[pollID]))
+	for _, vote := range r.votes[pollID] {
+		votes = append(votes, cloneVote(vote))
+	}
+	sort.Slice(votes, func(i, j int) bool { return votes[i].UserID < votes[j].UserID })
+	return votes, nil
+}
+
+func (r *MemoryVoteRepo) CountVotes(ctx context.Context, pollID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.polls.mu.RLock()
+	defer r.polls.mu.RUnlock()
+	return len(r.votes[pollID]), nil
+}
+
+func (r *MemoryVoteRepo) ImportVotes(ctx context.Context, votes []models.Vote) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.polls.mu.Lock()
+	defer r.polls.mu.Unlock()
+	for _, vote := range votes {
+		r.putLocked(vote)
+	}
+	return nil
+}
+
+func (r *MemoryVoteRepo) DeleteVotes(ctx context.Context, pollID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.polls.mu.Lock()
+	defer r.polls.mu.Unlock()
+	delete(r.votes, pollID)
+	return nil
+}
+
+func (r *MemoryVoteRepo) putLocked(vote models.Vote) {
+	votes, ok := r.votes[vote.PollID]
+	if !ok {
+		votes = make(map[string]models.Vote)
+		r.votes[vote.PollID] = votes
+	}
+	votes[vote.UserID] = cloneVote(vote)
+}
+
+func cloneVote(vote models.Vote) models.Vote {
+	if vote.Choices != nil {
+		vote.Choices = append([]string(nil), vote.Choices...)
+	}
+	return vote
+}
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS polls (
+    id         TEXT PRIMARY KEY,
+    creator    TEXT NOT NULL,
+    question   TEXT NOT NULL,
+    voters     JSONB NOT NULL DEFAULT '{}'::jsonb,
+    options    JSONB NOT NULL DEFAULT '{}'::jsonb,
+    is_closed  BOOLEAN NOT NULL DEFAULT FALSE,
+    channel_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS polls_creator_idx ON polls (creator, id);
+CREATE INDEX IF NOT EXISTS polls_channel_idx ON polls (channel_id, id);
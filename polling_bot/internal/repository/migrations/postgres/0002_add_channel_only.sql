@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS channel_only BOOLEAN NOT NULL DEFAULT FALSE;
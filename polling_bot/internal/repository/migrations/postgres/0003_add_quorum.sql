@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS quorum INTEGER NOT NULL DEFAULT 0;
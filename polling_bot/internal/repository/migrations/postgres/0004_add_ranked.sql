@@ -0,0 +1,3 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS ranked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS option_order JSONB;
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS ballots JSONB;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS poll_schedules (
+    id           TEXT PRIMARY KEY,
+    creator      TEXT NOT NULL,
+    channel_id   TEXT NOT NULL,
+    cron         TEXT NOT NULL,
+    question     TEXT NOT NULL,
+    options      JSONB NOT NULL DEFAULT '[]'::jsonb,
+    channel_only BOOLEAN NOT NULL DEFAULT FALSE,
+    quorum       INTEGER NOT NULL DEFAULT 0,
+    ranked       BOOLEAN NOT NULL DEFAULT FALSE,
+    exclusive    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ,
+    last_run_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS poll_schedules_channel_idx ON poll_schedules (channel_id, id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS poll_audit (
+    seq     BIGSERIAL PRIMARY KEY,
+    id      TEXT NOT NULL UNIQUE,
+    poll_id TEXT NOT NULL,
+    actor   TEXT NOT NULL,
+    action  TEXT NOT NULL,
+    detail  TEXT NOT NULL DEFAULT '',
+    at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS poll_audit_poll_idx ON poll_audit (poll_id, seq);
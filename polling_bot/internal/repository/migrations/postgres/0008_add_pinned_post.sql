@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS pinned_post_id TEXT NOT NULL DEFAULT '';
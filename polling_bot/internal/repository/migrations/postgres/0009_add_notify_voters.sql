@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS notify_voters BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id  TEXT NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    user_id  TEXT NOT NULL,
+    choices  JSONB NOT NULL DEFAULT '[]'::jsonb,
+    voted_at TIMESTAMPTZ,
+    PRIMARY KEY (poll_id, user_id)
+);
+
+-- Голоса, записанные в самих опросах, переносятся в poll_votes;
+-- счётчики вариантов их уже учитывают
+INSERT INTO poll_votes (poll_id, user_id, choices)
+SELECT p.id, v.key, COALESCE(p.ballots -> v.key, '[]'::jsonb)
+FROM polls p, jsonb_each(p.voters) v
+WHERE v.value = 'true'::jsonb
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE polls DROP COLUMN IF EXISTS voters;
+ALTER TABLE polls DROP COLUMN IF EXISTS ballots;
@@ -0,0 +1 @@
+ALTER TABLE poll_votes ADD COLUMN IF NOT EXISTS post_id TEXT NOT NULL DEFAULT '';
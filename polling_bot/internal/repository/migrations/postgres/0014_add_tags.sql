@@ -0,0 +1,4 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Фильтр списка опросов по метке
+CREATE INDEX IF NOT EXISTS polls_tags_idx ON polls USING GIN (tags);
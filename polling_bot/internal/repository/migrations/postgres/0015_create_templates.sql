@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS poll_templates (
+    scope         TEXT NOT NULL,
+    name          TEXT NOT NULL,
+    creator       TEXT NOT NULL,
+    question      TEXT NOT NULL,
+    options       JSONB NOT NULL DEFAULT '[]'::jsonb,
+    description   TEXT NOT NULL DEFAULT '',
+    tags          JSONB NOT NULL DEFAULT '[]'::jsonb,
+    channel_only  BOOLEAN NOT NULL DEFAULT FALSE,
+    quorum        INTEGER NOT NULL DEFAULT 0,
+    ranked        BOOLEAN NOT NULL DEFAULT FALSE,
+    exclusive     BOOLEAN NOT NULL DEFAULT FALSE,
+    pin           BOOLEAN NOT NULL DEFAULT FALSE,
+    notify_voters BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Срок создаваемых опросов в секундах; 0 - срок по умолчанию
+    expires       BIGINT NOT NULL DEFAULT 0,
+    no_expire     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ,
+    PRIMARY KEY (scope, name)
+);
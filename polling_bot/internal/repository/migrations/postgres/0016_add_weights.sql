@@ -0,0 +1,6 @@
+-- Веса участников и суммы весов по вариантам; {} - опрос без весов
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS weights JSONB NOT NULL DEFAULT '{}'::jsonb;
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS weighted_options JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- Вес, с которым учтён голос; 0 - опрос без весов
+ALTER TABLE poll_votes ADD COLUMN IF NOT EXISTS weight INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS allow_abstain BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS abstained INTEGER NOT NULL DEFAULT 0;
+
+-- Воздержавшийся участник: бюллетень пуст, счётчики вариантов не тронуты
+ALTER TABLE poll_votes ADD COLUMN IF NOT EXISTS abstain BOOLEAN NOT NULL DEFAULT FALSE;
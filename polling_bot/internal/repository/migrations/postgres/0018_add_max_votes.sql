@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS max_votes INTEGER NOT NULL DEFAULT 0;
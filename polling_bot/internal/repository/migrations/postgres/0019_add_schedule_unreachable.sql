@@ -0,0 +1 @@
+ALTER TABLE poll_schedules ADD COLUMN IF NOT EXISTS unreachable_at TIMESTAMPTZ;
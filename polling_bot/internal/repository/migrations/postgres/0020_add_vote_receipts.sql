@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS vote_receipts BOOLEAN NOT NULL DEFAULT FALSE;
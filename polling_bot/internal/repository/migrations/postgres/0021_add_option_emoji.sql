@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS option_emoji JSONB NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS no_self_vote BOOLEAN NOT NULL DEFAULT FALSE;
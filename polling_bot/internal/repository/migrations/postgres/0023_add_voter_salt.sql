@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS voter_salt TEXT NOT NULL DEFAULT '';
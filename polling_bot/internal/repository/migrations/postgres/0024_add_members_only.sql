@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS members_only BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS views INTEGER NOT NULL DEFAULT 0;
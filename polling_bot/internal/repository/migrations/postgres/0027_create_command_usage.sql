@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS command_usage (
+    day     DATE NOT NULL,
+    command TEXT NOT NULL,
+    count   INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, command)
+);
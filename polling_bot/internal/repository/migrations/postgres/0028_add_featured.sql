@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS featured BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE polls ADD COLUMN IF NOT EXISTS allowed_voters JSONB NOT NULL DEFAULT '[]'::jsonb;
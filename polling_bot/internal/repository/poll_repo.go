@@ -2,190 +2,623 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"strings"
+	"math/rand"
 	"time"
 
 	"polling_bot/internal/models"
 
+	"github.com/rs/zerolog"
 	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
 )
 
 type PollRepository interface {
 	SavePoll(ctx context.Context, poll models.Poll) error
-	AddVoteAtomic(ctx context.Context, poll models.Poll) error
+	// IncrementOption прибавляет delta к счётчику варианта, не трогая участников.
+	IncrementOption(ctx context.Context, pollID, option string, delta int) error
+	// IncrementViews прибавляет один просмотр итогов опроса.
+	IncrementViews(ctx context.Context, pollID string) error
 	GetPoll(ctx context.Context, id string) (models.Poll, error)
+	// GetPollSummary возвращает опрос, как GetPoll, но не читает карты
+	// участников, если хранилище держит их в самом опросе: итогам хватает
+	// счётчиков. Опросы, голоса которых ещё не перенесены в хранилище
+	// голосов, читаются так заметно быстрее.
+	GetPollSummary(ctx context.Context, id string) (models.Poll, error)
+	// GetPolls читает сводки опросов, как GetPollSummary, сразу по списку
+	// ID: число обращений к хранилищу не растёт с числом опросов. Опросов,
+	// которых нет, в ответе нет; ошибкой это не считается.
+	GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error)
 	ClosePoll(ctx context.Context, pollID string) error
+	// SetCreator передаёт опрос другому пользователю, не трогая остальные поля.
+	SetCreator(ctx context.Context, pollID, creator string) error
+	// SetPinnedPost запоминает закреплённое сообщение опроса.
+	SetPinnedPost(ctx context.Context, pollID, postID string) error
+	// SetExpiryWarned отмечает, что канал предупреждён о закрытии опроса по сроку.
+	SetExpiryWarned(ctx context.Context, pollID string) error
+	// UpdateSettings записывает только заданные поля update.
+	UpdateSettings(ctx context.Context, pollID string, update SettingsUpdate) error
+	// SetFeatured ставит или снимает отметку администратора.
+	SetFeatured(ctx context.Context, pollID string, featured bool) error
+	// SetAllowedVoters заменяет список участников, которым разрешено
+	// голосовать; пустой список снимает ограничение.
+	SetAllowedVoters(ctx context.Context, pollID string, voters []string) error
 	DeletePoll(ctx context.Context, id string) error
+	// ListPolls возвращает страницу опросов по фильтру и курсор следующей
+	// страницы; пустой курсор означает, что опросов больше нет.
+	ListPolls(ctx context.Context, filter ListFilter) ([]models.Poll, string, error)
+	// CountPolls возвращает число опросов по фильтру, не читая сами опросы;
+	// курсор и размер страницы фильтра не учитываются.
+	CountPolls(ctx context.Context, filter ListFilter) (int, error)
+}
+
+// Connector - запросы к Tarantool, нужные репозиторию. Запрос отменяется
+// вместе с контекстом ctx, а не дожидается таймаута соединения.
+type Connector interface {
+	ConnectedNow() bool
+	Insert(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error)
+	Replace(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error)
+	Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error)
+	Delete(ctx context.Context, space, index interface{}, key interface{}) (*tarantool.Response, error)
+	SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error
+	Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error)
+}
+
+// tarantoolRepo - общее у репозиториев опросов и голосов: соединение
+// и политика повторов при конфликтах и переключении экземпляра.
+type tarantoolRepo struct {
+	conn   Connector
+	retry  RetryPolicy
+	logger zerolog.Logger
+
+	// Подменяются в тестах
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func() float64
+}
+
+func newTarantoolRepo(conn Connector, retry RetryPolicy, logger zerolog.Logger) tarantoolRepo {
+	return tarantoolRepo{
+		conn:   conn,
+		retry:  retry.normalized(),
+		logger: logger,
+		sleep:  sleepContext,
+		jitter: rand.Float64,
+	}
 }
 
 type TarantoolPollRepo struct {
-	conn      *tarantool.Connection
+	tarantoolRepo
 	spaceName string
 }
 
-func NewTarantoolPollRepo(conn *tarantool.Connection, spaceName string) *TarantoolPollRepo {
-	return &TarantoolPollRepo{
-		conn:      conn,
-		spaceName: spaceName,
-	}
+func NewTarantoolPollRepo(conn Connector, spaceName string, retry RetryPolicy, logger zerolog.Logger) *TarantoolPollRepo {
+	return &TarantoolPollRepo{tarantoolRepo: newTarantoolRepo(conn, retry, logger), spaceName: spaceName}
 }
 
 func (r *TarantoolPollRepo) SavePoll(ctx context.Context, poll models.Poll) error {
-	if err := ctx.Err(); err != nil {
+	return r.withFailover(ctx, func() error {
+		return r.savePoll(ctx, poll)
+	})
+}
+
+func (r *TarantoolPollRepo) savePoll(ctx context.Context, poll models.Poll) error {
+	if err := r.ready(ctx); err != nil {
 		return err
 	}
 
-	// Порядок полей должен точно соответствовать структуре space в Tarantool
-	data := []interface{}{
-		poll.ID,       // field 1: id (string)
-		poll.Creator,  // field 2: creator (string)
-		poll.Question, // field 3: question (string)
-		poll.Voters,   // field 4: voters (map)
-		poll.Options,  // field 5: options (map)
-		poll.Closed,   // field 6: is_closed (boolean)
+	_, err := r.conn.Replace(ctx, r.spaceName, newPollTuple(poll))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения опроса: %w", classifyError(err))
+	}
+	return nil
+}
+
+// Хранимые функции init.lua, которые меняют опрос на месте, чтобы голос
+// передавал по сети только участника и бюллетень, а не весь опрос.
+const (
+	funcAddVote         = "polls_add_vote"
+	funcIncrementOption = "polls_increment_option"
+	funcIncrementViews  = "polls_increment_views"
+	funcSetFeatured     = "polls_set_featured"
+	funcSetVoters       = "polls_set_voters"
+	funcDeleteVotes     = "polls_delete_votes"
+	funcCountPolls      = "polls_count"
+	funcCountVotes      = "polls_count_votes"
+	funcGetSummary      = "polls_get_summary"
+	funcGetMany         = "polls_get_many"
+)
+
+// Ответы хранимых функций
+const (
+	voteRecorded = "ok"
+	voteQuorum   = "quorum"
+	voteClosed   = "closed"
+	voteFull     = "full"
+	voteRepeated = "voted"
+	voteNotFound = "not_found"
+)
+
+// IncrementOption выполняется без повторов и переключения экземпляра:
+// прибавка, ответ на которую потерялся, могла уже примениться.
+func (r *TarantoolPollRepo) IncrementOption(ctx context.Context, pollID, option string, delta int) error {
+	if err := r.ready(ctx); err != nil {
+		return err
 	}
 
-	_, err := r.conn.Replace(r.spaceName, data)
+	resp, err := r.conn.Call17(ctx, funcIncrementOption, []interface{}{r.spaceName, pollID, option, delta})
 	if err != nil {
-		return fmt.Errorf("ошибка сохранения опроса: %w", err)
+		return fmt.Errorf("ошибка изменения счётчика варианта: %w", classifyError(err))
+	}
+	if callStatus(resp) == voteNotFound {
+		return ErrNotFound
 	}
 	return nil
 }
 
-func (r *TarantoolPollRepo) AddVoteAtomic(ctx context.Context, poll models.Poll) error {
-	if err := ctx.Err(); err != nil {
+// IncrementViews, как и IncrementOption, выполняется без повторов: лишний
+// просмотр хуже потерянного.
+func (r *TarantoolPollRepo) IncrementViews(ctx context.Context, pollID string) error {
+	if err := r.ready(ctx); err != nil {
 		return err
 	}
 
-	for i := 0; i < 5; i++ {
-		_, err := r.conn.Update(r.spaceName, "primary", []interface{}{poll.ID}, []interface{}{
-			[]interface{}{"=", 3, poll.Voters}, 
-			[]interface{}{"=", 4, poll.Options}, 
-		})
-
-		if err == nil {
-			return nil
-		}
+	resp, err := r.conn.Call17(ctx, funcIncrementViews, []interface{}{r.spaceName, pollID})
+	if err != nil {
+		return fmt.Errorf("ошибка учёта просмотра опроса: %w", classifyError(err))
+	}
+	if callStatus(resp) == voteNotFound {
+		return ErrNotFound
+	}
+	return nil
+}
 
-		if strings.Contains(err.Error(), "transaction conflict") {
-			time.Sleep(time.Duration(i+1) * 20 * time.Millisecond)
-			continue
-		}
-		return fmt.Errorf("ошибка сохранения голоса: %w", err)
+// callCount достаёт число-ответ хранимой функции.
+func callCount(resp *tarantool.Response) (int, error) {
+	if resp == nil || len(resp.Data) == 0 {
+		return 0, fmt.Errorf("хранимая функция не вернула число")
+	}
+	switch n := resp.Data[0].(type) {
+	case int64:
+		return int(n), nil
+	case uint64:
+		return int(n), nil
+	case int:
+		return n, nil
 	}
-	return errors.New("не удалось сохранить голос после 5 попыток")
+	return 0, fmt.Errorf("хранимая функция вернула %T вместо числа", resp.Data[0])
 }
 
+// callStatus достаёт строку-ответ хранимой функции.
+func callStatus(resp *tarantool.Response) string {
+	if resp == nil || len(resp.Data) == 0 {
+		return ""
+	}
+	status, _ := resp.Data[0].(string)
+	return status
+}
 
 func (r *TarantoolPollRepo) GetPoll(ctx context.Context, id string) (models.Poll, error) {
-	if err := ctx.Err(); err != nil {
+	var poll models.Poll
+	err := r.withFailover(ctx, func() (err error) {
+		poll, err = r.getPoll(ctx, id)
+		return err
+	})
+	return poll, err
+}
+
+func (r *TarantoolPollRepo) getPoll(ctx context.Context, id string) (models.Poll, error) {
+	if err := r.ready(ctx); err != nil {
+		return models.Poll{}, err
+	}
+
+	var tuples []pollTuple
+	err := r.conn.SelectTyped(ctx, r.spaceName, "primary", 0, 1, tarantool.IterEq, []interface{}{id}, &tuples)
+	if err != nil {
+		return models.Poll{}, fmt.Errorf("ошибка получения опроса: %w", classifyError(err))
+	}
+
+	if len(tuples) == 0 {
+		return models.Poll{}, ErrNotFound
+	}
+
+	return tuples[0].toModel(), nil
+}
+
+func (r *TarantoolPollRepo) GetPollSummary(ctx context.Context, id string) (models.Poll, error) {
+	var poll models.Poll
+	err := r.withFailover(ctx, func() (err error) {
+		poll, err = r.getPollSummary(ctx, id)
+		return err
+	})
+	return poll, err
+}
+
+// getPollSummary читает опрос хранимой функцией, которая отдаёт кортеж с
+// пустыми voters и ballots: карты участников не передаются по сети и не
+// разбираются.
+func (r *TarantoolPollRepo) getPollSummary(ctx context.Context, id string) (models.Poll, error) {
+	if err := r.ready(ctx); err != nil {
 		return models.Poll{}, err
 	}
 
-	res, err := r.conn.Select(r.spaceName, "primary", 0, 1, tarantool.IterEq, []interface{}{id})
+	resp, err := r.conn.Call17(ctx, funcGetSummary, []interface{}{r.spaceName, id})
 	if err != nil {
+		return models.Poll{}, fmt.Errorf("ошибка получения опроса: %w", classifyError(err))
+	}
+	if resp == nil || len(resp.Data) == 0 || resp.Data[0] == nil {
+		return models.Poll{}, ErrNotFound
+	}
+	// Call17 отдаёт кортеж без типа, поэтому он перекодируется в pollTuple
+	data, err := msgpack.Marshal(resp.Data[0])
+	if err != nil {
+		return models.Poll{}, fmt.Errorf("ошибка получения опроса: %w", err)
+	}
+	var t pollTuple
+	if err := msgpack.Unmarshal(data, &t); err != nil {
 		return models.Poll{}, fmt.Errorf("ошибка получения опроса: %w", err)
 	}
+	return t.toModel(), nil
+}
+
+// Сколько опросов GetPolls запрашивает за один вызов: ответ на длинный
+// список не должен занимать соединение надолго
+const getPollsBatch = 100
+
+func (r *TarantoolPollRepo) GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error) {
+	polls := make(map[string]models.Poll, len(ids))
+	for start := 0; start < len(ids); start += getPollsBatch {
+		batch := ids[start:min(start+getPollsBatch, len(ids))]
+		err := r.withFailover(ctx, func() error {
+			return r.getPolls(ctx, batch, polls)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return polls, nil
+}
 
-	if len(res.Data) == 0 {
-		return models.Poll{}, errors.New("опрос не найден")
+// getPolls читает сводки опросов batch одной хранимой функцией, которая,
+// как polls_get_summary, не отдаёт карты участников.
+func (r *TarantoolPollRepo) getPolls(ctx context.Context, batch []string, polls map[string]models.Poll) error {
+	if err := r.ready(ctx); err != nil {
+		return err
 	}
 
-	return parsePollTuple(res.Data[0])
+	resp, err := r.conn.Call17(ctx, funcGetMany, []interface{}{r.spaceName, batch})
+	if err != nil {
+		return fmt.Errorf("ошибка получения опросов: %w", classifyError(err))
+	}
+	if resp == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	data, err := msgpack.Marshal(resp.Data[0])
+	if err != nil {
+		return fmt.Errorf("ошибка получения опросов: %w", err)
+	}
+	var tuples []pollTuple
+	if err := msgpack.Unmarshal(data, &tuples); err != nil {
+		return fmt.Errorf("ошибка получения опросов: %w", err)
+	}
+	for _, t := range tuples {
+		polls[t.ID] = t.toModel()
+	}
+	return nil
 }
 
 func (r *TarantoolPollRepo) ClosePoll(ctx context.Context, pollID string) error {
-	if err := ctx.Err(); err != nil {
+	return r.withFailover(ctx, func() error {
+		return r.closePoll(ctx, pollID)
+	})
+}
+
+func (r *TarantoolPollRepo) closePoll(ctx context.Context, pollID string) error {
+	if err := r.ready(ctx); err != nil {
 		return err
 	}
 
-	_, err := r.conn.Update(r.spaceName, "primary", []interface{}{pollID}, []interface{}{
-		[]interface{}{"=", 6, true},
+	resp, err := r.conn.Update(ctx, r.spaceName, "primary", []interface{}{pollID}, []interface{}{
+		[]interface{}{"=", fieldClosed, true},
 	})
 	if err != nil {
-		return fmt.Errorf("ошибка закрытия опроса: %w", err)
+		return fmt.Errorf("ошибка закрытия опроса: %w", classifyError(err))
+	}
+	if len(resp.Data) == 0 {
+		return ErrNotFound
 	}
+	r.upgradeLegacy(ctx, pollID, resp)
 	return nil
 }
 
-func (r *TarantoolPollRepo) DeletePoll(ctx context.Context, id string) error {
-	if err := ctx.Err(); err != nil {
+func (r *TarantoolPollRepo) SetCreator(ctx context.Context, pollID, creator string) error {
+	return r.withFailover(ctx, func() error {
+		return r.setCreator(ctx, pollID, creator)
+	})
+}
+
+func (r *TarantoolPollRepo) setCreator(ctx context.Context, pollID, creator string) error {
+	if err := r.ready(ctx); err != nil {
 		return err
 	}
 
-	_, err := r.conn.Delete(r.spaceName, "primary", []interface{}{id})
+	resp, err := r.conn.Update(ctx, r.spaceName, "primary", []interface{}{pollID}, []interface{}{
+		[]interface{}{"=", fieldCreator, creator},
+	})
 	if err != nil {
-		return fmt.Errorf("ошибка удаления опроса: %w", err)
+		return fmt.Errorf("ошибка смены создателя опроса: %w", classifyError(err))
 	}
+	if len(resp.Data) == 0 {
+		return ErrNotFound
+	}
+	r.upgradeLegacy(ctx, pollID, resp)
 	return nil
 }
 
-func parsePollTuple(data interface{}) (models.Poll, error) {
-	tuple, ok := data.([]interface{})
-	if !ok || len(tuple) < 6 {
-		return models.Poll{}, errors.New("некорректный формат данных опроса")
+// SetPinnedPost обновляет поле, которого нет в кортежах старше этого поля.
+// Сообщение закрепляется сразу после создания опроса, а новые кортежи
+// записываются со всеми полями.
+func (r *TarantoolPollRepo) SetPinnedPost(ctx context.Context, pollID, postID string) error {
+	return r.withFailover(ctx, func() error {
+		return r.setPinnedPost(ctx, pollID, postID)
+	})
+}
+
+func (r *TarantoolPollRepo) setPinnedPost(ctx context.Context, pollID, postID string) error {
+	if err := r.ready(ctx); err != nil {
+		return err
 	}
 
-	poll := models.Poll{
-		ID:       toString(tuple[0]), 
-		Creator:  toString(tuple[1]), 
-		Question: toString(tuple[2]), 
-		Closed:   toBool(tuple[5]), 
+	resp, err := r.conn.Update(ctx, r.spaceName, "primary", []interface{}{pollID}, []interface{}{
+		[]interface{}{"=", fieldPinned, postID},
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения закреплённого сообщения: %w", classifyError(err))
+	}
+	if len(resp.Data) == 0 {
+		return ErrNotFound
 	}
+	r.upgradeLegacy(ctx, pollID, resp)
+	return nil
+}
 
-	if voters, ok := tuple[3].(map[interface{}]interface{}); ok {
-		poll.Voters = make(map[string]bool, len(voters))
-		for k, v := range voters {
-			poll.Voters[toString(k)] = toBool(v)
-		}
+// SetExpiryWarned, как и SetPinnedPost, меняет одно поле и не затирает
+// голоса, записанные после чтения опроса.
+func (r *TarantoolPollRepo) SetExpiryWarned(ctx context.Context, pollID string) error {
+	return r.withFailover(ctx, func() error {
+		return r.setExpiryWarned(ctx, pollID)
+	})
+}
+
+func (r *TarantoolPollRepo) setExpiryWarned(ctx context.Context, pollID string) error {
+	if err := r.ready(ctx); err != nil {
+		return err
 	}
 
-	if opts, ok := tuple[4].(map[interface{}]interface{}); ok {
-		poll.Options = make(map[string]int, len(opts))
-		for k, v := range opts {
-			poll.Options[toString(k)] = toInt(v)
-		}
+	resp, err := r.conn.Update(ctx, r.spaceName, "primary", []interface{}{pollID}, []interface{}{
+		[]interface{}{"=", fieldWarned, true},
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения предупреждения о сроке опроса: %w", classifyError(err))
+	}
+	if len(resp.Data) == 0 {
+		return ErrNotFound
 	}
+	r.upgradeLegacy(ctx, pollID, resp)
+	return nil
+}
 
-	return poll, nil
+// SetFeatured пишет отметку хранимой функцией: у кортежей до версии 2 поля
+// featured нет, и update-запрос не смог бы его записать. Повтор безопасен:
+// отметка ставится, а не переключается.
+func (r *TarantoolPollRepo) SetFeatured(ctx context.Context, pollID string, featured bool) error {
+	return r.withFailover(ctx, func() error {
+		return r.setFeatured(ctx, pollID, featured)
+	})
 }
 
-func toString(val interface{}) string {
-	if s, ok := val.(string); ok {
-		return s
+func (r *TarantoolPollRepo) setFeatured(ctx context.Context, pollID string, featured bool) error {
+	if err := r.ready(ctx); err != nil {
+		return err
+	}
+
+	resp, err := r.conn.Call17(ctx, funcSetFeatured, []interface{}{r.spaceName, pollID, featured})
+	if err != nil {
+		return fmt.Errorf("ошибка отметки опроса: %w", classifyError(err))
+	}
+	if callStatus(resp) == voteNotFound {
+		return ErrNotFound
 	}
-	return fmt.Sprintf("%v", val)
+	return nil
 }
 
-func toBool(val interface{}) bool {
-	switch v := val.(type) {
-	case bool:
-		return v
-	case int64:
-		return v != 0
-	case uint64:
-		return v != 0
-	case string:
-		return strings.ToLower(v) == "true"
-	default:
-		return false
+// SetAllowedVoters, как и SetFeatured, пишет поле версии 3 хранимой
+// функцией. Пустой список хранится как NULL.
+func (r *TarantoolPollRepo) SetAllowedVoters(ctx context.Context, pollID string, voters []string) error {
+	return r.withFailover(ctx, func() error {
+		return r.setAllowedVoters(ctx, pollID, voters)
+	})
+}
+
+func (r *TarantoolPollRepo) setAllowedVoters(ctx context.Context, pollID string, voters []string) error {
+	if err := r.ready(ctx); err != nil {
+		return err
+	}
+
+	var list interface{}
+	if len(voters) > 0 {
+		list = voters
 	}
+	resp, err := r.conn.Call17(ctx, funcSetVoters, []interface{}{r.spaceName, pollID, list})
+	if err != nil {
+		return fmt.Errorf("ошибка изменения списка участников: %w", classifyError(err))
+	}
+	if callStatus(resp) == voteNotFound {
+		return ErrNotFound
+	}
+	return nil
 }
 
-func toInt(val interface{}) int {
-	switch v := val.(type) {
-	case int:
-		return v
-	case int64:
-		return int(v)
-	case uint64:
-		return int(v)
-	case float64:
-		return int(v)
-	default:
-		return 0
+func (r *TarantoolPollRepo) DeletePoll(ctx context.Context, id string) error {
+	return r.withFailover(ctx, func() error {
+		return r.deletePoll(ctx, id)
+	})
+}
+
+func (r *TarantoolPollRepo) deletePoll(ctx context.Context, id string) error {
+	if err := r.ready(ctx); err != nil {
+		return err
 	}
+
+	resp, err := r.conn.Delete(ctx, r.spaceName, "primary", []interface{}{id})
+	if err != nil {
+		return fmt.Errorf("ошибка удаления опроса: %w", classifyError(err))
+	}
+	if len(resp.Data) == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
+const (
+	// Сколько кортежей запрашивается из индекса за один Select
+	listScanBatch = 100
+	// Сколько кортежей ListPolls просматривает за вызов, прежде чем отдать неполную страницу
+	listScanLimit = 1000
+)
+
+// CountPolls считает опросы хранимой функцией: кортежи не передаются по
+// сети, а без предикатов, кроме создателя или канала, хватает index:count.
+func (r *TarantoolPollRepo) CountPolls(ctx context.Context, filter ListFilter) (int, error) {
+	var count int
+	err := r.withFailover(ctx, func() (err error) {
+		count, err = r.countPolls(ctx, filter)
+		return err
+	})
+	return count, err
+}
+
+func (r *TarantoolPollRepo) countPolls(ctx context.Context, filter ListFilter) (int, error) {
+	if err := r.ready(ctx); err != nil {
+		return 0, err
+	}
+
+	// Пустой предикат передаётся как nil: Lua не проверяет его
+	optional := func(s string) interface{} {
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+	args := []interface{}{r.spaceName, optional(filter.Creator), optional(filter.ChannelID), nil, optional(filter.Tag), nil, nil, nil}
+	if filter.Closed != nil {
+		args[3] = *filter.Closed
+	}
+	if filter.Featured != nil {
+		args[7] = *filter.Featured
+	}
+	// created_at хранится в секундах: строгие границы округляются так,
+	// чтобы совпасть со сравнением времени в ListPolls
+	if !filter.CreatedAfter.IsZero() {
+		args[5] = filter.CreatedAfter.Unix()
+	}
+	if before := filter.CreatedBefore; !before.IsZero() {
+		seconds := before.Unix()
+		if before.Nanosecond() > 0 {
+			seconds++
+		}
+		args[6] = seconds
+	}
+
+	resp, err := r.conn.Call17(ctx, funcCountPolls, args)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта опросов: %w", classifyError(err))
+	}
+	return callCount(resp)
+}
+
+// ListPolls сканирует подходящий индекс: вторичный по создателю или каналу
+// (EQ, затем GT по паре ключ+id), иначе первичный (GT по id). Остальные
+// предикаты проверяются на стороне бота.
+func (r *TarantoolPollRepo) ListPolls(ctx context.Context, filter ListFilter) ([]models.Poll, string, error) {
+	var (
+		polls []models.Poll
+		next  string
+	)
+	err := r.withFailover(ctx, func() (err error) {
+		polls, next, err = r.listPolls(ctx, filter)
+		return err
+	})
+	return polls, next, err
+}
+
+func (r *TarantoolPollRepo) listPolls(ctx context.Context, filter ListFilter) ([]models.Poll, string, error) {
+	if err := r.ready(ctx); err != nil {
+		return nil, "", err
+	}
+
+	index, prefix, inRange := "primary", []interface{}{}, func(models.Poll) bool { return true }
+	switch {
+	case filter.Creator != "":
+		index, prefix = "creator", []interface{}{filter.Creator}
+		inRange = func(p models.Poll) bool { return p.Creator == filter.Creator }
+	case filter.ChannelID != "":
+		index, prefix = "channel", []interface{}{filter.ChannelID}
+		inRange = func(p models.Poll) bool { return p.ChannelID == filter.ChannelID }
+	}
+
+	limit := filter.pageLimit()
+	page := make([]models.Poll, 0, limit)
+	lastID := filter.Cursor
+
+	for scanned := 0; scanned < listScanLimit; {
+		key, iter := prefix, uint32(tarantool.IterEq)
+		if lastID != "" {
+			key = append(append([]interface{}{}, prefix...), lastID)
+			iter = tarantool.IterGt
+		}
+
+		var tuples []pollTuple
+		err := r.conn.SelectTyped(ctx, r.spaceName, index, 0, listScanBatch, iter, key, &tuples)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка получения списка опросов: %w", classifyError(err))
+		}
+
+		for _, t := range tuples {
+			poll := t.toModel()
+			if !inRange(poll) {
+				return page, "", nil
+			}
+			scanned++
+			if filter.matches(poll) {
+				if len(page) == limit {
+					return page, page[limit-1].ID, nil
+				}
+				page = append(page, poll)
+			}
+			lastID = poll.ID
+		}
+		if len(tuples) < listScanBatch {
+			return page, "", nil
+		}
+	}
+
+	// Просмотр ограничен, чтобы редкий фильтр не сканировал весь space за один
+	// вызов: страница может быть неполной, курсор продолжает с последнего опроса.
+	return page, lastID, nil
+}
+
+// ready отсекает запросы по отменённому контексту и пока драйвер
+// переподключается, чтобы сервис получал ErrUnavailable, а не ошибку драйвера.
+func (r *tarantoolRepo) ready(ctx context.Context) error {
+	return connReady(ctx, r.conn)
+}
+
+func connReady(ctx context.Context, conn Connector) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !conn.ConnectedNow() {
+		return ErrUnavailable
+	}
+	return nil
+}
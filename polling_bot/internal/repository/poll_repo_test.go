@@ -0,0 +1,1056 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// fakeConn имитирует соединение с Tarantool: хранит кортежи в памяти
+// и позволяет переключать состояние соединения и подсовывать ошибки.
+type fakeConn struct {
+	mu         sync.Mutex
+	connected  bool
+	tuples     map[string]pollTuple
+	votes      map[[2]string]voteTuple
+	updateErrs []error
+	callErrs   []error
+	calls      map[string]int
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		connected: true,
+		tuples:    make(map[string]pollTuple),
+		votes:     make(map[[2]string]voteTuple),
+		calls:     make(map[string]int),
+	}
+}
+
+func (f *fakeConn) setConnected(v bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.connected = v
+}
+
+func (f *fakeConn) ConnectedNow() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected
+}
+
+func (f *fakeConn) check(op string) error {
+	f.calls[op]++
+	if !f.connected {
+		return tarantool.ClientError{Code: tarantool.ErrConnectionNotReady, Msg: "connection is not ready"}
+	}
+	return nil
+}
+
+func (f *fakeConn) Insert(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeConn: Insert не используется")
+}
+
+func (f *fakeConn) Replace(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check("Replace"); err != nil {
+		return nil, err
+	}
+
+	// Прогоняем кортеж через msgpack, как это делает драйвер
+	data, err := msgpack.Marshal(tuple)
+	if err != nil {
+		return nil, err
+	}
+	if space == testVoteSpace {
+		var v voteTuple
+		if err := msgpack.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		f.votes[[2]string{v.PollID, v.UserID}] = v
+		return &tarantool.Response{Data: []interface{}{v}}, nil
+	}
+	var t pollTuple
+	if err := msgpack.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	f.tuples[t.ID] = t
+	return &tarantool.Response{Data: []interface{}{t}}, nil
+}
+
+func (f *fakeConn) Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check("Update"); err != nil {
+		return nil, err
+	}
+	if len(f.updateErrs) > 0 {
+		err := f.updateErrs[0]
+		f.updateErrs = f.updateErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	id := key.([]interface{})[0].(string)
+	t, ok := f.tuples[id]
+	if !ok {
+		return &tarantool.Response{}, nil
+	}
+	for _, raw := range ops.([]interface{}) {
+		op := raw.([]interface{})
+		switch op[1] {
+		case fieldCreator:
+			t.Creator = op[2].(string)
+		case fieldQuestion:
+			t.Question = op[2].(string)
+		case fieldVoters:
+			t.Voters = copyMap(op[2].(map[string]bool))
+		case fieldOptions:
+			t.Options = copyMap(op[2].(map[string]int))
+		case fieldClosed:
+			t.Closed = looseBool(op[2].(bool))
+		case fieldBallots:
+			t.Ballots = op[2].(map[string][]string)
+		case fieldPinned:
+			t.PinnedPostID = op[2].(string)
+		case fieldWarned:
+			t.ExpiryWarned = looseBool(op[2].(bool))
+		case fieldChannelOnly:
+			t.RestrictToChannel = looseBool(op[2].(bool))
+		case fieldQuorum:
+			t.Quorum = int64(op[2].(int))
+		case fieldExpiresAt:
+			t.ExpiresAt = op[2].(int64)
+		case fieldMaxVotes:
+			t.MaxVotes = int64(op[2].(int))
+		default:
+			return nil, fmt.Errorf("fakeConn: неизвестное поле %v", op[1])
+		}
+	}
+	f.tuples[id] = t
+	row, err := untypedTuple(t)
+	if err != nil {
+		return nil, err
+	}
+	return &tarantool.Response{Data: []interface{}{row}}, nil
+}
+
+func (f *fakeConn) Delete(ctx context.Context, space, index interface{}, key interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check("Delete"); err != nil {
+		return nil, err
+	}
+
+	id := key.([]interface{})[0].(string)
+	t, ok := f.tuples[id]
+	if !ok {
+		return &tarantool.Response{}, nil
+	}
+	delete(f.tuples, id)
+	return &tarantool.Response{Data: []interface{}{t}}, nil
+}
+
+func (f *fakeConn) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check("Select"); err != nil {
+		return err
+	}
+	f.calls[fmt.Sprintf("Select:%v", index)]++
+
+	keyParts := key.([]interface{})
+	if out, ok := result.(*[]voteTuple); ok {
+		return f.selectVotes(iterator, limit, keyParts, out)
+	}
+	indexKey := func(t pollTuple) []string {
+		switch index {
+		case "creator":
+			return []string{t.Creator, t.ID}
+		case "channel":
+			return []string{t.ChannelID, t.ID}
+		default:
+			return []string{t.ID}
+		}
+	}
+	// Сравнение префикса ключа индекса с ключом запроса, как в TREE-индексе
+	compare := func(t pollTuple) int {
+		parts := indexKey(t)
+		for i, k := range keyParts {
+			if c := strings.Compare(parts[i], k.(string)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+
+	all := make([]pollTuple, 0, len(f.tuples))
+	for _, t := range f.tuples {
+		all = append(all, t)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return slices.Compare(indexKey(all[i]), indexKey(all[j])) < 0
+	})
+
+	out := result.(*[]pollTuple)
+	skipped := uint32(0)
+	for _, t := range all {
+		switch iterator {
+		case tarantool.IterEq, tarantool.IterAll:
+			if compare(t) != 0 {
+				continue
+			}
+		case tarantool.IterGt:
+			if compare(t) <= 0 {
+				continue
+			}
+		default:
+			return fmt.Errorf("fakeConn: итератор %d не поддерживается", iterator)
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		if uint32(len(*out)) == limit {
+			break
+		}
+		t.Voters = copyMap(t.Voters)
+		t.Options = copyMap(t.Options)
+		if t.Weights != nil {
+			t.Weights = copyMap(t.Weights)
+			t.WeightedOptions = copyMap(t.WeightedOptions)
+		}
+		if t.Tags != nil {
+			t.Tags = append([]string(nil), t.Tags...)
+		}
+		if t.AllowedVoters != nil {
+			t.AllowedVoters = append([]string(nil), t.AllowedVoters...)
+		}
+		*out = append(*out, t)
+	}
+	return nil
+}
+
+// selectVotes повторяет выборку из первичного индекса (poll_id, user_id)
+// space голосов.
+func (f *fakeConn) selectVotes(iterator, limit uint32, keyParts []interface{}, out *[]voteTuple) error {
+	key := make([]string, len(keyParts))
+	for i, k := range keyParts {
+		key[i] = k.(string)
+	}
+	all := make([]voteTuple, 0, len(f.votes))
+	for _, v := range f.votes {
+		all = append(all, v)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return slices.Compare([]string{all[i].PollID, all[i].UserID}, []string{all[j].PollID, all[j].UserID}) < 0
+	})
+	for _, v := range all {
+		c := slices.Compare([]string{v.PollID, v.UserID}[:len(key)], key)
+		switch iterator {
+		case tarantool.IterEq:
+			if c != 0 {
+				continue
+			}
+		case tarantool.IterGt:
+			if c <= 0 {
+				continue
+			}
+		default:
+			return fmt.Errorf("fakeConn: итератор %d не поддерживается", iterator)
+		}
+		if uint32(len(*out)) == limit {
+			break
+		}
+		*out = append(*out, v)
+	}
+	return nil
+}
+
+// Call17 повторяет хранимые функции init.lua
+func (f *fakeConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check("Call"); err != nil {
+		return nil, err
+	}
+	if len(f.callErrs) > 0 {
+		err := f.callErrs[0]
+		f.callErrs = f.callErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a := args.([]interface{})
+	switch functionName {
+	case funcDeleteVotes:
+		for key := range f.votes {
+			if key[0] == a[1].(string) {
+				delete(f.votes, key)
+			}
+		}
+		return &tarantool.Response{Data: []interface{}{voteRecorded}}, nil
+	case funcCountVotes:
+		return &tarantool.Response{Data: []interface{}{uint64(f.countVotes(a[1].(string)))}}, nil
+	case funcCountPolls:
+		return &tarantool.Response{Data: []interface{}{int64(f.countPolls(a))}}, nil
+	case funcGetSummary:
+		t, ok := f.tuples[a[1].(string)]
+		if !ok {
+			return &tarantool.Response{Data: []interface{}{nil}}, nil
+		}
+		t.Voters, t.Ballots = voterSet{}, nil
+		row, err := untypedTuple(t)
+		if err != nil {
+			return nil, err
+		}
+		return &tarantool.Response{Data: []interface{}{row}}, nil
+	case funcGetMany:
+		rows := []interface{}{}
+		for _, id := range a[1].([]string) {
+			t, ok := f.tuples[id]
+			if !ok {
+				continue
+			}
+			t.Voters, t.Ballots = voterSet{}, nil
+			row, err := untypedTuple(t)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, row)
+		}
+		return &tarantool.Response{Data: []interface{}{rows}}, nil
+	case funcUpgradePoll:
+		t, ok := f.tuples[a[1].(string)]
+		if !ok {
+			return &tarantool.Response{Data: []interface{}{voteNotFound}}, nil
+		}
+		t.SchemaVersion = pollSchemaVersion
+		f.tuples[t.ID] = t
+		return &tarantool.Response{Data: []interface{}{voteRecorded}}, nil
+	case funcCountLegacy:
+		ids := slices.Sorted(maps.Keys(f.tuples))
+		sampled, legacy := min(len(ids), a[1].(int)), 0
+		for _, id := range ids[:sampled] {
+			if f.tuples[id].SchemaVersion < pollSchemaVersion {
+				legacy++
+			}
+		}
+		return &tarantool.Response{Data: []interface{}{uint64(sampled), uint64(legacy), uint64(len(ids))}}, nil
+	}
+
+	var id string
+	switch functionName {
+	case funcAddVote:
+		id = a[2].(string)
+	case funcIncrementOption, funcIncrementViews, funcSetFeatured, funcSetVoters:
+		id = a[1].(string)
+	default:
+		return nil, fmt.Errorf("fakeConn: неизвестная функция %s", functionName)
+	}
+	t, ok := f.tuples[id]
+	if !ok {
+		return &tarantool.Response{Data: []interface{}{voteNotFound}}, nil
+	}
+	t.Options = copyMap(t.Options)
+	// Хранимые функции голосования переводят кортеж в текущую версию
+	t.SchemaVersion = pollSchemaVersion
+
+	status := voteRecorded
+	switch functionName {
+	case funcIncrementOption:
+		t.Options[a[2].(string)] += a[3].(int)
+	case funcIncrementViews:
+		t.Views++
+	case funcSetFeatured:
+		t.Featured = looseBool(a[2].(bool))
+	case funcSetVoters:
+		t.AllowedVoters, _ = a[2].([]string)
+	default:
+		user, choices := a[3].(string), a[4].([]string)
+		if t.Closed {
+			if t.MaxVotes > 0 && int64(f.countVotes(id)) >= t.MaxVotes {
+				return &tarantool.Response{Data: []interface{}{voteFull}}, nil
+			}
+			return &tarantool.Response{Data: []interface{}{voteClosed}}, nil
+		}
+		weight, abstain := a[7].(int64), a[8].(bool)
+		if previous, voted := f.votes[[2]string{id, user}]; voted {
+			if !previous.Abstain || abstain {
+				return &tarantool.Response{Data: []interface{}{voteRepeated}}, nil
+			}
+			t.Abstained--
+		}
+		f.votes[[2]string{id, user}] = voteTuple{PollID: id, UserID: user, Choices: choices, VotedAt: a[5].(int64),
+			PostID: a[6].(string), Weight: weight, Abstain: abstain}
+		if abstain {
+			t.Abstained++
+		} else {
+			t.Options[choices[0]]++
+		}
+		if !abstain && weight > 0 {
+			t.WeightedOptions = copyMap(t.WeightedOptions)
+			if t.WeightedOptions == nil {
+				t.WeightedOptions = optionCounts{}
+			}
+			t.WeightedOptions[choices[0]] += int(weight)
+		}
+		voters := int64(f.countVotes(id))
+		if t.Quorum > 0 && voters >= t.Quorum || t.MaxVotes > 0 && voters >= t.MaxVotes {
+			t.Closed = true
+			status = voteQuorum
+		}
+	}
+	f.tuples[id] = t
+	return &tarantool.Response{Data: []interface{}{status}}, nil
+}
+
+// countPolls повторяет polls_count: nil в аргументе - предикат не задан
+func (f *fakeConn) countPolls(a []interface{}) int {
+	n := 0
+	for _, t := range f.tuples {
+		switch {
+		case a[1] != nil && t.Creator != a[1].(string),
+			a[2] != nil && t.ChannelID != a[2].(string),
+			a[3] != nil && bool(t.Closed) != a[3].(bool),
+			a[4] != nil && !slices.Contains(t.Tags, a[4].(string)),
+			a[5] != nil && t.CreatedAt <= a[5].(int64),
+			a[6] != nil && t.CreatedAt >= a[6].(int64),
+			a[7] != nil && bool(t.Featured) != a[7].(bool):
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func (f *fakeConn) countVotes(pollID string) int {
+	n := 0
+	for key := range f.votes {
+		if key[0] == pollID {
+			n++
+		}
+	}
+	return n
+}
+
+func copyMap[M ~map[K]V, K comparable, V any](m M) M {
+	out := make(M, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// untypedTuple - кортеж в том виде, в каком драйвер отдаёт ответ Call17:
+// без типа, после разбора msgpack.
+func untypedTuple(tuple interface{}) (interface{}, error) {
+	data, err := msgpack.Marshal(tuple)
+	if err != nil {
+		return nil, err
+	}
+	var row interface{}
+	if err := msgpack.Unmarshal(data, &row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// Space голосов, с которым работают тестовые репозитории голосов
+const testVoteSpace = "votes"
+
+// newTestRepo создаёт репозиторий поверх фейка без реальных пауз между повторами
+func newTestRepo(conn *fakeConn) *TarantoolPollRepo {
+	repo := NewTarantoolPollRepo(conn, "polls", DefaultRetryPolicy(), zerolog.Nop())
+	repo.sleep = func(context.Context, time.Duration) error { return nil }
+	return repo
+}
+
+// newTestVoteRepo - аналог newTestRepo для голосов
+func newTestVoteRepo(conn Connector) *TarantoolVoteRepo {
+	repo := NewTarantoolVoteRepo(conn, "polls", testVoteSpace, DefaultRetryPolicy(), zerolog.Nop())
+	repo.sleep = func(context.Context, time.Duration) error { return nil }
+	return repo
+}
+
+func testPoll(id string) models.Poll {
+	return models.Poll{
+		ID:       id,
+		Creator:  "user1",
+		Question: "Вопрос?",
+		Options:  map[string]int{"Да": 0, "Нет": 0},
+	}
+}
+
+// Тест проверяет полный цикл работы с опросом через репозиторий
+func TestPollRepo_Lifecycle(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			poll.ChannelID = "channel1"
+			poll.CreatedAt = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+			poll.Description = "Пояснение\nв две строки"
+			require.NoError(t, repo.SavePoll(ctx, poll))
+
+			require.NoError(t, repo.ClosePoll(ctx, poll.ID))
+
+			got, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			poll.Closed = true
+			assert.Equal(t, poll, got)
+
+			require.NoError(t, repo.DeletePoll(ctx, poll.ID))
+			_, err = repo.GetPoll(ctx, poll.ID)
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет передачу опроса другому создателю
+func TestPollRepo_SetCreator(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			poll.Options["Да"] = 1
+			require.NoError(t, repo.SavePoll(ctx, poll))
+			// Чтение кладёт опрос в кэш: смена создателя должна его сбросить
+			_, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+
+			require.NoError(t, repo.SetCreator(ctx, poll.ID, "user3"))
+			got, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			poll.Creator = "user3"
+			assert.Equal(t, poll, got)
+
+			assert.ErrorIs(t, repo.SetCreator(ctx, "missing", "user3"), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет сохранение закреплённого сообщения опроса
+func TestPollRepo_SetPinnedPost(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			require.NoError(t, repo.SavePoll(ctx, poll))
+			_, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+
+			require.NoError(t, repo.SetPinnedPost(ctx, poll.ID, "post1"))
+			got, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			poll.PinnedPostID = "post1"
+			assert.Equal(t, poll, got)
+
+			assert.ErrorIs(t, repo.SetPinnedPost(ctx, "missing", "post1"), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет отметку о предупреждении о сроке опроса
+func TestPollRepo_SetExpiryWarned(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			poll.ExpiresAt = time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+			require.NoError(t, repo.SavePoll(ctx, poll))
+			_, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+
+			require.NoError(t, repo.SetExpiryWarned(ctx, poll.ID))
+			got, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			poll.ExpiryWarned = true
+			assert.Equal(t, poll, got)
+
+			assert.ErrorIs(t, repo.SetExpiryWarned(ctx, "missing"), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет изменение настроек опроса: меняются только заданные поля,
+// новый срок снимает отметку о предупреждении
+func TestPollRepo_UpdateSettings(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			poll.Quorum = 5
+			poll.ExpiresAt = time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+			require.NoError(t, repo.SavePoll(ctx, poll))
+			require.NoError(t, repo.SetExpiryWarned(ctx, poll.ID))
+
+			channelOnly, maxVotes := true, 20
+			require.NoError(t, repo.UpdateSettings(ctx, poll.ID, SettingsUpdate{RestrictToChannel: &channelOnly, MaxVotes: &maxVotes}))
+			got, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			poll.RestrictToChannel, poll.MaxVotes, poll.ExpiryWarned = true, 20, true
+			assert.Equal(t, poll, got)
+
+			quorum, expiresAt := 0, time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+			require.NoError(t, repo.UpdateSettings(ctx, poll.ID, SettingsUpdate{Quorum: &quorum, ExpiresAt: &expiresAt}))
+			got, err = repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			poll.Quorum, poll.ExpiresAt, poll.ExpiryWarned = 0, expiresAt, false
+			assert.Equal(t, poll, got)
+
+			noExpiry := time.Time{}
+			require.NoError(t, repo.UpdateSettings(ctx, poll.ID, SettingsUpdate{ExpiresAt: &noExpiry}))
+			got, err = repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.True(t, got.ExpiresAt.IsZero())
+
+			question := "Где ужинаем?"
+			require.NoError(t, repo.UpdateSettings(ctx, poll.ID, SettingsUpdate{Question: &question}))
+			got, err = repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.Equal(t, question, got.Question)
+			assert.Equal(t, poll.Options, got.Options)
+
+			assert.ErrorIs(t, repo.UpdateSettings(ctx, "missing", SettingsUpdate{Quorum: &quorum}), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет изменение счётчика варианта
+func TestPollRepo_IncrementOption(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			require.NoError(t, repo.SavePoll(ctx, poll))
+			_, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+
+			require.NoError(t, repo.IncrementOption(ctx, poll.ID, "Да", 3))
+			require.NoError(t, repo.IncrementOption(ctx, poll.ID, "Да", -1))
+			got, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int{"Да": 2, "Нет": 0}, got.Options)
+
+			assert.ErrorIs(t, repo.IncrementOption(ctx, "missing", "Да", 1), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет учёт просмотров опроса: счётчик растёт на один и не
+// сбрасывает голоса
+func TestPollRepo_IncrementViews(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			poll.Options["Да"] = 2
+			require.NoError(t, repo.SavePoll(ctx, poll))
+			_, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+
+			require.NoError(t, repo.IncrementViews(ctx, poll.ID))
+			require.NoError(t, repo.IncrementViews(ctx, poll.ID))
+			got, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			poll.Views = 2
+			assert.Equal(t, poll, got)
+
+			assert.ErrorIs(t, repo.IncrementViews(ctx, "missing"), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет, что сводка опроса совпадает с опросом, прочитанным
+// полностью, а отсутствующий опрос не находится
+func TestPollRepo_GetPollSummary(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			poll.Options["Да"] = 7
+			poll.OptionOrder = []string{"Нет", "Да"}
+			poll.OptionEmoji = map[string]string{"Да": "👍"}
+			poll.Description = "Пояснение"
+			poll.Tags = []string{"release"}
+			poll.Weights = map[string]int{"alice": 3}
+			poll.WeightedOptions = map[string]int{"Да": 9, "Нет": 0}
+			poll.AllowAbstain, poll.Abstained = true, 2
+			poll.CreatedAt = time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+			poll.ExpiresAt = poll.CreatedAt.Add(time.Hour)
+			poll.Closed = true
+			require.NoError(t, repo.SavePoll(ctx, poll))
+
+			summary, err := repo.GetPollSummary(ctx, poll.ID)
+			require.NoError(t, err)
+			full, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.Equal(t, full, summary)
+			assert.Equal(t, poll, summary)
+
+			_, err = repo.GetPollSummary(ctx, "missing")
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет, что сводка опроса с неперенесёнными голосами читается
+// хранимой функцией, без выборки кортежа с картами участников
+func TestTarantoolPollRepo_GetPollSummary_Embedded(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	repo := newTestRepo(conn)
+	poll, votes := votedPoll(100)
+	require.NoError(t, repo.SavePoll(ctx, poll))
+	tuple := conn.tuples[poll.ID]
+	tuple.Voters, tuple.Ballots = voterSet{}, map[string][]string{}
+	for _, vote := range votes {
+		tuple.Voters[vote.UserID] = true
+		tuple.Ballots[vote.UserID] = vote.Choices
+	}
+	conn.tuples[poll.ID] = tuple
+	selects := conn.calls["Select"]
+
+	got, err := repo.GetPollSummary(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, poll, got)
+	assert.Equal(t, selects, conn.calls["Select"])
+	assert.Equal(t, 1, conn.calls["Call"])
+}
+
+// Тест проверяет сохранение порядка вариантов рейтингового опроса
+func TestPollRepo_RankedOrder(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			poll := testPoll("poll1")
+			poll.Ranked = true
+			poll.OptionOrder = []string{"Нет", "Да"}
+			require.NoError(t, repo.SavePoll(ctx, poll))
+
+			got, err := repo.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.Equal(t, poll, got)
+		})
+	}
+}
+
+// Тест проверяет, что операции над отсутствующим опросом возвращают ErrNotFound
+func TestPollRepo_MissingPoll(t *testing.T) {
+	for name, newRepo := range listRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			assert.ErrorIs(t, repo.ClosePoll(ctx, "missing"), ErrNotFound)
+			assert.ErrorIs(t, repo.DeletePoll(ctx, "missing"), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет, что после восстановления соединения вызовы снова проходят
+func TestTarantoolPollRepo_Reconnect(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	repo := newTestRepo(conn)
+	require.NoError(t, repo.SavePoll(ctx, testPoll("poll1")))
+
+	conn.setConnected(false)
+
+	_, err := repo.GetPoll(ctx, "poll1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, repo.SavePoll(ctx, testPoll("poll2")), ErrUnavailable)
+	assert.ErrorIs(t, repo.ClosePoll(ctx, "poll1"), ErrUnavailable)
+	assert.Zero(t, conn.calls["Select"], "запросы не должны уходить в драйвер без соединения")
+
+	conn.setConnected(true)
+
+	got, err := repo.GetPoll(ctx, "poll1")
+	require.NoError(t, err)
+	assert.Equal(t, "poll1", got.ID)
+	assert.NoError(t, repo.SavePoll(ctx, testPoll("poll2")))
+}
+
+// Тест проверяет, что обрыв посреди запроса тоже превращается в ErrUnavailable
+func TestTarantoolPollRepo_DriverNotReady(t *testing.T) {
+	conn := newFakeConn()
+	conn.updateErrs = []error{tarantool.ClientError{Code: tarantool.ErrConnectionClosed}}
+	repo := newTestRepo(conn)
+	require.NoError(t, repo.SavePoll(context.Background(), testPoll("poll1")))
+
+	err := repo.ClosePoll(context.Background(), "poll1")
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+// Тест проверяет, что отменённый контекст не доходит до драйвера
+func TestTarantoolPollRepo_ContextCanceled(t *testing.T) {
+	conn := newFakeConn()
+	repo := newTestRepo(conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.GetPoll(ctx, "poll1")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, conn.calls["Select"])
+}
+
+// wireConn считает байты, которые драйвер отправил бы в Tarantool
+// для записей голоса.
+type wireConn struct {
+	*fakeConn
+	bytes int
+}
+
+func (c *wireConn) Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error) {
+	data, err := msgpack.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	c.bytes += len(data)
+	return c.fakeConn.Update(ctx, space, index, key, ops)
+}
+
+func (c *wireConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	data, err := msgpack.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	c.bytes += len(data)
+	return c.fakeConn.Call17(ctx, functionName, args)
+}
+
+// votedPoll - опрос, в котором уже проголосовали voters участников, и их голоса.
+func votedPoll(voters int) (models.Poll, []models.Vote) {
+	poll := testPoll("poll1")
+	votes := make([]models.Vote, 0, voters)
+	for i := 0; i < voters; i++ {
+		poll.Options["Да"]++
+		votes = append(votes, models.Vote{PollID: poll.ID, UserID: fmt.Sprintf("voter%d", i), Choices: []string{"Да"}})
+	}
+	return poll, votes
+}
+
+// BenchmarkVote сравнивает запись голоса заменой карт участников внутри
+// опроса, как до появления space голосов, с записью одного голоса через
+// AddVote. wire-bytes/op - размер запроса к Tarantool: у замены он растёт
+// с числом участников, у AddVote - нет.
+func BenchmarkVote(b *testing.B) {
+	ctx := context.Background()
+	for _, voters := range []int{10, 1000, 10000} {
+		b.Run(fmt.Sprintf("memory/delta/voters=%d", voters), func(b *testing.B) {
+			polls := NewMemoryPollRepo()
+			repo := NewMemoryVoteRepo(polls)
+			poll, votes := votedPoll(voters)
+			require.NoError(b, polls.SavePoll(ctx, poll))
+			require.NoError(b, repo.ImportVotes(ctx, votes))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				vote := models.Vote{PollID: "poll1", UserID: fmt.Sprintf("user%d", i), Choices: []string{"Да"}}
+				if _, err := repo.AddVote(ctx, vote); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("tarantool/full/voters=%d", voters), func(b *testing.B) {
+			conn := &wireConn{fakeConn: newFakeConn()}
+			repo := newTestRepo(conn.fakeConn)
+			poll, votes := votedPoll(voters)
+			require.NoError(b, repo.SavePoll(ctx, poll))
+			embedded := make(map[string]bool, voters)
+			ballots := make(map[string][]string, voters)
+			for _, vote := range votes {
+				embedded[vote.UserID] = true
+				ballots[vote.UserID] = vote.Choices
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				user := fmt.Sprintf("user%d", i)
+				embedded[user] = true
+				poll.Options["Да"]++
+				ballots[user] = []string{"Да"}
+				_, err := conn.Update(ctx, "polls", "primary", []interface{}{poll.ID}, []interface{}{
+					[]interface{}{"=", fieldVoters, embedded},
+					[]interface{}{"=", fieldOptions, poll.Options},
+					[]interface{}{"=", fieldBallots, ballots},
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(conn.bytes)/float64(b.N), "wire-bytes/op")
+		})
+		b.Run(fmt.Sprintf("tarantool/delta/voters=%d", voters), func(b *testing.B) {
+			conn := &wireConn{fakeConn: newFakeConn()}
+			polls := newTestRepo(conn.fakeConn)
+			repo := newTestVoteRepo(conn.fakeConn)
+			poll, votes := votedPoll(voters)
+			require.NoError(b, polls.SavePoll(ctx, poll))
+			require.NoError(b, repo.ImportVotes(ctx, votes))
+			repo.conn = conn
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				vote := models.Vote{PollID: "poll1", UserID: fmt.Sprintf("user%d", i), Choices: []string{"Да"}}
+				if _, err := repo.AddVote(ctx, vote); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(conn.bytes)/float64(b.N), "wire-bytes/op")
+		})
+	}
+}
+
+// replyConn пропускает ответы fakeConn через msgpack, как драйвер, и
+// считает байты ответов: так в бенчмарке видна цена разбора кортежа.
+type replyConn struct {
+	*fakeConn
+	bytes int
+}
+
+func (c *replyConn) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	var tuples []pollTuple
+	if err := c.fakeConn.SelectTyped(ctx, space, index, offset, limit, iterator, key, &tuples); err != nil {
+		return err
+	}
+	data, err := msgpack.Marshal(tuples)
+	if err != nil {
+		return err
+	}
+	c.bytes += len(data)
+	return msgpack.Unmarshal(data, result)
+}
+
+func (c *replyConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	resp, err := c.fakeConn.Call17(ctx, functionName, args)
+	if err != nil {
+		return nil, err
+	}
+	data, err := msgpack.Marshal(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+	c.bytes += len(data)
+	var reply []interface{}
+	if err := msgpack.Unmarshal(data, &reply); err != nil {
+		return nil, err
+	}
+	return &tarantool.Response{Data: reply}, nil
+}
+
+// BenchmarkGetPollSummary сравнивает чтение для результатов всего кортежа
+// опроса с неперенесёнными голосами и сводки без карт участников.
+// wire-bytes/op - размер ответа Tarantool: у GetPoll он растёт с числом
+// участников, у GetPollSummary - нет.
+func BenchmarkGetPollSummary(b *testing.B) {
+	ctx := context.Background()
+	for _, voters := range []int{10, 1000, 10000} {
+		reads := map[string]func(repo *TarantoolPollRepo) (models.Poll, error){
+			"full":    func(repo *TarantoolPollRepo) (models.Poll, error) { return repo.GetPoll(ctx, "poll1") },
+			"summary": func(repo *TarantoolPollRepo) (models.Poll, error) { return repo.GetPollSummary(ctx, "poll1") },
+		}
+		for _, name := range []string{"full", "summary"} {
+			read := reads[name]
+			b.Run(fmt.Sprintf("tarantool/%s/voters=%d", name, voters), func(b *testing.B) {
+				conn := &replyConn{fakeConn: newFakeConn()}
+				repo := newTestRepo(conn.fakeConn)
+				poll, votes := votedPoll(voters)
+				require.NoError(b, repo.SavePoll(ctx, poll))
+				tuple := conn.tuples[poll.ID]
+				tuple.Voters, tuple.Ballots = voterSet{}, map[string][]string{}
+				for _, vote := range votes {
+					tuple.Voters[vote.UserID] = true
+					tuple.Ballots[vote.UserID] = vote.Choices
+				}
+				conn.tuples[poll.ID] = tuple
+				repo.conn = conn
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := read(repo); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(conn.bytes)/float64(b.N), "wire-bytes/op")
+			})
+		}
+	}
+}
+
+// BenchmarkGetPolls сравнивает чтение 50 опросов по одному и одним GetPolls.
+// round-trips/op - число запросов к Tarantool на одно чтение всех опросов.
+func BenchmarkGetPolls(b *testing.B) {
+	ctx := context.Background()
+	const n = 50
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("poll%02d", i)
+	}
+	reads := map[string]func(repo *TarantoolPollRepo) error{
+		"one-by-one": func(repo *TarantoolPollRepo) error {
+			for _, id := range ids {
+				if _, err := repo.GetPollSummary(ctx, id); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		"bulk": func(repo *TarantoolPollRepo) error {
+			_, err := repo.GetPolls(ctx, ids)
+			return err
+		},
+	}
+	for _, name := range []string{"one-by-one", "bulk"} {
+		read := reads[name]
+		b.Run(fmt.Sprintf("tarantool/%s/polls=%d", name, n), func(b *testing.B) {
+			conn := &replyConn{fakeConn: newFakeConn()}
+			repo := newTestRepo(conn.fakeConn)
+			for _, id := range ids {
+				require.NoError(b, repo.SavePoll(ctx, testPoll(id)))
+			}
+			repo.conn = conn
+			before := conn.calls["Call"] + conn.calls["Select"]
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := read(repo); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(conn.calls["Call"]+conn.calls["Select"]-before)/float64(b.N), "round-trips/op")
+			b.ReportMetric(float64(conn.bytes)/float64(b.N), "wire-bytes/op")
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Номера полей для update-операций: iproto нумерует поля с нуля.
+const (
+	fieldCreator = 1
+	fieldVoters  = 3
+	fieldOptions = 4
+	fieldClosed  = 5
+	fieldBallots = 12
+	fieldPinned  = 14
+	fieldWarned  = 17
+	// Версия раскладки; поля версий новее 1 идут после неё
+	fieldSchemaVersion = 31
+	fieldFeatured      = 32
+	fieldAllowedVoters = 33
+)
+
+// Версии раскладки кортежа опроса. В кортежах, записанных до появления
+// поля schema_version, его нет: это версия 0.
+const (
+	pollSchemaLegacy  = 0
+	pollSchemaV1      = 1
+	pollSchemaV2      = 2
+	pollSchemaVersion = 3
+	// Число полей кортежей версий 1, 2 и 3
+	pollFieldsV1 = fieldSchemaVersion + 1
+	pollFieldsV2 = fieldFeatured + 1
+	pollFieldsV3 = fieldAllowedVoters + 1
+)
+
+// pollTuple описывает раскладку опроса в space Tarantool.
+// Порядок полей должен точно соответствовать формату space в init.lua.
+type pollTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	ID       string // field 1: id (string)
+	Creator  string // field 2: creator (string)
+	Question string // field 3: question (string)
+	// field 4: voters (map); голоса хранятся в space голосов, здесь
+	// остаются только не перенесённые MigrateEmbeddedVotes
+	Voters  voterSet
+	Options optionCounts // field 5: options (map)
+	Closed  looseBool    // field 6: is_closed (boolean)
+
+	// Поля ниже появились позже и отсутствуют в старых кортежах
+	ChannelID string // field 7: channel_id (string, nullable)
+	CreatedAt int64  // field 8: created_at (unsigned, unix-время, nullable)
+	// field 9: channel_only (boolean, nullable)
+	RestrictToChannel looseBool
+	Quorum            int64 // field 10: quorum (unsigned, nullable)
+	// field 11: ranked (boolean, nullable)
+	Ranked      looseBool
+	OptionOrder []string            // field 12: option_order (array, nullable)
+	Ballots     map[string][]string // field 13: ballots (map, nullable), как voters
+	Winner      string              // field 14: winner (string, nullable)
+	// field 15: pinned_post_id (string, nullable)
+	PinnedPostID string
+	// field 16: notify_voters (boolean, nullable)
+	NotifyVoters looseBool
+	// field 17: expires_at (unsigned, unix-время, nullable)
+	ExpiresAt int64
+	// field 18: expiry_warned (boolean, nullable)
+	ExpiryWarned looseBool
+	// field 19: description (string, nullable)
+	Description string
+	Tags        []string // field 20: tags (array, nullable)
+	// field 21: weights (map, nullable), ID участника - вес голоса
+	Weights optionCounts
+	// field 22: weighted_options (map, nullable)
+	WeightedOptions optionCounts
+	// field 23: allow_abstain (boolean, nullable)
+	AllowAbstain looseBool
+	Abstained    int64 // field 24: abstained (unsigned, nullable)
+	MaxVotes     int64 // field 25: max_votes (unsigned, nullable)
+	// field 26: vote_receipts (boolean, nullable)
+	VoteReceipts looseBool
+	// field 27: option_emoji (map, nullable), текст варианта - эмодзи
+	OptionEmoji map[string]string
+	// field 28: no_self_vote (boolean, nullable)
+	NoSelfVote looseBool
+	// field 29: voter_salt (string, nullable), соль анонимного опроса
+	VoterSalt string
+	// field 30: members_only (boolean, nullable)
+	MembersOnly looseBool
+	Views       int64 // field 31: views (unsigned, nullable)
+	// field 32: schema_version (unsigned, nullable), версия раскладки;
+	// записывается SavePoll, старые кортежи получают её при записи
+	SchemaVersion int64
+	// field 33: featured (boolean, nullable), с версии 2
+	Featured looseBool
+	// field 34: allowed_voters (array, nullable), с версии 3
+	AllowedVoters []string
+}
+
+func newPollTuple(poll models.Poll) pollTuple {
+	t := pollTuple{
+		ID:       poll.ID,
+		Creator:  poll.Creator,
+		Question: poll.Question,
+		Voters:   voterSet{},
+		Options:  optionCounts(poll.Options),
+		Closed:   looseBool(poll.Closed),
+
+		ChannelID:         poll.ChannelID,
+		RestrictToChannel: looseBool(poll.RestrictToChannel),
+		Quorum:            int64(poll.Quorum),
+		Ranked:            looseBool(poll.Ranked),
+		OptionOrder:       poll.OptionOrder,
+		Winner:            poll.Winner,
+		PinnedPostID:      poll.PinnedPostID,
+		NotifyVoters:      looseBool(poll.NotifyVoters),
+		ExpiryWarned:      looseBool(poll.ExpiryWarned),
+		Description:       poll.Description,
+		Tags:              poll.Tags,
+		Weights:           optionCounts(poll.Weights),
+		WeightedOptions:   optionCounts(poll.WeightedOptions),
+		AllowAbstain:      looseBool(poll.AllowAbstain),
+		Abstained:         int64(poll.Abstained),
+		MaxVotes:          int64(poll.MaxVotes),
+		VoteReceipts:      looseBool(poll.VoteReceipts),
+		OptionEmoji:       poll.OptionEmoji,
+		NoSelfVote:        looseBool(poll.NoSelfVote),
+		VoterSalt:         poll.VoterSalt,
+		MembersOnly:       looseBool(poll.MembersOnly),
+		Views:             int64(poll.Views),
+		SchemaVersion:     pollSchemaVersion,
+		Featured:          looseBool(poll.Featured),
+		AllowedVoters:     poll.AllowedVoters,
+	}
+	if !poll.CreatedAt.IsZero() {
+		t.CreatedAt = poll.CreatedAt.Unix()
+	}
+	if !poll.ExpiresAt.IsZero() {
+		t.ExpiresAt = poll.ExpiresAt.Unix()
+	}
+	// Формат space требует map, nil ушёл бы как msgpack nil
+	if t.Options == nil {
+		t.Options = optionCounts{}
+	}
+	return t
+}
+
+func (t pollTuple) toModel() models.Poll {
+	poll := models.Poll{
+		ID:       t.ID,
+		Creator:  t.Creator,
+		Question: t.Question,
+		Options:  map[string]int(t.Options),
+		Closed:   bool(t.Closed),
+
+		ChannelID:         t.ChannelID,
+		RestrictToChannel: bool(t.RestrictToChannel),
+		Quorum:            int(t.Quorum),
+		Ranked:            bool(t.Ranked),
+		OptionOrder:       t.OptionOrder,
+		Winner:            t.Winner,
+		PinnedPostID:      t.PinnedPostID,
+		NotifyVoters:      bool(t.NotifyVoters),
+		ExpiryWarned:      bool(t.ExpiryWarned),
+		Description:       t.Description,
+		AllowAbstain:      bool(t.AllowAbstain),
+		Abstained:         int(t.Abstained),
+		MaxVotes:          int(t.MaxVotes),
+		VoteReceipts:      bool(t.VoteReceipts),
+		NoSelfVote:        bool(t.NoSelfVote),
+		VoterSalt:         t.VoterSalt,
+		MembersOnly:       bool(t.MembersOnly),
+		Views:             int(t.Views),
+		Featured:          bool(t.Featured),
+	}
+	if len(t.OptionEmoji) > 0 {
+		poll.OptionEmoji = t.OptionEmoji
+	}
+	if len(t.Tags) > 0 {
+		poll.Tags = t.Tags
+	}
+	if len(t.AllowedVoters) > 0 {
+		poll.AllowedVoters = t.AllowedVoters
+	}
+	if len(t.Weights) > 0 {
+		poll.Weights = map[string]int(t.Weights)
+		poll.WeightedOptions = map[string]int(t.WeightedOptions)
+	}
+	if t.CreatedAt > 0 {
+		poll.CreatedAt = time.Unix(t.CreatedAt, 0).UTC()
+	}
+	if t.ExpiresAt > 0 {
+		poll.ExpiresAt = time.Unix(t.ExpiresAt, 0).UTC()
+	}
+	return poll
+}
+
+// pollTupleLayout - номера полей pollTuple в порядке полей кортежа.
+var pollTupleLayout = tupleLayout(reflect.TypeOf(pollTuple{}))
+
+// tupleLayout - номера экспортируемых полей структуры кортежа: служебное
+// поле _msgpack в кортеж не входит.
+func tupleLayout(t reflect.Type) []int {
+	layout := make([]int, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			layout = append(layout, i)
+		}
+	}
+	return layout
+}
+
+// pollDecoders - проверка разобранного кортежа по версии раскладки. Поля
+// только добавляются в конец, поэтому по порядку они разбираются одинаково
+// для всех версий, а декодер версии знает, какие поля в ней обязаны быть.
+var pollDecoders = map[int64]func(t *pollTuple, fields int) error{
+	pollSchemaLegacy:  decodePollV0,
+	pollSchemaV1:      decodePollV1,
+	pollSchemaV2:      decodePollV2,
+	pollSchemaVersion: decodePollV3,
+}
+
+// DecodeMsgpack разбирает кортеж любой известной версии. Кортеж версии
+// новее бота не читается: в нём могут быть поля, без которых опрос
+// покажется не таким, каким его записали.
+func (t *pollTuple) DecodeMsgpack(d *msgpack.Decoder) error {
+	n, err := d.DecodeArrayLen()
+	if err != nil {
+		return fmt.Errorf("кортеж опроса: %w", err)
+	}
+	var decoded pollTuple
+	fields := reflect.ValueOf(&decoded).Elem()
+	for i := 0; i < n; i++ {
+		if i >= len(pollTupleLayout) {
+			if err := d.Skip(); err != nil {
+				return fmt.Errorf("кортеж опроса: %w", err)
+			}
+			continue
+		}
+		if err := d.Decode(fields.Field(pollTupleLayout[i]).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	decode, ok := pollDecoders[decoded.SchemaVersion]
+	if !ok {
+		return fmt.Errorf("кортеж опроса %s версии схемы %d, бот читает версии до %d", decoded.ID, decoded.SchemaVersion, pollSchemaVersion)
+	}
+	if err := decode(&decoded, n); err != nil {
+		return err
+	}
+	*t = decoded
+	return nil
+}
+
+// decodePollV0 - кортеж без версии, от шести полей первой схемы до всех
+// полей, появившихся до schema_version. Отсутствующие поля остаются
+// нулевыми, а карты, которых в старых кортежах могло не быть, - пустыми.
+func decodePollV0(t *pollTuple, fields int) error {
+	if t.Options == nil {
+		t.Options = optionCounts{}
+	}
+	return nil
+}
+
+// decodePollV1 - кортеж, записанный SavePoll с версией 1: в нём есть все
+// поля версии, и лишних нет. Поля featured в нём ещё нет.
+func decodePollV1(t *pollTuple, fields int) error {
+	if fields != pollFieldsV1 {
+		return fmt.Errorf("кортеж опроса %s версии схемы 1: %d полей вместо %d", t.ID, fields, pollFieldsV1)
+	}
+	return nil
+}
+
+// decodePollV2 - кортеж версии 2: поля версии 1 и featured.
+func decodePollV2(t *pollTuple, fields int) error {
+	if fields != pollFieldsV2 {
+		return fmt.Errorf("кортеж опроса %s версии схемы 2: %d полей вместо %d", t.ID, fields, pollFieldsV2)
+	}
+	return nil
+}
+
+// decodePollV3 - кортеж версии 3: поля версии 2 и allowed_voters.
+func decodePollV3(t *pollTuple, fields int) error {
+	if fields != pollFieldsV3 {
+		return fmt.Errorf("кортеж опроса %s версии схемы 3: %d полей вместо %d", t.ID, fields, pollFieldsV3)
+	}
+	return nil
+}
+
+// voterSet декодирует карту голосовавших, записанную любой версией бота:
+// ключи могут прийти не строками, значения - числами вместо bool.
+type voterSet map[string]bool
+
+func (v *voterSet) DecodeMsgpack(d *msgpack.Decoder) error {
+	raw, err := decodeLooseMap(d)
+	if err != nil {
+		return fmt.Errorf("поле voters: %w", err)
+	}
+	if raw == nil {
+		*v = nil
+		return nil
+	}
+	out := make(voterSet, len(raw))
+	for k, val := range raw {
+		out[k] = toBool(val)
+	}
+	*v = out
+	return nil
+}
+
+// optionCounts декодирует счётчики вариантов независимо от того,
+// пришли они как int64, uint64 или float64.
+type optionCounts map[string]int
+
+func (o *optionCounts) DecodeMsgpack(d *msgpack.Decoder) error {
+	raw, err := decodeLooseMap(d)
+	if err != nil {
+		return fmt.Errorf("поле options: %w", err)
+	}
+	if raw == nil {
+		*o = nil
+		return nil
+	}
+	out := make(optionCounts, len(raw))
+	for k, val := range raw {
+		out[k] = toInt(val)
+	}
+	*o = out
+	return nil
+}
+
+// looseBool принимает как bool, так и числовые/строковые флаги старых записей.
+type looseBool bool
+
+func (b *looseBool) DecodeMsgpack(d *msgpack.Decoder) error {
+	val, err := d.DecodeInterface()
+	if err != nil {
+		return fmt.Errorf("поле is_closed: %w", err)
+	}
+	*b = looseBool(toBool(val))
+	return nil
+}
+
+func decodeLooseMap(d *msgpack.Decoder) (map[string]interface{}, error) {
+	val, err := d.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+
+	switch m := val.(type) {
+	case nil:
+		return nil, nil
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			out[toString(k)] = v
+		}
+		return out, nil
+	case map[string]interface{}:
+		return m, nil
+	default:
+		return nil, fmt.Errorf("ожидалась map, получено %T", val)
+	}
+}
+
+func toString(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+func toBool(val interface{}) bool {
+	switch v := val.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case uint64:
+		return v != 0
+	case string:
+		return strings.ToLower(v) == "true"
+	default:
+		return false
+	}
+}
+
+func toInt(val interface{}) int {
+	switch v := val.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float32:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Тест проверяет, что опрос без потерь проходит через кодирование и декодирование
+func TestPollTuple_RoundTrip(t *testing.T) {
+	poll := models.Poll{
+		ID:       "poll1",
+		Creator:  "user1",
+		Question: "Вопрос?",
+		Options:  map[string]int{"Да": 2, "Нет": 0},
+		Closed:   true,
+
+		ChannelID:         "channel1",
+		CreatedAt:         time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		RestrictToChannel: true,
+		Quorum:            5,
+		Ranked:            true,
+		OptionOrder:       []string{"Нет", "Да"},
+		Winner:            "user3",
+		PinnedPostID:      "post1",
+		NotifyVoters:      true,
+		ExpiresAt:         time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC),
+		ExpiryWarned:      true,
+		Description:       "Первая строка\nвторая строка",
+		Tags:              []string{"release", "team-a"},
+		Weights:           map[string]int{"user2": 3},
+		WeightedOptions:   map[string]int{"Да": 4, "Нет": 0},
+		AllowAbstain:      true,
+		Abstained:         1,
+		MaxVotes:          50,
+		VoteReceipts:      true,
+		OptionEmoji:       map[string]string{"Да": ":+1:"},
+		NoSelfVote:        true,
+		VoterSalt:         "salt",
+		MembersOnly:       true,
+		Views:             7,
+		Featured:          true,
+		AllowedVoters:     []string{"user2", "user3"},
+	}
+
+	data, err := msgpack.Marshal(newPollTuple(poll))
+	require.NoError(t, err)
+
+	var decoded pollTuple
+	require.NoError(t, msgpack.Unmarshal(data, &decoded))
+	assert.Equal(t, poll, decoded.toModel())
+}
+
+// Тест проверяет, что кортеж кодируется массивом в порядке полей space
+func TestPollTuple_EncodesAsArray(t *testing.T) {
+	data, err := msgpack.Marshal(newPollTuple(models.Poll{ID: "poll1", Creator: "user1", Question: "Q"}))
+	require.NoError(t, err)
+
+	var raw []interface{}
+	require.NoError(t, msgpack.Unmarshal(data, &raw))
+	require.Len(t, raw, 34)
+	assert.Equal(t, "poll1", raw[0])
+	assert.Equal(t, "user1", raw[1])
+	assert.Equal(t, "Q", raw[2])
+	assert.Equal(t, map[interface{}]interface{}{}, raw[3], "пустая карта не должна кодироваться как nil")
+	assert.Equal(t, map[interface{}]interface{}{}, raw[4])
+	assert.Equal(t, false, raw[5])
+	assert.Equal(t, "", raw[6])
+	assert.EqualValues(t, 0, raw[7], "нулевое время хранится как 0")
+	assert.Equal(t, false, raw[8])
+	assert.EqualValues(t, 0, raw[9])
+	assert.Equal(t, false, raw[10])
+	assert.Nil(t, raw[11], "порядок вариантов нерейтингового опроса может отсутствовать")
+	assert.Nil(t, raw[12])
+	assert.Equal(t, "", raw[13])
+	assert.Equal(t, "", raw[14])
+	assert.Equal(t, false, raw[15])
+	assert.EqualValues(t, 0, raw[16], "опрос без срока хранит 0")
+	assert.Equal(t, false, raw[17])
+	assert.Equal(t, "", raw[18])
+	assert.Nil(t, raw[19], "опрос без меток может не хранить их")
+	assert.Nil(t, raw[20], "опрос без весов может не хранить их")
+	assert.Nil(t, raw[21])
+	assert.Equal(t, false, raw[22])
+	assert.EqualValues(t, 0, raw[23])
+	assert.EqualValues(t, 0, raw[24], "опрос без максимума голосов хранит 0")
+	assert.EqualValues(t, pollSchemaVersion, raw[31], "версия схемы - поле 32")
+	assert.Equal(t, false, raw[32])
+	assert.Nil(t, raw[33], "опрос без списка участников может не хранить его")
+}
+
+// Тест проверяет совместимость с кортежами, записанными старым кодом и Lua
+func TestPollTuple_DecodeLegacy(t *testing.T) {
+	tests := []struct {
+		name   string
+		tuple  []interface{}
+		want   models.Poll
+		voters voterSet
+	}{
+		{
+			name: "non-string keys and numeric values",
+			tuple: []interface{}{
+				"poll1", "user1", "Q",
+				map[interface{}]interface{}{42: 1, "user2": true},
+				map[interface{}]interface{}{1: uint64(3), "Нет": int64(-1)},
+				int64(1),
+			},
+			want: models.Poll{
+				ID: "poll1", Creator: "user1", Question: "Q",
+				Options: map[string]int{"1": 3, "Нет": -1},
+				Closed:  true,
+			},
+			voters: voterSet{"42": true, "user2": true},
+		},
+		{
+			name: "tuple without description",
+			tuple: []interface{}{
+				"poll1", "user1", "Q",
+				map[string]interface{}{}, map[string]interface{}{"A": 1}, false,
+				"channel1", uint64(1740830400), false, uint64(0), false, nil, nil, "", "", false,
+				uint64(0), true,
+			},
+			want: models.Poll{
+				ID: "poll1", Creator: "user1", Question: "Q",
+				Options:      map[string]int{"A": 1},
+				ChannelID:    "channel1",
+				CreatedAt:    time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+				ExpiryWarned: true,
+			},
+			voters: voterSet{},
+		},
+		{
+			name:  "missing optional fields",
+			tuple: []interface{}{"poll1", "user1", "Q"},
+			want: models.Poll{
+				ID: "poll1", Creator: "user1", Question: "Q",
+				Options: map[string]int{},
+			},
+		},
+		{
+			name:  "nil maps and string flag",
+			tuple: []interface{}{"poll1", "user1", "Q", nil, nil, "TRUE"},
+			want: models.Poll{
+				ID: "poll1", Creator: "user1", Question: "Q",
+				Options: map[string]int{},
+				Closed:  true,
+			},
+		},
+		{
+			name: "extra trailing fields are ignored",
+			tuple: []interface{}{
+				"poll1", "user1", "Q",
+				map[string]interface{}{}, map[string]interface{}{"A": 1}, false,
+				"channel1", uint64(1740830400), "field from a newer version",
+			},
+			want: models.Poll{
+				ID: "poll1", Creator: "user1", Question: "Q",
+				Options:   map[string]int{"A": 1},
+				ChannelID: "channel1",
+				CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+			},
+			voters: voterSet{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := msgpack.Marshal(tt.tuple)
+			require.NoError(t, err)
+
+			var decoded pollTuple
+			require.NoError(t, msgpack.Unmarshal(data, &decoded))
+			assert.Equal(t, tt.want, decoded.toModel())
+			// Участники старых кортежей нужны MigrateEmbeddedVotes
+			assert.Equal(t, tt.voters, decoded.Voters)
+		})
+	}
+}
+
+// Тест проверяет, что вместо карты не принимается значение другого типа
+func TestPollTuple_DecodeInvalidMap(t *testing.T) {
+	data, err := msgpack.Marshal([]interface{}{"poll1", "user1", "Q", "not a map"})
+	require.NoError(t, err)
+
+	var decoded pollTuple
+	assert.ErrorContains(t, msgpack.Unmarshal(data, &decoded), "поле voters")
+}
+
+// Тест проверяет выбор декодера по версии схемы на кортежах, собранных
+// вручную: старый кортеж из шести полей, кортежи версий 1, 2 и 3, версии
+// с лишним и недостающим полем и версия новее бота
+func TestPollTuple_DecodeVersions(t *testing.T) {
+	v1 := func(extra ...interface{}) []interface{} {
+		row := make([]interface{}, pollFieldsV1)
+		copy(row, []interface{}{"poll1", "user1", "Q", map[string]interface{}{}, map[string]interface{}{"A": 2}, true, "channel1"})
+		row[fieldSchemaVersion] = uint64(1)
+		return append(row, extra...)
+	}
+	v2 := func(featured interface{}) []interface{} {
+		row := v1(featured)
+		row[fieldSchemaVersion] = uint64(2)
+		return row
+	}
+	v3 := func(voters interface{}) []interface{} {
+		row := append(v2(true), voters)
+		row[fieldSchemaVersion] = uint64(3)
+		return row
+	}
+	short := v1()
+	short[fieldSchemaVersion] = uint64(2)
+	shortV3 := v2(true)
+	shortV3[fieldSchemaVersion] = uint64(3)
+	newer := v3(nil)
+	newer[fieldSchemaVersion] = uint64(4)
+
+	tests := []struct {
+		name        string
+		tuple       []interface{}
+		wantVersion int64
+		want        models.Poll
+		wantErr     string
+	}{
+		{
+			name:  "legacy six fields",
+			tuple: []interface{}{"poll1", "user1", "Q", map[string]interface{}{"user2": true}, nil, false},
+			want:  models.Poll{ID: "poll1", Creator: "user1", Question: "Q", Options: map[string]int{}},
+		},
+		{
+			name:        "version 1",
+			tuple:       v1(),
+			wantVersion: 1,
+			want:        models.Poll{ID: "poll1", Creator: "user1", Question: "Q", Options: map[string]int{"A": 2}, Closed: true, ChannelID: "channel1"},
+		},
+		{name: "version 1 with extra field", tuple: v1("extra"), wantErr: "кортеж опроса poll1 версии схемы 1: 33 полей вместо 32"},
+		{
+			name:        "version 2",
+			tuple:       v2(true),
+			wantVersion: 2,
+			want:        models.Poll{ID: "poll1", Creator: "user1", Question: "Q", Options: map[string]int{"A": 2}, Closed: true, ChannelID: "channel1", Featured: true},
+		},
+		{
+			name:        "version 2 with null featured",
+			tuple:       v2(nil),
+			wantVersion: 2,
+			want:        models.Poll{ID: "poll1", Creator: "user1", Question: "Q", Options: map[string]int{"A": 2}, Closed: true, ChannelID: "channel1"},
+		},
+		{name: "version 2 without featured", tuple: short, wantErr: "кортеж опроса poll1 версии схемы 2: 32 полей вместо 33"},
+		{
+			name:        "version 3",
+			tuple:       v3([]interface{}{"user2", "user3"}),
+			wantVersion: 3,
+			want:        models.Poll{ID: "poll1", Creator: "user1", Question: "Q", Options: map[string]int{"A": 2}, Closed: true, ChannelID: "channel1", Featured: true, AllowedVoters: []string{"user2", "user3"}},
+		},
+		{
+			name:        "version 3 without voter list",
+			tuple:       v3(nil),
+			wantVersion: 3,
+			want:        models.Poll{ID: "poll1", Creator: "user1", Question: "Q", Options: map[string]int{"A": 2}, Closed: true, ChannelID: "channel1", Featured: true},
+		},
+		{name: "version 3 without allowed_voters", tuple: shortV3, wantErr: "кортеж опроса poll1 версии схемы 3: 33 полей вместо 34"},
+		{name: "newer version", tuple: newer, wantErr: "кортеж опроса poll1 версии схемы 4, бот читает версии до 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := msgpack.Marshal(tt.tuple)
+			require.NoError(t, err)
+
+			var decoded pollTuple
+			err = msgpack.Unmarshal(data, &decoded)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, decoded.SchemaVersion)
+			assert.Equal(t, tt.want, decoded.toModel())
+		})
+	}
+}
+
+// Тест проверяет, что поля кортежа совпадают с форматом space, который
+// сверяет VerifySchema
+func TestPollTuple_LayoutMatchesFormat(t *testing.T) {
+	assert.Len(t, pollTupleLayout, pollFieldsV3)
+	assert.Len(t, pollFields, pollFieldsV3)
+	assert.Equal(t, "schema_version", pollFields[fieldSchemaVersion])
+	assert.Equal(t, "featured", pollFields[fieldFeatured])
+	assert.Equal(t, "allowed_voters", pollFields[fieldAllowedVoters])
+}
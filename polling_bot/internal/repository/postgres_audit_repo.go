@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"polling_bot/internal/audit"
+)
+
+// PostgresAuditRepo хранит журнал в таблице poll_audit. Таблицу
+// создают миграции PostgresPollRepo.Migrate.
+type PostgresAuditRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresAuditRepo(db *sql.DB) *PostgresAuditRepo {
+	return &PostgresAuditRepo{db: db}
+}
+
+func (r *PostgresAuditRepo) AppendEvent(ctx context.Context, event audit.Event) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO poll_audit (id, poll_id, actor, action, detail, at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		event.ID, event.PollID, event.Actor, string(event.Action), event.Detail, event.At.UTC())
+	if err != nil {
+		return fmt.Errorf("ошибка записи в журнал: %w", classifyPostgresError(err))
+	}
+	return nil
+}
+
+func (r *PostgresAuditRepo) ListEvents(ctx context.Context, pollID string, limit int) ([]audit.Event, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT id, poll_id, actor, action, detail, at FROM (
+			SELECT id, poll_id, actor, action, detail, at, seq FROM poll_audit
+			WHERE poll_id = $1 ORDER BY seq DESC LIMIT $2
+		) last ORDER BY seq`, pollID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	var out []audit.Event
+	for rows.Next() {
+		var event audit.Event
+		var action string
+		if err := rows.Scan(&event.ID, &event.PollID, &event.Actor, &action, &event.Detail, &event.At); err != nil {
+			return nil, fmt.Errorf("ошибка чтения журнала: %w", err)
+		}
+		event.Action = audit.Action(action)
+		event.At = event.At.UTC()
+		out = append(out, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала: %w", classifyPostgresError(err))
+	}
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"polling_bot/internal/models"
+)
+
+// PostgresChannelSettingsRepo хранит настройки каналов в таблице
+// channel_settings. Таблицу создают миграции PostgresPollRepo.Migrate.
+type PostgresChannelSettingsRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresChannelSettingsRepo(db *sql.DB) *PostgresChannelSettingsRepo {
+	return &PostgresChannelSettingsRepo{db: db}
+}
+
+func (r *PostgresChannelSettingsRepo) GetChannelSettings(ctx context.Context, channelID string) (models.ChannelSettings, error) {
+	var settings models.ChannelSettings
+	var updatedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `SELECT channel_id, digest, updated_by, updated_at FROM channel_settings
+		WHERE channel_id = $1`, channelID).Scan(&settings.ChannelID, &settings.Digest, &settings.UpdatedBy, &updatedAt)
+	if err != nil {
+		return models.ChannelSettings{}, fmt.Errorf("ошибка получения настроек канала: %w", classifyPostgresError(err))
+	}
+	if updatedAt.Valid {
+		settings.UpdatedAt = updatedAt.Time.UTC()
+	}
+	return settings, nil
+}
+
+func (r *PostgresChannelSettingsRepo) SaveChannelSettings(ctx context.Context, settings models.ChannelSettings) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO channel_settings (channel_id, digest, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (channel_id) DO UPDATE SET
+			digest = EXCLUDED.digest,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at`,
+		settings.ChannelID, settings.Digest, settings.UpdatedBy, nullTime(settings.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения настроек канала: %w", classifyPostgresError(err))
+	}
+	return nil
+}
+
+func (r *PostgresChannelSettingsRepo) ListDigestChannels(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT channel_id FROM channel_settings WHERE digest ORDER BY channel_id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения каналов со сводкой: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var channelID string
+		if err := rows.Scan(&channelID); err != nil {
+			return nil, fmt.Errorf("ошибка получения каналов со сводкой: %w", err)
+		}
+		out = append(out, channelID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка получения каналов со сводкой: %w", classifyPostgresError(err))
+	}
+	return out, nil
+}
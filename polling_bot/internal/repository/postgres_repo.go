@@ -0,0 +1,561 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+type PostgresPollRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresPollRepo(db *sql.DB) *PostgresPollRepo {
+	return &PostgresPollRepo{db: db}
+}
+
+// Ping проверяет, что PostgreSQL отвечает, и возвращает время ответа.
+func (r *PostgresPollRepo) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if err := r.db.PingContext(ctx); err != nil {
+		return 0, fmt.Errorf("PostgreSQL не отвечает: %w", err)
+	}
+	return time.Since(start), nil
+}
+
+// Migrate применяет встроенные миграции, которые ещё не записаны
+// в schema_migrations. Каждая миграция выполняется в своей транзакции.
+func (r *PostgresPollRepo) Migrate(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("ошибка создания таблицы миграций: %w", classifyPostgresError(err))
+	}
+
+	names, err := fs.Glob(postgresMigrations, "migrations/postgres/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(name[strings.LastIndex(name, "/")+1:], ".sql")
+		if err := r.applyMigration(ctx, name, version); err != nil {
+			return fmt.Errorf("ошибка миграции %s: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func (r *PostgresPollRepo) applyMigration(ctx context.Context, name, version string) error {
+	body, err := postgresMigrations.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return classifyPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT DO NOTHING`, version)
+	if err != nil {
+		return classifyPostgresError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+		return classifyPostgresError(err)
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresPollRepo) SavePoll(ctx context.Context, poll models.Poll) error {
+	options, order, err := marshalPollJSON(poll)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения опроса: %w", err)
+	}
+	tags, err := marshalStrings(poll.Tags)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения опроса: %w", err)
+	}
+	weights, err := marshalCounts(poll.Weights)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения опроса: %w", err)
+	}
+	weighted, err := marshalCounts(poll.WeightedOptions)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения опроса: %w", err)
+	}
+	emoji, err := marshalEmoji(poll.OptionEmoji)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения опроса: %w", err)
+	}
+	voters, err := marshalStrings(poll.AllowedVoters)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения опроса: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO polls (id, creator, question, options, is_closed, channel_id, created_at, channel_only, quorum,
+			ranked, option_order, winner, pinned_post_id, notify_voters, expires_at, expiry_warned, description, tags,
+			weights, weighted_options, allow_abstain, abstained, max_votes, vote_receipts, option_emoji,
+			no_self_vote, voter_salt, members_only, views, featured, allowed_voters)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23,
+			$24, $25, $26, $27, $28, $29, $30, $31)
+		ON CONFLICT (id) DO UPDATE SET
+			creator = EXCLUDED.creator,
+			question = EXCLUDED.question,
+			options = EXCLUDED.options,
+			is_closed = EXCLUDED.is_closed,
+			channel_id = EXCLUDED.channel_id,
+			created_at = EXCLUDED.created_at,
+			channel_only = EXCLUDED.channel_only,
+			quorum = EXCLUDED.quorum,
+			ranked = EXCLUDED.ranked,
+			option_order = EXCLUDED.option_order,
+			winner = EXCLUDED.winner,
+			pinned_post_id = EXCLUDED.pinned_post_id,
+			notify_voters = EXCLUDED.notify_voters,
+			expires_at = EXCLUDED.expires_at,
+			expiry_warned = EXCLUDED.expiry_warned,
+			description = EXCLUDED.description,
+			tags = EXCLUDED.tags,
+			weights = EXCLUDED.weights,
+			weighted_options = EXCLUDED.weighted_options,
+			allow_abstain = EXCLUDED.allow_abstain,
+			abstained = EXCLUDED.abstained,
+			max_votes = EXCLUDED.max_votes,
+			vote_receipts = EXCLUDED.vote_receipts,
+			option_emoji = EXCLUDED.option_emoji,
+			no_self_vote = EXCLUDED.no_self_vote,
+			voter_salt = EXCLUDED.voter_salt,
+			members_only = EXCLUDED.members_only,
+			views = EXCLUDED.views,
+			featured = EXCLUDED.featured,
+			allowed_voters = EXCLUDED.allowed_voters`,
+		poll.ID, poll.Creator, poll.Question, options, poll.Closed, poll.ChannelID, nullTime(poll.CreatedAt),
+		poll.RestrictToChannel, poll.Quorum, poll.Ranked, order, poll.Winner, poll.PinnedPostID, poll.NotifyVoters,
+		nullTime(poll.ExpiresAt), poll.ExpiryWarned, poll.Description, tags,
+		weights, weighted, poll.AllowAbstain, poll.Abstained, poll.MaxVotes, poll.VoteReceipts, emoji,
+		poll.NoSelfVote, poll.VoterSalt, poll.MembersOnly, poll.Views, poll.Featured, voters)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения опроса: %w", classifyPostgresError(err))
+	}
+	return nil
+}
+
+func (r *PostgresPollRepo) IncrementOption(ctx context.Context, pollID, option string, delta int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE polls
+		SET options = jsonb_set(options, ARRAY[$2::text], to_jsonb(COALESCE((options->>$2)::int, 0) + $3))
+		WHERE id = $1`, pollID, option, delta)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения счётчика варианта: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) IncrementViews(ctx context.Context, pollID string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE polls SET views = views + 1 WHERE id = $1`, pollID)
+	if err != nil {
+		return fmt.Errorf("ошибка учёта просмотра опроса: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) GetPoll(ctx context.Context, id string) (models.Poll, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+pollColumns+` FROM polls WHERE id = $1`, id)
+	poll, err := scanPoll(row)
+	if err != nil {
+		return models.Poll{}, fmt.Errorf("ошибка получения опроса: %w", classifyPostgresError(err))
+	}
+	return poll, nil
+}
+
+// GetPollSummary - то же, что GetPoll: строка опроса не содержит голосов,
+// они лежат в poll_votes.
+func (r *PostgresPollRepo) GetPollSummary(ctx context.Context, id string) (models.Poll, error) {
+	return r.GetPoll(ctx, id)
+}
+
+func (r *PostgresPollRepo) GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+pollColumns+` FROM polls WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения опросов: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	polls := make(map[string]models.Poll, len(ids))
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения опросов: %w", err)
+		}
+		polls[poll.ID] = poll
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка получения опросов: %w", classifyPostgresError(err))
+	}
+	return polls, nil
+}
+
+func (r *PostgresPollRepo) ClosePoll(ctx context.Context, pollID string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE polls SET is_closed = TRUE WHERE id = $1`, pollID)
+	if err != nil {
+		return fmt.Errorf("ошибка закрытия опроса: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) SetCreator(ctx context.Context, pollID, creator string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE polls SET creator = $2 WHERE id = $1`, pollID, creator)
+	if err != nil {
+		return fmt.Errorf("ошибка смены создателя опроса: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) SetPinnedPost(ctx context.Context, pollID, postID string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE polls SET pinned_post_id = $2 WHERE id = $1`, pollID, postID)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения закреплённого сообщения: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) SetExpiryWarned(ctx context.Context, pollID string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE polls SET expiry_warned = TRUE WHERE id = $1`, pollID)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения предупреждения о сроке опроса: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) SetFeatured(ctx context.Context, pollID string, featured bool) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE polls SET featured = $2 WHERE id = $1`, pollID, featured)
+	if err != nil {
+		return fmt.Errorf("ошибка отметки опроса: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) SetAllowedVoters(ctx context.Context, pollID string, voters []string) error {
+	encoded, err := marshalStrings(voters)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения списка участников: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE polls SET allowed_voters = $2 WHERE id = $1`, pollID, encoded)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения списка участников: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+// UpdateSettings собирает SET только из заданных полей update.
+func (r *PostgresPollRepo) UpdateSettings(ctx context.Context, pollID string, update SettingsUpdate) error {
+	sets := []string{}
+	args := []interface{}{pollID}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if update.Question != nil {
+		set("question", *update.Question)
+	}
+	if update.RestrictToChannel != nil {
+		set("channel_only", *update.RestrictToChannel)
+	}
+	if update.Quorum != nil {
+		set("quorum", *update.Quorum)
+	}
+	if update.MaxVotes != nil {
+		set("max_votes", *update.MaxVotes)
+	}
+	if update.ExpiresAt != nil {
+		set("expires_at", nullTime(*update.ExpiresAt))
+		set("expiry_warned", false)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	res, err := r.db.ExecContext(ctx, `UPDATE polls SET `+strings.Join(sets, ", ")+` WHERE id = $1`, args...)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения настроек опроса: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) DeletePoll(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM polls WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления опроса: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresPollRepo) ListPolls(ctx context.Context, filter ListFilter) ([]models.Poll, string, error) {
+	where, args := filterConditions(filter)
+	args = append(args, filter.Cursor)
+	where = append(where, fmt.Sprintf("id > $%d", len(args)))
+
+	limit := filter.pageLimit()
+	args = append(args, limit+1)
+	query := fmt.Sprintf(`SELECT %s FROM polls WHERE %s ORDER BY id LIMIT $%d`,
+		pollColumns, strings.Join(where, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка получения списка опросов: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	page := make([]models.Poll, 0, limit)
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка получения списка опросов: %w", err)
+		}
+		page = append(page, poll)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("ошибка получения списка опросов: %w", classifyPostgresError(err))
+	}
+
+	if len(page) > limit {
+		page = page[:limit]
+		return page, page[limit-1].ID, nil
+	}
+	return page, "", nil
+}
+
+func (r *PostgresPollRepo) CountPolls(ctx context.Context, filter ListFilter) (int, error) {
+	where, args := filterConditions(filter)
+	query := `SELECT count(*) FROM polls`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта опросов: %w", classifyPostgresError(err))
+	}
+	return count, nil
+}
+
+// filterConditions переводит предикаты фильтра, кроме курсора, в условия
+// WHERE с параметрами $1, $2...
+func filterConditions(filter ListFilter) ([]string, []interface{}) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Creator != "" {
+		add("creator = $%d", filter.Creator)
+	}
+	if filter.ChannelID != "" {
+		add("channel_id = $%d", filter.ChannelID)
+	}
+	if filter.Closed != nil {
+		add("is_closed = $%d", *filter.Closed)
+	}
+	if filter.Featured != nil {
+		add("featured = $%d", *filter.Featured)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		add("created_at > $%d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		add("created_at < $%d", filter.CreatedBefore)
+	}
+	if filter.Tag != "" {
+		add("tags @> jsonb_build_array($%d::text)", filter.Tag)
+	}
+	return where, args
+}
+
+const pollColumns = `id, creator, question, options, is_closed, channel_id, created_at, channel_only, quorum, ranked, option_order, winner, pinned_post_id, notify_voters, expires_at, expiry_warned, description, tags, weights, weighted_options, allow_abstain, abstained, max_votes, vote_receipts, option_emoji, no_self_vote, voter_salt, members_only, views, featured, allowed_voters`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPoll(row rowScanner) (models.Poll, error) {
+	var poll models.Poll
+	var options, order, tags, weights, weighted, emoji, voters []byte
+	var createdAt, expiresAt sql.NullTime
+
+	err := row.Scan(&poll.ID, &poll.Creator, &poll.Question, &options, &poll.Closed, &poll.ChannelID, &createdAt,
+		&poll.RestrictToChannel, &poll.Quorum, &poll.Ranked, &order,
+		&poll.Winner, &poll.PinnedPostID, &poll.NotifyVoters, &expiresAt, &poll.ExpiryWarned, &poll.Description, &tags,
+		&weights, &weighted, &poll.AllowAbstain, &poll.Abstained, &poll.MaxVotes, &poll.VoteReceipts, &emoji,
+		&poll.NoSelfVote, &poll.VoterSalt, &poll.MembersOnly, &poll.Views, &poll.Featured, &voters)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	if err := json.Unmarshal(options, &poll.Options); err != nil {
+		return models.Poll{}, fmt.Errorf("поле options: %w", err)
+	}
+	if order != nil {
+		if err := json.Unmarshal(order, &poll.OptionOrder); err != nil {
+			return models.Poll{}, fmt.Errorf("поле option_order: %w", err)
+		}
+	}
+	if err := json.Unmarshal(tags, &poll.Tags); err != nil {
+		return models.Poll{}, fmt.Errorf("поле tags: %w", err)
+	}
+	if len(poll.Tags) == 0 {
+		poll.Tags = nil
+	}
+	if err := json.Unmarshal(voters, &poll.AllowedVoters); err != nil {
+		return models.Poll{}, fmt.Errorf("поле allowed_voters: %w", err)
+	}
+	if len(poll.AllowedVoters) == 0 {
+		poll.AllowedVoters = nil
+	}
+	if poll.Weights, err = unmarshalCounts(weights); err != nil {
+		return models.Poll{}, fmt.Errorf("поле weights: %w", err)
+	}
+	if poll.WeightedOptions, err = unmarshalCounts(weighted); err != nil {
+		return models.Poll{}, fmt.Errorf("поле weighted_options: %w", err)
+	}
+	if err := json.Unmarshal(emoji, &poll.OptionEmoji); err != nil {
+		return models.Poll{}, fmt.Errorf("поле option_emoji: %w", err)
+	}
+	if len(poll.OptionEmoji) == 0 {
+		poll.OptionEmoji = nil
+	}
+	if poll.Options == nil {
+		poll.Options = make(map[string]int)
+	}
+	if createdAt.Valid {
+		poll.CreatedAt = createdAt.Time.UTC()
+	}
+	if expiresAt.Valid {
+		poll.ExpiresAt = expiresAt.Time.UTC()
+	}
+	return poll, nil
+}
+
+// marshalPollJSON кодирует счётчики вариантов и их порядок; порядок,
+// которого нет, хранится как NULL.
+func marshalPollJSON(poll models.Poll) (options, order []byte, err error) {
+	counts := poll.Options
+	if counts == nil {
+		counts = map[string]int{}
+	}
+	if options, err = json.Marshal(counts); err != nil {
+		return nil, nil, err
+	}
+	if poll.OptionOrder != nil {
+		if order, err = json.Marshal(poll.OptionOrder); err != nil {
+			return nil, nil, err
+		}
+	}
+	return options, order, nil
+}
+
+// marshalCounts кодирует словарь чисел для столбца JSONB NOT NULL: nil
+// хранится как {}.
+func marshalCounts(counts map[string]int) ([]byte, error) {
+	if counts == nil {
+		counts = map[string]int{}
+	}
+	return json.Marshal(counts)
+}
+
+// unmarshalCounts - обратное marshalCounts: пустой словарь читается как nil.
+func unmarshalCounts(data []byte) (map[string]int, error) {
+	var counts map[string]int
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return nil, nil
+	}
+	return counts, nil
+}
+
+// marshalEmoji кодирует эмодзи вариантов; опрос без эмодзи - пустой словарь.
+func marshalEmoji(emoji map[string]string) ([]byte, error) {
+	if emoji == nil {
+		emoji = map[string]string{}
+	}
+	return json.Marshal(emoji)
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// classifyPostgresError - аналог classifyError для ошибок database/sql и lib/pq.
+func classifyPostgresError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if kind := postgresErrorKind(err); kind != nil {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+func postgresErrorKind(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "40001", pqErr.Code == "40P01":
+			// serialization_failure и deadlock_detected
+			return ErrConflict
+		case pqErr.Code.Class() == "08", pqErr.Code.Class() == "57":
+			// connection_exception и operator_intervention (в т.ч. остановка сервера)
+			return ErrUnavailable
+		}
+		return nil
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return ErrUnavailable
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Интеграционные тесты PostgreSQL запускаются командой
+//
+//	TEST_POSTGRES_DSN=postgres://... go test -tags integration ./internal/repository/
+//
+// и переиспользуют контрактные тесты остальных реализаций.
+func init() {
+	extraRepos["postgres"] = newPostgresTestRepo
+	extraVoteRepos["postgres"] = func(t *testing.T) (PollRepository, VoteRepository) {
+		repo := newPostgresTestRepo(t).(*PostgresPollRepo)
+		return repo, NewPostgresVoteRepo(repo.db)
+	}
+	extraScheduleRepos["postgres"] = func(t *testing.T) ScheduleRepository {
+		repo := newPostgresTestRepo(t).(*PostgresPollRepo)
+		_, err := repo.db.Exec(`TRUNCATE poll_schedules`)
+		require.NoError(t, err)
+		return NewPostgresScheduleRepo(repo.db)
+	}
+	extraTemplateRepos["postgres"] = func(t *testing.T) TemplateRepository {
+		repo := newPostgresTestRepo(t).(*PostgresPollRepo)
+		_, err := repo.db.Exec(`TRUNCATE poll_templates`)
+		require.NoError(t, err)
+		return NewPostgresTemplateRepo(repo.db)
+	}
+	extraAuditRepos["postgres"] = func(t *testing.T) AuditRepository {
+		repo := newPostgresTestRepo(t).(*PostgresPollRepo)
+		_, err := repo.db.Exec(`TRUNCATE poll_audit`)
+		require.NoError(t, err)
+		return NewPostgresAuditRepo(repo.db)
+	}
+	extraChannelSettingsRepos["postgres"] = func(t *testing.T) ChannelSettingsRepository {
+		repo := newPostgresTestRepo(t).(*PostgresPollRepo)
+		_, err := repo.db.Exec(`TRUNCATE channel_settings`)
+		require.NoError(t, err)
+		return NewPostgresChannelSettingsRepo(repo.db)
+	}
+	extraUsageRepos["postgres"] = func(t *testing.T) UsageRepository {
+		repo := newPostgresTestRepo(t).(*PostgresPollRepo)
+		_, err := repo.db.Exec(`TRUNCATE command_usage`)
+		require.NoError(t, err)
+		return NewPostgresUsageRepo(repo.db)
+	}
+}
+
+func newPostgresTestRepo(t *testing.T) PollRepository {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN не задан")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	repo := NewPostgresPollRepo(db)
+	require.NoError(t, repo.Migrate(context.Background()))
+	// Голоса ссылаются на опросы и очищаются вместе с ними
+	_, err = db.Exec(`TRUNCATE polls CASCADE`)
+	require.NoError(t, err)
+	return repo
+}
+
+// Тест проверяет, что повторный запуск миграций ничего не ломает
+func TestPostgresMigrate_Idempotent(t *testing.T) {
+	repo := newPostgresTestRepo(t).(*PostgresPollRepo)
+	require.NoError(t, repo.Migrate(context.Background()))
+
+	var applied int
+	require.NoError(t, repo.db.QueryRow(`SELECT count(*) FROM schema_migrations`).Scan(&applied))
+	names, err := fs.Glob(postgresMigrations, "migrations/postgres/*.sql")
+	require.NoError(t, err)
+	assert.Equal(t, len(names), applied)
+}
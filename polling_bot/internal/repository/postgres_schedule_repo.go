@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"polling_bot/internal/models"
+)
+
+// PostgresScheduleRepo хранит расписания в таблице poll_schedules. Таблицу
+// создают миграции PostgresPollRepo.Migrate.
+type PostgresScheduleRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresScheduleRepo(db *sql.DB) *PostgresScheduleRepo {
+	return &PostgresScheduleRepo{db: db}
+}
+
+func (r *PostgresScheduleRepo) SaveSchedule(ctx context.Context, schedule models.Schedule) error {
+	options := schedule.Options
+	if options == nil {
+		options = []string{}
+	}
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения расписания: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO poll_schedules (id, creator, channel_id, cron, question, options, channel_only, quorum, ranked,
+			exclusive, created_at, last_run_at, unreachable_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (id) DO UPDATE SET
+			creator = EXCLUDED.creator,
+			channel_id = EXCLUDED.channel_id,
+			cron = EXCLUDED.cron,
+			question = EXCLUDED.question,
+			options = EXCLUDED.options,
+			channel_only = EXCLUDED.channel_only,
+			quorum = EXCLUDED.quorum,
+			ranked = EXCLUDED.ranked,
+			exclusive = EXCLUDED.exclusive,
+			created_at = EXCLUDED.created_at,
+			last_run_at = EXCLUDED.last_run_at,
+			unreachable_at = EXCLUDED.unreachable_at`,
+		schedule.ID, schedule.Creator, schedule.ChannelID, schedule.Cron, schedule.Question, optionsJSON,
+		schedule.RestrictToChannel, schedule.Quorum, schedule.Ranked, schedule.Exclusive, nullTime(schedule.CreatedAt), nullTime(schedule.LastRunAt),
+		nullTime(schedule.UnreachableAt))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения расписания: %w", classifyPostgresError(err))
+	}
+	return nil
+}
+
+func (r *PostgresScheduleRepo) GetSchedule(ctx context.Context, id string) (models.Schedule, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM poll_schedules WHERE id = $1`, id)
+	schedule, err := scanSchedule(row)
+	if err != nil {
+		return models.Schedule{}, fmt.Errorf("ошибка получения расписания: %w", classifyPostgresError(err))
+	}
+	return schedule, nil
+}
+
+func (r *PostgresScheduleRepo) DeleteSchedule(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM poll_schedules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления расписания: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresScheduleRepo) ListSchedules(ctx context.Context, channelID string) ([]models.Schedule, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+scheduleColumns+` FROM poll_schedules
+		WHERE $1 = '' OR channel_id = $1 ORDER BY id`, channelID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка расписаний: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	var out []models.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения списка расписаний: %w", err)
+		}
+		out = append(out, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка получения списка расписаний: %w", classifyPostgresError(err))
+	}
+	return out, nil
+}
+
+const scheduleColumns = `id, creator, channel_id, cron, question, options, channel_only, quorum, ranked, exclusive, created_at, last_run_at, unreachable_at`
+
+func scanSchedule(row rowScanner) (models.Schedule, error) {
+	var schedule models.Schedule
+	var options []byte
+	var createdAt, lastRunAt, unreachableAt sql.NullTime
+
+	err := row.Scan(&schedule.ID, &schedule.Creator, &schedule.ChannelID, &schedule.Cron, &schedule.Question, &options,
+		&schedule.RestrictToChannel, &schedule.Quorum, &schedule.Ranked, &schedule.Exclusive, &createdAt, &lastRunAt, &unreachableAt)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	if err := json.Unmarshal(options, &schedule.Options); err != nil {
+		return models.Schedule{}, fmt.Errorf("поле options: %w", err)
+	}
+	if createdAt.Valid {
+		schedule.CreatedAt = createdAt.Time.UTC()
+	}
+	if lastRunAt.Valid {
+		schedule.LastRunAt = lastRunAt.Time.UTC()
+	}
+	if unreachableAt.Valid {
+		schedule.UnreachableAt = unreachableAt.Time.UTC()
+	}
+	return schedule, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/models"
+)
+
+// PostgresTemplateRepo хранит шаблоны в таблице poll_templates. Таблицу
+// создают миграции PostgresPollRepo.Migrate.
+type PostgresTemplateRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresTemplateRepo(db *sql.DB) *PostgresTemplateRepo {
+	return &PostgresTemplateRepo{db: db}
+}
+
+func (r *PostgresTemplateRepo) CreateTemplate(ctx context.Context, template models.Template) error {
+	options, err := marshalStrings(template.Options)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения шаблона: %w", err)
+	}
+	tags, err := marshalStrings(template.Tags)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения шаблона: %w", err)
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO poll_templates (scope, name, creator, question, options, description, tags, channel_only, quorum,
+			ranked, exclusive, pin, notify_voters, expires, no_expire, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT DO NOTHING`,
+		template.Scope, template.Name, template.Creator, template.Question, options, template.Description, tags,
+		template.RestrictToChannel, template.Quorum, template.Ranked, template.Exclusive, template.Pin,
+		template.NotifyVoters, int64(template.Expires/time.Second), template.NoExpire, nullTime(template.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения шаблона: %w", classifyPostgresError(err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (r *PostgresTemplateRepo) GetTemplate(ctx context.Context, scope, name string) (models.Template, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+templateColumns+` FROM poll_templates WHERE scope = $1 AND name = $2`, scope, name)
+	template, err := scanTemplate(row)
+	if err != nil {
+		return models.Template{}, fmt.Errorf("ошибка получения шаблона: %w", classifyPostgresError(err))
+	}
+	return template, nil
+}
+
+func (r *PostgresTemplateRepo) DeleteTemplate(ctx context.Context, scope, name string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM poll_templates WHERE scope = $1 AND name = $2`, scope, name)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления шаблона: %w", classifyPostgresError(err))
+	}
+	return requireAffected(res)
+}
+
+func (r *PostgresTemplateRepo) ListTemplates(ctx context.Context, scope string) ([]models.Template, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+templateColumns+` FROM poll_templates
+		WHERE scope = $1 ORDER BY name`, scope)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка шаблонов: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	var out []models.Template
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения списка шаблонов: %w", err)
+		}
+		out = append(out, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка получения списка шаблонов: %w", classifyPostgresError(err))
+	}
+	return out, nil
+}
+
+const templateColumns = `scope, name, creator, question, options, description, tags, channel_only, quorum, ranked, exclusive, pin, notify_voters, expires, no_expire, created_at`
+
+func scanTemplate(row rowScanner) (models.Template, error) {
+	var template models.Template
+	var options, tags []byte
+	var expires int64
+	var createdAt sql.NullTime
+
+	err := row.Scan(&template.Scope, &template.Name, &template.Creator, &template.Question, &options, &template.Description,
+		&tags, &template.RestrictToChannel, &template.Quorum, &template.Ranked, &template.Exclusive, &template.Pin,
+		&template.NotifyVoters, &expires, &template.NoExpire, &createdAt)
+	if err != nil {
+		return models.Template{}, err
+	}
+	if err := json.Unmarshal(options, &template.Options); err != nil {
+		return models.Template{}, fmt.Errorf("поле options: %w", err)
+	}
+	if err := json.Unmarshal(tags, &template.Tags); err != nil {
+		return models.Template{}, fmt.Errorf("поле tags: %w", err)
+	}
+	if len(template.Tags) == 0 {
+		template.Tags = nil
+	}
+	template.Expires = time.Duration(expires) * time.Second
+	if createdAt.Valid {
+		template.CreatedAt = createdAt.Time.UTC()
+	}
+	return template, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/models"
+)
+
+// PostgresUsageRepo хранит счётчики команд в таблице command_usage.
+// Таблицу создают миграции PostgresPollRepo.Migrate.
+type PostgresUsageRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresUsageRepo(db *sql.DB) *PostgresUsageRepo {
+	return &PostgresUsageRepo{db: db}
+}
+
+// AddUsage записывает пачку одной транзакцией, чтобы при ошибке не
+// осталось прибавленной половины, которую вызывающий прибавит ещё раз.
+func (r *PostgresUsageRepo) AddUsage(ctx context.Context, usage []models.CommandUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка записи статистики команд: %w", classifyPostgresError(err))
+	}
+	defer tx.Rollback()
+
+	for _, u := range usage {
+		_, err := tx.ExecContext(ctx, `INSERT INTO command_usage (day, command, count) VALUES ($1, $2, $3)
+			ON CONFLICT (day, command) DO UPDATE SET count = command_usage.count + EXCLUDED.count`,
+			time.Unix(usageDay(u.Day), 0).UTC(), u.Command, u.Count)
+		if err != nil {
+			return fmt.Errorf("ошибка записи статистики команд: %w", classifyPostgresError(err))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка записи статистики команд: %w", classifyPostgresError(err))
+	}
+	return nil
+}
+
+func (r *PostgresUsageRepo) ListUsage(ctx context.Context, since time.Time) ([]models.CommandUsage, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT day, command, count FROM command_usage
+		WHERE day >= $1 ORDER BY day, command`, time.Unix(usageDay(since), 0).UTC())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения статистики команд: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	var out []models.CommandUsage
+	for rows.Next() {
+		var u models.CommandUsage
+		if err := rows.Scan(&u.Day, &u.Command, &u.Count); err != nil {
+			return nil, fmt.Errorf("ошибка получения статистики команд: %w", err)
+		}
+		u.Day = u.Day.UTC()
+		out = append(out, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка получения статистики команд: %w", classifyPostgresError(err))
+	}
+	return out, nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"polling_bot/internal/models"
+)
+
+// PostgresVoteRepo хранит голоса в таблице poll_votes. Таблицу создают
+// миграции PostgresPollRepo.Migrate; голоса удаляются вместе с опросом.
+type PostgresVoteRepo struct {
+	db *sql.DB
+}
+
+func NewPostgresVoteRepo(db *sql.DB) *PostgresVoteRepo {
+	return &PostgresVoteRepo{db: db}
+}
+
+// AddVote блокирует строку опроса до конца транзакции, чтобы параллельные
+// голоса проверялись и учитывались по очереди.
+func (r *PostgresVoteRepo) AddVote(ctx context.Context, vote models.Vote) (bool, error) {
+	choices, err := marshalStrings(vote.Choices)
+	if err != nil {
+		return false, fmt.Errorf("ошибка сохранения голоса: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+	}
+	defer tx.Rollback()
+
+	var closed bool
+	var quorum, maxVotes int
+	err = tx.QueryRowContext(ctx, `SELECT is_closed, quorum, max_votes FROM polls WHERE id = $1 FOR UPDATE`, vote.PollID).
+		Scan(&closed, &quorum, &maxVotes)
+	if err != nil {
+		return false, fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+	}
+	if closed {
+		if maxVotes > 0 {
+			var voters int
+			err := tx.QueryRowContext(ctx, `SELECT count(*) FROM poll_votes WHERE poll_id = $1`, vote.PollID).Scan(&voters)
+			if err != nil {
+				return false, fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+			}
+			if voters >= maxVotes {
+				return false, ErrPollFull
+			}
+		}
+		return false, ErrPollClosed
+	}
+
+	// Строка опроса заблокирована, поэтому прочитанный голос не изменится
+	// до конца транзакции
+	abstained := 0
+	var previous bool
+	err = tx.QueryRowContext(ctx, `SELECT abstain FROM poll_votes WHERE poll_id = $1 AND user_id = $2`,
+		vote.PollID, vote.UserID).Scan(&previous)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+	case !previous || vote.Abstain:
+		return false, ErrAlreadyVoted
+	default:
+		// Воздержавшийся уже учтён в кворуме, меняется только его выбор
+		abstained--
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO poll_votes (poll_id, user_id, choices, voted_at, post_id, weight, abstain)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (poll_id, user_id) DO UPDATE
+		SET choices = EXCLUDED.choices, voted_at = EXCLUDED.voted_at, post_id = EXCLUDED.post_id,
+			weight = EXCLUDED.weight, abstain = EXCLUDED.abstain`,
+		vote.PollID, vote.UserID, string(choices), nullTime(vote.VotedAt), vote.PostID, vote.Weight, vote.Abstain)
+	if err != nil {
+		return false, fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+	}
+
+	var choice string
+	if vote.Abstain {
+		abstained++
+	} else {
+		choice = vote.Choices[0]
+	}
+	// Кворум и максимум голосов считают участников вместе с этим голосом,
+	// опрос закрывает меньший из них; голос без веса (weight = 0) не меняет
+	// суммы весов, а воздержавшийся - счётчиков
+	err = tx.QueryRowContext(ctx, `UPDATE polls SET
+			options = CASE WHEN $6 THEN options
+				ELSE jsonb_set(options, ARRAY[$2::text], to_jsonb(COALESCE((options->>$2)::int, 0) + 1)) END,
+			weighted_options = CASE WHEN NOT $6 AND $4 > 0
+				THEN jsonb_set(weighted_options, ARRAY[$2::text], to_jsonb(COALESCE((weighted_options->>$2)::int, 0) + $4))
+				ELSE weighted_options END,
+			abstained = abstained + $5,
+			is_closed = COALESCE((SELECT count(*) FROM poll_votes WHERE poll_id = $1) >= LEAST(NULLIF($3, 0), NULLIF($7, 0)), FALSE)
+		WHERE id = $1
+		RETURNING is_closed`,
+		vote.PollID, choice, quorum, vote.Weight, abstained, vote.Abstain, maxVotes).Scan(&closed)
+	if err != nil {
+		return false, fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+	}
+	return closed, nil
+}
+
+func (r *PostgresVoteRepo) GetVote(ctx context.Context, pollID, userID string) (models.Vote, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+voteColumns+` FROM poll_votes WHERE poll_id = $1 AND user_id = $2`,
+		pollID, userID)
+	vote, err := scanVote(row)
+	if err != nil {
+		return models.Vote{}, fmt.Errorf("ошибка получения голоса: %w", classifyPostgresError(err))
+	}
+	return vote, nil
+}
+
+func (r *PostgresVoteRepo) ListVotes(ctx context.Context, pollID string) ([]models.Vote, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+voteColumns+` FROM poll_votes WHERE poll_id = $1 ORDER BY user_id`, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения голосов: %w", classifyPostgresError(err))
+	}
+	defer rows.Close()
+
+	var votes []models.Vote
+	for rows.Next() {
+		vote, err := scanVote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения голосов: %w", err)
+		}
+		votes = append(votes, vote)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка получения голосов: %w", classifyPostgresError(err))
+	}
+	return votes, nil
+}
+
+func (r *PostgresVoteRepo) CountVotes(ctx context.Context, pollID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT count(*) FROM poll_votes WHERE poll_id = $1`, pollID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта голосов: %w", classifyPostgresError(err))
+	}
+	return count, nil
+}
+
+func (r *PostgresVoteRepo) ImportVotes(ctx context.Context, votes []models.Vote) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+	}
+	defer tx.Rollback()
+
+	for _, vote := range votes {
+		choices, err := marshalStrings(vote.Choices)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения голоса: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO poll_votes (poll_id, user_id, choices, voted_at, post_id, weight, abstain)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (poll_id, user_id) DO UPDATE
+			SET choices = EXCLUDED.choices, voted_at = EXCLUDED.voted_at, post_id = EXCLUDED.post_id,
+				weight = EXCLUDED.weight, abstain = EXCLUDED.abstain`,
+			vote.PollID, vote.UserID, string(choices), nullTime(vote.VotedAt), vote.PostID, vote.Weight, vote.Abstain)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка сохранения голоса: %w", classifyPostgresError(err))
+	}
+	return nil
+}
+
+func (r *PostgresVoteRepo) DeleteVotes(ctx context.Context, pollID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM poll_votes WHERE poll_id = $1`, pollID); err != nil {
+		return fmt.Errorf("ошибка удаления голосов: %w", classifyPostgresError(err))
+	}
+	return nil
+}
+
+// marshalStrings кодирует список строк для столбца JSONB NOT NULL: nil,
+// например голос без бюллетеня, хранится как [].
+func marshalStrings(choices []string) ([]byte, error) {
+	if choices == nil {
+		choices = []string{}
+	}
+	return json.Marshal(choices)
+}
+
+const voteColumns = `poll_id, user_id, choices, voted_at, post_id, weight, abstain`
+
+func scanVote(row rowScanner) (models.Vote, error) {
+	var vote models.Vote
+	var choices []byte
+	var votedAt sql.NullTime
+	if err := row.Scan(&vote.PollID, &vote.UserID, &choices, &votedAt, &vote.PostID, &vote.Weight, &vote.Abstain); err != nil {
+		return models.Vote{}, err
+	}
+	if err := json.Unmarshal(choices, &vote.Choices); err != nil {
+		return models.Vote{}, fmt.Errorf("поле choices: %w", err)
+	}
+	if len(vote.Choices) == 0 {
+		vote.Choices = nil
+	}
+	if votedAt.Valid {
+		vote.VotedAt = votedAt.Time.UTC()
+	}
+	return vote, nil
+}
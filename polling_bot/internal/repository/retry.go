@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy задаёт повторы записи голоса при конфликте транзакций.
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:  5,
+		BaseDelay: 20 * time.Millisecond,
+		MaxDelay:  time.Second,
+	}
+}
+
+// normalized подставляет значения по умолчанию вместо некорректных.
+func (p RetryPolicy) normalized() RetryPolicy {
+	def := DefaultRetryPolicy()
+	if p.Attempts < 1 {
+		p.Attempts = 1
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = def.BaseDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay * 50
+	}
+	return p
+}
+
+// backoff возвращает паузу перед повтором номер attempt (с единицы):
+// экспонента от базовой паузы с потолком MaxDelay и случайной половиной
+// сверху, чтобы конкурирующие голоса не повторялись синхронно.
+func (p RetryPolicy) backoff(attempt int, jitter func() float64) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(jitter()*float64(delay-half))
+}
+
+// sleepContext ждёт d или отмены контекста, смотря что наступит раньше.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
+)
+
+// fakeSleeper запоминает запрошенные паузы вместо реального ожидания
+type fakeSleeper struct {
+	delays []time.Duration
+	err    error
+}
+
+func (s *fakeSleeper) sleep(ctx context.Context, d time.Duration) error {
+	s.delays = append(s.delays, d)
+	return s.err
+}
+
+func conflicts(n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = tarantool.Error{Code: tarantool.ErrTransactionConflict, Msg: "Transaction has been aborted by conflict"}
+	}
+	return errs
+}
+
+func newRetryRepo(t *testing.T, conn *fakeConn, policy RetryPolicy, sleeper *fakeSleeper) *TarantoolVoteRepo {
+	require.NoError(t, newTestRepo(conn).SavePoll(context.Background(), testPoll("poll1")))
+	repo := newTestVoteRepo(conn)
+	repo.retry = policy.normalized()
+	repo.sleep = sleeper.sleep
+	repo.jitter = func() float64 { return 0 }
+	return repo
+}
+
+var retryVote = models.Vote{PollID: "poll1", UserID: "user2", Choices: []string{"Да"}}
+
+// Тест проверяет рост паузы, потолок и границы джиттера
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{Attempts: 10, BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	none := func() float64 { return 0 }
+	full := func() float64 { return 1 }
+
+	assert.Equal(t, 5*time.Millisecond, p.backoff(1, none))
+	assert.Equal(t, 10*time.Millisecond, p.backoff(1, full))
+	assert.Equal(t, 10*time.Millisecond, p.backoff(2, none))
+	assert.Equal(t, 20*time.Millisecond, p.backoff(3, none))
+	assert.Equal(t, 25*time.Millisecond, p.backoff(8, none), "пауза ограничена MaxDelay")
+	assert.Equal(t, 50*time.Millisecond, p.backoff(8, full))
+}
+
+// Тест проверяет подстановку значений по умолчанию
+func TestRetryPolicy_Normalized(t *testing.T) {
+	p := RetryPolicy{}.normalized()
+	assert.Equal(t, 1, p.Attempts)
+	assert.Equal(t, DefaultRetryPolicy().BaseDelay, p.BaseDelay)
+	assert.GreaterOrEqual(t, p.MaxDelay, p.BaseDelay)
+}
+
+// Тест проверяет, что голос записывается после нескольких конфликтов
+func TestAddVote_RetriesConflicts(t *testing.T) {
+	conn := newFakeConn()
+	sleeper := &fakeSleeper{}
+	repo := newRetryRepo(t, conn, RetryPolicy{Attempts: 5, BaseDelay: 20 * time.Millisecond, MaxDelay: time.Second}, sleeper)
+	conn.callErrs = conflicts(3)
+
+	_, err := repo.AddVote(context.Background(), retryVote)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, conn.calls["Call"])
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}, sleeper.delays)
+
+	_, err = repo.GetVote(context.Background(), "poll1", "user2")
+	assert.NoError(t, err)
+}
+
+// Тест проверяет исчерпание попыток и счётчик метрики
+func TestAddVote_RetriesExhausted(t *testing.T) {
+	conn := newFakeConn()
+	sleeper := &fakeSleeper{}
+	repo := newRetryRepo(t, conn, RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond}, sleeper)
+	conn.callErrs = conflicts(10)
+	before := metrics.VoteRetriesExhausted.Value()
+
+	_, err := repo.AddVote(context.Background(), retryVote)
+
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 3, conn.calls["Call"])
+	assert.Len(t, sleeper.delays, 2, "после последней попытки пауза не нужна")
+	assert.Equal(t, before+1, metrics.VoteRetriesExhausted.Value())
+}
+
+// Тест проверяет, что отмена контекста прерывает ожидание повтора
+func TestAddVote_ContextCanceledDuringWait(t *testing.T) {
+	conn := newFakeConn()
+	sleeper := &fakeSleeper{err: context.Canceled}
+	repo := newRetryRepo(t, conn, DefaultRetryPolicy(), sleeper)
+	conn.callErrs = conflicts(10)
+
+	_, err := repo.AddVote(context.Background(), retryVote)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, conn.calls["Call"])
+}
+
+// Тест проверяет, что ошибки, отличные от конфликта, не повторяются
+func TestAddVote_NonConflictNotRetried(t *testing.T) {
+	conn := newFakeConn()
+	sleeper := &fakeSleeper{}
+	repo := newRetryRepo(t, conn, DefaultRetryPolicy(), sleeper)
+	conn.callErrs = []error{tarantool.Error{Code: tarantool.ErrIllegalParams, Msg: "bad"}}
+
+	_, err := repo.AddVote(context.Background(), retryVote)
+
+	assert.ErrorContains(t, err, "ошибка сохранения голоса")
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 1, conn.calls["Call"])
+	assert.Empty(t, sleeper.delays)
+}
+
+// Тест проверяет, что реальное ожидание завершается по отмене контекста
+func TestSleepContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := sleepContext(ctx, time.Hour)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Less(t, time.Since(start), time.Second)
+
+	assert.NoError(t, sleepContext(context.Background(), time.Millisecond))
+}
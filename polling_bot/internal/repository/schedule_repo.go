@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// ScheduleRepository хранит расписания повторяющихся опросов.
+type ScheduleRepository interface {
+	SaveSchedule(ctx context.Context, schedule models.Schedule) error
+	GetSchedule(ctx context.Context, id string) (models.Schedule, error)
+	DeleteSchedule(ctx context.Context, id string) error
+	// ListSchedules возвращает расписания канала в порядке ID,
+	// а при пустом channelID - все расписания.
+	ListSchedules(ctx context.Context, channelID string) ([]models.Schedule, error)
+}
+
+type TarantoolScheduleRepo struct {
+	conn      Connector
+	spaceName string
+}
+
+func NewTarantoolScheduleRepo(conn Connector, spaceName string) *TarantoolScheduleRepo {
+	return &TarantoolScheduleRepo{conn: conn, spaceName: spaceName}
+}
+
+func (r *TarantoolScheduleRepo) SaveSchedule(ctx context.Context, schedule models.Schedule) error {
+	if err := connReady(ctx, r.conn); err != nil {
+		return err
+	}
+
+	if _, err := r.conn.Replace(ctx, r.spaceName, newScheduleTuple(schedule)); err != nil {
+		return fmt.Errorf("ошибка сохранения расписания: %w", classifyError(err))
+	}
+	return nil
+}
+
+func (r *TarantoolScheduleRepo) GetSchedule(ctx context.Context, id string) (models.Schedule, error) {
+	if err := connReady(ctx, r.conn); err != nil {
+		return models.Schedule{}, err
+	}
+
+	var tuples []scheduleTuple
+	err := r.conn.SelectTyped(ctx, r.spaceName, "primary", 0, 1, tarantool.IterEq, []interface{}{id}, &tuples)
+	if err != nil {
+		return models.Schedule{}, fmt.Errorf("ошибка получения расписания: %w", classifyError(err))
+	}
+	if len(tuples) == 0 {
+		return models.Schedule{}, ErrNotFound
+	}
+	return tuples[0].toModel(), nil
+}
+
+func (r *TarantoolScheduleRepo) DeleteSchedule(ctx context.Context, id string) error {
+	if err := connReady(ctx, r.conn); err != nil {
+		return err
+	}
+
+	resp, err := r.conn.Delete(ctx, r.spaceName, "primary", []interface{}{id})
+	if err != nil {
+		return fmt.Errorf("ошибка удаления расписания: %w", classifyError(err))
+	}
+	if len(resp.Data) == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListSchedules читает индекс пачками, продолжая по паре ключ+id, как ListPolls.
+// Расписаний на порядки меньше, чем опросов, поэтому постраничной выдачи нет.
+func (r *TarantoolScheduleRepo) ListSchedules(ctx context.Context, channelID string) ([]models.Schedule, error) {
+	if err := connReady(ctx, r.conn); err != nil {
+		return nil, err
+	}
+
+	index, prefix := "primary", []interface{}{}
+	if channelID != "" {
+		index, prefix = "channel", []interface{}{channelID}
+	}
+
+	var out []models.Schedule
+	lastID := ""
+	for {
+		key, iter := prefix, uint32(tarantool.IterEq)
+		if lastID != "" {
+			key = append(append([]interface{}{}, prefix...), lastID)
+			iter = tarantool.IterGt
+		}
+
+		var tuples []scheduleTuple
+		err := r.conn.SelectTyped(ctx, r.spaceName, index, 0, listScanBatch, iter, key, &tuples)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения списка расписаний: %w", classifyError(err))
+		}
+		for _, t := range tuples {
+			if channelID != "" && t.ChannelID != channelID {
+				return out, nil
+			}
+			out = append(out, t.toModel())
+			lastID = t.ID
+		}
+		if len(tuples) < listScanBatch {
+			return out, nil
+		}
+	}
+}
+
+// scheduleTuple описывает раскладку расписания в space Tarantool.
+// Порядок полей должен точно соответствовать формату space в init.lua.
+type scheduleTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	ID                string    // field 1: id (string)
+	Creator           string    // field 2: creator (string)
+	ChannelID         string    // field 3: channel_id (string)
+	Cron              string    // field 4: cron (string)
+	Question          string    // field 5: question (string)
+	Options           []string  // field 6: options (array)
+	RestrictToChannel looseBool // field 7: channel_only (boolean)
+	Quorum            int64     // field 8: quorum (unsigned)
+	Ranked            looseBool // field 9: ranked (boolean)
+	Exclusive         looseBool // field 10: exclusive (boolean)
+	CreatedAt         int64     // field 11: created_at (unsigned, unix-время)
+	LastRunAt         int64     // field 12: last_run_at (unsigned, unix-время, 0 - не запускалось)
+	UnreachableAt     int64     // field 13: unreachable_at (unsigned, nullable, unix-время, 0 - канал доступен)
+}
+
+func newScheduleTuple(schedule models.Schedule) scheduleTuple {
+	t := scheduleTuple{
+		ID:                schedule.ID,
+		Creator:           schedule.Creator,
+		ChannelID:         schedule.ChannelID,
+		Cron:              schedule.Cron,
+		Question:          schedule.Question,
+		Options:           schedule.Options,
+		RestrictToChannel: looseBool(schedule.RestrictToChannel),
+		Quorum:            int64(schedule.Quorum),
+		Ranked:            looseBool(schedule.Ranked),
+		Exclusive:         looseBool(schedule.Exclusive),
+	}
+	if !schedule.CreatedAt.IsZero() {
+		t.CreatedAt = schedule.CreatedAt.Unix()
+	}
+	if !schedule.LastRunAt.IsZero() {
+		t.LastRunAt = schedule.LastRunAt.Unix()
+	}
+	if !schedule.UnreachableAt.IsZero() {
+		t.UnreachableAt = schedule.UnreachableAt.Unix()
+	}
+	// Формат space требует array, nil ушёл бы как msgpack nil
+	if t.Options == nil {
+		t.Options = []string{}
+	}
+	return t
+}
+
+func (t scheduleTuple) toModel() models.Schedule {
+	schedule := models.Schedule{
+		ID:                t.ID,
+		Creator:           t.Creator,
+		ChannelID:         t.ChannelID,
+		Cron:              t.Cron,
+		Question:          t.Question,
+		Options:           t.Options,
+		RestrictToChannel: bool(t.RestrictToChannel),
+		Quorum:            int(t.Quorum),
+		Ranked:            bool(t.Ranked),
+		Exclusive:         bool(t.Exclusive),
+	}
+	if t.CreatedAt > 0 {
+		schedule.CreatedAt = time.Unix(t.CreatedAt, 0).UTC()
+	}
+	if t.LastRunAt > 0 {
+		schedule.LastRunAt = time.Unix(t.LastRunAt, 0).UTC()
+	}
+	if t.UnreachableAt > 0 {
+		schedule.UnreachableAt = time.Unix(t.UnreachableAt, 0).UTC()
+	}
+	return schedule
+}
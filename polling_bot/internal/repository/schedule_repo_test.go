@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// fakeScheduleConn - упрощённый fakeConn для space расписаний.
+type fakeScheduleConn struct {
+	mu        sync.Mutex
+	connected bool
+	tuples    map[string]scheduleTuple
+}
+
+func newFakeScheduleConn() *fakeScheduleConn {
+	return &fakeScheduleConn{connected: true, tuples: make(map[string]scheduleTuple)}
+}
+
+func (f *fakeScheduleConn) ConnectedNow() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected
+}
+
+func (f *fakeScheduleConn) Replace(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := msgpack.Marshal(tuple)
+	if err != nil {
+		return nil, err
+	}
+	var t scheduleTuple
+	if err := msgpack.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	f.tuples[t.ID] = t
+	return &tarantool.Response{Data: []interface{}{t}}, nil
+}
+
+func (f *fakeScheduleConn) Insert(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeScheduleConn: Insert не используется")
+}
+
+func (f *fakeScheduleConn) Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeScheduleConn: Update не используется")
+}
+
+func (f *fakeScheduleConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeScheduleConn: Call17 не используется")
+}
+
+func (f *fakeScheduleConn) Delete(ctx context.Context, space, index interface{}, key interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := key.([]interface{})[0].(string)
+	t, ok := f.tuples[id]
+	if !ok {
+		return &tarantool.Response{}, nil
+	}
+	delete(f.tuples, id)
+	return &tarantool.Response{Data: []interface{}{t}}, nil
+}
+
+func (f *fakeScheduleConn) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	indexKey := func(t scheduleTuple) string {
+		if index == "channel" {
+			return t.ChannelID + "\x00" + t.ID
+		}
+		return t.ID
+	}
+	parts := make([]string, 0, 2)
+	for _, k := range key.([]interface{}) {
+		parts = append(parts, k.(string))
+	}
+	want := strings.Join(parts, "\x00")
+
+	all := make([]scheduleTuple, 0, len(f.tuples))
+	for _, t := range f.tuples {
+		all = append(all, t)
+	}
+	sort.Slice(all, func(i, j int) bool { return indexKey(all[i]) < indexKey(all[j]) })
+
+	out := result.(*[]scheduleTuple)
+	for _, t := range all {
+		k := indexKey(t)
+		switch iterator {
+		case tarantool.IterEq:
+			if k != want && !strings.HasPrefix(k, want+"\x00") && want != "" {
+				continue
+			}
+		case tarantool.IterGt:
+			if k <= want {
+				continue
+			}
+		default:
+			return fmt.Errorf("fakeScheduleConn: итератор %d не поддерживается", iterator)
+		}
+		if uint32(len(*out)) == limit {
+			break
+		}
+		*out = append(*out, t)
+	}
+	return nil
+}
+
+// extraScheduleRepos - аналог extraRepos для расписаний.
+var extraScheduleRepos = map[string]func(t *testing.T) ScheduleRepository{}
+
+func scheduleRepos() map[string]func(t *testing.T) ScheduleRepository {
+	repos := map[string]func(t *testing.T) ScheduleRepository{
+		"memory": func(*testing.T) ScheduleRepository { return NewMemoryScheduleRepo() },
+		"tarantool": func(*testing.T) ScheduleRepository {
+			return NewTarantoolScheduleRepo(newFakeScheduleConn(), "poll_schedules")
+		},
+	}
+	for name, newRepo := range extraScheduleRepos {
+		repos[name] = newRepo
+	}
+	return repos
+}
+
+func testSchedule(id, channelID string) models.Schedule {
+	return models.Schedule{
+		ID:        id,
+		Creator:   "user1",
+		ChannelID: channelID,
+		Cron:      "0 10 * * 1",
+		Question:  "Где обедаем?",
+		Options:   []string{"Пицца", "Суши"},
+		Quorum:    3,
+		Ranked:    true,
+		CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// Тест проверяет полный цикл работы с расписанием через репозиторий
+func TestScheduleRepo_Lifecycle(t *testing.T) {
+	for name, newRepo := range scheduleRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			schedule := testSchedule("s1", "c1")
+			require.NoError(t, repo.SaveSchedule(ctx, schedule))
+			got, err := repo.GetSchedule(ctx, "s1")
+			require.NoError(t, err)
+			assert.Equal(t, schedule, got)
+
+			schedule.LastRunAt = time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+			schedule.UnreachableAt = time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)
+			require.NoError(t, repo.SaveSchedule(ctx, schedule))
+			got, err = repo.GetSchedule(ctx, "s1")
+			require.NoError(t, err)
+			assert.Equal(t, schedule.LastRunAt, got.LastRunAt)
+			assert.Equal(t, schedule.UnreachableAt, got.UnreachableAt)
+
+			require.NoError(t, repo.DeleteSchedule(ctx, "s1"))
+			_, err = repo.GetSchedule(ctx, "s1")
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.ErrorIs(t, repo.DeleteSchedule(ctx, "s1"), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет выборку расписаний по каналу и целиком
+func TestScheduleRepo_List(t *testing.T) {
+	for name, newRepo := range scheduleRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			// Больше одной пачки выборки из Tarantool
+			var all, inC1 []string
+			for i := 0; i < listScanBatch+20; i++ {
+				id, channel := fmt.Sprintf("s%03d", i), "c2"
+				if i%3 == 0 {
+					channel = "c1"
+					inC1 = append(inC1, id)
+				}
+				all = append(all, id)
+				require.NoError(t, repo.SaveSchedule(ctx, testSchedule(id, channel)))
+			}
+
+			ids := func(schedules []models.Schedule) []string {
+				out := make([]string, 0, len(schedules))
+				for _, s := range schedules {
+					out = append(out, s.ID)
+				}
+				return out
+			}
+
+			got, err := repo.ListSchedules(ctx, "")
+			require.NoError(t, err)
+			assert.Equal(t, all, ids(got))
+
+			got, err = repo.ListSchedules(ctx, "c1")
+			require.NoError(t, err)
+			assert.Equal(t, inC1, ids(got))
+
+			got, err = repo.ListSchedules(ctx, "c3")
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
+
+// Тест проверяет, что при потере соединения с Tarantool возвращается ErrUnavailable
+func TestTarantoolScheduleRepo_Unavailable(t *testing.T) {
+	conn := newFakeScheduleConn()
+	conn.connected = false
+	repo := NewTarantoolScheduleRepo(conn, "poll_schedules")
+
+	_, err := repo.ListSchedules(context.Background(), "")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, repo.SaveSchedule(context.Background(), testSchedule("s1", "c1")), ErrUnavailable)
+}
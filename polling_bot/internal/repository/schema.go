@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Системные представления схемы Tarantool, доступные пользователю бота
+// без прав на сами системные spaces.
+const (
+	vspaceID        = 281 // _vspace
+	vspaceNameIndex = 2   // индекс name в _vspace
+	vindexID        = 289 // _vindex
+	// Больше индексов у space Tarantool быть не может
+	maxSpaceIndexes = 128
+)
+
+// SchemaVerifier - репозиторий, который проверяет перед запуском, что в
+// хранилище есть его spaces и индексы.
+type SchemaVerifier interface {
+	VerifySchema(ctx context.Context) error
+}
+
+// SchemaError перечисляет, чего не хватает в схеме хранилища.
+type SchemaError struct {
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %s; создайте недостающее скриптом database/tarantool/init.lua с теми же именами spaces (TARANTOOL_*)",
+		ErrSchema, strings.Join(e.Problems, "; "))
+}
+
+func (e *SchemaError) Unwrap() error {
+	return ErrSchema
+}
+
+// VerifySchema проверяет схемы всех репозиториев и собирает недостающее
+// в одну SchemaError, чтобы исправить всё за раз.
+func VerifySchema(ctx context.Context, verifiers ...SchemaVerifier) error {
+	var problems []string
+	for _, v := range verifiers {
+		err := v.VerifySchema(ctx)
+		var schemaErr *SchemaError
+		if errors.As(err, &schemaErr) {
+			problems = append(problems, schemaErr.Problems...)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(problems) > 0 {
+		return &SchemaError{Problems: problems}
+	}
+	return nil
+}
+
+// spaceSchema - space, нужный боту, и его индексы с полями частей по порядку.
+// fields - поля формата по порядку, если бот проверяет формат целиком.
+type spaceSchema struct {
+	name    string
+	fields  []string
+	indexes []indexSchema
+}
+
+type indexSchema struct {
+	name  string
+	parts []string
+}
+
+// pollFields - формат space опросов в init.lua, поле кортежа pollTuple
+// на каждое поле формата.
+var pollFields = []string{
+	"id", "creator", "question", "voters", "options", "is_closed",
+	"channel_id", "created_at", "channel_only", "quorum", "ranked", "option_order",
+	"ballots", "winner", "pinned_post_id", "notify_voters", "expires_at", "expiry_warned",
+	"description", "tags", "weights", "weighted_options", "allow_abstain", "abstained",
+	"max_votes", "vote_receipts", "option_emoji", "no_self_vote", "voter_salt", "members_only",
+	"views", "schema_version", "featured", "allowed_voters",
+}
+
+// Схемы spaces повторяют init.lua: индексы, по которым бот выбирает записи.
+// Формат space опросов проверяется целиком: кортеж текущей версии не
+// записать в space, формат которого короче.
+func pollSchema(name string) spaceSchema {
+	return spaceSchema{name: name, fields: pollFields, indexes: []indexSchema{
+		{name: "primary", parts: []string{"id"}},
+		{name: "creator", parts: []string{"creator"}},
+		{name: "channel", parts: []string{"channel_id"}},
+	}}
+}
+
+func voteSchema(name string) spaceSchema {
+	return spaceSchema{name: name, indexes: []indexSchema{
+		{name: "primary", parts: []string{"poll_id", "user_id"}},
+	}}
+}
+
+func scheduleSchema(name string) spaceSchema {
+	return spaceSchema{name: name, indexes: []indexSchema{
+		{name: "primary", parts: []string{"id"}},
+		{name: "channel", parts: []string{"channel_id"}},
+	}}
+}
+
+func templateSchema(name string) spaceSchema {
+	return spaceSchema{name: name, indexes: []indexSchema{
+		{name: "primary", parts: []string{"scope", "name"}},
+	}}
+}
+
+func auditSchema(name string) spaceSchema {
+	return spaceSchema{name: name, indexes: []indexSchema{
+		{name: "primary", parts: []string{"id"}},
+		{name: "poll", parts: []string{"poll_id", "at"}},
+	}}
+}
+
+func channelSettingsSchema(name string) spaceSchema {
+	return spaceSchema{name: name, indexes: []indexSchema{
+		{name: "primary", parts: []string{"channel_id"}},
+	}}
+}
+
+func usageSchema(name string) spaceSchema {
+	return spaceSchema{name: name, indexes: []indexSchema{
+		{name: "primary", parts: []string{"day", "command"}},
+	}}
+}
+
+func (r *TarantoolPollRepo) VerifySchema(ctx context.Context) error {
+	return verifySchema(ctx, r.conn, pollSchema(r.spaceName))
+}
+
+func (r *TarantoolVoteRepo) VerifySchema(ctx context.Context) error {
+	return verifySchema(ctx, r.conn, voteSchema(r.voteSpace))
+}
+
+func (r *TarantoolScheduleRepo) VerifySchema(ctx context.Context) error {
+	return verifySchema(ctx, r.conn, scheduleSchema(r.spaceName))
+}
+
+func (r *TarantoolTemplateRepo) VerifySchema(ctx context.Context) error {
+	return verifySchema(ctx, r.conn, templateSchema(r.spaceName))
+}
+
+func (r *TarantoolAuditRepo) VerifySchema(ctx context.Context) error {
+	return verifySchema(ctx, r.conn, auditSchema(r.spaceName))
+}
+
+func (r *TarantoolChannelSettingsRepo) VerifySchema(ctx context.Context) error {
+	return verifySchema(ctx, r.conn, channelSettingsSchema(r.spaceName))
+}
+
+func (r *TarantoolUsageRepo) VerifySchema(ctx context.Context) error {
+	return verifySchema(ctx, r.conn, usageSchema(r.spaceName))
+}
+
+// verifySchema читает описание space и его индексов из _vspace и _vindex
+// и сравнивает с ожидаемым. Расхождения возвращаются SchemaError, ошибки
+// запросов - как есть.
+func verifySchema(ctx context.Context, conn Connector, want spaceSchema) error {
+	if err := connReady(ctx, conn); err != nil {
+		return err
+	}
+
+	var spaces []vspaceTuple
+	err := conn.SelectTyped(ctx, vspaceID, vspaceNameIndex, 0, 1, tarantool.IterEq, []interface{}{want.name}, &spaces)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения схемы space %s: %w", want.name, classifyError(err))
+	}
+	if len(spaces) == 0 {
+		return &SchemaError{Problems: []string{fmt.Sprintf("нет space %s", want.name)}}
+	}
+	space := spaces[0]
+
+	var indexes []vindexTuple
+	err = conn.SelectTyped(ctx, vindexID, 0, 0, maxSpaceIndexes, tarantool.IterEq, []interface{}{space.ID}, &indexes)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения индексов space %s: %w", want.name, classifyError(err))
+	}
+	byName := make(map[string]vindexTuple, len(indexes))
+	for _, index := range indexes {
+		byName[index.Name] = index
+	}
+
+	problems := verifyFormat(space, want)
+	for _, wantIndex := range want.indexes {
+		index, ok := byName[wantIndex.name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("в space %s нет индекса %s (%s)",
+				want.name, wantIndex.name, strings.Join(wantIndex.parts, ", ")))
+			continue
+		}
+		parts := make([]string, len(index.Parts))
+		for i, field := range index.Parts {
+			parts[i] = space.fieldName(field)
+		}
+		if strings.Join(parts, ",") != strings.Join(wantIndex.parts, ",") {
+			problems = append(problems, fmt.Sprintf("индекс %s.%s построен по (%s), ожидается (%s)",
+				want.name, wantIndex.name, strings.Join(parts, ", "), strings.Join(wantIndex.parts, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return &SchemaError{Problems: problems}
+	}
+	return nil
+}
+
+// verifyFormat сверяет поля формата space с want.fields: их число и имена
+// по порядку. Лишние поля в конце формата не мешают.
+func verifyFormat(space vspaceTuple, want spaceSchema) []string {
+	if len(want.fields) == 0 {
+		return nil
+	}
+	if len(space.Format) < len(want.fields) {
+		return []string{fmt.Sprintf("в формате space %s %d полей, ожидается %d: нет %s",
+			want.name, len(space.Format), len(want.fields), strings.Join(want.fields[len(space.Format):], ", "))}
+	}
+	var problems []string
+	for i, field := range want.fields {
+		if name := space.fieldName(i); name != field {
+			problems = append(problems, fmt.Sprintf("поле %d space %s называется %s, ожидается %s", i+1, want.name, name, field))
+		}
+	}
+	return problems
+}
+
+// vspaceTuple - запись _vspace: id, владелец, имя, движок, число полей,
+// флаги и формат.
+type vspaceTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	ID         uint32
+	Owner      uint32
+	Name       string
+	Engine     string
+	FieldCount uint32
+	Flags      map[string]interface{}
+	Format     []map[string]interface{}
+}
+
+// fieldName - имя поля по номеру с нуля; поле без формата - по номеру с
+// единицы, как в сообщениях Tarantool.
+func (s vspaceTuple) fieldName(field int) string {
+	if field >= 0 && field < len(s.Format) {
+		if name, ok := s.Format[field]["name"].(string); ok {
+			return name
+		}
+	}
+	return fmt.Sprintf("#%d", field+1)
+}
+
+// vindexTuple - запись _vindex: space, номер и имя индекса, тип,
+// параметры и части.
+type vindexTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	SpaceID uint32
+	IID     uint32
+	Name    string
+	Type    string
+	Opts    map[string]interface{}
+	Parts   indexParts
+}
+
+// indexParts - номера полей частей индекса с нуля. Старые версии Tarantool
+// хранят часть массивом [номер, тип], новые - map с ключом field.
+type indexParts []int
+
+func (p *indexParts) DecodeMsgpack(d *msgpack.Decoder) error {
+	val, err := d.DecodeInterface()
+	if err != nil {
+		return fmt.Errorf("части индекса: %w", err)
+	}
+	raw, _ := val.([]interface{})
+	parts := make(indexParts, 0, len(raw))
+	for _, part := range raw {
+		switch part := part.(type) {
+		case []interface{}:
+			if len(part) == 0 {
+				return errors.New("части индекса: пустая часть")
+			}
+			parts = append(parts, toInt(part[0]))
+		case map[interface{}]interface{}:
+			parts = append(parts, toInt(part["field"]))
+		case map[string]interface{}:
+			parts = append(parts, toInt(part["field"]))
+		default:
+			return fmt.Errorf("части индекса: неожиданный тип %T", part)
+		}
+	}
+	*p = parts
+	return nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// schemaConn отдаёт записи _vspace и _vindex в том виде, в каком их
+// присылает Tarantool.
+type schemaConn struct {
+	Connector
+	spaces  map[string][]interface{}
+	indexes map[uint32][][]interface{}
+	err     error
+}
+
+func (c *schemaConn) ConnectedNow() bool { return true }
+
+func (c *schemaConn) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	var tuples []interface{}
+	switch space {
+	case vspaceID:
+		if tuple, ok := c.spaces[key.([]interface{})[0].(string)]; ok {
+			tuples = append(tuples, tuple)
+		}
+	case vindexID:
+		for _, tuple := range c.indexes[key.([]interface{})[0].(uint32)] {
+			tuples = append(tuples, tuple)
+		}
+	}
+	data, err := msgpack.Marshal(tuples)
+	if err != nil {
+		return err
+	}
+	return msgpack.Unmarshal(data, result)
+}
+
+// schemaSpace - запись _vspace с форматом из имён полей.
+func schemaSpace(id uint64, name string, fields ...string) []interface{} {
+	format := make([]interface{}, len(fields))
+	for i, field := range fields {
+		format[i] = map[string]interface{}{"name": field, "type": "string"}
+	}
+	return []interface{}{id, 1, name, "memtx", 0, map[string]interface{}{}, format}
+}
+
+// schemaIndex - запись _vindex с частями в новом формате.
+func schemaIndex(space, iid uint64, name string, fields ...int) []interface{} {
+	parts := make([]interface{}, len(fields))
+	for i, field := range fields {
+		parts[i] = map[string]interface{}{"field": field, "type": "string"}
+	}
+	return []interface{}{space, iid, name, "tree", map[string]interface{}{"unique": iid == 0}, parts}
+}
+
+// Тест проверяет сверку space опросов с ожидаемой схемой: всё на месте,
+// нет space, формат короче или с другим полем, нет вторичного индекса,
+// индекс по другим полям
+func TestTarantoolPollRepo_VerifySchema(t *testing.T) {
+	fields := pollFields
+	renamed := append([]string(nil), pollFields...)
+	renamed[30] = "view_count"
+	complete := map[uint32][][]interface{}{512: {
+		schemaIndex(512, 0, "primary", 0),
+		schemaIndex(512, 1, "creator", 1),
+		schemaIndex(512, 2, "channel", 6),
+	}}
+	tests := []struct {
+		name    string
+		spaces  map[string][]interface{}
+		indexes map[uint32][][]interface{}
+		want    []string
+	}{
+		{
+			name:    "complete",
+			spaces:  map[string][]interface{}{"polls": schemaSpace(512, "polls", fields...)},
+			indexes: complete,
+		},
+		{
+			name:   "missing space",
+			spaces: map[string][]interface{}{"poll": schemaSpace(512, "poll", fields...)},
+			want:   []string{"нет space polls"},
+		},
+		{
+			name:    "short format",
+			spaces:  map[string][]interface{}{"polls": schemaSpace(512, "polls", fields[:30]...)},
+			indexes: complete,
+			want:    []string{"в формате space polls 30 полей, ожидается 34: нет views, schema_version, featured, allowed_voters"},
+		},
+		{
+			name:    "renamed field",
+			spaces:  map[string][]interface{}{"polls": schemaSpace(512, "polls", append(renamed, "extra")...)},
+			indexes: complete,
+			want:    []string{"поле 31 space polls называется view_count, ожидается views"},
+		},
+		{
+			name:   "missing secondary index",
+			spaces: map[string][]interface{}{"polls": schemaSpace(512, "polls", fields...)},
+			indexes: map[uint32][][]interface{}{512: {
+				schemaIndex(512, 0, "primary", 0),
+				schemaIndex(512, 1, "creator", 1),
+			}},
+			want: []string{"в space polls нет индекса channel (channel_id)"},
+		},
+		{
+			name:   "wrong index parts",
+			spaces: map[string][]interface{}{"polls": schemaSpace(512, "polls", fields...)},
+			indexes: map[uint32][][]interface{}{512: {
+				schemaIndex(512, 0, "primary", 0),
+				schemaIndex(512, 1, "creator", 1, 0),
+				// Старый формат частей: [номер поля, тип], поле без имени
+				{uint64(512), uint64(2), "channel", "tree", map[string]interface{}{}, []interface{}{[]interface{}{39, "string"}}},
+			}},
+			want: []string{
+				"индекс polls.creator построен по (creator, id), ожидается (creator)",
+				"индекс polls.channel построен по (#40), ожидается (channel_id)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewTarantoolPollRepo(&schemaConn{spaces: tt.spaces, indexes: tt.indexes}, "polls", DefaultRetryPolicy(), zerolog.Nop())
+			err := repo.VerifySchema(context.Background())
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var schemaErr *SchemaError
+			require.ErrorAs(t, err, &schemaErr)
+			assert.Equal(t, tt.want, schemaErr.Problems)
+			assert.ErrorIs(t, err, ErrSchema)
+			assert.Contains(t, err.Error(), "init.lua")
+		})
+	}
+}
+
+// Тест проверяет, что VerifySchema собирает расхождения всех репозиториев
+// в одну ошибку, а ошибку чтения схемы возвращает как есть
+func TestVerifySchema(t *testing.T) {
+	conn := &schemaConn{
+		spaces: map[string][]interface{}{
+			"polls":      schemaSpace(512, "polls", pollFields...),
+			"poll_votes": schemaSpace(513, "poll_votes", "poll_id", "user_id"),
+		},
+		indexes: map[uint32][][]interface{}{
+			512: {schemaIndex(512, 0, "primary", 0), schemaIndex(512, 1, "creator", 1), schemaIndex(512, 2, "channel", 6)},
+			513: {schemaIndex(513, 0, "primary", 0, 1)},
+		},
+	}
+	err := VerifySchema(context.Background(),
+		NewTarantoolPollRepo(conn, "polls", DefaultRetryPolicy(), zerolog.Nop()),
+		NewTarantoolVoteRepo(conn, "polls", "poll_votes", DefaultRetryPolicy(), zerolog.Nop()),
+		NewTarantoolScheduleRepo(conn, "poll_schedules"),
+		NewTarantoolAuditRepo(conn, "poll_audit"),
+	)
+	var schemaErr *SchemaError
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Equal(t, []string{"нет space poll_schedules", "нет space poll_audit"}, schemaErr.Problems)
+
+	readErr := errors.New("нет прав на _vspace")
+	err = VerifySchema(context.Background(), NewTarantoolTemplateRepo(&schemaConn{err: readErr}, "poll_templates"))
+	assert.ErrorIs(t, err, readErr)
+	assert.NotErrorIs(t, err, ErrSchema)
+}
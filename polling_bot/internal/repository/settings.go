@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SettingsUpdate - настройки опроса, изменённые командой set, и вопрос,
+// исправленный командой edit; nil - поле не меняется. Записываются только заданные поля, голоса, записанные
+// после чтения опроса, не затираются.
+type SettingsUpdate struct {
+	RestrictToChannel *bool
+	Quorum            *int
+	MaxVotes          *int
+	// Новый срок, нулевое время - без срока. Смена срока снимает отметку
+	// о предупреждении: о новом сроке канал предупреждается заново
+	ExpiresAt *time.Time
+	Question  *string
+}
+
+// Номера полей настроек и вопроса для update-операций, см. pollTuple
+const (
+	fieldQuestion    = 2
+	fieldChannelOnly = 8
+	fieldQuorum      = 9
+	fieldExpiresAt   = 16
+	fieldMaxVotes    = 24
+)
+
+// UpdateSettings, как и SetExpiryWarned, меняет поля одним update и не
+// затирает голоса, записанные после чтения опроса.
+func (r *TarantoolPollRepo) UpdateSettings(ctx context.Context, pollID string, update SettingsUpdate) error {
+	return r.withFailover(ctx, func() error {
+		return r.updateSettings(ctx, pollID, update)
+	})
+}
+
+func (r *TarantoolPollRepo) updateSettings(ctx context.Context, pollID string, update SettingsUpdate) error {
+	if err := r.ready(ctx); err != nil {
+		return err
+	}
+
+	var ops []interface{}
+	if update.Question != nil {
+		ops = append(ops, []interface{}{"=", fieldQuestion, *update.Question})
+	}
+	if update.RestrictToChannel != nil {
+		ops = append(ops, []interface{}{"=", fieldChannelOnly, *update.RestrictToChannel})
+	}
+	if update.Quorum != nil {
+		ops = append(ops, []interface{}{"=", fieldQuorum, *update.Quorum})
+	}
+	if update.MaxVotes != nil {
+		ops = append(ops, []interface{}{"=", fieldMaxVotes, *update.MaxVotes})
+	}
+	if update.ExpiresAt != nil {
+		var expiresAt int64
+		if !update.ExpiresAt.IsZero() {
+			expiresAt = update.ExpiresAt.Unix()
+		}
+		ops = append(ops, []interface{}{"=", fieldExpiresAt, expiresAt}, []interface{}{"=", fieldWarned, false})
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	resp, err := r.conn.Update(ctx, r.spaceName, "primary", []interface{}{pollID}, ops)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения настроек опроса: %w", classifyError(err))
+	}
+	if len(resp.Data) == 0 {
+		return ErrNotFound
+	}
+	r.upgradeLegacy(ctx, pollID, resp)
+	return nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// TemplateRepository хранит шаблоны опросов по ключу (область, имя).
+type TemplateRepository interface {
+	// CreateTemplate сохраняет новый шаблон; если в области уже есть
+	// шаблон с таким именем, возвращает ErrDuplicate.
+	CreateTemplate(ctx context.Context, template models.Template) error
+	GetTemplate(ctx context.Context, scope, name string) (models.Template, error)
+	DeleteTemplate(ctx context.Context, scope, name string) error
+	// ListTemplates возвращает шаблоны области в порядке имён.
+	ListTemplates(ctx context.Context, scope string) ([]models.Template, error)
+}
+
+type TarantoolTemplateRepo struct {
+	conn      Connector
+	spaceName string
+}
+
+func NewTarantoolTemplateRepo(conn Connector, spaceName string) *TarantoolTemplateRepo {
+	return &TarantoolTemplateRepo{conn: conn, spaceName: spaceName}
+}
+
+// CreateTemplate использует insert: уникальность имени в области
+// проверяет первичный индекс (scope, name).
+func (r *TarantoolTemplateRepo) CreateTemplate(ctx context.Context, template models.Template) error {
+	if err := connReady(ctx, r.conn); err != nil {
+		return err
+	}
+
+	if _, err := r.conn.Insert(ctx, r.spaceName, newTemplateTuple(template)); err != nil {
+		return fmt.Errorf("ошибка сохранения шаблона: %w", classifyError(err))
+	}
+	return nil
+}
+
+func (r *TarantoolTemplateRepo) GetTemplate(ctx context.Context, scope, name string) (models.Template, error) {
+	if err := connReady(ctx, r.conn); err != nil {
+		return models.Template{}, err
+	}
+
+	var tuples []templateTuple
+	err := r.conn.SelectTyped(ctx, r.spaceName, "primary", 0, 1, tarantool.IterEq, []interface{}{scope, name}, &tuples)
+	if err != nil {
+		return models.Template{}, fmt.Errorf("ошибка получения шаблона: %w", classifyError(err))
+	}
+	if len(tuples) == 0 {
+		return models.Template{}, ErrNotFound
+	}
+	return tuples[0].toModel(), nil
+}
+
+func (r *TarantoolTemplateRepo) DeleteTemplate(ctx context.Context, scope, name string) error {
+	if err := connReady(ctx, r.conn); err != nil {
+		return err
+	}
+
+	resp, err := r.conn.Delete(ctx, r.spaceName, "primary", []interface{}{scope, name})
+	if err != nil {
+		return fmt.Errorf("ошибка удаления шаблона: %w", classifyError(err))
+	}
+	if len(resp.Data) == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListTemplates читает первичный индекс пачками, продолжая после
+// последнего прочитанного имени, как ListSchedules.
+func (r *TarantoolTemplateRepo) ListTemplates(ctx context.Context, scope string) ([]models.Template, error) {
+	if err := connReady(ctx, r.conn); err != nil {
+		return nil, err
+	}
+
+	var out []models.Template
+	iterator, key := uint32(tarantool.IterEq), []interface{}{scope}
+	for {
+		var tuples []templateTuple
+		err := r.conn.SelectTyped(ctx, r.spaceName, "primary", 0, listScanBatch, iterator, key, &tuples)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения списка шаблонов: %w", classifyError(err))
+		}
+		for _, t := range tuples {
+			// IterGt по полному ключу выходит за шаблоны области
+			if t.Scope != scope {
+				return out, nil
+			}
+			out = append(out, t.toModel())
+		}
+		if len(tuples) < listScanBatch {
+			return out, nil
+		}
+		iterator, key = tarantool.IterGt, []interface{}{scope, tuples[len(tuples)-1].Name}
+	}
+}
+
+// templateTuple описывает раскладку шаблона в space Tarantool.
+// Порядок полей должен точно соответствовать формату space в init.lua.
+type templateTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	Scope             string    // field 1: scope (string)
+	Name              string    // field 2: name (string)
+	Creator           string    // field 3: creator (string)
+	Question          string    // field 4: question (string)
+	Options           []string  // field 5: options (array)
+	Description       string    // field 6: description (string)
+	Tags              []string  // field 7: tags (array)
+	RestrictToChannel looseBool // field 8: channel_only (boolean)
+	Quorum            int64     // field 9: quorum (unsigned)
+	Ranked            looseBool // field 10: ranked (boolean)
+	Exclusive         looseBool // field 11: exclusive (boolean)
+	Pin               looseBool // field 12: pin (boolean)
+	NotifyVoters      looseBool // field 13: notify_voters (boolean)
+	Expires           int64     // field 14: expires (unsigned, секунды, 0 - срок по умолчанию)
+	NoExpire          looseBool // field 15: no_expire (boolean)
+	CreatedAt         int64     // field 16: created_at (unsigned, unix-время)
+}
+
+func newTemplateTuple(template models.Template) templateTuple {
+	t := templateTuple{
+		Scope:             template.Scope,
+		Name:              template.Name,
+		Creator:           template.Creator,
+		Question:          template.Question,
+		Options:           template.Options,
+		Description:       template.Description,
+		Tags:              template.Tags,
+		RestrictToChannel: looseBool(template.RestrictToChannel),
+		Quorum:            int64(template.Quorum),
+		Ranked:            looseBool(template.Ranked),
+		Exclusive:         looseBool(template.Exclusive),
+		Pin:               looseBool(template.Pin),
+		NotifyVoters:      looseBool(template.NotifyVoters),
+		Expires:           int64(template.Expires / time.Second),
+		NoExpire:          looseBool(template.NoExpire),
+	}
+	if !template.CreatedAt.IsZero() {
+		t.CreatedAt = template.CreatedAt.Unix()
+	}
+	// Формат space требует array, nil ушёл бы как msgpack nil
+	if t.Options == nil {
+		t.Options = []string{}
+	}
+	if t.Tags == nil {
+		t.Tags = []string{}
+	}
+	return t
+}
+
+func (t templateTuple) toModel() models.Template {
+	template := models.Template{
+		Scope:             t.Scope,
+		Name:              t.Name,
+		Creator:           t.Creator,
+		Question:          t.Question,
+		Options:           t.Options,
+		Description:       t.Description,
+		RestrictToChannel: bool(t.RestrictToChannel),
+		Quorum:            int(t.Quorum),
+		Ranked:            bool(t.Ranked),
+		Exclusive:         bool(t.Exclusive),
+		Pin:               bool(t.Pin),
+		NotifyVoters:      bool(t.NotifyVoters),
+		Expires:           time.Duration(t.Expires) * time.Second,
+		NoExpire:          bool(t.NoExpire),
+	}
+	if len(t.Tags) > 0 {
+		template.Tags = t.Tags
+	}
+	if t.CreatedAt > 0 {
+		template.CreatedAt = time.Unix(t.CreatedAt, 0).UTC()
+	}
+	return template
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// fakeTemplateConn - упрощённый fakeConn для space шаблонов с первичным
+// индексом (scope, name).
+type fakeTemplateConn struct {
+	mu        sync.Mutex
+	connected bool
+	tuples    map[string]templateTuple
+}
+
+func newFakeTemplateConn() *fakeTemplateConn {
+	return &fakeTemplateConn{connected: true, tuples: make(map[string]templateTuple)}
+}
+
+func templateTupleKey(t templateTuple) string {
+	return t.Scope + "\x00" + t.Name
+}
+
+func (f *fakeTemplateConn) ConnectedNow() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected
+}
+
+func (f *fakeTemplateConn) Replace(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeTemplateConn: Replace не используется")
+}
+
+// Insert, как Tarantool, отказывает при занятом первичном ключе
+func (f *fakeTemplateConn) Insert(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := msgpack.Marshal(tuple)
+	if err != nil {
+		return nil, err
+	}
+	var t templateTuple
+	if err := msgpack.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	if _, ok := f.tuples[templateTupleKey(t)]; ok {
+		return nil, tarantool.Error{Code: tarantool.ErrTupleFound, Msg: "Duplicate key exists"}
+	}
+	f.tuples[templateTupleKey(t)] = t
+	return &tarantool.Response{Data: []interface{}{t}}, nil
+}
+
+func (f *fakeTemplateConn) Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeTemplateConn: Update не используется")
+}
+
+func (f *fakeTemplateConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeTemplateConn: Call17 не используется")
+}
+
+func (f *fakeTemplateConn) Delete(ctx context.Context, space, index interface{}, key interface{}) (*tarantool.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := key.([]interface{})
+	k := parts[0].(string) + "\x00" + parts[1].(string)
+	t, ok := f.tuples[k]
+	if !ok {
+		return &tarantool.Response{}, nil
+	}
+	delete(f.tuples, k)
+	return &tarantool.Response{Data: []interface{}{t}}, nil
+}
+
+func (f *fakeTemplateConn) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := make([]string, 0, 2)
+	for _, k := range key.([]interface{}) {
+		parts = append(parts, k.(string))
+	}
+	want := strings.Join(parts, "\x00")
+
+	all := make([]templateTuple, 0, len(f.tuples))
+	for _, t := range f.tuples {
+		all = append(all, t)
+	}
+	sort.Slice(all, func(i, j int) bool { return templateTupleKey(all[i]) < templateTupleKey(all[j]) })
+
+	out := result.(*[]templateTuple)
+	for _, t := range all {
+		k := templateTupleKey(t)
+		switch iterator {
+		case tarantool.IterEq:
+			if k != want && !strings.HasPrefix(k, want+"\x00") {
+				continue
+			}
+		case tarantool.IterGt:
+			if k <= want {
+				continue
+			}
+		default:
+			return fmt.Errorf("fakeTemplateConn: итератор %d не поддерживается", iterator)
+		}
+		if uint32(len(*out)) == limit {
+			break
+		}
+		// Копия, как после декодирования ответа Tarantool
+		t.Options = append([]string(nil), t.Options...)
+		t.Tags = append([]string(nil), t.Tags...)
+		*out = append(*out, t)
+	}
+	return nil
+}
+
+// extraTemplateRepos - аналог extraRepos для шаблонов.
+var extraTemplateRepos = map[string]func(t *testing.T) TemplateRepository{}
+
+func templateRepos() map[string]func(t *testing.T) TemplateRepository {
+	repos := map[string]func(t *testing.T) TemplateRepository{
+		"memory": func(*testing.T) TemplateRepository { return NewMemoryTemplateRepo() },
+		"tarantool": func(*testing.T) TemplateRepository {
+			return NewTarantoolTemplateRepo(newFakeTemplateConn(), "poll_templates")
+		},
+	}
+	for name, newRepo := range extraTemplateRepos {
+		repos[name] = newRepo
+	}
+	return repos
+}
+
+func testTemplate(scope, name string) models.Template {
+	return models.Template{
+		Scope:        scope,
+		Name:         name,
+		Creator:      "user1",
+		Question:     "Где обедаем?",
+		Options:      []string{"Пицца", "Суши"},
+		Description:  "Решаем до 12:00",
+		Tags:         []string{"food"},
+		Quorum:       3,
+		Ranked:       true,
+		NotifyVoters: true,
+		Expires:      36 * time.Hour,
+		CreatedAt:    time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// Тест проверяет полный цикл работы с шаблоном через репозиторий
+func TestTemplateRepo_Lifecycle(t *testing.T) {
+	for name, newRepo := range templateRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			template := testTemplate(models.UserTemplateScope("user1"), "lunch")
+			require.NoError(t, repo.CreateTemplate(ctx, template))
+			got, err := repo.GetTemplate(ctx, template.Scope, "lunch")
+			require.NoError(t, err)
+			assert.Equal(t, template, got)
+
+			require.NoError(t, repo.DeleteTemplate(ctx, template.Scope, "lunch"))
+			_, err = repo.GetTemplate(ctx, template.Scope, "lunch")
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.ErrorIs(t, repo.DeleteTemplate(ctx, template.Scope, "lunch"), ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет, что имя шаблона уникально в своей области, но не между
+// областями, а повторное сохранение не подменяет исходный шаблон
+func TestTemplateRepo_Duplicate(t *testing.T) {
+	for name, newRepo := range templateRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			user := models.UserTemplateScope("user1")
+			require.NoError(t, repo.CreateTemplate(ctx, testTemplate(user, "lunch")))
+
+			again := testTemplate(user, "lunch")
+			again.Question = "Где ужинаем?"
+			assert.ErrorIs(t, repo.CreateTemplate(ctx, again), ErrDuplicate)
+			got, err := repo.GetTemplate(ctx, user, "lunch")
+			require.NoError(t, err)
+			assert.Equal(t, "Где обедаем?", got.Question)
+
+			require.NoError(t, repo.CreateTemplate(ctx, testTemplate(models.ChannelTemplateScope("c1"), "lunch")))
+			require.NoError(t, repo.CreateTemplate(ctx, testTemplate(models.UserTemplateScope("user2"), "lunch")))
+		})
+	}
+}
+
+// Тест проверяет выборку шаблонов области в порядке имён
+func TestTemplateRepo_List(t *testing.T) {
+	for name, newRepo := range templateRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			// Больше одной пачки выборки из Tarantool
+			var inC1 []string
+			for i := 0; i < listScanBatch+20; i++ {
+				scope := models.UserTemplateScope("user1")
+				name := fmt.Sprintf("t%03d", i)
+				if i%3 == 0 {
+					scope = models.ChannelTemplateScope("c1")
+					inC1 = append(inC1, name)
+				}
+				require.NoError(t, repo.CreateTemplate(ctx, testTemplate(scope, name)))
+			}
+
+			got, err := repo.ListTemplates(ctx, models.ChannelTemplateScope("c1"))
+			require.NoError(t, err)
+			names := make([]string, 0, len(got))
+			for _, template := range got {
+				names = append(names, template.Name)
+			}
+			assert.Equal(t, inC1, names)
+
+			got, err = repo.ListTemplates(ctx, models.ChannelTemplateScope("c2"))
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
+
+// Тест проверяет, что при потере соединения с Tarantool возвращается ErrUnavailable
+func TestTarantoolTemplateRepo_Unavailable(t *testing.T) {
+	conn := newFakeTemplateConn()
+	conn.connected = false
+	repo := NewTarantoolTemplateRepo(conn, "poll_templates")
+
+	_, err := repo.ListTemplates(context.Background(), models.UserTemplateScope("user1"))
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, repo.CreateTemplate(context.Background(), testTemplate("user:user1", "lunch")), ErrUnavailable)
+}
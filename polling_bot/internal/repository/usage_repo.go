@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// UsageRepository хранит счётчики выполненных команд по дням.
+type UsageRepository interface {
+	// AddUsage прибавляет счётчики к уже записанным за те же день и
+	// команду.
+	AddUsage(ctx context.Context, usage []models.CommandUsage) error
+	// ListUsage возвращает счётчики с дня since включительно в порядке
+	// дня и команды.
+	ListUsage(ctx context.Context, since time.Time) ([]models.CommandUsage, error)
+}
+
+// funcAddUsage - хранимая функция init.lua, которая прибавляет пачку
+// счётчиков за один вызов.
+const funcAddUsage = "usage_add"
+
+type TarantoolUsageRepo struct {
+	conn      Connector
+	spaceName string
+}
+
+func NewTarantoolUsageRepo(conn Connector, spaceName string) *TarantoolUsageRepo {
+	return &TarantoolUsageRepo{conn: conn, spaceName: spaceName}
+}
+
+// AddUsage выполняется без повторов: при ошибке счётчики остаются у
+// вызывающего и уходят следующей пачкой.
+func (r *TarantoolUsageRepo) AddUsage(ctx context.Context, usage []models.CommandUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	if err := connReady(ctx, r.conn); err != nil {
+		return err
+	}
+
+	entries := make([]interface{}, 0, len(usage))
+	for _, u := range usage {
+		entries = append(entries, []interface{}{usageDay(u.Day), u.Command, u.Count})
+	}
+	if _, err := r.conn.Call17(ctx, funcAddUsage, []interface{}{r.spaceName, entries}); err != nil {
+		return fmt.Errorf("ошибка записи статистики команд: %w", classifyError(err))
+	}
+	return nil
+}
+
+func (r *TarantoolUsageRepo) ListUsage(ctx context.Context, since time.Time) ([]models.CommandUsage, error) {
+	if err := connReady(ctx, r.conn); err != nil {
+		return nil, err
+	}
+
+	var out []models.CommandUsage
+	iterator, key := uint32(tarantool.IterGe), []interface{}{usageDay(since)}
+	for {
+		var tuples []usageTuple
+		err := r.conn.SelectTyped(ctx, r.spaceName, "primary", 0, listScanBatch, iterator, key, &tuples)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка получения статистики команд: %w", classifyError(err))
+		}
+		for _, t := range tuples {
+			out = append(out, t.toModel())
+		}
+		if len(tuples) < listScanBatch {
+			return out, nil
+		}
+		last := tuples[len(tuples)-1]
+		iterator, key = tarantool.IterGt, []interface{}{last.Day, last.Command}
+	}
+}
+
+// usageDay - начало дня t по UTC в unix-времени, как день хранится в
+// space.
+func usageDay(t time.Time) int64 {
+	return t.UTC().Truncate(24 * time.Hour).Unix()
+}
+
+// usageTuple описывает раскладку счётчика команды в space Tarantool.
+// Порядок полей должен точно соответствовать формату space в init.lua.
+type usageTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	Day     int64  // field 1: day (unsigned, unix-время начала дня по UTC)
+	Command string // field 2: command (string)
+	Count   int    // field 3: count (unsigned)
+}
+
+func (t usageTuple) toModel() models.CommandUsage {
+	return models.CommandUsage{
+		Day:     time.Unix(t.Day, 0).UTC(),
+		Command: t.Command,
+		Count:   t.Count,
+	}
+}
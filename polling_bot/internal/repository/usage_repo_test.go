@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/go-tarantool"
+)
+
+// fakeUsageConn - упрощённый fakeConn для space счётчиков команд с
+// первичным индексом (day, command) и хранимой функцией usage_add.
+type fakeUsageConn struct {
+	mu        sync.Mutex
+	connected bool
+	counts    map[usageKey]int
+	calls     int
+}
+
+func newFakeUsageConn() *fakeUsageConn {
+	return &fakeUsageConn{connected: true, counts: make(map[usageKey]int)}
+}
+
+func (f *fakeUsageConn) ConnectedNow() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connected
+}
+
+func (f *fakeUsageConn) Replace(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeUsageConn: Replace не используется")
+}
+
+func (f *fakeUsageConn) Insert(ctx context.Context, space interface{}, tuple interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeUsageConn: Insert не используется")
+}
+
+func (f *fakeUsageConn) Update(ctx context.Context, space, index interface{}, key, ops interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeUsageConn: Update не используется")
+}
+
+func (f *fakeUsageConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	if functionName != funcAddUsage {
+		return nil, fmt.Errorf("fakeUsageConn: функция %s не поддерживается", functionName)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	for _, entry := range args.([]interface{})[1].([]interface{}) {
+		e := entry.([]interface{})
+		f.counts[usageKey{day: e[0].(int64), command: e[1].(string)}] += e[2].(int)
+	}
+	return &tarantool.Response{Data: []interface{}{voteRecorded}}, nil
+}
+
+func (f *fakeUsageConn) Delete(ctx context.Context, space, index interface{}, key interface{}) (*tarantool.Response, error) {
+	return nil, fmt.Errorf("fakeUsageConn: Delete не используется")
+}
+
+func (f *fakeUsageConn) SelectTyped(ctx context.Context, space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := key.([]interface{})
+	from := usageKey{day: parts[0].(int64)}
+	if len(parts) > 1 {
+		from.command = parts[1].(string)
+	}
+	keys := make([]usageKey, 0, len(f.counts))
+	for k := range f.counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return usageKeyLess(keys[i], keys[j]) })
+
+	out := result.(*[]usageTuple)
+	for _, k := range keys {
+		switch iterator {
+		case tarantool.IterGe:
+			if usageKeyLess(k, from) {
+				continue
+			}
+		case tarantool.IterGt:
+			if !usageKeyLess(from, k) {
+				continue
+			}
+		default:
+			return fmt.Errorf("fakeUsageConn: итератор %d не поддерживается", iterator)
+		}
+		if uint32(len(*out)) == limit {
+			break
+		}
+		*out = append(*out, usageTuple{Day: k.day, Command: k.command, Count: f.counts[k]})
+	}
+	return nil
+}
+
+func usageKeyLess(a, b usageKey) bool {
+	if a.day != b.day {
+		return a.day < b.day
+	}
+	return a.command < b.command
+}
+
+// extraUsageRepos - аналог extraRepos для счётчиков команд.
+var extraUsageRepos = map[string]func(t *testing.T) UsageRepository{}
+
+func usageRepos() map[string]func(t *testing.T) UsageRepository {
+	repos := map[string]func(t *testing.T) UsageRepository{
+		"memory": func(*testing.T) UsageRepository { return NewMemoryUsageRepo() },
+		"tarantool": func(*testing.T) UsageRepository {
+			return NewTarantoolUsageRepo(newFakeUsageConn(), "poll_usage")
+		},
+	}
+	for name, newRepo := range extraUsageRepos {
+		repos[name] = newRepo
+	}
+	return repos
+}
+
+// Тест проверяет, что счётчики прибавляются к записанным за те же день и
+// команду, а выборка начинается с начала дня since
+func TestUsageRepo_AddAndList(t *testing.T) {
+	monday := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+
+	for name, newRepo := range usageRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+
+			require.NoError(t, repo.AddUsage(ctx, nil))
+			require.NoError(t, repo.AddUsage(ctx, []models.CommandUsage{
+				{Day: monday, Command: "vote", Count: 3},
+				{Day: monday, Command: "create", Count: 1},
+			}))
+			require.NoError(t, repo.AddUsage(ctx, []models.CommandUsage{
+				{Day: monday, Command: "vote", Count: 2},
+				{Day: tuesday, Command: "vote", Count: 4},
+			}))
+
+			got, err := repo.ListUsage(ctx, monday)
+			require.NoError(t, err)
+			assert.Equal(t, []models.CommandUsage{
+				{Day: monday, Command: "create", Count: 1},
+				{Day: monday, Command: "vote", Count: 5},
+				{Day: tuesday, Command: "vote", Count: 4},
+			}, got)
+
+			got, err = repo.ListUsage(ctx, tuesday.Add(15*time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, []models.CommandUsage{{Day: tuesday, Command: "vote", Count: 4}}, got)
+		})
+	}
+}
+
+// Тест проверяет выборку больше одной пачки и запись пачки одним вызовом
+// хранимой функции
+func TestTarantoolUsageRepo_Batches(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeUsageConn()
+	repo := NewTarantoolUsageRepo(conn, "poll_usage")
+	day := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	usage := make([]models.CommandUsage, 0, listScanBatch+20)
+	for i := 0; i < listScanBatch+20; i++ {
+		usage = append(usage, models.CommandUsage{Day: day, Command: fmt.Sprintf("cmd%03d", i), Count: 1})
+	}
+	require.NoError(t, repo.AddUsage(ctx, usage))
+	assert.Equal(t, 1, conn.calls)
+
+	got, err := repo.ListUsage(ctx, day)
+	require.NoError(t, err)
+	assert.Equal(t, usage, got)
+}
+
+// Тест проверяет, что при потере соединения с Tarantool возвращается ErrUnavailable
+func TestTarantoolUsageRepo_Unavailable(t *testing.T) {
+	conn := newFakeUsageConn()
+	conn.connected = false
+	repo := NewTarantoolUsageRepo(conn, "poll_usage")
+
+	_, err := repo.ListUsage(context.Background(), time.Now())
+	assert.ErrorIs(t, err, ErrUnavailable)
+	err = repo.AddUsage(context.Background(), []models.CommandUsage{{Day: time.Now(), Command: "vote", Count: 1}})
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
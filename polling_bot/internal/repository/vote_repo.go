@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/models"
+
+	"github.com/rs/zerolog"
+	"github.com/tarantool/go-tarantool"
+)
+
+// VoteRepository хранит голоса участников отдельно от опросов, по одной
+// записи на пару (опрос, участник). Счётчики вариантов остаются в опросе.
+type VoteRepository interface {
+	// AddVote записывает голос и прибавляет его первому варианту бюллетеня
+	// в счётчиках опроса одной операцией хранилища; голос с весом Weight
+	// прибавляется и к сумме весов варианта, а голос Abstain - только к
+	// числу воздержавшихся. Голос, достигший кворума, закрывает опрос;
+	// closed сообщает об этом. Голос в закрытый опрос и повторный голос
+	// отклоняются ошибками ErrPollClosed и ErrAlreadyVoted; только
+	// воздержавшийся может заменить свой голос настоящим. Голос, после
+	// которого опрос набрал MaxVotes голосов, тоже закрывает опрос, а голос
+	// в такой опрос отклоняется ошибкой ErrPollFull.
+	AddVote(ctx context.Context, vote models.Vote) (closed bool, err error)
+	// GetVote возвращает голос участника или ErrNotFound, если он не голосовал.
+	GetVote(ctx context.Context, pollID, userID string) (models.Vote, error)
+	// ListVotes возвращает голоса опроса в порядке ID участников.
+	ListVotes(ctx context.Context, pollID string) ([]models.Vote, error)
+	// CountVotes возвращает число голосов опроса, включая воздержавшихся,
+	// не читая сами голоса.
+	CountVotes(ctx context.Context, pollID string) (int, error)
+	// ImportVotes записывает голоса как есть, не трогая счётчики опроса:
+	// так переносятся голоса, уже учтённые в опросе.
+	ImportVotes(ctx context.Context, votes []models.Vote) error
+	DeleteVotes(ctx context.Context, pollID string) error
+}
+
+// Сколько голосов читать из Tarantool за один запрос
+const voteBatchSize = 1000
+
+type TarantoolVoteRepo struct {
+	tarantoolRepo
+	pollSpace string
+	voteSpace string
+}
+
+func NewTarantoolVoteRepo(conn Connector, pollSpace, voteSpace string, retry RetryPolicy, logger zerolog.Logger) *TarantoolVoteRepo {
+	return &TarantoolVoteRepo{tarantoolRepo: newTarantoolRepo(conn, retry, logger), pollSpace: pollSpace, voteSpace: voteSpace}
+}
+
+// AddVote безопасно повторять: если первый вызов дошёл до хранилища,
+// повтор отклоняется как ErrAlreadyVoted и голос не удваивается.
+func (r *TarantoolVoteRepo) AddVote(ctx context.Context, vote models.Vote) (bool, error) {
+	var closed bool
+	err := r.withFailover(ctx, func() (err error) {
+		closed, err = r.addVote(ctx, vote)
+		return err
+	})
+	return closed, err
+}
+
+func (r *TarantoolVoteRepo) addVote(ctx context.Context, vote models.Vote) (bool, error) {
+	if err := r.ready(ctx); err != nil {
+		return false, err
+	}
+
+	t := newVoteTuple(vote)
+	args := []interface{}{r.pollSpace, r.voteSpace, t.PollID, t.UserID, t.Choices, t.VotedAt, t.PostID, t.Weight, t.Abstain}
+	for attempt := 1; ; attempt++ {
+		resp, err := r.conn.Call17(ctx, funcAddVote, args)
+
+		if err == nil {
+			switch status := callStatus(resp); status {
+			case voteRecorded, voteQuorum:
+				return status == voteQuorum, nil
+			case voteClosed:
+				return false, ErrPollClosed
+			case voteFull:
+				return false, ErrPollFull
+			case voteRepeated:
+				return false, ErrAlreadyVoted
+			case voteNotFound:
+				return false, ErrNotFound
+			default:
+				return false, fmt.Errorf("ошибка сохранения голоса: неожиданный ответ %s: %q", funcAddVote, status)
+			}
+		}
+
+		err = classifyError(err)
+		if !errors.Is(err, ErrConflict) {
+			return false, fmt.Errorf("ошибка сохранения голоса: %w", err)
+		}
+		if attempt >= r.retry.Attempts {
+			metrics.VoteRetriesExhausted.Add(1)
+			return false, fmt.Errorf("%w: не удалось сохранить голос после %d попыток", ErrConflict, attempt)
+		}
+
+		delay := r.retry.backoff(attempt, r.jitter)
+		r.logger.Debug().
+			Str("poll_id", vote.PollID).
+			Int("attempt", attempt).
+			Dur("delay", delay).
+			Msg("Конфликт транзакции при записи голоса, повтор")
+		if err := r.sleep(ctx, delay); err != nil {
+			return false, err
+		}
+	}
+}
+
+func (r *TarantoolVoteRepo) GetVote(ctx context.Context, pollID, userID string) (models.Vote, error) {
+	var vote models.Vote
+	err := r.withFailover(ctx, func() error {
+		if err := r.ready(ctx); err != nil {
+			return err
+		}
+		var tuples []voteTuple
+		err := r.conn.SelectTyped(ctx, r.voteSpace, "primary", 0, 1, tarantool.IterEq, []interface{}{pollID, userID}, &tuples)
+		if err != nil {
+			return fmt.Errorf("ошибка получения голоса: %w", classifyError(err))
+		}
+		if len(tuples) == 0 {
+			return ErrNotFound
+		}
+		vote = tuples[0].toModel()
+		return nil
+	})
+	return vote, err
+}
+
+// ListVotes читает первичный индекс (poll_id, user_id) страницами,
+// продолжая после последнего прочитанного участника.
+func (r *TarantoolVoteRepo) ListVotes(ctx context.Context, pollID string) ([]models.Vote, error) {
+	var votes []models.Vote
+	err := r.withFailover(ctx, func() error {
+		votes = nil
+		iterator, key := uint32(tarantool.IterEq), []interface{}{pollID}
+		for {
+			if err := r.ready(ctx); err != nil {
+				return err
+			}
+			var tuples []voteTuple
+			err := r.conn.SelectTyped(ctx, r.voteSpace, "primary", 0, voteBatchSize, iterator, key, &tuples)
+			if err != nil {
+				return fmt.Errorf("ошибка получения голосов: %w", classifyError(err))
+			}
+			for _, t := range tuples {
+				// IterGt по полному ключу выходит за голоса опроса
+				if t.PollID != pollID {
+					return nil
+				}
+				votes = append(votes, t.toModel())
+			}
+			if len(tuples) < voteBatchSize {
+				return nil
+			}
+			iterator, key = tarantool.IterGt, []interface{}{pollID, tuples[len(tuples)-1].UserID}
+		}
+	})
+	return votes, err
+}
+
+// CountVotes считает голоса по частичному ключу первичного индекса
+// (poll_id) на стороне Tarantool.
+func (r *TarantoolVoteRepo) CountVotes(ctx context.Context, pollID string) (int, error) {
+	var count int
+	err := r.withFailover(ctx, func() error {
+		if err := r.ready(ctx); err != nil {
+			return err
+		}
+		resp, err := r.conn.Call17(ctx, funcCountVotes, []interface{}{r.voteSpace, pollID})
+		if err != nil {
+			return fmt.Errorf("ошибка подсчёта голосов: %w", classifyError(err))
+		}
+		count, err = callCount(resp)
+		return err
+	})
+	return count, err
+}
+
+// ImportVotes использует replace, поэтому прерванный перенос можно
+// запустить ещё раз.
+func (r *TarantoolVoteRepo) ImportVotes(ctx context.Context, votes []models.Vote) error {
+	for _, vote := range votes {
+		err := r.withFailover(ctx, func() error {
+			if err := r.ready(ctx); err != nil {
+				return err
+			}
+			if _, err := r.conn.Replace(ctx, r.voteSpace, newVoteTuple(vote)); err != nil {
+				return fmt.Errorf("ошибка сохранения голоса: %w", classifyError(err))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TarantoolVoteRepo) DeleteVotes(ctx context.Context, pollID string) error {
+	return r.withFailover(ctx, func() error {
+		if err := r.ready(ctx); err != nil {
+			return err
+		}
+		if _, err := r.conn.Call17(ctx, funcDeleteVotes, []interface{}{r.voteSpace, pollID}); err != nil {
+			return fmt.Errorf("ошибка удаления голосов: %w", classifyError(err))
+		}
+		return nil
+	})
+}
+
+// Сколько опросов просматривать за один запрос при переносе голосов
+const migrateBatchSize = 100
+
+// MigrateEmbeddedVotes переносит голоса, которые прежние версии бота
+// хранили в самом опросе (поля voters и ballots), в space голосов и
+// очищает эти поля. Счётчики вариантов уже учитывают перенесённые голоса
+// и не меняются. Перенос можно прерывать и запускать повторно; он
+// возвращает число перенесённых голосов.
+func (r *TarantoolVoteRepo) MigrateEmbeddedVotes(ctx context.Context) (int, error) {
+	moved := 0
+	iterator, key := uint32(tarantool.IterAll), []interface{}{}
+	for {
+		if err := r.ready(ctx); err != nil {
+			return moved, err
+		}
+		var tuples []pollTuple
+		err := r.conn.SelectTyped(ctx, r.pollSpace, "primary", 0, migrateBatchSize, iterator, key, &tuples)
+		if err != nil {
+			return moved, fmt.Errorf("ошибка переноса голосов: %w", classifyError(err))
+		}
+
+		for _, t := range tuples {
+			if len(t.Voters) == 0 && len(t.Ballots) == 0 {
+				continue
+			}
+			votes := embeddedVotes(t)
+			if err := r.ImportVotes(ctx, votes); err != nil {
+				return moved, fmt.Errorf("ошибка переноса голосов опроса %s: %w", t.ID, err)
+			}
+			ops := []interface{}{[]interface{}{"=", fieldVoters, map[string]bool{}}}
+			// В кортежах первых версий поля ballots ещё нет
+			if len(t.Ballots) > 0 {
+				ops = append(ops, []interface{}{"=", fieldBallots, map[string][]string(nil)})
+			}
+			if _, err := r.conn.Update(ctx, r.pollSpace, "primary", []interface{}{t.ID}, ops); err != nil {
+				return moved, fmt.Errorf("ошибка переноса голосов опроса %s: %w", t.ID, classifyError(err))
+			}
+			moved += len(votes)
+		}
+
+		if len(tuples) < migrateBatchSize {
+			return moved, nil
+		}
+		iterator, key = tarantool.IterGt, []interface{}{tuples[len(tuples)-1].ID}
+	}
+}
+
+// embeddedVotes собирает голоса из полей voters и ballots опроса.
+// Участник со значением false голосом не считался.
+func embeddedVotes(t pollTuple) []models.Vote {
+	votes := make([]models.Vote, 0, len(t.Voters))
+	for userID, voted := range t.Voters {
+		if voted {
+			votes = append(votes, models.Vote{PollID: t.ID, UserID: userID, Choices: t.Ballots[userID]})
+		}
+	}
+	return votes
+}
+
+// voteTuple описывает раскладку голоса в space Tarantool.
+// Порядок полей должен точно соответствовать формату space в init.lua.
+type voteTuple struct {
+	_msgpack struct{} `msgpack:",asArray"`
+
+	PollID  string   // field 1: poll_id (string)
+	UserID  string   // field 2: user_id (string)
+	Choices []string // field 3: choices (array)
+	VotedAt int64    // field 4: voted_at (unsigned, unix-время, 0 - неизвестно)
+	PostID  string   // field 5: post_id (string, nullable)
+	Weight  int64    // field 6: weight (unsigned, nullable, 0 - опрос без весов)
+	Abstain bool     // field 7: abstain (boolean, nullable)
+}
+
+func newVoteTuple(vote models.Vote) voteTuple {
+	t := voteTuple{PollID: vote.PollID, UserID: vote.UserID, Choices: vote.Choices, PostID: vote.PostID, Weight: int64(vote.Weight),
+		Abstain: vote.Abstain}
+	// Формат space требует array, nil ушёл бы как msgpack nil
+	if t.Choices == nil {
+		t.Choices = []string{}
+	}
+	if !vote.VotedAt.IsZero() {
+		t.VotedAt = vote.VotedAt.Unix()
+	}
+	return t
+}
+
+func (t voteTuple) toModel() models.Vote {
+	vote := models.Vote{PollID: t.PollID, UserID: t.UserID, PostID: t.PostID, Weight: int(t.Weight), Abstain: t.Abstain}
+	if len(t.Choices) > 0 {
+		vote.Choices = t.Choices
+	}
+	if t.VotedAt > 0 {
+		vote.VotedAt = time.Unix(t.VotedAt, 0).UTC()
+	}
+	return vote
+}
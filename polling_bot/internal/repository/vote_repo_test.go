@@ -0,0 +1,476 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"polling_bot/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// extraVoteRepos - аналог extraRepos для голосов: репозиторий голосов
+// возвращается вместе с репозиторием опросов того же хранилища.
+var extraVoteRepos = map[string]func(t *testing.T) (PollRepository, VoteRepository){}
+
+func voteRepos() map[string]func(t *testing.T) (PollRepository, VoteRepository) {
+	repos := map[string]func(t *testing.T) (PollRepository, VoteRepository){
+		"memory": func(*testing.T) (PollRepository, VoteRepository) {
+			polls := NewMemoryPollRepo()
+			return polls, NewMemoryVoteRepo(polls)
+		},
+		"tarantool": func(*testing.T) (PollRepository, VoteRepository) {
+			conn := newFakeConn()
+			return newTestRepo(conn), newTestVoteRepo(conn)
+		},
+		"cached": func(*testing.T) (PollRepository, VoteRepository) {
+			polls := NewMemoryPollRepo()
+			cached := NewCachedRepo(polls, time.Minute, 100)
+			return cached, cached.Votes(NewMemoryVoteRepo(polls))
+		},
+	}
+	for name, newRepos := range extraVoteRepos {
+		repos[name] = newRepos
+	}
+	return repos
+}
+
+func testVote(pollID, userID string) models.Vote {
+	return models.Vote{PollID: pollID, UserID: userID, Choices: []string{"Да"}}
+}
+
+// Тест проверяет, что голос учитывается в счётчике опроса, голос,
+// достигший кворума, закрывает опрос, а голоса в закрытый опрос и
+// повторные голоса отклоняются
+func TestVoteRepo_AddVoteQuorum(t *testing.T) {
+	for name, newRepos := range voteRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			polls, votes := newRepos(t)
+
+			poll := testPoll("poll1")
+			poll.Quorum = 2
+			require.NoError(t, polls.SavePoll(ctx, poll))
+			// Чтение кладёт опрос в кэш: голос должен его сбросить
+			_, err := polls.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+
+			closed, err := votes.AddVote(ctx, testVote(poll.ID, "user2"))
+			require.NoError(t, err)
+			assert.False(t, closed)
+			_, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: "user2", Choices: []string{"Нет"}})
+			assert.ErrorIs(t, err, ErrAlreadyVoted)
+
+			closed, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: "user3", Choices: []string{"Нет"}})
+			require.NoError(t, err)
+			assert.True(t, closed, "голос, достигший кворума, закрывает опрос")
+
+			_, err = votes.AddVote(ctx, testVote(poll.ID, "user4"))
+			assert.ErrorIs(t, err, ErrPollClosed)
+
+			got, err := polls.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.True(t, got.Closed)
+			assert.Equal(t, map[string]int{"Да": 1, "Нет": 1}, got.Options)
+
+			list, err := votes.ListVotes(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.Equal(t, []models.Vote{
+				testVote(poll.ID, "user2"),
+				{PollID: poll.ID, UserID: "user3", Choices: []string{"Нет"}},
+			}, list)
+
+			_, err = votes.AddVote(ctx, testVote("missing", "user2"))
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет чтение голоса участника с полным бюллетенем, временем
+// и сообщением, которым он подан
+func TestVoteRepo_GetVote(t *testing.T) {
+	for name, newRepos := range voteRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			polls, votes := newRepos(t)
+
+			poll := testPoll("poll1")
+			poll.Ranked = true
+			require.NoError(t, polls.SavePoll(ctx, poll))
+
+			vote := models.Vote{
+				PollID:  poll.ID,
+				UserID:  "user2",
+				Choices: []string{"Нет", "Да"},
+				VotedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+				PostID:  "post1",
+			}
+			_, err := votes.AddVote(ctx, vote)
+			require.NoError(t, err)
+
+			got, err := votes.GetVote(ctx, poll.ID, "user2")
+			require.NoError(t, err)
+			assert.Equal(t, vote, got)
+
+			_, err = votes.GetVote(ctx, poll.ID, "user3")
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+// Тест проверяет, что голос с весом прибавляется и к числу голосов, и к
+// сумме весов варианта, а вес хранится в самом голосе
+func TestVoteRepo_AddVoteWeighted(t *testing.T) {
+	for name, newRepos := range voteRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			polls, votes := newRepos(t)
+
+			poll := testPoll("poll1")
+			poll.Weights = map[string]int{"user2": 3}
+			poll.WeightedOptions = map[string]int{"Да": 0, "Нет": 0}
+			require.NoError(t, polls.SavePoll(ctx, poll))
+			_, err := polls.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+
+			weighted := models.Vote{PollID: poll.ID, UserID: "user2", Choices: []string{"Да"}, Weight: 3}
+			_, err = votes.AddVote(ctx, weighted)
+			require.NoError(t, err)
+			_, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: "user3", Choices: []string{"Да"}, Weight: 1})
+			require.NoError(t, err)
+
+			got, err := polls.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int{"Да": 2, "Нет": 0}, got.Options)
+			assert.Equal(t, map[string]int{"Да": 4, "Нет": 0}, got.WeightedOptions)
+			assert.Equal(t, poll.Weights, got.Weights)
+
+			vote, err := votes.GetVote(ctx, poll.ID, "user2")
+			require.NoError(t, err)
+			assert.Equal(t, weighted, vote)
+		})
+	}
+}
+
+// Тест проверяет, что воздержавшийся учитывается в кворуме, но не в
+// счётчиках вариантов, и может один раз заменить свой голос настоящим
+func TestVoteRepo_AddVoteAbstain(t *testing.T) {
+	for name, newRepos := range voteRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			polls, votes := newRepos(t)
+
+			poll := testPoll("poll1")
+			poll.AllowAbstain = true
+			poll.Quorum = 3
+			require.NoError(t, polls.SavePoll(ctx, poll))
+			_, err := polls.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+
+			abstain := models.Vote{PollID: poll.ID, UserID: "user2", Abstain: true}
+			closed, err := votes.AddVote(ctx, abstain)
+			require.NoError(t, err)
+			assert.False(t, closed)
+			_, err = votes.AddVote(ctx, abstain)
+			assert.ErrorIs(t, err, ErrAlreadyVoted, "воздержаться можно только один раз")
+			_, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: "user3", Abstain: true})
+			require.NoError(t, err)
+
+			got, err := polls.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 2, got.Abstained)
+			assert.Equal(t, map[string]int{"Да": 0, "Нет": 0}, got.Options)
+			vote, err := votes.GetVote(ctx, poll.ID, "user2")
+			require.NoError(t, err)
+			assert.Equal(t, abstain, vote)
+
+			// Замена голоса не добавляет участника в кворум
+			closed, err = votes.AddVote(ctx, testVote(poll.ID, "user2"))
+			require.NoError(t, err)
+			assert.False(t, closed)
+			_, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: "user2", Abstain: true})
+			assert.ErrorIs(t, err, ErrAlreadyVoted, "настоящий голос не заменяется")
+
+			got, err = polls.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.Equal(t, 1, got.Abstained)
+			assert.Equal(t, map[string]int{"Да": 1, "Нет": 0}, got.Options)
+			vote, err = votes.GetVote(ctx, poll.ID, "user2")
+			require.NoError(t, err)
+			assert.Equal(t, testVote(poll.ID, "user2"), vote)
+
+			closed, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: "user4", Abstain: true})
+			require.NoError(t, err)
+			assert.True(t, closed, "воздержавшиеся учитываются в кворуме")
+		})
+	}
+}
+
+// Тест проверяет, что записанные кортежи анонимного опроса не содержат ID
+// участников и сообщений, а повторный голос и замена воздержания находятся
+// по ключу участника
+func TestTarantoolVoteRepo_AnonymousPoll(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	polls, votes := newTestRepo(conn), newTestVoteRepo(conn)
+
+	poll := testPoll("poll1")
+	poll.AllowAbstain = true
+	poll.VoterSalt = "salt"
+	require.NoError(t, polls.SavePoll(ctx, poll))
+
+	voters := []string{"voter-alice", "voter-bob"}
+	posts := []string{"post-alice", "post-bob"}
+	_, err := votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: poll.VoterKey("secret", voters[0]), PostID: poll.PostKey("secret", posts[0]), Abstain: true})
+	require.NoError(t, err)
+	_, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: poll.VoterKey("secret", voters[0]), PostID: poll.PostKey("secret", posts[0]), Choices: []string{"Да"}})
+	require.NoError(t, err, "воздержание заменяется голосом по ключу участника")
+	_, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: poll.VoterKey("secret", voters[1]), PostID: poll.PostKey("secret", posts[1]), Choices: []string{"Нет"}})
+	require.NoError(t, err)
+	_, err = votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: poll.VoterKey("secret", voters[1]), Choices: []string{"Да"}})
+	assert.ErrorIs(t, err, ErrAlreadyVoted)
+
+	vote, err := votes.GetVote(ctx, poll.ID, poll.VoterKey("secret", voters[0]))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Да"}, vote.Choices)
+	assert.Equal(t, poll.PostKey("secret", posts[0]), vote.PostID)
+	got, err := polls.GetPoll(ctx, poll.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Да": 1, "Нет": 1}, got.Options)
+	assert.Zero(t, got.Abstained)
+
+	var stored []byte
+	for _, tuple := range conn.votes {
+		data, err := msgpack.Marshal(tuple)
+		require.NoError(t, err)
+		stored = append(stored, data...)
+	}
+	for _, tuple := range conn.tuples {
+		data, err := msgpack.Marshal(tuple)
+		require.NoError(t, err)
+		stored = append(stored, data...)
+	}
+	require.Len(t, conn.votes, 2)
+	for _, id := range append(voters, posts...) {
+		assert.NotContains(t, string(stored), id, "кортежи не должны содержать ID участника или сообщения")
+	}
+}
+
+// Тест проверяет, что голос, набравший максимум, закрывает опрос, а
+// следующий голос отклоняется как ErrPollFull, в отличие от голоса
+// в опрос, завершённый раньше максимума
+func TestVoteRepo_AddVoteMaxVotes(t *testing.T) {
+	for name, newRepos := range voteRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			polls, votes := newRepos(t)
+
+			poll := testPoll("poll1")
+			poll.MaxVotes = 2
+			poll.AllowAbstain = true
+			require.NoError(t, polls.SavePoll(ctx, poll))
+
+			closed, err := votes.AddVote(ctx, models.Vote{PollID: poll.ID, UserID: "user1", Abstain: true})
+			require.NoError(t, err)
+			assert.False(t, closed)
+			// Замена воздержания не считается новым голосом
+			closed, err = votes.AddVote(ctx, testVote(poll.ID, "user1"))
+			require.NoError(t, err)
+			assert.False(t, closed)
+			closed, err = votes.AddVote(ctx, testVote(poll.ID, "user2"))
+			require.NoError(t, err)
+			assert.True(t, closed, "второй голос набирает максимум")
+
+			_, err = votes.AddVote(ctx, testVote(poll.ID, "user3"))
+			assert.ErrorIs(t, err, ErrPollFull)
+			assert.ErrorIs(t, err, ErrPollClosed, "набранный опрос закрыт")
+			got, err := polls.GetPoll(ctx, poll.ID)
+			require.NoError(t, err)
+			assert.True(t, got.Closed)
+			assert.Equal(t, map[string]int{"Да": 2, "Нет": 0}, got.Options)
+
+			ended := testPoll("poll2")
+			ended.MaxVotes = 2
+			require.NoError(t, polls.SavePoll(ctx, ended))
+			require.NoError(t, polls.ClosePoll(ctx, ended.ID))
+			_, err = votes.AddVote(ctx, testVote(ended.ID, "user1"))
+			assert.ErrorIs(t, err, ErrPollClosed)
+			assert.NotErrorIs(t, err, ErrPollFull, "опрос завершён до максимума")
+		})
+	}
+}
+
+// Тест проверяет перенос голосов без изменения счётчиков, порядок
+// ListVotes, подсчёт CountVotes и удаление голосов одного опроса
+func TestVoteRepo_ImportListDelete(t *testing.T) {
+	for name, newRepos := range voteRepos() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			polls, votes := newRepos(t)
+
+			for _, id := range []string{"poll1", "poll2"} {
+				require.NoError(t, polls.SavePoll(ctx, testPoll(id)))
+			}
+			imported := []models.Vote{
+				testVote("poll1", "user3"),
+				{PollID: "poll1", UserID: "user1"},
+				testVote("poll2", "user2"),
+			}
+			require.NoError(t, votes.ImportVotes(ctx, imported))
+
+			got, err := polls.GetPoll(ctx, "poll1")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int{"Да": 0, "Нет": 0}, got.Options, "перенос не трогает счётчики")
+
+			list, err := votes.ListVotes(ctx, "poll1")
+			require.NoError(t, err)
+			assert.Equal(t, []models.Vote{imported[1], imported[0]}, list, "голоса идут по ID участников")
+
+			for id, want := range map[string]int{"poll1": 2, "poll2": 1, "missing": 0} {
+				count, err := votes.CountVotes(ctx, id)
+				require.NoError(t, err)
+				assert.Equal(t, want, count, id)
+			}
+
+			require.NoError(t, votes.DeleteVotes(ctx, "poll1"))
+			count, err := votes.CountVotes(ctx, "poll1")
+			require.NoError(t, err)
+			assert.Zero(t, count)
+			list, err = votes.ListVotes(ctx, "poll1")
+			require.NoError(t, err)
+			assert.Empty(t, list)
+			list, err = votes.ListVotes(ctx, "poll2")
+			require.NoError(t, err)
+			assert.Equal(t, []models.Vote{imported[2]}, list, "голоса других опросов остаются")
+		})
+	}
+}
+
+// Тест проверяет постраничное чтение голосов из Tarantool
+func TestTarantoolListVotes_Pages(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	repo := newTestVoteRepo(conn)
+
+	var imported []models.Vote
+	for i := 0; i < voteBatchSize+10; i++ {
+		imported = append(imported, testVote("poll1", fmt.Sprintf("user%04d", i)))
+	}
+	imported = append(imported, testVote("poll2", "user0000"))
+	require.NoError(t, repo.ImportVotes(ctx, imported))
+
+	list, err := repo.ListVotes(ctx, "poll1")
+	require.NoError(t, err)
+	assert.Equal(t, imported[:voteBatchSize+10], list)
+	assert.Equal(t, 2, conn.calls["Select:primary"])
+}
+
+// Тест проверяет перенос голосов из полей voters и ballots опроса
+func TestTarantoolVoteRepo_MigrateEmbeddedVotes(t *testing.T) {
+	ctx := context.Background()
+	conn := newFakeConn()
+	polls := newTestRepo(conn)
+	repo := newTestVoteRepo(conn)
+
+	for i := 0; i < migrateBatchSize+1; i++ {
+		require.NoError(t, polls.SavePoll(ctx, testPoll(fmt.Sprintf("p%03d", i))))
+	}
+	// Участники, записанные прежними версиями, и один голос без бюллетеня
+	legacy := conn.tuples["p100"]
+	legacy.Options = optionCounts{"Да": 2, "Нет": 1}
+	legacy.Voters = voterSet{"user1": true, "user2": true, "user3": true, "user4": false}
+	legacy.Ballots = map[string][]string{"user1": {"Да"}, "user2": {"Нет", "Да"}}
+	conn.tuples["p100"] = legacy
+
+	moved, err := repo.MigrateEmbeddedVotes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, moved)
+
+	list, err := repo.ListVotes(ctx, "p100")
+	require.NoError(t, err)
+	assert.Equal(t, []models.Vote{
+		{PollID: "p100", UserID: "user1", Choices: []string{"Да"}},
+		{PollID: "p100", UserID: "user2", Choices: []string{"Нет", "Да"}},
+		{PollID: "p100", UserID: "user3"},
+	}, list)
+	assert.Empty(t, conn.tuples["p100"].Voters)
+	assert.Empty(t, conn.tuples["p100"].Ballots)
+
+	got, err := polls.GetPoll(ctx, "p100")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Да": 2, "Нет": 1}, got.Options)
+
+	moved, err = repo.MigrateEmbeddedVotes(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, moved, "повторный запуск ничего не переносит")
+}
+
+// Тест проверяет чтение голосов, записанных до появления поля post_id
+func TestVoteTuple_DecodeWithoutPostID(t *testing.T) {
+	data, err := msgpack.Marshal([]interface{}{"poll1", "user1", []string{"Да"}, int64(1740830400)})
+	require.NoError(t, err)
+
+	var decoded voteTuple
+	require.NoError(t, msgpack.Unmarshal(data, &decoded))
+	assert.Equal(t, models.Vote{
+		PollID:  "poll1",
+		UserID:  "user1",
+		Choices: []string{"Да"},
+		VotedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}, decoded.toModel())
+}
+
+// BenchmarkCountVoters сравнивает способы узнать число проголосовавших в
+// опросе на 10000 участников: загрузку опроса целиком, загрузку всех
+// голосов и CountVotes.
+func BenchmarkCountVoters(b *testing.B) {
+	ctx := context.Background()
+	const voters = 10000
+	repos := map[string]func() (PollRepository, VoteRepository){
+		"memory": func() (PollRepository, VoteRepository) {
+			polls := NewMemoryPollRepo()
+			return polls, NewMemoryVoteRepo(polls)
+		},
+		"tarantool": func() (PollRepository, VoteRepository) {
+			conn := newFakeConn()
+			return newTestRepo(conn), newTestVoteRepo(conn)
+		},
+	}
+	counts := map[string]func(PollRepository, VoteRepository) (int, error){
+		"get-poll": func(polls PollRepository, _ VoteRepository) (int, error) {
+			poll, err := polls.GetPoll(ctx, "poll1")
+			return poll.VoterCount(), err
+		},
+		"list-votes": func(_ PollRepository, votes VoteRepository) (int, error) {
+			list, err := votes.ListVotes(ctx, "poll1")
+			return len(list), err
+		},
+		"count-votes": func(_ PollRepository, votes VoteRepository) (int, error) {
+			return votes.CountVotes(ctx, "poll1")
+		},
+	}
+	for repoName, newRepos := range repos {
+		for countName, count := range counts {
+			b.Run(repoName+"/"+countName, func(b *testing.B) {
+				polls, votes := newRepos()
+				poll, list := votedPoll(voters)
+				require.NoError(b, polls.SavePoll(ctx, poll))
+				require.NoError(b, votes.ImportVotes(ctx, list))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					n, err := count(polls, votes)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if n != voters {
+						b.Fatalf("получено %d проголосовавших, ожидалось %d", n, voters)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"strings"
+
+	"polling_bot/internal/i18n"
+)
+
+// isAbstain сообщает, что выбор - псевдовариант «Воздержался». Он
+// узнаётся на любом языке бота, чтобы не зависеть от языка участника.
+func isAbstain(choice string) bool {
+	choice = strings.TrimSpace(choice)
+	for _, lang := range []i18n.Lang{i18n.Russian, i18n.English} {
+		if strings.EqualFold(choice, i18n.New(string(lang)).T(i18n.AbstainOption)) {
+			return true
+		}
+	}
+	return false
+}
+
+// abstainBallot проверяет бюллетень опроса, в котором можно воздержаться:
+// abstain сообщает, что участник воздерживается. Воздержание исключает
+// остальные варианты бюллетеня.
+func abstainBallot(choices []string) (abstain bool, err error) {
+	for _, choice := range choices {
+		if isAbstain(choice) {
+			abstain = true
+		}
+	}
+	if abstain && len(choices) > 1 {
+		return false, i18n.NewError(i18n.AbstainExclusive)
+	}
+	return abstain, nil
+}
+
+// validateAbstain не даёт назвать настоящий вариант так же, как
+// псевдовариант воздержания.
+func validateAbstain(options []string, opts CreateOptions) error {
+	if !opts.AllowAbstain {
+		return nil
+	}
+	for _, option := range options {
+		if isAbstain(option) {
+			return i18n.NewError(i18n.AbstainReserved, option)
+		}
+	}
+	return nil
+}
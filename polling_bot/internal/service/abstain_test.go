@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/repository"
+)
+
+func newAbstainService(t *testing.T, opts CreateOptions, options ...string) (*PollServiceImpl, string) {
+	t.Helper()
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	opts.AllowAbstain = true
+	created, err := s.CreatePollWithID(context.Background(), "creator1", "Релизим?", options, opts)
+	require.NoError(t, err)
+	return s, created.ID
+}
+
+// Тест проверяет, что воздержание отображается в итогах отдельно от
+// голосов и может быть заменено настоящим голосом
+func TestAddVote_AbstainThenVote(t *testing.T) {
+	ctx := context.Background()
+	s, pollID := newAbstainService(t, CreateOptions{}, "Да", "Нет")
+
+	reply, err := s.AddVote(ctx, "u1", pollID, []string{"воздержался"})
+	require.NoError(t, err)
+	assert.Equal(t, "Вы воздержались в голосовании "+pollID+
+		". Передумаете - проголосуйте за вариант, голос заменит воздержание", reply)
+	_, err = s.AddVote(ctx, "u2", pollID, []string{"Abstain"})
+	require.NoError(t, err, "псевдовариант узнаётся на любом языке бота")
+	_, err = s.AddVote(ctx, "u1", pollID, []string{"Воздержался"})
+	assert.EqualError(t, err, "вы уже голосовали в этом опросе")
+
+	myVote, err := s.MyVote(ctx, "u1", pollID)
+	require.NoError(t, err)
+	assert.Equal(t, "Вы воздержались в опросе "+pollID, myVote)
+	results, err := s.GetResults(ctx, "creator1", pollID)
+	require.NoError(t, err)
+	assert.Equal(t, "**Результаты опроса "+pollID+"**\nРелизим?\n"+
+		"- Да: 0 голосов\n- Нет: 0 голосов\nВоздержались: 2\n", results)
+
+	_, err = s.AddVote(ctx, "u1", pollID, []string{"Нет"})
+	require.NoError(t, err)
+	_, err = s.AddVote(ctx, "u1", pollID, []string{"Да"})
+	assert.EqualError(t, err, "вы уже голосовали в этом опросе", "заменить можно только воздержание")
+
+	results, err = s.GetResults(ctx, "creator1", pollID)
+	require.NoError(t, err)
+	assert.Equal(t, "**Результаты опроса "+pollID+"**\nРелизим?\n"+
+		"- Да: 0 голосов\n- Нет: 1 голосов\nВоздержались: 1\n", results)
+}
+
+// Тест проверяет, что воздержавшиеся учитываются в кворуме
+func TestAddVote_AbstainQuorum(t *testing.T) {
+	ctx := context.Background()
+	s, pollID := newAbstainService(t, CreateOptions{Quorum: 2}, "Да", "Нет")
+
+	_, err := s.AddVote(ctx, "u1", pollID, []string{"Воздержался"})
+	require.NoError(t, err)
+	// Замена воздержания не добавляет участника
+	_, err = s.AddVote(ctx, "u1", pollID, []string{"Да"})
+	require.NoError(t, err)
+
+	reply, err := s.AddVote(ctx, "u2", pollID, []string{"Воздержался"})
+	require.NoError(t, err)
+	assert.Contains(t, reply, "Вы воздержались в голосовании "+pollID)
+	assert.Contains(t, reply, "- Да: 1 голосов\n- Нет: 0 голосов\nВоздержались: 1\n")
+
+	_, err = s.AddVote(ctx, "u2", pollID, []string{"Да"})
+	assert.EqualError(t, err, "опрос завершен")
+}
+
+// Тест проверяет, что в рейтинговом опросе воздержание исключает
+// остальные варианты и не попадает в подсчёт раундов
+func TestAddVote_AbstainRanked(t *testing.T) {
+	ctx := context.Background()
+	s, pollID := newAbstainService(t, CreateOptions{Ranked: true}, "Пицца", "Суши")
+
+	_, err := s.AddVote(ctx, "u1", pollID, []string{"Пицца", "Воздержался"})
+	assert.EqualError(t, err, "воздержаться можно только без других вариантов")
+	_, err = s.AddVote(ctx, "u1", pollID, []string{"Воздержался"})
+	require.NoError(t, err)
+	_, err = s.AddVote(ctx, "u2", pollID, []string{"Суши", "Пицца"})
+	require.NoError(t, err)
+
+	results, err := s.GetResults(ctx, "creator1", pollID)
+	require.NoError(t, err)
+	assert.Contains(t, results, "Раунд 1: Пицца - 0, Суши - 1\n")
+	assert.Contains(t, results, "Воздержались: 1\n")
+}
+
+// Тест проверяет проверки воздержания при создании опроса и голосовании
+func TestAbstain_Validation(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+	_, err := s.CreatePoll(ctx, "creator1", "Релизим?", []string{"Да", "Воздержался"}, CreateOptions{AllowAbstain: true})
+	assert.EqualError(t, err, "вариант 'Воздержался' зарезервирован для воздержавшихся")
+
+	// Без флага «Воздержался» - обычный вариант
+	created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Воздержался"}, CreateOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, created.Message, "Можно воздержаться")
+	_, err = s.AddVote(ctx, "u1", created.ID, []string{"Воздержался"})
+	require.NoError(t, err)
+	stored, err := repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Options["Воздержался"])
+	assert.Zero(t, stored.Abstained)
+
+	created, err = s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да"}, CreateOptions{})
+	require.NoError(t, err)
+	_, err = s.AddVote(ctx, "u1", created.ID, []string{"Воздержался"})
+	assert.Error(t, err, "без флага воздержаться нельзя")
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"crypto/rand"
+
+	"polling_bot/internal/i18n"
+)
+
+// SetAnonymousSecret задаёт ключ, которым подписываются ключи участников
+// анонимных опросов. Ключ хранится вне хранилища опросов: иначе тот, кто
+// читает хранилище, перебрал бы ID пользователей и узнал, кто как
+// голосовал. Смена ключа теряет связь участников с их голосами в уже
+// созданных анонимных опросах. Без ключа анонимные опросы не создаются.
+func (s *PollServiceImpl) SetAnonymousSecret(secret string) {
+	s.anonymousSecret = secret
+}
+
+// newVoterSalt - соль нового анонимного опроса. Соль хранится вместе с
+// опросом, а ключи участников подписываются ещё и ключом бота.
+func newVoterSalt() string {
+	return rand.Text()
+}
+
+// validateAnonymous отклоняет настройки, которые требуют хранить ID
+// участников: веса задаются по участникам, рассылке итогов нужны ID
+// голосовавших, а список участников хранит их ID в опросе.
+func validateAnonymous(opts CreateOptions) error {
+	if !opts.Anonymous {
+		return nil
+	}
+	if len(opts.Weights) > 0 {
+		return i18n.NewError(i18n.AnonymousWeights)
+	}
+	if opts.NotifyVoters {
+		return i18n.NewError(i18n.AnonymousNotify)
+	}
+	if len(opts.Voters) > 0 {
+		return i18n.NewError(i18n.AnonymousVoters)
+	}
+	return nil
+}
+
+// checkAnonymousSecret отклоняет анонимный опрос, если ключ бота не задан:
+// ключи участников без него восстанавливались бы по данным хранилища.
+func (s *PollServiceImpl) checkAnonymousSecret(anonymous bool) error {
+	if anonymous && s.anonymousSecret == "" {
+		return i18n.NewError(i18n.AnonymousUnavailable)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+func newAnonymousService(t *testing.T) (*PollServiceImpl, *repository.MemoryPollRepo, *repository.MemoryVoteRepo, *repository.MemoryAuditRepo) {
+	t.Helper()
+	repo := repository.NewMemoryPollRepo()
+	votes := repository.NewMemoryVoteRepo(repo)
+	journal := repository.NewMemoryAuditRepo()
+	s := NewPollService(repo, votes, zerolog.Nop())
+	s.SetAuditRepository(journal)
+	s.SetAnonymousSecret("bot-secret")
+	return s, repo, votes, journal
+}
+
+// Тест проверяет голосование в анонимном опросе: голоса хранятся без ID
+// участников и сообщений, повторный голос отклоняется, воздержание
+// заменяется голосом, участник видит свой голос, повторная доставка
+// получает исходный ответ, а журнал не называет голосовавших
+func TestAnonymousPoll_Votes(t *testing.T) {
+	ctx := context.Background()
+	s, repo, votes, journal := newAnonymousService(t)
+	created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"},
+		CreateOptions{Anonymous: true, AllowAbstain: true})
+	require.NoError(t, err)
+	assert.Contains(t, created.Message, "Опрос анонимный: бот хранит голоса без ID участников")
+
+	alice := WithOrigin(ctx, Origin{PostID: "post-alice"})
+	_, err = s.AddVote(ctx, "alice", created.ID, []string{"Воздержался"})
+	require.NoError(t, err)
+	reply, err := s.AddVote(alice, "alice", created.ID, []string{"Да"})
+	require.NoError(t, err, "воздержание заменяется голосом")
+	assert.Equal(t, "Ваш голос в голосовании "+created.ID+" записан: Да", reply)
+	_, err = s.AddVote(ctx, "bob", created.ID, []string{"Нет"})
+	require.NoError(t, err)
+
+	_, err = s.AddVote(ctx, "bob", created.ID, []string{"Да"})
+	assert.EqualError(t, err, "вы уже голосовали в этом опросе")
+	again, err := s.AddVote(alice, "alice", created.ID, []string{"Да"})
+	require.NoError(t, err, "повторная доставка получает исходный ответ")
+	assert.Equal(t, reply, again)
+
+	choices, voted, err := s.GetUserVote(ctx, "alice", created.ID)
+	require.NoError(t, err)
+	assert.True(t, voted)
+	assert.Equal(t, []string{"Да"}, choices)
+	_, voted, err = s.GetUserVote(ctx, "carol", created.ID)
+	require.NoError(t, err)
+	assert.False(t, voted)
+
+	poll, err := repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Да": 1, "Нет": 1}, poll.Options)
+	stored, err := votes.ListVotes(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	for _, vote := range stored {
+		assert.NotContains(t, []string{"alice", "bob"}, vote.UserID)
+		assert.NotEqual(t, "post-alice", vote.PostID)
+	}
+	// Соли из хранилища без ключа бота не хватает, чтобы найти голос участника
+	assert.Contains(t, []string{stored[0].UserID, stored[1].UserID}, poll.VoterKey("bot-secret", "alice"))
+	assert.NotContains(t, []string{stored[0].UserID, stored[1].UserID}, poll.VoterKey("", "alice"))
+
+	events, err := journal.ListEvents(ctx, created.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1, "голоса анонимного опроса не пишутся в журнал")
+	assert.Equal(t, audit.ActionCreated, events[0].Action)
+}
+
+// Тест проверяет, что функции, которым нужны голосовавшие, в анонимном
+// опросе отказывают, а настройки, привязанные к участникам, не
+// сочетаются с анонимностью
+func TestAnonymousPoll_Refusals(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     func(ctx context.Context, s *PollServiceImpl, pollID string) error
+		wantKey i18n.Key
+	}{
+		{name: "winner", wantKey: i18n.WinnerAnonymous, run: func(ctx context.Context, s *PollServiceImpl, pollID string) error {
+			if _, err := s.EndPoll(ctx, "creator1", pollID); err != nil {
+				return err
+			}
+			_, err := s.PickWinner(ctx, "creator1", pollID, "", false)
+			return err
+		}},
+		{name: "audit", wantKey: i18n.AuditAnonymous, run: func(ctx context.Context, s *PollServiceImpl, pollID string) error {
+			_, err := s.AuditLog(ctx, pollID, 0)
+			return err
+		}},
+		{name: "set", wantKey: i18n.SettingCreateOnly, run: func(ctx context.Context, s *PollServiceImpl, pollID string) error {
+			_, err := s.UpdateSetting(ctx, "creator1", pollID, SettingChange{Setting: SettingAnonymous})
+			return err
+		}},
+		{name: "weights", wantKey: i18n.AnonymousWeights, run: func(ctx context.Context, s *PollServiceImpl, _ string) error {
+			_, err := s.CreatePoll(ctx, "creator1", "Q", []string{"A", "B"}, CreateOptions{Anonymous: true, Weights: map[string]int{"alice": 2}})
+			return err
+		}},
+		{name: "notify voters", wantKey: i18n.AnonymousNotify, run: func(ctx context.Context, s *PollServiceImpl, _ string) error {
+			_, err := s.CreatePoll(ctx, "creator1", "Q", []string{"A", "B"}, CreateOptions{Anonymous: true, NotifyVoters: true})
+			return err
+		}},
+		{name: "voters", wantKey: i18n.AnonymousVoters, run: func(ctx context.Context, s *PollServiceImpl, _ string) error {
+			_, err := s.CreatePoll(ctx, "creator1", "Q", []string{"A", "B"}, CreateOptions{Anonymous: true, Voters: []string{"alice"}})
+			return err
+		}},
+		{name: "set voters", wantKey: i18n.AnonymousVoters, run: func(ctx context.Context, s *PollServiceImpl, pollID string) error {
+			_, err := s.UpdateSetting(ctx, "creator1", pollID, SettingChange{Setting: SettingVoters, Usernames: []string{"alice"}})
+			return err
+		}},
+		{name: "schedule", wantKey: i18n.AnonymousCreateOnly, run: func(ctx context.Context, s *PollServiceImpl, _ string) error {
+			schedules := NewScheduleService(repository.NewMemoryScheduleRepo(), s, zerolog.Nop())
+			_, err := schedules.CreateSchedule(ctx, "creator1", "0 9 * * 1", "Q", []string{"A", "B"}, CreateOptions{Anonymous: true})
+			return err
+		}},
+		{name: "template", wantKey: i18n.AnonymousCreateOnly, run: func(ctx context.Context, s *PollServiceImpl, _ string) error {
+			templates := NewTemplateService(repository.NewMemoryTemplateRepo(), s, zerolog.Nop())
+			_, err := templates.SaveTemplate(ctx, "creator1", "release", "Q", []string{"A", "B"}, CreateOptions{Anonymous: true}, false)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithOrigin(context.Background(), Origin{ChannelID: "channel1"})
+			s, _, _, _ := newAnonymousService(t)
+			created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"}, CreateOptions{Anonymous: true})
+			require.NoError(t, err)
+			_, err = s.AddVote(ctx, "alice", created.ID, []string{"Да"})
+			require.NoError(t, err)
+
+			err = tt.run(ctx, s, created.ID)
+			var localized *i18n.Error
+			require.ErrorAs(t, err, &localized)
+			assert.Equal(t, tt.wantKey, localized.Key)
+		})
+	}
+}
+
+// Тест проверяет, что рассылка итогов по умолчанию не включается в
+// анонимном опросе, а клон анонимного опроса анонимен со своей солью
+func TestAnonymousPoll_NotifyAndClone(t *testing.T) {
+	ctx := context.Background()
+	s, repo, _, _ := newAnonymousService(t)
+	s.SetVoterNotifications(NotifyOptions{Default: true})
+	created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"}, CreateOptions{Anonymous: true})
+	require.NoError(t, err)
+
+	source, err := repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.True(t, source.Anonymous())
+	assert.False(t, source.NotifyVoters)
+
+	_, err = s.ClonePoll(ctx, "creator1", created.ID, CloneOverrides{})
+	require.NoError(t, err)
+	polls, _, err := repo.ListPolls(ctx, repository.ListFilter{Creator: "creator1"})
+	require.NoError(t, err)
+	require.Len(t, polls, 2)
+	for _, poll := range polls {
+		assert.True(t, poll.Anonymous())
+	}
+	assert.NotEqual(t, polls[0].VoterSalt, polls[1].VoterSalt)
+}
+
+// Тест проверяет, что без ключа бота анонимный опрос не создаётся, а в уже
+// созданном голоса не принимаются
+func TestAnonymousPoll_NoSecret(t *testing.T) {
+	ctx := context.Background()
+	s, _, _, _ := newAnonymousService(t)
+	created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"}, CreateOptions{Anonymous: true})
+	require.NoError(t, err)
+
+	s.SetAnonymousSecret("")
+	_, err = s.CreatePoll(ctx, "creator1", "Q", []string{"A", "B"}, CreateOptions{Anonymous: true})
+	assert.EqualError(t, err, "анонимные опросы не настроены: не задан ключ BOT_ANONYMOUS_SECRET")
+	_, err = s.AddVote(ctx, "alice", created.ID, []string{"Да"})
+	assert.EqualError(t, err, "анонимные опросы не настроены: не задан ключ BOT_ANONYMOUS_SECRET")
+}
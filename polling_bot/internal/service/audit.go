@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+const (
+	// Сколько событий показывает audit без явного числа и не больше скольких
+	defaultAuditEvents = 20
+	maxAuditEvents     = 100
+	// Время событий показывается в UTC, чтобы журнал читался одинаково
+	// независимо от часового пояса сервера
+	auditTimeLayout = "2006-01-02 15:04:05 MST"
+)
+
+// Описание действия в журнале и что лежит в Detail: текст или ID пользователя
+var auditActions = map[audit.Action]struct {
+	key        i18n.Key
+	hasDetail  bool
+	detailUser bool
+}{
+	audit.ActionCreated:        {key: i18n.AuditCreated, hasDetail: true},
+	audit.ActionCloned:         {key: i18n.AuditCloned, hasDetail: true},
+	audit.ActionVoted:          {key: i18n.AuditVoted, hasDetail: true},
+	audit.ActionQuorumClosed:   {key: i18n.AuditQuorumClosed, hasDetail: true},
+	audit.ActionMaxVotesClosed: {key: i18n.AuditMaxVotes, hasDetail: true},
+	audit.ActionEnded:          {key: i18n.AuditEnded},
+	audit.ActionDeleted:        {key: i18n.AuditDeleted, hasDetail: true},
+	audit.ActionWinner:         {key: i18n.AuditWinner, hasDetail: true, detailUser: true},
+	audit.ActionTransferred:    {key: i18n.AuditTransferred, hasDetail: true, detailUser: true},
+	audit.ActionSettingChanged: {key: i18n.AuditSetting, hasDetail: true},
+	audit.ActionQuestionEdited: {key: i18n.AuditEdited, hasDetail: true},
+	audit.ActionExpired:        {key: i18n.AuditExpired},
+	audit.ActionDiscarded:      {key: i18n.AuditDiscarded},
+	audit.ActionFeatured:       {key: i18n.AuditFeatured},
+	audit.ActionUnfeatured:     {key: i18n.AuditUnfeatured},
+}
+
+// SetAuditRepository включает журнал событий опросов: после каждого успешного
+// изменения опроса сервис добавляет в него запись.
+func (s *PollServiceImpl) SetAuditRepository(journal repository.AuditRepository) {
+	s.journal = journal
+}
+
+// record добавляет событие в журнал. Ошибка записи не отменяет уже
+// выполненное действие пользователя и только логируется.
+func (s *PollServiceImpl) record(ctx context.Context, pollID, actor string, action audit.Action, detail string) {
+	if s.journal == nil {
+		return
+	}
+	event := audit.Event{
+		ID:     uuid.New().String(),
+		PollID: pollID,
+		Actor:  actor,
+		Action: action,
+		Detail: detail,
+		At:     s.now().UTC(),
+	}
+	if err := s.journal.AppendEvent(ctx, event); err != nil {
+		s.logger.Error().Err(err).Str("poll_id", pollID).Str("action", string(action)).Msg("Не удалось записать событие в журнал")
+	}
+}
+
+// AuditLog показывает последние limit событий опроса, по умолчанию 20.
+// Журнал доступен и после удаления опроса; журнал анонимного опроса,
+// пока опрос есть, не показывается. Голоса анонимного опроса в журнал не
+// пишутся, поэтому и после удаления он не называет участников.
+func (s *PollServiceImpl) AuditLog(ctx context.Context, pollID string, limit int) (string, error) {
+	if s.journal == nil {
+		return "", i18n.NewError(i18n.AuditUnavailable)
+	}
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	switch {
+	case err == nil && poll.Anonymous():
+		return "", i18n.NewError(i18n.AuditAnonymous)
+	case err != nil && !errors.Is(err, repository.ErrNotFound):
+		return "", s.storageError(err, i18n.OpGetPoll)
+	}
+	if limit <= 0 {
+		limit = defaultAuditEvents
+	}
+	if limit > maxAuditEvents {
+		limit = maxAuditEvents
+	}
+
+	events, err := s.journal.ListEvents(ctx, pollID, limit)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpListAudit)
+	}
+	loc := i18n.FromContext(ctx)
+	if len(events) == 0 {
+		return loc.T(i18n.AuditEmpty, pollID), nil
+	}
+
+	// Одни и те же участники встречаются в журнале много раз
+	names := make(map[string]string)
+	name := func(userID string) string {
+		if _, ok := names[userID]; !ok {
+			names[userID] = s.username(ctx, userID)
+		}
+		return names[userID]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.AuditHeader, pollID))
+	for _, event := range events {
+		var description string
+		action, known := auditActions[event.Action]
+		switch {
+		case !known:
+			description = loc.T(i18n.AuditUnknown, string(event.Action), event.Detail)
+		case action.detailUser:
+			description = loc.T(action.key, name(event.Detail))
+		case action.hasDetail:
+			description = loc.T(action.key, event.Detail)
+		default:
+			description = loc.T(action.key)
+		}
+		sb.WriteString(loc.T(i18n.AuditLine, event.At.UTC().Format(auditTimeLayout), name(event.Actor), description))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// recordingJournal запоминает события; с err каждая запись завершается ошибкой.
+type recordingJournal struct {
+	mu     sync.Mutex
+	events []audit.Event
+	err    error
+}
+
+func (j *recordingJournal) AppendEvent(ctx context.Context, event audit.Event) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err != nil {
+		return j.err
+	}
+	j.events = append(j.events, event)
+	return nil
+}
+
+func (j *recordingJournal) ListEvents(ctx context.Context, pollID string, limit int) ([]audit.Event, error) {
+	return nil, errors.New("recordingJournal: ListEvents не используется")
+}
+
+const auditPollID = "123e4567-e89b-12d3-a456-426614174000"
+
+var auditNow = time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC)
+
+func auditTestPoll() models.Poll {
+	return models.Poll{
+		ID:          auditPollID,
+		Creator:     "creator1",
+		Question:    "Где обедаем?",
+		Options:     map[string]int{"Пицца": 1, "Суши": 0},
+		OptionOrder: []string{"Пицца", "Суши"},
+	}
+}
+
+func newAuditTestService(t *testing.T, poll models.Poll, journal repository.AuditRepository) (*PollServiceImpl, repository.PollRepository) {
+	t.Helper()
+	repo := repository.NewMemoryPollRepo()
+	require.NoError(t, repo.SavePoll(context.Background(), poll))
+	votes := repository.NewMemoryVoteRepo(repo)
+	// Голос u1 уже учтён в счётчике Пиццы
+	require.NoError(t, votes.ImportVotes(context.Background(), []models.Vote{{PollID: poll.ID, UserID: "u1", Choices: []string{"Пицца"}}}))
+	s := NewPollService(repo, votes, zerolog.Nop())
+	s.now = func() time.Time { return auditNow }
+	s.randIntn = func(int) (int, error) { return 0, nil }
+	s.SetUserFinder(stubUserFinder{"ivan": {ID: "u2", Username: "ivan"}})
+	s.SetUserNames(stubUserNames{"creator1": "anna", "u1": "oleg", "u2": "ivan"})
+	s.SetAuditRepository(journal)
+	return s, repo
+}
+
+// Тест проверяет, что каждое успешное изменение опроса пишет ровно одно событие
+func TestAudit_EveryMutationRecordsOneEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*models.Poll)
+		run    func(ctx context.Context, s *PollServiceImpl) error
+		want   audit.Event
+		// Событие относится к новому опросу, а не к исходному
+		newPoll bool
+	}{
+		{
+			name: "create",
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.CreatePoll(ctx, "creator1", "Где ужинаем?", []string{"Паста", "Суп"}, CreateOptions{})
+				return err
+			},
+			want:    audit.Event{Actor: "creator1", Action: audit.ActionCreated, Detail: "Где ужинаем?"},
+			newPoll: true,
+		},
+		{
+			name: "clone",
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.ClonePoll(ctx, "u2", auditPollID, CloneOverrides{})
+				return err
+			},
+			want:    audit.Event{Actor: "u2", Action: audit.ActionCloned, Detail: auditPollID},
+			newPoll: true,
+		},
+		{
+			name: "vote",
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.AddVote(ctx, "u2", auditPollID, []string{"суши"})
+				return err
+			},
+			want: audit.Event{Actor: "u2", Action: audit.ActionVoted, Detail: "Суши"},
+		},
+		{
+			name:   "ranked vote",
+			mutate: func(p *models.Poll) { p.Ranked = true },
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.AddVote(ctx, "u2", auditPollID, []string{"Суши", "Пицца"})
+				return err
+			},
+			want: audit.Event{Actor: "u2", Action: audit.ActionVoted, Detail: "Суши > Пицца"},
+		},
+		{
+			name:   "vote reaching quorum",
+			mutate: func(p *models.Poll) { p.Quorum = 2 },
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.AddVote(ctx, "u2", auditPollID, []string{"Пицца"})
+				return err
+			},
+			want: audit.Event{Actor: "u2", Action: audit.ActionQuorumClosed, Detail: "Пицца"},
+		},
+		{
+			name: "end",
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.EndPoll(ctx, "creator1", auditPollID)
+				return err
+			},
+			want: audit.Event{Actor: "creator1", Action: audit.ActionEnded},
+		},
+		{
+			name: "delete",
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.DeletePoll(ctx, "creator1", auditPollID)
+				return err
+			},
+			want: audit.Event{Actor: "creator1", Action: audit.ActionDeleted, Detail: "Где обедаем?"},
+		},
+		{
+			name:   "winner",
+			mutate: func(p *models.Poll) { p.Closed = true },
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.PickWinner(ctx, "creator1", auditPollID, "", false)
+				return err
+			},
+			want: audit.Event{Actor: "creator1", Action: audit.ActionWinner, Detail: "u1"},
+		},
+		{
+			name: "transfer",
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.TransferPoll(ctx, "creator1", auditPollID, "@ivan")
+				return err
+			},
+			want: audit.Event{Actor: "creator1", Action: audit.ActionTransferred, Detail: "u2"},
+		},
+		{
+			name: "feature",
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.FeaturePoll(ctx, "admin1", auditPollID, true)
+				return err
+			},
+			want: audit.Event{Actor: "admin1", Action: audit.ActionFeatured},
+		},
+		{
+			name:   "unfeature",
+			mutate: func(p *models.Poll) { p.Featured = true },
+			run: func(ctx context.Context, s *PollServiceImpl) error {
+				_, err := s.FeaturePoll(ctx, "admin1", auditPollID, false)
+				return err
+			},
+			want: audit.Event{Actor: "admin1", Action: audit.ActionUnfeatured},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := auditTestPoll()
+			if tt.mutate != nil {
+				tt.mutate(&poll)
+			}
+			journal := &recordingJournal{}
+			s, _ := newAuditTestService(t, poll, journal)
+
+			require.NoError(t, tt.run(context.Background(), s))
+			require.Len(t, journal.events, 1)
+
+			got := journal.events[0]
+			assert.NotEmpty(t, got.ID)
+			if tt.newPoll {
+				assert.NotEqual(t, auditPollID, got.PollID)
+			} else {
+				assert.Equal(t, auditPollID, got.PollID)
+			}
+			assert.Equal(t, tt.want.Actor, got.Actor)
+			assert.Equal(t, tt.want.Action, got.Action)
+			assert.Equal(t, tt.want.Detail, got.Detail)
+			assert.Equal(t, auditNow, got.At)
+		})
+	}
+}
+
+// Тест проверяет, что отклонённые действия и повтор создания не попадают в журнал
+func TestAudit_RejectedActionsNotRecorded(t *testing.T) {
+	journal := &recordingJournal{}
+	s, _ := newAuditTestService(t, auditTestPoll(), journal)
+	ctx := context.Background()
+
+	_, err := s.AddVote(ctx, "u1", auditPollID, []string{"Суши"})
+	assert.Error(t, err)
+	_, err = s.EndPoll(ctx, "u2", auditPollID)
+	assert.Error(t, err)
+	_, err = s.DeletePoll(ctx, "u2", auditPollID)
+	assert.Error(t, err)
+	_, err = s.PickWinner(ctx, "creator1", auditPollID, "", false)
+	assert.Error(t, err)
+
+	// Повторная доставка команды create не создаёт опрос и не пишет событие
+	origin := WithOrigin(ctx, Origin{PostID: "post1", ChannelID: "c1"})
+	for i := 0; i < 2; i++ {
+		_, err = s.CreatePoll(origin, "creator1", "Где ужинаем?", []string{"Паста"}, CreateOptions{})
+		require.NoError(t, err)
+	}
+	assert.Len(t, journal.events, 1)
+}
+
+// Тест проверяет, что ошибка записи в журнал не отменяет действие пользователя
+func TestAudit_WriteFailureDoesNotFailAction(t *testing.T) {
+	journal := &recordingJournal{err: repository.ErrUnavailable}
+	s, repo := newAuditTestService(t, auditTestPoll(), journal)
+
+	_, err := s.AddVote(context.Background(), "u2", auditPollID, []string{"Суши"})
+	require.NoError(t, err)
+
+	poll, err := repo.GetPoll(context.Background(), auditPollID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, poll.Options["Суши"])
+}
+
+// Тест проверяет вывод журнала командой audit
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	journal := repository.NewMemoryAuditRepo()
+	s, _ := newAuditTestService(t, auditTestPoll(), journal)
+
+	_, err := s.AddVote(ctx, "u2", auditPollID, []string{"Суши"})
+	require.NoError(t, err)
+	_, err = s.TransferPoll(ctx, "creator1", auditPollID, "ivan")
+	require.NoError(t, err)
+	_, err = s.EndPoll(ctx, "u2", auditPollID)
+	require.NoError(t, err)
+	_, err = s.PickWinner(ctx, "u2", auditPollID, "", false)
+	require.NoError(t, err)
+	_, err = s.DeletePoll(ctx, "u2", auditPollID)
+	require.NoError(t, err)
+
+	// Журнал остаётся доступен после удаления опроса
+	got, err := s.AuditLog(ctx, auditPollID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "**Журнал опроса "+auditPollID+"**\n"+
+		"- `2025-03-01 12:30:00 UTC` @ivan проголосовал(а): Суши\n"+
+		"- `2025-03-01 12:30:00 UTC` @anna передал(а) опрос пользователю @ivan\n"+
+		"- `2025-03-01 12:30:00 UTC` @ivan завершил(а) опрос\n"+
+		"- `2025-03-01 12:30:00 UTC` @ivan выбрал(а) победителя @oleg\n"+
+		"- `2025-03-01 12:30:00 UTC` @ivan удалил(а) опрос: Где обедаем?\n", got)
+
+	got, err = s.AuditLog(ctx, auditPollID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "**Журнал опроса "+auditPollID+"**\n"+
+		"- `2025-03-01 12:30:00 UTC` @ivan удалил(а) опрос: Где обедаем?\n", got)
+
+	got, err = s.AuditLog(ctx, "00000000-0000-0000-0000-000000000000", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "В журнале опроса 00000000-0000-0000-0000-000000000000 нет событий", got)
+
+	_, err = s.AuditLog(ctx, "poll1", 0)
+	assert.EqualError(t, err, "неверный формат ID опроса")
+}
+
+// Тест проверяет событие неизвестного действия и отсутствие журнала
+func TestAuditLog_UnknownActionAndDisabled(t *testing.T) {
+	ctx := context.Background()
+	journal := repository.NewMemoryAuditRepo()
+	require.NoError(t, journal.AppendEvent(ctx, audit.Event{
+		ID: "e1", PollID: auditPollID, Actor: "creator1", Action: "reopened", Detail: "вручную", At: auditNow,
+	}))
+	s, _ := newAuditTestService(t, auditTestPoll(), journal)
+
+	got, err := s.AuditLog(ctx, auditPollID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "**Журнал опроса "+auditPollID+"**\n- `2025-03-01 12:30:00 UTC` @anna reopened: вручную\n", got)
+
+	polls := repository.NewMemoryPollRepo()
+	disabled := NewPollService(polls, repository.NewMemoryVoteRepo(polls), zerolog.Nop())
+	_, err = disabled.AuditLog(ctx, auditPollID, 0)
+	assert.EqualError(t, err, "журнал событий не настроен")
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+// channelLimit - сколько опросов за сутки можно создать в одном канале.
+type channelLimit struct {
+	perDay int
+	loc    *time.Location
+}
+
+// SetChannelPollLimit ограничивает число опросов, созданных в канале за
+// сутки; сутки начинаются в полночь по loc (nil - время сервера бота).
+// Лимит мягкий: удалённые опросы не считаются, а несколько экземпляров
+// бота могут одновременно превысить его на один-два опроса. Личные
+// сообщения не ограничиваются. perDay 0 снимает ограничение.
+func (s *PollServiceImpl) SetChannelPollLimit(perDay int, loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s.channelLimit = channelLimit{perDay: perDay, loc: loc}
+}
+
+// limitedIn сообщает, ограничено ли число опросов в канале сообщения.
+func (s *PollServiceImpl) limitedIn(ctx context.Context) bool {
+	origin := OriginFrom(ctx)
+	return s.channelLimit.perDay > 0 && origin.ChannelID != "" && !origin.Direct
+}
+
+// checkChannelLimit считает опросы канала, созданные с начала текущих суток,
+// и возвращает ошибку со временем сброса лимита, если места больше нет.
+func (s *PollServiceImpl) checkChannelLimit(ctx context.Context, channelID string) error {
+	dayStart, reset := dayWindow(s.now(), s.channelLimit.loc)
+	// CreatedAfter - строгая граница, а опрос, созданный ровно в полночь,
+	// относится к новым суткам
+	created, err := s.repo.CountPolls(ctx, repository.ListFilter{
+		ChannelID:    channelID,
+		CreatedAfter: dayStart.Add(-time.Nanosecond),
+	})
+	if err != nil {
+		return s.storageError(err, i18n.OpListPolls)
+	}
+	if created >= s.channelLimit.perDay {
+		return i18n.NewError(i18n.ChannelPollLimit, s.channelLimit.perDay, reset.Format(expiryTimeLayout))
+	}
+	return nil
+}
+
+// dayWindow возвращает начало суток, в которые попадает now, и начало
+// следующих по часовому поясу loc.
+func dayWindow(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	year, month, day := now.In(loc).Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	return start, time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Часовой пояс лимита в тестах: сутки начинаются на три часа раньше, чем в UTC
+var limitZone = time.FixedZone("MSK", 3*60*60)
+
+// Тест проверяет, какие опросы канала считаются в лимит за сутки
+func TestChannelPollLimit(t *testing.T) {
+	// 3 марта, 12:00 по MSK
+	noon := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		perDay   int
+		now      time.Time
+		existing []models.Poll
+		origin   Origin
+		wantErr  string
+	}{
+		{
+			name:     "below the limit",
+			perDay:   2,
+			now:      noon,
+			existing: []models.Poll{{ChannelID: "c1", CreatedAt: noon.Add(-time.Hour)}},
+			origin:   Origin{ChannelID: "c1"},
+		},
+		{
+			name:   "limit reached",
+			perDay: 2,
+			now:    noon,
+			existing: []models.Poll{
+				{ChannelID: "c1", CreatedAt: noon.Add(-2 * time.Hour)},
+				{ChannelID: "c1", CreatedAt: noon.Add(-time.Hour)},
+			},
+			origin:  Origin{ChannelID: "c1"},
+			wantErr: "в канале уже создано опросов за сутки: 2 - больше нельзя. Следующий опрос можно будет создать после 04.03.2025 00:00 MSK",
+		},
+		{
+			name:   "previous day is not counted",
+			perDay: 2,
+			// 00:30 4 марта по MSK, но ещё 3 марта по UTC
+			now: time.Date(2025, 3, 3, 21, 30, 0, 0, time.UTC),
+			existing: []models.Poll{
+				{ChannelID: "c1", CreatedAt: time.Date(2025, 3, 3, 20, 0, 0, 0, time.UTC)},
+				{ChannelID: "c1", CreatedAt: time.Date(2025, 3, 3, 20, 59, 59, 0, time.UTC)},
+			},
+			origin: Origin{ChannelID: "c1"},
+		},
+		{
+			name:   "poll created at midnight counts",
+			perDay: 2,
+			now:    time.Date(2025, 3, 3, 22, 0, 0, 0, time.UTC),
+			existing: []models.Poll{
+				{ChannelID: "c1", CreatedAt: time.Date(2025, 3, 3, 21, 0, 0, 0, time.UTC)},
+				{ChannelID: "c1", CreatedAt: time.Date(2025, 3, 3, 21, 30, 0, 0, time.UTC)},
+			},
+			origin:  Origin{ChannelID: "c1"},
+			wantErr: "в канале уже создано опросов за сутки: 2 - больше нельзя. Следующий опрос можно будет создать после 05.03.2025 00:00 MSK",
+		},
+		{
+			name:   "other channels are not counted",
+			perDay: 2,
+			now:    noon,
+			existing: []models.Poll{
+				{ChannelID: "c2", CreatedAt: noon.Add(-2 * time.Hour)},
+				{ChannelID: "c2", CreatedAt: noon.Add(-time.Hour)},
+			},
+			origin: Origin{ChannelID: "c1"},
+		},
+		{
+			name:   "direct messages are not limited",
+			perDay: 2,
+			now:    noon,
+			existing: []models.Poll{
+				{ChannelID: "c1", CreatedAt: noon.Add(-2 * time.Hour)},
+				{ChannelID: "c1", CreatedAt: noon.Add(-time.Hour)},
+			},
+			origin: Origin{ChannelID: "c1", Direct: true},
+		},
+		{
+			name: "limit disabled",
+			now:  noon,
+			existing: []models.Poll{
+				{ChannelID: "c1", CreatedAt: noon.Add(-time.Hour)},
+			},
+			origin: Origin{ChannelID: "c1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			ctx := context.Background()
+			for i, poll := range tt.existing {
+				poll.ID = fmt.Sprintf("existing%d", i)
+				poll.Creator = "user2"
+				poll.Question = "Обед?"
+				poll.Options = map[string]int{"A": 0}
+				require.NoError(t, repo.SavePoll(ctx, poll))
+			}
+
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.now = func() time.Time { return tt.now }
+			s.SetChannelPollLimit(tt.perDay, limitZone)
+
+			_, err := s.CreatePoll(WithOrigin(ctx, tt.origin), "user1", "Q", []string{"A"}, CreateOptions{})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// Тест проверяет, что лимит сбрасывается в полночь по часовому поясу лимита,
+// а удалённый опрос освобождает место
+func TestChannelPollLimit_Reset(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	clock := &expiryClock{now: time.Date(2025, 3, 3, 20, 0, 0, 0, time.UTC)}
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.now = clock.Now
+	s.SetChannelPollLimit(2, limitZone)
+
+	create := func(postID string) (CreatedPoll, error) {
+		ctx := WithOrigin(context.Background(), Origin{PostID: postID, ChannelID: "c1"})
+		return s.CreatePollWithID(ctx, "creator1", "Обед?", []string{"Пицца", "Суши"}, CreateOptions{})
+	}
+
+	first, err := create("post1")
+	require.NoError(t, err)
+	_, err = create("post2")
+	require.NoError(t, err)
+	_, err = create("post3")
+	assert.EqualError(t, err, "в канале уже создано опросов за сутки: 2 - больше нельзя. Следующий опрос можно будет создать после 04.03.2025 00:00 MSK")
+
+	// Повтор уже выполненной команды не создаёт опрос и не упирается в лимит
+	again, err := create("post1")
+	require.NoError(t, err)
+	assert.True(t, again.Duplicate)
+
+	require.NoError(t, repo.DeletePoll(context.Background(), first.ID))
+	_, err = create("post3")
+	assert.NoError(t, err, "удалённый опрос не считается")
+	_, err = create("post4")
+	assert.Error(t, err)
+
+	// Полночь по MSK: по UTC ещё те же сутки
+	clock.now = time.Date(2025, 3, 3, 21, 0, 0, 0, time.UTC)
+	_, err = create("post4")
+	assert.NoError(t, err)
+}
+
+// Тест проверяет границы суток в часовом поясе лимита
+func TestDayWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantStart time.Time
+		wantReset time.Time
+	}{
+		{
+			name:      "same day in both zones",
+			now:       time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 3, 3, 0, 0, 0, 0, limitZone),
+			wantReset: time.Date(2025, 3, 4, 0, 0, 0, 0, limitZone),
+		},
+		{
+			name:      "next day in the limit zone",
+			now:       time.Date(2025, 3, 3, 22, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 3, 4, 0, 0, 0, 0, limitZone),
+			wantReset: time.Date(2025, 3, 5, 0, 0, 0, 0, limitZone),
+		},
+		{
+			name:      "end of month",
+			now:       time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 3, 31, 0, 0, 0, 0, limitZone),
+			wantReset: time.Date(2025, 4, 1, 0, 0, 0, 0, limitZone),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, reset := dayWindow(tt.now, limitZone)
+			assert.True(t, tt.wantStart.Equal(start), "начало суток %s", start)
+			assert.True(t, tt.wantReset.Equal(reset), "сброс %s", reset)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"polling_bot/internal/i18n"
+)
+
+// CloneOverrides - что заменить в копии опроса; пустые поля берутся из исходного.
+type CloneOverrides struct {
+	Question string
+}
+
+// ClonePoll создаёт новый открытый опрос с вопросом, вариантами и флагами
+// исходного. Создателем копии становится userID, голоса не переносятся.
+// Копия проходит те же проверки, что и обычное создание опроса.
+func (s *PollServiceImpl) ClonePoll(ctx context.Context, userID, sourceID string, overrides CloneOverrides) (string, error) {
+	sourceID, err := normalizePollID(sourceID)
+	if err != nil {
+		return "", err
+	}
+	source, err := s.repo.GetPoll(ctx, sourceID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpGetPoll)
+	}
+	if err := s.checkChannel(ctx, source, userID, false); err != nil {
+		return "", err
+	}
+
+	question := source.Question
+	if overrides.Question != "" {
+		question = overrides.Question
+	}
+	opts := CreateOptions{
+		RestrictToChannel: source.RestrictToChannel,
+		Quorum:            source.Quorum,
+		Ranked:            source.Ranked,
+		NotifyVoters:      source.NotifyVoters,
+		Description:       source.Description,
+		Tags:              source.Tags,
+		AllowAbstain:      source.AllowAbstain,
+		MaxVotes:          source.MaxVotes,
+		VoteReceipts:      source.VoteReceipts,
+		NoSelfVote:        source.NoSelfVote,
+		MembersOnly:       source.MembersOnly,
+		// Копия получает свою соль: голоса двух опросов не сопоставить
+		Anonymous: source.Anonymous(),
+	}
+
+	created, err := s.createPoll(ctx, userID, question, joinOptionEmoji(source.OptionList()), opts, source.ID)
+	if err != nil {
+		return "", err
+	}
+	return created.Message + i18n.FromContext(ctx).T(i18n.ClonedFrom, source.ID), nil
+}
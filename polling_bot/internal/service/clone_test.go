@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+)
+
+const cloneSourceID = "123e4567-e89b-12d3-a456-426614174000"
+
+// Тест проверяет копирование опроса командой clone
+func TestClonePoll(t *testing.T) {
+	source := models.Poll{
+		ID:                cloneSourceID,
+		Creator:           "creator1",
+		Question:          "Где обедаем?",
+		Options:           map[string]int{"Суши": 1, "Пицца": 2},
+		Closed:            true,
+		ChannelID:         "c1",
+		RestrictToChannel: true,
+		Quorum:            5,
+		OptionOrder:       []string{"Суши", "Пицца"},
+		Winner:            "user1",
+	}
+
+	tests := []struct {
+		name         string
+		source       models.Poll
+		origin       service.Origin
+		overrides    service.CloneOverrides
+		wantQuestion string
+		wantOptions  []string
+		wantErr      string
+	}{
+		{
+			name:         "same question and options",
+			source:       source,
+			origin:       service.Origin{ChannelID: "c1"},
+			wantQuestion: "Где обедаем?",
+			wantOptions:  []string{"Суши", "Пицца"},
+		},
+		{
+			name:         "new question",
+			source:       source,
+			origin:       service.Origin{ChannelID: "c1"},
+			overrides:    service.CloneOverrides{Question: "Где обедаем в пятницу?"},
+			wantQuestion: "Где обедаем в пятницу?",
+			wantOptions:  []string{"Суши", "Пицца"},
+		},
+		{
+			name: "poll without option order",
+			source: func() models.Poll {
+				p := source
+				p.OptionOrder = nil
+				return p
+			}(),
+			origin:       service.Origin{ChannelID: "c1"},
+			wantQuestion: "Где обедаем?",
+			wantOptions:  []string{"Пицца", "Суши"},
+		},
+		{
+			name:      "question too long",
+			source:    source,
+			origin:    service.Origin{ChannelID: "c1"},
+			overrides: service.CloneOverrides{Question: strings.Repeat("a", 256)},
+			wantErr:   "вопрос слишком длинный",
+		},
+		{
+			name:    "channel-only poll from another channel",
+			source:  source,
+			origin:  service.Origin{ChannelID: "c2"},
+			wantErr: "этот опрос доступен только в своём канале",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			require.NoError(t, repo.SavePoll(context.Background(), tt.source))
+			votes := repository.NewMemoryVoteRepo(repo)
+			require.NoError(t, votes.ImportVotes(context.Background(), []models.Vote{
+				{PollID: cloneSourceID, UserID: "user1", Choices: []string{"Суши"}},
+				{PollID: cloneSourceID, UserID: "user2", Choices: []string{"Пицца"}},
+				{PollID: cloneSourceID, UserID: "user3", Choices: []string{"Пицца"}},
+			}))
+			s := service.NewPollService(repo, votes, zerolog.Nop())
+			ctx := service.WithOrigin(context.Background(), tt.origin)
+
+			result, err := s.ClonePoll(ctx, "user2", cloneSourceID, tt.overrides)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				polls, _, err := repo.ListPolls(context.Background(), repository.ListFilter{})
+				require.NoError(t, err)
+				assert.Len(t, polls, 1, "копия не создаётся")
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, result, "Копия опроса `"+cloneSourceID+"`")
+
+			polls, _, err := repo.ListPolls(context.Background(), repository.ListFilter{})
+			require.NoError(t, err)
+			require.Len(t, polls, 2)
+			var clone models.Poll
+			for _, p := range polls {
+				if p.ID != cloneSourceID {
+					clone = p
+				}
+			}
+			assert.Contains(t, result, clone.ID)
+			assert.Equal(t, "user2", clone.Creator)
+			assert.Equal(t, tt.wantQuestion, clone.Question)
+			assert.Equal(t, tt.wantOptions, clone.OptionOrder)
+			assert.Equal(t, map[string]int{"Суши": 0, "Пицца": 0}, clone.Options)
+			cloneVotes, err := votes.ListVotes(context.Background(), clone.ID)
+			require.NoError(t, err)
+			assert.Empty(t, cloneVotes)
+			assert.Empty(t, clone.Winner)
+			assert.False(t, clone.Closed)
+			assert.True(t, clone.RestrictToChannel)
+			assert.Equal(t, 5, clone.Quorum)
+		})
+	}
+}
+
+// Тест проверяет копирование несуществующего опроса
+func TestClonePoll_NotFound(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := service.NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+	_, err := s.ClonePoll(context.Background(), "user1", cloneSourceID, service.CloneOverrides{})
+	assert.ErrorIs(t, err, service.ErrPollNotFound)
+}
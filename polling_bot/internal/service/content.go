@@ -0,0 +1,121 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"polling_bot/internal/i18n"
+)
+
+// Упоминания, которые уведомляют весь канал; в итогах опроса они
+// срабатывали бы при каждом показе
+var channelMentionRegex = regexp.MustCompile(`(?i)(?:^|[^\pL\pN_.-])(@(?:here|channel|all))(?:$|[^\pL\pN_-])`)
+
+// Ссылка со схемой или адрес, начинающийся с www.
+var linkRegex = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)\S+`)
+
+// ContentRules - ограничения на текст вопроса и вариантов опроса.
+// Упоминания всего канала, управляющие и невидимые символы запрещены всегда.
+type ContentRules struct {
+	// Запретить ссылки в вопросе и вариантах
+	BlockLinks bool
+}
+
+// SetContentRules задаёт ограничения на текст вопроса и вариантов.
+func (s *PollServiceImpl) SetContentRules(rules ContentRules) {
+	s.content = rules
+}
+
+// CheckContent проверяет вопрос и варианты по правилам содержимого,
+// не создавая опрос: так расписание отклоняется сразу, а не при запуске.
+func (s *PollServiceImpl) CheckContent(question string, options []string) error {
+	_, _, err := s.normalizeContent(question, options)
+	return err
+}
+
+// normalizeContent приводит вопрос и варианты к NFC, очищает пробелы в
+// вариантах и проверяет их по правилам содержимого. После NFC «é» одной
+// буквой и «e» с отдельным ударением - один и тот же вариант, и проверка
+// повторов это видит.
+func (s *PollServiceImpl) normalizeContent(question string, options []string) (string, []string, error) {
+	question = norm.NFC.String(question)
+	// В вопросе допустимы переводы строк
+	if err := s.checkText(question, true); err != nil {
+		return "", nil, err
+	}
+	normalized := make([]string, len(options))
+	for i, option := range options {
+		normalized[i] = normalizeOptionText(option)
+		if normalized[i] == "" {
+			return "", nil, i18n.NewError(i18n.OptionBlank)
+		}
+		if err := s.checkText(normalized[i], false); err != nil {
+			return "", nil, err
+		}
+	}
+	return question, normalized, nil
+}
+
+// normalizeOptionText - текст варианта или выбора голосующего, каким
+// вариант хранится: в NFC, без управляющих символов, с пробелами,
+// табуляциями, переводами строк и неразрывными пробелами, схлопнутыми в
+// один пробел. Перевод строки из скопированного текста ломал бы итоги, а
+// за вариант с ним нельзя было бы проголосовать, набрав его.
+func normalizeOptionText(text string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range norm.NFC.String(text) {
+		switch {
+		case unicode.IsSpace(r):
+			space = sb.Len() > 0
+		case unicode.IsControl(r):
+			// Управляющий символ не виден и набрать его нельзя
+		default:
+			if space {
+				sb.WriteByte(' ')
+				space = false
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// checkText проверяет один вопрос или вариант.
+func (s *PollServiceImpl) checkText(text string, multiline bool) error {
+	for _, r := range text {
+		if invisibleRune(r) {
+			return i18n.NewError(i18n.InvisibleInPoll, text)
+		}
+		if unicode.IsControl(r) && !(multiline && r == '\n') {
+			return i18n.NewError(i18n.ControlCharInPoll, text)
+		}
+	}
+	if m := channelMentionRegex.FindStringSubmatch(text); m != nil {
+		return i18n.NewError(i18n.MentionInPoll, m[1])
+	}
+	if s.content.BlockLinks {
+		if link := linkRegex.FindString(text); link != "" {
+			return i18n.NewError(i18n.LinkInPoll, link)
+		}
+	}
+	return nil
+}
+
+// invisibleRune сообщает, что символ не виден в тексте: с ним два варианта
+// выглядят одинаково, хотя различаются. Соединитель U+200D разрешён: из него
+// собираются эмодзи.
+func invisibleRune(r rune) bool {
+	switch {
+	case r == '\u00ad', r == '\u180e', r == '\ufeff':
+		return true
+	case r >= '\u200b' && r <= '\u200f' && r != '\u200d':
+		return true
+	case r >= '\u202a' && r <= '\u202e', r >= '\u2060' && r <= '\u206f':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет правила содержимого вопроса и вариантов: упоминания всего
+// канала, ссылки при BlockLinks, управляющие и невидимые символы; перевод
+// строки в варианте не ошибка, его заменяет пробел
+func TestCheckContent(t *testing.T) {
+	tests := []struct {
+		name       string
+		blockLinks bool
+		question   string
+		options    []string
+		wantKey    i18n.Key
+	}{
+		{name: "plain", question: "Где обедаем?", options: []string{"Пицца", "Суши"}},
+		{name: "here in option", question: "Где обедаем?", options: []string{"@here", "Суши"}, wantKey: i18n.MentionInPoll},
+		{name: "channel in question", question: "Привет, @Channel! Где обедаем?", options: []string{"Пицца"}, wantKey: i18n.MentionInPoll},
+		{name: "all before punctuation", question: "Где обедаем?", options: []string{"Спросить @all."}, wantKey: i18n.MentionInPoll},
+		{name: "email is not a mention", question: "Куда писать?", options: []string{"team@here.example", "@heretic"}},
+		{name: "link allowed", question: "Где обедаем?", options: []string{"http://phishing.example"}},
+		{name: "link blocked", blockLinks: true, question: "Где обедаем?", options: []string{"http://phishing.example"}, wantKey: i18n.LinkInPoll},
+		{name: "www link blocked", blockLinks: true, question: "Смотрим www.example.com?", options: []string{"Да"}, wantKey: i18n.LinkInPoll},
+		{name: "newline in question", question: "Где обедаем?\nВ пятницу", options: []string{"Пицца"}},
+		{name: "newline in option", question: "Где обедаем?", options: []string{"Пиц\nца"}},
+		{name: "blank option", question: "Где обедаем?", options: []string{"Пицца", " \t\r\n"}, wantKey: i18n.OptionBlank},
+		{name: "bell", question: "Где\a обедаем?", options: []string{"Пицца"}, wantKey: i18n.ControlCharInPoll},
+		{name: "zero-width space", question: "Где обедаем?", options: []string{"Пи\u200bцца"}, wantKey: i18n.InvisibleInPoll},
+		{name: "bidi override", question: "Где обедаем?", options: []string{"\u202eащциП"}, wantKey: i18n.InvisibleInPoll},
+		{name: "emoji with joiner", question: "Кто идёт?", options: []string{"\U0001F468\u200d\U0001F469\u200d\U0001F467"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.SetContentRules(ContentRules{BlockLinks: tt.blockLinks})
+
+			err := s.CheckContent(tt.question, tt.options)
+			if tt.wantKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var localized *i18n.Error
+			require.ErrorAs(t, err, &localized)
+			assert.Equal(t, tt.wantKey, localized.Key)
+		})
+	}
+}
+
+// Тест проверяет, что варианты, которые выглядят одинаково, не создают
+// опрос: одинаковые после NFC отклоняются как повтор, с невидимым символом -
+// как невидимые символы. Голос в другой форме Unicode засчитывается
+func TestCreatePoll_NormalizedOptions(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	tests := []struct {
+		name    string
+		options []string
+		wantErr string
+	}{
+		{name: "composed and decomposed", options: []string{"Café", "Cafe\u0301"}, wantErr: "все опции в голосовании должны быть уникальными"},
+		{name: "zero-width duplicate", options: []string{"Пицца", "Пиц\u200bца"}, wantErr: "в «Пиц\u200bца» есть невидимые символы, из-за которых опции выглядят одинаково"},
+		{name: "byte order mark duplicate", options: []string{"Суши", "\ufeffСуши"}, wantErr: "в «\ufeffСуши» есть невидимые символы, из-за которых опции выглядят одинаково"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+			_, err := s.CreatePoll(ctx, "user1", "Где обедаем?", tt.options, CreateOptions{})
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, i18n.FromContext(ctx).Error(err))
+		})
+	}
+
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	created, err := s.CreatePollWithID(ctx, "user1", "Где обедаем?", []string{"Cafe\u0301", "Суши"}, CreateOptions{})
+	require.NoError(t, err)
+	poll, err := repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Café", "Суши"}, poll.OptionOrder, "варианты хранятся в NFC")
+
+	_, err = s.AddVote(ctx, "user2", created.ID, []string{"Cafe\u0301"})
+	require.NoError(t, err)
+	poll, err = repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, poll.Options["Café"])
+}
+
+// Тест проверяет очистку текста варианта: пробелы любого вида схлопываются
+// в один, управляющие символы убираются
+func TestNormalizeOptionText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain", text: "Суши бар", want: "Суши бар"},
+		{name: "tabs", text: "Суши\t\tбар", want: "Суши бар"},
+		{name: "CRLF", text: "Суши\r\nбар\r\n", want: "Суши бар"},
+		{name: "non-breaking space", text: "Суши\u00a0бар", want: "Суши бар"},
+		{name: "surrounding whitespace", text: "  \tСуши   бар \n", want: "Суши бар"},
+		{name: "control characters", text: "Су\aши\x00 бар\x1b", want: "Суши бар"},
+		{name: "decomposed", text: "Cafe\u0301", want: "Café"},
+		{name: "whitespace only", text: " \t\u00a0\r\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeOptionText(tt.text))
+		})
+	}
+}
+
+// Тест проверяет, что вариант хранится очищенным, варианты, различающиеся
+// только пробелами, - повтор, а голос с табуляцией или неразрывным пробелом
+// находит вариант
+func TestCreatePoll_OptionWhitespace(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	for _, options := range [][]string{
+		{"Суши бар", "Суши\tбар"},
+		{"Суши бар", "Суши\r\nбар"},
+		{"Суши бар", " Суши\u00a0\u00a0бар "},
+	} {
+		repo := repository.NewMemoryPollRepo()
+		s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+		_, err := s.CreatePoll(ctx, "user1", "Где обедаем?", options, CreateOptions{})
+		assert.EqualError(t, err, "все опции в голосовании должны быть уникальными", "%q", options)
+	}
+
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	created, err := s.CreatePollWithID(ctx, "user1", "Где обедаем?", []string{"Суши\r\n бар", "Пицца"}, CreateOptions{})
+	require.NoError(t, err)
+	poll, err := repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Суши бар", "Пицца"}, poll.OptionOrder)
+
+	for i, choice := range []string{"Суши\tбар", "суши\u00a0бар", "Суши\nбар"} {
+		_, err = s.AddVote(ctx, fmt.Sprintf("voter%d", i), created.ID, []string{choice})
+		require.NoError(t, err, "%q", choice)
+	}
+	poll, err = repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, poll.Options["Суши бар"])
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"polling_bot/internal/i18n"
+)
+
+// cronSpec - разобранное cron-выражение из пяти полей. Каждое поле - битовая
+// маска допустимых значений.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	// Как в cron, если ограничены и день месяца, и день недели, подходит
+	// любой из них; "*" в одном из полей оставляет только другое
+	domAny, dowAny bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{0, 59}, // минута
+	{0, 23}, // час
+	{1, 31}, // день месяца
+	{1, 12}, // месяц
+	{0, 7},  // день недели, 0 и 7 - воскресенье
+}
+
+// parseCron разбирает выражение вида "0 10 * * 1". Поле - "*" или список
+// через запятую из чисел и диапазонов "a-b", к "*" и диапазону можно
+// добавить шаг "/n".
+func parseCron(expr string) (cronSpec, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return cronSpec{}, i18n.NewError(i18n.CronFieldCount)
+	}
+
+	var masks [5]uint64
+	for i, part := range parts {
+		mask, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return cronSpec{}, err
+		}
+		masks[i] = mask
+	}
+	// Воскресенье хранится как 0
+	if masks[4]&(1<<7) != 0 {
+		masks[4] = masks[4]&^(1<<7) | 1
+	}
+
+	return cronSpec{
+		minute: masks[0],
+		hour:   masks[1],
+		dom:    masks[2],
+		month:  masks[3],
+		dow:    masks[4],
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	invalid := i18n.NewError(i18n.CronFieldInvalid, field, bounds.min, bounds.max)
+
+	var mask uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, invalid
+			}
+			step = n
+		}
+
+		lo, hi := bounds.min, bounds.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			a, err := strconv.Atoi(from)
+			if err != nil {
+				return 0, invalid
+			}
+			lo, hi = a, a
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, invalid
+				}
+			} else if hasStep {
+				// "5/15" - с 5 до конца диапазона с шагом 15
+				hi = bounds.max
+			}
+		}
+		if lo < bounds.min || hi > bounds.max || lo > hi {
+			return 0, invalid
+		}
+
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << v
+		}
+	}
+	return mask, nil
+}
+
+// matches сообщает, попадает ли минута t в расписание. Время
+// сравнивается в часовом поясе t.
+func (c cronSpec) matches(t time.Time) bool {
+	return c.minute&(1<<t.Minute()) != 0 &&
+		c.hour&(1<<t.Hour()) != 0 &&
+		c.dayMatches(t)
+}
+
+func (c cronSpec) dayMatches(t time.Time) bool {
+	if c.month&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Дальше этого срока следующий запуск не ищется: 29 февраля по понедельникам
+// встречается раз в несколько лет, а выражения вроде "0 0 30 2 *" не срабатывают никогда
+const cronSearchYears = 8
+
+// next возвращает первую минуту расписания после after; false, если
+// расписание не срабатывает в обозримом будущем.
+func (c cronSpec) next(after time.Time) (time.Time, bool) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case !c.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
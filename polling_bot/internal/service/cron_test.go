@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 2025-03-03 - понедельник
+var cronBase = time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC)
+
+// Тест проверяет поиск следующего срабатывания cron-выражения
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{name: "every minute", expr: "* * * * *", want: time.Date(2025, 3, 3, 9, 31, 0, 0, time.UTC)},
+		{name: "monday at ten", expr: "0 10 * * 1", want: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)},
+		{name: "next week", expr: "0 9 * * 1", want: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 12 * * 7", want: time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)},
+		{name: "step", expr: "*/20 * * * *", want: time.Date(2025, 3, 3, 9, 40, 0, 0, time.UTC)},
+		{name: "range with step", expr: "0 8-18/4 * * *", want: time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)},
+		{name: "list", expr: "15,45 9 * * *", want: time.Date(2025, 3, 3, 9, 45, 0, 0, time.UTC)},
+		{name: "day of month", expr: "0 0 1 * *", want: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// Ограничены оба поля дня: подходит любое из них
+		{name: "day of month or weekday", expr: "0 0 15 * 5", want: time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", expr: "0 0 29 2 *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseCron(tt.expr)
+			require.NoError(t, err)
+			next, ok := spec.next(cronBase)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, next)
+			assert.True(t, spec.matches(next))
+		})
+	}
+}
+
+// Тест проверяет сообщения о неверных cron-выражениях
+func TestParseCron_Errors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "0 10 * *", wantErr: "в cron-выражении должно быть 5 полей: минута, час, день месяца, месяц, день недели"},
+		{expr: "60 10 * * 1", wantErr: "неверное поле cron-выражения '60': допустимы значения от 0 до 59"},
+		{expr: "0 10 * * mon", wantErr: "неверное поле cron-выражения 'mon': допустимы значения от 0 до 7"},
+		{expr: "0 18-8 * * *", wantErr: "неверное поле cron-выражения '18-8': допустимы значения от 0 до 23"},
+		{expr: "*/0 * * * *", wantErr: "неверное поле cron-выражения '*/0': допустимы значения от 0 до 59"},
+		{expr: "0 0 0 * *", wantErr: "неверное поле cron-выражения '0': допустимы значения от 1 до 31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := parseCron(tt.expr)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+// Тест проверяет выражение, которое никогда не срабатывает
+func TestCronNext_Never(t *testing.T) {
+	spec, err := parseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	_, ok := spec.next(cronBase)
+	assert.False(t, ok)
+}
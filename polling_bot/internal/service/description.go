@@ -0,0 +1,23 @@
+package service
+
+import (
+	"unicode/utf8"
+
+	"polling_bot/internal/i18n"
+)
+
+// Сколько символов по умолчанию может занимать пояснение к опросу
+const defaultMaxDescriptionLength = 1000
+
+// SetMaxDescriptionLength задаёт, сколько символов может занимать
+// пояснение к опросу.
+func (s *PollServiceImpl) SetMaxDescriptionLength(n int) {
+	s.maxDescription = n
+}
+
+func (s *PollServiceImpl) validateDescription(description string) error {
+	if utf8.RuneCountInString(description) > s.maxDescription {
+		return i18n.NewError(i18n.DescriptionTooLong, s.maxDescription)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет проверку длины пояснения в символах и её настройку
+func TestCreatePoll_DescriptionLength(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		description string
+		wantErr     string
+	}{
+		{name: "default limit", description: strings.Repeat("я", defaultMaxDescriptionLength)},
+		{name: "over default limit", description: strings.Repeat("я", defaultMaxDescriptionLength+1),
+			wantErr: "пояснение длиннее 1000 символов"},
+		{name: "configured limit", limit: 10, description: "Одиннадцать",
+			wantErr: "пояснение длиннее 10 символов"},
+		{name: "surrounding spaces are not counted", limit: 10, description: "  Пояснение  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			if tt.limit > 0 {
+				s.SetMaxDescriptionLength(tt.limit)
+			}
+
+			_, err := s.CreatePoll(context.Background(), "creator1", "Где обедаем?", []string{"Пицца"},
+				CreateOptions{Description: tt.description})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// Тест проверяет, что многострочное пояснение сохраняется и выводится
+// цитатой под вопросом с экранированной разметкой при создании, в итогах
+// и в копии опроса
+func TestCreatePoll_Description(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+	created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"},
+		CreateOptions{Description: " Выбираем на *пятницу*\n\n# не заголовок\n"})
+	require.NoError(t, err)
+	quoted := "> Выбираем на \\*пятницу\\*\n>\n> \\# не заголовок\n"
+	assert.Equal(t, "Голосование создано успешно! ID: `"+created.ID+"`\nВопрос: Где обедаем?\n"+quoted+
+		"Варианты:\n1. Пицца\n2. Суши\n", created.Message)
+
+	stored, err := repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Выбираем на *пятницу*\n\n# не заголовок", stored.Description, "хранится текст без экранирования")
+
+	results, err := s.GetResults(ctx, "creator1", created.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(results, "**Результаты опроса "+created.ID+"**\nГде обедаем?\n"+quoted), results)
+
+	clone, err := s.ClonePoll(ctx, "creator1", created.ID, CloneOverrides{})
+	require.NoError(t, err)
+	assert.Contains(t, clone, "Вопрос: Где обедаем?\n"+quoted)
+}
+
+// Тест проверяет, что опрос без пояснения выводится как раньше
+func TestCreatePoll_WithoutDescription(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+	created, err := s.CreatePollWithID(context.Background(), "creator1", "Где обедаем?", []string{"Пицца"}, CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Голосование создано успешно! ID: `"+created.ID+"`\nВопрос: Где обедаем?\nВарианты:\n1. Пицца\n", created.Message)
+}
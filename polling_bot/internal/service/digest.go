@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+// Сколько опросов показывает сводка; об остальных - одной строкой
+const maxDigestPolls = 20
+
+// Час сводки по умолчанию
+const defaultDigestHour = 9
+
+type DigestService interface {
+	// SetDigest включает или выключает сводку в канале, из которого
+	// пришла команда.
+	SetDigest(ctx context.Context, userID string, enabled bool) (string, error)
+}
+
+// Digest - сводка открытых опросов для публикации в канале.
+type Digest struct {
+	ChannelID string
+	Message   string
+}
+
+type DigestServiceImpl struct {
+	settings repository.ChannelSettingsRepository
+	polls    repository.PollRepository
+	logger   zerolog.Logger
+	now      func() time.Time
+	hour     int
+}
+
+// NewDigestService создаёт сервис сводок; опросы читаются напрямую из
+// хранилища, как при проверке сроков.
+func NewDigestService(settings repository.ChannelSettingsRepository, polls repository.PollRepository, logger zerolog.Logger) *DigestServiceImpl {
+	return &DigestServiceImpl{settings: settings, polls: polls, logger: logger, now: time.Now, hour: defaultDigestHour}
+}
+
+// SetHour задаёт час, в который RunDigests готовит сводки; время - то же,
+// что передаёт планировщик бота.
+func (s *DigestServiceImpl) SetHour(hour int) {
+	s.hour = hour
+}
+
+func (s *DigestServiceImpl) SetDigest(ctx context.Context, userID string, enabled bool) (string, error) {
+	origin := OriginFrom(ctx)
+	if origin.ChannelID == "" || origin.Direct {
+		return "", i18n.NewError(i18n.DigestNoChannel)
+	}
+
+	settings, err := s.settings.GetChannelSettings(ctx, origin.ChannelID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		settings = models.ChannelSettings{ChannelID: origin.ChannelID}
+	case err != nil:
+		return "", s.storageError(err, i18n.OpSaveDigest)
+	}
+	settings.Digest = enabled
+	settings.UpdatedBy = userID
+	settings.UpdatedAt = s.now().UTC()
+	if err := s.settings.SaveChannelSettings(ctx, settings); err != nil {
+		return "", s.storageError(err, i18n.OpSaveDigest)
+	}
+
+	loc := i18n.FromContext(ctx)
+	if !enabled {
+		return loc.T(i18n.DigestDisabled), nil
+	}
+	return loc.T(i18n.DigestEnabled, s.hour), nil
+}
+
+// RunDigests готовит сводки каналов со включённой сводкой, если at - начало
+// часа сводки, и возвращает их для публикации. Как и расписания, сводка
+// за время, пока бот не работал, не навёрстывается. Каналы без открытых
+// опросов сводку не получают; ошибки отдельных каналов только логируются.
+func (s *DigestServiceImpl) RunDigests(ctx context.Context, at time.Time) []Digest {
+	if at.Hour() != s.hour || at.Minute() != 0 {
+		return nil
+	}
+	channels, err := s.settings.ListDigestChannels(ctx)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Не удалось получить каналы со сводкой опросов")
+		return nil
+	}
+
+	loc := i18n.FromContext(ctx)
+	var digests []Digest
+	for _, channelID := range channels {
+		polls, total, err := s.openPolls(ctx, channelID, at)
+		if err != nil {
+			s.logger.Error().Err(err).Str("channel_id", channelID).Msg("Не удалось получить открытые опросы для сводки")
+			continue
+		}
+		if total == 0 {
+			continue
+		}
+		digests = append(digests, Digest{ChannelID: channelID, Message: renderDigest(loc, polls, total, at)})
+	}
+	return digests
+}
+
+// openPolls возвращает первые maxDigestPolls открытых опросов канала и
+// сколько их всего. Опросы, срок которых наступил, но которые ещё не
+// закрыты, в сводку не попадают.
+func (s *DigestServiceImpl) openPolls(ctx context.Context, channelID string, at time.Time) ([]models.Poll, int, error) {
+	open := false
+	filter := repository.ListFilter{ChannelID: channelID, Closed: &open, Limit: repository.MaxListLimit}
+
+	var polls []models.Poll
+	total := 0
+	for {
+		page, cursor, err := s.polls.ListPolls(ctx, filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, poll := range page {
+			if expired(poll, at) {
+				continue
+			}
+			total++
+			if len(polls) < maxDigestPolls {
+				polls = append(polls, poll)
+			}
+		}
+		if cursor == "" {
+			return polls, total, nil
+		}
+		filter.Cursor = cursor
+	}
+}
+
+// storageError - аналог PollServiceImpl.storageError для настроек каналов.
+func (s *DigestServiceImpl) storageError(err error, op i18n.Key) error {
+	if errors.Is(err, repository.ErrUnavailable) {
+		s.logger.Error().Err(err).Str("operation", string(op)).Msg("Хранилище недоступно")
+		return ErrServiceUnavailable
+	}
+	return i18n.Wrap(err, op)
+}
+
+// renderDigest - сводка открытых опросов канала на момент at.
+func renderDigest(loc *i18n.Localizer, polls []models.Poll, total int, at time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.DigestHeader))
+	for _, poll := range polls {
+		votes := 0
+		for _, count := range poll.Options {
+			votes += count
+		}
+		question := strings.Join(strings.Fields(poll.Question), " ")
+		if poll.ExpiresAt.IsZero() {
+			sb.WriteString(loc.T(i18n.DigestLine, poll.ID, question, votes))
+			continue
+		}
+		sb.WriteString(loc.T(i18n.DigestLineExpires, poll.ID, question, votes, formatRemaining(poll.ExpiresAt.Sub(at))))
+	}
+	if total > len(polls) {
+		sb.WriteString(loc.T(i18n.DigestMore, total-len(polls)))
+	}
+	return sb.String()
+}
+
+// formatRemaining показывает время до закрытия опроса: 2d4h, 4h12m, 7m.
+func formatRemaining(d time.Duration) string {
+	if d < 24*time.Hour {
+		return formatUptime(d)
+	}
+	hours := int(d / time.Hour)
+	return fmt.Sprintf("%dd%dh", hours/24, hours%24)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+type digestFixture struct {
+	digests  *DigestServiceImpl
+	settings *repository.MemoryChannelSettingsRepo
+	polls    *repository.MemoryPollRepo
+}
+
+func newDigestFixture(t *testing.T, now time.Time) digestFixture {
+	t.Helper()
+	settings := repository.NewMemoryChannelSettingsRepo()
+	polls := repository.NewMemoryPollRepo()
+	s := NewDigestService(settings, polls, zerolog.Nop())
+	s.now = func() time.Time { return now }
+	return digestFixture{digests: s, settings: settings, polls: polls}
+}
+
+func (f digestFixture) enable(t *testing.T, channelIDs ...string) {
+	t.Helper()
+	for _, channelID := range channelIDs {
+		require.NoError(t, f.settings.SaveChannelSettings(context.Background(), models.ChannelSettings{ChannelID: channelID, Digest: true}))
+	}
+}
+
+func (f digestFixture) savePoll(t *testing.T, poll models.Poll) {
+	t.Helper()
+	require.NoError(t, f.polls.SavePoll(context.Background(), poll))
+}
+
+// Тест проверяет включение и выключение сводки в канале
+func TestSetDigest(t *testing.T) {
+	now := time.Date(2025, 3, 3, 15, 0, 0, 0, time.UTC)
+	f := newDigestFixture(t, now)
+	f.digests.SetHour(8)
+	ctx := WithOrigin(context.Background(), Origin{ChannelID: "c1"})
+
+	msg, err := f.digests.SetDigest(ctx, "user1", true)
+	require.NoError(t, err)
+	assert.Equal(t, "Сводка открытых опросов включена: бот будет публиковать её в этом канале каждый день в 08:00", msg)
+	settings, err := f.settings.GetChannelSettings(context.Background(), "c1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ChannelSettings{ChannelID: "c1", Digest: true, UpdatedBy: "user1", UpdatedAt: now}, settings)
+
+	msg, err = f.digests.SetDigest(ctx, "user2", false)
+	require.NoError(t, err)
+	assert.Equal(t, "Сводка открытых опросов в этом канале выключена", msg)
+	channels, err := f.settings.ListDigestChannels(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, channels)
+}
+
+// Тест проверяет, что сводку нельзя включить вне канала
+func TestSetDigest_NoChannel(t *testing.T) {
+	f := newDigestFixture(t, time.Now())
+
+	for name, ctx := range map[string]context.Context{
+		"no origin": context.Background(),
+		"direct":    WithOrigin(context.Background(), Origin{ChannelID: "dm1", Direct: true}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := f.digests.SetDigest(ctx, "user1", true)
+			assert.EqualError(t, err, "сводку опросов можно включить только в канале")
+		})
+	}
+}
+
+// Тест проверяет, что сводки готовятся только в начале часа сводки и
+// только для каналов со включённой сводкой и открытыми опросами
+func TestRunDigests(t *testing.T) {
+	at := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	f := newDigestFixture(t, at)
+	f.enable(t, "c1", "c2")
+	f.savePoll(t, models.Poll{ID: "p1", ChannelID: "c1", Question: "Где\nобедаем?", Options: map[string]int{"Пицца": 2, "Суши": 1}})
+	f.savePoll(t, models.Poll{ID: "p2", ChannelID: "c1", Question: "Релиз?", Options: map[string]int{"Да": 0},
+		ExpiresAt: at.Add(26*time.Hour + 30*time.Minute)})
+	// Закрытые и просроченные опросы в сводку не попадают
+	f.savePoll(t, models.Poll{ID: "p3", ChannelID: "c1", Question: "Закрыт", Options: map[string]int{"Да": 1}, Closed: true})
+	f.savePoll(t, models.Poll{ID: "p4", ChannelID: "c1", Question: "Истёк", Options: map[string]int{"Да": 1}, ExpiresAt: at.Add(-time.Minute)})
+	// Свежий опрос канала без сводки и канал со сводкой без открытых опросов
+	f.savePoll(t, models.Poll{ID: "p5", ChannelID: "c3", Question: "Чужой", Options: map[string]int{"Да": 1}})
+	f.savePoll(t, models.Poll{ID: "p6", ChannelID: "c2", Question: "Старый", Options: map[string]int{"Да": 1}, Closed: true})
+
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	assert.Nil(t, f.digests.RunDigests(ctx, at.Add(time.Minute)))
+	assert.Nil(t, f.digests.RunDigests(ctx, at.Add(time.Hour)))
+
+	digests := f.digests.RunDigests(ctx, at)
+	require.Len(t, digests, 1)
+	assert.Equal(t, "c1", digests[0].ChannelID)
+	assert.Equal(t, "**Открытые опросы канала**\n"+
+		"- `p1` Где обедаем?, голосов: 3\n"+
+		"- `p2` Релиз?, голосов: 0, до закрытия: 1d2h\n", digests[0].Message)
+}
+
+// Тест проверяет, что сводка учитывает настроенный час и время планировщика
+func TestRunDigests_Hour(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	at := time.Date(2025, 3, 3, 18, 0, 0, 0, msk)
+	f := newDigestFixture(t, at)
+	f.digests.SetHour(18)
+	f.enable(t, "c1")
+	f.savePoll(t, models.Poll{ID: "p1", ChannelID: "c1", Question: "Q", Options: map[string]int{"A": 1}})
+
+	assert.Len(t, f.digests.RunDigests(context.Background(), at), 1)
+	assert.Nil(t, f.digests.RunDigests(context.Background(), at.UTC()))
+}
+
+// Тест проверяет сводку с большим числом опросов
+func TestRenderDigest(t *testing.T) {
+	at := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	var polls []models.Poll
+	for i := 1; i <= maxDigestPolls; i++ {
+		poll := models.Poll{ID: fmt.Sprintf("p%02d", i), Question: fmt.Sprintf("Вопрос %d", i), Options: map[string]int{"Да": i, "Нет": 1}}
+		if i%2 == 0 {
+			poll.ExpiresAt = at.Add(time.Duration(i) * 47 * time.Minute)
+		}
+		polls = append(polls, poll)
+	}
+
+	forEachLang(t, "digest", func(loc *i18n.Localizer) string { return renderDigest(loc, polls[:2], 2, at) })
+	forEachLang(t, "digest_more", func(loc *i18n.Localizer) string { return renderDigest(loc, polls, 27, at) })
+}
+
+// Тест проверяет формат времени до закрытия опроса
+func TestFormatRemaining(t *testing.T) {
+	tests := map[time.Duration]string{
+		7*time.Minute + 30*time.Second: "7m",
+		4*time.Hour + 12*time.Minute:   "4h12m",
+		52 * time.Hour:                 "2d4h",
+	}
+	for d, want := range tests {
+		assert.Equal(t, want, formatRemaining(d))
+	}
+}
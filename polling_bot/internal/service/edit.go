@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// PostEditor меняет текст опубликованного сообщения. Если его реализует
+// Pinner, после исправления вопроса обновляется и закреплённое сообщение
+// опроса.
+type PostEditor interface {
+	EditPost(ctx context.Context, postID, message string) error
+}
+
+// EditQuestion исправляет вопрос открытого опроса, пока за него никто не
+// проголосовал: иначе вопрос можно было бы подменить после голосов.
+// Новый вопрос проверяется по тем же правилам, что при создании.
+func (s *PollServiceImpl) EditQuestion(ctx context.Context, userID, pollID, question string) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	question, _, err = s.normalizeContent(question, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(question) > maxQuestionLength {
+		return "", i18n.NewError(i18n.QuestionTooLong)
+	}
+
+	// Голос в этом процессе не пройдёт между проверкой и записью вопроса
+	unlock := s.voteLocks.lock(pollID)
+	defer unlock()
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpGetPoll)
+	}
+	if poll.Creator != userID {
+		return "", i18n.NewError(i18n.OnlyCreatorEdit)
+	}
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return "", err
+	}
+	if poll.Closed {
+		return "", i18n.NewError(i18n.EditPollClosed)
+	}
+	if voters := poll.VoterCount(); voters > 0 {
+		return "", i18n.NewError(i18n.EditHasVotes, poll.ID, voters)
+	}
+
+	if err := s.repo.UpdateSettings(ctx, pollID, repository.SettingsUpdate{Question: &question}); err != nil {
+		return "", s.storageError(err, i18n.OpEditQuestion)
+	}
+	s.record(ctx, pollID, userID, audit.ActionQuestionEdited, question)
+	poll.Question = question
+
+	loc := i18n.FromContext(ctx)
+	s.refreshPinned(ctx, loc, poll)
+	return loc.T(i18n.QuestionEdited, poll.ID, question), nil
+}
+
+// refreshPinned обновляет закреплённое сообщение опроса. Вопрос уже
+// исправлен, поэтому сбой только логируется.
+func (s *PollServiceImpl) refreshPinned(ctx context.Context, loc *i18n.Localizer, poll models.Poll) {
+	editor, ok := s.pinner.(PostEditor)
+	if poll.PinnedPostID == "" || !ok {
+		return
+	}
+	// Сообщение показывало веса и список участников по именам, как их ввёл
+	// создатель
+	var weights map[string]int
+	if poll.Weighted() {
+		weights = make(map[string]int, len(poll.Weights))
+		for userID, weight := range poll.Weights {
+			weights[strings.TrimPrefix(s.username(ctx, userID), "@")] = weight
+		}
+	}
+	if err := editor.EditPost(ctx, poll.PinnedPostID, renderCreated(loc, poll, weights, s.voterNames(ctx, poll))); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось обновить закреплённое сообщение опроса")
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+// editingPinner публикует опрос сообщением post1 и запоминает текст
+// сообщений.
+type editingPinner struct {
+	posts map[string]string
+}
+
+func (p *editingPinner) Publish(ctx context.Context, channelID, message string) (string, error) {
+	postID := "post1"
+	p.posts[postID] = message
+	return postID, nil
+}
+
+func (p *editingPinner) PinPost(ctx context.Context, postID string) error   { return nil }
+func (p *editingPinner) UnpinPost(ctx context.Context, postID string) error { return nil }
+
+func (p *editingPinner) EditPost(ctx context.Context, postID, message string) error {
+	p.posts[postID] = message
+	return nil
+}
+
+// Тест проверяет исправление вопроса: только создателем, только в
+// открытом опросе без голосов и по правилам вопроса при создании
+func TestEditQuestion(t *testing.T) {
+	ctx := WithOrigin(i18n.WithLocalizer(context.Background(), i18n.New("ru")), Origin{ChannelID: "channel1"})
+	newService := func(t *testing.T) (*PollServiceImpl, string) {
+		repo := repository.NewMemoryPollRepo()
+		s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+		created, err := s.CreatePollWithID(ctx, "creator1", "Где обдеаем?", []string{"Пицца", "Суши"}, CreateOptions{AllowAbstain: true})
+		require.NoError(t, err)
+		return s, created.ID
+	}
+
+	tests := []struct {
+		name     string
+		prepare  func(t *testing.T, s *PollServiceImpl, pollID string)
+		userID   string
+		question string
+		wantErr  string
+	}{
+		{name: "creator without votes", userID: "creator1", question: "Где обедаем?"},
+		{name: "not the creator", userID: "user1", question: "Где обедаем?", wantErr: "только создатель может исправить вопрос опроса"},
+		{
+			name: "after a vote",
+			prepare: func(t *testing.T, s *PollServiceImpl, pollID string) {
+				_, err := s.AddVote(ctx, "user1", pollID, []string{"Пицца"})
+				require.NoError(t, err)
+			},
+			userID: "creator1", question: "Где ужинаем?",
+			wantErr: "голоса отданы за прежний вопрос",
+		},
+		{
+			name: "after an abstention",
+			prepare: func(t *testing.T, s *PollServiceImpl, pollID string) {
+				_, err := s.AddVote(ctx, "user1", pollID, []string{"воздержался"})
+				require.NoError(t, err)
+			},
+			userID: "creator1", question: "Где ужинаем?",
+			wantErr: "уже проголосовали (1)",
+		},
+		{
+			name: "closed poll",
+			prepare: func(t *testing.T, s *PollServiceImpl, pollID string) {
+				_, err := s.EndPoll(ctx, "creator1", pollID)
+				require.NoError(t, err)
+			},
+			userID: "creator1", question: "Где обедаем?",
+			wantErr: "вопрос завершённого опроса исправить нельзя",
+		},
+		{name: "too long", userID: "creator1", question: strings.Repeat("в", maxQuestionLength), wantErr: "вопрос слишком длинный"},
+		{name: "channel mention", userID: "creator1", question: "Где обедаем, @channel?", wantErr: "@channel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, pollID := newService(t)
+			if tt.prepare != nil {
+				tt.prepare(t, s, pollID)
+			}
+
+			got, err := s.EditQuestion(ctx, tt.userID, pollID, tt.question)
+			poll, getErr := s.repo.GetPoll(ctx, pollID)
+			require.NoError(t, getErr)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Equal(t, "Где обдеаем?", poll.Question)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Вопрос опроса "+pollID+" исправлен: "+tt.question, got)
+			assert.Equal(t, tt.question, poll.Question)
+			assert.Equal(t, map[string]int{"Пицца": 0, "Суши": 0}, poll.Options)
+		})
+	}
+}
+
+// Тест проверяет, что исправление вопроса попадает в журнал и обновляет
+// закреплённое сообщение опроса
+func TestEditQuestion_PinnedAndAudit(t *testing.T) {
+	ctx := WithOrigin(i18n.WithLocalizer(context.Background(), i18n.New("ru")), Origin{ChannelID: "channel1"})
+	repo := repository.NewMemoryPollRepo()
+	journal := repository.NewMemoryAuditRepo()
+	pinner := &editingPinner{posts: make(map[string]string)}
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.SetAuditRepository(journal)
+	s.SetPinner(pinner)
+
+	created, err := s.CreatePollWithID(ctx, "creator1", "Где обдеаем?", []string{"Пицца", "Суши"}, CreateOptions{Pin: true})
+	require.NoError(t, err)
+	require.Contains(t, pinner.posts["post1"], "Где обдеаем?")
+
+	_, err = s.EditQuestion(ctx, "creator1", created.ID, "Где обедаем?")
+	require.NoError(t, err)
+	assert.Contains(t, pinner.posts["post1"], "Где обедаем?")
+	assert.NotContains(t, pinner.posts["post1"], "Где обдеаем?")
+	assert.Contains(t, pinner.posts["post1"], "Пицца")
+
+	events, err := journal.ListEvents(ctx, created.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, audit.ActionQuestionEdited, events[1].Action)
+	assert.Equal(t, "creator1", events[1].Actor)
+	assert.Equal(t, "Где обедаем?", events[1].Detail)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Сколько опросов закрывает одна команда end --all: остальные закрываются
+// повтором команды
+const maxEndedPolls = 50
+
+// EndAllOptions - параметры команды end --all.
+type EndAllOptions struct {
+	// Все открытые опросы канала команды, а не только опросы пользователя
+	Channel bool
+}
+
+// EndPolls закрывает открытые опросы пользователя во всех каналах, а с
+// opts.Channel - открытые опросы канала команды, чьи бы они ни были.
+// Опрос, который закрыть не удалось, не останавливает остальные: ответ
+// сообщает число закрытых и ошибки по опросам. Итоги каждого закрытого
+// опроса публикуются в его канале. За раз закрывается не больше
+// maxEndedPolls опросов в порядке ID.
+func (s *PollServiceImpl) EndPolls(ctx context.Context, userID string, opts EndAllOptions) (string, error) {
+	open := false
+	filter := repository.ListFilter{Closed: &open, Creator: userID, Limit: maxEndedPolls + 1}
+	if opts.Channel {
+		origin := OriginFrom(ctx)
+		if origin.ChannelID == "" || origin.Direct {
+			return "", i18n.NewError(i18n.EndChannelOnly)
+		}
+		filter.ChannelID, filter.Creator = origin.ChannelID, ""
+	}
+
+	// Лишний опрос сообщает, что закрыты не все
+	polls, err := s.collectPolls(ctx, filter, maxEndedPolls+1, nil)
+	if err != nil {
+		return "", err
+	}
+	loc := i18n.FromContext(ctx)
+	if len(polls) == 0 {
+		return loc.T(i18n.EndAllNone), nil
+	}
+	total := len(polls)
+	if len(polls) > maxEndedPolls {
+		polls = polls[:maxEndedPolls]
+		if total, err = s.countPolls(ctx, filter, nil); err != nil {
+			return "", err
+		}
+	}
+
+	var closed int
+	var failures strings.Builder
+	for _, poll := range polls {
+		if err := s.endOne(ctx, loc, userID, poll); err != nil {
+			s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось закрыть опрос командой end --all")
+			failures.WriteString(loc.T(i18n.EndAllFailure, poll.ID, loc.Error(err)))
+			continue
+		}
+		closed++
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.EndAllSummary, closed, len(polls)-closed))
+	sb.WriteString(failures.String())
+	if total > len(polls) {
+		sb.WriteString(loc.T(i18n.EndAllTruncated, len(polls), total))
+	}
+	return sb.String(), nil
+}
+
+// endOne закрывает один опрос команды end --all и публикует его итоги в
+// канале опроса. Ограничение опроса каналом проверяется, как в end.
+func (s *PollServiceImpl) endOne(ctx context.Context, loc *i18n.Localizer, userID string, poll models.Poll) error {
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return err
+	}
+	poll, err := s.closePoll(ctx, userID, poll)
+	if err != nil {
+		return err
+	}
+	if s.announcer == nil || poll.ChannelID == "" {
+		return nil
+	}
+	message := loc.T(i18n.PollEnded, poll.ID) + "\n" + s.summary(ctx, loc, poll)
+	if err := s.announcer.Announce(ctx, poll.ChannelID, message); err != nil {
+		// Опрос уже закрыт: неопубликованные итоги видны командой results
+		s.logger.Error().Err(err).Str("poll_id", poll.ID).Msg("Не удалось опубликовать итоги опроса")
+	}
+	return nil
+}
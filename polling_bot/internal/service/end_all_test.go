@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// closeFailingRepo - хранилище в памяти, которое не закрывает опросы из
+// failIDs.
+type closeFailingRepo struct {
+	*repository.MemoryPollRepo
+	failIDs map[string]bool
+}
+
+func (r *closeFailingRepo) ClosePoll(ctx context.Context, pollID string) error {
+	if r.failIDs[pollID] {
+		return errors.New("диск переполнен")
+	}
+	return r.MemoryPollRepo.ClosePoll(ctx, pollID)
+}
+
+// messageAnnouncer запоминает каналы и тексты опубликованных итогов
+type messageAnnouncer struct {
+	channels []string
+	messages []string
+}
+
+func (a *messageAnnouncer) Announce(ctx context.Context, channelID, message string) error {
+	a.channels = append(a.channels, channelID)
+	a.messages = append(a.messages, message)
+	return nil
+}
+
+func newEndAllService(t *testing.T, polls []models.Poll, failIDs ...string) (*PollServiceImpl, *closeFailingRepo, *messageAnnouncer) {
+	t.Helper()
+	memory := repository.NewMemoryPollRepo()
+	for _, poll := range polls {
+		require.NoError(t, memory.SavePoll(context.Background(), poll))
+	}
+	repo := &closeFailingRepo{MemoryPollRepo: memory, failIDs: make(map[string]bool)}
+	for _, id := range failIDs {
+		repo.failIDs[id] = true
+	}
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(memory), zerolog.Nop())
+	announcer := &messageAnnouncer{}
+	s.SetAnnouncer(announcer)
+	return s, repo, announcer
+}
+
+func endAllPoll(id, creator, channelID string, closed bool) models.Poll {
+	return models.Poll{
+		ID:          id,
+		Creator:     creator,
+		Question:    "Опрос " + id,
+		Options:     map[string]int{"Да": 1, "Нет": 0},
+		OptionOrder: []string{"Да", "Нет"},
+		ChannelID:   channelID,
+		Closed:      closed,
+	}
+}
+
+// Тест проверяет end --all и end --all --channel: закрываются только
+// подходящие открытые опросы, ошибка одного опроса не останавливает
+// остальные и попадает в ответ, итоги закрытых публикуются в их каналах
+func TestEndPolls(t *testing.T) {
+	polls := []models.Poll{
+		endAllPoll("p1", "creator1", "c1", false),
+		endAllPoll("p2", "creator1", "c2", false),
+		endAllPoll("p3", "creator1", "c1", false),
+		endAllPoll("p4", "other", "c1", false),
+		endAllPoll("p5", "creator1", "c1", true),
+	}
+	restricted := endAllPoll("p6", "creator1", "c3", false)
+	restricted.RestrictToChannel = true
+	polls = append(polls, restricted)
+
+	tests := []struct {
+		name          string
+		opts          EndAllOptions
+		origin        Origin
+		failIDs       []string
+		want          string
+		wantClosed    []string
+		wantAnnounced []string
+		wantErr       string
+	}{
+		{
+			name:          "own polls in every channel",
+			origin:        Origin{ChannelID: "c1"},
+			failIDs:       []string{"p2"},
+			want:          "Закрыто опросов: 2, ошибок: 2\n- p2 - ошибка завершения опроса\n- p6 - этот опрос доступен только в своём канале\n",
+			wantClosed:    []string{"p1", "p3", "p5"},
+			wantAnnounced: []string{"c1", "c1"},
+		},
+		{
+			name:   "own polls from direct messages",
+			origin: Origin{ChannelID: "d1", Direct: true},
+			// Опрос, ограниченный каналом, и end закрывает только из его канала
+			want:          "Закрыто опросов: 3, ошибок: 1\n- p6 - этот опрос доступен только в своём канале\n",
+			wantClosed:    []string{"p1", "p2", "p3", "p5"},
+			wantAnnounced: []string{"c1", "c2", "c1"},
+		},
+		{
+			name:          "channel polls",
+			opts:          EndAllOptions{Channel: true},
+			origin:        Origin{ChannelID: "c1"},
+			want:          "Закрыто опросов: 3, ошибок: 0\n",
+			wantClosed:    []string{"p1", "p3", "p4", "p5"},
+			wantAnnounced: []string{"c1", "c1", "c1"},
+		},
+		{
+			name:       "nothing to close",
+			opts:       EndAllOptions{Channel: true},
+			origin:     Origin{ChannelID: "c9"},
+			want:       "Открытых опросов нет: закрывать нечего",
+			wantClosed: []string{"p5"},
+		},
+		{
+			name:       "channel flag in direct messages",
+			opts:       EndAllOptions{Channel: true},
+			origin:     Origin{ChannelID: "d1", Direct: true},
+			wantErr:    "флаг --channel работает только в канале, а не в личных сообщениях",
+			wantClosed: []string{"p5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, announcer := newEndAllService(t, polls, tt.failIDs...)
+			ctx := WithOrigin(i18n.WithLocalizer(context.Background(), i18n.New("ru")), tt.origin)
+
+			reply, err := s.EndPolls(ctx, "creator1", tt.opts)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, reply)
+			}
+			var closed []string
+			stored, _, err := repo.ListPolls(ctx, repository.ListFilter{})
+			require.NoError(t, err)
+			for _, poll := range stored {
+				if poll.Closed {
+					closed = append(closed, poll.ID)
+				}
+			}
+			assert.Equal(t, tt.wantClosed, closed)
+			assert.Equal(t, tt.wantAnnounced, announcer.channels)
+			for _, message := range announcer.messages {
+				assert.Contains(t, message, "окончено\n**Результаты опроса")
+			}
+		})
+	}
+}
+
+// Тест проверяет, что end --all закрывает не больше maxEndedPolls опросов
+// и сообщает, сколько осталось
+func TestEndPolls_Truncated(t *testing.T) {
+	var polls []models.Poll
+	for i := range maxEndedPolls + 3 {
+		polls = append(polls, endAllPoll(fmt.Sprintf("p%03d", i), "creator1", "c1", false))
+	}
+	s, repo, _ := newEndAllService(t, polls)
+	ctx := WithOrigin(i18n.WithLocalizer(context.Background(), i18n.New("ru")), Origin{ChannelID: "c1"})
+
+	reply, err := s.EndPolls(ctx, "creator1", EndAllOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Закрыто опросов: 50, ошибок: 0\n"+
+		"Закрыты первые 50 опросов из 53: повторите команду, чтобы закрыть остальные\n", reply)
+
+	open := false
+	left, err := repo.CountPolls(ctx, repository.ListFilter{Closed: &open})
+	require.NoError(t, err)
+	assert.Equal(t, 3, left)
+
+	reply, err = s.EndPolls(ctx, "creator1", EndAllOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Закрыто опросов: 3, ошибок: 0\n", reply)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// За сколько до закрытия по сроку предупреждать канал опроса
+const expiryWarning = 24 * time.Hour
+
+// Как показывать срок опроса в сообщениях
+const expiryTimeLayout = "02.01.2006 15:04 MST"
+
+// ExpiryOptions - автоматическое закрытие забытых опросов.
+type ExpiryOptions struct {
+	// Срок опроса, созданного без --expires; 0 - такие опросы без срока
+	DefaultTTL time.Duration
+	// Разрешить создателям отказаться от срока флагом --no-expire
+	AllowNoExpire bool
+}
+
+// ExpiryNotice - сообщение для канала опроса о скором или состоявшемся
+// закрытии по сроку.
+type ExpiryNotice struct {
+	PollID    string
+	ChannelID string
+	Message   string
+}
+
+// SetExpiry задаёт срок опросов по умолчанию и разрешает --no-expire.
+func (s *PollServiceImpl) SetExpiry(opts ExpiryOptions) {
+	s.expiry = opts
+}
+
+// expiresAt возвращает срок нового опроса: явный, по умолчанию или нулевое
+// время для опроса без срока.
+func (s *PollServiceImpl) expiresAt(opts CreateOptions, createdAt time.Time) (time.Time, error) {
+	switch {
+	case opts.NoExpire && opts.Expires > 0:
+		return time.Time{}, i18n.NewError(i18n.ExpiresConflict)
+	case opts.NoExpire && !s.expiry.AllowNoExpire:
+		return time.Time{}, i18n.NewError(i18n.NoExpireForbidden)
+	case opts.NoExpire:
+		return time.Time{}, nil
+	case opts.Expires > 0:
+		return createdAt.Add(opts.Expires), nil
+	case s.expiry.DefaultTTL > 0:
+		return createdAt.Add(s.expiry.DefaultTTL), nil
+	default:
+		return time.Time{}, nil
+	}
+}
+
+// expired сообщает, что срок опроса наступил к at, даже если RunExpiry
+// ещё не успел его закрыть.
+func expired(poll models.Poll, at time.Time) bool {
+	return !poll.ExpiresAt.IsZero() && !at.Before(poll.ExpiresAt)
+}
+
+// RunExpiry закрывает открытые опросы, срок которых наступил к at, и
+// предупреждает каналы об опросах, которые закроются в ближайшие сутки,
+// возвращая сообщения для публикации. Предупреждение отмечается в опросе
+// до публикации и поэтому отправляется один раз. Опрос, срок которого
+// прошёл, пока бот не работал, закрывается без предупреждения. Ошибки
+// отдельных опросов только логируются.
+func (s *PollServiceImpl) RunExpiry(ctx context.Context, at time.Time) []ExpiryNotice {
+	open := false
+	filter := repository.ListFilter{Closed: &open, Limit: repository.MaxListLimit}
+
+	var notices []ExpiryNotice
+	for {
+		polls, next, err := s.repo.ListPolls(ctx, filter)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Не удалось получить открытые опросы для проверки срока")
+			return notices
+		}
+		for _, poll := range polls {
+			if notice, ok := s.expire(ctx, poll, at); ok {
+				notices = append(notices, notice)
+			}
+		}
+		if next == "" {
+			return notices
+		}
+		filter.Cursor = next
+	}
+}
+
+// expire закрывает опрос с наступившим сроком или предупреждает о скором
+// закрытии; ok == false, если публиковать нечего.
+func (s *PollServiceImpl) expire(ctx context.Context, poll models.Poll, at time.Time) (ExpiryNotice, bool) {
+	if poll.ExpiresAt.IsZero() || poll.Closed {
+		return ExpiryNotice{}, false
+	}
+	loc := i18n.FromContext(ctx)
+	log := s.logger.With().Str("poll_id", poll.ID).Logger()
+
+	var message string
+	switch {
+	case expired(poll, at):
+		closed, changed, err := s.closeOpenPoll(ctx, poll.ID)
+		if err != nil {
+			log.Error().Err(err).Msg("Не удалось закрыть опрос по сроку")
+			return ExpiryNotice{}, false
+		}
+		// Опрос успели завершить командой end: итоги уже разосланы
+		if !changed {
+			return ExpiryNotice{}, false
+		}
+		poll = closed
+		s.observe(poll)
+		log.Info().Time("expires_at", poll.ExpiresAt).Msg("Опрос закрыт по сроку")
+		// Опрос закрывает бот, действие записывается за создателем
+		s.record(ctx, poll.ID, poll.Creator, audit.ActionExpired, "")
+		s.unpin(ctx, poll)
+		s.notifyVoters(ctx, poll)
+		message = loc.T(i18n.PollExpired, poll.ID) + s.summary(ctx, loc, poll)
+	case !poll.ExpiryWarned && !at.Before(poll.ExpiresAt.Add(-expiryWarning)):
+		// Без отметки следующий вызов предупредил бы снова
+		if err := s.repo.SetExpiryWarned(ctx, poll.ID); err != nil {
+			log.Error().Err(err).Msg("Не удалось отметить предупреждение о сроке опроса")
+			return ExpiryNotice{}, false
+		}
+		message = loc.T(i18n.ExpiryWarning, poll.ID, poll.Question, renderTime(poll.ExpiresAt))
+	default:
+		return ExpiryNotice{}, false
+	}
+
+	if poll.ChannelID == "" {
+		return ExpiryNotice{}, false
+	}
+	return ExpiryNotice{PollID: poll.ID, ChannelID: poll.ChannelID, Message: message}, true
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/repository"
+)
+
+// expiryClock - часы сервиса, которые тест переводит вручную
+type expiryClock struct {
+	now time.Time
+}
+
+func (c *expiryClock) Now() time.Time { return c.now }
+
+func newExpiryService(t *testing.T, opts ExpiryOptions) (*PollServiceImpl, repository.PollRepository, *expiryClock) {
+	t.Helper()
+	repo := repository.NewMemoryPollRepo()
+	clock := &expiryClock{now: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)}
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.now = clock.Now
+	s.SetExpiry(opts)
+	return s, repo, clock
+}
+
+func createExpiring(t *testing.T, s *PollServiceImpl, postID string, opts CreateOptions) string {
+	t.Helper()
+	ctx := WithOrigin(context.Background(), Origin{PostID: postID, ChannelID: "c1"})
+	created, err := s.CreatePollWithID(ctx, "creator1", "Обед?", []string{"Пицца", "Суши"}, opts)
+	require.NoError(t, err)
+	return created.ID
+}
+
+// Тест проверяет срок нового опроса: явный, по умолчанию и отказ от срока
+func TestCreatePoll_Expiry(t *testing.T) {
+	created := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		expiry  ExpiryOptions
+		opts    CreateOptions
+		want    time.Time
+		wantErr string
+	}{
+		{name: "no default", want: time.Time{}},
+		{name: "default", expiry: ExpiryOptions{DefaultTTL: 72 * time.Hour}, want: created.Add(72 * time.Hour)},
+		{name: "explicit wins", expiry: ExpiryOptions{DefaultTTL: 72 * time.Hour}, opts: CreateOptions{Expires: 2 * time.Hour}, want: created.Add(2 * time.Hour)},
+		{name: "no-expire allowed", expiry: ExpiryOptions{DefaultTTL: 72 * time.Hour, AllowNoExpire: true}, opts: CreateOptions{NoExpire: true}, want: time.Time{}},
+		{name: "no-expire forbidden", expiry: ExpiryOptions{DefaultTTL: 72 * time.Hour}, opts: CreateOptions{NoExpire: true}, wantErr: "опросы без срока запрещены: укажите срок флагом --expires"},
+		{name: "both flags", expiry: ExpiryOptions{AllowNoExpire: true}, opts: CreateOptions{NoExpire: true, Expires: time.Hour}, wantErr: "нельзя указать --expires и --no-expire вместе"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, _ := newExpiryService(t, tt.expiry)
+			ctx := WithOrigin(context.Background(), Origin{PostID: "post1", ChannelID: "c1"})
+			result, err := s.CreatePollWithID(ctx, "creator1", "Обед?", []string{"Пицца"}, tt.opts)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			poll, err := repo.GetPoll(ctx, result.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, poll.ExpiresAt)
+			if tt.want.IsZero() {
+				assert.NotContains(t, result.Message, "закроется автоматически")
+			} else {
+				assert.Contains(t, result.Message, "Опрос закроется автоматически")
+			}
+		})
+	}
+}
+
+// Тест проверяет порядок: одно предупреждение за сутки, отказ в голосе
+// после срока и закрытие с итогами
+func TestRunExpiry_WarnThenClose(t *testing.T) {
+	s, repo, clock := newExpiryService(t, ExpiryOptions{DefaultTTL: 72 * time.Hour})
+	ctx := context.Background()
+	created := clock.now
+	pollID := createExpiring(t, s, "post1", CreateOptions{})
+	_, err := s.AddVote(ctx, "u1", pollID, []string{"Пицца"})
+	require.NoError(t, err)
+
+	assert.Empty(t, s.RunExpiry(ctx, created.Add(47*time.Hour+59*time.Minute)), "рано предупреждать")
+
+	notices := s.RunExpiry(ctx, created.Add(48*time.Hour))
+	require.Len(t, notices, 1)
+	assert.Equal(t, pollID, notices[0].PollID)
+	assert.Equal(t, "c1", notices[0].ChannelID)
+	assert.Contains(t, notices[0].Message, "Опрос `"+pollID+"` «Обед?» закроется автоматически")
+	assert.Empty(t, s.RunExpiry(ctx, created.Add(48*time.Hour+time.Minute)), "предупреждение отправляется один раз")
+	assert.Empty(t, s.RunExpiry(ctx, created.Add(71*time.Hour+59*time.Minute)))
+
+	// Срок наступил, но опрос ещё не закрыт: голос уже не принимается
+	clock.now = created.Add(72 * time.Hour)
+	_, err = s.AddVote(ctx, "u2", pollID, []string{"Суши"})
+	assert.EqualError(t, err, "опрос завершен")
+
+	notices = s.RunExpiry(ctx, clock.now)
+	require.Len(t, notices, 1)
+	assert.Contains(t, notices[0].Message, "Срок опроса "+pollID+" истёк")
+	assert.Contains(t, notices[0].Message, "- Пицца: 1 голосов")
+	poll, err := repo.GetPoll(ctx, pollID)
+	require.NoError(t, err)
+	assert.True(t, poll.Closed)
+
+	assert.Empty(t, s.RunExpiry(ctx, clock.now.Add(time.Minute)), "закрытый опрос больше не проверяется")
+}
+
+// Тест проверяет, что опрос, срок которого прошёл без предупреждения,
+// закрывается одним сообщением, а короткий срок предупреждается сразу
+func TestRunExpiry_Missed(t *testing.T) {
+	s, _, clock := newExpiryService(t, ExpiryOptions{})
+	ctx := context.Background()
+	created := clock.now
+	missed := createExpiring(t, s, "post1", CreateOptions{Expires: 72 * time.Hour})
+	short := createExpiring(t, s, "post2", CreateOptions{Expires: 2 * time.Hour})
+	createExpiring(t, s, "post3", CreateOptions{})
+
+	notices := s.RunExpiry(ctx, created.Add(time.Minute))
+	require.Len(t, notices, 1)
+	assert.Equal(t, short, notices[0].PollID)
+	assert.Contains(t, notices[0].Message, "закроется автоматически")
+
+	notices = s.RunExpiry(ctx, created.Add(100*time.Hour))
+	require.Len(t, notices, 2)
+	for _, notice := range notices {
+		assert.Contains(t, []string{missed, short}, notice.PollID)
+		assert.Contains(t, notice.Message, "истёк")
+	}
+	assert.Empty(t, s.RunExpiry(ctx, created.Add(200*time.Hour)), "опрос без срока не закрывается")
+}
+
+// Тест проверяет проверку сроков после перезапуска: новый сервис над тем же
+// хранилищем закрывает опросы, срок которых прошёл, пока бот не работал,
+// ровно один раз, не повторяет уже отправленное предупреждение и не
+// трогает опросы, срок которых ещё не подошёл
+func TestRunExpiry_Restart(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	journal := repository.NewMemoryAuditRepo()
+	started := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	newService := func() *PollServiceImpl {
+		s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+		s.now = func() time.Time { return started }
+		s.SetAuditRepository(journal)
+		return s
+	}
+
+	before := newService()
+	overdue := createExpiring(t, before, "post1", CreateOptions{Expires: 2 * time.Hour})
+	warned := createExpiring(t, before, "post2", CreateOptions{Expires: 20 * time.Hour})
+	later := createExpiring(t, before, "post3", CreateOptions{Expires: 72 * time.Hour})
+	notices := before.RunExpiry(ctx, started)
+	require.Len(t, notices, 2, "предупреждения до перезапуска")
+
+	// Бот не работал три часа: срок первого опроса прошёл
+	restarted := started.Add(3 * time.Hour)
+	notices = newService().RunExpiry(ctx, restarted)
+	require.Len(t, notices, 1)
+	assert.Equal(t, overdue, notices[0].PollID)
+	assert.Contains(t, notices[0].Message, "истёк")
+	// Второй перезапуск и следующая минута ничего не повторяют
+	assert.Empty(t, newService().RunExpiry(ctx, restarted))
+	assert.Empty(t, newService().RunExpiry(ctx, restarted.Add(time.Minute)))
+
+	for id, closed := range map[string]bool{overdue: true, warned: false, later: false} {
+		poll, err := repo.GetPoll(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, closed, poll.Closed, id)
+	}
+	events, err := journal.ListEvents(ctx, overdue, 10)
+	require.NoError(t, err)
+	var expiredEvents int
+	for _, event := range events {
+		if event.Action == audit.ActionExpired {
+			expiredEvents++
+		}
+	}
+	assert.Equal(t, 1, expiredEvents, "опрос закрывается по сроку один раз")
+}
+
+// Тест проверяет, что проверка сроков, прочитавшая опрос до команды end,
+// не закрывает его второй раз и не публикует итоги снова
+func TestRunExpiry_EndedMeanwhile(t *testing.T) {
+	s, repo, clock := newExpiryService(t, ExpiryOptions{})
+	ctx := context.Background()
+	pollID := createExpiring(t, s, "post1", CreateOptions{Expires: time.Hour})
+	stale, err := repo.GetPoll(ctx, pollID)
+	require.NoError(t, err)
+
+	_, err = s.EndPoll(ctx, "creator1", pollID)
+	require.NoError(t, err)
+	_, ok := s.expire(ctx, stale, clock.now.Add(2*time.Hour))
+	assert.False(t, ok)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+)
+
+// FeaturePoll отмечает опрос, чтобы списки показывали его первым, или
+// снимает отметку. Отметить можно и закрытый опрос: он остаётся в списках
+// с пометкой о закрытии.
+func (s *PollServiceImpl) FeaturePoll(ctx context.Context, userID, pollID string, featured bool) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpGetPoll)
+	}
+
+	if err := s.repo.SetFeatured(ctx, pollID, featured); err != nil {
+		return "", s.storageError(err, i18n.OpFeaturePoll)
+	}
+	loc := i18n.FromContext(ctx)
+	if !featured {
+		s.record(ctx, pollID, userID, audit.ActionUnfeatured, "")
+		return loc.T(i18n.PollUnfeatured, pollID), nil
+	}
+	s.record(ctx, pollID, userID, audit.ActionFeatured, "")
+	if poll.Closed {
+		return loc.T(i18n.PollFeaturedClosed, pollID), nil
+	}
+	return loc.T(i18n.PollFeatured, pollID), nil
+}
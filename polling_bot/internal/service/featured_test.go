@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет отметку опроса: открытого и закрытого, снятие отметки и
+// опрос, которого нет
+func TestFeaturePoll(t *testing.T) {
+	tests := []struct {
+		name     string
+		poll     models.Poll
+		pollID   string
+		featured bool
+		want     string
+		wantErr  string
+	}{
+		{name: "open poll", poll: models.Poll{ID: listPollID(1)}, featured: true,
+			want: "Опрос " + listPollID(1) + " отмечен ⭐ и показывается первым в списках опросов"},
+		{name: "closed poll", poll: models.Poll{ID: listPollID(1), Closed: true}, featured: true,
+			want: "Опрос " + listPollID(1) + " закрыт, но отмечен ⭐: в списках он показывается первым с пометкой о закрытии"},
+		{name: "unfeature", poll: models.Poll{ID: listPollID(1), Featured: true},
+			want: "С опроса " + listPollID(1) + " снята отметка ⭐"},
+		{name: "missing poll", poll: models.Poll{ID: listPollID(1)}, pollID: listPollID(2), featured: true,
+			wantErr: "опрос не найден"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			tt.poll.Options = map[string]int{"Да": 0}
+			require.NoError(t, repo.SavePoll(ctx, tt.poll))
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+			pollID := tt.pollID
+			if pollID == "" {
+				pollID = tt.poll.ID
+			}
+			got, err := s.FeaturePoll(ctx, "admin1", pollID, tt.featured)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			poll, err := repo.GetPoll(ctx, pollID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.featured, poll.Featured)
+			assert.Equal(t, tt.poll.Closed, poll.Closed, "отметка не меняет состояние опроса")
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import "polling_bot/internal/models"
+
+// irvRound - раунд подсчёта по системе мгновенного второго тура: первые
+// предпочтения бюллетеней среди оставшихся вариантов и выбывший вариант.
+type irvRound struct {
+	Counts     map[string]int
+	Eliminated string
+}
+
+type irvResult struct {
+	Rounds []irvRound
+	// Пусто, если ни в одном бюллетене не осталось действующих вариантов
+	Winner string
+}
+
+// instantRunoff подсчитывает рейтинговые бюллетени. В каждом раунде бюллетень
+// отдаётся самому предпочтительному из оставшихся вариантов; вариант с
+// большинством действующих бюллетеней побеждает, иначе выбывает вариант
+// с наименьшим числом голосов. При равенстве выбывает созданный позже,
+// так что результат не зависит от порядка бюллетеней. order - варианты
+// в порядке создания.
+func instantRunoff(order []string, ballots [][]string) irvResult {
+	remaining := make(map[string]bool, len(order))
+	for _, option := range order {
+		remaining[option] = true
+	}
+
+	var result irvResult
+	for len(remaining) > 0 {
+		counts := make(map[string]int, len(remaining))
+		for option := range remaining {
+			counts[option] = 0
+		}
+		active := 0
+		for _, ballot := range ballots {
+			for _, choice := range ballot {
+				if remaining[choice] {
+					counts[choice]++
+					active++
+					break
+				}
+			}
+		}
+		if active == 0 {
+			return result
+		}
+
+		round := irvRound{Counts: counts}
+		leader, loser := "", ""
+		for _, option := range order {
+			if !remaining[option] {
+				continue
+			}
+			if leader == "" || counts[option] > counts[leader] {
+				leader = option
+			}
+			if loser == "" || counts[option] <= counts[loser] {
+				loser = option
+			}
+		}
+		if counts[leader]*2 > active || len(remaining) == 1 {
+			result.Rounds = append(result.Rounds, round)
+			result.Winner = leader
+			return result
+		}
+
+		round.Eliminated = loser
+		delete(remaining, loser)
+		result.Rounds = append(result.Rounds, round)
+	}
+	return result
+}
+
+// optionOrder возвращает тексты вариантов в порядке models.Poll.OptionList.
+func optionOrder(poll models.Poll) []string {
+	options := poll.OptionList()
+	order := make([]string, len(options))
+	for i, option := range options {
+		order[i] = option.Text
+	}
+	return order
+}
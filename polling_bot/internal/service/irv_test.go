@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест проверяет подсчёт рейтинговых бюллетеней по раундам
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name           string
+		order          []string
+		ballots        [][]string
+		wantWinner     string
+		wantEliminated []string
+	}{
+		{
+			name:       "majority in the first round",
+			order:      []string{"A", "B", "C"},
+			ballots:    [][]string{{"A"}, {"A", "B"}, {"B"}},
+			wantWinner: "A",
+		},
+		{
+			name:  "votes transfer after elimination",
+			order: []string{"A", "B", "C"},
+			ballots: [][]string{
+				{"A", "B"}, {"A", "B"},
+				{"B", "A"}, {"B", "A"},
+				{"C", "B"},
+			},
+			wantWinner:     "B",
+			wantEliminated: []string{"C"},
+		},
+		{
+			name:  "tie on elimination drops the later option",
+			order: []string{"A", "B", "C"},
+			ballots: [][]string{
+				{"A"}, {"A"},
+				{"B", "A"},
+				{"C", "B"},
+			},
+			wantWinner:     "A",
+			wantEliminated: []string{"C", "B"},
+		},
+		{
+			name:           "final tie goes to the earlier option",
+			order:          []string{"A", "B"},
+			ballots:        [][]string{{"B"}, {"A"}},
+			wantWinner:     "A",
+			wantEliminated: []string{"B"},
+		},
+		{
+			name:  "partial rankings are exhausted",
+			order: []string{"A", "B", "C"},
+			ballots: [][]string{
+				{"A"}, {"A"},
+				{"B"}, {"B"},
+				{"C"},
+			},
+			wantWinner:     "A",
+			wantEliminated: []string{"C", "B"},
+		},
+		{
+			name:  "options without first choices are eliminated first",
+			order: []string{"A", "B", "C", "D"},
+			ballots: [][]string{
+				{"A", "D"}, {"B", "D"}, {"C", "D"},
+			},
+			wantWinner:     "A",
+			wantEliminated: []string{"D", "C", "B"},
+		},
+		{
+			name:    "no ballots",
+			order:   []string{"A", "B"},
+			ballots: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := instantRunoff(tt.order, tt.ballots)
+			assert.Equal(t, tt.wantWinner, result.Winner)
+
+			var eliminated []string
+			for _, round := range result.Rounds {
+				if round.Eliminated != "" {
+					eliminated = append(eliminated, round.Eliminated)
+				}
+			}
+			assert.Equal(t, tt.wantEliminated, eliminated)
+		})
+	}
+}
+
+// Тест проверяет, что результат не зависит от порядка бюллетеней
+func TestInstantRunoff_BallotOrder(t *testing.T) {
+	order := []string{"A", "B", "C"}
+	ballots := [][]string{{"C", "A"}, {"B"}, {"A"}, {"B", "C"}, {"C", "B"}}
+	want := instantRunoff(order, ballots)
+
+	reversed := make([][]string, len(ballots))
+	for i, ballot := range ballots {
+		reversed[len(ballots)-1-i] = ballot
+	}
+	assert.Equal(t, want, instantRunoff(order, reversed))
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Сколько опросов показывает команда list
+const maxListedPolls = 20
+
+// ListOptions - параметры команды list.
+type ListOptions struct {
+	// Только опросы с этой меткой
+	Tag string
+	// Опросы всех каналов, доступные пользователю, а не только канала команды
+	All bool
+}
+
+// ListOpenPolls показывает открытые опросы канала, из которого пришла
+// команда, а в личных сообщениях - открытые опросы пользователя; с opts.All -
+// все открытые опросы, которые пользователь может видеть. С непустой меткой
+// выводятся только опросы с ней. Первыми идут отмеченные администратором
+// опросы, в том числе закрытые, из всех каналов, где пользователь может их
+// видеть; за ними - остальные в порядке ID. Показываются первые
+// maxListedPolls опросов и сколько их всего.
+func (s *PollServiceImpl) ListOpenPolls(ctx context.Context, userID string, opts ListOptions) (string, error) {
+	tag, err := normalizeTag(opts.Tag)
+	if err != nil {
+		return "", err
+	}
+
+	filter := openPollsFilter(ctx, userID)
+	scope := i18n.ListScopeChannel
+	visible := func(poll models.Poll) bool { return visibleTo(ctx, poll, userID) }
+	var keep func(models.Poll) bool
+	switch {
+	case opts.All:
+		filter.ChannelID, filter.Creator = "", ""
+		scope = ""
+		keep = visible
+	case filter.Creator != "":
+		scope = i18n.ListScopeMine
+	}
+	yes, no := true, false
+	filter.Tag = tag
+	filter.Featured = &no
+	filter.Limit = maxListedPolls + 1
+	featured := repository.ListFilter{Featured: &yes, Tag: tag, Limit: maxListedPolls + 1}
+
+	// Лишний опрос сообщает, что показаны не все
+	polls, err := s.collectPolls(ctx, featured, maxListedPolls+1, visible)
+	if err != nil {
+		return "", err
+	}
+	if rest := maxListedPolls + 1 - len(polls); rest > 0 {
+		regular, err := s.collectPolls(ctx, filter, rest, keep)
+		if err != nil {
+			return "", err
+		}
+		polls = append(polls, regular...)
+	}
+
+	total := len(polls)
+	if len(polls) > maxListedPolls {
+		featuredTotal, err := s.countPolls(ctx, featured, visible)
+		if err != nil {
+			return "", err
+		}
+		total, err = s.countPolls(ctx, filter, keep)
+		if err != nil {
+			return "", err
+		}
+		total += featuredTotal
+	}
+	return renderList(i18n.FromContext(ctx), tag, scope, polls, total), nil
+}
+
+// visibleTo сообщает, может ли пользователь видеть опрос в общем списке:
+// опрос, ограниченный каналом, виден в своём канале и создателю.
+func visibleTo(ctx context.Context, poll models.Poll, userID string) bool {
+	return !poll.RestrictToChannel || poll.Creator == userID || poll.ChannelID == OriginFrom(ctx).ChannelID
+}
+
+// countPolls считает опросы по фильтру, прошедшие keep. Без keep считает
+// хранилище, не читая опросы; с keep опросы читаются страницами.
+func (s *PollServiceImpl) countPolls(ctx context.Context, filter repository.ListFilter, keep func(models.Poll) bool) (int, error) {
+	if keep == nil {
+		total, err := s.repo.CountPolls(ctx, filter)
+		if err != nil {
+			return 0, s.storageError(err, i18n.OpListPolls)
+		}
+		return total, nil
+	}
+	filter.Cursor = ""
+	filter.Limit = repository.MaxListLimit
+	total := 0
+	for {
+		page, cursor, err := s.repo.ListPolls(ctx, filter)
+		if err != nil {
+			return 0, s.storageError(err, i18n.OpListPolls)
+		}
+		for _, poll := range page {
+			if keep(poll) {
+				total++
+			}
+		}
+		if cursor == "" {
+			return total, nil
+		}
+		filter.Cursor = cursor
+	}
+}
+
+// openPollsFilter выбирает открытые опросы канала, из которого пришла
+// команда, а в личных сообщениях - открытые опросы пользователя.
+func openPollsFilter(ctx context.Context, userID string) repository.ListFilter {
+	open := false
+	filter := repository.ListFilter{Closed: &open}
+	if origin := OriginFrom(ctx); origin.ChannelID != "" && !origin.Direct {
+		filter.ChannelID = origin.ChannelID
+	} else {
+		filter.Creator = userID
+	}
+	return filter
+}
+
+// collectPolls читает страницы ListPolls, пока не наберёт limit опросов,
+// прошедших keep, или пока опросы не кончатся. Опросы, не прошедшие keep,
+// не накапливаются; nil keep оставляет все опросы.
+func (s *PollServiceImpl) collectPolls(ctx context.Context, filter repository.ListFilter, limit int, keep func(models.Poll) bool) ([]models.Poll, error) {
+	var polls []models.Poll
+	for {
+		page, cursor, err := s.repo.ListPolls(ctx, filter)
+		if err != nil {
+			return nil, s.storageError(err, i18n.OpListPolls)
+		}
+		for _, poll := range page {
+			if keep != nil && !keep(poll) {
+				continue
+			}
+			polls = append(polls, poll)
+			if len(polls) == limit {
+				return polls, nil
+			}
+		}
+		// Страница может быть пустой при непустом курсоре, если хранилище
+		// ограничивает объём сканирования за вызов
+		if cursor == "" {
+			return polls, nil
+		}
+		filter.Cursor = cursor
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+func listPollID(i int) string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+}
+
+// newListService создаёт сервис с опросами: открытыми и закрытым
+// в канале c1, опросом канала c2 и опросом из личных сообщений.
+func newListService(t *testing.T, extra ...models.Poll) *PollServiceImpl {
+	t.Helper()
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	polls := []models.Poll{
+		{ID: listPollID(1), Creator: "u1", Question: "Где обедаем?", ChannelID: "c1",
+			Options: map[string]int{"Пицца": 2, "Суши": 1}, Tags: []string{"food"}},
+		{ID: listPollID(2), Creator: "u2", Question: "Когда\nрелиз?", ChannelID: "c1",
+			Options: map[string]int{"Пятница": 0}, Tags: []string{"release", "team-a"}},
+		{ID: listPollID(3), Creator: "u1", Question: "Закрытый", ChannelID: "c1", Closed: true,
+			Options: map[string]int{"Да": 1}, Tags: []string{"release"}},
+		{ID: listPollID(4), Creator: "u1", Question: "Другой канал", ChannelID: "c2",
+			Options: map[string]int{"Да": 0}},
+		{ID: listPollID(5), Creator: "u1", Question: "Личный", ChannelID: "dm",
+			Options: map[string]int{"Да": 0}, Tags: []string{"release"}},
+		{ID: listPollID(6), Creator: "u2", Question: "Только для c2", ChannelID: "c2", RestrictToChannel: true,
+			Options: map[string]int{"Да": 0}},
+		{ID: listPollID(7), Creator: "u2", Question: "Чужой канал", ChannelID: "c3",
+			Options: map[string]int{"Да": 0}, Tags: []string{"release"}},
+	}
+	for _, poll := range append(polls, extra...) {
+		require.NoError(t, repo.SavePoll(ctx, poll))
+	}
+	return NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+}
+
+// Тест проверяет область списка открытых опросов: канал команды, свои
+// опросы в личных сообщениях и все доступные с --all из канала и из
+// личных сообщений, фильтр по метке и пустые списки
+func TestListOpenPolls(t *testing.T) {
+	const (
+		scopeChannel = "_Только опросы этого канала, опросы всех каналов - с флагом --all_\n"
+		scopeMine    = "_В личных сообщениях показаны ваши опросы, опросы всех каналов - с флагом --all_\n"
+	)
+	tests := []struct {
+		name    string
+		origin  Origin
+		opts    ListOptions
+		want    string
+		wantErr string
+	}{
+		{name: "channel", origin: Origin{ChannelID: "c1"},
+			want: "**Открытые опросы**\n" +
+				"- `" + listPollID(1) + "` Где обедаем?, голосов: 3 `food`\n" +
+				"- `" + listPollID(2) + "` Когда релиз?, голосов: 0 `release`, `team-a`\n" +
+				scopeChannel},
+		{name: "tag", origin: Origin{ChannelID: "c1"}, opts: ListOptions{Tag: " Release "},
+			want: "**Открытые опросы с меткой `release`**\n" +
+				"- `" + listPollID(2) + "` Когда релиз?, голосов: 0 `release`, `team-a`\n" +
+				scopeChannel},
+		{name: "restricted poll in its channel", origin: Origin{ChannelID: "c2"},
+			want: "**Открытые опросы**\n" +
+				"- `" + listPollID(4) + "` Другой канал, голосов: 0\n" +
+				"- `" + listPollID(6) + "` Только для c2, голосов: 0\n" +
+				scopeChannel},
+		{name: "direct messages", origin: Origin{ChannelID: "dm", Direct: true},
+			want: "**Открытые опросы**\n" +
+				"- `" + listPollID(1) + "` Где обедаем?, голосов: 3 `food`\n" +
+				"- `" + listPollID(4) + "` Другой канал, голосов: 0\n" +
+				"- `" + listPollID(5) + "` Личный, голосов: 0 `release`\n" +
+				scopeMine},
+		{name: "all from channel", origin: Origin{ChannelID: "c1"}, opts: ListOptions{All: true},
+			want: "**Открытые опросы**\n" +
+				"- `" + listPollID(1) + "` Где обедаем?, голосов: 3 `food`\n" +
+				"- `" + listPollID(2) + "` Когда релиз?, голосов: 0 `release`, `team-a`\n" +
+				"- `" + listPollID(4) + "` Другой канал, голосов: 0\n" +
+				"- `" + listPollID(5) + "` Личный, голосов: 0 `release`\n" +
+				"- `" + listPollID(7) + "` Чужой канал, голосов: 0 `release`\n"},
+		{name: "all from restricted channel", origin: Origin{ChannelID: "c2"}, opts: ListOptions{All: true, Tag: "release"},
+			want: "**Открытые опросы с меткой `release`**\n" +
+				"- `" + listPollID(2) + "` Когда релиз?, голосов: 0 `release`, `team-a`\n" +
+				"- `" + listPollID(5) + "` Личный, голосов: 0 `release`\n" +
+				"- `" + listPollID(7) + "` Чужой канал, голосов: 0 `release`\n"},
+		{name: "all from direct messages", origin: Origin{ChannelID: "dm", Direct: true}, opts: ListOptions{All: true},
+			want: "**Открытые опросы**\n" +
+				"- `" + listPollID(1) + "` Где обедаем?, голосов: 3 `food`\n" +
+				"- `" + listPollID(2) + "` Когда релиз?, голосов: 0 `release`, `team-a`\n" +
+				"- `" + listPollID(4) + "` Другой канал, голосов: 0\n" +
+				"- `" + listPollID(5) + "` Личный, голосов: 0 `release`\n" +
+				"- `" + listPollID(7) + "` Чужой канал, голосов: 0 `release`\n"},
+		{name: "empty channel", origin: Origin{ChannelID: "c4"}, want: "Открытых опросов нет\n" + strings.TrimSuffix(scopeChannel, "\n")},
+		{name: "no polls with tag", origin: Origin{ChannelID: "c2"}, opts: ListOptions{Tag: "release"},
+			want: "Открытых опросов с меткой `release` нет\n" + strings.TrimSuffix(scopeChannel, "\n")},
+		{name: "no polls with tag anywhere", origin: Origin{ChannelID: "c2"}, opts: ListOptions{Tag: "nothing", All: true},
+			want: "Открытых опросов с меткой `nothing` нет"},
+		{name: "invalid tag", origin: Origin{ChannelID: "c1"}, opts: ListOptions{Tag: "team a"},
+			wantErr: "метка 'team a' может содержать только буквы, цифры, - и _"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newListService(t)
+			got, err := s.ListOpenPolls(WithOrigin(context.Background(), tt.origin), "u1", tt.opts)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Тест проверяет, что отмеченные опросы идут первыми в списке любого
+// канала, закрытые - с пометкой, а ограниченные каналом - только там, где
+// их можно видеть
+func TestListOpenPolls_Featured(t *testing.T) {
+	const scopeChannel = "_Только опросы этого канала, опросы всех каналов - с флагом --all_\n"
+	featured := []models.Poll{
+		{ID: listPollID(8), Creator: "u2", Question: "Опрос компании", ChannelID: "c3", Featured: true,
+			Options: map[string]int{"Да": 4}},
+		{ID: listPollID(9), Creator: "u2", Question: "Итоги года", ChannelID: "c1", Closed: true, Featured: true,
+			Options: map[string]int{"Да": 1}, Tags: []string{"release"}},
+		{ID: listPollID(10), Creator: "u2", Question: "Только для c2", ChannelID: "c2", RestrictToChannel: true, Featured: true,
+			Options: map[string]int{"Да": 0}},
+	}
+
+	tests := []struct {
+		name   string
+		origin Origin
+		opts   ListOptions
+		want   string
+	}{
+		{name: "channel", origin: Origin{ChannelID: "c1"},
+			want: "**Открытые опросы**\n" +
+				"- `" + listPollID(8) + "` ⭐ Опрос компании, голосов: 4\n" +
+				"- `" + listPollID(9) + "` ⭐ Итоги года _(закрыт)_, голосов: 1 `release`\n" +
+				"- `" + listPollID(1) + "` Где обедаем?, голосов: 3 `food`\n" +
+				"- `" + listPollID(2) + "` Когда релиз?, голосов: 0 `release`, `team-a`\n" +
+				scopeChannel},
+		{name: "restricted channel", origin: Origin{ChannelID: "c2"},
+			want: "**Открытые опросы**\n" +
+				"- `" + listPollID(8) + "` ⭐ Опрос компании, голосов: 4\n" +
+				"- `" + listPollID(9) + "` ⭐ Итоги года _(закрыт)_, голосов: 1 `release`\n" +
+				"- `" + listPollID(10) + "` ⭐ Только для c2, голосов: 0\n" +
+				"- `" + listPollID(4) + "` Другой канал, голосов: 0\n" +
+				"- `" + listPollID(6) + "` Только для c2, голосов: 0\n" +
+				scopeChannel},
+		{name: "tag", origin: Origin{ChannelID: "c1"}, opts: ListOptions{Tag: "release"},
+			want: "**Открытые опросы с меткой `release`**\n" +
+				"- `" + listPollID(9) + "` ⭐ Итоги года _(закрыт)_, голосов: 1 `release`\n" +
+				"- `" + listPollID(2) + "` Когда релиз?, голосов: 0 `release`, `team-a`\n" +
+				scopeChannel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newListService(t, featured...)
+			got, err := s.ListOpenPolls(WithOrigin(context.Background(), tt.origin), "u1", tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Отмеченные опросы входят в ограничение списка и в общее число
+	var extra []models.Poll
+	for i := 0; i < maxListedPolls; i++ {
+		extra = append(extra, models.Poll{ID: listPollID(100 + i), Creator: "u2", Question: "Ещё опрос",
+			ChannelID: "c1", Options: map[string]int{"Да": 0}})
+	}
+	s := newListService(t, append(featured, extra...)...)
+	got, err := s.ListOpenPolls(WithOrigin(context.Background(), Origin{ChannelID: "c1"}), "u1", ListOptions{})
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	require.Len(t, lines, maxListedPolls+3)
+	assert.Contains(t, lines[1], "⭐ Опрос компании")
+	assert.Equal(t, "Показаны первые 20 опросов из 24, уточните выбор меткой: --tag", lines[len(lines)-2])
+}
+
+// Тест проверяет, что список ограничен maxListedPolls опросами и сообщает,
+// что показаны не все и сколько опросов всего
+func TestListOpenPolls_Limit(t *testing.T) {
+	tests := []struct {
+		name     string
+		extra    int
+		all      bool
+		wantMore string
+	}{
+		{name: "exactly the limit", extra: maxListedPolls - 2},
+		{name: "over the limit", extra: maxListedPolls - 1, wantMore: "Показаны первые 20 опросов из 21, уточните выбор меткой: --tag"},
+		{name: "far over the limit", extra: maxListedPolls + 15, wantMore: "Показаны первые 20 опросов из 37, уточните выбор меткой: --tag"},
+		// Опрос, ограниченный чужим каналом, не считается
+		{name: "all channels over the limit", extra: maxListedPolls + 15, all: true, wantMore: "Показаны первые 20 опросов из 40, уточните выбор меткой: --tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var extra []models.Poll
+			for i := 0; i < tt.extra; i++ {
+				extra = append(extra, models.Poll{ID: listPollID(100 + i), Creator: "u2", Question: "Ещё опрос",
+					ChannelID: "c1", Options: map[string]int{"Да": 0}})
+			}
+			s := newListService(t, extra...)
+
+			got, err := s.ListOpenPolls(WithOrigin(context.Background(), Origin{ChannelID: "c1"}), "u1", ListOptions{All: tt.all})
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+			if tt.all {
+				assert.Len(t, lines, maxListedPolls+2, "заголовок, опросы и подсказка")
+				assert.Equal(t, tt.wantMore, lines[len(lines)-1])
+			} else if tt.wantMore != "" {
+				assert.Len(t, lines, maxListedPolls+3, "заголовок, опросы, подсказка и область списка")
+				assert.Equal(t, tt.wantMore, lines[len(lines)-2])
+			} else {
+				assert.Len(t, lines, maxListedPolls+2)
+				assert.NotContains(t, got, "Показаны первые")
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет, что при 2×N одновременных голосах опрос с --max-votes N
+// принимает ровно N голосов, закрывается и отклоняет остальные
+func TestAddVote_MaxVotesConcurrent(t *testing.T) {
+	const maxVotes = 20
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	created, err := s.CreatePollWithID(ctx, "creator1", "Слоты?", []string{"Да", "Нет"}, CreateOptions{MaxVotes: maxVotes})
+	require.NoError(t, err)
+	pollID := created.ID
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2*maxVotes)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = s.AddVote(ctx, fmt.Sprintf("user%d", i), pollID, []string{"Да"})
+		}(i)
+	}
+	wg.Wait()
+
+	accepted := 0
+	for _, err := range errs {
+		if err == nil {
+			accepted++
+			continue
+		}
+		assert.EqualError(t, err, "опрос уже набрал максимум голосов")
+	}
+	assert.Equal(t, maxVotes, accepted)
+
+	poll, err := repo.GetPoll(ctx, pollID)
+	require.NoError(t, err)
+	assert.True(t, poll.Closed)
+	assert.Equal(t, maxVotes, poll.Options["Да"])
+}
+
+// Тест проверяет, что последний допустимый голос закрывает опрос с
+// объявлением итогов и записью в журнал, а в многовариантном рейтинговом
+// опросе считаются участники, а не выбранные варианты
+func TestAddVote_MaxVotesCloses(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	auditRepo := repository.NewMemoryAuditRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.SetAuditRepository(auditRepo)
+	created, err := s.CreatePollWithID(ctx, "creator1", "Слоты?", []string{"A", "B", "C"},
+		CreateOptions{MaxVotes: 2, Quorum: 5, Ranked: true})
+	require.NoError(t, err)
+	pollID := created.ID
+	assert.Contains(t, created.Message, "Опрос завершится после 2 голосов\n")
+
+	reply, err := s.AddVote(ctx, "u1", pollID, []string{"A", "B", "C"})
+	require.NoError(t, err)
+	assert.NotContains(t, reply, "максимум голосов")
+
+	reply, err = s.AddVote(ctx, "u2", pollID, []string{"B", "A"})
+	require.NoError(t, err)
+	assert.Contains(t, reply, "Опрос "+pollID+" набрал максимум голосов и завершён\n")
+	assert.NotContains(t, reply, "Кворум достигнут")
+
+	_, err = s.AddVote(ctx, "u3", pollID, []string{"C"})
+	assert.EqualError(t, err, "опрос уже набрал максимум голосов")
+
+	events, err := auditRepo.ListEvents(ctx, pollID, 10)
+	require.NoError(t, err)
+	actions := make([]audit.Action, 0, len(events))
+	for _, event := range events {
+		actions = append(actions, event.Action)
+	}
+	assert.Contains(t, actions, audit.ActionMaxVotesClosed)
+	assert.NotContains(t, actions, audit.ActionQuorumClosed)
+}
+
+// Тест проверяет проверку максимума голосов при создании опроса
+func TestMaxVotes_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     CreateOptions
+		wantErr  string
+		wantLine string
+	}{
+		{name: "Без ограничения", opts: CreateOptions{}},
+		{name: "Ограничение задано", opts: CreateOptions{MaxVotes: 3}, wantLine: "Опрос завершится после 3 голосов\n"},
+		{name: "Отрицательное ограничение", opts: CreateOptions{MaxVotes: -1}, wantErr: "максимум голосов должен быть целым числом не меньше 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			created, err := s.CreatePollWithID(context.Background(), "creator1", "Слоты?", []string{"Да"}, tt.opts)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantLine != "" {
+				assert.Contains(t, created.Message, tt.wantLine)
+			} else {
+				assert.NotContains(t, created.Message, "голосов\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+const (
+	// Сколько помнить, что пользователь состоит в канале
+	defaultMembershipCacheTTL = time.Minute
+	// С какого размера кэш членства очищается от устаревших записей
+	membershipCachePrune = 1024
+)
+
+var errNoChannelMembers = errors.New("проверка членства в канале не настроена")
+
+// MembersOnlyOptions - проверка голосов в опросах с --members-only.
+type MembersOnlyOptions struct {
+	// Сколько помнить, что пользователь состоит в канале; 0 - спрашивать
+	// Mattermost при каждом голосе. Отказ не запоминается: пользователя
+	// могли только что добавить в канал
+	CacheTTL time.Duration
+	// Принять голос, если Mattermost не ответил; иначе голос отклоняется
+	FailOpen bool
+}
+
+// SetMembersOnly задаёт кэш и поведение при сбое проверки членства.
+func (s *PollServiceImpl) SetMembersOnly(opts MembersOnlyOptions) {
+	s.membership = opts
+}
+
+// memberCache помнит подтверждённое членство в канале до срока.
+type memberCache struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func memberKey(channelID, userID string) string {
+	return channelID + "\x00" + userID
+}
+
+func (c *memberCache) has(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return now.Before(c.until[key])
+}
+
+func (c *memberCache) add(key string, now, until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.until == nil {
+		c.until = make(map[string]time.Time)
+	}
+	if len(c.until) >= membershipCachePrune {
+		for k, t := range c.until {
+			if !now.Before(t) {
+				delete(c.until, k)
+			}
+		}
+	}
+	c.until[key] = until
+}
+
+// isMember проверяет членство в канале, сначала по кэшу.
+func (s *PollServiceImpl) isMember(ctx context.Context, channelID, userID string) (bool, error) {
+	if s.members == nil {
+		return false, errNoChannelMembers
+	}
+	key := memberKey(channelID, userID)
+	ttl := s.membership.CacheTTL
+	if ttl > 0 && s.memberCache.has(key, s.now()) {
+		return true, nil
+	}
+	member, err := s.members.IsChannelMember(ctx, channelID, userID)
+	if err != nil {
+		return false, err
+	}
+	if member && ttl > 0 {
+		now := s.now()
+		s.memberCache.add(key, now, now.Add(ttl))
+	}
+	return member, nil
+}
+
+// checkMember отклоняет голос постороннего в опросе с --members-only. Голос
+// из канала опроса не проверяется: написать в канал может только его
+// участник. Если Mattermost не ответил, голос принимается или отклоняется
+// по MembersOnlyOptions.FailOpen.
+func (s *PollServiceImpl) checkMember(ctx context.Context, poll models.Poll, userID string) error {
+	if !poll.MembersOnly || poll.ChannelID == "" || OriginFrom(ctx).ChannelID == poll.ChannelID {
+		return nil
+	}
+	member, err := s.isMember(ctx, poll.ChannelID, userID)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Bool("fail_open", s.membership.FailOpen).
+			Msg("Не удалось проверить членство голосующего в канале опроса")
+		if s.membership.FailOpen {
+			return nil
+		}
+		return i18n.NewError(i18n.MembersUnavailable)
+	}
+	if !member {
+		return i18n.NewError(i18n.MembersOnlyRejected)
+	}
+	return nil
+}
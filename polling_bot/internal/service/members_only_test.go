@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+// countingMembers отвечает на проверку членства по списку участников
+// канала town или ошибкой err и считает обращения.
+type countingMembers struct {
+	members map[string]bool
+	err     error
+	calls   int
+}
+
+func (m *countingMembers) IsChannelMember(ctx context.Context, channelID, userID string) (bool, error) {
+	m.calls++
+	if m.err != nil {
+		return false, m.err
+	}
+	return channelID == "town" && m.members[userID], nil
+}
+
+// newMembersOnlyService - сервис с проверкой членства members и часами
+// clock; опросы создаются в канале town.
+func newMembersOnlyService(members ChannelMembers, opts MembersOnlyOptions, clock *time.Time) *PollServiceImpl {
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	if members != nil {
+		s.SetChannelMembers(members)
+	}
+	s.SetMembersOnly(opts)
+	s.now = func() time.Time { return *clock }
+	return s
+}
+
+// Тест проверяет голос в опросе с --members-only: посторонний получает
+// отказ, участник из другого канала и из личных сообщений голосует, голос
+// из канала опроса не проверяется, а сбой Mattermost отклоняет или
+// принимает голос по настройке
+func TestAddVote_MembersOnly(t *testing.T) {
+	errMattermost := errors.New("mattermost недоступен")
+	tests := []struct {
+		name       string
+		members    *countingMembers
+		failOpen   bool
+		restricted bool
+		origin     Origin
+		voter      string
+		wantErr    string
+		wantCalls  int
+	}{
+		{
+			name:      "outsider",
+			members:   &countingMembers{members: map[string]bool{"alice": true}},
+			voter:     "mallory",
+			wantErr:   "в этом опросе голосуют только участники его канала",
+			wantCalls: 1,
+		},
+		{
+			name:      "member from another channel",
+			members:   &countingMembers{members: map[string]bool{"alice": true}},
+			origin:    Origin{ChannelID: "other"},
+			voter:     "alice",
+			wantCalls: 1,
+		},
+		{
+			name:    "poll channel",
+			members: &countingMembers{},
+			origin:  Origin{ChannelID: "town"},
+			voter:   "alice",
+		},
+		{
+			name:      "direct message",
+			members:   &countingMembers{members: map[string]bool{"alice": true}},
+			origin:    Origin{ChannelID: "dm", Direct: true},
+			voter:     "alice",
+			wantCalls: 1,
+		},
+		{
+			// Проверка канала и проверка участника спрашивают Mattermost
+			// один раз: второй ответ берётся из кэша
+			name:       "direct message to channel-only poll",
+			members:    &countingMembers{members: map[string]bool{"alice": true}},
+			restricted: true,
+			origin:     Origin{ChannelID: "dm", Direct: true},
+			voter:      "alice",
+			wantCalls:  1,
+		},
+		{
+			name:       "outsider in direct messages to channel-only poll",
+			members:    &countingMembers{},
+			restricted: true,
+			origin:     Origin{ChannelID: "dm", Direct: true},
+			voter:      "mallory",
+			wantErr:    "этот опрос доступен только в своём канале",
+			wantCalls:  1,
+		},
+		{
+			name:      "fail closed",
+			members:   &countingMembers{err: errMattermost},
+			voter:     "alice",
+			wantErr:   "не удалось проверить, что вы участник канала опроса, попробуйте позже",
+			wantCalls: 1,
+		},
+		{
+			name:      "fail open",
+			members:   &countingMembers{err: errMattermost},
+			failOpen:  true,
+			voter:     "alice",
+			wantCalls: 1,
+		},
+		{
+			name:    "no checker",
+			voter:   "alice",
+			wantErr: "не удалось проверить, что вы участник канала опроса, попробуйте позже",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+			var members ChannelMembers
+			if tt.members != nil {
+				members = tt.members
+			}
+			s := newMembersOnlyService(members, MembersOnlyOptions{CacheTTL: time.Minute, FailOpen: tt.failOpen}, &clock)
+			ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+			created, err := s.CreatePollWithID(WithOrigin(ctx, Origin{ChannelID: "town"}), "creator1", "Релизим?", []string{"Да", "Нет"},
+				CreateOptions{MembersOnly: true, RestrictToChannel: tt.restricted})
+			require.NoError(t, err)
+
+			reply, err := s.AddVote(WithOrigin(ctx, tt.origin), tt.voter, created.ID, []string{"Да"})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "Ваш голос в голосовании "+created.ID+" записан: Да", reply)
+			}
+			if tt.members != nil {
+				assert.Equal(t, tt.wantCalls, tt.members.calls, "обращения к Mattermost")
+			}
+		})
+	}
+}
+
+// Тест проверяет кэш членства: подтверждённое членство помнится до срока и
+// после него проверяется заново, отказ не запоминается, а без срока
+// Mattermost спрашивается при каждом голосе
+func TestAddVote_MembersOnlyCache(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		// Голоса пользователя в опросах подряд и сдвиг часов перед каждым
+		voter     string
+		advance   []time.Duration
+		wantCalls int
+	}{
+		{name: "cached", ttl: time.Minute, voter: "alice", advance: []time.Duration{0, 30 * time.Second, 20 * time.Second}, wantCalls: 1},
+		{name: "expired", ttl: time.Minute, voter: "alice", advance: []time.Duration{0, 30 * time.Second, 30 * time.Second}, wantCalls: 2},
+		{name: "not cached when rejected", ttl: time.Minute, voter: "mallory", advance: []time.Duration{0, 0, 0}, wantCalls: 3},
+		{name: "no cache", voter: "alice", advance: []time.Duration{0, 0, 0}, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+			members := &countingMembers{members: map[string]bool{"alice": true}}
+			s := newMembersOnlyService(members, MembersOnlyOptions{CacheTTL: tt.ttl}, &clock)
+			ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+
+			for _, advance := range tt.advance {
+				created, err := s.CreatePollWithID(WithOrigin(ctx, Origin{ChannelID: "town"}), "creator1", "Релизим?", []string{"Да", "Нет"},
+					CreateOptions{MembersOnly: true})
+				require.NoError(t, err)
+				clock = clock.Add(advance)
+				_, err = s.AddVote(ctx, tt.voter, created.ID, []string{"Да"})
+				if tt.voter == "alice" {
+					require.NoError(t, err)
+				} else {
+					require.Error(t, err)
+				}
+			}
+			assert.Equal(t, tt.wantCalls, members.calls)
+		})
+	}
+}
+
+// Тест проверяет, что опрос с --members-only создаётся только в канале
+func TestCreatePoll_MembersOnly(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+	_, err := s.CreatePoll(ctx, "creator1", "Релизим?", []string{"Да", "Нет"}, CreateOptions{MembersOnly: true})
+	assert.EqualError(t, err, "флаг --members-only работает только в канале, а не в личных сообщениях")
+
+	reply, err := s.CreatePoll(WithOrigin(ctx, Origin{ChannelID: "town"}), "creator1", "Релизим?", []string{"Да", "Нет"}, CreateOptions{MembersOnly: true})
+	require.NoError(t, err)
+	assert.Contains(t, reply, "Голосовать могут только участники этого канала\n")
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// GetUserVote возвращает бюллетень пользователя в написании вариантов опроса,
+// в рейтинговом опросе - по убыванию предпочтения. voted сообщает, голосовал
+// ли пользователь: у голосов, поданных до появления бюллетеней, choices пуст.
+// Закрытие опроса на ответ не влияет.
+// У воздержавшегося choices пуст.
+func (s *PollServiceImpl) GetUserVote(ctx context.Context, userID, pollID string) (choices []string, voted bool, err error) {
+	pollID, err = normalizePollID(pollID)
+	if err != nil {
+		return nil, false, err
+	}
+	vote, voted, err := s.userVote(ctx, userID, pollID)
+	return append([]string(nil), vote.Choices...), voted, err
+}
+
+func (s *PollServiceImpl) userVote(ctx context.Context, userID, pollID string) (models.Vote, bool, error) {
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return models.Vote{}, false, s.storageError(err, i18n.OpGetPoll)
+	}
+	// Как и голосовать, смотреть свой голос можно из личных сообщений
+	if err := s.checkChannel(ctx, poll, userID, true); err != nil {
+		return models.Vote{}, false, err
+	}
+	vote, err := s.votes.GetVote(ctx, pollID, poll.VoterKey(s.anonymousSecret, userID))
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return models.Vote{}, false, nil
+	case err != nil:
+		return models.Vote{}, false, s.storageError(err, i18n.OpGetVote)
+	}
+	return vote, true, nil
+}
+
+// MyVote показывает пользователю его собственный голос.
+func (s *PollServiceImpl) MyVote(ctx context.Context, userID, pollID string) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	vote, voted, err := s.userVote(ctx, userID, pollID)
+	if err != nil {
+		return "", err
+	}
+
+	return renderMyVote(i18n.FromContext(ctx), pollID, vote, voted), nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+const myVotePollID = "123e4567-e89b-12d3-a456-426614174000"
+
+// Тест проверяет, что myvote показывает записанный выбор пользователя
+func TestMyVote(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranked bool
+		closed bool
+		// Голос пользователя u1; nil - не голосовал
+		choices []string
+		want    string
+	}{
+		{name: "single choice", choices: []string{"пицца"},
+			want: "Ваш голос в опросе " + myVotePollID + ": Пицца"},
+		{name: "closed poll", closed: true, choices: []string{"Суши"},
+			want: "Ваш голос в опросе " + myVotePollID + ": Суши"},
+		{name: "ranked", ranked: true, choices: []string{"Суши", "Пицца", "Паста"},
+			want: "Ваш рейтинг в опросе " + myVotePollID + ":\n1. Суши\n2. Пицца\n3. Паста\n"},
+		{name: "partial ranking", ranked: true, choices: []string{"паста", "суши"},
+			want: "Ваш рейтинг в опросе " + myVotePollID + ":\n1. Паста\n2. Суши\n"},
+		{name: "ranked single", ranked: true, choices: []string{"Пицца"},
+			want: "Ваш голос в опросе " + myVotePollID + ": Пицца"},
+		{name: "not voted",
+			want: "Вы ещё не голосовали в опросе " + myVotePollID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			require.NoError(t, repo.SavePoll(ctx, models.Poll{
+				ID:          myVotePollID,
+				Creator:     "creator1",
+				Question:    "Где обедаем?",
+				Options:     map[string]int{"Пицца": 0, "Суши": 0, "Паста": 0},
+				OptionOrder: []string{"Пицца", "Суши", "Паста"},
+				Ranked:      tt.ranked,
+			}))
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			// Чужой голос не должен попасть в ответ
+			_, err := s.AddVote(ctx, "u2", myVotePollID, []string{"Паста"})
+			require.NoError(t, err)
+			if tt.choices != nil {
+				_, err := s.AddVote(ctx, "u1", myVotePollID, tt.choices)
+				require.NoError(t, err)
+			}
+			if tt.closed {
+				_, err := s.EndPoll(ctx, "creator1", myVotePollID)
+				require.NoError(t, err)
+			}
+
+			got, err := s.MyVote(ctx, "u1", myVotePollID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Тест проверяет голос, поданный до появления бюллетеней
+func TestMyVote_LegacyVote(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	require.NoError(t, repo.SavePoll(ctx, models.Poll{
+		ID:       myVotePollID,
+		Creator:  "creator1",
+		Question: "Где обедаем?",
+		Options:  map[string]int{"Пицца": 1},
+	}))
+	votes := repository.NewMemoryVoteRepo(repo)
+	require.NoError(t, votes.ImportVotes(ctx, []models.Vote{{PollID: myVotePollID, UserID: "u1"}}))
+	s := NewPollService(repo, votes, zerolog.Nop())
+
+	choices, voted, err := s.GetUserVote(ctx, "u1", myVotePollID)
+	require.NoError(t, err)
+	assert.True(t, voted)
+	assert.Empty(t, choices)
+
+	got, err := s.MyVote(ctx, "u1", myVotePollID)
+	require.NoError(t, err)
+	assert.Equal(t, "Вы голосовали в опросе "+myVotePollID+", но выбор не сохранён: голос подан до обновления бота", got)
+}
+
+// Тест проверяет ошибки myvote
+func TestMyVote_Errors(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	require.NoError(t, repo.SavePoll(ctx, models.Poll{
+		ID:                myVotePollID,
+		Creator:           "creator1",
+		Question:          "Где обедаем?",
+		Options:           map[string]int{"Пицца": 1},
+		ChannelID:         "c1",
+		RestrictToChannel: true,
+	}))
+	votes := repository.NewMemoryVoteRepo(repo)
+	require.NoError(t, votes.ImportVotes(ctx, []models.Vote{{PollID: myVotePollID, UserID: "u1", Choices: []string{"Пицца"}}}))
+	s := NewPollService(repo, votes, zerolog.Nop())
+
+	_, err := s.MyVote(ctx, "u1", "00000000-0000-0000-0000-000000000000")
+	assert.EqualError(t, err, "опрос не найден")
+
+	_, err = s.MyVote(WithOrigin(ctx, Origin{ChannelID: "c2"}), "u1", myVotePollID)
+	assert.EqualError(t, err, "этот опрос доступен только в своём канале")
+
+	got, err := s.MyVote(WithOrigin(ctx, Origin{ChannelID: "c1"}), "u1", myVotePollID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ваш голос в опросе "+myVotePollID+": Пицца", got)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// NotifyOptions - рассылка итогов в личные сообщения участникам закрытого опроса.
+type NotifyOptions struct {
+	// Рассылать итоги во всех новых опросах, а не только созданных с --notify-voters
+	Default bool
+	// Сколько личных сообщений отправлять одновременно
+	Concurrency int
+	// Не чаще одного сообщения за Interval; 0 - без ограничения
+	Interval time.Duration
+}
+
+// SetVoterNotifications задаёт, как рассылать итоги участникам закрытых
+// опросов. Сообщения отправляет DirectMessenger.
+func (s *PollServiceImpl) SetVoterNotifications(opts NotifyOptions) {
+	s.notify = opts
+}
+
+// notifyVoters рассылает итоги закрытого опроса его участникам, если опрос
+// создан с рассылкой. Участники анонимного опроса не известны. Рассылка
+// большого опроса занимает время, поэтому идёт в фоне и переживает
+// завершение команды, закрывшей опрос.
+func (s *PollServiceImpl) notifyVoters(ctx context.Context, poll models.Poll) {
+	if !poll.NotifyVoters || poll.Anonymous() || s.messenger == nil {
+		return
+	}
+	go s.sendResults(context.WithoutCancel(ctx), poll)
+}
+
+// sendResults отправляет итоги каждому участнику не более чем в
+// Concurrency потоков и не чаще раза в Interval, пропуская тех, кому
+// сообщение не доставлено, и сообщает создателю, скольким участникам
+// итоги дошли. Опрос без голосов не рассылается.
+func (s *PollServiceImpl) sendResults(ctx context.Context, poll models.Poll) {
+	votes, err := s.votes.ListVotes(ctx, poll.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("poll_id", poll.ID).Msg("Не удалось получить участников опроса для рассылки итогов")
+		return
+	}
+	if len(votes) == 0 {
+		return
+	}
+	loc := i18n.FromContext(ctx)
+	message := loc.T(i18n.NotifyResults, poll.ID) + renderResults(loc, poll, voteBallots(votes))
+
+	// ListVotes возвращает голоса в порядке ID участников
+	voters := make([]string, 0, len(votes))
+	for _, vote := range votes {
+		voters = append(voters, vote.UserID)
+	}
+
+	var tick <-chan time.Time
+	if s.notify.Interval > 0 {
+		ticker := time.NewTicker(s.notify.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var failed atomic.Int64
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	for range max(s.notify.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userID := range jobs {
+				if err := s.messenger.SendDirect(ctx, userID, message); err != nil {
+					failed.Add(1)
+					s.logger.Warn().Err(err).Str("poll_id", poll.ID).Str("user_id", userID).
+						Msg("Не удалось отправить участнику итоги опроса")
+				}
+			}
+		}()
+	}
+	for _, userID := range voters {
+		if tick != nil {
+			<-tick
+		}
+		jobs <- userID
+	}
+	close(jobs)
+	wg.Wait()
+
+	sent := len(voters) - int(failed.Load())
+	s.logger.Info().Str("poll_id", poll.ID).Int("sent", sent).Int("failed", int(failed.Load())).
+		Msg("Итоги опроса разосланы участникам")
+	if err := s.messenger.SendDirect(ctx, poll.Creator, loc.T(i18n.NotifySummary, poll.ID, sent, len(voters))); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось сообщить создателю о рассылке итогов")
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/repository"
+)
+
+// notifyMessenger запоминает личные сообщения и сообщает о сводке для
+// создателя: ею рассылка заканчивается
+type notifyMessenger struct {
+	creator string
+	failFor map[string]bool
+	delay   time.Duration
+
+	mu       sync.Mutex
+	sent     map[string]string
+	inFlight int
+	peak     int
+	done     chan string
+}
+
+func newNotifyMessenger(creator string) *notifyMessenger {
+	return &notifyMessenger{creator: creator, sent: map[string]string{}, done: make(chan string, 1)}
+}
+
+func (m *notifyMessenger) SendDirect(ctx context.Context, userID, message string) error {
+	if userID == m.creator {
+		m.done <- message
+		return nil
+	}
+	m.mu.Lock()
+	m.inFlight++
+	m.peak = max(m.peak, m.inFlight)
+	m.mu.Unlock()
+	time.Sleep(m.delay)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight--
+	if m.failFor[userID] {
+		return errors.New("личный канал не создан")
+	}
+	m.sent[userID] = message
+	return nil
+}
+
+func (m *notifyMessenger) summary(t *testing.T) string {
+	t.Helper()
+	select {
+	case message := <-m.done:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("Создатель не получил сводку рассылки")
+		return ""
+	}
+}
+
+// newNotifyPoll создаёт опрос с голосами участников voters
+func newNotifyPoll(t *testing.T, s *PollServiceImpl, opts CreateOptions, voters ...string) string {
+	t.Helper()
+	ctx := WithOrigin(context.Background(), Origin{PostID: "post1", ChannelID: "c1"})
+	created, err := s.createPoll(ctx, "creator1", "Обед?", []string{"Пицца", "Суши"}, opts, "")
+	require.NoError(t, err)
+	for _, userID := range voters {
+		_, err := s.AddVote(ctx, userID, created.ID, []string{"Пицца"})
+		require.NoError(t, err)
+	}
+	return created.ID
+}
+
+// Тест проверяет рассылку итогов участникам при завершении опроса
+// с пропуском тех, кому не удалось написать
+func TestEndPoll_NotifyVoters(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	messenger := newNotifyMessenger("creator1")
+	messenger.failFor = map[string]bool{"u2": true}
+	messenger.delay = 5 * time.Millisecond
+	s.SetDirectMessenger(messenger)
+	s.SetVoterNotifications(NotifyOptions{Concurrency: 2, Interval: time.Millisecond})
+
+	voters := []string{"u1", "u2", "u3", "u4", "u5", "u6"}
+	pollID := newNotifyPoll(t, s, CreateOptions{NotifyVoters: true}, voters...)
+	_, err := s.EndPoll(context.Background(), "creator1", pollID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Итоги опроса "+pollID+" отправлены участникам: 5 из 6", messenger.summary(t))
+	messenger.mu.Lock()
+	defer messenger.mu.Unlock()
+	assert.Len(t, messenger.sent, 5)
+	assert.NotContains(t, messenger.sent, "u2")
+	assert.Contains(t, messenger.sent["u1"], "Опрос "+pollID+", в котором вы голосовали, завершён")
+	assert.Contains(t, messenger.sent["u1"], "- Пицца: 6 голосов")
+	assert.LessOrEqual(t, messenger.peak, 2, "одновременно не больше Concurrency сообщений")
+}
+
+// Тест проверяет рассылку итогов при закрытии опроса по кворуму
+// и рассылку по умолчанию из конфигурации
+func TestQuorum_NotifyVotersByDefault(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	messenger := newNotifyMessenger("creator1")
+	s.SetDirectMessenger(messenger)
+	s.SetVoterNotifications(NotifyOptions{Default: true})
+
+	pollID := newNotifyPoll(t, s, CreateOptions{Quorum: 2}, "u1", "u2")
+
+	assert.Equal(t, "Итоги опроса "+pollID+" отправлены участникам: 2 из 2", messenger.summary(t))
+	messenger.mu.Lock()
+	defer messenger.mu.Unlock()
+	assert.Len(t, messenger.sent, 2)
+}
+
+// Тест проверяет, что без флага итоги не рассылаются
+func TestEndPoll_NoNotify(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	messenger := newNotifyMessenger("creator1")
+	s.SetDirectMessenger(messenger)
+
+	pollID := newNotifyPoll(t, s, CreateOptions{}, "u1")
+	_, err := s.EndPoll(context.Background(), "creator1", pollID)
+	require.NoError(t, err)
+
+	select {
+	case message := <-messenger.done:
+		t.Fatalf("Неожиданная рассылка: %s", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Empty(t, messenger.sent)
+}
+
+// Тест проверяет, что повторное завершение опроса отклоняется и не
+// рассылает итоги и не пишет завершение в журнал второй раз
+func TestEndPoll_AlreadyClosed(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	journal := repository.NewMemoryAuditRepo()
+	s.SetAuditRepository(journal)
+	messenger := newNotifyMessenger("creator1")
+	s.SetDirectMessenger(messenger)
+
+	pollID := newNotifyPoll(t, s, CreateOptions{NotifyVoters: true}, "u1")
+	_, err := s.EndPoll(context.Background(), "creator1", pollID)
+	require.NoError(t, err)
+	messenger.summary(t)
+
+	_, err = s.EndPoll(context.Background(), "creator1", pollID)
+	assert.EqualError(t, err, "голосование "+pollID+" уже окончено")
+	select {
+	case message := <-messenger.done:
+		t.Fatalf("Неожиданная повторная рассылка: %s", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+	events, err := journal.ListEvents(context.Background(), pollID, 10)
+	require.NoError(t, err)
+	var ended int
+	for _, event := range events {
+		if event.Action == audit.ActionEnded {
+			ended++
+		}
+	}
+	assert.Equal(t, 1, ended)
+}
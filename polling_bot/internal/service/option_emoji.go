@@ -0,0 +1,61 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"polling_bot/internal/models"
+)
+
+// Эмодзи перед текстом варианта: символы эмодзи или код Mattermost, затем
+// ":" и текст - "🍕:Пицца", ":pizza::Пицца". Модификаторы цвета кожи,
+// соединитель и селектор вариантов входят в эмодзи.
+var optionEmojiRegex = regexp.MustCompile(`^(:[a-z0-9_+-]+:|[\p{So}\x{1F3FB}-\x{1F3FF}\x{200D}\x{FE0F}\x{20E3}]+):(.*)$`)
+
+// splitOptionEmoji отделяет эмодзи от текста варианта. Вариант без эмодзи
+// или с пустым текстом после эмодзи возвращается как есть.
+func splitOptionEmoji(option string) (emoji, text string) {
+	m := optionEmojiRegex.FindStringSubmatch(option)
+	if m == nil || strings.TrimSpace(m[2]) == "" {
+		return "", option
+	}
+	return m[1], strings.TrimSpace(m[2])
+}
+
+// splitOptions отделяет эмодзи от вариантов. emoji - эмодзи по тексту
+// варианта; nil, если эмодзи нет ни у одного.
+func splitOptions(options []string) (texts []string, emoji map[string]string) {
+	texts = make([]string, len(options))
+	for i, option := range options {
+		e, text := splitOptionEmoji(option)
+		texts[i] = text
+		if e != "" {
+			if emoji == nil {
+				emoji = make(map[string]string)
+			}
+			emoji[text] = e
+		}
+	}
+	return texts, emoji
+}
+
+// joinOptionEmoji возвращает варианты опроса в синтаксисе создания, с
+// эмодзи перед текстом: так clone переносит эмодзи в новый опрос.
+func joinOptionEmoji(options []models.Option) []string {
+	out := make([]string, len(options))
+	for i, option := range options {
+		out[i] = option.Text
+		if option.Emoji != "" {
+			out[i] = option.Emoji + ":" + option.Text
+		}
+	}
+	return out
+}
+
+// optionLabel - вариант для показа: эмодзи, если задано, и текст.
+func optionLabel(emoji, text string) string {
+	if emoji == "" {
+		return text
+	}
+	return emoji + " " + text
+}
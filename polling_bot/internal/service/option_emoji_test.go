@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет, какие префиксы варианта считаются эмодзи
+func TestSplitOptionEmoji(t *testing.T) {
+	tests := []struct {
+		option    string
+		wantEmoji string
+		wantText  string
+	}{
+		{option: "🍕:Пицца", wantEmoji: "🍕", wantText: "Пицца"},
+		{option: "🍕: Пицца", wantEmoji: "🍕", wantText: "Пицца"},
+		{option: ":pizza::Пицца", wantEmoji: ":pizza:", wantText: "Пицца"},
+		{option: "👍🏽:Да", wantEmoji: "👍🏽", wantText: "Да"},
+		{option: "Пицца", wantText: "Пицца"},
+		{option: "Время: 12:00", wantText: "Время: 12:00"},
+		{option: "🍕:", wantText: "🍕:"},
+		{option: ":pizza:", wantText: ":pizza:"},
+		{option: "🍕 Пицца", wantText: "🍕 Пицца"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.option, func(t *testing.T) {
+			emoji, text := splitOptionEmoji(tt.option)
+			assert.Equal(t, tt.wantEmoji, emoji)
+			assert.Equal(t, tt.wantText, text)
+		})
+	}
+}
+
+// Тест проверяет опрос с эмодзи вариантов: эмодзи хранятся отдельно от
+// текста, повтор определяется по тексту без учёта регистра, голосовать
+// можно текстом или номером варианта, а clone переносит эмодзи
+func TestCreatePoll_OptionEmoji(t *testing.T) {
+	ctx := WithOrigin(i18n.WithLocalizer(context.Background(), i18n.New("ru")), Origin{PostID: "post1", ChannelID: "c1"})
+
+	for _, options := range [][]string{{"🍕:Пицца", "Пицца"}, {"Пицца", "пицца "}} {
+		repo := repository.NewMemoryPollRepo()
+		s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+		_, err := s.CreatePoll(ctx, "creator1", "Где обедаем?", options, CreateOptions{})
+		var localized *i18n.Error
+		require.ErrorAs(t, err, &localized, "варианты %q", options)
+		assert.Equal(t, i18n.DuplicateOptions, localized.Key, "варианты %q", options)
+	}
+
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"🍕:Пицца", ":sushi::Суши", "3"}, CreateOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, created.Message, "1. 🍕 Пицца\n2. :sushi: Суши\n3. 3\n")
+
+	poll, err := repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Пицца", "Суши", "3"}, poll.OptionOrder)
+	assert.Equal(t, map[string]string{"Пицца": "🍕", "Суши": ":sushi:"}, poll.OptionEmoji)
+
+	votes := []struct {
+		user   string
+		choice string
+		want   string
+	}{
+		{user: "user1", choice: "пицца", want: "Пицца"},
+		{user: "user2", choice: "2", want: "Суши"},
+		// Текст варианта важнее номера
+		{user: "user3", choice: "3", want: "3"},
+	}
+	for i, vote := range votes {
+		voteCtx := WithOrigin(ctx, Origin{PostID: "vote" + vote.user, ChannelID: "c1"})
+		reply, err := s.AddVote(voteCtx, vote.user, created.ID, []string{vote.choice})
+		require.NoError(t, err, "голос %d", i)
+		assert.Contains(t, reply, vote.want)
+	}
+	_, err = s.AddVote(WithOrigin(ctx, Origin{PostID: "vote4", ChannelID: "c1"}), "user4", created.ID, []string{"4"})
+	assert.Error(t, err, "варианта с номером 4 нет")
+
+	poll, err = repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Пицца": 1, "Суши": 1, "3": 1}, poll.Options)
+
+	cloneCtx := WithOrigin(ctx, Origin{PostID: "clone1", ChannelID: "c1"})
+	reply, err := s.ClonePoll(cloneCtx, "creator1", created.ID, CloneOverrides{})
+	require.NoError(t, err)
+	assert.Contains(t, reply, "1. 🍕 Пицца\n2. :sushi: Суши\n")
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// Наибольшее расстояние Левенштейна, при котором вариант предлагается как опечатка
+const maxSuggestDistance = 2
+
+// findOption ищет вариант опроса по тексту, как matchOption, или по номеру
+// варианта (models.Option.ID). Текст важнее номера: вариант «2» находится
+// по тексту, даже если есть второй вариант.
+func findOption(poll models.Poll, choice string) (match, suggestion string) {
+	match, suggestion = matchOption(poll.Options, choice)
+	if match != "" {
+		return match, ""
+	}
+	if id, err := strconv.Atoi(strings.TrimSpace(choice)); err == nil {
+		if option, ok := poll.OptionByID(id); ok {
+			return option.Text, ""
+		}
+	}
+	return "", suggestion
+}
+
+// joinWords разбирает голос за вариант из нескольких слов, набранный без
+// кавычек: «vote ID Суши бар» приходит выбором ["Суши", "бар"]. Если слова
+// вместе называют вариант, голос - за него. В рейтинговом опросе те же
+// слова могут быть и порядком вариантов; если подходят оба прочтения,
+// угадывать нельзя. Иначе выбор возвращается как есть и разбирается
+// обычным порядком.
+func joinWords(poll models.Poll, choices []string) ([]string, error) {
+	if len(choices) < 2 {
+		return choices, nil
+	}
+	joined := strings.Join(choices, " ")
+	match, suggestion := matchOption(poll.Options, joined)
+	if match == "" {
+		// Опечатку в варианте из нескольких слов подсказать можно, только
+		// если выбор не может быть несколькими вариантами
+		if poll.Ranked {
+			return choices, nil
+		}
+		if suggestion != "" {
+			return nil, i18n.NewError(i18n.OptionSuggestion, joined, suggestion)
+		}
+		// Если и по отдельности слова не варианты, это не лишний выбор
+		// в опросе с одним ответом, а неизвестный вариант
+		if _, err := resolveBallot(poll, choices); err != nil {
+			return nil, i18n.NewError(i18n.OptionNotFound, joined)
+		}
+		return choices, nil
+	}
+	if poll.Ranked {
+		if ballot, err := resolveBallot(poll, choices); err == nil {
+			return nil, i18n.NewError(i18n.AmbiguousVote, match, strings.Join(ballot, " > "))
+		}
+	}
+	return []string{match}, nil
+}
+
+// matchOption ищет вариант, соответствующий выбору пользователя. Совпадение
+// без учёта регистра и лишних пробелов принимается сразу (match). Иначе
+// возвращается подсказка - единственный ближайший вариант на расстоянии
+// не больше maxSuggestDistance; при равных кандидатах подсказки нет.
+func matchOption(options map[string]int, choice string) (match, suggestion string) {
+	if _, ok := options[choice]; ok {
+		return choice, ""
+	}
+
+	normalized := normalizeOption(choice)
+	var folded []string
+	for option := range options {
+		if normalizeOption(option) == normalized {
+			folded = append(folded, option)
+		}
+	}
+	if len(folded) == 1 {
+		return folded[0], ""
+	}
+	if len(folded) > 1 {
+		// Варианты отличаются только регистром - угадывать нельзя
+		return "", ""
+	}
+
+	best, bestDistance, ties := "", maxSuggestDistance+1, 0
+	for option := range options {
+		d := levenshtein(normalized, normalizeOption(option))
+		switch {
+		case d < bestDistance:
+			best, bestDistance, ties = option, d, 1
+		case d == bestDistance:
+			ties++
+		}
+	}
+	if ties != 1 {
+		return "", ""
+	}
+	return "", best
+}
+
+// normalizeOption - ключ сравнения вариантов: текст варианта, как его
+// хранит normalizeOptionText, в нижнем регистре.
+func normalizeOption(s string) string {
+	return strings.ToLower(normalizeOptionText(s))
+}
+
+// levenshtein считает расстояние редактирования по символам, а не байтам,
+// чтобы кириллица не считалась вдвое дальше латиницы.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"polling_bot/internal/models"
+)
+
+// Тест проверяет сопоставление выбора с вариантами и подсказки при опечатках
+func TestMatchOption(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        []string
+		choice         string
+		wantMatch      string
+		wantSuggestion string
+	}{
+		{name: "exact", options: []string{"Option1", "Option2"}, choice: "Option1", wantMatch: "Option1"},
+		{name: "case insensitive", options: []string{"Option1", "Option2"}, choice: "option1", wantMatch: "Option1"},
+		{name: "extra whitespace", options: []string{"Красный цвет"}, choice: "  красный   цвет ", wantMatch: "Красный цвет"},
+		{name: "cyrillic case", options: []string{"Да", "Нет"}, choice: "ДА", wantMatch: "Да"},
+		{name: "latin typo", options: []string{"Option1", "Banana"}, choice: "Optin1", wantSuggestion: "Option1"},
+		{name: "mixed script typo", options: []string{"Option1", "Banana"}, choice: "Оptin1", wantSuggestion: "Option1"},
+		{name: "cyrillic typo", options: []string{"Пицца", "Суши"}, choice: "пица", wantSuggestion: "Пицца"},
+		{name: "too far", options: []string{"Пицца", "Суши"}, choice: "Бургер"},
+		{name: "ambiguous", options: []string{"Option1", "Option2"}, choice: "Option3"},
+		{name: "options differ only by case", options: []string{"ok", "OK"}, choice: "Ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := make(map[string]int, len(tt.options))
+			for _, option := range tt.options {
+				options[option] = 0
+			}
+
+			match, suggestion := matchOption(options, tt.choice)
+			assert.Equal(t, tt.wantMatch, match)
+			assert.Equal(t, tt.wantSuggestion, suggestion)
+		})
+	}
+}
+
+// Тест проверяет разбор голоса за вариант из нескольких слов без кавычек
+func TestJoinWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		ranked  bool
+		choices []string
+		want    []string
+		wantErr string
+	}{
+		{name: "single word", options: []string{"Пицца", "Суши бар"}, choices: []string{"Пицца"}, want: []string{"Пицца"}},
+		{name: "joined option", options: []string{"Пицца", "Суши бар"}, choices: []string{"Суши", "бар"}, want: []string{"Суши бар"}},
+		{name: "joined option in any case", options: []string{"Option One", "Option Two"}, choices: []string{"option", "ONE"}, want: []string{"Option One"}},
+		{name: "joined typo", options: []string{"Option One", "Banana"}, choices: []string{"Option", "Onee"}, wantErr: "вариант 'Option Onee' не найден, возможно вы имели в виду 'Option One'?"},
+		{name: "two options in a single choice poll", options: []string{"Пицца", "Суши"}, choices: []string{"Пицца", "Суши"}, want: []string{"Пицца", "Суши"}},
+		{name: "unknown words", options: []string{"Пицца", "Суши бар"}, choices: []string{"Бургер", "кинг"}, wantErr: "вариант 'Бургер кинг' не существует"},
+		{name: "ranking", options: []string{"Пицца", "Суши", "Суши бар"}, ranked: true, choices: []string{"Суши", "Пицца"}, want: []string{"Суши", "Пицца"}},
+		{name: "joined option in a ranked poll", options: []string{"Пицца", "Суши бар"}, ranked: true, choices: []string{"Суши", "бар"}, want: []string{"Суши бар"}},
+		{
+			name:    "ambiguous join",
+			options: []string{"Нью", "Йорк", "Нью Йорк"},
+			ranked:  true,
+			choices: []string{"Нью", "Йорк"},
+			wantErr: "непонятно, голос за вариант 'Нью Йорк' или за варианты по порядку Нью > Йорк; вариант из нескольких слов возьмите в кавычки",
+		},
+		{name: "unknown words in a ranked poll", options: []string{"Пицца", "Суши"}, ranked: true, choices: []string{"Бургер", "кинг"}, want: []string{"Бургер", "кинг"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := models.Poll{Options: make(map[string]int, len(tt.options)), Ranked: tt.ranked}
+			for _, option := range tt.options {
+				poll.Options[option] = 0
+			}
+
+			got, err := joinWords(poll, tt.choices)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Тест проверяет, что расстояние считается по символам
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("", ""))
+	assert.Equal(t, 3, levenshtein("", "абв"))
+	assert.Equal(t, 1, levenshtein("пица", "пицца"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+}
@@ -0,0 +1,23 @@
+package service
+
+import "context"
+
+// Origin описывает сообщение Mattermost, из которого пришла команда.
+type Origin struct {
+	PostID    string
+	ChannelID string
+	// Direct - команда пришла в личных сообщениях
+	Direct bool
+}
+
+type originKey struct{}
+
+func WithOrigin(ctx context.Context, origin Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// OriginFrom возвращает источник команды; пустой Origin, если он не задан.
+func OriginFrom(ctx context.Context) Origin {
+	origin, _ := ctx.Value(originKey{}).(Origin)
+	return origin
+}
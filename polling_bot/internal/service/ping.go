@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polling_bot/internal/buildinfo"
+	"polling_bot/internal/i18n"
+)
+
+// StoragePinger измеряет время ответа хранилища опросов.
+type StoragePinger interface {
+	Ping(ctx context.Context) (time.Duration, error)
+}
+
+// BuildInfo - сборка, время запуска и возможности бота для команд ping
+// и version. Пустые версия, коммит и дата показываются как dev.
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+	Started time.Time
+	// Возможности, которые включены или выключены настройками, в порядке вывода
+	Features []Feature
+}
+
+// Feature - возможность бота и включена ли она.
+type Feature struct {
+	Name    string
+	Enabled bool
+}
+
+// Info возвращает сведения о сборке с dev вместо пустых значений.
+func (b BuildInfo) Info() buildinfo.Info {
+	return buildinfo.New(b.Version, b.Commit, b.Date)
+}
+
+// SetStoragePinger задаёт, время ответа какого хранилища показывает
+// команда ping; name - его название в ответе, например "tarantool".
+func (s *PollServiceImpl) SetStoragePinger(name string, pinger StoragePinger) {
+	s.storageName, s.pinger = name, pinger
+}
+
+// SetBuildInfo задаёт сборку, время запуска и возможности бота для команд
+// ping и version.
+func (s *PollServiceImpl) SetBuildInfo(info BuildInfo) {
+	s.build = info
+}
+
+// Ping отвечает, что бот работает, с временем ответа хранилища, временем
+// работы и версией бота. Недоступное хранилище не ошибка команды: ответ
+// и должен показать, какое звено пути не работает.
+func (s *PollServiceImpl) Ping(ctx context.Context) (string, error) {
+	loc := i18n.FromContext(ctx)
+	uptime := formatUptime(s.now().Sub(s.build.Started))
+	version := s.build.Info().Version
+	if s.pinger == nil {
+		return loc.T(i18n.Pong, uptime, version), nil
+	}
+
+	latency, err := s.pinger.Ping(ctx)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("storage", s.storageName).Msg("Хранилище не ответило на ping")
+		return loc.T(i18n.PongStorage, s.storageName, loc.T(i18n.PongStorageDown), uptime, version), nil
+	}
+	return loc.T(i18n.PongStorage, s.storageName, formatLatency(latency), uptime, version), nil
+}
+
+// Version показывает сборку бота, время работы, хранилище и возможности,
+// включённые настройками: по ответу видно, что именно работает у
+// пользователя, который сообщил о проблеме.
+func (s *PollServiceImpl) Version(ctx context.Context) (string, error) {
+	return renderVersion(i18n.FromContext(ctx), s.build, s.storageName, formatUptime(s.now().Sub(s.build.Started))), nil
+}
+
+// formatLatency показывает время ответа в целых миллисекундах: 3ms.
+func formatLatency(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// formatUptime показывает время работы с точностью до минуты: 4h12m, 7m.
+func formatUptime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	minutes := int(d / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+// fakePinger отвечает на ping за заданное время или ошибкой
+type fakePinger struct {
+	latency time.Duration
+	err     error
+}
+
+func (p fakePinger) Ping(ctx context.Context) (time.Duration, error) {
+	return p.latency, p.err
+}
+
+// Тест проверяет ответ на ping с временем ответа хранилища, временем
+// работы и версией бота, в том числе при недоступном хранилище
+func TestPing(t *testing.T) {
+	started := time.Date(2025, 3, 3, 6, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		lang    string
+		pinger  StoragePinger
+		version string
+		uptime  time.Duration
+		want    string
+	}{
+		{
+			name:    "storage responds",
+			pinger:  fakePinger{latency: 3*time.Millisecond + 400*time.Microsecond},
+			version: "v1.4.0",
+			uptime:  4*time.Hour + 12*time.Minute + 30*time.Second,
+			want:    "pong — tarantool: 3ms, uptime: 4h12m, версия: v1.4.0",
+		},
+		{
+			name:    "storage is down",
+			pinger:  fakePinger{err: errors.New("Tarantool не отвечает")},
+			version: "v1.4.0",
+			uptime:  7 * time.Minute,
+			want:    "pong — tarantool: недоступен, uptime: 7m, версия: v1.4.0",
+		},
+		{
+			name:   "version not injected",
+			pinger: fakePinger{latency: 0},
+			uptime: 26 * time.Hour,
+			want:   "pong — tarantool: 0ms, uptime: 26h0m, версия: dev",
+		},
+		{
+			name:    "no storage pinger",
+			version: "v1.4.0",
+			uptime:  30 * time.Second,
+			want:    "pong — uptime: 0m, версия: v1.4.0",
+		},
+		{
+			name:    "english",
+			lang:    "en",
+			pinger:  fakePinger{err: errors.New("down")},
+			version: "v1.4.0",
+			uptime:  time.Hour,
+			want:    "pong — tarantool: unavailable, uptime: 1h0m, version: v1.4.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.now = func() time.Time { return started.Add(tt.uptime) }
+			s.SetBuildInfo(BuildInfo{Version: tt.version, Started: started})
+			if tt.pinger != nil {
+				s.SetStoragePinger("tarantool", tt.pinger)
+			}
+			ctx := context.Background()
+			if tt.lang != "" {
+				ctx = i18n.WithLocalizer(ctx, i18n.New(tt.lang))
+			}
+
+			msg, err := s.Ping(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, msg)
+		})
+	}
+}
+
+// Тест проверяет ответ version: сборка, время работы с запуска бота,
+// хранилище из SetStoragePinger и возможности в заданном порядке
+func TestVersion(t *testing.T) {
+	started := time.Date(2025, 3, 3, 6, 0, 0, 0, time.UTC)
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.now = func() time.Time { return started.Add(90 * time.Minute) }
+	s.SetStoragePinger("postgres", fakePinger{})
+	s.SetBuildInfo(BuildInfo{
+		Version:  "v1.4.0",
+		Commit:   "abc1234",
+		Started:  started,
+		Features: []Feature{{Name: "templates", Enabled: true}, {Name: "audit"}},
+	})
+
+	msg, err := s.Version(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "**Бот опросов v1.4.0**\n"+
+		"Коммит: abc1234, собран: dev\n"+
+		"Работает: 1h30m\n"+
+		"Хранилище: postgres\n"+
+		"Возможности:\n- templates: вкл\n- audit: выкл\n", msg)
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"polling_bot/internal/i18n"
+)
+
+// ErrInvalidPollID - в команде передан не ID опроса.
+var ErrInvalidPollID = i18n.NewError(i18n.InvalidPollID)
+
+// normalizePollID проверяет ID опроса из команды до обращения к хранилищу
+// и приводит его к виду, в котором ID хранятся: UUID в нижнем регистре.
+// Строку, которая не разбирается как UUID, хранилище никогда не найдёт,
+// а драйвер может ответить на неё ошибкой запроса вместо «не найден».
+func normalizePollID(pollID string) (string, error) {
+	id, err := uuid.Parse(strings.TrimSpace(pollID))
+	if err != nil {
+		return "", ErrInvalidPollID
+	}
+	return id.String(), nil
+}
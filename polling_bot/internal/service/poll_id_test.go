@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет разбор ID опроса: UUID в любом регистре приводится к
+// нижнему, а строки, похожие на UUID только длиной и алфавитом, отклоняются
+func TestNormalizePollID(t *testing.T) {
+	tests := []struct {
+		name   string
+		pollID string
+		want   string
+	}{
+		{name: "canonical", pollID: "123e4567-e89b-12d3-a456-426614174000", want: "123e4567-e89b-12d3-a456-426614174000"},
+		{name: "upper case", pollID: "123E4567-E89B-12D3-A456-426614174000", want: "123e4567-e89b-12d3-a456-426614174000"},
+		{name: "surrounding spaces", pollID: " 123e4567-e89b-12d3-a456-426614174000\t", want: "123e4567-e89b-12d3-a456-426614174000"},
+		{name: "only dashes", pollID: "------------------------------------"},
+		{name: "misplaced dashes", pollID: "123e4567e-89b-12d3-a456-426614174000"},
+		{name: "not hex", pollID: "123e4567-e89b-12d3-a456-42661417400g"},
+		{name: "injection", pollID: "'; drop"},
+		{name: "empty", pollID: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizePollID(tt.pollID)
+			if tt.want == "" {
+				assert.ErrorIs(t, err, ErrInvalidPollID)
+				assert.Empty(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Тест проверяет, что каждая команда с ID опроса отклоняет неверный ID до
+// обращения к хранилищу и находит опрос по ID в верхнем регистре
+func TestPollIDValidation(t *testing.T) {
+	const pollID = "123e4567-e89b-12d3-a456-426614174000"
+	ctx := context.Background()
+	commands := map[string]func(s *PollServiceImpl, pollID string) error{
+		"vote": func(s *PollServiceImpl, pollID string) error {
+			_, err := s.AddVote(ctx, "u1", pollID, []string{"Да"})
+			return err
+		},
+		"results": func(s *PollServiceImpl, pollID string) error { _, err := s.GetResults(ctx, "u1", pollID); return err },
+		"myvote":  func(s *PollServiceImpl, pollID string) error { _, err := s.MyVote(ctx, "u1", pollID); return err },
+		"user vote": func(s *PollServiceImpl, pollID string) error {
+			_, _, err := s.GetUserVote(ctx, "u1", pollID)
+			return err
+		},
+		"end": func(s *PollServiceImpl, pollID string) error {
+			_, err := s.EndPoll(ctx, "creator1", pollID)
+			return err
+		},
+		"delete": func(s *PollServiceImpl, pollID string) error {
+			_, err := s.DeletePoll(ctx, "creator1", pollID)
+			return err
+		},
+		"winner": func(s *PollServiceImpl, pollID string) error {
+			_, err := s.PickWinner(ctx, "creator1", pollID, "", false)
+			return err
+		},
+		"clone": func(s *PollServiceImpl, pollID string) error {
+			_, err := s.ClonePoll(ctx, "creator1", pollID, CloneOverrides{})
+			return err
+		},
+		"settings": func(s *PollServiceImpl, pollID string) error {
+			_, err := s.PollSettings(ctx, "creator1", pollID)
+			return err
+		},
+		"set": func(s *PollServiceImpl, pollID string) error {
+			_, err := s.UpdateSetting(ctx, "creator1", pollID, SettingChange{Setting: SettingQuorum, Number: 5})
+			return err
+		},
+		"audit": func(s *PollServiceImpl, pollID string) error { _, err := s.AuditLog(ctx, pollID, 0); return err },
+	}
+
+	for name, run := range commands {
+		t.Run(name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			require.NoError(t, repo.SavePoll(ctx, models.Poll{
+				ID:          pollID,
+				Creator:     "creator1",
+				Question:    "Выпускаем?",
+				Options:     map[string]int{"Да": 0, "Нет": 0},
+				OptionOrder: []string{"Да", "Нет"},
+			}))
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.SetAuditRepository(repository.NewMemoryAuditRepo())
+
+			assert.ErrorIs(t, run(s, "'; drop"), ErrInvalidPollID)
+			assert.ErrorIs(t, run(s, "123e4567-e89b-12d3-a456-42661417400z"), ErrInvalidPollID)
+			// Опрос найден; победителя открытого опроса выбрать нельзя, но
+			// это уже не ошибка ID
+			err := run(s, "123E4567-E89B-12D3-A456-426614174000")
+			assert.NotErrorIs(t, err, ErrInvalidPollID)
+			assert.NotErrorIs(t, err, ErrPollNotFound)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import "sync"
+
+// pollLocks - блокировки опросов по ID. Блокировка создаётся при первом
+// обращении и удаляется, когда её больше никто не держит и не ждёт,
+// поэтому карта не растёт с числом опросов.
+//
+// Блокировка действует внутри одного процесса. Хранилище всё равно
+// повторяет проверки голоса при записи: несколько экземпляров бота
+// с общим хранилищем друг друга не ждут.
+type pollLocks struct {
+	mu    sync.Mutex
+	locks map[string]*pollLock
+}
+
+type pollLock struct {
+	sync.Mutex
+	// Сколько вызывающих держат или ждут блокировку
+	refs int
+}
+
+// lock захватывает блокировку опроса pollID и возвращает функцию,
+// которая её отпускает.
+func (l *pollLocks) lock(pollID string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*pollLock)
+	}
+	pl, ok := l.locks[pollID]
+	if !ok {
+		pl = &pollLock{}
+		l.locks[pollID] = pl
+	}
+	pl.refs++
+	l.mu.Unlock()
+
+	pl.Lock()
+	return func() {
+		pl.Unlock()
+		l.mu.Lock()
+		pl.refs--
+		if pl.refs == 0 {
+			delete(l.locks, pollID)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// size возвращает число блокировок в карте.
+func (l *pollLocks) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
+
+// Тест проверяет, что блокировка одного опроса не пускает второго
+// вызывающего, не мешает другим опросам и удаляется после освобождения
+func TestPollLocks(t *testing.T) {
+	var locks pollLocks
+	unlock := locks.lock("p1")
+
+	acquired := make(chan struct{})
+	go func() {
+		release := locks.lock("p1")
+		close(acquired)
+		release()
+	}()
+	locks.lock("p2")()
+
+	select {
+	case <-acquired:
+		t.Fatal("блокировка опроса захвачена дважды")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	<-acquired
+
+	assert.Eventually(t, func() bool { return locks.size() == 0 }, time.Second, time.Millisecond)
+}
+
+// Тест проверяет под -race, что параллельные голоса учитываются точно:
+// 200 разных участников дают 200 голосов, а 200 одновременных голосов
+// одного участника - ровно один
+func TestAddVote_Concurrent(t *testing.T) {
+	const voters = 200
+	const pollID = "123e4567-e89b-12d3-a456-426614174000"
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		user        func(i int) string
+		wantSuccess int
+	}{
+		{name: "distinct users", user: func(i int) string { return fmt.Sprintf("user%d", i) }, wantSuccess: voters},
+		{name: "same user", user: func(int) string { return "user1" }, wantSuccess: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			require.NoError(t, repo.SavePoll(ctx, models.Poll{
+				ID:          pollID,
+				Creator:     "creator1",
+				Question:    "Где обедаем?",
+				Options:     map[string]int{"Пицца": 0, "Суши": 0},
+				OptionOrder: []string{"Пицца", "Суши"},
+			}))
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+			var wg sync.WaitGroup
+			var succeeded, rejected atomic.Int32
+			start := make(chan struct{})
+			for i := range voters {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					// У каждого голоса своё сообщение: это не повторная доставка
+					voteCtx := WithOrigin(ctx, Origin{PostID: fmt.Sprintf("post%d", i), ChannelID: "c1"})
+					option := []string{"Пицца", "Суши"}[i%2]
+					if _, err := s.AddVote(voteCtx, tt.user(i), pollID, []string{option}); err != nil {
+						assert.ErrorContains(t, err, "уже голосовали")
+						rejected.Add(1)
+						return
+					}
+					succeeded.Add(1)
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			assert.Equal(t, int32(tt.wantSuccess), succeeded.Load())
+			assert.Equal(t, int32(voters-tt.wantSuccess), rejected.Load())
+			poll, err := repo.GetPoll(ctx, pollID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSuccess, poll.Options["Пицца"]+poll.Options["Суши"])
+			assert.Zero(t, s.voteLocks.size(), "блокировки опроса удалены")
+		})
+	}
+}
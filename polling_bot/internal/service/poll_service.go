@@ -3,149 +3,956 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
-	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
 	"polling_bot/internal/models"
 	"polling_bot/internal/repository"
-
 )
 
-var pollIDRegex = regexp.MustCompile(`^[a-f0-9\-]{36}$`)
+// Пространство имён для ID опросов, выводимых из (создатель, сообщение)
+var createNamespace = uuid.MustParse("6f1c5d2e-8b0a-4c3e-9f4d-2a7b1e0c9d58")
+
 const (
 	maxQuestionLength = 255
 	maxOptionLength   = 100
 )
 
+var (
+	ErrPollNotFound       = i18n.NewError(i18n.PollNotFound)
+	ErrServiceUnavailable = i18n.NewError(i18n.ServiceUnavailable)
+)
+
+// CreateOptions - настройки опроса, задаваемые флагами команды create.
+type CreateOptions struct {
+	RestrictToChannel bool
+	// Закрыть опрос, когда проголосуют столько участников; 0 - без кворума
+	Quorum int
+	// Не создавать опрос, если в канале уже есть открытый
+	Exclusive bool
+	// Рейтинговое голосование с подсчётом по системе мгновенного второго тура
+	Ranked bool
+	// Опубликовать опрос в канале и закрепить сообщение
+	Pin bool
+	// Разослать участникам итоги в личные сообщения, когда опрос закроется
+	NotifyVoters bool
+	// Срок опроса; 0 - срок по умолчанию
+	Expires time.Duration
+	// Опрос без срока, даже если задан срок по умолчанию
+	NoExpire bool
+	// Пояснение к вопросу, может занимать несколько строк
+	Description string
+	// Метки опроса; приводятся к нижнему регистру
+	Tags []string
+	// Веса голосов по именам пользователей без "@"; у остальных вес 1
+	Weights map[string]int
+	// Разрешить воздержаться псевдовариантом «Воздержался»
+	AllowAbstain bool
+	// Закрыть опрос после стольких голосов и не принимать новые; 0 - без
+	// ограничения
+	MaxVotes int
+	// Присылать участнику квитанцию о голосе в личные сообщения
+	VoteReceipts bool
+	// Не принимать голос создателя опроса
+	NoSelfVote bool
+	// Хранить голоса без ID участников
+	Anonymous bool
+	// Принимать голоса только участников канала опроса
+	MembersOnly bool
+	// Имена участников без "@", которым разрешено голосовать; пусто -
+	// голосовать может любой
+	Voters []string
+}
+
+// ChannelMembers проверяет членство пользователя в канале Mattermost.
+type ChannelMembers interface {
+	IsChannelMember(ctx context.Context, channelID, userID string) (bool, error)
+}
+
+// Announcer публикует сообщение в канале Mattermost.
+type Announcer interface {
+	Announce(ctx context.Context, channelID, message string) error
+}
+
+// Pinner публикует сообщение опроса и закрепляет его в канале Mattermost.
+type Pinner interface {
+	Publish(ctx context.Context, channelID, message string) (postID string, err error)
+	PinPost(ctx context.Context, postID string) error
+	UnpinPost(ctx context.Context, postID string) error
+}
+
 type PollService interface {
-	CreatePoll(ctx context.Context, userID, question string, options []string) (string, error)
-	AddVote(ctx context.Context, userID, pollID, choice string) (string, error)
+	CreatePoll(ctx context.Context, userID, question string, options []string, opts CreateOptions) (string, error)
+	// AddVote принимает один вариант, а в рейтинговом опросе - варианты
+	// по убыванию предпочтения.
+	AddVote(ctx context.Context, userID, pollID string, choices []string) (string, error)
 	GetResults(ctx context.Context, userID, pollID string) (string, error)
+	MyVote(ctx context.Context, userID, pollID string) (string, error)
 	EndPoll(ctx context.Context, userID, pollID string) (string, error)
+	// EndPolls завершает все открытые опросы пользователя, а с opts.Channel -
+	// все открытые опросы канала команды; права на это проверяет обработчик.
+	EndPolls(ctx context.Context, userID string, opts EndAllOptions) (string, error)
 	DeletePoll(ctx context.Context, userID, pollID string) (string, error)
+	PickWinner(ctx context.Context, userID, pollID, option string, again bool) (string, error)
+	ClonePoll(ctx context.Context, userID, sourceID string, overrides CloneOverrides) (string, error)
+	TransferPoll(ctx context.Context, userID, pollID, username string) (string, error)
+	// PollSettings и UpdateSetting показывают и меняют настройки открытого
+	// опроса; доступны только создателю.
+	PollSettings(ctx context.Context, userID, pollID string) (string, error)
+	UpdateSetting(ctx context.Context, userID, pollID string, change SettingChange) (string, error)
+	// FeaturePoll ставит или снимает отметку, с которой опрос показывается
+	// первым в списках; права проверяет обработчик.
+	FeaturePoll(ctx context.Context, userID, pollID string, featured bool) (string, error)
+	// AuditLog показывает журнал событий опроса; права проверяет обработчик.
+	AuditLog(ctx context.Context, pollID string, limit int) (string, error)
+	// ListOpenPolls показывает открытые опросы канала команды или, в личных
+	// сообщениях, открытые опросы пользователя, а с opts.All - все доступные
+	// ему; opts.Tag оставляет опросы с меткой.
+	ListOpenPolls(ctx context.Context, userID string, opts ListOptions) (string, error)
+	// SearchPolls ищет среди тех же опросов, что и ListOpenPolls, по тексту вопроса.
+	SearchPolls(ctx context.Context, userID, query string) (string, error)
+	// Ping проверяет путь до хранилища и сообщает время работы и версию бота.
+	Ping(ctx context.Context) (string, error)
+	// Version показывает сборку, время работы и возможности бота.
+	Version(ctx context.Context) (string, error)
 }
 
 type PollServiceImpl struct {
-	repo repository.PollRepository
+	repo      repository.PollRepository
+	votes     repository.VoteRepository
+	logger    zerolog.Logger
+	now       func() time.Time
+	members   ChannelMembers
+	announcer Announcer
+	pinner    Pinner
+	users     UserNames
+	finder    UserFinder
+	statuses  UserStatuses
+	messenger DirectMessenger
+	journal   repository.AuditRepository
+	notify    NotifyOptions
+	expiry    ExpiryOptions
+	randIntn  func(n int) (int, error)
+	// Сколько узнавать повторную доставку сообщения с голосом
+	voteKeyTTL time.Duration
+	// Сколько символов может занимать пояснение к опросу
+	maxDescription int
+	// Ограничения на текст вопроса и вариантов
+	content ContentRules
+	// Квитанции о голосе во всех новых опросах, а не только с --receipts
+	receipts bool
+	// Все новые опросы запрещают голос создателя, а не только с --no-self-vote
+	noSelfVote bool
+	// Ключ бота для ключей участников анонимных опросов
+	anonymousSecret string
+	// Хранилище и сборка для команды ping
+	storageName string
+	pinger      StoragePinger
+	build       BuildInfo
+
+	// Все опросы создаются как Exclusive
+	onePollPerChannel bool
+	// Лимит опросов в канале за сутки, см. SetChannelPollLimit
+	channelLimit channelLimit
+	// Сериализует проверки открытых и созданных за сутки опросов канала
+	// и сохранение нового
+	exclusiveMu sync.Mutex
+	// Блокировки опросов на время записи голоса
+	voteLocks pollLocks
+	// Проверка голосов в опросах с --members-only
+	membership  MembersOnlyOptions
+	memberCache memberCache
+	// Недавние просмотры итогов, см. countView
+	viewCache viewCache
+	// Метрики отслеживаемых опросов
+	tracker *PollTracker
 }
 
-func NewPollService(repo repository.PollRepository) *PollServiceImpl {
-	return &PollServiceImpl{repo: repo}
+func NewPollService(repo repository.PollRepository, votes repository.VoteRepository, logger zerolog.Logger) *PollServiceImpl {
+	return &PollServiceImpl{
+		repo:       repo,
+		votes:      votes,
+		logger:     logger,
+		now:        time.Now,
+		randIntn:   cryptoIntn,
+		voteKeyTTL: defaultVoteKeyTTL,
+		membership: MembersOnlyOptions{CacheTTL: defaultMembershipCacheTTL},
+
+		maxDescription: defaultMaxDescriptionLength,
+		build:          BuildInfo{Started: time.Now()},
+	}
 }
 
-func (s *PollServiceImpl) CreatePoll(ctx context.Context, userID, question string, options []string) (string, error) {
-	if len(options) < 1 {
-		return "", errors.New("должна быть хотя бы одна опция")
+// SetChannelMembers включает голосование из личных сообщений в опросах,
+// ограниченных каналом: голос принимается, если пользователь состоит в канале.
+func (s *PollServiceImpl) SetChannelMembers(members ChannelMembers) {
+	s.members = members
+}
+
+// SetAnnouncer задаёт, куда публиковать итоги опроса, закрытого по кворуму,
+// если голос пришёл не из канала опроса, и опросов, закрытых end --all.
+func (s *PollServiceImpl) SetAnnouncer(announcer Announcer) {
+	s.announcer = announcer
+}
+
+// SetPinner включает флаг --pin: сообщение опроса публикуется и закрепляется
+// через pinner, а при завершении и удалении опроса открепляется.
+func (s *PollServiceImpl) SetPinner(pinner Pinner) {
+	s.pinner = pinner
+}
+
+// SetOnePollPerChannel запрещает создавать опрос в канале, где уже есть
+// открытый. Личные сообщения не ограничиваются.
+func (s *PollServiceImpl) SetOnePollPerChannel(enabled bool) {
+	s.onePollPerChannel = enabled
+}
+
+// CreatedPoll - результат создания опроса для вызывающих не из чата,
+// которым нужен ID нового опроса.
+type CreatedPoll struct {
+	ID string
+	// Ответ бота, как на команду create
+	Message string
+	// Опрос по этому источнику уже был создан: повтор запроса
+	Duplicate bool
+}
+
+func (s *PollServiceImpl) CreatePoll(ctx context.Context, userID, question string, options []string, opts CreateOptions) (string, error) {
+	created, err := s.createPoll(ctx, userID, question, options, opts, "")
+	return created.Message, err
+}
+
+// CreatePollWithID создаёт опрос как CreatePoll и возвращает его ID.
+// Повтор с тем же Origin.PostID возвращает уже созданный опрос.
+func (s *PollServiceImpl) CreatePollWithID(ctx context.Context, userID, question string, options []string, opts CreateOptions) (CreatedPoll, error) {
+	return s.createPoll(ctx, userID, question, options, opts, "")
+}
+
+// createPoll создаёт опрос; clonedFrom - ID исходного опроса для журнала,
+// если опрос создаётся командой clone.
+func (s *PollServiceImpl) createPoll(ctx context.Context, userID, question string, options []string, opts CreateOptions, clonedFrom string) (CreatedPoll, error) {
+	poll, err := s.ValidatePollInput(ctx, userID, question, options, opts)
+	if err != nil {
+		return CreatedPoll{}, err
 	}
-	if len(question) > maxQuestionLength {
-		return "", errors.New("вопрос слишком длинный")
+	id, exists, err := s.newPollID(ctx, userID)
+	if err != nil {
+		return CreatedPoll{}, err
 	}
-	for _, option := range options {
-		if len(option) > maxOptionLength {
-			return "", errors.New("вариант ответа слишком длинный")
+	poll.ID = id
+
+	// Повторная доставка уже выполненной команды не создаёт второй опрос
+	if !exists {
+		exclusive, limited := s.exclusiveIn(ctx, opts), s.limitedIn(ctx)
+		if exclusive || limited {
+			// Проверки и сохранение атомарны только внутри процесса: несколько
+			// экземпляров бота могут одновременно создать по опросу в канале
+			s.exclusiveMu.Lock()
+			defer s.exclusiveMu.Unlock()
+		}
+		if exclusive {
+			if err := s.checkNoOpenPoll(ctx, poll.ChannelID); err != nil {
+				return CreatedPoll{}, err
+			}
+		}
+		if limited {
+			if err := s.checkChannelLimit(ctx, poll.ChannelID); err != nil {
+				return CreatedPoll{}, err
+			}
+		}
+		if err := s.repo.SavePoll(ctx, poll); err != nil {
+			return CreatedPoll{}, s.storageError(err, i18n.OpSavePoll)
+		}
+		if clonedFrom != "" {
+			s.record(ctx, poll.ID, userID, audit.ActionCloned, clonedFrom)
+		} else {
+			s.record(ctx, poll.ID, userID, audit.ActionCreated, poll.Question)
 		}
 	}
 
+	message := renderCreated(i18n.FromContext(ctx), poll, opts.Weights, opts.Voters)
+	if opts.Pin && !exists {
+		message = s.publishPinned(ctx, poll, message)
+	}
+	return CreatedPoll{ID: poll.ID, Message: message, Duplicate: exists}, nil
+}
+
+// ValidatePollInput проверяет вопрос, варианты и флаги нового опроса по всем
+// правилам создания и возвращает опрос, который был бы создан, но без ID и
+// без сохранения. Создание опроса проходит через неё же, поэтому предпросмотр
+// create --dry-run и create не расходятся. Правило одного открытого опроса в
+// канале здесь не проверяется: создание проверяет его вместе с сохранением.
+func (s *PollServiceImpl) ValidatePollInput(ctx context.Context, userID, question string, options []string, opts CreateOptions) (models.Poll, error) {
+	question, options, err := s.normalizeContent(question, options)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	if err := validatePoll(question, options, opts); err != nil {
+		return models.Poll{}, err
+	}
+	if opts.MembersOnly && OriginFrom(ctx).ChannelID == "" {
+		return models.Poll{}, i18n.NewError(i18n.MembersOnlyNoChannel)
+	}
+	if err := s.checkAnonymousSecret(opts.Anonymous); err != nil {
+		return models.Poll{}, err
+	}
+	options, emoji := splitOptions(options)
+	description := strings.TrimSpace(opts.Description)
+	if err := s.validateDescription(description); err != nil {
+		return models.Poll{}, err
+	}
+	tags, err := normalizeTags(opts.Tags)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	weights, err := s.resolveWeights(ctx, opts.Weights)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	voters, err := s.resolveVoters(ctx, opts.Voters)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	if err := validateVoters(voters, opts.Quorum); err != nil {
+		return models.Poll{}, err
+	}
+
+	createdAt := s.now().UTC()
+	expiresAt, err := s.expiresAt(opts, createdAt)
+	if err != nil {
+		return models.Poll{}, err
+	}
+
 	poll := models.Poll{
-		ID:       uuid.New().String(),
 		Creator:  userID,
 		Question: question,
 		Options:  make(map[string]int),
-		Voters:   make(map[string]bool),
 		Closed:   false,
+
+		Description:       description,
+		Tags:              tags,
+		ChannelID:         OriginFrom(ctx).ChannelID,
+		CreatedAt:         createdAt,
+		RestrictToChannel: opts.RestrictToChannel,
+		Quorum:            opts.Quorum,
+		Ranked:            opts.Ranked,
+		OptionOrder:       append([]string(nil), options...),
+		OptionEmoji:       emoji,
+		NotifyVoters:      (opts.NotifyVoters || s.notify.Default) && !opts.Anonymous,
+		ExpiresAt:         expiresAt,
+		Weights:           weights,
+		AllowAbstain:      opts.AllowAbstain,
+		MaxVotes:          opts.MaxVotes,
+		VoteReceipts:      opts.VoteReceipts || s.receipts,
+		NoSelfVote:        opts.NoSelfVote || s.noSelfVote,
+		MembersOnly:       opts.MembersOnly,
+		AllowedVoters:     voters,
 	}
+	if opts.Anonymous {
+		poll.VoterSalt = newVoterSalt()
+	}
+	for _, option := range options {
+		poll.Options[option] = 0
+	}
+	if poll.Weighted() {
+		poll.WeightedOptions = make(map[string]int, len(options))
+		for _, option := range options {
+			poll.WeightedOptions[option] = 0
+		}
+	}
+	return poll, nil
+}
 
+// publishPinned публикует сообщение нового опроса в его канале, закрепляет
+// его и возвращает ответ на команду. Бот может не иметь прав на закрепление,
+// поэтому сбой не отменяет созданный опрос, а добавляет предупреждение в ответ.
+func (s *PollServiceImpl) publishPinned(ctx context.Context, poll models.Poll, message string) string {
+	loc := i18n.FromContext(ctx)
+	if s.pinner == nil || poll.ChannelID == "" {
+		return message + loc.T(i18n.PinUnavailable)
+	}
+	postID, err := s.pinner.Publish(ctx, poll.ChannelID, message)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось опубликовать опрос для закрепления")
+		return message + loc.T(i18n.PinUnavailable)
+	}
+	// Сообщение опроса уже в канале, поэтому ответ на команду его не повторяет
+	if err := s.pinner.PinPost(ctx, postID); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось закрепить опрос")
+		return loc.T(i18n.PinFailed, poll.ID)
+	}
+	if err := s.repo.SetPinnedPost(ctx, poll.ID, postID); err != nil {
+		// Опрос закреплён, но открепить его при завершении придётся вручную
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось сохранить закреплённое сообщение опроса")
+	}
+	return loc.T(i18n.PollPinned, poll.ID)
+}
+
+// unpin открепляет сообщение завершённого или удалённого опроса.
+// Ошибка не мешает команде: опрос уже закрыт.
+func (s *PollServiceImpl) unpin(ctx context.Context, poll models.Poll) {
+	if poll.PinnedPostID == "" || s.pinner == nil {
+		return
+	}
+	if err := s.pinner.UnpinPost(ctx, poll.PinnedPostID); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось открепить опрос")
+	}
+}
+
+// ValidatePoll проверяет опрос по тем же правилам, что и создание, но
+// ничего не сохраняет: так проверяются шаблоны опросов.
+func (s *PollServiceImpl) ValidatePoll(question string, options []string, opts CreateOptions) error {
+	question, options, err := s.normalizeContent(question, options)
+	if err != nil {
+		return err
+	}
+	if err := validatePoll(question, options, opts); err != nil {
+		return err
+	}
+	if err := s.validateDescription(strings.TrimSpace(opts.Description)); err != nil {
+		return err
+	}
+	if _, err := normalizeTags(opts.Tags); err != nil {
+		return err
+	}
+	_, err = s.expiresAt(opts, s.now())
+	return err
+}
+
+// validatePoll проверяет вопрос, варианты и настройки нового опроса.
+// Варианты могут начинаться с эмодзи; проверяется текст без него.
+// Варианты, которые различаются только регистром или пробелами, -
+// повтор: голос за такой вариант нельзя было бы отличить.
+func validatePoll(question string, options []string, opts CreateOptions) error {
+	if len(options) < 1 {
+		return i18n.NewError(i18n.NoOptions)
+	}
+	if len(question) > maxQuestionLength {
+		return i18n.NewError(i18n.QuestionTooLong)
+	}
+	options, _ = splitOptions(options)
+	seen := make(map[string]bool, len(options))
 	for _, option := range options {
-		if _, exists := poll.Options[option]; exists {
-			return "", errors.New("все опции в голосовании должны быть уникальными")
+		if len(option) > maxOptionLength {
+			return i18n.NewError(i18n.OptionTooLong)
 		}
-		poll.Options[option] = 0
+		key := normalizeOption(option)
+		if seen[key] {
+			return i18n.NewError(i18n.DuplicateOptions)
+		}
+		seen[key] = true
+	}
+	if opts.Quorum < 0 {
+		return i18n.NewError(i18n.QuorumInvalid)
+	}
+	if opts.MaxVotes < 0 {
+		return i18n.NewError(i18n.MaxVotesInvalid)
+	}
+	if err := validateAbstain(options, opts); err != nil {
+		return err
+	}
+	if err := validateAnonymous(opts); err != nil {
+		return err
+	}
+	if err := validateVoters(opts.Voters, opts.Quorum); err != nil {
+		return err
+	}
+	return validateWeights(opts.Weights, opts.Ranked)
+}
+
+// exclusiveIn сообщает, действует ли правило одного открытого опроса
+// для канала, из которого пришла команда.
+func (s *PollServiceImpl) exclusiveIn(ctx context.Context, opts CreateOptions) bool {
+	origin := OriginFrom(ctx)
+	return (s.onePollPerChannel || opts.Exclusive) && origin.ChannelID != "" && !origin.Direct
+}
+
+// checkNoOpenPoll возвращает ошибку с ID и вопросом открытого опроса канала,
+// если такой есть.
+func (s *PollServiceImpl) checkNoOpenPoll(ctx context.Context, channelID string) error {
+	open := false
+	filter := repository.ListFilter{ChannelID: channelID, Closed: &open, Limit: 1}
+	for {
+		polls, cursor, err := s.repo.ListPolls(ctx, filter)
+		if err != nil {
+			return s.storageError(err, i18n.OpListPolls)
+		}
+		if len(polls) > 0 {
+			return i18n.NewError(i18n.ChannelHasPoll, polls[0].ID, polls[0].Question)
+		}
+		// Страница может быть пустой при непустом курсоре, если хранилище
+		// ограничивает объём сканирования за вызов
+		if cursor == "" {
+			return nil
+		}
+		filter.Cursor = cursor
+	}
+}
+
+// newPollID выбирает ID нового опроса. Если известно исходное сообщение,
+// ID выводится из пары (создатель, сообщение), чтобы повторно доставленная
+// команда не создала второй опрос. exists сообщает, что опрос с этим ID
+// уже создан. Создателем он может быть уже другой пользователь, если опрос
+// передали командой transfer: повтор всё равно не перезаписывает опрос,
+// иначе его счётчики обнулились бы, а голоса остались.
+func (s *PollServiceImpl) newPollID(ctx context.Context, userID string) (string, bool, error) {
+	postID := OriginFrom(ctx).PostID
+	if postID == "" {
+		return uuid.New().String(), false, nil
+	}
+	id := uuid.NewSHA1(createNamespace, []byte(userID+"/"+postID)).String()
+
+	existing, err := s.repo.GetPoll(ctx, id)
+	switch {
+	case err == nil:
+		s.logger.Info().Str("poll_id", id).Str("creator", existing.Creator).Msg("Опрос по этому сообщению уже создан")
+		return id, true, nil
+	case errors.Is(err, repository.ErrNotFound):
+		return id, false, nil
+	default:
+		return "", false, s.storageError(err, i18n.OpGetPoll)
+	}
+}
+
+func (s *PollServiceImpl) AddVote(ctx context.Context, userID, pollID string, choices []string) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	// Выбор очищается так же, как варианты при создании: вариант, набранный
+	// с табуляцией или неразрывным пробелом, находится
+	normalized := make([]string, len(choices))
+	for i, choice := range choices {
+		normalized[i] = normalizeOptionText(choice)
 	}
+	choices = normalized
 
-	if err := s.repo.SavePoll(ctx, poll); err != nil {
-		return "", fmt.Errorf("ошибка сохранения опроса: %w", err)
+	// Голоса одного опроса в процессе записываются по очереди: иначе два
+	// голоса могут пройти проверки по одному и тому же прочитанному опросу
+	unlock := s.voteLocks.lock(pollID)
+	ballot, poll, redelivered, err := s.voteLocked(ctx, userID, pollID, choices)
+	unlock()
+	if err != nil {
+		return "", err
+	}
+	if redelivered != nil {
+		return voteReply(i18n.FromContext(ctx), pollID, redelivered.Choices), nil
 	}
+	s.observe(poll)
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Голосование создано успешно! ID: `%s`\nВопрос: %s\nВарианты:\n", poll.ID, poll.Question))
-	for i, option := range options {
-		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, option))
+	action := audit.ActionVoted
+	switch {
+	case poll.Closed && closedByMaxVotes(poll):
+		action = audit.ActionMaxVotesClosed
+	case poll.Closed:
+		action = audit.ActionQuorumClosed
 	}
+	loc := i18n.FromContext(ctx)
+	detail := strings.Join(ballot, " > ")
+	if len(ballot) == 0 {
+		detail = loc.T(i18n.AbstainOption)
+	}
+	// Журнал назвал бы участника и его выбор
+	if !poll.Anonymous() {
+		s.record(ctx, pollID, userID, action, detail)
+	}
+	s.sendReceipt(ctx, userID, poll, detail)
 
-	return sb.String(), nil
+	reply := voteReply(loc, pollID, ballot) + selfVoteNote(loc, poll, userID)
+	if poll.Closed {
+		s.unpin(ctx, poll)
+		s.notifyVoters(ctx, poll)
+		reply += "\n" + s.announceClosed(ctx, poll)
+	}
+	return reply, nil
 }
 
-func (s *PollServiceImpl) AddVote(ctx context.Context, userID, pollID, choice string) (string, error) {
-	if !pollIDRegex.MatchString(pollID) {
-		return "", errors.New("неверный формат ID опроса")
+// voteLocked записывает голос и вызывается под блокировкой опроса.
+// Конфликт записи повторяет хранилище по своей политике повторов, поэтому
+// сервис голос не повторяет. redelivered - уже учтённый голос, если
+// команда пришла повторно.
+func (s *PollServiceImpl) voteLocked(ctx context.Context, userID, pollID string, choices []string) ([]string, models.Poll, *models.Vote, error) {
+	ballot, poll, err := s.tryVote(ctx, userID, pollID, choices)
+	if err == nil {
+		return ballot, poll, nil, nil
+	}
+	// Ответ на первую доставку мог потеряться: повтор получает его ещё раз,
+	// а журнал и рассылки не повторяются
+	if vote, ok := s.redeliveredVote(ctx, userID, pollID, err); ok {
+		s.logger.Debug().Str("poll_id", pollID).Str("user_id", userID).Msg("Повторная доставка учтённого голоса")
+		return nil, models.Poll{}, &vote, nil
+	}
+	return nil, models.Poll{}, nil, err
+}
+
+// voteReply - ответ на учтённый голос; пустой бюллетень - воздержание.
+func voteReply(loc *i18n.Localizer, pollID string, ballot []string) string {
+	if len(ballot) == 0 {
+		return loc.T(i18n.VoteAbstained, pollID)
+	}
+	return loc.T(i18n.VoteRecorded, pollID, strings.Join(ballot, " > "))
+}
+
+// closedByMaxVotes сообщает, что закрытый голосом опрос закрылся по
+// максимуму голосов, а не по кворуму: опрос закрывается на меньшем из них.
+func closedByMaxVotes(poll models.Poll) bool {
+	return poll.MaxVotes > 0 && (poll.Quorum == 0 || poll.MaxVotes <= poll.Quorum)
+}
+
+// announceClosed сообщает о закрытии опроса голосом: по кворуму или по
+// максимуму голосов. Итоги публикуются в канале опроса; если голос пришёл
+// оттуда же или публикация не удалась, они возвращаются для ответа на голос.
+func (s *PollServiceImpl) announceClosed(ctx context.Context, poll models.Poll) string {
+	loc := i18n.FromContext(ctx)
+	heading := loc.T(i18n.QuorumReached, poll.ID)
+	if closedByMaxVotes(poll) {
+		heading = loc.T(i18n.MaxVotesClosed, poll.ID)
+		s.logger.Info().Str("poll_id", poll.ID).Int("max_votes", poll.MaxVotes).Msg("Опрос набрал максимум голосов")
+	} else {
+		s.logger.Info().Str("poll_id", poll.ID).Int("quorum", poll.Quorum).Msg("Опрос завершён по кворуму")
+	}
+	summary := heading + s.summary(ctx, loc, poll)
+
+	origin := OriginFrom(ctx)
+	if s.announcer == nil || poll.ChannelID == "" || origin.ChannelID == poll.ChannelID {
+		return summary
+	}
+	if err := s.announcer.Announce(ctx, poll.ChannelID, summary); err != nil {
+		s.logger.Error().Err(err).Str("poll_id", poll.ID).Msg("Не удалось опубликовать итоги опроса")
+		return summary
 	}
+	return heading
+}
 
+// tryVote выполняет одну попытку чтения, проверки и записи голоса и
+// возвращает бюллетень в написании опроса и опрос после голоса. Голос
+// записывается в репозиторий голосов вместе со счётчиком опроса; голос,
+// достигший кворума или максимума голосов, закрывает опрос той же записью.
+// Повторный голос отклоняет хранилище. Конфликт записи хранилище повторяет
+// само по своей политике повторов; если повторы не помогли, конфликт
+// возвращается как ошибка сохранения голоса.
+func (s *PollServiceImpl) tryVote(ctx context.Context, userID, pollID string, choices []string) ([]string, models.Poll, error) {
 	poll, err := s.repo.GetPoll(ctx, pollID)
 	if err != nil {
-		return "", errors.New("опрос не найден")
+		return nil, models.Poll{}, s.storageError(err, i18n.OpGetPoll)
 	}
-	if poll.Closed {
-		return "", errors.New("опрос завершен")
+	if err := s.checkChannel(ctx, poll, userID, true); err != nil {
+		return nil, models.Poll{}, err
+	}
+	// Срок мог наступить раньше, чем RunExpiry закрыл опрос
+	if poll.Closed || expired(poll, s.now()) {
+		if poll.Full() {
+			return nil, models.Poll{}, i18n.NewError(i18n.MaxVotesReached)
+		}
+		return nil, models.Poll{}, i18n.NewError(i18n.PollClosed)
+	}
+	if err := checkSelfVote(poll, userID); err != nil {
+		return nil, models.Poll{}, err
+	}
+	if err := checkVoter(poll, userID); err != nil {
+		return nil, models.Poll{}, err
+	}
+	if err := s.checkMember(ctx, poll, userID); err != nil {
+		return nil, models.Poll{}, err
 	}
-	if poll.Voters[userID] {
-		return "", errors.New("вы уже голосовали в этом опросе")
+	if err := s.checkAnonymousSecret(poll.Anonymous()); err != nil {
+		return nil, models.Poll{}, err
 	}
-	if _, exists := poll.Options[choice]; !exists {
-		return "", fmt.Errorf("вариант '%s' не существует", choice)
+	var abstain bool
+	if poll.AllowAbstain {
+		if abstain, err = abstainBallot(choices); err != nil {
+			return nil, models.Poll{}, err
+		}
+	}
+	if !abstain {
+		if choices, err = joinWords(poll, choices); err != nil {
+			return nil, models.Poll{}, err
+		}
+	}
+	if !poll.Ranked && len(choices) != 1 {
+		return nil, models.Poll{}, i18n.NewError(i18n.SingleChoiceOnly)
 	}
 
-	poll.Voters[userID] = true
-	poll.Options[choice]++
-	if err := s.repo.AddVoteAtomic(ctx, poll); err != nil {
-		return "", fmt.Errorf("ошибка сохранения голоса: %w", err)
+	var ballot []string
+	if !abstain {
+		if ballot, err = resolveBallot(poll, choices); err != nil {
+			return nil, models.Poll{}, err
+		}
 	}
 
-	return fmt.Sprintf("Ваш голос в голосовании %s записан: %s", pollID, choice), nil
+	// Хранилище повторяет проверки и решает о кворуме само: прочитанный
+	// опрос мог устареть, пока шёл разбор бюллетеня. Воздержавшийся может
+	// заменить воздержание голосом, это тоже решает хранилище
+	// Голос анонимного опроса хранится под ключами участника и сообщения
+	vote := models.Vote{PollID: pollID, UserID: poll.VoterKey(s.anonymousSecret, userID), Choices: ballot, VotedAt: s.now(), PostID: poll.PostKey(s.anonymousSecret, OriginFrom(ctx).PostID), Abstain: abstain}
+	if poll.Weighted() && !abstain {
+		vote.Weight = poll.VoteWeight(userID)
+	}
+	closed, err := s.votes.AddVote(ctx, vote)
+	switch {
+	case errors.Is(err, repository.ErrPollFull):
+		return nil, models.Poll{}, i18n.NewError(i18n.MaxVotesReached)
+	case errors.Is(err, repository.ErrPollClosed):
+		return nil, models.Poll{}, i18n.NewError(i18n.PollClosed)
+	case errors.Is(err, repository.ErrAlreadyVoted):
+		return nil, models.Poll{}, i18n.NewError(i18n.AlreadyVoted)
+	case err != nil:
+		return nil, models.Poll{}, s.storageError(err, i18n.OpSaveVote)
+	}
+
+	poll.Closed = closed
+	if abstain {
+		poll.Abstained++
+		return nil, poll, nil
+	}
+	// В рейтинговом опросе счётчик варианта - число первых предпочтений
+	poll.Options[ballot[0]]++
+	if vote.Weight > 0 {
+		if poll.WeightedOptions == nil {
+			poll.WeightedOptions = make(map[string]int)
+		}
+		poll.WeightedOptions[ballot[0]] += vote.Weight
+	}
+	return ballot, poll, nil
+}
+
+// resolveBallot приводит выбор к написанию вариантов опроса. Частичный
+// рейтинг допустим, повтор варианта - нет.
+func resolveBallot(poll models.Poll, choices []string) ([]string, error) {
+	ballot := make([]string, 0, len(choices))
+	seen := make(map[string]bool, len(choices))
+	for _, choice := range choices {
+		option, suggestion := findOption(poll, choice)
+		if option == "" {
+			if suggestion != "" {
+				return nil, i18n.NewError(i18n.OptionSuggestion, choice, suggestion)
+			}
+			return nil, i18n.NewError(i18n.OptionNotFound, choice)
+		}
+		if seen[option] {
+			return nil, i18n.NewError(i18n.DuplicateRanking, option)
+		}
+		seen[option] = true
+		ballot = append(ballot, option)
+	}
+	return ballot, nil
 }
 
 func (s *PollServiceImpl) GetResults(ctx context.Context, userID, pollID string) (string, error) {
-	poll, err := s.repo.GetPoll(ctx, pollID)
+	text, _, err := s.ResultsReport(ctx, userID, pollID)
+	return text, err
+}
+
+// ResultsReport возвращает итоги опроса и текстом, как GetResults,
+// и данными, по которым бот строит вложение сообщения.
+func (s *PollServiceImpl) ResultsReport(ctx context.Context, userID, pollID string) (string, Results, error) {
+	pollID, err := normalizePollID(pollID)
 	if err != nil {
-		return "", errors.New("опрос не найден")
+		return "", Results{}, err
 	}
+	poll, err := s.repo.GetPollSummary(ctx, pollID)
+	if err != nil {
+		return "", Results{}, s.storageError(err, i18n.OpGetPoll)
+	}
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return "", Results{}, err
+	}
+	s.countView(ctx, poll, userID)
+
+	ballots, err := s.ballots(ctx, poll)
+	if err != nil {
+		return "", Results{}, s.storageError(err, i18n.OpListVotes)
+	}
+	results := BuildResults(poll, ballots)
+	return renderResultsOf(i18n.FromContext(ctx), results), results, nil
+}
+
+// ballots читает бюллетени рейтингового опроса, по которым пересчитываются
+// его итоги. Итогам обычного опроса хватает счётчиков, голоса не читаются.
+func (s *PollServiceImpl) ballots(ctx context.Context, poll models.Poll) ([][]string, error) {
+	if !poll.Ranked {
+		return nil, nil
+	}
+	votes, err := s.votes.ListVotes(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	return voteBallots(votes), nil
+}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("**Результаты опроса %s**\n%s\n", pollID, poll.Question))
-	for option, count := range poll.Options {
-		sb.WriteString(fmt.Sprintf("- %s: %d голосов\n", option, count))
+// voteBallots собирает бюллетени голосов; голоса без бюллетеня пропускаются.
+func voteBallots(votes []models.Vote) [][]string {
+	ballots := make([][]string, 0, len(votes))
+	for _, vote := range votes {
+		if len(vote.Choices) > 0 {
+			ballots = append(ballots, vote.Choices)
+		}
 	}
-	return sb.String(), nil
+	return ballots
+}
+
+// summary подводит итоги для сообщения о действии, которое уже выполнено,
+// поэтому не возвращает ошибку: если бюллетени не прочитались, итоги
+// рейтингового опроса показываются по счётчикам первых предпочтений.
+func (s *PollServiceImpl) summary(ctx context.Context, loc *i18n.Localizer, poll models.Poll) string {
+	ballots, err := s.ballots(ctx, poll)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось прочитать бюллетени опроса")
+		poll.Ranked = false
+	}
+	return renderResults(loc, poll, ballots)
 }
 
 func (s *PollServiceImpl) EndPoll(ctx context.Context, userID, pollID string) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
 	poll, err := s.repo.GetPoll(ctx, pollID)
 	if err != nil {
-		return "", errors.New("опрос не найден")
+		return "", s.storageError(err, i18n.OpGetPoll)
 	}
 	if poll.Creator != userID {
-		return "", errors.New("только создатель может завершить опрос")
+		return "", i18n.NewError(i18n.OnlyCreatorCanEnd)
+	}
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return "", err
 	}
 
+	if poll, err = s.closePoll(ctx, userID, poll); err != nil {
+		return "", err
+	}
+	loc := i18n.FromContext(ctx)
+	// Итог рейтингового опроса не виден из счётчиков, поэтому он
+	// подводится сразу при завершении
+	if poll.Ranked {
+		return loc.T(i18n.PollEnded, pollID) + "\n" + s.summary(ctx, loc, poll), nil
+	}
+	return loc.T(i18n.PollEnded, pollID), nil
+}
+
+// closePoll закрывает опрос от имени userID: запись в журнал, открепление
+// сообщения и рассылка итогов участникам, как при завершении командой end.
+// Уже закрытый опрос отклоняется: иначе участники получили бы итоги второй
+// раз, а журнал - второе завершение.
+func (s *PollServiceImpl) closePoll(ctx context.Context, userID string, poll models.Poll) (models.Poll, error) {
+	poll, changed, err := s.closeOpenPoll(ctx, poll.ID)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	if !changed {
+		return models.Poll{}, i18n.NewError(i18n.PollAlreadyClosed, poll.ID)
+	}
+	s.record(ctx, poll.ID, userID, audit.ActionEnded, "")
+	s.unpin(ctx, poll)
+	s.observe(poll)
+	s.notifyVoters(ctx, poll)
+	return poll, nil
+}
+
+// closeOpenPoll закрывает опрос, если он ещё открыт, и возвращает его
+// закрытым. Опрос перечитывается под блокировкой голосов, поэтому команда
+// end и закрытие по сроку, сошедшиеся в этом процессе, не закроют его
+// дважды. changed == false - опрос уже был закрыт.
+func (s *PollServiceImpl) closeOpenPoll(ctx context.Context, pollID string) (poll models.Poll, changed bool, err error) {
+	unlock := s.voteLocks.lock(pollID)
+	defer unlock()
+	poll, err = s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return models.Poll{}, false, s.storageError(err, i18n.OpGetPoll)
+	}
+	if poll.Closed {
+		return poll, false, nil
+	}
 	if err := s.repo.ClosePoll(ctx, pollID); err != nil {
-		return "", fmt.Errorf("ошибка завершения опроса: %w", err)
+		return models.Poll{}, false, s.storageError(err, i18n.OpEndPoll)
 	}
-	return fmt.Sprintf("Голосование %s окончено", pollID), nil
+	poll.Closed = true
+	return poll, true, nil
 }
 
 func (s *PollServiceImpl) DeletePoll(ctx context.Context, userID, pollID string) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
 	poll, err := s.repo.GetPoll(ctx, pollID)
 	if err != nil {
-		return "", errors.New("опрос не найден")
+		return "", s.storageError(err, i18n.OpGetPoll)
 	}
 	if poll.Creator != userID {
-		return "", errors.New("только создатель может удалить опрос")
+		return "", i18n.NewError(i18n.OnlyCreatorDelete)
 	}
 
 	if err := s.repo.DeletePoll(ctx, pollID); err != nil {
-		return "", fmt.Errorf("ошибка удаления опроса: %w", err)
+		return "", s.storageError(err, i18n.OpDeletePoll)
+	}
+	// Опрос уже удалён, оставшиеся голоса ни на что не влияют
+	if err := s.votes.DeleteVotes(ctx, pollID); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", pollID).Msg("Не удалось удалить голоса опроса")
+	}
+	// Вопрос сохраняется в журнале: после удаления его больше негде посмотреть
+	s.record(ctx, pollID, userID, audit.ActionDeleted, poll.Question)
+	s.unpin(ctx, poll)
+	return i18n.FromContext(ctx).T(i18n.PollDeleted, pollID), nil
+}
+
+// DiscardPoll удаляет только что созданный опрос, о котором никто не узнал:
+// бот не смог опубликовать ответ на create. Права создателя не проверяются,
+// опрос удаляет сам бот.
+func (s *PollServiceImpl) DiscardPoll(ctx context.Context, pollID string) error {
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return s.storageError(err, i18n.OpGetPoll)
+	}
+	if err := s.repo.DeletePoll(ctx, pollID); err != nil {
+		return s.storageError(err, i18n.OpDeletePoll)
+	}
+	if err := s.votes.DeleteVotes(ctx, pollID); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", pollID).Msg("Не удалось удалить голоса опроса")
+	}
+	s.record(ctx, pollID, poll.Creator, audit.ActionDiscarded, "")
+	return nil
+}
+
+// checkChannel не даёт работать с опросом, ограниченным каналом, из другого
+// канала. Команды без известного канала (не из чата) не ограничиваются.
+// allowDirect разрешает личные сообщения от участников канала опроса.
+func (s *PollServiceImpl) checkChannel(ctx context.Context, poll models.Poll, userID string, allowDirect bool) error {
+	origin := OriginFrom(ctx)
+	if !poll.RestrictToChannel || poll.ChannelID == "" || origin.ChannelID == "" || origin.ChannelID == poll.ChannelID {
+		return nil
+	}
+
+	if allowDirect && origin.Direct && s.members != nil {
+		member, err := s.isMember(ctx, poll.ChannelID, userID)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось проверить членство в канале опроса")
+		}
+		if member {
+			return nil
+		}
+	}
+	return i18n.NewError(i18n.ChannelOnly)
+}
+
+// storageError переводит ошибку хранилища в ответ пользователю: отсутствие
+// опроса и недоступность базы получают понятные сообщения, остальное
+// оборачивается описанием операции.
+func (s *PollServiceImpl) storageError(err error, op i18n.Key) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrPollNotFound
+	case errors.Is(err, repository.ErrUnavailable):
+		s.logger.Error().Err(err).Str("operation", string(op)).Msg("Хранилище недоступно")
+		return ErrServiceUnavailable
+	default:
+		return i18n.Wrap(err, op)
 	}
-	return fmt.Sprintf("Голосование %s удалено", pollID), nil
 }
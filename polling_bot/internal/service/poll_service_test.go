@@ -4,15 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
-	
+	"time"
+
 	"github.com/google/uuid"
-	
+	"github.com/rs/zerolog"
+	"github.com/tarantool/go-tarantool"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/metrics"
 	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
 	"polling_bot/internal/service"
 )
 
@@ -30,8 +38,18 @@ func (m *MockPollRepository) GetPoll(ctx context.Context, pollID string) (models
 	return args.Get(0).(models.Poll), args.Error(1)
 }
 
-func (m *MockPollRepository) AddVoteAtomic(ctx context.Context, poll models.Poll) error {
-	args := m.Called(ctx, poll)
+func (m *MockPollRepository) GetPollSummary(ctx context.Context, pollID string) (models.Poll, error) {
+	args := m.Called(ctx, pollID)
+	return args.Get(0).(models.Poll), args.Error(1)
+}
+
+func (m *MockPollRepository) IncrementOption(ctx context.Context, pollID, option string, delta int) error {
+	args := m.Called(ctx, pollID, option, delta)
+	return args.Error(0)
+}
+
+func (m *MockPollRepository) IncrementViews(ctx context.Context, pollID string) error {
+	args := m.Called(ctx, pollID)
 	return args.Error(0)
 }
 
@@ -40,11 +58,99 @@ func (m *MockPollRepository) ClosePoll(ctx context.Context, pollID string) error
 	return args.Error(0)
 }
 
+func (m *MockPollRepository) SetCreator(ctx context.Context, pollID, creator string) error {
+	args := m.Called(ctx, pollID, creator)
+	return args.Error(0)
+}
+
+func (m *MockPollRepository) SetPinnedPost(ctx context.Context, pollID, postID string) error {
+	args := m.Called(ctx, pollID, postID)
+	return args.Error(0)
+}
+
+func (m *MockPollRepository) SetExpiryWarned(ctx context.Context, pollID string) error {
+	args := m.Called(ctx, pollID)
+	return args.Error(0)
+}
+
+func (m *MockPollRepository) SetFeatured(ctx context.Context, pollID string, featured bool) error {
+	args := m.Called(ctx, pollID, featured)
+	return args.Error(0)
+}
+
+func (m *MockPollRepository) SetAllowedVoters(ctx context.Context, pollID string, voters []string) error {
+	args := m.Called(ctx, pollID, voters)
+	return args.Error(0)
+}
+
+func (m *MockPollRepository) UpdateSettings(ctx context.Context, pollID string, update repository.SettingsUpdate) error {
+	args := m.Called(ctx, pollID, update)
+	return args.Error(0)
+}
+
 func (m *MockPollRepository) DeletePoll(ctx context.Context, pollID string) error {
 	args := m.Called(ctx, pollID)
 	return args.Error(0)
 }
 
+func (m *MockPollRepository) ListPolls(ctx context.Context, filter repository.ListFilter) ([]models.Poll, string, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]models.Poll), args.String(1), args.Error(2)
+}
+
+func (m *MockPollRepository) CountPolls(ctx context.Context, filter repository.ListFilter) (int, error) {
+	args := m.Called(ctx, filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPollRepository) GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error) {
+	args := m.Called(ctx, ids)
+	polls, _ := args.Get(0).(map[string]models.Poll)
+	return polls, args.Error(1)
+}
+
+type MockVoteRepository struct {
+	mock.Mock
+}
+
+func (m *MockVoteRepository) AddVote(ctx context.Context, vote models.Vote) (bool, error) {
+	args := m.Called(ctx, vote)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockVoteRepository) GetVote(ctx context.Context, pollID, userID string) (models.Vote, error) {
+	args := m.Called(ctx, pollID, userID)
+	return args.Get(0).(models.Vote), args.Error(1)
+}
+
+func (m *MockVoteRepository) ListVotes(ctx context.Context, pollID string) ([]models.Vote, error) {
+	args := m.Called(ctx, pollID)
+	return args.Get(0).([]models.Vote), args.Error(1)
+}
+
+func (m *MockVoteRepository) CountVotes(ctx context.Context, pollID string) (int, error) {
+	args := m.Called(ctx, pollID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockVoteRepository) ImportVotes(ctx context.Context, votes []models.Vote) error {
+	args := m.Called(ctx, votes)
+	return args.Error(0)
+}
+
+func (m *MockVoteRepository) DeleteVotes(ctx context.Context, pollID string) error {
+	args := m.Called(ctx, pollID)
+	return args.Error(0)
+}
+
+// ballotOf сопоставляет голос по опросу, участнику и бюллетеню; время
+// голоса задают часы сервиса.
+func ballotOf(pollID, userID string, choices ...string) interface{} {
+	return mock.MatchedBy(func(v models.Vote) bool {
+		return v.PollID == pollID && v.UserID == userID && slices.Equal(v.Choices, choices)
+	})
+}
+
 func TestCreatePoll(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -125,8 +231,8 @@ func TestCreatePoll(t *testing.T) {
 			mockRepo := new(MockPollRepository)
 			tt.mockSetup(mockRepo)
 
-			service := service.NewPollService(mockRepo)
-			result, err := service.CreatePoll(context.Background(), tt.userID, tt.question, tt.options)
+			s := service.NewPollService(mockRepo, new(MockVoteRepository), zerolog.Nop())
+			result, err := s.CreatePoll(context.Background(), tt.userID, tt.question, tt.options, service.CreateOptions{})
 
 			if tt.expectedErr != "" {
 				assert.ErrorContains(t, err, tt.expectedErr)
@@ -155,7 +261,7 @@ func TestAddVote(t *testing.T) {
 		userID      string
 		pollID      string
 		choice      string
-		mockSetup   func(*MockPollRepository)
+		mockSetup   func(*MockPollRepository, *MockVoteRepository)
 		expected    string
 		expectedErr string
 	}{
@@ -164,23 +270,16 @@ func TestAddVote(t *testing.T) {
 			userID: userID,
 			pollID: validPollID,
 			choice: "Option1",
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				poll := models.Poll{
 					ID:       validPollID,
 					Creator:  "creator",
 					Question: question,
 					Options:  map[string]int{"Option1": 0, "Option2": 0},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
-				m.On("AddVoteAtomic", mock.Anything, mock.Anything).
-					Return(nil).
-					Run(func(args mock.Arguments) {
-						updatedPoll := args.Get(1).(models.Poll)
-						assert.True(t, updatedPoll.Voters[userID])
-						assert.Equal(t, 1, updatedPoll.Options["Option1"])
-					})
+				v.On("AddVote", mock.Anything, ballotOf(validPollID, userID, "Option1")).Return(false, nil)
 			},
 			expected: fmt.Sprintf("Ваш голос в голосовании %s записан: Option1", validPollID),
 		},
@@ -189,7 +288,7 @@ func TestAddVote(t *testing.T) {
 			userID:      userID,
 			pollID:      "invalid-id",
 			choice:      "Option1",
-			mockSetup:   func(m *MockPollRepository) {},
+			mockSetup:   func(*MockPollRepository, *MockVoteRepository) {},
 			expectedErr: "неверный формат ID опроса",
 		},
 		{
@@ -197,9 +296,9 @@ func TestAddVote(t *testing.T) {
 			userID: userID,
 			pollID: validPollID,
 			choice: "Option1",
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				m.On("GetPoll", mock.Anything, validPollID).
-					Return(models.Poll{}, errors.New("not found"))
+					Return(models.Poll{}, repository.ErrNotFound)
 			},
 			expectedErr: "опрос не найден",
 		},
@@ -208,13 +307,12 @@ func TestAddVote(t *testing.T) {
 			userID: userID,
 			pollID: validPollID,
 			choice: "Option1",
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				poll := models.Poll{
 					ID:       validPollID,
 					Creator:  "creator",
 					Question: question,
 					Options:  map[string]int{"Option1": 0, "Option2": 0},
-					Voters:   make(map[string]bool),
 					Closed:   true,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
@@ -226,16 +324,18 @@ func TestAddVote(t *testing.T) {
 			userID: userID,
 			pollID: validPollID,
 			choice: "Option1",
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				poll := models.Poll{
 					ID:       validPollID,
 					Creator:  "creator",
 					Question: question,
 					Options:  map[string]int{"Option1": 0, "Option2": 0},
-					Voters:   map[string]bool{userID: true},
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
+				// Повторный голос отклоняет хранилище
+				v.On("AddVote", mock.Anything, ballotOf(validPollID, userID, "Option1")).
+					Return(false, repository.ErrAlreadyVoted)
 			},
 			expectedErr: "вы уже голосовали в этом опросе",
 		},
@@ -244,48 +344,123 @@ func TestAddVote(t *testing.T) {
 			userID: userID,
 			pollID: validPollID,
 			choice: "InvalidOption",
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				poll := models.Poll{
 					ID:       validPollID,
 					Creator:  "creator",
 					Question: question,
 					Options:  map[string]int{"Option1": 0, "Option2": 0},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
 			},
 			expectedErr: "вариант 'InvalidOption' не существует",
 		},
+		{
+			name:   "typo gets a suggestion",
+			userID: userID,
+			pollID: validPollID,
+			choice: "Optin1",
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
+				poll := models.Poll{
+					ID:       validPollID,
+					Creator:  "creator",
+					Question: question,
+					Options:  map[string]int{"Option1": 0, "Banana": 0},
+				}
+				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
+			},
+			expectedErr: "вариант 'Optin1' не найден, возможно вы имели в виду 'Option1'?",
+		},
+		{
+			name:   "case-insensitive choice is accepted",
+			userID: userID,
+			pollID: validPollID,
+			choice: "option1",
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
+				poll := models.Poll{
+					ID:       validPollID,
+					Creator:  "creator",
+					Question: question,
+					Options:  map[string]int{"Option1": 0, "Option2": 0},
+				}
+				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
+				// В хранилище уходит вариант в написании опроса
+				v.On("AddVote", mock.Anything, ballotOf(validPollID, userID, "Option1")).Return(false, nil)
+			},
+			expected: fmt.Sprintf("Ваш голос в голосовании %s записан: Option1", validPollID),
+		},
 		{
 			name:   "save vote error",
 			userID: userID,
 			pollID: validPollID,
 			choice: "Option1",
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				poll := models.Poll{
 					ID:       validPollID,
 					Creator:  "creator",
 					Question: question,
 					Options:  map[string]int{"Option1": 0, "Option2": 0},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
-				m.On("AddVoteAtomic", mock.Anything, mock.Anything).
-					Return(errors.New("db error"))
+				v.On("AddVote", mock.Anything, mock.Anything).
+					Return(false, errors.New("db error"))
 			},
 			expectedErr: "ошибка сохранения голоса: db error",
 		},
+		{
+			name:   "storage rejects a vote in a poll closed meanwhile",
+			userID: userID,
+			pollID: validPollID,
+			choice: "Option1",
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
+				m.On("GetPoll", mock.Anything, validPollID).Return(models.Poll{
+					ID:      validPollID,
+					Options: map[string]int{"Option1": 0},
+				}, nil)
+				v.On("AddVote", mock.Anything, mock.Anything).
+					Return(false, repository.ErrPollClosed)
+			},
+			expectedErr: "опрос завершен",
+		},
+		{
+			name:   "storage unavailable",
+			userID: userID,
+			pollID: validPollID,
+			choice: "Option1",
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
+				m.On("GetPoll", mock.Anything, validPollID).
+					Return(models.Poll{}, fmt.Errorf("ошибка получения опроса: %w", repository.ErrUnavailable))
+			},
+			expectedErr: "сервис временно недоступен, попробуйте позже",
+		},
+		{
+			// Конфликт уже повторило хранилище, сервис голос не повторяет
+			name:   "conflict is not retried again",
+			userID: userID,
+			pollID: validPollID,
+			choice: "Option1",
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
+				m.On("GetPoll", mock.Anything, validPollID).Return(models.Poll{
+					ID:       validPollID,
+					Creator:  "creator",
+					Question: question,
+					Options:  map[string]int{"Option1": 0},
+				}, nil).Once()
+				v.On("AddVote", mock.Anything, mock.Anything).Return(false, repository.ErrConflict).Once()
+			},
+			expectedErr: "ошибка сохранения голоса: конфликт транзакции",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := new(MockPollRepository)
-			tt.mockSetup(mockRepo)
+			mockRepo, mockVotes := new(MockPollRepository), new(MockVoteRepository)
+			tt.mockSetup(mockRepo, mockVotes)
 
-			service := service.NewPollService(mockRepo)
-			result, err := service.AddVote(context.Background(), tt.userID, tt.pollID, tt.choice)
+			service := service.NewPollService(mockRepo, mockVotes, zerolog.Nop())
+			result, err := service.AddVote(context.Background(), tt.userID, tt.pollID, []string{tt.choice})
 
 			if tt.expectedErr != "" {
 				assert.ErrorContains(t, err, tt.expectedErr)
@@ -296,10 +471,45 @@ func TestAddVote(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockVotes.AssertExpectations(t)
 		})
 	}
 }
 
+// conflictConn - соединение Tarantool, на котором каждая запись голоса
+// завершается конфликтом транзакции
+type conflictConn struct {
+	repository.Connector
+	calls int
+}
+
+func (c *conflictConn) ConnectedNow() bool { return true }
+
+func (c *conflictConn) Call17(ctx context.Context, functionName string, args interface{}) (*tarantool.Response, error) {
+	c.calls++
+	return nil, tarantool.Error{Code: tarantool.ErrTransactionConflict, Msg: "transaction conflict"}
+}
+
+// Тест проверяет, что голос при конфликтах записывается ровно
+// RetryPolicy.Attempts раз: повторяет только хранилище, а исчерпание
+// попыток учитывается в метрике один раз
+func TestAddVote_ConflictAttempts(t *testing.T) {
+	ctx := context.Background()
+	pollID := uuid.New().String()
+	polls := repository.NewMemoryPollRepo()
+	assert.NoError(t, polls.SavePoll(ctx, models.Poll{ID: pollID, Creator: "creator", Question: "Q?", Options: map[string]int{"Да": 0}}))
+	conn := &conflictConn{}
+	policy := repository.RetryPolicy{Attempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	s := service.NewPollService(polls, repository.NewTarantoolVoteRepo(conn, "polls", "votes", policy, zerolog.Nop()), zerolog.Nop())
+	before := metrics.VoteRetriesExhausted.Value()
+
+	_, err := s.AddVote(ctx, "user1", pollID, []string{"Да"})
+
+	assert.ErrorContains(t, err, "ошибка сохранения голоса: конфликт транзакции")
+	assert.Equal(t, policy.Attempts, conn.calls)
+	assert.Equal(t, before+1, metrics.VoteRetriesExhausted.Value())
+}
+
 func TestGetResults(t *testing.T) {
 	validPollID := uuid.New().String()
 	question := "Test question?"
@@ -322,23 +532,61 @@ func TestGetResults(t *testing.T) {
 					Creator:  "creator",
 					Question: question,
 					Options:  map[string]int{"Option1": 5, "Option2": 3},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
-				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
+				m.On("GetPollSummary", mock.Anything, validPollID).Return(poll, nil)
+				m.On("IncrementViews", mock.Anything, validPollID).Return(nil)
 			},
 			expected: fmt.Sprintf("**Результаты опроса %s**\n%s\n- Option1: 5 голосов\n- Option2: 3 голосов\n", validPollID, question),
 		},
+		{
+			name:   "upper-case poll ID",
+			userID: "user1",
+			pollID: strings.ToUpper(validPollID),
+			mockSetup: func(m *MockPollRepository) {
+				m.On("GetPollSummary", mock.Anything, validPollID).
+					Return(models.Poll{ID: validPollID, Question: question, Options: map[string]int{"Option1": 1}}, nil)
+				m.On("IncrementViews", mock.Anything, validPollID).Return(nil)
+			},
+			expected: fmt.Sprintf("**Результаты опроса %s**\n%s\n- Option1: 1 голосов\n", validPollID, question),
+		},
+		{
+			name:        "invalid poll ID",
+			userID:      "user1",
+			pollID:      "'; drop",
+			mockSetup:   func(*MockPollRepository) {},
+			expectedErr: "неверный формат ID опроса",
+		},
 		{
 			name:   "poll not found",
 			userID: "user1",
 			pollID: validPollID,
 			mockSetup: func(m *MockPollRepository) {
-				m.On("GetPoll", mock.Anything, validPollID).
-					Return(models.Poll{}, errors.New("not found"))
+				m.On("GetPollSummary", mock.Anything, validPollID).
+					Return(models.Poll{}, repository.ErrNotFound)
 			},
 			expectedErr: "опрос не найден",
 		},
+		{
+			name:   "storage unavailable",
+			userID: "user1",
+			pollID: validPollID,
+			mockSetup: func(m *MockPollRepository) {
+				m.On("GetPollSummary", mock.Anything, validPollID).
+					Return(models.Poll{}, fmt.Errorf("ошибка получения опроса: %w", repository.ErrUnavailable))
+			},
+			expectedErr: "сервис временно недоступен, попробуйте позже",
+		},
+		{
+			name:   "unclassified storage error",
+			userID: "user1",
+			pollID: validPollID,
+			mockSetup: func(m *MockPollRepository) {
+				m.On("GetPollSummary", mock.Anything, validPollID).
+					Return(models.Poll{}, errors.New("boom"))
+			},
+			expectedErr: "ошибка получения опроса: boom",
+		},
 	}
 
 	for _, tt := range tests {
@@ -346,7 +594,7 @@ func TestGetResults(t *testing.T) {
 			mockRepo := new(MockPollRepository)
 			tt.mockSetup(mockRepo)
 
-			service := service.NewPollService(mockRepo)
+			service := service.NewPollService(mockRepo, new(MockVoteRepository), zerolog.Nop())
 			result, err := service.GetResults(context.Background(), tt.userID, tt.pollID)
 
 			if tt.expectedErr != "" {
@@ -386,7 +634,6 @@ func TestEndPoll(t *testing.T) {
 					Creator:  creatorID,
 					Question: question,
 					Options:  map[string]int{"Option1": 5, "Option2": 3},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
@@ -394,13 +641,20 @@ func TestEndPoll(t *testing.T) {
 			},
 			expected: fmt.Sprintf("Голосование %s окончено", validPollID),
 		},
+		{
+			name:        "invalid poll ID",
+			userID:      creatorID,
+			pollID:      "123e4567-e89b-12d3-a456-42661417400g",
+			mockSetup:   func(*MockPollRepository) {},
+			expectedErr: "неверный формат ID опроса",
+		},
 		{
 			name:   "poll not found",
 			userID: creatorID,
 			pollID: validPollID,
 			mockSetup: func(m *MockPollRepository) {
 				m.On("GetPoll", mock.Anything, validPollID).
-					Return(models.Poll{}, errors.New("not found"))
+					Return(models.Poll{}, repository.ErrNotFound)
 			},
 			expectedErr: "опрос не найден",
 		},
@@ -414,7 +668,6 @@ func TestEndPoll(t *testing.T) {
 					Creator:  creatorID,
 					Question: question,
 					Options:  map[string]int{"Option1": 5, "Option2": 3},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
@@ -431,7 +684,6 @@ func TestEndPoll(t *testing.T) {
 					Creator:  creatorID,
 					Question: question,
 					Options:  map[string]int{"Option1": 5, "Option2": 3},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
@@ -446,7 +698,7 @@ func TestEndPoll(t *testing.T) {
 			mockRepo := new(MockPollRepository)
 			tt.mockSetup(mockRepo)
 
-			service := service.NewPollService(mockRepo)
+			service := service.NewPollService(mockRepo, new(MockVoteRepository), zerolog.Nop())
 			result, err := service.EndPoll(context.Background(), tt.userID, tt.pollID)
 
 			if tt.expectedErr != "" {
@@ -472,7 +724,7 @@ func TestDeletePoll(t *testing.T) {
 		name        string
 		userID      string
 		pollID      string
-		mockSetup   func(*MockPollRepository)
+		mockSetup   func(*MockPollRepository, *MockVoteRepository)
 		expected    string
 		expectedErr string
 	}{
@@ -480,27 +732,45 @@ func TestDeletePoll(t *testing.T) {
 			name:   "successful delete by creator",
 			userID: creatorID,
 			pollID: validPollID,
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				poll := models.Poll{
 					ID:       validPollID,
 					Creator:  creatorID,
 					Question: question,
 					Options:  map[string]int{"Option1": 5, "Option2": 3},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
 				m.On("DeletePoll", mock.Anything, validPollID).Return(nil)
+				v.On("DeleteVotes", mock.Anything, validPollID).Return(nil)
 			},
 			expected: fmt.Sprintf("Голосование %s удалено", validPollID),
 		},
+		{
+			name:   "votes left behind do not fail the delete",
+			userID: creatorID,
+			pollID: validPollID,
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
+				m.On("GetPoll", mock.Anything, validPollID).Return(models.Poll{ID: validPollID, Creator: creatorID}, nil)
+				m.On("DeletePoll", mock.Anything, validPollID).Return(nil)
+				v.On("DeleteVotes", mock.Anything, validPollID).Return(errors.New("db error"))
+			},
+			expected: fmt.Sprintf("Голосование %s удалено", validPollID),
+		},
+		{
+			name:        "invalid poll ID",
+			userID:      creatorID,
+			pollID:      "------------------------------------",
+			mockSetup:   func(*MockPollRepository, *MockVoteRepository) {},
+			expectedErr: "неверный формат ID опроса",
+		},
 		{
 			name:   "poll not found",
 			userID: creatorID,
 			pollID: validPollID,
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				m.On("GetPoll", mock.Anything, validPollID).
-					Return(models.Poll{}, errors.New("not found"))
+					Return(models.Poll{}, repository.ErrNotFound)
 			},
 			expectedErr: "опрос не найден",
 		},
@@ -508,13 +778,12 @@ func TestDeletePoll(t *testing.T) {
 			name:   "not creator",
 			userID: otherUserID,
 			pollID: validPollID,
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				poll := models.Poll{
 					ID:       validPollID,
 					Creator:  creatorID,
 					Question: question,
 					Options:  map[string]int{"Option1": 5, "Option2": 3},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
@@ -525,13 +794,12 @@ func TestDeletePoll(t *testing.T) {
 			name:   "delete poll error",
 			userID: creatorID,
 			pollID: validPollID,
-			mockSetup: func(m *MockPollRepository) {
+			mockSetup: func(m *MockPollRepository, v *MockVoteRepository) {
 				poll := models.Poll{
 					ID:       validPollID,
 					Creator:  creatorID,
 					Question: question,
 					Options:  map[string]int{"Option1": 5, "Option2": 3},
-					Voters:   make(map[string]bool),
 					Closed:   false,
 				}
 				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
@@ -543,10 +811,10 @@ func TestDeletePoll(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockRepo := new(MockPollRepository)
-			tt.mockSetup(mockRepo)
+			mockRepo, mockVotes := new(MockPollRepository), new(MockVoteRepository)
+			tt.mockSetup(mockRepo, mockVotes)
 
-			service := service.NewPollService(mockRepo)
+			service := service.NewPollService(mockRepo, mockVotes, zerolog.Nop())
 			result, err := service.DeletePoll(context.Background(), tt.userID, tt.pollID)
 
 			if tt.expectedErr != "" {
@@ -558,6 +826,537 @@ func TestDeletePoll(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockVotes.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStorageErrorClasses(t *testing.T) {
+	validPollID := uuid.New().String()
+	creatorID := "creator1"
+	poll := models.Poll{
+		ID:       validPollID,
+		Creator:  creatorID,
+		Question: "Test question?",
+		Options:  map[string]int{"Option1": 0},
+	}
+
+	tests := []struct {
+		name        string
+		call        func(s *service.PollServiceImpl) (string, error)
+		mockSetup   func(*MockPollRepository)
+		expectedErr error
+	}{
+		{
+			name: "create unavailable",
+			call: func(s *service.PollServiceImpl) (string, error) {
+				return s.CreatePoll(context.Background(), creatorID, "Q", []string{"A"}, service.CreateOptions{})
+			},
+			mockSetup: func(m *MockPollRepository) {
+				m.On("SavePoll", mock.Anything, mock.Anything).Return(repository.ErrUnavailable)
+			},
+			expectedErr: service.ErrServiceUnavailable,
+		},
+		{
+			name: "end poll removed concurrently",
+			call: func(s *service.PollServiceImpl) (string, error) {
+				return s.EndPoll(context.Background(), creatorID, validPollID)
+			},
+			mockSetup: func(m *MockPollRepository) {
+				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
+				m.On("ClosePoll", mock.Anything, validPollID).Return(repository.ErrNotFound)
+			},
+			expectedErr: service.ErrPollNotFound,
+		},
+		{
+			name: "end poll unavailable",
+			call: func(s *service.PollServiceImpl) (string, error) {
+				return s.EndPoll(context.Background(), creatorID, validPollID)
+			},
+			mockSetup: func(m *MockPollRepository) {
+				m.On("GetPoll", mock.Anything, validPollID).Return(models.Poll{}, repository.ErrUnavailable)
+			},
+			expectedErr: service.ErrServiceUnavailable,
+		},
+		{
+			name: "delete unavailable",
+			call: func(s *service.PollServiceImpl) (string, error) {
+				return s.DeletePoll(context.Background(), creatorID, validPollID)
+			},
+			mockSetup: func(m *MockPollRepository) {
+				m.On("GetPoll", mock.Anything, validPollID).Return(poll, nil)
+				m.On("DeletePoll", mock.Anything, validPollID).Return(repository.ErrUnavailable)
+			},
+			expectedErr: service.ErrServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockPollRepository)
+			tt.mockSetup(mockRepo)
+
+			result, err := tt.call(service.NewPollService(mockRepo, new(MockVoteRepository), zerolog.Nop()))
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Empty(t, result)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Тест проверяет, что ответы и ошибки сервиса следуют языку из контекста
+func TestLocalizedResponses(t *testing.T) {
+	pollID := uuid.New().String()
+	poll := models.Poll{
+		ID:       pollID,
+		Creator:  "creator1",
+		Question: "Lunch?",
+		Options:  map[string]int{"Pizza": 2},
+	}
+	mockRepo, mockVotes := new(MockPollRepository), new(MockVoteRepository)
+	mockRepo.On("GetPoll", mock.Anything, pollID).Return(poll, nil)
+	mockRepo.On("GetPollSummary", mock.Anything, pollID).Return(poll, nil)
+	mockRepo.On("IncrementViews", mock.Anything, pollID).Return(nil)
+	mockVotes.On("AddVote", mock.Anything, mock.Anything).Return(false, repository.ErrAlreadyVoted)
+	s := service.NewPollService(mockRepo, mockVotes, zerolog.Nop())
+	en := i18n.New("en")
+	ctx := i18n.WithLocalizer(context.Background(), en)
+
+	result, err := s.GetResults(ctx, "user1", pollID)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("**Results of poll %s**\nLunch?\n- Pizza: 2 votes\n", pollID), result)
+
+	_, err = s.AddVote(ctx, "user1", pollID, []string{"Pizza"})
+	assert.Equal(t, "you have already voted in this poll", en.Error(err))
+	assert.EqualError(t, err, "вы уже голосовали в этом опросе", "текст для логов остаётся на языке по умолчанию")
+}
+
+// Тест проверяет, что повторная доставка команды создания из того же сообщения
+// не создаёт второй опрос и возвращает тот же ответ
+func TestCreatePoll_SameOriginIsIdempotent(t *testing.T) {
+	mockRepo := new(MockPollRepository)
+	var saved models.Poll
+	mockRepo.On("GetPoll", mock.Anything, mock.Anything).Return(models.Poll{}, repository.ErrNotFound).Once()
+	mockRepo.On("SavePoll", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(models.Poll)
+	}).Return(nil).Once()
+
+	s := service.NewPollService(mockRepo, new(MockVoteRepository), zerolog.Nop())
+	ctx := service.WithOrigin(context.Background(), service.Origin{PostID: "post1", ChannelID: "c1"})
+
+	first, err := s.CreatePoll(ctx, "user1", "Lunch?", []string{"Pizza", "Sushi"}, service.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, first, saved.ID)
+
+	mockRepo.On("GetPoll", mock.Anything, saved.ID).Return(saved, nil).Once()
+	second, err := s.CreatePoll(ctx, "user1", "Lunch?", []string{"Pizza", "Sushi"}, service.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	mockRepo.AssertNumberOfCalls(t, "SavePoll", 1)
+
+	// Та же команда другого пользователя - это другой опрос
+	mockRepo.On("GetPoll", mock.Anything, mock.Anything).Return(models.Poll{}, repository.ErrNotFound).Once()
+	mockRepo.On("SavePoll", mock.Anything, mock.Anything).Return(nil).Once()
+	other, err := s.CreatePoll(ctx, "user2", "Lunch?", []string{"Pizza", "Sushi"}, service.CreateOptions{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, other)
+	mockRepo.AssertExpectations(t)
+}
+
+// stubMembers отвечает на проверку членства в канале по заданному списку
+type stubMembers struct {
+	members map[string]bool
+}
+
+func (m stubMembers) IsChannelMember(ctx context.Context, channelID, userID string) (bool, error) {
+	return m.members[channelID+"/"+userID], nil
+}
+
+// Тест проверяет, что опрос, ограниченный каналом, доступен только из своего
+// канала, а из личных сообщений - участникам этого канала
+func TestChannelRestriction(t *testing.T) {
+	pollID := uuid.New().String()
+	newPoll := func(restricted bool) models.Poll {
+		return models.Poll{
+			ID:                pollID,
+			Creator:           "creator1",
+			Question:          "Q",
+			Options:           map[string]int{"A": 0},
+			ChannelID:         "c1",
+			RestrictToChannel: restricted,
+		}
+	}
+	channelErr := "этот опрос доступен только в своём канале"
+	vote := func(s *service.PollServiceImpl, ctx context.Context, userID string) (string, error) {
+		return s.AddVote(ctx, userID, pollID, []string{"A"})
+	}
+
+	tests := []struct {
+		name       string
+		restricted bool
+		origin     service.Origin
+		userID     string
+		call       func(s *service.PollServiceImpl, ctx context.Context, userID string) (string, error)
+		wantErr    string
+	}{
+		{
+			name:       "vote from the poll channel",
+			restricted: true,
+			origin:     service.Origin{ChannelID: "c1"},
+			userID:     "user1",
+			call:       vote,
+		},
+		{
+			name:       "vote from another channel",
+			restricted: true,
+			origin:     service.Origin{ChannelID: "c2"},
+			userID:     "user1",
+			call:       vote,
+			wantErr:    channelErr,
+		},
+		{
+			name:       "vote by DM from a channel member",
+			restricted: true,
+			origin:     service.Origin{ChannelID: "dm", Direct: true},
+			userID:     "member",
+			call:       vote,
+		},
+		{
+			name:       "vote by DM from a stranger",
+			restricted: true,
+			origin:     service.Origin{ChannelID: "dm", Direct: true},
+			userID:     "user1",
+			call:       vote,
+			wantErr:    channelErr,
+		},
+		{
+			name:       "results from another channel",
+			restricted: true,
+			origin:     service.Origin{ChannelID: "c2"},
+			userID:     "user1",
+			call: func(s *service.PollServiceImpl, ctx context.Context, userID string) (string, error) {
+				return s.GetResults(ctx, userID, pollID)
+			},
+			wantErr: channelErr,
+		},
+		{
+			name:       "results by DM are not allowed even for members",
+			restricted: true,
+			origin:     service.Origin{ChannelID: "dm", Direct: true},
+			userID:     "member",
+			call: func(s *service.PollServiceImpl, ctx context.Context, userID string) (string, error) {
+				return s.GetResults(ctx, userID, pollID)
+			},
+			wantErr: channelErr,
+		},
+		{
+			name:       "end from another channel",
+			restricted: true,
+			origin:     service.Origin{ChannelID: "c2"},
+			userID:     "creator1",
+			call: func(s *service.PollServiceImpl, ctx context.Context, userID string) (string, error) {
+				return s.EndPoll(ctx, userID, pollID)
+			},
+			wantErr: channelErr,
+		},
+		{
+			name:       "unrestricted poll from another channel",
+			restricted: false,
+			origin:     service.Origin{ChannelID: "c2"},
+			userID:     "user1",
+			call:       vote,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, mockVotes := new(MockPollRepository), new(MockVoteRepository)
+			mockRepo.On("GetPoll", mock.Anything, pollID).Return(newPoll(tt.restricted), nil)
+			mockRepo.On("GetPollSummary", mock.Anything, pollID).Return(newPoll(tt.restricted), nil)
+			mockVotes.On("AddVote", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+
+			s := service.NewPollService(mockRepo, mockVotes, zerolog.Nop())
+			s.SetChannelMembers(stubMembers{members: map[string]bool{"c1/member": true}})
+			ctx := service.WithOrigin(context.Background(), tt.origin)
+
+			_, err := tt.call(s, ctx, tt.userID)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockVotes.AssertNotCalled(t, "AddVote", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// Тест проверяет, что опрос запоминает канал создания и флаг ограничения
+func TestCreatePoll_StoresChannel(t *testing.T) {
+	mockRepo := new(MockPollRepository)
+	mockRepo.On("GetPoll", mock.Anything, mock.Anything).Return(models.Poll{}, repository.ErrNotFound)
+	mockRepo.On("SavePoll", mock.Anything, mock.MatchedBy(func(p models.Poll) bool {
+		return p.ChannelID == "c1" && p.RestrictToChannel
+	})).Return(nil)
+
+	s := service.NewPollService(mockRepo, new(MockVoteRepository), zerolog.Nop())
+	ctx := service.WithOrigin(context.Background(), service.Origin{PostID: "post1", ChannelID: "c1"})
+	result, err := s.CreatePoll(ctx, "user1", "Q", []string{"A"}, service.CreateOptions{RestrictToChannel: true})
+	assert.NoError(t, err)
+	assert.Contains(t, result, "Голосовать и смотреть результаты можно только в этом канале")
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест проверяет, что без возможности закрепить опрос он всё равно создаётся
+func TestCreatePoll_PinUnavailable(t *testing.T) {
+	mockRepo := new(MockPollRepository)
+	mockRepo.On("GetPoll", mock.Anything, mock.Anything).Return(models.Poll{}, repository.ErrNotFound)
+	mockRepo.On("SavePoll", mock.Anything, mock.Anything).Return(nil)
+
+	s := service.NewPollService(mockRepo, new(MockVoteRepository), zerolog.Nop())
+	ctx := service.WithOrigin(context.Background(), service.Origin{PostID: "post1", ChannelID: "c1"})
+	result, err := s.CreatePoll(ctx, "user1", "Q", []string{"A"}, service.CreateOptions{Pin: true})
+	assert.NoError(t, err)
+	assert.Contains(t, result, "Голосование создано")
+	assert.Contains(t, result, "Опрос не закреплён")
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "SetPinnedPost", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// stubAnnouncer запоминает опубликованные сообщения
+type stubAnnouncer struct {
+	channels []string
+	messages []string
+}
+
+func (a *stubAnnouncer) Announce(ctx context.Context, channelID, message string) error {
+	a.channels = append(a.channels, channelID)
+	a.messages = append(a.messages, message)
+	return nil
+}
+
+// Тест проверяет закрытие опроса голосом, достигшим кворума, и публикацию итогов
+func TestAddVote_Quorum(t *testing.T) {
+	pollID := uuid.New().String()
+	newPoll := func() models.Poll {
+		return models.Poll{
+			ID:        pollID,
+			Creator:   "creator1",
+			Question:  "Standup?",
+			Options:   map[string]int{"Да": 1, "Нет": 0},
+			ChannelID: "c1",
+			Quorum:    2,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		quorum       int
+		origin       service.Origin
+		wantClosed   bool
+		wantAnnounce bool
+	}{
+		{name: "below quorum", quorum: 3, origin: service.Origin{ChannelID: "c1"}},
+		{name: "quorum reached in the poll channel", quorum: 2, origin: service.Origin{ChannelID: "c1"}, wantClosed: true},
+		{name: "quorum reached from a DM", quorum: 2, origin: service.Origin{ChannelID: "dm", Direct: true}, wantClosed: true, wantAnnounce: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := newPoll()
+			poll.Quorum = tt.quorum
+			mockRepo, mockVotes := new(MockPollRepository), new(MockVoteRepository)
+			mockRepo.On("GetPoll", mock.Anything, pollID).Return(poll, nil)
+			mockVotes.On("AddVote", mock.Anything, ballotOf(pollID, "user2", "Да")).Return(tt.wantClosed, nil)
+
+			announcer := &stubAnnouncer{}
+			s := service.NewPollService(mockRepo, mockVotes, zerolog.Nop())
+			s.SetAnnouncer(announcer)
+			ctx := service.WithOrigin(context.Background(), tt.origin)
+
+			result, err := s.AddVote(ctx, "user2", pollID, []string{"Да"})
+			assert.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+			mockVotes.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "ClosePoll", mock.Anything, mock.Anything)
+
+			results := "- Да: 2 голосов"
+			switch {
+			case !tt.wantClosed:
+				assert.NotContains(t, result, "Кворум достигнут")
+				assert.Empty(t, announcer.messages)
+			case tt.wantAnnounce:
+				assert.Equal(t, []string{"c1"}, announcer.channels)
+				assert.Contains(t, announcer.messages[0], results)
+				assert.Contains(t, result, "Кворум достигнут")
+				assert.NotContains(t, result, results, "итоги уже опубликованы в канале опроса")
+			default:
+				assert.Empty(t, announcer.messages)
+				assert.Contains(t, result, results)
+			}
+		})
+	}
+}
+
+// Тест проверяет, что отрицательный кворум отклоняется
+func TestCreatePoll_InvalidQuorum(t *testing.T) {
+	s := service.NewPollService(new(MockPollRepository), new(MockVoteRepository), zerolog.Nop())
+	_, err := s.CreatePoll(context.Background(), "user1", "Q", []string{"A"}, service.CreateOptions{Quorum: -1})
+	assert.EqualError(t, err, "кворум должен быть целым числом не меньше 1")
+}
+
+// Тест проверяет правило одного открытого опроса в канале
+func TestCreatePoll_OnePollPerChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		global  bool
+		opts    service.CreateOptions
+		origin  service.Origin
+		wantErr bool
+	}{
+		{name: "global rule in a busy channel", global: true, origin: service.Origin{ChannelID: "c1"}, wantErr: true},
+		{name: "exclusive flag in a busy channel", opts: service.CreateOptions{Exclusive: true}, origin: service.Origin{ChannelID: "c1"}, wantErr: true},
+		{name: "rule disabled", origin: service.Origin{ChannelID: "c1"}},
+		{name: "channel without open polls", global: true, origin: service.Origin{ChannelID: "c2"}},
+		{name: "channel with a closed poll", global: true, origin: service.Origin{ChannelID: "c3"}},
+		{name: "direct messages are not limited", global: true, origin: service.Origin{ChannelID: "c1", Direct: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			ctx := context.Background()
+			existing := models.Poll{ID: "open1", Creator: "user2", Question: "Обед?", ChannelID: "c1", Options: map[string]int{"A": 0}}
+			assert.NoError(t, repo.SavePoll(ctx, existing))
+			closed := models.Poll{ID: "closed1", Creator: "user2", Question: "Ужин?", ChannelID: "c3", Closed: true, Options: map[string]int{"A": 0}}
+			assert.NoError(t, repo.SavePoll(ctx, closed))
+
+			s := service.NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.SetOnePollPerChannel(tt.global)
+			ctx = service.WithOrigin(ctx, tt.origin)
+
+			_, err := s.CreatePoll(ctx, "user1", "Q", []string{"A"}, tt.opts)
+			if tt.wantErr {
+				assert.EqualError(t, err, "в канале уже есть открытый опрос `open1`: Обед?. Завершите его, прежде чем создавать новый")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// Тест проверяет, что одновременные создания в одном канале дают один опрос
+func TestCreatePoll_OnePollPerChannelRace(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := service.NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.SetOnePollPerChannel(true)
+
+	const creators = 10
+	var wg sync.WaitGroup
+	errs := make([]error, creators)
+	for i := 0; i < creators; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := service.WithOrigin(context.Background(), service.Origin{PostID: fmt.Sprintf("post%d", i), ChannelID: "c1"})
+			_, errs[i] = s.CreatePoll(ctx, fmt.Sprintf("user%d", i), "Q", []string{"A"}, service.CreateOptions{})
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	polls, _, err := repo.ListPolls(context.Background(), repository.ListFilter{ChannelID: "c1"})
+	assert.NoError(t, err)
+	assert.Len(t, polls, 1)
+}
+
+// Тест проверяет рейтинговый опрос от создания до подведения итогов
+func TestRankedPoll(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	votes := repository.NewMemoryVoteRepo(repo)
+	s := service.NewPollService(repo, votes, zerolog.Nop())
+	ctx := context.Background()
+
+	created, err := s.CreatePoll(ctx, "creator1", "Обед?", []string{"Пицца", "Суши", "Борщ"}, service.CreateOptions{Ranked: true})
+	assert.NoError(t, err)
+	assert.Contains(t, created, "Рейтинговый опрос")
+	polls, _, err := repo.ListPolls(ctx, repository.ListFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, polls, 1)
+	pollID := polls[0].ID
+	assert.Equal(t, []string{"Пицца", "Суши", "Борщ"}, polls[0].OptionOrder)
+
+	ballots := map[string][]string{
+		"user1": {"Пицца", "Суши"},
+		"user2": {"пицца"},
+		"user3": {"Суши", "Пицца"},
+		"user4": {"Суши"},
+		"user5": {"Борщ", "Пицца"},
+	}
+	for _, userID := range []string{"user1", "user2", "user3", "user4", "user5"} {
+		_, err := s.AddVote(ctx, userID, pollID, ballots[userID])
+		assert.NoError(t, err)
+	}
+
+	stored, err := repo.GetPoll(ctx, pollID)
+	assert.NoError(t, err)
+	vote, err := votes.GetVote(ctx, pollID, "user2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Пицца"}, vote.Choices, "вариант хранится в написании опроса")
+	assert.Equal(t, 2, stored.Options["Пицца"], "счётчик - число первых предпочтений")
+
+	results, err := s.GetResults(ctx, "user1", pollID)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("**Результаты опроса %s**\nОбед?\n", pollID)+
+		"Раунд 1: Пицца - 2, Суши - 2, Борщ - 1\n"+
+		"Выбывает: Борщ\n"+
+		"Раунд 2: Пицца - 3, Суши - 2\n"+
+		"**Победитель: Пицца**\n", results)
+
+	ended, err := s.EndPoll(ctx, "creator1", pollID)
+	assert.NoError(t, err)
+	assert.Contains(t, ended, "**Победитель: Пицца**")
+}
+
+// Тест проверяет проверку бюллетеней
+func TestAddVote_Ballots(t *testing.T) {
+	pollID := uuid.New().String()
+	tests := []struct {
+		name    string
+		ranked  bool
+		choices []string
+		wantErr string
+	}{
+		{name: "duplicate option in a ballot", ranked: true, choices: []string{"A", "B", "a"}, wantErr: "вариант 'A' указан в бюллетене дважды"},
+		{name: "unknown option in a ballot", ranked: true, choices: []string{"A", "Z"}, wantErr: "вариант 'Z' не существует"},
+		{name: "ranking in a plain poll", choices: []string{"A", "B"}, wantErr: "в этом опросе можно выбрать только один вариант"},
+		{name: "partial ranking", ranked: true, choices: []string{"B"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			poll := models.Poll{
+				ID: pollID, Creator: "creator1", Question: "Q",
+				Options: map[string]int{"A": 0, "B": 0, "C": 0},
+				Ranked:  tt.ranked,
+			}
+			assert.NoError(t, repo.SavePoll(context.Background(), poll))
+			s := service.NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+			_, err := s.AddVote(context.Background(), "user1", pollID, tt.choices)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
 		})
 	}
 }
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// PreviewPoll проверяет опрос так же, как CreatePoll, включая правило одного
+// открытого опроса в канале, но ничего не сохраняет и не публикует: ответ
+// показывает вопрос, варианты по порядку и флаги, с которыми опрос был бы
+// создан.
+func (s *PollServiceImpl) PreviewPoll(ctx context.Context, userID, question string, options []string, opts CreateOptions) (string, error) {
+	poll, err := s.ValidatePollInput(ctx, userID, question, options, opts)
+	if err != nil {
+		return "", err
+	}
+	exclusive := s.exclusiveIn(ctx, opts)
+	if exclusive {
+		if err := s.checkNoOpenPoll(ctx, poll.ChannelID); err != nil {
+			return "", err
+		}
+	}
+	return renderPreview(i18n.FromContext(ctx), poll, opts, exclusive), nil
+}
+
+// renderPreview - ответ на create --dry-run. Закрепление и правило одного
+// открытого опроса в опросе не хранятся, поэтому берутся из opts.
+func renderPreview(loc *i18n.Localizer, poll models.Poll, opts CreateOptions, exclusive bool) string {
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.PollPreview, poll.Question, renderDescription(poll.Description)))
+	renderPollSetup(&sb, loc, poll, opts.Weights, opts.Voters)
+	if exclusive {
+		sb.WriteString(loc.T(i18n.PreviewExclusive))
+	}
+	if opts.Pin {
+		sb.WriteString(loc.T(i18n.PreviewPin))
+	}
+	sb.WriteString(loc.T(i18n.PreviewFooter))
+	return sb.String()
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// newPreviewService - сервис с правилами, которые проверяет create: ссылки
+// запрещены, пояснение короткое, участники для --weights известны, а в
+// канале c2 уже есть открытый опрос.
+func newPreviewService(t *testing.T) (*PollServiceImpl, *repository.MemoryPollRepo) {
+	t.Helper()
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.now = func() time.Time { return time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC) }
+	s.SetContentRules(ContentRules{BlockLinks: true})
+	s.SetMaxDescriptionLength(20)
+	s.SetUserFinder(weightUsers)
+	require.NoError(t, repo.SavePoll(context.Background(), models.Poll{
+		ID: "open1", ChannelID: "c2", Question: "Уже открыт", Options: map[string]int{"Да": 0},
+	}))
+	return s, repo
+}
+
+// Тест проверяет, что предпросмотр и создание отвечают одинаково на одни и
+// те же данные, а предпросмотр ничего не сохраняет
+func TestPreviewPoll_MatchesCreate(t *testing.T) {
+	inChannel := WithOrigin(context.Background(), Origin{PostID: "post1", ChannelID: "c1"})
+	busyChannel := WithOrigin(context.Background(), Origin{PostID: "post1", ChannelID: "c2"})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		question string
+		options  []string
+		opts     CreateOptions
+		wantErr  string
+	}{
+		{name: "valid", question: "Где обедаем?", options: []string{"🍕:Пицца", "Суши"},
+			opts: CreateOptions{Quorum: 3, Tags: []string{"Team-A"}, Expires: 2 * time.Hour}},
+		{name: "weights", question: "Релизим?", options: []string{"Да", "Нет"},
+			opts: CreateOptions{Weights: map[string]int{"alice": 2}}},
+		{name: "no options", question: "Q", wantErr: "должна быть хотя бы одна опция"},
+		{name: "question too long", question: strings.Repeat("в", maxQuestionLength+1), options: []string{"A"}},
+		{name: "option too long", question: "Q", options: []string{strings.Repeat("a", maxOptionLength+1)}},
+		{name: "duplicate options", question: "Q", options: []string{"Пицца", " пицца "}},
+		{name: "emoji duplicate", question: "Q", options: []string{"🍕:Пицца", "Пицца"}},
+		{name: "mention", question: "Q", options: []string{"@channel"}},
+		{name: "link", question: "См. https://example.com", options: []string{"A"}},
+		{name: "description too long", question: "Q", options: []string{"A"},
+			opts: CreateOptions{Description: strings.Repeat("д", 21)}},
+		{name: "bad tag", question: "Q", options: []string{"A"}, opts: CreateOptions{Tags: []string{"no spaces"}}},
+		{name: "unknown weight user", question: "Q", options: []string{"A"},
+			opts: CreateOptions{Weights: map[string]int{"carol": 2}}},
+		{name: "ranked weights", question: "Q", options: []string{"A", "B"},
+			opts: CreateOptions{Ranked: true, Weights: map[string]int{"alice": 2}}},
+		{name: "expires conflict", question: "Q", options: []string{"A"},
+			opts: CreateOptions{Expires: time.Hour, NoExpire: true}},
+		{name: "no expire forbidden", question: "Q", options: []string{"A"}, opts: CreateOptions{NoExpire: true}},
+		{name: "members only outside channel", ctx: context.Background(), question: "Q", options: []string{"A"},
+			opts: CreateOptions{MembersOnly: true}},
+		{name: "exclusive", ctx: busyChannel, question: "Q", options: []string{"A"}, opts: CreateOptions{Exclusive: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := inChannel
+			if tt.ctx != nil {
+				ctx = tt.ctx
+			}
+
+			previews, previewRepo := newPreviewService(t)
+			preview, previewErr := previews.PreviewPoll(ctx, "user1", tt.question, tt.options, tt.opts)
+			creates, _ := newPreviewService(t)
+			_, createErr := creates.CreatePoll(ctx, "user1", tt.question, tt.options, tt.opts)
+
+			// Предпросмотр не сохраняет опрос, даже если он прошёл проверки
+			stored, err := previewRepo.CountPolls(context.Background(), repository.ListFilter{})
+			require.NoError(t, err)
+			assert.Equal(t, 1, stored)
+
+			if createErr != nil {
+				assert.EqualError(t, previewErr, createErr.Error())
+				if tt.wantErr != "" {
+					assert.EqualError(t, createErr, tt.wantErr)
+				}
+				return
+			}
+			require.NoError(t, previewErr)
+			assert.Contains(t, preview, "Предпросмотр: опрос не создан")
+		})
+	}
+}
+
+// Тест проверяет, что предпросмотр показывает вопрос, варианты по порядку и
+// действующие флаги, в том числе заданные настройками бота
+func TestPreviewPoll_Render(t *testing.T) {
+	s, _ := newPreviewService(t)
+	s.SetOnePollPerChannel(true)
+	s.SetVoteReceipts(true)
+	ctx := WithOrigin(context.Background(), Origin{ChannelID: "c1"})
+
+	forEachLang(t, "preview", func(loc *i18n.Localizer) string {
+		got, err := s.PreviewPoll(i18n.WithLocalizer(ctx, loc), "user1", "Где\nобедаем?", []string{"🍕:Пицца", "Суши", "Дома"},
+			CreateOptions{Pin: true, Quorum: 2, Expires: 3 * time.Hour, Tags: []string{"lunch"}, Description: "Пятница"})
+		require.NoError(t, err)
+		return got
+	})
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// Сколько участников показывает одна страница progress
+const progressPageSize = 50
+
+// PollProgress показывает создателю, сколько голосов набрал опрос со
+// списком участников или кворумом, а в опросе со списком - кто ещё не
+// проголосовал и кто уже проголосовал. Анонимный опрос списка участников
+// не имеет. page - страница участников, с 1; 0 - первая.
+func (s *PollServiceImpl) PollProgress(ctx context.Context, userID, pollID string, page int) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpGetPoll)
+	}
+	if poll.Creator != userID {
+		return "", i18n.NewError(i18n.OnlyCreatorProgress)
+	}
+	if !poll.Restricted() && poll.Quorum == 0 {
+		return "", i18n.NewError(i18n.ProgressUnrestricted, poll.ID)
+	}
+
+	loc := i18n.FromContext(ctx)
+	expected := poll.Quorum
+	if poll.Restricted() {
+		expected = poll.ExpectedVoters()
+	}
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.PollProgress, poll.ID, poll.VoterCount(), expected))
+	if !poll.Restricted() {
+		return sb.String(), nil
+	}
+
+	waiting, voted, err := s.splitVoters(ctx, poll)
+	if err != nil {
+		return "", err
+	}
+	// Сначала те, кого опрос ждёт: ради них команду и вызывают
+	members := slices.Concat(waiting, voted)
+	pages := max((len(members)+progressPageSize-1)/progressPageSize, 1)
+	if page > pages {
+		return "", i18n.NewError(i18n.ProgressOutOfRange, pages)
+	}
+	page = max(page, 1)
+	from := (page - 1) * progressPageSize
+	to := min(from+progressPageSize, len(members))
+
+	// Имена запрашиваются только для участников страницы
+	var waitingNames, votedNames []string
+	for i := from; i < to; i++ {
+		if i < len(waiting) {
+			waitingNames = append(waitingNames, s.username(ctx, members[i]))
+		} else {
+			votedNames = append(votedNames, s.username(ctx, members[i]))
+		}
+	}
+	if len(waitingNames) > 0 {
+		sb.WriteString(loc.T(i18n.ProgressWaiting, len(waiting), strings.Join(waitingNames, ", ")))
+	}
+	if len(votedNames) > 0 {
+		sb.WriteString(loc.T(i18n.ProgressVoted, len(voted), strings.Join(votedNames, ", ")))
+	}
+	if pages > 1 {
+		if page < pages {
+			sb.WriteString(loc.T(i18n.ProgressPage, from+1, to, len(members), page+1))
+		} else {
+			sb.WriteString(loc.T(i18n.ProgressPageLast, from+1, to, len(members)))
+		}
+	}
+	return sb.String(), nil
+}
+
+// splitVoters делит список участников опроса на тех, кто ещё не
+// голосовал, и тех, кто голосовал, в порядке списка. Воздержавшийся
+// считается проголосовавшим. Голоса тех, кого из списка убрали, не
+// показываются.
+func (s *PollServiceImpl) splitVoters(ctx context.Context, poll models.Poll) (waiting, voted []string, err error) {
+	votes, err := s.votes.ListVotes(ctx, poll.ID)
+	if err != nil {
+		return nil, nil, s.storageError(err, i18n.OpListVotes)
+	}
+	cast := make(map[string]bool, len(votes))
+	for _, vote := range votes {
+		cast[vote.UserID] = true
+	}
+	for _, userID := range poll.AllowedVoters {
+		if cast[userID] {
+			voted = append(voted, userID)
+		} else {
+			waiting = append(waiting, userID)
+		}
+	}
+	return waiting, voted, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+var committeeNames = stubUserNames{"u-alice": "alice", "u-bob": "bob", "u-carol": "carol"}
+
+// Тест проверяет ход голосования: в опросе со списком видно, кто
+// проголосовал и кого опрос ждёт, в опросе с одним кворумом - только число
+// голосов, а опросу без списка и кворума ждать некого
+func TestPollProgress(t *testing.T) {
+	voters := []string{"alice", "bob", "carol"}
+	tests := []struct {
+		name    string
+		opts    CreateOptions
+		userID  string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "voter list",
+			opts:   CreateOptions{Voters: voters},
+			userID: "creator1",
+			want:   "Опрос %s: 1 из 3 голосов\nЕщё не проголосовали (2): @bob, @carol\nПроголосовали (1): @alice\n",
+		},
+		{
+			name:   "voter list with a quorum",
+			opts:   CreateOptions{Voters: voters, Quorum: 2},
+			userID: "creator1",
+			want:   "Опрос %s: 1 из 2 голосов\nЕщё не проголосовали (2): @bob, @carol\nПроголосовали (1): @alice\n",
+		},
+		{
+			name:   "quorum only",
+			opts:   CreateOptions{Quorum: 10},
+			userID: "creator1",
+			want:   "Опрос %s: 1 из 10 голосов\n",
+		},
+		{
+			name:    "anyone can vote",
+			userID:  "creator1",
+			wantErr: "у опроса %s нет списка участников и кворума: ждать некого",
+		},
+		{
+			name:    "not the creator",
+			opts:    CreateOptions{Voters: voters},
+			userID:  "u-alice",
+			wantErr: "только создатель может смотреть, кто проголосовал",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.SetUserFinder(committee)
+			s.SetUserNames(committeeNames)
+
+			created, err := s.CreatePollWithID(ctx, "creator1", "Утверждаем бюджет?", []string{"Да", "Нет"}, tt.opts)
+			require.NoError(t, err)
+			_, err = s.AddVote(ctx, "u-alice", created.ID, []string{"Да"})
+			require.NoError(t, err)
+
+			got, err := s.PollProgress(ctx, tt.userID, created.ID, 0)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, strings.ReplaceAll(tt.wantErr, "%s", created.ID))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf(tt.want, created.ID), got)
+		})
+	}
+}
+
+// Тест проверяет страницы progress: сначала участники, которых опрос ждёт,
+// по 50 на странице, и отказ для страницы за последней
+func TestPollProgress_Pages(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	votes := repository.NewMemoryVoteRepo(repo)
+	s := NewPollService(repo, votes, zerolog.Nop())
+
+	poll := models.Poll{ID: winnerPollID, Creator: "creator1", Question: "Q", Options: map[string]int{"Да": 0}}
+	for i := 0; i < 120; i++ {
+		poll.AllowedVoters = append(poll.AllowedVoters, fmt.Sprintf("u%03d", i))
+	}
+	require.NoError(t, repo.SavePoll(ctx, poll))
+	for i := 0; i < 10; i++ {
+		_, err := votes.AddVote(ctx, models.Vote{PollID: winnerPollID, UserID: fmt.Sprintf("u%03d", i), Choices: []string{"Да"}})
+		require.NoError(t, err)
+	}
+	ids := func(from, to int) string {
+		var names []string
+		for i := from; i < to; i++ {
+			names = append(names, fmt.Sprintf("u%03d", i))
+		}
+		return strings.Join(names, ", ")
+	}
+
+	first, err := s.PollProgress(ctx, "creator1", winnerPollID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "Опрос "+winnerPollID+": 10 из 120 голосов\n"+
+		"Ещё не проголосовали (110): "+ids(10, 60)+"\n"+
+		"Показаны участники 1-50 из 120, используйте --page 2\n", first)
+
+	last, err := s.PollProgress(ctx, "creator1", winnerPollID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "Опрос "+winnerPollID+": 10 из 120 голосов\n"+
+		"Ещё не проголосовали (110): "+ids(110, 120)+"\n"+
+		"Проголосовали (10): "+ids(0, 10)+"\n"+
+		"Показаны участники 101-120 из 120\n", last)
+
+	_, err = s.PollProgress(ctx, "creator1", winnerPollID, 4)
+	assert.EqualError(t, err, "в списке участников опроса страниц: 3")
+}
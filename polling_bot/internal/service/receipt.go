@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// SetVoteReceipts включает квитанции о голосе во всех новых опросах, а не
+// только созданных с --receipts. Квитанции отправляет DirectMessenger.
+func (s *PollServiceImpl) SetVoteReceipts(byDefault bool) {
+	s.receipts = byDefault
+}
+
+// sendReceipt присылает участнику квитанцию о записанном голосе, если опрос
+// создан с квитанциями: вопрос, выбор и время. Голос уже записан, поэтому
+// недоставленная квитанция только пишется в лог.
+func (s *PollServiceImpl) sendReceipt(ctx context.Context, userID string, poll models.Poll, choice string) {
+	if !poll.VoteReceipts || s.messenger == nil {
+		return
+	}
+	message := i18n.FromContext(ctx).T(i18n.VoteReceipt, poll.ID, poll.Question, choice, renderTime(s.now()))
+	if err := s.messenger.SendDirect(context.WithoutCancel(ctx), userID, message); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Str("user_id", userID).
+			Msg("Не удалось отправить участнику квитанцию о голосе")
+	}
+}
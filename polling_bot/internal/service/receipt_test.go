@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+// failingMessenger не доставляет личные сообщения
+type failingMessenger struct {
+	calls int
+}
+
+func (m *failingMessenger) SendDirect(ctx context.Context, userID, message string) error {
+	m.calls++
+	return errors.New("личный канал недоступен")
+}
+
+// Тест проверяет квитанцию о голосе: она приходит участнику в опросе с
+// --receipts или при включённых по умолчанию квитанциях и не приходит в
+// остальных опросах и при повторной доставке команды
+func TestAddVote_Receipts(t *testing.T) {
+	previous := renderLocation
+	renderLocation = time.UTC
+	t.Cleanup(func() { renderLocation = previous })
+
+	tests := []struct {
+		name      string
+		byDefault bool
+		opts      CreateOptions
+		choices   []string
+		want      string
+	}{
+		{
+			name:    "receipts flag",
+			opts:    CreateOptions{VoteReceipts: true},
+			choices: []string{"Суши"},
+			want:    "**Ваш голос учтён**\nОпрос: `%s`\nВопрос: Где обедаем?\nВыбор: Суши\nВремя: 03.03.2025 10:15 UTC",
+		},
+		{
+			name:      "receipts by default",
+			byDefault: true,
+			opts:      CreateOptions{Ranked: true},
+			choices:   []string{"Суши", "Пицца"},
+			want:      "**Ваш голос учтён**\nОпрос: `%s`\nВопрос: Где обедаем?\nВыбор: Суши > Пицца\nВремя: 03.03.2025 10:15 UTC",
+		},
+		{name: "no receipts", choices: []string{"Суши"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithOrigin(i18n.WithLocalizer(context.Background(), i18n.New("ru")), Origin{PostID: "post1", ChannelID: "c1"})
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.now = func() time.Time { return time.Date(2025, 3, 3, 10, 15, 0, 0, time.UTC) }
+			messenger := &recordingMessenger{sent: map[string]string{}}
+			s.SetDirectMessenger(messenger)
+			s.SetVoteReceipts(tt.byDefault)
+
+			created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, tt.opts)
+			require.NoError(t, err)
+			_, err = s.AddVote(WithOrigin(ctx, Origin{PostID: "vote1", ChannelID: "c1"}), "user2", created.ID, tt.choices)
+			require.NoError(t, err)
+
+			if tt.want == "" {
+				assert.Empty(t, messenger.sent)
+				return
+			}
+			assert.Equal(t, map[string]string{"user2": fmt.Sprintf(tt.want, created.ID)}, messenger.sent)
+
+			// Повторная доставка того же голоса квитанцию не дублирует
+			delete(messenger.sent, "user2")
+			_, err = s.AddVote(WithOrigin(ctx, Origin{PostID: "vote1", ChannelID: "c1"}), "user2", created.ID, tt.choices)
+			require.NoError(t, err)
+			assert.Empty(t, messenger.sent)
+		})
+	}
+}
+
+// Тест проверяет, что недоставленная квитанция не отменяет голос
+func TestAddVote_ReceiptNotDelivered(t *testing.T) {
+	ctx := WithOrigin(context.Background(), Origin{PostID: "post1", ChannelID: "c1"})
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	messenger := &failingMessenger{}
+	s.SetDirectMessenger(messenger)
+
+	created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, CreateOptions{VoteReceipts: true})
+	require.NoError(t, err)
+	reply, err := s.AddVote(WithOrigin(ctx, Origin{PostID: "vote1", ChannelID: "c1"}), "user2", created.ID, []string{"Пицца"})
+	require.NoError(t, err)
+	assert.Contains(t, reply, "записан")
+	assert.Equal(t, 1, messenger.calls)
+
+	poll, err := repo.GetPoll(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, poll.Options["Пицца"])
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// Сколько по умолчанию помнить сообщение, которым подан голос
+const defaultVoteKeyTTL = 10 * time.Minute
+
+// SetVoteKeyTTL задаёт, сколько после голоса его сообщение узнаётся при
+// повторной доставке: в этот срок повтор получает исходный ответ вместо
+// отказа. 0 выключает проверку.
+func (s *PollServiceImpl) SetVoteKeyTTL(ttl time.Duration) {
+	s.voteKeyTTL = ttl
+}
+
+// redeliveredVote проверяет, не отклонён ли голос потому, что это же
+// сообщение уже было учтено: повторная доставка события после
+// переподключения отклоняется как повторный голос, а если первый голос
+// закрыл опрос по кворуму, - как голос в закрытый опрос.
+func (s *PollServiceImpl) redeliveredVote(ctx context.Context, userID, pollID string, err error) (models.Vote, bool) {
+	var localized *i18n.Error
+	if !errors.As(err, &localized) || (localized.Key != i18n.AlreadyVoted && localized.Key != i18n.PollClosed) {
+		return models.Vote{}, false
+	}
+	postID := OriginFrom(ctx).PostID
+	if postID == "" || s.voteKeyTTL <= 0 {
+		return models.Vote{}, false
+	}
+
+	// Голос анонимного опроса хранится под ключами участника и сообщения
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return models.Vote{}, false
+	}
+	vote, err := s.votes.GetVote(ctx, pollID, poll.VoterKey(s.anonymousSecret, userID))
+	if err != nil || vote.PostID != poll.PostKey(s.anonymousSecret, postID) || s.now().Sub(vote.VotedAt) > s.voteKeyTTL {
+		return models.Vote{}, false
+	}
+	return vote, true
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+const redeliveryPollID = "123e4567-e89b-12d3-a456-426614174000"
+
+// Тест проверяет ответ на повторный голос: повторная доставка того же
+// сообщения получает исходный ответ, пока не истёк срок, а голос из
+// другого сообщения отклоняется
+func TestAddVote_Redelivery(t *testing.T) {
+	voted := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		quorum int
+		// Сообщения первой и повторной доставки голоса
+		first, again string
+		after        time.Duration
+		disabled     bool
+		want         string
+		wantErr      string
+	}{
+		{name: "redelivered post", first: "post1", again: "post1", after: time.Minute,
+			want: "Ваш голос в голосовании " + redeliveryPollID + " записан: Пицца"},
+		{name: "redelivered quorum vote", quorum: 1, first: "post1", again: "post1", after: time.Minute,
+			want: "Ваш голос в голосовании " + redeliveryPollID + " записан: Пицца"},
+		{name: "another post", first: "post1", again: "post2", after: time.Minute,
+			wantErr: "вы уже голосовали в этом опросе"},
+		{name: "key expired", first: "post1", again: "post1", after: 11 * time.Minute,
+			wantErr: "вы уже голосовали в этом опросе"},
+		{name: "check disabled", first: "post1", again: "post1", after: time.Minute, disabled: true,
+			wantErr: "вы уже голосовали в этом опросе"},
+		{name: "no post", after: time.Minute,
+			wantErr: "вы уже голосовали в этом опросе"},
+		{name: "redelivered quorum vote expired", quorum: 1, first: "post1", again: "post1", after: time.Hour,
+			wantErr: "опрос завершен"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			require.NoError(t, repo.SavePoll(ctx, models.Poll{
+				ID:          redeliveryPollID,
+				Creator:     "creator1",
+				Question:    "Где обедаем?",
+				Options:     map[string]int{"Пицца": 0, "Суши": 0},
+				OptionOrder: []string{"Пицца", "Суши"},
+				Quorum:      tt.quorum,
+			}))
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			if tt.disabled {
+				s.SetVoteKeyTTL(0)
+			}
+			now := voted
+			s.now = func() time.Time { return now }
+
+			first := WithOrigin(ctx, Origin{PostID: tt.first, ChannelID: "c1"})
+			_, err := s.AddVote(first, "u1", redeliveryPollID, []string{"пицца"})
+			require.NoError(t, err)
+
+			now = voted.Add(tt.after)
+			again := WithOrigin(ctx, Origin{PostID: tt.again, ChannelID: "c1"})
+			got, err := s.AddVote(again, "u1", redeliveryPollID, []string{"Суши"})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			stored, err := repo.GetPoll(ctx, redeliveryPollID)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int{"Пицца": 1, "Суши": 0}, stored.Options, "повтор не учитывается ещё раз")
+		})
+	}
+}
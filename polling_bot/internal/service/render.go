@@ -0,0 +1,413 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"polling_bot/internal/buildinfo"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// Все ответы сервиса пользователю собираются здесь из каталога i18n.
+// Функции не обращаются к хранилищу и зависят только от аргументов:
+// одинаковые данные дают одинаковый текст, что проверяют эталоны
+// testdata/render.
+
+// Часовой пояс сроков в сообщениях - пояс сервера бота; тесты задают
+// его, чтобы эталоны не зависели от машины.
+var renderLocation = time.Local
+
+// renderTime показывает срок опроса.
+func renderTime(t time.Time) string {
+	return t.In(renderLocation).Format(expiryTimeLayout)
+}
+
+// renderCreated - ответ на создание опроса: вопрос, варианты по порядку
+// и включённые флаги.
+func renderCreated(loc *i18n.Localizer, poll models.Poll, weights map[string]int, voters []string) string {
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.PollCreated, poll.ID, poll.Question, renderDescription(poll.Description)))
+	renderPollSetup(&sb, loc, poll, weights, voters)
+	return sb.String()
+}
+
+// renderPollSetup дописывает варианты нового опроса и действующие флаги:
+// общая часть ответа на create и предпросмотра create --dry-run.
+func renderPollSetup(sb *strings.Builder, loc *i18n.Localizer, poll models.Poll, weights map[string]int, voters []string) {
+	for _, option := range poll.OptionList() {
+		sb.WriteString(loc.T(i18n.PollCreatedOption, option.ID, optionLabel(option.Emoji, option.Text)))
+	}
+	if len(poll.Tags) > 0 {
+		sb.WriteString(loc.T(i18n.CreatedTags, renderTags(poll.Tags)))
+	}
+	if poll.Weighted() {
+		sb.WriteString(loc.T(i18n.CreatedWeights, renderWeights(weights)))
+	}
+	if poll.AllowAbstain {
+		sb.WriteString(loc.T(i18n.CreatedAbstain, loc.T(i18n.AbstainOption)))
+	}
+	if poll.RestrictToChannel {
+		sb.WriteString(loc.T(i18n.CreatedChannelOnly))
+	}
+	if poll.MembersOnly {
+		sb.WriteString(loc.T(i18n.CreatedMembersOnly))
+	}
+	if poll.Restricted() {
+		sb.WriteString(loc.T(i18n.CreatedVoters, renderUsernames(voters)))
+	}
+	if poll.Quorum > 0 {
+		sb.WriteString(loc.T(i18n.CreatedQuorum, poll.Quorum))
+	}
+	if poll.MaxVotes > 0 {
+		sb.WriteString(loc.T(i18n.CreatedMaxVotes, poll.MaxVotes))
+	}
+	if poll.Ranked {
+		sb.WriteString(loc.T(i18n.CreatedRanked))
+	}
+	if poll.NotifyVoters {
+		sb.WriteString(loc.T(i18n.CreatedNotify))
+	}
+	if poll.VoteReceipts {
+		sb.WriteString(loc.T(i18n.CreatedReceipts))
+	}
+	if poll.NoSelfVote {
+		sb.WriteString(loc.T(i18n.CreatedNoSelfVote))
+	}
+	if poll.Anonymous() {
+		sb.WriteString(loc.T(i18n.CreatedAnonymous))
+	}
+	if !poll.ExpiresAt.IsZero() {
+		sb.WriteString(loc.T(i18n.CreatedExpires, renderTime(poll.ExpiresAt)))
+	}
+}
+
+// renderResults подводит итоги опроса и показывает их.
+func renderResults(loc *i18n.Localizer, poll models.Poll, ballots [][]string) string {
+	return renderResultsOf(loc, BuildResults(poll, ballots))
+}
+
+// RenderResults показывает итоги текстом, как ответ results; обработчик
+// перестраивает им ответ для страницы вариантов из results --page.
+func RenderResults(loc *i18n.Localizer, results Results) string {
+	return renderResultsOf(loc, results)
+}
+
+// renderResultsOf - итоги опроса: варианты страницы с числом голосов, в
+// рейтинговом опросе - раунды подсчёта.
+func renderResultsOf(loc *i18n.Localizer, results Results) string {
+	if results.Ranked {
+		return renderRanked(loc, results) + renderRecent(loc, results) + renderActive(loc, results)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(resultsHeader(loc, results))
+	weighted := make(map[string]int, len(results.Weighted))
+	for _, votes := range results.Weighted {
+		weighted[votes.Option] = votes.Votes
+	}
+	for _, votes := range results.PageOptions() {
+		if results.Weighted != nil {
+			sb.WriteString(loc.T(i18n.ResultsLineWeighted, results.Label(votes.Option), votes.Votes, weighted[votes.Option]))
+			continue
+		}
+		sb.WriteString(loc.T(i18n.ResultsLine, results.Label(votes.Option), votes.Votes))
+	}
+	sb.WriteString(RenderResultsPage(loc, results))
+	sb.WriteString(renderAbstained(loc, results))
+	sb.WriteString(renderWaiting(loc, results))
+	sb.WriteString(renderRecent(loc, results))
+	sb.WriteString(renderActive(loc, results))
+	return sb.String()
+}
+
+// resultsHeader - заголовок итогов: вопрос, пояснение и метки опроса.
+func resultsHeader(loc *i18n.Localizer, results Results) string {
+	header := loc.T(i18n.ResultsHeader, results.PollID, results.Question, renderDescription(results.Description))
+	if len(results.Tags) > 0 {
+		header += loc.T(i18n.ResultsTags, renderTags(results.Tags))
+	}
+	return header
+}
+
+// renderRanked показывает раунды подсчёта рейтингового опроса и победителя.
+func renderRanked(loc *i18n.Localizer, results Results) string {
+	var sb strings.Builder
+	sb.WriteString(resultsHeader(loc, results))
+	for i, round := range results.Rounds {
+		counts := make([]string, 0, len(round.Votes))
+		for _, votes := range round.Votes {
+			counts = append(counts, fmt.Sprintf("%s - %d", results.Label(votes.Option), votes.Votes))
+		}
+		sb.WriteString(loc.T(i18n.RankedRound, i+1, strings.Join(counts, ", ")))
+		if round.Eliminated != "" {
+			sb.WriteString(loc.T(i18n.RankedEliminated, round.Eliminated))
+		}
+	}
+	sb.WriteString(renderAbstained(loc, results))
+	sb.WriteString(renderWaiting(loc, results))
+	if results.RankedWinner == "" {
+		sb.WriteString(loc.T(i18n.RankedNoWinner))
+	} else {
+		sb.WriteString(loc.T(i18n.RankedWinner, results.RankedWinner))
+	}
+	return sb.String()
+}
+
+// RenderResultsPage - строка под вариантами, если они не умещаются на одну
+// страницу: какие варианты показаны и как открыть следующую страницу.
+func RenderResultsPage(loc *i18n.Localizer, results Results) string {
+	pages := results.Pages()
+	if pages == 1 {
+		return ""
+	}
+	from, to := results.pageBounds()
+	if page := max(results.Page, 1); page < pages {
+		return loc.T(i18n.ResultsPage, from+1, to, len(results.Options), page+1)
+	}
+	return loc.T(i18n.ResultsPageLast, from+1, to, len(results.Options))
+}
+
+// renderAbstained - строка итогов с числом воздержавшихся, если в опросе
+// можно воздержаться.
+func renderAbstained(loc *i18n.Localizer, results Results) string {
+	if !results.AllowAbstain {
+		return ""
+	}
+	return loc.T(i18n.ResultsAbstained, results.Abstained)
+}
+
+// renderWaiting - сколько голосов ещё ждёт открытый опрос со списком
+// участников; воздержавшиеся тоже проголосовали.
+func renderWaiting(loc *i18n.Localizer, results Results) string {
+	voted := results.VoterCount + results.Abstained
+	if results.Expected == 0 || results.Closed || voted >= results.Expected {
+		return ""
+	}
+	return loc.T(i18n.ResultsWaiting, voted, results.Expected, results.Expected-voted)
+}
+
+// markdownEscaper экранирует разметку Mattermost: пояснение показывается
+// как написано и не превращается в заголовки, таблицы и блоки кода
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `~`, `\~`,
+	`[`, `\[`, `]`, `\]`, `#`, `\#`, `>`, `\>`, `|`, `\|`,
+)
+
+// renderDescription показывает пояснение цитатой под вопросом, строку
+// пояснения - строкой цитаты. Пустое пояснение не выводится.
+func renderDescription(description string) string {
+	if description == "" {
+		return ""
+	}
+	var sb strings.Builder
+	for _, line := range strings.Split(description, "\n") {
+		sb.WriteString(strings.TrimRight("> "+markdownEscaper.Replace(line), " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// renderTags показывает метки кодом, чтобы подчёркивание в метке
+// не превращалось в курсив.
+func renderTags(tags []string) string {
+	quoted := make([]string, len(tags))
+	for i, tag := range tags {
+		quoted[i] = "`" + tag + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// renderWeights перечисляет веса в ответе на create: "@alice=2, @bob=3".
+func renderWeights(weights map[string]int) string {
+	parts := make([]string, 0, len(weights))
+	for _, username := range sortedUsernames(weights) {
+		parts = append(parts, fmt.Sprintf("@%s=%d", username, weights[username]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderList - ответ команды list: первые maxListedPolls опросов и,
+// если показаны не все, сколько их всего. scope - примечание, чем ограничен
+// список; у списка всех опросов его нет.
+func renderList(loc *i18n.Localizer, tag string, scope i18n.Key, polls []models.Poll, total int) string {
+	if len(polls) == 0 {
+		empty := loc.T(i18n.ListEmpty)
+		if tag != "" {
+			empty = loc.T(i18n.ListEmptyTag, renderTags([]string{tag}))
+		}
+		if scope != "" {
+			empty += "\n" + strings.TrimSuffix(loc.T(scope), "\n")
+		}
+		return empty
+	}
+
+	var sb strings.Builder
+	if tag != "" {
+		sb.WriteString(loc.T(i18n.ListHeaderTag, renderTags([]string{tag})))
+	} else {
+		sb.WriteString(loc.T(i18n.ListHeader))
+	}
+	renderPollList(&sb, loc, polls, maxListedPolls)
+	if len(polls) > maxListedPolls {
+		sb.WriteString(loc.T(i18n.ListMore, maxListedPolls, total))
+	}
+	if scope != "" {
+		sb.WriteString(loc.T(scope))
+	}
+	return sb.String()
+}
+
+// renderSearch - ответ команды search: первые maxSearchResults совпадений.
+func renderSearch(loc *i18n.Localizer, query string, polls []models.Poll) string {
+	shown := markdownEscaper.Replace(strings.Join(strings.Fields(query), " "))
+	if len(polls) == 0 {
+		return loc.T(i18n.SearchNoMatches, shown)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.SearchHeader, shown))
+	renderPollList(&sb, loc, polls, maxSearchResults)
+	if len(polls) > maxSearchResults {
+		sb.WriteString(loc.T(i18n.SearchMore, maxSearchResults))
+	}
+	return sb.String()
+}
+
+// renderPollList выводит не больше limit опросов строками с ID, вопросом,
+// числом голосов и метками; отмеченные опросы - со звездой.
+func renderPollList(sb *strings.Builder, loc *i18n.Localizer, polls []models.Poll, limit int) {
+	for i, poll := range polls {
+		if i == limit {
+			return
+		}
+		votes := 0
+		for _, count := range poll.Options {
+			votes += count
+		}
+		var tags string
+		if len(poll.Tags) > 0 {
+			tags = " " + renderTags(poll.Tags)
+		}
+		// Вопрос может занимать несколько строк, в списке он идёт одной
+		question := strings.Join(strings.Fields(poll.Question), " ")
+		switch {
+		case poll.Featured && poll.Closed:
+			question = loc.T(i18n.ListFeaturedClosed, question)
+		case poll.Featured:
+			question = loc.T(i18n.ListFeatured, question)
+		}
+		sb.WriteString(loc.T(i18n.ListLine, poll.ID, question, votes, tags))
+	}
+}
+
+// renderMyVote - ответ команды myvote; voted сообщает, голосовал ли
+// пользователь.
+func renderMyVote(loc *i18n.Localizer, pollID string, vote models.Vote, voted bool) string {
+	choices := vote.Choices
+	switch {
+	case !voted:
+		return loc.T(i18n.MyVoteNone, pollID)
+	case vote.Abstain:
+		return loc.T(i18n.MyVoteAbstained, pollID)
+	case len(choices) == 0:
+		return loc.T(i18n.MyVoteUnknown, pollID)
+	case len(choices) == 1:
+		return loc.T(i18n.MyVoteChoice, pollID, choices[0])
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.MyVoteRankedHeader, pollID))
+	for i, choice := range choices {
+		sb.WriteString(loc.T(i18n.MyVoteRankedLine, i+1, choice))
+	}
+	return sb.String()
+}
+
+// renderSettings - текущие значения изменяемых настроек опроса.
+func renderSettings(loc *i18n.Localizer, poll models.Poll) string {
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.SettingsHeader, poll.ID))
+	for _, setting := range Settings {
+		sb.WriteString(loc.T(i18n.SettingsLine, setting, settingValue(loc, poll, setting)))
+	}
+	for _, setting := range FixedSettings {
+		sb.WriteString(loc.T(i18n.SettingsFixedLine, setting, settingValue(loc, poll, setting)))
+	}
+	return sb.String()
+}
+
+// settingValue - значение настройки опроса для ответа.
+func settingValue(loc *i18n.Localizer, poll models.Poll, setting string) string {
+	switch setting {
+	case SettingChannelOnly:
+		if poll.RestrictToChannel {
+			return loc.T(i18n.SettingOn)
+		}
+		return loc.T(i18n.SettingOff)
+	case SettingNoSelfVote:
+		if poll.NoSelfVote {
+			return loc.T(i18n.SettingOn)
+		}
+		return loc.T(i18n.SettingOff)
+	case SettingAnonymous:
+		if poll.Anonymous() {
+			return loc.T(i18n.SettingOn)
+		}
+		return loc.T(i18n.SettingOff)
+	case SettingMembersOnly:
+		if poll.MembersOnly {
+			return loc.T(i18n.SettingOn)
+		}
+		return loc.T(i18n.SettingOff)
+	case SettingQuorum:
+		return settingNumber(loc, poll.Quorum)
+	case SettingMaxVotes:
+		return settingNumber(loc, poll.MaxVotes)
+	case SettingVoters:
+		return settingNumber(loc, len(poll.AllowedVoters))
+	case SettingExpires:
+		if poll.ExpiresAt.IsZero() {
+			return loc.T(i18n.SettingNone)
+		}
+		return renderTime(poll.ExpiresAt)
+	default:
+		return ""
+	}
+}
+
+// settingNumber - кворум или максимум голосов; 0 - без ограничения.
+func settingNumber(loc *i18n.Localizer, n int) string {
+	if n == 0 {
+		return loc.T(i18n.SettingNone)
+	}
+	return strconv.Itoa(n)
+}
+
+// renderVersion - ответ команды version; сборки без коммита и даты
+// показываются одной версией.
+func renderVersion(loc *i18n.Localizer, build BuildInfo, storage, uptime string) string {
+	info := build.Info()
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.VersionHeader, info.Version))
+	if info.Commit != buildinfo.Dev || info.Date != buildinfo.Dev {
+		sb.WriteString(loc.T(i18n.VersionBuild, info.Commit, info.Date))
+	}
+	sb.WriteString(loc.T(i18n.VersionUptime, uptime))
+	if storage != "" {
+		sb.WriteString(loc.T(i18n.VersionStorage, storage))
+	}
+	if len(build.Features) > 0 {
+		sb.WriteString(loc.T(i18n.VersionFeatures))
+		for _, feature := range build.Features {
+			state := loc.T(i18n.SettingOff)
+			if feature.Enabled {
+				state = loc.T(i18n.SettingOn)
+			}
+			sb.WriteString(loc.T(i18n.SettingsLine, feature.Name, state))
+		}
+	}
+	return sb.String()
+}
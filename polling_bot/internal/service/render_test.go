@@ -0,0 +1,302 @@
+package service
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталоны в testdata")
+
+const renderPollID = "123e4567-e89b-12d3-a456-426614174000"
+
+// goldenText сравнивает текст с эталоном testdata/render/<name>.<lang>.golden;
+// go test -update перезаписывает эталон.
+func goldenText(t *testing.T, name string, lang i18n.Lang, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "render", fmt.Sprintf("%s.%s.golden", name, lang))
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "нет эталона, запустите go test -update")
+	assert.Equal(t, string(want), got, "текст не совпадает с эталоном %s", path)
+}
+
+// forEachLang выполняет проверку на каждом языке бота: эталоны показывают
+// перевод каждого ответа рядом.
+func forEachLang(t *testing.T, name string, render func(loc *i18n.Localizer) string) {
+	t.Helper()
+	previous := renderLocation
+	renderLocation = time.UTC
+	t.Cleanup(func() { renderLocation = previous })
+	for _, lang := range []i18n.Lang{i18n.Russian, i18n.English} {
+		t.Run(name+"/"+string(lang), func(t *testing.T) {
+			loc := i18n.New(string(lang))
+			got := render(loc)
+			// Каждый вызов на тех же данных даёт тот же текст
+			for i := 0; i < 10; i++ {
+				require.Equal(t, got, render(loc))
+			}
+			goldenText(t, name, lang, got)
+		})
+	}
+}
+
+func renderPoll(mutate func(*models.Poll)) models.Poll {
+	poll := models.Poll{
+		ID:          renderPollID,
+		Creator:     "creator1",
+		Question:    "Где обедаем?",
+		Options:     map[string]int{"Столовая": 0, "Кафе": 0, "Пицца": 0},
+		OptionOrder: []string{"Столовая", "Кафе", "Пицца"},
+		ChannelID:   "c1",
+	}
+	if mutate != nil {
+		mutate(&poll)
+	}
+	return poll
+}
+
+// Тест проверяет ответ на создание опроса по эталонам
+func TestRenderCreated(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*models.Poll)
+		weights map[string]int
+	}{
+		{name: "created_basic"},
+		{
+			name: "created_flags",
+			mutate: func(p *models.Poll) {
+				p.Description = "Решаем до *полудня*\nБюджет - 500"
+				p.Tags = []string{"team_a", "lunch"}
+				p.Weights = map[string]int{"u2": 3, "u1": 2}
+				p.AllowAbstain = true
+				p.RestrictToChannel = true
+				p.Quorum = 5
+				p.MaxVotes = 10
+				p.NotifyVoters = true
+				p.VoteReceipts = true
+				p.NoSelfVote = true
+				p.ExpiresAt = time.Date(2025, 3, 6, 12, 30, 0, 0, time.UTC)
+			},
+			weights: map[string]int{"bob": 3, "alice": 2},
+		},
+		{name: "created_ranked", mutate: func(p *models.Poll) { p.Ranked = true }},
+		{name: "created_anonymous", mutate: func(p *models.Poll) { p.VoterSalt = "salt" }},
+		{name: "created_members_only", mutate: func(p *models.Poll) { p.MembersOnly = true }},
+		{name: "created_emoji", mutate: func(p *models.Poll) { p.OptionEmoji = map[string]string{"Пицца": "🍕", "Кафе": ":coffee:"} }},
+	}
+	for _, tt := range tests {
+		poll := renderPoll(tt.mutate)
+		forEachLang(t, tt.name, func(loc *i18n.Localizer) string {
+			return renderCreated(loc, poll, tt.weights, nil)
+		})
+	}
+}
+
+// Тест проверяет итоги опроса по эталонам: варианты по алфавиту при любом
+// порядке создания, ничья, голоса с весами, воздержавшиеся и раунды
+// рейтингового опроса
+func TestRenderResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*models.Poll)
+		ballots [][]string
+	}{
+		{
+			name: "results_open",
+			mutate: func(p *models.Poll) {
+				p.Options = map[string]int{"Столовая": 1, "Кафе": 4, "Пицца": 2}
+			},
+		},
+		{
+			name: "results_closed",
+			mutate: func(p *models.Poll) {
+				p.Closed = true
+				p.Description = "Решаем до полудня"
+				p.Tags = []string{"lunch"}
+				p.Options = map[string]int{"Столовая": 3, "Кафе": 0, "Пицца": 1}
+			},
+		},
+		{
+			name: "results_tie",
+			mutate: func(p *models.Poll) {
+				p.Options = map[string]int{"Столовая": 2, "Кафе": 2, "Пицца": 2}
+			},
+		},
+		{name: "results_empty"},
+		{
+			name: "results_weighted",
+			mutate: func(p *models.Poll) {
+				p.Options = map[string]int{"Столовая": 1, "Кафе": 2, "Пицца": 0}
+				p.Weights = map[string]int{"u1": 3}
+				p.WeightedOptions = map[string]int{"Столовая": 3, "Кафе": 2, "Пицца": 0}
+				p.AllowAbstain = true
+				p.Abstained = 1
+			},
+		},
+		{
+			name: "results_ranked",
+			mutate: func(p *models.Poll) {
+				p.Ranked = true
+				p.Options = map[string]int{"Столовая": 2, "Кафе": 2, "Пицца": 1}
+			},
+			ballots: [][]string{
+				{"Столовая", "Кафе"}, {"Столовая"}, {"Кафе", "Столовая"}, {"Кафе", "Пицца"}, {"Пицца", "Кафе"},
+			},
+		},
+		{name: "results_ranked_no_votes", mutate: func(p *models.Poll) { p.Ranked = true }},
+		{
+			name: "results_emoji",
+			mutate: func(p *models.Poll) {
+				p.Options = map[string]int{"Столовая": 1, "Кафе": 4, "Пицца": 2}
+				p.OptionEmoji = map[string]string{"Пицца": "🍕", "Кафе": ":coffee:"}
+			},
+		},
+	}
+	for _, tt := range tests {
+		poll := renderPoll(tt.mutate)
+		forEachLang(t, tt.name, func(loc *i18n.Localizer) string {
+			return renderResults(loc, poll, tt.ballots)
+		})
+	}
+}
+
+// Тест проверяет итоги опроса, варианты которого не умещаются на одну
+// страницу: первая и последняя страницы текстом и таблицей
+func TestRenderResultsPages(t *testing.T) {
+	poll := renderPoll(func(p *models.Poll) {
+		p.Options = make(map[string]int)
+		for i := 1; i <= 30; i++ {
+			p.Options[fmt.Sprintf("Вариант %02d", i)] = i % 4
+		}
+	})
+	results := BuildResults(poll, nil)
+	require.Equal(t, 2, results.Pages())
+
+	forEachLang(t, "results_pages", func(loc *i18n.Localizer) string {
+		last := results
+		last.Page = 2
+		return renderResultsOf(loc, results) + "\n" + renderResultsOf(loc, last) + "\n" + RenderResultsTable(loc, last, 0)
+	})
+
+	results.Ranked = true
+	assert.Equal(t, 1, results.Pages(), "раунды рейтингового опроса на страницы не делятся")
+	results.Ranked, results.Page = false, 3
+	assert.Empty(t, results.PageOptions())
+}
+
+// Тест проверяет ответы list и search по эталонам
+func TestRenderList(t *testing.T) {
+	polls := make([]models.Poll, 0, maxListedPolls+1)
+	for i := 0; i <= maxListedPolls; i++ {
+		polls = append(polls, renderPoll(func(p *models.Poll) {
+			p.ID = fmt.Sprintf("%08d-e89b-12d3-a456-426614174000", i)
+			p.Question = fmt.Sprintf("Вопрос\n%d", i)
+			p.Options = map[string]int{"Да": i, "Нет": 1}
+			if i%5 == 0 {
+				p.Tags = []string{"release"}
+			}
+		}))
+	}
+
+	forEachLang(t, "list", func(loc *i18n.Localizer) string { return renderList(loc, "", i18n.ListScopeChannel, polls[:3], 3) })
+	forEachLang(t, "list_more", func(loc *i18n.Localizer) string { return renderList(loc, "", i18n.ListScopeChannel, polls, 35) })
+	forEachLang(t, "list_tag", func(loc *i18n.Localizer) string {
+		return renderList(loc, "release", i18n.ListScopeChannel, polls[:1], 1)
+	})
+	forEachLang(t, "list_mine", func(loc *i18n.Localizer) string { return renderList(loc, "", i18n.ListScopeMine, polls[:1], 1) })
+	forEachLang(t, "list_all", func(loc *i18n.Localizer) string { return renderList(loc, "", "", polls[:3], 3) })
+	forEachLang(t, "list_empty", func(loc *i18n.Localizer) string { return renderList(loc, "", i18n.ListScopeChannel, nil, 0) })
+	forEachLang(t, "list_empty_tag", func(loc *i18n.Localizer) string { return renderList(loc, "release", i18n.ListScopeChannel, nil, 0) })
+	forEachLang(t, "search", func(loc *i18n.Localizer) string { return renderSearch(loc, "  вопрос *1* ", polls[:2]) })
+	forEachLang(t, "search_more", func(loc *i18n.Localizer) string {
+		return renderSearch(loc, "вопрос", polls[:maxSearchResults+1])
+	})
+	forEachLang(t, "search_empty", func(loc *i18n.Localizer) string { return renderSearch(loc, "ужин", nil) })
+}
+
+// Тест проверяет ответы myvote и set по эталонам
+func TestRenderMyVoteAndSettings(t *testing.T) {
+	forEachLang(t, "myvote_none", func(loc *i18n.Localizer) string {
+		return renderMyVote(loc, renderPollID, models.Vote{}, false)
+	})
+	forEachLang(t, "myvote_choice", func(loc *i18n.Localizer) string {
+		return renderMyVote(loc, renderPollID, models.Vote{Choices: []string{"Кафе"}}, true)
+	})
+	forEachLang(t, "myvote_ranked", func(loc *i18n.Localizer) string {
+		return renderMyVote(loc, renderPollID, models.Vote{Choices: []string{"Кафе", "Пицца", "Столовая"}}, true)
+	})
+	forEachLang(t, "myvote_abstained", func(loc *i18n.Localizer) string {
+		return renderMyVote(loc, renderPollID, models.Vote{Abstain: true}, true)
+	})
+
+	poll := renderPoll(func(p *models.Poll) {
+		p.Quorum = 5
+		p.ExpiresAt = time.Date(2025, 3, 6, 12, 30, 0, 0, time.UTC)
+	})
+	forEachLang(t, "settings", func(loc *i18n.Localizer) string { return renderSettings(loc, poll) })
+}
+
+// Тест проверяет тексты ошибок, которые видит пользователь, по эталону:
+// причина ошибки хранилища в ответ не попадает
+func TestRenderErrors(t *testing.T) {
+	s := NewPollService(repository.NewMemoryPollRepo(), nil, zerolog.Nop())
+	errs := []struct {
+		name string
+		err  error
+	}{
+		{"not found", s.storageError(repository.ErrNotFound, i18n.OpGetPoll)},
+		{"unavailable", s.storageError(repository.ErrUnavailable, i18n.OpGetPoll)},
+		{"storage", s.storageError(errors.New("tuple too long"), i18n.OpSavePoll)},
+		{"unexpected", errors.New("panic")},
+		{"question too long", validatePoll(strings.Repeat("в", maxQuestionLength+1), []string{"Да"}, CreateOptions{})},
+		{"no options", validatePoll("Где обедаем?", nil, CreateOptions{})},
+		{"only creator", i18n.NewError(i18n.OnlyCreatorCanEnd)},
+		{"setting unknown", i18n.NewError(i18n.SettingUnknown, "ranked", strings.Join(Settings, ", "))},
+	}
+	forEachLang(t, "errors", func(loc *i18n.Localizer) string {
+		var sb strings.Builder
+		for _, e := range errs {
+			require.Error(t, e.err, e.name)
+			fmt.Fprintf(&sb, "%s: %s\n", e.name, loc.Error(e.err))
+		}
+		return sb.String()
+	})
+}
+
+// Тест проверяет ответ version по эталонам: сборка без -ldflags показывается
+// как dev без строки коммита
+func TestRenderVersion(t *testing.T) {
+	release := BuildInfo{
+		Version: "v1.4.0",
+		Commit:  "abc1234",
+		Date:    "2025-03-01T10:00:00Z",
+		Features: []Feature{
+			{Name: "schedules", Enabled: true},
+			{Name: "rich-results"},
+		},
+	}
+	forEachLang(t, "version_release", func(loc *i18n.Localizer) string {
+		return renderVersion(loc, release, "tarantool", "4h12m")
+	})
+	forEachLang(t, "version_dev", func(loc *i18n.Localizer) string {
+		return renderVersion(loc, BuildInfo{}, "", "0m")
+	})
+}
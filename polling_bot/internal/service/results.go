@@ -0,0 +1,145 @@
+package service
+
+import (
+	"sort"
+
+	"polling_bot/internal/models"
+)
+
+// Results - итоги опроса без привязки к языку ответа: из них строится
+// и ответ команды results, и JSON HTTP API.
+type Results struct {
+	PollID      string
+	Creator     string
+	Question    string
+	Description string
+	Tags        []string
+	Closed      bool
+	Ranked      bool
+	// Число проголосовавших; кто именно голосовал, в итоги не входит
+	VoterCount int
+	// Варианты в порядке создания; в рейтинговом опросе - с числом первых предпочтений
+	Options []OptionVotes
+	// Эмодзи вариантов по тексту; варианта без эмодзи здесь нет
+	Emoji map[string]string
+	// В опросе можно воздержаться; Abstained - число воздержавшихся,
+	// они не входят в VoterCount
+	AllowAbstain bool
+	Abstained    int
+	// Сколько голосов ждёт опрос со списком участников, см.
+	// models.Poll.ExpectedVoters; 0 - опрос без списка
+	Expected int
+	// Суммы весов голосов за варианты в том же порядке, только в опросе с весами
+	Weighted []OptionVotes
+	// Раунды мгновенного второго тура, только в рейтинговом опросе
+	Rounds []RankedRound
+	// Победивший вариант рейтингового опроса; пусто, если бюллетеней нет
+	RankedWinner string
+	// Изменения за период, только в ответе results --since
+	Recent *RecentVotes
+	// Итоги без деактивированных участников, только в ответе results --active-only
+	Active *ActiveVotes
+	// Страница вариантов для показа, с 1; 0 - первая
+	Page int
+}
+
+// Сколько вариантов показывается на странице итогов
+const resultsPageSize = 25
+
+// Pages - число страниц вариантов. Раунды рейтингового опроса на страницы
+// не делятся, он всегда на одной странице.
+func (r Results) Pages() int {
+	if r.Ranked || len(r.Options) <= resultsPageSize {
+		return 1
+	}
+	return (len(r.Options) + resultsPageSize - 1) / resultsPageSize
+}
+
+// PageOptions - варианты страницы Page по алфавиту, как их показывает
+// бот. Страница за последней пуста.
+func (r Results) PageOptions() []OptionVotes {
+	options := append([]OptionVotes(nil), r.Options...)
+	sort.Slice(options, func(i, j int) bool { return options[i].Option < options[j].Option })
+	from, to := r.pageBounds()
+	return options[from:to]
+}
+
+// pageBounds - границы страницы Page в вариантах по алфавиту.
+func (r Results) pageBounds() (int, int) {
+	if r.Pages() == 1 {
+		return 0, len(r.Options)
+	}
+	page := r.Page
+	if page < 1 {
+		page = 1
+	}
+	from := min((page-1)*resultsPageSize, len(r.Options))
+	return from, min(from+resultsPageSize, len(r.Options))
+}
+
+// Label - вариант для показа: эмодзи, если оно задано, и текст.
+func (r Results) Label(option string) string {
+	return optionLabel(r.Emoji[option], option)
+}
+
+// OptionVotes - вариант и число отданных за него голосов.
+type OptionVotes struct {
+	Option string
+	Votes  int
+}
+
+// RankedRound - раунд подсчёта рейтингового опроса: голоса оставшихся
+// вариантов в порядке создания и выбывший вариант.
+type RankedRound struct {
+	Votes      []OptionVotes
+	Eliminated string
+}
+
+// BuildResults подводит итоги опроса по его счётчикам; рейтинговый опрос
+// пересчитывается по бюллетеням участников при каждом вызове. Итогам
+// обычного опроса бюллетени не нужны, ballots может быть nil.
+func BuildResults(poll models.Poll, ballots [][]string) Results {
+	order := optionOrder(poll)
+	results := Results{
+		PollID:       poll.ID,
+		Creator:      poll.Creator,
+		Question:     poll.Question,
+		Description:  poll.Description,
+		Tags:         poll.Tags,
+		Closed:       poll.Closed,
+		Ranked:       poll.Ranked,
+		AllowAbstain: poll.AllowAbstain,
+		Abstained:    poll.Abstained,
+		Expected:     poll.ExpectedVoters(),
+		Options:      make([]OptionVotes, 0, len(order)),
+		Emoji:        poll.OptionEmoji,
+	}
+	// Каждый участник учтён ровно в одном счётчике: в рейтинговом опросе
+	// счётчик варианта - число первых предпочтений
+	for _, option := range order {
+		results.Options = append(results.Options, OptionVotes{Option: option, Votes: poll.Options[option]})
+		results.VoterCount += poll.Options[option]
+	}
+	if poll.Weighted() {
+		results.Weighted = make([]OptionVotes, 0, len(order))
+		for _, option := range order {
+			results.Weighted = append(results.Weighted, OptionVotes{Option: option, Votes: poll.WeightedOptions[option]})
+		}
+	}
+	if !poll.Ranked {
+		return results
+	}
+
+	irv := instantRunoff(order, ballots)
+	for _, round := range irv.Rounds {
+		votes := make([]OptionVotes, 0, len(round.Counts))
+		for _, option := range order {
+			if count, ok := round.Counts[option]; ok {
+				votes = append(votes, OptionVotes{Option: option, Votes: count})
+			}
+		}
+		results.Rounds = append(results.Rounds, RankedRound{Votes: votes, Eliminated: round.Eliminated})
+	}
+	results.RankedWinner = irv.Winner
+	return results
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// UserStatuses сообщает, какие из пользователей Mattermost деактивированы.
+type UserStatuses interface {
+	DeactivatedUsers(ctx context.Context, userIDs []string) (map[string]bool, error)
+}
+
+// SetUserStatuses включает итоги без деактивированных участников: без него
+// results --active-only показывает все голоса с предупреждением.
+func (s *PollServiceImpl) SetUserStatuses(statuses UserStatuses) {
+	s.statuses = statuses
+}
+
+// ActiveVotes - итоги без голосов деактивированных участников, только
+// в ответе results --active-only.
+type ActiveVotes struct {
+	// Участников не удалось проверить: Results пуст, показаны все голоса
+	Unverified bool
+	// Сколько голосовавших или воздержавшихся деактивированы
+	Deactivated int
+	// Итоги без их голосов
+	Results Results
+}
+
+// ResultsActiveOnly возвращает итоги опроса, как ResultsReport, и вместе с
+// ними итоги без голосов участников, деактивированных в Mattermost.
+// Проверка участников доступна только создателю опроса; если Mattermost
+// не ответил, итоги показываются без исключений с предупреждением.
+func (s *PollServiceImpl) ResultsActiveOnly(ctx context.Context, userID, pollID string) (string, Results, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", Results{}, err
+	}
+	poll, err := s.repo.GetPollSummary(ctx, pollID)
+	if err != nil {
+		return "", Results{}, s.storageError(err, i18n.OpGetPoll)
+	}
+	if poll.Creator != userID {
+		return "", Results{}, i18n.NewError(i18n.OnlyCreatorActive)
+	}
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return "", Results{}, err
+	}
+	if poll.Anonymous() {
+		return "", Results{}, i18n.NewError(i18n.ActiveAnonymous)
+	}
+	s.countView(ctx, poll, userID)
+
+	votes, err := s.votes.ListVotes(ctx, poll.ID)
+	if err != nil {
+		return "", Results{}, s.storageError(err, i18n.OpListVotes)
+	}
+	var ballots [][]string
+	if poll.Ranked {
+		ballots = voteBallots(votes)
+	}
+	results := BuildResults(poll, ballots)
+	results.Active = s.activeVotes(ctx, poll, votes)
+	return renderResultsOf(i18n.FromContext(ctx), results), results, nil
+}
+
+// activeVotes проверяет голосовавших в Mattermost и подводит итоги без
+// деактивированных.
+func (s *PollServiceImpl) activeVotes(ctx context.Context, poll models.Poll, votes []models.Vote) *ActiveVotes {
+	if s.statuses == nil {
+		return &ActiveVotes{Unverified: true}
+	}
+	userIDs := make([]string, 0, len(votes))
+	for _, vote := range votes {
+		userIDs = append(userIDs, vote.UserID)
+	}
+	deactivated, err := s.statuses.DeactivatedUsers(ctx, userIDs)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось проверить участников опроса в Mattermost")
+		return &ActiveVotes{Unverified: true}
+	}
+	active := withoutVoters(poll, votes, deactivated)
+	return &active
+}
+
+// withoutVoters вычитает из счётчиков опроса голоса участников excluded.
+// Голос без бюллетеня не известно, за что отдан, поэтому он остаётся
+// в итогах.
+func withoutVoters(poll models.Poll, votes []models.Vote, excluded map[string]bool) ActiveVotes {
+	var active ActiveVotes
+	poll.Options = copyCounts(poll.Options)
+	poll.WeightedOptions = copyCounts(poll.WeightedOptions)
+	kept := make([]models.Vote, 0, len(votes))
+	for _, vote := range votes {
+		if !excluded[vote.UserID] {
+			kept = append(kept, vote)
+			continue
+		}
+		switch {
+		case vote.Abstain:
+			poll.Abstained--
+		case len(vote.Choices) > 0:
+			poll.Options[vote.Choices[0]]--
+			if vote.Weight > 0 {
+				poll.WeightedOptions[vote.Choices[0]] -= vote.Weight
+			}
+		default:
+			kept = append(kept, vote)
+			continue
+		}
+		active.Deactivated++
+	}
+	var ballots [][]string
+	if poll.Ranked {
+		ballots = voteBallots(kept)
+	}
+	active.Results = BuildResults(poll, ballots)
+	return active
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	if counts == nil {
+		return nil
+	}
+	out := make(map[string]int, len(counts))
+	for option, n := range counts {
+		out[option] = n
+	}
+	return out
+}
+
+// renderActive - итоги без деактивированных участников под обычными:
+// голоса вариантов по алфавиту, в рейтинговом опросе - первые
+// предпочтения и победитель.
+func renderActive(loc *i18n.Localizer, results Results) string {
+	active := results.Active
+	switch {
+	case active == nil:
+		return ""
+	case active.Unverified:
+		return loc.T(i18n.ActiveUnverified)
+	case active.Deactivated == 0:
+		return loc.T(i18n.ActiveNone)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.ActiveHeader, active.Deactivated))
+	filtered := active.Results
+	options := append([]OptionVotes(nil), filtered.Options...)
+	sort.Slice(options, func(i, j int) bool { return options[i].Option < options[j].Option })
+	weighted := make(map[string]int, len(filtered.Weighted))
+	for _, votes := range filtered.Weighted {
+		weighted[votes.Option] = votes.Votes
+	}
+	for _, votes := range options {
+		if filtered.Weighted != nil {
+			sb.WriteString(loc.T(i18n.ResultsLineWeighted, results.Label(votes.Option), votes.Votes, weighted[votes.Option]))
+			continue
+		}
+		sb.WriteString(loc.T(i18n.ResultsLine, results.Label(votes.Option), votes.Votes))
+	}
+	sb.WriteString(renderAbstained(loc, filtered))
+	if filtered.Ranked {
+		if filtered.RankedWinner == "" {
+			sb.WriteString(loc.T(i18n.RankedNoWinner))
+		} else {
+			sb.WriteString(loc.T(i18n.RankedWinner, filtered.RankedWinner))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// fakeStatuses - проверка участников, которая считает деактивированными
+// пользователей deactivated и запоминает, кого спросили.
+type fakeStatuses struct {
+	deactivated map[string]bool
+	err         error
+	asked       []string
+}
+
+func (f *fakeStatuses) DeactivatedUsers(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	f.asked = append(f.asked, userIDs...)
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := make(map[string]bool)
+	for _, userID := range userIDs {
+		if f.deactivated[userID] {
+			out[userID] = true
+		}
+	}
+	return out, nil
+}
+
+// Тест проверяет вычитание голосов деактивированных участников: голос,
+// воздержание и вес вычитаются, голос без бюллетеня остаётся, а
+// рейтинговый опрос пересчитывается по оставшимся бюллетеням
+func TestWithoutVoters(t *testing.T) {
+	excluded := map[string]bool{"u1": true, "u3": true, "u5": true}
+	tests := []struct {
+		name            string
+		mutate          func(*models.Poll)
+		votes           []models.Vote
+		wantDeactivated int
+		wantOptions     []OptionVotes
+		wantWeighted    []OptionVotes
+		wantAbstained   int
+		wantWinner      string
+	}{
+		{
+			name: "plain",
+			mutate: func(p *models.Poll) {
+				p.Options = map[string]int{"Столовая": 2, "Кафе": 2, "Пицца": 0}
+				p.AllowAbstain = true
+				p.Abstained = 1
+			},
+			votes: []models.Vote{
+				{UserID: "u1", Choices: []string{"Кафе"}},
+				{UserID: "u2", Choices: []string{"Кафе"}},
+				{UserID: "u3", Abstain: true},
+				{UserID: "u4", Choices: []string{"Столовая"}},
+				{UserID: "u5"},
+			},
+			wantDeactivated: 2,
+			wantOptions:     []OptionVotes{{"Столовая", 2}, {"Кафе", 1}, {"Пицца", 0}},
+		},
+		{
+			name: "weighted",
+			mutate: func(p *models.Poll) {
+				p.Options = map[string]int{"Столовая": 1, "Кафе": 1, "Пицца": 0}
+				p.Weights = map[string]int{"u1": 3}
+				p.WeightedOptions = map[string]int{"Столовая": 3, "Кафе": 1, "Пицца": 0}
+			},
+			votes: []models.Vote{
+				{UserID: "u1", Choices: []string{"Столовая"}, Weight: 3},
+				{UserID: "u2", Choices: []string{"Кафе"}, Weight: 1},
+			},
+			wantDeactivated: 1,
+			wantOptions:     []OptionVotes{{"Столовая", 0}, {"Кафе", 1}, {"Пицца", 0}},
+			wantWeighted:    []OptionVotes{{"Столовая", 0}, {"Кафе", 1}, {"Пицца", 0}},
+		},
+		{
+			name: "ranked",
+			mutate: func(p *models.Poll) {
+				p.Ranked = true
+				p.Options = map[string]int{"Столовая": 2, "Кафе": 1, "Пицца": 1}
+			},
+			votes: []models.Vote{
+				{UserID: "u1", Choices: []string{"Столовая"}},
+				{UserID: "u2", Choices: []string{"Столовая", "Кафе"}},
+				{UserID: "u3", Choices: []string{"Пицца", "Столовая"}},
+				{UserID: "u4", Choices: []string{"Кафе"}},
+				{UserID: "u6", Choices: []string{"Кафе"}},
+			},
+			wantDeactivated: 2,
+			wantOptions:     []OptionVotes{{"Столовая", 1}, {"Кафе", 1}, {"Пицца", 0}},
+			wantWinner:      "Кафе",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := renderPoll(tt.mutate)
+			before := BuildResults(poll, voteBallots(tt.votes))
+			active := withoutVoters(poll, tt.votes, excluded)
+			assert.Equal(t, tt.wantDeactivated, active.Deactivated)
+			assert.Equal(t, tt.wantOptions, active.Results.Options)
+			assert.Equal(t, tt.wantWeighted, active.Results.Weighted)
+			assert.Equal(t, tt.wantAbstained, active.Results.Abstained)
+			assert.Equal(t, tt.wantWinner, active.Results.RankedWinner)
+			assert.Equal(t, before, BuildResults(poll, voteBallots(tt.votes)), "счётчики опроса не меняются")
+		})
+	}
+}
+
+// Тест проверяет итоги без деактивированных участников по эталонам:
+// исключённые голоса, опрос без деактивированных, сбой проверки и
+// рейтинговый опрос с победителем без исключённых
+func TestRenderResultsActive(t *testing.T) {
+	plain := renderPoll(func(p *models.Poll) {
+		p.Options = map[string]int{"Столовая": 2, "Кафе": 3, "Пицца": 1}
+		p.AllowAbstain = true
+		p.Abstained = 1
+	})
+	ranked := renderPoll(func(p *models.Poll) {
+		p.Ranked = true
+		p.Options = map[string]int{"Столовая": 2, "Кафе": 1, "Пицца": 1}
+	})
+	rankedVotes := []models.Vote{
+		{UserID: "u1", Choices: []string{"Столовая"}},
+		{UserID: "u2", Choices: []string{"Столовая", "Кафе"}},
+		{UserID: "u3", Choices: []string{"Пицца", "Кафе"}},
+		{UserID: "u4", Choices: []string{"Кафе"}},
+	}
+	tests := []struct {
+		name    string
+		poll    models.Poll
+		ballots [][]string
+		active  ActiveVotes
+	}{
+		{
+			name: "results_active",
+			poll: plain,
+			active: withoutVoters(plain, []models.Vote{
+				{UserID: "u1", Choices: []string{"Кафе"}},
+				{UserID: "u2", Choices: []string{"Кафе"}},
+				{UserID: "u3", Abstain: true},
+			}, map[string]bool{"u1": true, "u2": true, "u3": true}),
+		},
+		{name: "results_active_none", poll: plain, active: withoutVoters(plain, nil, nil)},
+		{name: "results_active_unverified", poll: plain, active: ActiveVotes{Unverified: true}},
+		{
+			name:    "results_active_ranked",
+			poll:    ranked,
+			ballots: voteBallots(rankedVotes),
+			active:  withoutVoters(ranked, rankedVotes, map[string]bool{"u1": true}),
+		},
+	}
+
+	for _, tt := range tests {
+		forEachLang(t, tt.name, func(loc *i18n.Localizer) string {
+			results := BuildResults(tt.poll, tt.ballots)
+			results.Active = &tt.active
+			return renderResultsOf(loc, results)
+		})
+	}
+}
+
+// Тест проверяет results --active-only: проверка голосовавших в
+// Mattermost, итоги без деактивированных, только создателю, отказ в
+// анонимном опросе и итоги без исключений, если Mattermost не ответил
+func TestResultsActiveOnly(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, CreateOptions{})
+	require.NoError(t, err)
+	for user, option := range map[string]string{"alice": "Пицца", "bob": "Суши", "carol": "Суши"} {
+		_, err := s.AddVote(ctx, user, created.ID, []string{option})
+		require.NoError(t, err)
+	}
+
+	text, results, err := s.ResultsActiveOnly(ctx, "creator1", created.ID)
+	require.NoError(t, err, "без проверки участников итоги показываются с предупреждением")
+	assert.True(t, results.Active.Unverified)
+	assert.Contains(t, text, "Не удалось проверить участников в Mattermost")
+
+	statuses := &fakeStatuses{deactivated: map[string]bool{"bob": true, "dave": true}}
+	s.SetUserStatuses(statuses)
+	text, results, err = s.ResultsActiveOnly(ctx, "creator1", created.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"alice", "bob", "carol"}, statuses.asked)
+	assert.Equal(t, 1, results.Active.Deactivated)
+	assert.Equal(t, []OptionVotes{{"Пицца", 1}, {"Суши", 1}}, results.Active.Results.Options)
+	assert.Contains(t, text, "- Пицца: 1 голосов\n- Суши: 2 голосов\n")
+	assert.Contains(t, text, "\n**Без деактивированных участников**: не учтено голосов - 1\n- Пицца: 1 голосов\n- Суши: 1 голосов\n")
+
+	statuses.err = errors.New("mattermost недоступен")
+	text, results, err = s.ResultsActiveOnly(ctx, "creator1", created.ID)
+	require.NoError(t, err)
+	assert.True(t, results.Active.Unverified)
+	assert.Contains(t, text, "- Суши: 2 голосов\n\n⚠️ Не удалось проверить участников в Mattermost, показаны все голоса\n")
+
+	_, _, err = s.ResultsActiveOnly(ctx, "alice", created.ID)
+	assert.EqualError(t, err, "только создатель может исключить голоса деактивированных участников")
+
+	s.SetAnonymousSecret("bot-secret")
+	anonymous, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"}, CreateOptions{Anonymous: true})
+	require.NoError(t, err)
+	_, _, err = s.ResultsActiveOnly(ctx, "creator1", anonymous.ID)
+	var localized *i18n.Error
+	require.ErrorAs(t, err, &localized)
+	assert.Equal(t, i18n.ActiveAnonymous, localized.Key)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// RecentVotes - изменения итогов за период команды results --since:
+// голоса, поданные начиная с Since.
+type RecentVotes struct {
+	Since time.Time
+	// Период длиннее возраста опроса: Since - время создания опроса,
+	// и за период поданы все голоса
+	WholePoll bool
+	// Новые голоса за варианты в порядке создания; в рейтинговом опросе -
+	// первые предпочтения
+	Options   []OptionVotes
+	Abstained int
+	// Сколько участников проголосовали или воздержались за период
+	NewVoters int
+}
+
+// ResultsSince возвращает итоги опроса, как ResultsReport, и вместе с ними
+// изменения за последний window по времени голосов. Голос, заменивший
+// воздержание, считается по времени замены.
+func (s *PollServiceImpl) ResultsSince(ctx context.Context, userID, pollID string, window time.Duration) (string, Results, error) {
+	if window <= 0 {
+		return "", Results{}, i18n.NewError(i18n.SinceInvalid)
+	}
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", Results{}, err
+	}
+	poll, err := s.repo.GetPollSummary(ctx, pollID)
+	if err != nil {
+		return "", Results{}, s.storageError(err, i18n.OpGetPoll)
+	}
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return "", Results{}, err
+	}
+	s.countView(ctx, poll, userID)
+
+	// Время голосов есть только в самих голосах, поэтому они читаются и
+	// для обычного опроса
+	votes, err := s.votes.ListVotes(ctx, poll.ID)
+	if err != nil {
+		return "", Results{}, s.storageError(err, i18n.OpListVotes)
+	}
+	var ballots [][]string
+	if poll.Ranked {
+		ballots = voteBallots(votes)
+	}
+	results := BuildResults(poll, ballots)
+	recent := recentVotes(poll, votes, s.now().Add(-window))
+	results.Recent = &recent
+	return renderResultsOf(i18n.FromContext(ctx), results), results, nil
+}
+
+// recentVotes считает голоса, поданные не раньше since. Период, который
+// начинается до создания опроса, сужается до времени создания.
+func recentVotes(poll models.Poll, votes []models.Vote, since time.Time) RecentVotes {
+	recent := RecentVotes{Since: since.UTC()}
+	if !poll.CreatedAt.IsZero() && !since.After(poll.CreatedAt) {
+		recent.Since, recent.WholePoll = poll.CreatedAt.UTC(), true
+	}
+
+	counts := make(map[string]int)
+	for _, vote := range votes {
+		// У голосов, перенесённых из прежнего формата, времени нет
+		if vote.VotedAt.IsZero() || vote.VotedAt.Before(recent.Since) {
+			continue
+		}
+		recent.NewVoters++
+		if len(vote.Choices) == 0 {
+			recent.Abstained++
+			continue
+		}
+		counts[vote.Choices[0]]++
+	}
+	order := optionOrder(poll)
+	recent.Options = make([]OptionVotes, 0, len(order))
+	for _, option := range order {
+		recent.Options = append(recent.Options, OptionVotes{Option: option, Votes: counts[option]})
+	}
+	return recent
+}
+
+// renderRecent - изменения итогов за период: новые участники и прибавка
+// голосов у вариантов, которые их получили, по алфавиту, как и итоги.
+func renderRecent(loc *i18n.Localizer, results Results) string {
+	recent := results.Recent
+	if recent == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	if recent.WholePoll {
+		sb.WriteString(loc.T(i18n.RecentHeaderWhole, renderTime(recent.Since)))
+	} else {
+		sb.WriteString(loc.T(i18n.RecentHeader, renderTime(recent.Since)))
+	}
+	sb.WriteString(loc.T(i18n.RecentVoters, recent.NewVoters))
+	options := append([]OptionVotes(nil), recent.Options...)
+	sort.Slice(options, func(i, j int) bool { return options[i].Option < options[j].Option })
+	for _, votes := range options {
+		if votes.Votes > 0 {
+			sb.WriteString(loc.T(i18n.RecentLine, results.Label(votes.Option), votes.Votes))
+		}
+	}
+	if recent.Abstained > 0 {
+		sb.WriteString(loc.T(i18n.RecentAbstained, recent.Abstained))
+	}
+	return sb.String()
+}
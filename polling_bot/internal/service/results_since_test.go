@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+var sinceCreated = time.Date(2025, 3, 6, 9, 0, 0, 0, time.UTC)
+
+// Тест проверяет подсчёт изменений за период по времени голосов: голос на
+// границе периода входит в него, воздержание считается отдельно, голоса без
+// времени не входят, а период длиннее возраста опроса сужается до создания
+func TestRecentVotes(t *testing.T) {
+	poll := renderPoll(func(p *models.Poll) { p.CreatedAt = sinceCreated })
+	votes := []models.Vote{
+		{UserID: "u1", Choices: []string{"Кафе"}, VotedAt: sinceCreated.Add(10 * time.Minute)},
+		{UserID: "u2", Choices: []string{"Пицца"}, VotedAt: sinceCreated.Add(2 * time.Hour)},
+		{UserID: "u3", Choices: []string{"Кафе", "Пицца"}, VotedAt: sinceCreated.Add(150 * time.Minute)},
+		{UserID: "u4", VotedAt: sinceCreated.Add(170 * time.Minute)},
+		{UserID: "u5", Choices: []string{"Столовая"}},
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		want  RecentVotes
+	}{
+		{
+			name:  "last hour",
+			since: sinceCreated.Add(2 * time.Hour),
+			want: RecentVotes{
+				Since:     sinceCreated.Add(2 * time.Hour),
+				Options:   []OptionVotes{{"Столовая", 0}, {"Кафе", 1}, {"Пицца", 1}},
+				Abstained: 1,
+				NewVoters: 3,
+			},
+		},
+		{
+			name:  "nothing new",
+			since: sinceCreated.Add(3 * time.Hour),
+			want: RecentVotes{
+				Since:   sinceCreated.Add(3 * time.Hour),
+				Options: []OptionVotes{{"Столовая", 0}, {"Кафе", 0}, {"Пицца", 0}},
+			},
+		},
+		{
+			name:  "longer than poll age",
+			since: sinceCreated.Add(-24 * time.Hour),
+			want: RecentVotes{
+				Since:     sinceCreated,
+				WholePoll: true,
+				Options:   []OptionVotes{{"Столовая", 0}, {"Кафе", 2}, {"Пицца", 1}},
+				Abstained: 1,
+				NewVoters: 4,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, recentVotes(poll, votes, tt.since))
+		})
+	}
+}
+
+// Тест проверяет итоги с изменениями за период по эталонам
+func TestRenderResultsSince(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranked bool
+		recent RecentVotes
+	}{
+		{
+			name: "results_since",
+			recent: RecentVotes{
+				Since:     sinceCreated.Add(2 * time.Hour),
+				Options:   []OptionVotes{{"Столовая", 0}, {"Кафе", 2}, {"Пицца", 1}},
+				Abstained: 1,
+				NewVoters: 4,
+			},
+		},
+		{
+			name: "results_since_whole",
+			recent: RecentVotes{
+				Since:     sinceCreated,
+				WholePoll: true,
+				Options:   []OptionVotes{{"Столовая", 1}, {"Кафе", 4}, {"Пицца", 2}},
+				NewVoters: 7,
+			},
+		},
+		{
+			name:   "results_since_ranked_none",
+			ranked: true,
+			recent: RecentVotes{
+				Since:   sinceCreated.Add(2 * time.Hour),
+				Options: []OptionVotes{{"Столовая", 0}, {"Кафе", 0}, {"Пицца", 0}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		poll := renderPoll(func(p *models.Poll) {
+			p.Options = map[string]int{"Столовая": 1, "Кафе": 4, "Пицца": 2}
+			p.AllowAbstain = true
+			p.Abstained = 1
+			p.Ranked = tt.ranked
+		})
+		var ballots [][]string
+		if tt.ranked {
+			ballots = [][]string{{"Столовая"}, {"Кафе"}, {"Кафе"}, {"Кафе"}, {"Кафе"}, {"Пицца"}, {"Пицца"}}
+		}
+		forEachLang(t, tt.name, func(loc *i18n.Localizer) string {
+			results := BuildResults(poll, ballots)
+			results.Recent = &tt.recent
+			return renderResultsOf(loc, results)
+		})
+	}
+}
+
+// Тест проверяет results --since на опросе с голосами в разное время:
+// изменения считаются от часов сервиса, а неверный период отклоняется
+func TestResultsSince(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	now := sinceCreated
+	s.now = func() time.Time { return now }
+	created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, CreateOptions{})
+	require.NoError(t, err)
+
+	for i, vote := range []struct {
+		user   string
+		option string
+		after  time.Duration
+	}{
+		{user: "u1", option: "Пицца", after: 10 * time.Minute},
+		{user: "u2", option: "Суши", after: 2 * time.Hour},
+		{user: "u3", option: "Суши", after: 150 * time.Minute},
+	} {
+		now = sinceCreated.Add(vote.after)
+		_, err := s.AddVote(WithOrigin(ctx, Origin{PostID: "vote" + vote.user}), vote.user, created.ID, []string{vote.option})
+		require.NoError(t, err, "голос %d", i)
+	}
+	now = sinceCreated.Add(3 * time.Hour)
+
+	text, results, err := s.ResultsSince(ctx, "u1", created.ID, time.Hour+30*time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, results.Recent)
+	assert.Equal(t, sinceCreated.Add(90*time.Minute), results.Recent.Since)
+	assert.Equal(t, 2, results.Recent.NewVoters)
+	assert.Contains(t, text, "- Пицца: 1 голосов\n- Суши: 2 голосов\n")
+	assert.Contains(t, text, "\n**Изменения с 06.03.2025 10:30 UTC**\nНовых участников: 2\n- Суши: +2\n")
+	assert.NotContains(t, text, "Пицца: +")
+
+	_, results, err = s.ResultsSince(ctx, "u1", created.ID, 7*24*time.Hour)
+	require.NoError(t, err)
+	assert.True(t, results.Recent.WholePoll)
+	assert.Equal(t, 3, results.Recent.NewVoters)
+
+	_, _, err = s.ResultsSince(ctx, "u1", created.ID, 0)
+	assert.EqualError(t, err, "период --since задаётся как 90m, 12h или 3d и не может быть меньше минуты")
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"polling_bot/internal/i18n"
+)
+
+const (
+	// Сколько символов варианта по умолчанию помещается в таблицу итогов
+	defaultTableOptionWidth = 40
+	// Из скольких делений состоит шкала варианта
+	tableBarCells = 10
+)
+
+// ResultsTableOptions - итоги команды results таблицей Markdown.
+type ResultsTableOptions struct {
+	// Показывать итоги таблицей и без флага --table
+	Default bool
+	// Сколько символов варианта помещается в таблицу, длинный вариант
+	// обрезается с «…»; 0 - 40 символов
+	OptionWidth int
+}
+
+// tableCellEscaper экранирует разделитель столбцов: вариант с "|" иначе
+// разбил бы строку таблицы
+var tableCellEscaper = strings.NewReplacer(`|`, `\|`)
+
+// RenderResultsTable показывает итоги таблицей Markdown: вариант, число
+// голосов, доля от проголосовавших и шкала, под вариантами - строка итога.
+// Числа выравниваются по правому краю, варианты длиннее optionWidth
+// символов обрезаются. Раунды рейтингового опроса в таблицу не
+// укладываются, поэтому он показывается как без таблицы.
+func RenderResultsTable(loc *i18n.Localizer, results Results, optionWidth int) string {
+	if results.Ranked {
+		return renderResultsOf(loc, results)
+	}
+	if optionWidth <= 0 {
+		optionWidth = defaultTableOptionWidth
+	}
+
+	var sb strings.Builder
+	// Таблица отделяется от текста пустыми строками: иначе Markdown
+	// считает соседнюю строку её частью
+	sb.WriteString(resultsHeader(loc, results) + "\n")
+	header := []string{loc.T(i18n.ResultsTableOption), loc.T(i18n.ResultsTableVotes)}
+	align := []string{":--", "--:"}
+	if results.Weighted != nil {
+		header = append(header, loc.T(i18n.ResultsTableWeighted))
+		align = append(align, "--:")
+	}
+	header = append(header, "%", loc.T(i18n.ResultsTableBar))
+	align = append(align, "--:", ":--")
+	writeTableRow(&sb, header)
+	writeTableRow(&sb, align)
+
+	weighted := make(map[string]int, len(results.Weighted))
+	var weightedTotal int
+	for _, votes := range results.Weighted {
+		weighted[votes.Option] = votes.Votes
+		weightedTotal += votes.Votes
+	}
+	// Варианты страницы по алфавиту, как в текстовых итогах
+	for _, votes := range results.PageOptions() {
+		share := percent(votes.Votes, results.VoterCount)
+		row := []string{tableCell(results.Label(votes.Option), optionWidth), fmt.Sprint(votes.Votes)}
+		if results.Weighted != nil {
+			row = append(row, fmt.Sprint(weighted[votes.Option]))
+		}
+		writeTableRow(&sb, append(row, fmt.Sprintf("%d%%", share), tableBar(share)))
+	}
+
+	total := []string{"**" + loc.T(i18n.ResultsTableTotal) + "**", fmt.Sprintf("**%d**", results.VoterCount)}
+	if results.Weighted != nil {
+		total = append(total, fmt.Sprintf("**%d**", weightedTotal))
+	}
+	share := 0
+	if results.VoterCount > 0 {
+		share = 100
+	}
+	writeTableRow(&sb, append(total, fmt.Sprintf("**%d%%**", share), ""))
+
+	if footer := RenderResultsPage(loc, results) + renderAbstained(loc, results) + renderRecent(loc, results) + renderActive(loc, results); footer != "" {
+		sb.WriteString("\n" + strings.TrimPrefix(footer, "\n"))
+	}
+	return sb.String()
+}
+
+func writeTableRow(sb *strings.Builder, cells []string) {
+	sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+// tableCell обрезает текст до width символов и экранирует его для ячейки.
+// Обрезается сам текст, чтобы не разрезать экранирование.
+func tableCell(text string, width int) string {
+	if runes := []rune(text); len(runes) > width {
+		text = strings.TrimRight(string(runes[:width-1]), " ") + "…"
+	}
+	return tableCellEscaper.Replace(text)
+}
+
+// tableBar - шкала доли share в процентах из tableBarCells делений.
+func tableBar(share int) string {
+	filled := (share*tableBarCells + 50) / 100
+	return strings.Repeat("█", filled) + strings.Repeat("░", tableBarCells-filled)
+}
+
+// percent - доля part от total в целых процентах; при total 0 - 0.
+func percent(part, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return int(math.Round(float64(part) * 100 / float64(total)))
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// Тест проверяет итоги таблицей по эталонам: много вариантов, опрос без
+// голосов, веса с воздержавшимися, длинные варианты и варианты с "|",
+// изменения за период и рейтинговый опрос, который остаётся по раундам
+func TestRenderResultsTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*models.Poll)
+		ballots [][]string
+		width   int
+		recent  *RecentVotes
+	}{
+		{
+			name: "table_many_options",
+			mutate: func(p *models.Poll) {
+				p.Options = make(map[string]int)
+				p.OptionOrder = nil
+				for i, option := range strings.Fields("Январь Февраль Март Апрель Май Июнь Июль Август Сентябрь Октябрь Ноябрь Декабрь") {
+					p.Options[option] = i % 4
+					p.OptionOrder = append(p.OptionOrder, option)
+				}
+				p.OptionEmoji = map[string]string{"Май": "🌷"}
+			},
+		},
+		{name: "table_empty"},
+		{
+			name: "table_weighted",
+			mutate: func(p *models.Poll) {
+				p.Description = "Решаем до полудня"
+				p.Options = map[string]int{"Столовая": 1, "Кафе": 2, "Пицца": 0}
+				p.Weights = map[string]int{"u1": 3}
+				p.WeightedOptions = map[string]int{"Столовая": 3, "Кафе": 2, "Пицца": 0}
+				p.AllowAbstain = true
+				p.Abstained = 1
+			},
+		},
+		{
+			name:  "table_long_options",
+			width: 20,
+			mutate: func(p *models.Poll) {
+				p.Options = map[string]int{
+					"Столовая на первом этаже рядом с переговорной": 2,
+					"Кафе | бар":       1,
+					"Пицца|суши|роллы": 0,
+				}
+				p.OptionOrder = []string{"Столовая на первом этаже рядом с переговорной", "Кафе | бар", "Пицца|суши|роллы"}
+			},
+		},
+		{
+			name: "table_since",
+			mutate: func(p *models.Poll) {
+				p.Options = map[string]int{"Столовая": 1, "Кафе": 4, "Пицца": 2}
+			},
+			recent: &RecentVotes{
+				Since:     time.Date(2025, 3, 6, 11, 30, 0, 0, time.UTC),
+				NewVoters: 2,
+				Options:   []OptionVotes{{Option: "Кафе", Votes: 2}},
+			},
+		},
+		{
+			name: "table_ranked",
+			mutate: func(p *models.Poll) {
+				p.Ranked = true
+				p.Options = map[string]int{"Столовая": 2, "Кафе": 2, "Пицца": 1}
+			},
+			ballots: [][]string{
+				{"Столовая", "Кафе"}, {"Столовая"}, {"Кафе", "Столовая"}, {"Кафе", "Пицца"}, {"Пицца", "Кафе"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		results := BuildResults(renderPoll(tt.mutate), tt.ballots)
+		results.Recent = tt.recent
+		forEachLang(t, tt.name, func(loc *i18n.Localizer) string {
+			return RenderResultsTable(loc, results, tt.width)
+		})
+	}
+}
+
+// Тест проверяет обрезку варианта по символам, а не байтам, и шкалу доли
+func TestTableCellAndBar(t *testing.T) {
+	assert.Equal(t, "Столова…", tableCell("Столовая на первом этаже", 8))
+	assert.Equal(t, "Столовая", tableCell("Столовая", 8))
+	assert.Equal(t, "Кафе…", tableCell("Кафе  и бар", 6), "пробел перед «…» убирается")
+	assert.Equal(t, `a\|b`, tableCell("a|b", 8))
+
+	assert.Equal(t, "░░░░░░░░░░", tableBar(0))
+	assert.Equal(t, "█████░░░░░", tableBar(45))
+	assert.Equal(t, "██████████", tableBar(100))
+}
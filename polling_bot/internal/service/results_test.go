@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"polling_bot/internal/models"
+)
+
+// Тест проверяет структурированные итоги обычного и рейтингового опроса
+func TestBuildResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		poll    models.Poll
+		ballots [][]string
+		want    Results
+	}{
+		{
+			name: "plain",
+			poll: models.Poll{
+				ID: "p1", Question: "Где обедаем?", Closed: true,
+				Options:     map[string]int{"Суши": 1, "Пицца": 2, "Паста": 0},
+				OptionOrder: []string{"Пицца", "Суши"},
+			},
+			want: Results{
+				PollID: "p1", Question: "Где обедаем?", Closed: true, VoterCount: 3,
+				// Варианты без сохранённого порядка идут после остальных по алфавиту
+				Options: []OptionVotes{{"Пицца", 2}, {"Суши", 1}, {"Паста", 0}},
+			},
+		},
+		{
+			name: "weighted",
+			poll: models.Poll{
+				ID: "p3", Question: "Релизим?",
+				Options:         map[string]int{"Да": 2, "Нет": 1},
+				OptionOrder:     []string{"Да", "Нет"},
+				Weights:         map[string]int{"u1": 3},
+				WeightedOptions: map[string]int{"Да": 2, "Нет": 3},
+			},
+			want: Results{
+				PollID: "p3", Question: "Релизим?", VoterCount: 3,
+				Options:  []OptionVotes{{"Да", 2}, {"Нет", 1}},
+				Weighted: []OptionVotes{{"Да", 2}, {"Нет", 3}},
+			},
+		},
+		{
+			name: "ranked",
+			poll: models.Poll{
+				ID: "p2", Question: "Где обедаем?", Ranked: true,
+				Options:     map[string]int{"Пицца": 2, "Суши": 2, "Паста": 1},
+				OptionOrder: []string{"Пицца", "Суши", "Паста"},
+			},
+			ballots: [][]string{
+				{"Пицца"}, {"Пицца"},
+				{"Суши"}, {"Суши"},
+				{"Паста", "Суши"},
+			},
+			want: Results{
+				PollID: "p2", Question: "Где обедаем?", Ranked: true, VoterCount: 5,
+				Options: []OptionVotes{{"Пицца", 2}, {"Суши", 2}, {"Паста", 1}},
+				Rounds: []RankedRound{
+					{Votes: []OptionVotes{{"Пицца", 2}, {"Суши", 2}, {"Паста", 1}}, Eliminated: "Паста"},
+					{Votes: []OptionVotes{{"Пицца", 2}, {"Суши", 3}}},
+				},
+				RankedWinner: "Суши",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, BuildResults(tt.poll, tt.ballots))
+		})
+	}
+}
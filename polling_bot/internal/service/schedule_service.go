@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+var ErrScheduleNotFound = i18n.NewError(i18n.ScheduleNotFound)
+
+// Формат времени следующего запуска в ответе на создание расписания
+const scheduleTimeLayout = "2006-01-02 15:04 MST"
+
+type ScheduleService interface {
+	CreateSchedule(ctx context.Context, userID, cron, question string, options []string, opts CreateOptions) (string, error)
+	// ListSchedules перечисляет расписания канала, из которого пришла команда.
+	ListSchedules(ctx context.Context, userID string) (string, error)
+	DeleteSchedule(ctx context.Context, userID, scheduleID string) (string, error)
+}
+
+// ScheduledPoll - опрос, созданный по расписанию, и сообщение для его канала.
+type ScheduledPoll struct {
+	ScheduleID string
+	ChannelID  string
+	Message    string
+	// Создатель расписания и вопрос - для сообщения, если канал недоступен
+	Creator  string
+	Question string
+	// ID созданного опроса; пусто, если сервис опросов его не сообщает
+	PollID string
+}
+
+// ContentChecker реализуют сервисы опросов, которые проверяют текст
+// вопроса и вариантов по правилам содержимого.
+type ContentChecker interface {
+	CheckContent(question string, options []string) error
+}
+
+// pollCreator реализуют сервисы опросов, которые возвращают ID нового опроса.
+type pollCreator interface {
+	CreatePollWithID(ctx context.Context, userID, question string, options []string, opts CreateOptions) (CreatedPoll, error)
+}
+
+type ScheduleServiceImpl struct {
+	repo   repository.ScheduleRepository
+	polls  PollService
+	logger zerolog.Logger
+	now    func() time.Time
+}
+
+// NewScheduleService создаёт сервис расписаний; опросы создаются через polls,
+// поэтому на них действуют те же проверки, что и на команду create.
+func NewScheduleService(repo repository.ScheduleRepository, polls PollService, logger zerolog.Logger) *ScheduleServiceImpl {
+	return &ScheduleServiceImpl{repo: repo, polls: polls, logger: logger, now: time.Now}
+}
+
+func (s *ScheduleServiceImpl) CreateSchedule(ctx context.Context, userID, cron, question string, options []string, opts CreateOptions) (string, error) {
+	spec, err := parseCron(cron)
+	if err != nil {
+		return "", err
+	}
+	now := s.now()
+	next, ok := spec.next(now)
+	if !ok {
+		return "", i18n.NewError(i18n.CronNeverFires, cron)
+	}
+	// Веса привязаны к участникам одного опроса и в расписании не хранятся
+	if len(opts.Weights) > 0 {
+		return "", i18n.NewError(i18n.WeightsCreateOnly)
+	}
+	// Список участников, как и веса, относится к одному опросу
+	if len(opts.Voters) > 0 {
+		return "", i18n.NewError(i18n.VotersCreateOnly)
+	}
+	if opts.AllowAbstain {
+		return "", i18n.NewError(i18n.AbstainCreateOnly)
+	}
+	if opts.MaxVotes > 0 {
+		return "", i18n.NewError(i18n.MaxVotesCreateOnly)
+	}
+	if opts.VoteReceipts {
+		return "", i18n.NewError(i18n.ReceiptsCreateOnly)
+	}
+	if opts.NoSelfVote {
+		return "", i18n.NewError(i18n.NoSelfVoteCreateOnly)
+	}
+	if opts.Anonymous {
+		return "", i18n.NewError(i18n.AnonymousCreateOnly)
+	}
+	if opts.MembersOnly {
+		return "", i18n.NewError(i18n.MembersCreateOnly)
+	}
+	if err := validatePoll(question, options, opts); err != nil {
+		return "", err
+	}
+	if checker, ok := s.polls.(ContentChecker); ok {
+		if err := checker.CheckContent(question, options); err != nil {
+			return "", err
+		}
+	}
+	channelID := OriginFrom(ctx).ChannelID
+	if channelID == "" {
+		return "", i18n.NewError(i18n.ScheduleNoChannel)
+	}
+
+	schedule := models.Schedule{
+		ID:                uuid.New().String(),
+		Creator:           userID,
+		ChannelID:         channelID,
+		Cron:              strings.Join(strings.Fields(cron), " "),
+		Question:          question,
+		Options:           append([]string(nil), options...),
+		RestrictToChannel: opts.RestrictToChannel,
+		Quorum:            opts.Quorum,
+		Ranked:            opts.Ranked,
+		Exclusive:         opts.Exclusive,
+		CreatedAt:         now.UTC(),
+	}
+	if err := s.repo.SaveSchedule(ctx, schedule); err != nil {
+		return "", s.storageError(err, i18n.OpSaveSchedule)
+	}
+
+	return i18n.FromContext(ctx).T(i18n.ScheduleCreated,
+		schedule.ID, schedule.Cron, schedule.Question, next.Format(scheduleTimeLayout)), nil
+}
+
+func (s *ScheduleServiceImpl) ListSchedules(ctx context.Context, userID string) (string, error) {
+	schedules, err := s.repo.ListSchedules(ctx, OriginFrom(ctx).ChannelID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpListSchedules)
+	}
+
+	loc := i18n.FromContext(ctx)
+	if len(schedules) == 0 {
+		return loc.T(i18n.ScheduleListEmpty), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.ScheduleListHeader))
+	for _, schedule := range schedules {
+		sb.WriteString(loc.T(i18n.ScheduleListLine, schedule.ID, schedule.Cron, schedule.Question))
+	}
+	return sb.String(), nil
+}
+
+func (s *ScheduleServiceImpl) DeleteSchedule(ctx context.Context, userID, scheduleID string) (string, error) {
+	schedule, err := s.repo.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpGetSchedule)
+	}
+	if schedule.Creator != userID {
+		return "", i18n.NewError(i18n.OnlyCreatorSchedule)
+	}
+
+	if err := s.repo.DeleteSchedule(ctx, scheduleID); err != nil {
+		return "", s.storageError(err, i18n.OpDeleteSchedule)
+	}
+	return i18n.FromContext(ctx).T(i18n.ScheduleDeleted, scheduleID), nil
+}
+
+// RunDue создаёт опросы расписаний, которые срабатывают в минуту at, и
+// возвращает их для публикации. Пропущенные минуты не досоздаются: смотрится
+// только at. Минута запуска запоминается в расписании, а ID опроса выводится
+// из расписания и минуты, так что повторный вызов за ту же минуту второй опрос
+// не создаёт. Ошибки отдельных расписаний только логируются.
+func (s *ScheduleServiceImpl) RunDue(ctx context.Context, at time.Time) []ScheduledPoll {
+	minute := at.Truncate(time.Minute)
+	schedules, err := s.repo.ListSchedules(ctx, "")
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Не удалось получить расписания")
+		return nil
+	}
+
+	var due []ScheduledPoll
+	for _, schedule := range schedules {
+		log := s.logger.With().Str("schedule_id", schedule.ID).Logger()
+		spec, err := parseCron(schedule.Cron)
+		if err != nil {
+			log.Warn().Err(err).Str("cron", schedule.Cron).Msg("Неверное cron-выражение в сохранённом расписании")
+			continue
+		}
+		if !spec.matches(minute) || !schedule.LastRunAt.Before(minute) {
+			continue
+		}
+		if !schedule.UnreachableAt.IsZero() {
+			log.Debug().Msg("Канал расписания недоступен боту, опрос не создаётся")
+			continue
+		}
+
+		schedule.LastRunAt = minute.UTC()
+		if err := s.repo.SaveSchedule(ctx, schedule); err != nil {
+			// Без отметки о запуске следующий вызов создал бы опрос снова
+			log.Error().Err(err).Msg("Не удалось отметить запуск расписания")
+			continue
+		}
+
+		pollCtx := WithOrigin(ctx, Origin{
+			PostID:    fmt.Sprintf("schedule/%s/%d", schedule.ID, minute.Unix()),
+			ChannelID: schedule.ChannelID,
+		})
+		created, err := s.createPoll(pollCtx, schedule)
+		if err != nil {
+			log.Warn().Err(err).Msg("Не удалось создать опрос по расписанию")
+			continue
+		}
+		due = append(due, ScheduledPoll{
+			ScheduleID: schedule.ID,
+			ChannelID:  schedule.ChannelID,
+			Message:    i18n.FromContext(ctx).T(i18n.ScheduledPoll, schedule.ID, created.Message),
+			Creator:    schedule.Creator,
+			Question:   schedule.Question,
+			PollID:     created.ID,
+		})
+	}
+	return due
+}
+
+// createPoll создаёт опрос расписания. ID опроса известен, только если
+// сервис опросов умеет его вернуть.
+func (s *ScheduleServiceImpl) createPoll(ctx context.Context, schedule models.Schedule) (CreatedPoll, error) {
+	opts := CreateOptions{
+		RestrictToChannel: schedule.RestrictToChannel,
+		Quorum:            schedule.Quorum,
+		Ranked:            schedule.Ranked,
+		Exclusive:         schedule.Exclusive,
+	}
+	if creator, ok := s.polls.(pollCreator); ok {
+		return creator.CreatePollWithID(ctx, schedule.Creator, schedule.Question, schedule.Options, opts)
+	}
+	message, err := s.polls.CreatePoll(ctx, schedule.Creator, schedule.Question, schedule.Options, opts)
+	return CreatedPoll{Message: message}, err
+}
+
+// MarkUnreachable останавливает расписание, в канал которого бот не может
+// писать: RunDue его больше не запускает. Возвращает true, только если
+// расписание остановлено этим вызовом, чтобы создателю написали один раз.
+func (s *ScheduleServiceImpl) MarkUnreachable(ctx context.Context, scheduleID string) (bool, error) {
+	schedule, err := s.repo.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return false, s.storageError(err, i18n.OpGetSchedule)
+	}
+	if !schedule.UnreachableAt.IsZero() {
+		return false, nil
+	}
+	schedule.UnreachableAt = s.now().UTC()
+	if err := s.repo.SaveSchedule(ctx, schedule); err != nil {
+		return false, s.storageError(err, i18n.OpSaveSchedule)
+	}
+	return true, nil
+}
+
+// storageError - аналог PollServiceImpl.storageError для расписаний.
+func (s *ScheduleServiceImpl) storageError(err error, op i18n.Key) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return ErrScheduleNotFound
+	case errors.Is(err, repository.ErrUnavailable):
+		s.logger.Error().Err(err).Str("operation", string(op)).Msg("Хранилище недоступно")
+		return ErrServiceUnavailable
+	default:
+		return i18n.Wrap(err, op)
+	}
+}
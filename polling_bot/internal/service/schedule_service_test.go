@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+type scheduleFixture struct {
+	schedules *ScheduleServiceImpl
+	repo      *repository.MemoryScheduleRepo
+	polls     *repository.MemoryPollRepo
+	ctx       context.Context
+}
+
+func newScheduleFixture(t *testing.T, now time.Time) scheduleFixture {
+	t.Helper()
+	polls := repository.NewMemoryPollRepo()
+	repo := repository.NewMemoryScheduleRepo()
+	s := NewScheduleService(repo, NewPollService(polls, repository.NewMemoryVoteRepo(polls), zerolog.Nop()), zerolog.Nop())
+	s.now = func() time.Time { return now }
+	return scheduleFixture{
+		schedules: s,
+		repo:      repo,
+		polls:     polls,
+		ctx:       WithOrigin(context.Background(), Origin{ChannelID: "c1"}),
+	}
+}
+
+func (f scheduleFixture) onlySchedule(t *testing.T) models.Schedule {
+	t.Helper()
+	schedules, err := f.repo.ListSchedules(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	return schedules[0]
+}
+
+// Тест проверяет создание расписания и ошибки, о которых сообщается сразу
+func TestCreateSchedule(t *testing.T) {
+	// Понедельник, 09:30
+	now := time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		cron     string
+		question string
+		options  []string
+		wantErr  string
+		want     string
+	}{
+		{
+			name:     "weekly poll",
+			cron:     " 0  10 * * 1 ",
+			question: "Где обедаем?",
+			options:  []string{"Пицца", "Суши"},
+			want:     "0 10 * * 1\nВопрос: Где обедаем?\nСледующий опрос: 2025-03-03 10:00 UTC",
+		},
+		{name: "bad cron", cron: "0 25 * * 1", question: "Q", options: []string{"A"}, wantErr: "неверное поле cron-выражения '25': допустимы значения от 0 до 23"},
+		{name: "never fires", cron: "0 0 31 4 *", question: "Q", options: []string{"A"}, wantErr: "расписание '0 0 31 4 *' никогда не сработает"},
+		{name: "duplicate options", cron: "0 10 * * 1", question: "Q", options: []string{"A", "A"}, wantErr: "все опции в голосовании должны быть уникальными"},
+		{name: "channel mention", cron: "0 10 * * 1", question: "Q", options: []string{"@here"}, wantErr: "вопрос и опции не могут упоминать @here: упоминание срабатывало бы при каждом показе итогов"},
+		{name: "no channel", ctx: context.Background(), cron: "0 10 * * 1", question: "Q", options: []string{"A"}, wantErr: "расписание можно создать только в канале"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newScheduleFixture(t, now)
+			ctx := f.ctx
+			if tt.ctx != nil {
+				ctx = tt.ctx
+			}
+
+			result, err := f.schedules.CreateSchedule(ctx, "user1", tt.cron, tt.question, tt.options, CreateOptions{Quorum: 3})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				schedules, err := f.repo.ListSchedules(context.Background(), "")
+				require.NoError(t, err)
+				assert.Empty(t, schedules)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, result, tt.want)
+
+			stored := f.onlySchedule(t)
+			assert.Contains(t, result, stored.ID)
+			assert.Equal(t, "0 10 * * 1", stored.Cron)
+			assert.Equal(t, "c1", stored.ChannelID)
+			assert.Equal(t, "user1", stored.Creator)
+			assert.Equal(t, 3, stored.Quorum)
+		})
+	}
+}
+
+// Тест проверяет список и удаление расписаний канала
+func TestListAndDeleteSchedules(t *testing.T) {
+	f := newScheduleFixture(t, time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC))
+	_, err := f.schedules.CreateSchedule(f.ctx, "user1", "0 10 * * 1", "Обед?", []string{"A", "B"}, CreateOptions{})
+	require.NoError(t, err)
+	other := WithOrigin(context.Background(), Origin{ChannelID: "c2"})
+	_, err = f.schedules.CreateSchedule(other, "user2", "0 9 * * *", "Стендап?", []string{"Да"}, CreateOptions{})
+	require.NoError(t, err)
+
+	list, err := f.schedules.ListSchedules(f.ctx, "user3")
+	require.NoError(t, err)
+	assert.Contains(t, list, "0 10 * * 1: Обед?")
+	assert.NotContains(t, list, "Стендап?")
+
+	empty, err := f.schedules.ListSchedules(WithOrigin(context.Background(), Origin{ChannelID: "c3"}), "user3")
+	require.NoError(t, err)
+	assert.Equal(t, "В этом канале нет расписаний", empty)
+
+	schedules, err := f.repo.ListSchedules(context.Background(), "c1")
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	id := schedules[0].ID
+
+	_, err = f.schedules.DeleteSchedule(f.ctx, "user2", id)
+	assert.EqualError(t, err, "только создатель может удалить расписание")
+	result, err := f.schedules.DeleteSchedule(f.ctx, "user1", id)
+	require.NoError(t, err)
+	assert.Equal(t, "Расписание "+id+" удалено", result)
+	_, err = f.schedules.DeleteSchedule(f.ctx, "user1", id)
+	assert.ErrorIs(t, err, ErrScheduleNotFound)
+}
+
+// Тест проверяет создание опросов по наступившим расписаниям
+func TestRunDue(t *testing.T) {
+	f := newScheduleFixture(t, time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC))
+	_, err := f.schedules.CreateSchedule(f.ctx, "user1", "0 10 * * 1", "Обед?", []string{"Пицца", "Суши"},
+		CreateOptions{RestrictToChannel: true})
+	require.NoError(t, err)
+	schedule := f.onlySchedule(t)
+	countPolls := func() int {
+		polls, _, err := f.polls.ListPolls(context.Background(), repository.ListFilter{})
+		require.NoError(t, err)
+		return len(polls)
+	}
+
+	assert.Empty(t, f.schedules.RunDue(context.Background(), time.Date(2025, 3, 3, 9, 59, 0, 0, time.UTC)))
+
+	due := f.schedules.RunDue(context.Background(), time.Date(2025, 3, 3, 10, 0, 20, 0, time.UTC))
+	require.Len(t, due, 1)
+	assert.Equal(t, schedule.ID, due[0].ScheduleID)
+	assert.Equal(t, "c1", due[0].ChannelID)
+	assert.Contains(t, due[0].Message, "Опрос по расписанию `"+schedule.ID+"`")
+	assert.Contains(t, due[0].Message, "Вопрос: Обед?")
+
+	polls, _, err := f.polls.ListPolls(context.Background(), repository.ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, polls, 1)
+	assert.Equal(t, "user1", polls[0].Creator)
+	assert.Equal(t, "c1", polls[0].ChannelID)
+	assert.Equal(t, []string{"Пицца", "Суши"}, polls[0].OptionOrder)
+	assert.True(t, polls[0].RestrictToChannel)
+	assert.Equal(t, polls[0].ID, due[0].PollID)
+	assert.Equal(t, "user1", due[0].Creator)
+	assert.Equal(t, "Обед?", due[0].Question)
+	assert.Equal(t, time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC), f.onlySchedule(t).LastRunAt)
+
+	// Повторный вызов за ту же минуту опрос не дублирует
+	assert.Empty(t, f.schedules.RunDue(context.Background(), time.Date(2025, 3, 3, 10, 0, 50, 0, time.UTC)))
+	assert.Equal(t, 1, countPolls())
+
+	// Следующий понедельник - новый опрос
+	require.Len(t, f.schedules.RunDue(context.Background(), time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)), 1)
+	assert.Equal(t, 2, countPolls())
+}
+
+// Тест проверяет, что пропущенные запуски не досоздаются
+func TestRunDue_SkipsMissedTicks(t *testing.T) {
+	f := newScheduleFixture(t, time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC))
+	_, err := f.schedules.CreateSchedule(f.ctx, "user1", "0 10 * * *", "Стендап?", []string{"Да"}, CreateOptions{})
+	require.NoError(t, err)
+
+	// Бот не работал несколько дней и запустился в 12:00
+	assert.Empty(t, f.schedules.RunDue(context.Background(), time.Date(2025, 3, 6, 12, 0, 0, 0, time.UTC)))
+	polls, _, err := f.polls.ListPolls(context.Background(), repository.ListFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, polls)
+
+	assert.Len(t, f.schedules.RunDue(context.Background(), time.Date(2025, 3, 7, 10, 0, 0, 0, time.UTC)), 1)
+}
+
+// Тест проверяет, что остановленное расписание больше не запускается,
+// а повторная остановка не считается первой
+func TestMarkUnreachable(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC)
+	f := newScheduleFixture(t, now)
+	_, err := f.schedules.CreateSchedule(f.ctx, "user1", "0 10 * * *", "Стендап?", []string{"Да"}, CreateOptions{})
+	require.NoError(t, err)
+	id := f.onlySchedule(t).ID
+
+	first, err := f.schedules.MarkUnreachable(context.Background(), id)
+	require.NoError(t, err)
+	assert.True(t, first)
+	assert.Equal(t, now, f.onlySchedule(t).UnreachableAt)
+
+	first, err = f.schedules.MarkUnreachable(context.Background(), id)
+	require.NoError(t, err)
+	assert.False(t, first, "расписание уже остановлено")
+
+	assert.Empty(t, f.schedules.RunDue(context.Background(), time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)))
+	polls, _, err := f.polls.ListPolls(context.Background(), repository.ListFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, polls)
+
+	_, err = f.schedules.MarkUnreachable(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrScheduleNotFound)
+}
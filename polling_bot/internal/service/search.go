@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Сколько найденных опросов показывает команда search
+const maxSearchResults = 10
+
+// SearchPolls ищет открытые опросы по тексту вопроса среди тех же опросов,
+// что показывает list. Опросы читаются страницами, в памяти остаются только
+// совпадения, и поиск останавливается на первых maxSearchResults.
+func (s *PollServiceImpl) SearchPolls(ctx context.Context, userID, query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", i18n.NewError(i18n.SearchQueryEmpty)
+	}
+	matcher := newQuestionMatcher(query)
+
+	filter := openPollsFilter(ctx, userID)
+	filter.Limit = repository.MaxListLimit
+
+	// Лишнее совпадение сообщает, что показаны не все
+	polls, err := s.collectPolls(ctx, filter, maxSearchResults+1, func(poll models.Poll) bool {
+		return matcher.matches(poll.Question)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return renderSearch(i18n.FromContext(ctx), query, polls), nil
+}
+
+// questionMatcher сравнивает вопрос с запросом без учёта регистра: вопрос
+// подходит, если содержит запрос целиком или если каждое слово запроса
+// начинает какое-то слово вопроса ("обед пятн" находит "Где обедаем в пятницу?").
+type questionMatcher struct {
+	query  string
+	tokens []string
+}
+
+func newQuestionMatcher(query string) questionMatcher {
+	query = strings.ToLower(query)
+	return questionMatcher{query: strings.Join(strings.Fields(query), " "), tokens: words(query)}
+}
+
+func (m questionMatcher) matches(question string) bool {
+	question = strings.ToLower(question)
+	if strings.Contains(strings.Join(strings.Fields(question), " "), m.query) {
+		return true
+	}
+	if len(m.tokens) == 0 {
+		return false
+	}
+	questionWords := words(question)
+	for _, token := range m.tokens {
+		found := false
+		for _, word := range questionWords {
+			if strings.HasPrefix(word, token) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// words делит текст на слова из букв и цифр: знаки препинания не мешают
+// совпадению с началом слова.
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет совпадение вопроса с запросом: подстрока без учёта
+// регистра или начало слов вопроса для каждого слова запроса
+func TestQuestionMatcher(t *testing.T) {
+	tests := []struct {
+		query    string
+		question string
+		want     bool
+	}{
+		{query: "обед", question: "Где обедаем?", want: true},
+		{query: "ОБЕДАЕМ", question: "Где обедаем?", want: true},
+		{query: "даем", question: "Где обедаем?", want: true},
+		{query: "где обед", question: "Где  обедаем?", want: true},
+		{query: "обед пятн", question: "Где обедаем в пятницу?", want: true},
+		{query: "пятн обед", question: "Где обедаем в пятницу?", want: true},
+		{query: "обед субб", question: "Где обедаем в пятницу?"},
+		{query: "lunch", question: "Где обедаем?"},
+		{query: "?", question: "Где обедаем?", want: true},
+		{query: "!", question: "Где обедаем?"},
+		{query: "релиз", question: "Когда\nрелиз?", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query+" in "+tt.question, func(t *testing.T) {
+			assert.Equal(t, tt.want, newQuestionMatcher(tt.query).matches(tt.question))
+		})
+	}
+}
+
+// Тест проверяет ответы поиска: совпадения среди открытых опросов канала,
+// отдельные ответы на пустой запрос и на запрос без совпадений
+func TestSearchPolls(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  Origin
+		query   string
+		want    string
+		wantErr string
+	}{
+		{name: "matches", origin: Origin{ChannelID: "c1"}, query: "ОБЕД",
+			want: "**Опросы по запросу «ОБЕД»**\n" +
+				"- `" + listPollID(1) + "` Где обедаем?, голосов: 3 `food`\n"},
+		{name: "closed polls skipped", origin: Origin{ChannelID: "c1"}, query: "закрытый",
+			want: "Открытых опросов по запросу «закрытый» не найдено"},
+		{name: "direct messages", origin: Origin{ChannelID: "dm", Direct: true}, query: "канал",
+			want: "**Опросы по запросу «канал»**\n" +
+				"- `" + listPollID(4) + "` Другой канал, голосов: 0\n"},
+		{name: "no matches", origin: Origin{ChannelID: "c1"}, query: "  lunch   *now* ",
+			want: "Открытых опросов по запросу «lunch \\*now\\*» не найдено"},
+		{name: "empty query", origin: Origin{ChannelID: "c1"}, query: "  ",
+			wantErr: "укажите текст для поиска по вопросам опросов"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newListService(t)
+			got, err := s.SearchPolls(WithOrigin(context.Background(), tt.origin), "u1", tt.query)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Тест проверяет, что поиск проходит страницы списка опросов, показывает
+// первые maxSearchResults совпадений и сообщает, что нашлись не все
+func TestSearchPolls_Limit(t *testing.T) {
+	tests := []struct {
+		name     string
+		matches  int
+		wantMore bool
+	}{
+		{name: "exactly the limit", matches: maxSearchResults},
+		{name: "over the limit", matches: maxSearchResults + 5, wantMore: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Совпадения стоят после нескольких страниц других опросов
+			var extra []models.Poll
+			for i := 0; i < 2*repository.MaxListLimit+tt.matches; i++ {
+				question := "Другой вопрос"
+				if i >= 2*repository.MaxListLimit {
+					question = "Когда ретро?"
+				}
+				extra = append(extra, models.Poll{ID: listPollID(100 + i), Creator: "u2", Question: question,
+					ChannelID: "c1", Options: map[string]int{"Да": 0}})
+			}
+			s := newListService(t, extra...)
+
+			got, err := s.SearchPolls(WithOrigin(context.Background(), Origin{ChannelID: "c1"}), "u1", "ретро")
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+			more := "Показаны первые 10 совпадений, уточните запрос"
+			if tt.wantMore {
+				assert.Len(t, lines, maxSearchResults+2, "заголовок, опросы и подсказка")
+				assert.Equal(t, more, lines[len(lines)-1])
+			} else {
+				assert.Len(t, lines, maxSearchResults+1)
+				assert.NotContains(t, got, more)
+			}
+			assert.Equal(t, "- `"+listPollID(100+2*repository.MaxListLimit)+"` Когда ретро?, голосов: 0", lines[1])
+		})
+	}
+}
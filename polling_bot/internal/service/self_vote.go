@@ -0,0 +1,30 @@
+package service
+
+import (
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// SetDisallowSelfVote запрещает создателю голосовать во всех новых опросах,
+// а не только созданных с --no-self-vote.
+func (s *PollServiceImpl) SetDisallowSelfVote(byDefault bool) {
+	s.noSelfVote = byDefault
+}
+
+// checkSelfVote отклоняет голос создателя в опросе с --no-self-vote, в том
+// числе воздержание и замену воздержания голосом.
+func checkSelfVote(poll models.Poll, userID string) error {
+	if poll.NoSelfVote && poll.Creator == userID {
+		return i18n.NewError(i18n.SelfVoteForbidden)
+	}
+	return nil
+}
+
+// selfVoteNote - напоминание создателю, проголосовавшему в своём опросе:
+// голос учтён, но участники могут счесть его предвзятым.
+func selfVoteNote(loc *i18n.Localizer, poll models.Poll, userID string) string {
+	if poll.NoSelfVote || poll.Creator != userID {
+		return ""
+	}
+	return "\n" + loc.T(i18n.SelfVoteNote)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет голос создателя в своём опросе: без --no-self-vote он
+// учитывается с напоминанием, с флагом или запретом по умолчанию
+// отклоняется, в том числе воздержание и замена воздержания голосом
+func TestAddVote_SelfVote(t *testing.T) {
+	const selfVoteNote = "\n_Это ваш опрос: голос учтён наравне с остальными._"
+
+	tests := []struct {
+		name      string
+		opts      CreateOptions
+		byDefault bool
+		// Сначала воздержаться, затем проголосовать за вариант
+		abstainFirst bool
+		wantErr      string
+	}{
+		{name: "allowed", opts: CreateOptions{}},
+		{name: "allowed after abstaining", opts: CreateOptions{AllowAbstain: true}, abstainFirst: true},
+		{name: "flag", opts: CreateOptions{NoSelfVote: true}, wantErr: "создатель не может голосовать в этом опросе"},
+		{name: "default", byDefault: true, wantErr: "создатель не может голосовать в этом опросе"},
+		{name: "abstain forbidden", opts: CreateOptions{NoSelfVote: true, AllowAbstain: true}, abstainFirst: true, wantErr: "создатель не может голосовать в этом опросе"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.SetDisallowSelfVote(tt.byDefault)
+			created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"}, tt.opts)
+			require.NoError(t, err)
+
+			if tt.abstainFirst {
+				reply, err := s.AddVote(ctx, "creator1", created.ID, []string{"Воздержался"})
+				if tt.wantErr != "" {
+					assert.EqualError(t, err, tt.wantErr)
+				} else {
+					require.NoError(t, err)
+					assert.Contains(t, reply, selfVoteNote)
+				}
+			}
+			reply, err := s.AddVote(ctx, "creator1", created.ID, []string{"Да"})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "Ваш голос в голосовании "+created.ID+" записан: Да"+selfVoteNote, reply)
+			}
+
+			// Голоса остальных участников напоминания не получают
+			reply, err = s.AddVote(ctx, "u1", created.ID, []string{"Нет"})
+			require.NoError(t, err)
+			assert.Equal(t, "Ваш голос в голосовании "+created.ID+" записан: Нет", reply)
+		})
+	}
+}
+
+// Тест проверяет, что запрет голоса создателя виден в настройках, но
+// командой set не снимается, и что клон опроса его сохраняет
+func TestNoSelfVote_SettingsAndClone(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"}, CreateOptions{NoSelfVote: true})
+	require.NoError(t, err)
+
+	settings, err := s.PollSettings(ctx, "creator1", created.ID)
+	require.NoError(t, err)
+	assert.Contains(t, settings, "- no-self-vote: вкл (задаётся при создании)\n")
+	_, err = s.UpdateSetting(ctx, "creator1", created.ID, SettingChange{Setting: SettingNoSelfVote})
+	assert.EqualError(t, err, "настройка no-self-vote задаётся только при создании опроса")
+
+	_, err = s.ClonePoll(WithOrigin(ctx, Origin{PostID: "clone1"}), "creator1", created.ID, CloneOverrides{})
+	require.NoError(t, err)
+	polls, _, err := repo.ListPolls(ctx, repository.ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, polls, 2)
+	for _, poll := range polls {
+		assert.True(t, poll.NoSelfVote, "опрос %s", poll.ID)
+	}
+}
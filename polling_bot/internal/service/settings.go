@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Настройки опроса, которые создатель может изменить после создания
+// командой set. Остальные флаги create (ranked, веса, воздержание)
+// меняют смысл уже отданных голосов и после создания не меняются.
+const (
+	SettingChannelOnly = "channel-only"
+	SettingQuorum      = "quorum"
+	SettingMaxVotes    = "max-votes"
+	SettingExpires     = "expires"
+	SettingVoters      = "voters"
+)
+
+// Settings - имена изменяемых настроек в порядке вывода.
+var Settings = []string{SettingChannelOnly, SettingQuorum, SettingMaxVotes, SettingExpires, SettingVoters}
+
+// Настройки, которые команда set показывает после изменяемых, но не
+// меняет. Запрет голоса создателя: создатель, который уже проголосовал, не
+// должен снимать запрет задним числом. Анонимность: отданные голоса уже
+// хранятся без ID участников или с ними. Голосование только участников:
+// голоса посторонних, отданные до включения, уже учтены.
+const (
+	SettingNoSelfVote  = "no-self-vote"
+	SettingAnonymous   = "anonymous"
+	SettingMembersOnly = "members-only"
+)
+
+// FixedSettings - имена неизменяемых настроек в порядке вывода.
+var FixedSettings = []string{SettingNoSelfVote, SettingAnonymous, SettingMembersOnly}
+
+// SettingChange - новое значение одной настройки, разобранное обработчиком:
+// Enabled для channel-only, Number для quorum и max-votes (0 - без
+// ограничения), Expires для expires (0 - без срока), Usernames для voters
+// (пусто - голосовать может любой).
+type SettingChange struct {
+	Setting   string
+	Enabled   bool
+	Number    int
+	Expires   time.Duration
+	Usernames []string
+}
+
+// PollSettings показывает создателю текущие значения изменяемых настроек.
+func (s *PollServiceImpl) PollSettings(ctx context.Context, userID, pollID string) (string, error) {
+	poll, err := s.settingsPoll(ctx, userID, pollID)
+	if err != nil {
+		return "", err
+	}
+
+	return renderSettings(i18n.FromContext(ctx), poll), nil
+}
+
+// UpdateSetting меняет одну настройку открытого опроса и отвечает прежним
+// и новым значением. Кворум и максимум голосов нельзя опустить до уже
+// набранного числа голосов: опрос закрылся бы без последнего голоса.
+// Список участников отвечает новым списком, см. updateVoters.
+func (s *PollServiceImpl) UpdateSetting(ctx context.Context, userID, pollID string, change SettingChange) (string, error) {
+	poll, err := s.settingsPoll(ctx, userID, pollID)
+	if err != nil {
+		return "", err
+	}
+
+	var update repository.SettingsUpdate
+	changed := poll
+	switch change.Setting {
+	case SettingChannelOnly:
+		update.RestrictToChannel = &change.Enabled
+		changed.RestrictToChannel = change.Enabled
+	case SettingQuorum:
+		if change.Number > 0 && change.Number <= poll.VoterCount() {
+			return "", i18n.NewError(i18n.SettingQuorumReached, poll.VoterCount())
+		}
+		if poll.Restricted() && change.Number > len(poll.AllowedVoters) {
+			return "", i18n.NewError(i18n.VotersQuorum, change.Number, len(poll.AllowedVoters))
+		}
+		update.Quorum = &change.Number
+		changed.Quorum = change.Number
+	case SettingMaxVotes:
+		if change.Number > 0 && change.Number <= poll.VoterCount() {
+			return "", i18n.NewError(i18n.SettingMaxVotesMet, poll.VoterCount())
+		}
+		update.MaxVotes = &change.Number
+		changed.MaxVotes = change.Number
+	case SettingVoters:
+		return s.updateVoters(ctx, userID, poll, change.Usernames)
+	case SettingNoSelfVote, SettingAnonymous, SettingMembersOnly:
+		return "", i18n.NewError(i18n.SettingCreateOnly, change.Setting)
+	case SettingExpires:
+		if change.Expires == 0 && !s.expiry.AllowNoExpire {
+			return "", i18n.NewError(i18n.NoExpireForbidden)
+		}
+		var expiresAt time.Time
+		if change.Expires > 0 {
+			expiresAt = s.now().UTC().Add(change.Expires).Truncate(time.Second)
+		}
+		update.ExpiresAt = &expiresAt
+		changed.ExpiresAt = expiresAt
+	default:
+		return "", i18n.NewError(i18n.SettingUnknown, change.Setting, strings.Join(Settings, ", "))
+	}
+
+	loc := i18n.FromContext(ctx)
+	before, after := settingValue(loc, poll, change.Setting), settingValue(loc, changed, change.Setting)
+	if before == after {
+		return loc.T(i18n.SettingUnchanged, change.Setting, poll.ID, after), nil
+	}
+	if err := s.repo.UpdateSettings(ctx, poll.ID, update); err != nil {
+		return "", s.storageError(err, i18n.OpUpdateSettings)
+	}
+	s.record(ctx, poll.ID, userID, audit.ActionSettingChanged, change.Setting+": "+after)
+	return loc.T(i18n.SettingChanged, change.Setting, poll.ID, before, after), nil
+}
+
+// settingsPoll читает опрос для команды set: менять и смотреть настройки
+// может только создатель, и только пока опрос открыт.
+func (s *PollServiceImpl) settingsPoll(ctx context.Context, userID, pollID string) (models.Poll, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return models.Poll{}, s.storageError(err, i18n.OpGetPoll)
+	}
+	if poll.Creator != userID {
+		return models.Poll{}, i18n.NewError(i18n.OnlyCreatorSettings)
+	}
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return models.Poll{}, err
+	}
+	if poll.Closed || expired(poll, s.now()) {
+		return models.Poll{}, i18n.NewError(i18n.SettingsPollClosed)
+	}
+	return poll, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+const settingsPollID = "123e4567-e89b-12d3-a456-426614174000"
+
+var settingsNow = time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+
+func newSettingsService(t *testing.T, mutate func(*models.Poll), expiry ExpiryOptions) (*PollServiceImpl, repository.PollRepository, repository.AuditRepository) {
+	t.Helper()
+	poll := models.Poll{
+		ID:        settingsPollID,
+		Creator:   "creator1",
+		Question:  "Где обедаем?",
+		Options:   map[string]int{"Пицца": 2, "Суши": 1},
+		ChannelID: "c1",
+		Quorum:    10,
+		ExpiresAt: settingsNow.Add(24 * time.Hour),
+	}
+	if mutate != nil {
+		mutate(&poll)
+	}
+	repo := repository.NewMemoryPollRepo()
+	require.NoError(t, repo.SavePoll(context.Background(), poll))
+	journal := repository.NewMemoryAuditRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.now = func() time.Time { return settingsNow }
+	s.SetExpiry(expiry)
+	s.SetAuditRepository(journal)
+	return s, repo, journal
+}
+
+// Тест проверяет изменение настроек открытого опроса и отказы команды set
+func TestUpdateSetting(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*models.Poll)
+		expiry  ExpiryOptions
+		userID  string
+		change  SettingChange
+		want    string
+		wantErr string
+		check   func(t *testing.T, poll models.Poll)
+	}{
+		{
+			name:   "channel-only on",
+			userID: "creator1",
+			change: SettingChange{Setting: SettingChannelOnly, Enabled: true},
+			want:   "Настройка channel-only опроса " + settingsPollID + ": выкл → вкл",
+			check:  func(t *testing.T, poll models.Poll) { assert.True(t, poll.RestrictToChannel) },
+		},
+		{
+			name:   "lower quorum",
+			userID: "creator1",
+			change: SettingChange{Setting: SettingQuorum, Number: 5},
+			want:   "Настройка quorum опроса " + settingsPollID + ": 10 → 5",
+			check:  func(t *testing.T, poll models.Poll) { assert.Equal(t, 5, poll.Quorum) },
+		},
+		{
+			name:   "remove quorum",
+			userID: "creator1",
+			change: SettingChange{Setting: SettingQuorum},
+			want:   "Настройка quorum опроса " + settingsPollID + ": 10 → нет",
+			check:  func(t *testing.T, poll models.Poll) { assert.Zero(t, poll.Quorum) },
+		},
+		{
+			name:    "quorum already reached",
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingQuorum, Number: 3},
+			wantErr: "в опросе уже 3 голосов: кворум должен быть больше",
+		},
+		{
+			name:   "max votes",
+			userID: "creator1",
+			change: SettingChange{Setting: SettingMaxVotes, Number: 4},
+			want:   "Настройка max-votes опроса " + settingsPollID + ": нет → 4",
+			check:  func(t *testing.T, poll models.Poll) { assert.Equal(t, 4, poll.MaxVotes) },
+		},
+		{
+			name:    "max votes already reached",
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingMaxVotes, Number: 2},
+			wantErr: "в опросе уже 3 голосов: максимум должен быть больше",
+		},
+		{
+			name:   "new expiry from now",
+			mutate: func(p *models.Poll) { p.ExpiryWarned = true },
+			userID: "creator1",
+			change: SettingChange{Setting: SettingExpires, Expires: 72 * time.Hour},
+			want:   "Настройка expires опроса " + settingsPollID,
+			check: func(t *testing.T, poll models.Poll) {
+				assert.Equal(t, settingsNow.Add(72*time.Hour), poll.ExpiresAt)
+				assert.False(t, poll.ExpiryWarned)
+			},
+		},
+		{
+			name:   "remove expiry",
+			expiry: ExpiryOptions{AllowNoExpire: true},
+			userID: "creator1",
+			change: SettingChange{Setting: SettingExpires},
+			want:   "→ нет",
+			check:  func(t *testing.T, poll models.Poll) { assert.True(t, poll.ExpiresAt.IsZero()) },
+		},
+		{
+			name:    "remove expiry forbidden",
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingExpires},
+			wantErr: "опросы без срока запрещены: укажите срок флагом --expires",
+		},
+		{
+			name:   "unchanged",
+			userID: "creator1",
+			change: SettingChange{Setting: SettingQuorum, Number: 10},
+			want:   "Настройка quorum опроса " + settingsPollID + " уже 10",
+		},
+		{
+			name:    "not the creator",
+			userID:  "u1",
+			change:  SettingChange{Setting: SettingQuorum, Number: 5},
+			wantErr: "только создатель может менять настройки опроса",
+		},
+		{
+			name:    "closed poll",
+			mutate:  func(p *models.Poll) { p.Closed = true },
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingQuorum, Number: 5},
+			wantErr: "настройки завершённого опроса менять нельзя",
+		},
+		{
+			name:    "expired poll",
+			mutate:  func(p *models.Poll) { p.ExpiresAt = settingsNow.Add(-time.Minute) },
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingQuorum, Number: 5},
+			wantErr: "настройки завершённого опроса менять нельзя",
+		},
+		{
+			name:    "unknown setting",
+			userID:  "creator1",
+			change:  SettingChange{Setting: "ranked"},
+			wantErr: "неизвестная настройка 'ranked'. Доступные настройки: channel-only, quorum, max-votes, expires, voters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, journal := newSettingsService(t, tt.mutate, tt.expiry)
+			before, err := repo.GetPoll(context.Background(), settingsPollID)
+			require.NoError(t, err)
+
+			result, err := s.UpdateSetting(context.Background(), tt.userID, settingsPollID, tt.change)
+			stored, getErr := repo.GetPoll(context.Background(), settingsPollID)
+			require.NoError(t, getErr)
+			events, listErr := journal.ListEvents(context.Background(), settingsPollID, 10)
+			require.NoError(t, listErr)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, before, stored)
+				assert.Empty(t, events)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, result, tt.want)
+			if tt.check == nil {
+				assert.Equal(t, before, stored)
+				assert.Empty(t, events)
+				return
+			}
+			tt.check(t, stored)
+			require.Len(t, events, 1)
+			assert.Equal(t, audit.ActionSettingChanged, events[0].Action)
+			assert.Equal(t, "creator1", events[0].Actor)
+		})
+	}
+}
+
+// Тест проверяет вывод текущих настроек опроса создателю
+func TestPollSettings(t *testing.T) {
+	s, _, _ := newSettingsService(t, func(p *models.Poll) { p.ExpiresAt = time.Time{} }, ExpiryOptions{})
+
+	result, err := s.PollSettings(context.Background(), "creator1", settingsPollID)
+	require.NoError(t, err)
+	assert.Equal(t, "**Настройки опроса "+settingsPollID+"**\n"+
+		"- channel-only: выкл\n- quorum: 10\n- max-votes: нет\n- expires: нет\n- voters: нет\n"+
+		"- no-self-vote: выкл (задаётся при создании)\n"+
+		"- anonymous: выкл (задаётся при создании)\n"+
+		"- members-only: выкл (задаётся при создании)\n", result)
+
+	_, err = s.PollSettings(context.Background(), "u1", settingsPollID)
+	assert.EqualError(t, err, "только создатель может менять настройки опроса")
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"polling_bot/internal/i18n"
+)
+
+const (
+	// Сколько меток может быть у опроса
+	maxTags = 5
+	// Сколько символов может занимать метка
+	maxTagLength = 30
+)
+
+// normalizeTags приводит метки опроса к нижнему регистру и убирает
+// повторы, сохраняя порядок. Пустой список меток - nil.
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTags {
+		return nil, i18n.NewError(i18n.TagsTooMany, maxTags)
+	}
+	return normalized, nil
+}
+
+// normalizeTag приводит метку к нижнему регистру и проверяет её: метка
+// выводится и ищется как одно слово, поэтому в ней только буквы, цифры,
+// дефис и подчёркивание.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if utf8.RuneCountInString(tag) > maxTagLength {
+		return "", i18n.NewError(i18n.TagTooLong, tag, maxTagLength)
+	}
+	if !isWord(tag) {
+		return "", i18n.NewError(i18n.TagInvalid, tag)
+	}
+	return tag, nil
+}
+
+// isWord сообщает, что s состоит только из букв, цифр, дефиса и подчёркивания.
+func isWord(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
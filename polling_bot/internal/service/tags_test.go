@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет нормализацию меток и ошибки проверки для каждого
+// нарушения правил
+func TestCreatePoll_Tags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr string
+	}{
+		{name: "no tags"},
+		{name: "lowercased and trimmed", tags: []string{" Release", "TEAM-A ", "q3_2025"},
+			want: []string{"release", "team-a", "q3_2025"}},
+		{name: "duplicates and empty tags dropped", tags: []string{"release", "", "Release", " "},
+			want: []string{"release"}},
+		{name: "non-latin letters", tags: []string{"Обед"}, want: []string{"обед"}},
+		{name: "five tags", tags: []string{"a", "b", "c", "d", "e"}, want: []string{"a", "b", "c", "d", "e"}},
+		{name: "duplicates do not count", tags: []string{"a", "b", "c", "d", "e", "A"},
+			want: []string{"a", "b", "c", "d", "e"}},
+		{name: "too many tags", tags: []string{"a", "b", "c", "d", "e", "f"},
+			wantErr: "у опроса может быть не больше 5 меток"},
+		{name: "longest tag", tags: []string{strings.Repeat("я", maxTagLength)},
+			want: []string{strings.Repeat("я", maxTagLength)}},
+		{name: "tag too long", tags: []string{strings.Repeat("я", maxTagLength+1)},
+			wantErr: "метка '" + strings.Repeat("я", maxTagLength+1) + "' длиннее 30 символов"},
+		{name: "space inside tag", tags: []string{"team a"},
+			wantErr: "метка 'team a' может содержать только буквы, цифры, - и _"},
+		{name: "markup in tag", tags: []string{"`x`"},
+			wantErr: "метка '`x`' может содержать только буквы, цифры, - и _"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+			created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца"}, CreateOptions{Tags: tt.tags})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			stored, err := repo.GetPoll(ctx, created.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stored.Tags)
+		})
+	}
+}
+
+// Тест проверяет вывод меток при создании опроса, в итогах и в копии
+func TestCreatePoll_RenderTags(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+	created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"},
+		CreateOptions{Tags: []string{"Release", "team_a"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Голосование создано успешно! ID: `"+created.ID+"`\nВопрос: Где обедаем?\n"+
+		"Варианты:\n1. Пицца\n2. Суши\nМетки: `release`, `team_a`\n", created.Message)
+
+	results, err := s.GetResults(ctx, "creator1", created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "**Результаты опроса "+created.ID+"**\nГде обедаем?\nМетки: `release`, `team_a`\n"+
+		"- Пицца: 0 голосов\n- Суши: 0 голосов\n", results)
+
+	clone, err := s.ClonePoll(ctx, "creator2", created.ID, CloneOverrides{})
+	require.NoError(t, err)
+	assert.Contains(t, clone, "Метки: `release`, `team_a`\n")
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Сколько символов может занимать имя шаблона
+const maxTemplateNameLength = 30
+
+type TemplateService interface {
+	// SaveTemplate сохраняет шаблон в личной области пользователя, а с
+	// shared - в области канала, из которого пришла команда.
+	SaveTemplate(ctx context.Context, userID, name, question string, options []string, opts CreateOptions, shared bool) (string, error)
+	// ListTemplates перечисляет личные шаблоны пользователя и шаблоны канала.
+	ListTemplates(ctx context.Context, userID string) (string, error)
+	DeleteTemplate(ctx context.Context, userID, name string, shared bool) (string, error)
+	// CreateFromTemplate создаёт опрос по шаблону: личному, а если его нет -
+	// по шаблону канала.
+	CreateFromTemplate(ctx context.Context, userID, name string) (string, error)
+}
+
+// TemplatePolls - то, что сервису шаблонов нужно от сервиса опросов.
+type TemplatePolls interface {
+	CreatePoll(ctx context.Context, userID, question string, options []string, opts CreateOptions) (string, error)
+	ValidatePoll(question string, options []string, opts CreateOptions) error
+}
+
+type TemplateServiceImpl struct {
+	repo   repository.TemplateRepository
+	polls  TemplatePolls
+	logger zerolog.Logger
+	now    func() time.Time
+}
+
+// NewTemplateService создаёт сервис шаблонов; шаблон проверяется и опросы
+// по нему создаются через polls, как командой create.
+func NewTemplateService(repo repository.TemplateRepository, polls TemplatePolls, logger zerolog.Logger) *TemplateServiceImpl {
+	return &TemplateServiceImpl{repo: repo, polls: polls, logger: logger, now: time.Now}
+}
+
+func (s *TemplateServiceImpl) SaveTemplate(ctx context.Context, userID, name, question string, options []string, opts CreateOptions, shared bool) (string, error) {
+	name, err := normalizeTemplateName(name)
+	if err != nil {
+		return "", err
+	}
+	scope, err := templateScope(ctx, userID, shared)
+	if err != nil {
+		return "", err
+	}
+	// Веса привязаны к участникам одного опроса и в шаблоне не хранятся
+	if len(opts.Weights) > 0 {
+		return "", i18n.NewError(i18n.WeightsCreateOnly)
+	}
+	// Список участников, как и веса, относится к одному опросу
+	if len(opts.Voters) > 0 {
+		return "", i18n.NewError(i18n.VotersCreateOnly)
+	}
+	if opts.AllowAbstain {
+		return "", i18n.NewError(i18n.AbstainCreateOnly)
+	}
+	if opts.MaxVotes > 0 {
+		return "", i18n.NewError(i18n.MaxVotesCreateOnly)
+	}
+	if opts.VoteReceipts {
+		return "", i18n.NewError(i18n.ReceiptsCreateOnly)
+	}
+	if opts.NoSelfVote {
+		return "", i18n.NewError(i18n.NoSelfVoteCreateOnly)
+	}
+	if opts.Anonymous {
+		return "", i18n.NewError(i18n.AnonymousCreateOnly)
+	}
+	if opts.MembersOnly {
+		return "", i18n.NewError(i18n.MembersCreateOnly)
+	}
+	if err := s.polls.ValidatePoll(question, options, opts); err != nil {
+		return "", err
+	}
+	// ValidatePoll уже проверил метки
+	tags, _ := normalizeTags(opts.Tags)
+
+	template := models.Template{
+		Scope:             scope,
+		Name:              name,
+		Creator:           userID,
+		Question:          question,
+		Options:           append([]string(nil), options...),
+		Description:       strings.TrimSpace(opts.Description),
+		Tags:              tags,
+		RestrictToChannel: opts.RestrictToChannel,
+		Quorum:            opts.Quorum,
+		Ranked:            opts.Ranked,
+		Exclusive:         opts.Exclusive,
+		Pin:               opts.Pin,
+		NotifyVoters:      opts.NotifyVoters,
+		Expires:           opts.Expires,
+		NoExpire:          opts.NoExpire,
+		CreatedAt:         s.now().UTC(),
+	}
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return "", i18n.NewError(i18n.TemplateExists, name)
+		}
+		return "", s.storageError(err, i18n.OpSaveTemplate)
+	}
+
+	loc := i18n.FromContext(ctx)
+	if shared {
+		return loc.T(i18n.TemplateSavedChannel, name, question), nil
+	}
+	return loc.T(i18n.TemplateSaved, name, question), nil
+}
+
+func (s *TemplateServiceImpl) ListTemplates(ctx context.Context, userID string) (string, error) {
+	templates, err := s.repo.ListTemplates(ctx, models.UserTemplateScope(userID))
+	if err != nil {
+		return "", s.storageError(err, i18n.OpListTemplates)
+	}
+	if origin := OriginFrom(ctx); origin.ChannelID != "" && !origin.Direct {
+		shared, err := s.repo.ListTemplates(ctx, models.ChannelTemplateScope(origin.ChannelID))
+		if err != nil {
+			return "", s.storageError(err, i18n.OpListTemplates)
+		}
+		templates = append(templates, shared...)
+	}
+
+	loc := i18n.FromContext(ctx)
+	if len(templates) == 0 {
+		return loc.T(i18n.TemplateListEmpty), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.TemplateListHeader))
+	for _, template := range templates {
+		var mark string
+		if template.ChannelScoped() {
+			mark = loc.T(i18n.TemplateChannelMark)
+		}
+		sb.WriteString(loc.T(i18n.TemplateListLine, template.Name, template.Question, mark))
+	}
+	return sb.String(), nil
+}
+
+func (s *TemplateServiceImpl) DeleteTemplate(ctx context.Context, userID, name string, shared bool) (string, error) {
+	name, err := normalizeTemplateName(name)
+	if err != nil {
+		return "", err
+	}
+	scope, err := templateScope(ctx, userID, shared)
+	if err != nil {
+		return "", err
+	}
+	template, err := s.repo.GetTemplate(ctx, scope, name)
+	if err != nil {
+		return "", s.templateError(err, name, i18n.OpGetTemplate)
+	}
+	// Личный шаблон виден только создателю, а шаблон канала - всем в канале
+	if template.Creator != userID {
+		return "", i18n.NewError(i18n.OnlyCreatorTemplate)
+	}
+
+	if err := s.repo.DeleteTemplate(ctx, scope, name); err != nil {
+		return "", s.templateError(err, name, i18n.OpDeleteTemplate)
+	}
+	return i18n.FromContext(ctx).T(i18n.TemplateDeleted, name), nil
+}
+
+// CreateFromTemplate копирует вопрос, варианты и флаги шаблона в новый
+// опрос: дальнейшие изменения шаблона на созданные опросы не влияют.
+// Создателем опроса становится userID, а не автор шаблона.
+func (s *TemplateServiceImpl) CreateFromTemplate(ctx context.Context, userID, name string) (string, error) {
+	name, err := normalizeTemplateName(name)
+	if err != nil {
+		return "", err
+	}
+	template, err := s.repo.GetTemplate(ctx, models.UserTemplateScope(userID), name)
+	if origin := OriginFrom(ctx); errors.Is(err, repository.ErrNotFound) && origin.ChannelID != "" && !origin.Direct {
+		template, err = s.repo.GetTemplate(ctx, models.ChannelTemplateScope(origin.ChannelID), name)
+	}
+	if err != nil {
+		return "", s.templateError(err, name, i18n.OpGetTemplate)
+	}
+
+	created, err := s.polls.CreatePoll(ctx, userID, template.Question, append([]string(nil), template.Options...), CreateOptions{
+		RestrictToChannel: template.RestrictToChannel,
+		Quorum:            template.Quorum,
+		Exclusive:         template.Exclusive,
+		Ranked:            template.Ranked,
+		Pin:               template.Pin,
+		NotifyVoters:      template.NotifyVoters,
+		Expires:           template.Expires,
+		NoExpire:          template.NoExpire,
+		Description:       template.Description,
+		Tags:              append([]string(nil), template.Tags...),
+	})
+	if err != nil {
+		return "", err
+	}
+	return created + i18n.FromContext(ctx).T(i18n.CreatedFromTemplate, name), nil
+}
+
+// normalizeTemplateName приводит имя шаблона к нижнему регистру: имя
+// набирается в команде create-from, поэтому в нём, как в метке, только
+// буквы, цифры, дефис и подчёркивание.
+func normalizeTemplateName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if utf8.RuneCountInString(name) > maxTemplateNameLength {
+		return "", i18n.NewError(i18n.TemplateNameTooLong, maxTemplateNameLength)
+	}
+	if name == "" || !isWord(name) {
+		return "", i18n.NewError(i18n.TemplateNameInvalid, name)
+	}
+	return name, nil
+}
+
+// templateScope выбирает область шаблона: личную или канала команды.
+// Шаблон канала нельзя сохранить из личных сообщений.
+func templateScope(ctx context.Context, userID string, shared bool) (string, error) {
+	if !shared {
+		return models.UserTemplateScope(userID), nil
+	}
+	origin := OriginFrom(ctx)
+	if origin.ChannelID == "" || origin.Direct {
+		return "", i18n.NewError(i18n.TemplateNoChannel)
+	}
+	return models.ChannelTemplateScope(origin.ChannelID), nil
+}
+
+// templateError - storageError, который называет ненайденный шаблон.
+func (s *TemplateServiceImpl) templateError(err error, name string, op i18n.Key) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return i18n.NewError(i18n.TemplateNotFound, name)
+	}
+	return s.storageError(err, op)
+}
+
+// storageError - аналог PollServiceImpl.storageError для шаблонов.
+func (s *TemplateServiceImpl) storageError(err error, op i18n.Key) error {
+	if errors.Is(err, repository.ErrUnavailable) {
+		s.logger.Error().Err(err).Str("operation", string(op)).Msg("Хранилище недоступно")
+		return ErrServiceUnavailable
+	}
+	return i18n.Wrap(err, op)
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+type templateFixture struct {
+	templates *TemplateServiceImpl
+	repo      *repository.MemoryTemplateRepo
+	polls     *repository.MemoryPollRepo
+	service   *PollServiceImpl
+	ctx       context.Context
+}
+
+func newTemplateFixture(t *testing.T) templateFixture {
+	t.Helper()
+	polls := repository.NewMemoryPollRepo()
+	repo := repository.NewMemoryTemplateRepo()
+	service := NewPollService(polls, repository.NewMemoryVoteRepo(polls), zerolog.Nop())
+	s := NewTemplateService(repo, service, zerolog.Nop())
+	s.now = func() time.Time { return time.Date(2025, 3, 3, 9, 30, 0, 0, time.UTC) }
+	return templateFixture{
+		templates: s,
+		repo:      repo,
+		polls:     polls,
+		service:   service,
+		ctx:       WithOrigin(context.Background(), Origin{ChannelID: "c1"}),
+	}
+}
+
+func (f templateFixture) allPolls(t *testing.T) []models.Poll {
+	t.Helper()
+	polls, _, err := f.polls.ListPolls(context.Background(), repository.ListFilter{})
+	require.NoError(t, err)
+	return polls
+}
+
+// Тест проверяет сохранение шаблона и ошибки, о которых сообщается сразу
+func TestSaveTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		tmplName string
+		question string
+		options  []string
+		opts     CreateOptions
+		shared   bool
+		want     string
+		wantErr  string
+	}{
+		{name: "personal", tmplName: " Stand_Up ", question: "Как дела?", options: []string{"Хорошо", "Есть блокеры"},
+			want: "Шаблон `stand_up` сохранён: Как дела?"},
+		{name: "channel", tmplName: "standup", question: "Как дела?", options: []string{"Хорошо"}, shared: true,
+			want: "Шаблон канала `standup` сохранён: Как дела?"},
+		{name: "channel from direct messages", ctx: WithOrigin(context.Background(), Origin{ChannelID: "dm", Direct: true}),
+			tmplName: "standup", question: "Как дела?", options: []string{"Хорошо"}, shared: true,
+			wantErr: "шаблон канала можно сохранить или удалить только в канале"},
+		{name: "invalid name", tmplName: "stand up", question: "Q", options: []string{"A"},
+			wantErr: "неверное имя шаблона 'stand up': допустимы буквы, цифры, - и _"},
+		{name: "empty name", tmplName: "  ", question: "Q", options: []string{"A"},
+			wantErr: "неверное имя шаблона '': допустимы буквы, цифры, - и _"},
+		{name: "long name", tmplName: strings.Repeat("я", maxTemplateNameLength+1), question: "Q", options: []string{"A"},
+			wantErr: "имя шаблона длиннее 30 символов"},
+		{name: "duplicate options", tmplName: "t", question: "Q", options: []string{"A", "A"},
+			wantErr: "все опции в голосовании должны быть уникальными"},
+		{name: "long question", tmplName: "t", question: strings.Repeat("q", 256), options: []string{"A"},
+			wantErr: "вопрос слишком длинный"},
+		{name: "invalid tag", tmplName: "t", question: "Q", options: []string{"A"}, opts: CreateOptions{Tags: []string{"a b"}},
+			wantErr: "метка 'a b' может содержать только буквы, цифры, - и _"},
+		{name: "weights", tmplName: "t", question: "Q", options: []string{"A"}, opts: CreateOptions{Weights: map[string]int{"alice": 2}},
+			wantErr: "веса голосов задаются только командой create"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTemplateFixture(t)
+			ctx := f.ctx
+			if tt.ctx != nil {
+				ctx = tt.ctx
+			}
+
+			got, err := f.templates.SaveTemplate(ctx, "user1", tt.tmplName, tt.question, tt.options, tt.opts, tt.shared)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Тест проверяет, что имя шаблона уникально в своей области: личный
+// шаблон и шаблон канала с одним именем не мешают друг другу
+func TestSaveTemplate_NameCollision(t *testing.T) {
+	f := newTemplateFixture(t)
+	_, err := f.templates.SaveTemplate(f.ctx, "user1", "lunch", "Где обедаем?", []string{"Пицца"}, CreateOptions{}, false)
+	require.NoError(t, err)
+
+	_, err = f.templates.SaveTemplate(f.ctx, "user1", "LUNCH", "Где ужинаем?", []string{"Суши"}, CreateOptions{}, false)
+	assert.EqualError(t, err, "шаблон lunch уже существует")
+
+	_, err = f.templates.SaveTemplate(f.ctx, "user2", "lunch", "Где ужинаем?", []string{"Суши"}, CreateOptions{}, false)
+	require.NoError(t, err)
+	_, err = f.templates.SaveTemplate(f.ctx, "user1", "lunch", "Где ужинаем?", []string{"Суши"}, CreateOptions{}, true)
+	require.NoError(t, err)
+	_, err = f.templates.SaveTemplate(f.ctx, "user2", "lunch", "Где ужинаем?", []string{"Суши"}, CreateOptions{}, true)
+	assert.EqualError(t, err, "шаблон lunch уже существует")
+
+	template, err := f.repo.GetTemplate(context.Background(), models.UserTemplateScope("user1"), "lunch")
+	require.NoError(t, err)
+	assert.Equal(t, "Где обедаем?", template.Question, "повтор не подменяет шаблон")
+}
+
+// Тест проверяет список шаблонов: личные и шаблоны текущего канала
+func TestListTemplates(t *testing.T) {
+	f := newTemplateFixture(t)
+	for _, save := range []struct {
+		userID, name string
+		ctx          context.Context
+		shared       bool
+	}{
+		{userID: "user1", name: "retro"},
+		{userID: "user1", name: "lunch"},
+		{userID: "user2", name: "standup", shared: true},
+		{userID: "user2", name: "other", ctx: WithOrigin(context.Background(), Origin{ChannelID: "c2"}), shared: true},
+		{userID: "user2", name: "private"},
+	} {
+		ctx := f.ctx
+		if save.ctx != nil {
+			ctx = save.ctx
+		}
+		_, err := f.templates.SaveTemplate(ctx, save.userID, save.name, "Вопрос "+save.name, []string{"Да"}, CreateOptions{}, save.shared)
+		require.NoError(t, err)
+	}
+
+	got, err := f.templates.ListTemplates(f.ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, "**Шаблоны опросов**\n"+
+		"- `lunch` Вопрос lunch\n"+
+		"- `retro` Вопрос retro\n"+
+		"- `standup` Вопрос standup (канал)\n", got)
+
+	got, err = f.templates.ListTemplates(WithOrigin(context.Background(), Origin{ChannelID: "dm", Direct: true}), "user3")
+	require.NoError(t, err)
+	assert.Equal(t, "Шаблонов пока нет", got)
+}
+
+// Тест проверяет удаление шаблона: отсутствующий шаблон, чужой шаблон
+// канала и удаление своего
+func TestDeleteTemplate(t *testing.T) {
+	f := newTemplateFixture(t)
+	_, err := f.templates.SaveTemplate(f.ctx, "user1", "lunch", "Где обедаем?", []string{"Пицца"}, CreateOptions{}, true)
+	require.NoError(t, err)
+
+	_, err = f.templates.DeleteTemplate(f.ctx, "user1", "lunch", false)
+	assert.EqualError(t, err, "шаблон lunch не найден", "личного шаблона с таким именем нет")
+	_, err = f.templates.DeleteTemplate(f.ctx, "user2", "lunch", true)
+	assert.EqualError(t, err, "только создатель может удалить шаблон")
+
+	got, err := f.templates.DeleteTemplate(f.ctx, "user1", "Lunch", true)
+	require.NoError(t, err)
+	assert.Equal(t, "Шаблон lunch удалён", got)
+	_, err = f.templates.DeleteTemplate(f.ctx, "user1", "lunch", true)
+	assert.EqualError(t, err, "шаблон lunch не найден")
+}
+
+// Тест проверяет выбор шаблона для create-from: личный важнее шаблона
+// канала, шаблон канала доступен только в его канале
+func TestCreateFromTemplate(t *testing.T) {
+	f := newTemplateFixture(t)
+	_, err := f.templates.SaveTemplate(f.ctx, "user1", "lunch", "Где обедаем?", []string{"Пицца"}, CreateOptions{}, false)
+	require.NoError(t, err)
+	_, err = f.templates.SaveTemplate(f.ctx, "user2", "lunch", "Где обедаем всей командой?", []string{"Суши"}, CreateOptions{}, true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		userID       string
+		tmplName     string
+		wantQuestion string
+		wantErr      string
+	}{
+		{name: "personal first", ctx: f.ctx, userID: "user1", tmplName: "lunch", wantQuestion: "Где обедаем?"},
+		{name: "channel template", ctx: f.ctx, userID: "user3", tmplName: "LUNCH", wantQuestion: "Где обедаем всей командой?"},
+		{name: "channel template elsewhere", ctx: WithOrigin(context.Background(), Origin{ChannelID: "c2"}),
+			userID: "user3", tmplName: "lunch", wantErr: "шаблон lunch не найден"},
+		{name: "missing template", ctx: f.ctx, userID: "user1", tmplName: "retro", wantErr: "шаблон retro не найден"},
+		{name: "invalid name", ctx: f.ctx, userID: "user1", tmplName: "ret ro", wantErr: "неверное имя шаблона 'ret ro': допустимы буквы, цифры, - и _"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.templates.CreateFromTemplate(tt.ctx, tt.userID, tt.tmplName)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, got, "Вопрос: "+tt.wantQuestion+"\n")
+			assert.True(t, strings.HasSuffix(got, "Опрос по шаблону `lunch`\n"), got)
+		})
+	}
+}
+
+// Тест проверяет, что опрос по шаблону получает флаги шаблона, но живёт
+// отдельно от него и от других опросов по тому же шаблону
+func TestCreateFromTemplate_Independent(t *testing.T) {
+	f := newTemplateFixture(t)
+	opts := CreateOptions{Quorum: 3, Ranked: true, Description: " Решаем до 12:00 ", Tags: []string{"Food"}, Expires: 36 * time.Hour}
+	_, err := f.templates.SaveTemplate(f.ctx, "user1", "lunch", "Где обедаем?", []string{"Пицца", "Суши"}, opts, true)
+	require.NoError(t, err)
+
+	_, err = f.templates.CreateFromTemplate(f.ctx, "user2", "lunch")
+	require.NoError(t, err)
+	_, err = f.templates.CreateFromTemplate(f.ctx, "user2", "lunch")
+	require.NoError(t, err)
+
+	polls := f.allPolls(t)
+	require.Len(t, polls, 2)
+	assert.NotEqual(t, polls[0].ID, polls[1].ID)
+	for _, poll := range polls {
+		assert.Equal(t, "user2", poll.Creator, "создатель опроса - автор команды")
+		assert.Equal(t, "c1", poll.ChannelID)
+		assert.Equal(t, 3, poll.Quorum)
+		assert.True(t, poll.Ranked)
+		assert.Equal(t, "Решаем до 12:00", poll.Description)
+		assert.Equal(t, []string{"food"}, poll.Tags)
+		assert.False(t, poll.ExpiresAt.IsZero())
+	}
+
+	// Голос в одном опросе не попадает в другой
+	_, err = f.service.AddVote(f.ctx, "user3", polls[0].ID, []string{"Суши", "Пицца"})
+	require.NoError(t, err)
+	second, err := f.polls.GetPoll(context.Background(), polls[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"Пицца": 0, "Суши": 0}, second.Options)
+
+	// Новый шаблон под тем же именем не меняет созданные опросы
+	_, err = f.templates.DeleteTemplate(f.ctx, "user1", "lunch", true)
+	require.NoError(t, err)
+	_, err = f.templates.SaveTemplate(f.ctx, "user1", "lunch", "Где ужинаем?", []string{"Бургеры"}, CreateOptions{}, true)
+	require.NoError(t, err)
+
+	for _, poll := range polls {
+		got, err := f.polls.GetPoll(context.Background(), poll.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Где обедаем?", got.Question)
+		assert.Equal(t, []string{"Пицца", "Суши"}, got.OptionOrder)
+	}
+	_, err = f.templates.CreateFromTemplate(f.ctx, "user2", "lunch")
+	require.NoError(t, err)
+	assert.Len(t, f.allPolls(t), 3)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// Как часто сверять метрики отслеживаемых опросов с хранилищем по
+	// умолчанию: голос, записанный другим экземпляром бота, виден только так
+	defaultTrackSyncInterval = time.Minute
+	// Сколько по умолчанию показывать метрики закрытого опроса
+	defaultTrackGrace = time.Hour
+)
+
+// TrackOptions - опросы, голоса которых бот отдаёт метриками для дашбордов.
+type TrackOptions struct {
+	PollIDs []string
+	// Как часто сверять метрики с хранилищем
+	SyncInterval time.Duration
+	// Сколько после закрытия опроса ещё отдавать его метрики
+	Grace time.Duration
+}
+
+// PollTracker обновляет метрики poll_votes отслеживаемых опросов после
+// каждого голоса и при периодической сверке с хранилищем, а метрики
+// закрытого опроса убирает через TrackOptions.Grace.
+type PollTracker struct {
+	polls    repository.PollRepository
+	gauges   *metrics.PollGauges
+	logger   zerolog.Logger
+	now      func() time.Time
+	interval time.Duration
+	grace    time.Duration
+
+	mu sync.Mutex
+	// Отслеживаемые опросы и когда они закрылись; нулевое время - открыт
+	closed map[string]time.Time
+}
+
+// NewPollTracker отслеживает опросы opts.PollIDs. Неверные ID и опросы,
+// которым не хватило места в gauges, пропускаются с предупреждением.
+func NewPollTracker(polls repository.PollRepository, gauges *metrics.PollGauges, opts TrackOptions, logger zerolog.Logger) *PollTracker {
+	t := &PollTracker{
+		polls:    polls,
+		gauges:   gauges,
+		logger:   logger,
+		now:      time.Now,
+		interval: defaultTrackSyncInterval,
+		grace:    defaultTrackGrace,
+		closed:   make(map[string]time.Time),
+	}
+	if opts.SyncInterval > 0 {
+		t.interval = opts.SyncInterval
+	}
+	if opts.Grace > 0 {
+		t.grace = opts.Grace
+	}
+	for _, raw := range opts.PollIDs {
+		pollID, err := normalizePollID(raw)
+		if err != nil {
+			logger.Warn().Str("poll_id", raw).Msg("Неверный ID отслеживаемого опроса")
+			continue
+		}
+		// Пустые ряды резервируют место, пока опрос не прочитан
+		if !gauges.Set(pollID, nil) {
+			logger.Warn().Str("poll_id", pollID).Msg("Превышено число отслеживаемых опросов, опрос пропущен")
+			continue
+		}
+		t.closed[pollID] = time.Time{}
+	}
+	return t
+}
+
+// Observe обновляет метрики опроса по его прочитанному или только что
+// записанному состоянию. Закрытый опрос отсчитывает Grace с первого раза,
+// когда его увидел Observe.
+func (t *PollTracker) Observe(poll models.Poll) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	closedAt, ok := t.closed[poll.ID]
+	if !ok {
+		return
+	}
+	if poll.Closed && closedAt.IsZero() {
+		t.closed[poll.ID] = t.now()
+	}
+	t.gauges.Set(poll.ID, poll.Options)
+}
+
+// Sync сверяет метрики с хранилищем и убирает метрики опросов, которые
+// удалены или закрыты дольше Grace: такие опросы больше не отслеживаются.
+// Ошибка чтения только логируется: метрики обновит следующая сверка.
+func (t *PollTracker) Sync(ctx context.Context) {
+	t.mu.Lock()
+	ids := make([]string, 0, len(t.closed))
+	for pollID := range t.closed {
+		ids = append(ids, pollID)
+	}
+	t.mu.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	// Все опросы читаются одним запросом; итоги - по счётчикам, карты
+	// голосов GetPolls не читает
+	polls, err := t.polls.GetPolls(ctx, ids)
+	if err != nil {
+		t.logger.Warn().Err(err).Int("polls", len(ids)).Msg("Не удалось обновить метрики отслеживаемых опросов")
+		return
+	}
+	for _, pollID := range ids {
+		poll, ok := polls[pollID]
+		if !ok {
+			t.forget(pollID)
+			continue
+		}
+		t.Observe(poll)
+		t.expire(pollID)
+	}
+}
+
+// expire перестаёт отслеживать опрос, закрытый дольше Grace.
+func (t *PollTracker) expire(pollID string) {
+	t.mu.Lock()
+	closedAt, ok := t.closed[pollID]
+	t.mu.Unlock()
+	if ok && !closedAt.IsZero() && t.now().Sub(closedAt) >= t.grace {
+		t.forget(pollID)
+	}
+}
+
+func (t *PollTracker) forget(pollID string) {
+	t.mu.Lock()
+	delete(t.closed, pollID)
+	t.mu.Unlock()
+	t.gauges.Delete(pollID)
+	t.logger.Info().Str("poll_id", pollID).Msg("Метрики опроса больше не отдаются")
+}
+
+// Run сверяет метрики сразу и затем раз в TrackOptions.SyncInterval, пока
+// не отменён ctx.
+func (t *PollTracker) Run(ctx context.Context) {
+	t.Sync(ctx)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Sync(ctx)
+		}
+	}
+}
+
+// SetPollTracker обновляет метрики отслеживаемых опросов после голоса и
+// закрытия опроса, не дожидаясь сверки.
+func (s *PollServiceImpl) SetPollTracker(tracker *PollTracker) {
+	s.tracker = tracker
+}
+
+func (s *PollServiceImpl) observe(poll models.Poll) {
+	if s.tracker != nil {
+		s.tracker.Observe(poll)
+	}
+}
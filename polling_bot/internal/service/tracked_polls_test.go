@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет, что голос в отслеживаемом опросе сразу меняет его
+// метрики, голос в другом опросе в метрики не попадает, а голос, записанный
+// другим экземпляром бота, появляется после сверки
+func TestPollTracker_UpdatesOnVote(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryPollRepo()
+	votes := repository.NewMemoryVoteRepo(repo)
+	s := NewPollService(repo, votes, zerolog.Nop())
+	tracked, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, CreateOptions{})
+	require.NoError(t, err)
+	other, err := s.CreatePollWithID(ctx, "creator1", "Когда?", []string{"Утром", "Вечером"}, CreateOptions{})
+	require.NoError(t, err)
+
+	gauges := metrics.NewPollGauges(10)
+	tracker := NewPollTracker(repo, gauges, TrackOptions{PollIDs: []string{tracked.ID}}, zerolog.Nop())
+	s.SetPollTracker(tracker)
+	assert.Equal(t, map[string]map[string]int{tracked.ID: {}}, gauges.Snapshot(), "до сверки опрос без рядов")
+
+	_, err = s.AddVote(ctx, "user1", tracked.ID, []string{"Пицца"})
+	require.NoError(t, err)
+	_, err = s.AddVote(ctx, "user1", other.ID, []string{"Утром"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]int{tracked.ID: {"Пицца": 1, "Суши": 0}}, gauges.Snapshot())
+
+	// Другой экземпляр бота пишет в то же хранилище, но не в эти метрики
+	elsewhere := NewPollService(repo, votes, zerolog.Nop())
+	_, err = elsewhere.AddVote(ctx, "user2", tracked.ID, []string{"Суши"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, gauges.Snapshot()[tracked.ID]["Суши"])
+	tracker.Sync(ctx)
+	assert.Equal(t, map[string]map[string]int{tracked.ID: {"Пицца": 1, "Суши": 1}}, gauges.Snapshot())
+}
+
+// Тест проверяет, что метрики закрытого опроса убираются после Grace, а
+// удалённого - при первой сверке
+func TestPollTracker_CleanupAfterClose(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	closed, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, CreateOptions{})
+	require.NoError(t, err)
+	deleted, err := s.CreatePollWithID(ctx, "creator1", "Когда?", []string{"Утром", "Вечером"}, CreateOptions{})
+	require.NoError(t, err)
+
+	gauges := metrics.NewPollGauges(10)
+	tracker := NewPollTracker(repo, gauges, TrackOptions{PollIDs: []string{closed.ID, deleted.ID}, Grace: time.Hour}, zerolog.Nop())
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+	s.SetPollTracker(tracker)
+
+	_, err = s.AddVote(ctx, "user1", closed.ID, []string{"Пицца"})
+	require.NoError(t, err)
+	_, err = s.EndPoll(ctx, "creator1", closed.ID)
+	require.NoError(t, err)
+	_, err = s.DeletePoll(ctx, "creator1", deleted.ID)
+	require.NoError(t, err)
+
+	now = now.Add(59 * time.Minute)
+	tracker.Sync(ctx)
+	assert.Equal(t, map[string]map[string]int{closed.ID: {"Пицца": 1, "Суши": 0}}, gauges.Snapshot(),
+		"удалённый опрос убран, закрытый ещё отдаётся")
+
+	now = now.Add(time.Minute)
+	tracker.Sync(ctx)
+	assert.Empty(t, gauges.Snapshot())
+}
+
+// bulkReadRepo считает чтения GetPolls; err - ошибка следующего чтения
+type bulkReadRepo struct {
+	repository.PollRepository
+	reads int
+	err   error
+}
+
+func (r *bulkReadRepo) GetPolls(ctx context.Context, ids []string) (map[string]models.Poll, error) {
+	r.reads++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.PollRepository.GetPolls(ctx, ids)
+}
+
+// Тест проверяет, что сверка читает все отслеживаемые опросы одним
+// запросом, а при ошибке чтения метрики остаются прежними
+func TestPollTracker_SyncReadsOnce(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	memory := repository.NewMemoryPollRepo()
+	repo := &bulkReadRepo{PollRepository: memory}
+	s := NewPollService(memory, repository.NewMemoryVoteRepo(memory), zerolog.Nop())
+	var ids []string
+	for i := 0; i < 3; i++ {
+		created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, CreateOptions{})
+		require.NoError(t, err)
+		ids = append(ids, created.ID)
+	}
+
+	gauges := metrics.NewPollGauges(10)
+	tracker := NewPollTracker(repo, gauges, TrackOptions{PollIDs: ids}, zerolog.Nop())
+	tracker.Sync(ctx)
+	assert.Equal(t, 1, repo.reads)
+	assert.Len(t, gauges.Snapshot(), 3)
+
+	repo.err = errors.New("tarantool недоступен")
+	tracker.Sync(ctx)
+	assert.Equal(t, 2, repo.reads)
+	assert.Len(t, gauges.Snapshot(), 3, "опросы не забыты из-за ошибки чтения")
+}
+
+// Тест проверяет, что неверные ID и опросы сверх ограничения метрик не
+// отслеживаются
+func TestPollTracker_Limit(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	gauges := metrics.NewPollGauges(1)
+	first, second := "9b2f3c1e-1d2a-4c5b-8e6f-7a8b9c0d1e2f", "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	NewPollTracker(repo, gauges, TrackOptions{PollIDs: []string{"not-a-poll", " " + first + " ", second}}, zerolog.Nop())
+
+	assert.Equal(t, map[string]map[string]int{first: {}}, gauges.Snapshot())
+}
+
+// Тест проверяет, что сверка останавливается с отменой контекста
+func TestPollTracker_RunStops(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	tracker := NewPollTracker(repo, metrics.NewPollGauges(1), TrackOptions{SyncInterval: time.Millisecond}, zerolog.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tracker.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run не остановился после отмены контекста")
+	}
+}
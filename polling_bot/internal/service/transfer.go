@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+)
+
+// User - пользователь Mattermost, найденный по имени.
+type User struct {
+	ID       string
+	Username string
+	IsBot    bool
+}
+
+// UserFinder ищет пользователя Mattermost по имени; found == false,
+// если такого пользователя нет.
+type UserFinder interface {
+	FindUser(ctx context.Context, username string) (user User, found bool, err error)
+}
+
+// DirectMessenger отправляет пользователю личное сообщение от бота.
+type DirectMessenger interface {
+	SendDirect(ctx context.Context, userID, message string) error
+}
+
+// SetUserFinder включает команду transfer: без него новому владельцу
+// не по чему найти пользователя.
+func (s *PollServiceImpl) SetUserFinder(finder UserFinder) {
+	s.finder = finder
+}
+
+// SetDirectMessenger задаёт, как уведомлять нового владельца опроса.
+func (s *PollServiceImpl) SetDirectMessenger(messenger DirectMessenger) {
+	s.messenger = messenger
+}
+
+// TransferPoll передаёт открытый опрос пользователю username (с "@" или без).
+// В опросе, ограниченном каналом, новый владелец должен состоять в канале.
+func (s *PollServiceImpl) TransferPoll(ctx context.Context, userID, pollID, username string) (string, error) {
+	if s.finder == nil {
+		return "", i18n.NewError(i18n.TransferUnavailable)
+	}
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpGetPoll)
+	}
+	if poll.Creator != userID {
+		return "", i18n.NewError(i18n.OnlyCreatorTransfer)
+	}
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return "", err
+	}
+	if poll.Closed {
+		return "", i18n.NewError(i18n.TransferPollClosed)
+	}
+
+	mention := "@" + strings.TrimPrefix(username, "@")
+	user, found, err := s.finder.FindUser(ctx, strings.TrimPrefix(username, "@"))
+	if err != nil {
+		return "", i18n.Wrap(err, i18n.OpFindUser)
+	}
+	if !found {
+		return "", i18n.NewError(i18n.TransferUserNotFound, mention)
+	}
+	if user.ID == userID {
+		return "", i18n.NewError(i18n.TransferToSelf)
+	}
+	if user.IsBot {
+		return "", i18n.NewError(i18n.TransferToBot)
+	}
+	if poll.RestrictToChannel && poll.ChannelID != "" && s.members != nil {
+		member, err := s.members.IsChannelMember(ctx, poll.ChannelID, user.ID)
+		if err != nil {
+			return "", i18n.Wrap(err, i18n.OpFindUser)
+		}
+		if !member {
+			return "", i18n.NewError(i18n.TransferNotMember, mention)
+		}
+	}
+
+	if err := s.repo.SetCreator(ctx, pollID, user.ID); err != nil {
+		return "", s.storageError(err, i18n.OpTransferPoll)
+	}
+	s.record(ctx, pollID, userID, audit.ActionTransferred, user.ID)
+
+	loc := i18n.FromContext(ctx)
+	if s.messenger != nil {
+		notice := loc.T(i18n.TransferNotice, s.username(ctx, userID), poll.ID, poll.Question)
+		if err := s.messenger.SendDirect(ctx, user.ID, notice); err != nil {
+			s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось уведомить нового владельца опроса")
+		}
+	}
+	return loc.T(i18n.PollTransferred, poll.ID, mention), nil
+}
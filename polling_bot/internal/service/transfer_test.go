@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+type stubUserFinder map[string]User
+
+func (f stubUserFinder) FindUser(ctx context.Context, username string) (User, bool, error) {
+	user, ok := f[username]
+	return user, ok, nil
+}
+
+type stubMembers map[string]bool
+
+func (m stubMembers) IsChannelMember(ctx context.Context, channelID, userID string) (bool, error) {
+	return m[channelID+"/"+userID], nil
+}
+
+type recordingMessenger struct {
+	sent map[string]string
+}
+
+func (m *recordingMessenger) SendDirect(ctx context.Context, userID, message string) error {
+	m.sent[userID] = message
+	return nil
+}
+
+const transferPollID = "123e4567-e89b-12d3-a456-426614174000"
+
+// Тест проверяет передачу опроса другому пользователю
+func TestTransferPoll(t *testing.T) {
+	users := stubUserFinder{
+		"ivan":   {ID: "u2", Username: "ivan"},
+		"olga":   {ID: "u3", Username: "olga"},
+		"me":     {ID: "creator1", Username: "me"},
+		"helper": {ID: "b1", Username: "helper", IsBot: true},
+	}
+
+	tests := []struct {
+		name     string
+		mutate   func(*models.Poll)
+		userID   string
+		username string
+		want     string
+		wantErr  string
+	}{
+		{name: "to a user", userID: "creator1", username: "@ivan", want: "Опрос " + transferPollID + " передан пользователю @ivan"},
+		{name: "without @", userID: "creator1", username: "ivan", want: "Опрос " + transferPollID + " передан пользователю @ivan"},
+		{name: "not the creator", userID: "u2", username: "@olga", wantErr: "только создатель может передать опрос"},
+		{name: "closed poll", mutate: func(p *models.Poll) { p.Closed = true }, userID: "creator1", username: "@ivan", wantErr: "завершённый опрос передать нельзя"},
+		{name: "to oneself", userID: "creator1", username: "@me", wantErr: "вы уже создатель этого опроса"},
+		{name: "to a bot", userID: "creator1", username: "@helper", wantErr: "нельзя передать опрос боту"},
+		{name: "unknown user", userID: "creator1", username: "@nobody", wantErr: "пользователь @nobody не найден"},
+		{name: "channel member", mutate: func(p *models.Poll) { p.RestrictToChannel = true }, userID: "creator1", username: "@ivan", want: "передан пользователю @ivan"},
+		{name: "not a channel member", mutate: func(p *models.Poll) { p.RestrictToChannel = true }, userID: "creator1", username: "@olga", wantErr: "@olga не состоит в канале опроса"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := models.Poll{
+				ID:        transferPollID,
+				Creator:   "creator1",
+				Question:  "Где обедаем?",
+				Options:   map[string]int{"Пицца": 0},
+				ChannelID: "c1",
+			}
+			if tt.mutate != nil {
+				tt.mutate(&poll)
+			}
+			repo := repository.NewMemoryPollRepo()
+			require.NoError(t, repo.SavePoll(context.Background(), poll))
+			messenger := &recordingMessenger{sent: map[string]string{}}
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.SetUserFinder(users)
+			s.SetDirectMessenger(messenger)
+			s.SetChannelMembers(stubMembers{"c1/u2": true})
+			s.SetUserNames(stubUserNames{"creator1": "anna"})
+
+			result, err := s.TransferPoll(context.Background(), tt.userID, transferPollID, tt.username)
+			stored, getErr := repo.GetPoll(context.Background(), transferPollID)
+			require.NoError(t, getErr)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, "creator1", stored.Creator)
+				assert.Empty(t, messenger.sent)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, result, tt.want)
+			assert.Equal(t, "u2", stored.Creator)
+			assert.Equal(t, "@anna передал(а) вам опрос `"+transferPollID+"`: Где обедаем?\nТеперь вы можете завершить его командой end",
+				messenger.sent["u2"])
+
+			// Прежний создатель больше не управляет опросом
+			_, err = s.EndPoll(context.Background(), "creator1", transferPollID)
+			assert.EqualError(t, err, "только создатель может завершить опрос")
+		})
+	}
+}
+
+// Тест проверяет ответ transfer, когда поиск пользователей не настроен
+func TestTransferPoll_Unavailable(t *testing.T) {
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+
+	_, err := s.TransferPoll(context.Background(), "creator1", transferPollID, "@ivan")
+	assert.EqualError(t, err, "передача опросов не настроена")
+}
+
+// Тест проверяет, что повтор команды create после передачи опроса не
+// перезаписывает опрос и не обнуляет его голоса
+func TestCreatePoll_RedeliveredAfterTransfer(t *testing.T) {
+	ctx := WithOrigin(context.Background(), Origin{PostID: "post1", ChannelID: "c1"})
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	s.SetUserFinder(stubUserFinder{"ivan": {ID: "u2", Username: "ivan"}})
+
+	created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, CreateOptions{})
+	require.NoError(t, err)
+	_, err = s.AddVote(context.Background(), "u5", created.ID, []string{"Пицца"})
+	require.NoError(t, err)
+	_, err = s.TransferPoll(context.Background(), "creator1", created.ID, "@ivan")
+	require.NoError(t, err)
+
+	again, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, CreateOptions{})
+	require.NoError(t, err)
+	assert.True(t, again.Duplicate)
+	assert.Equal(t, created.ID, again.ID)
+	stored, err := repo.GetPoll(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "u2", stored.Creator)
+	assert.Equal(t, 1, stored.Options["Пицца"])
+}
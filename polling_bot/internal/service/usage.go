@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// За сколько дней показывать статистику без аргумента и не больше
+	// скольких дней
+	defaultUsageDays = 7
+	maxUsageDays     = 90
+	// Сколько команд дня показывать в колонке самых частых
+	usageTopCommands = 3
+	// Как часто записывать накопленные счётчики по умолчанию
+	defaultUsageFlushInterval = time.Minute
+	// Сколько ждать последней записи при остановке бота
+	usageFinalFlushTimeout = 5 * time.Second
+)
+
+type UsageService interface {
+	// Usage - таблица выполненных команд по дням за последние days дней,
+	// включая сегодняшний.
+	Usage(ctx context.Context, days int) (string, error)
+}
+
+type usageKey struct {
+	day     time.Time
+	command string
+}
+
+// UsageServiceImpl считает выполненные команды в памяти и пачками
+// записывает счётчики в хранилище: Record не ждёт хранилища, поэтому учёт
+// не замедляет ответы пользователям.
+type UsageServiceImpl struct {
+	repo     repository.UsageRepository
+	logger   zerolog.Logger
+	now      func() time.Time
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[usageKey]int
+}
+
+func NewUsageService(repo repository.UsageRepository, logger zerolog.Logger) *UsageServiceImpl {
+	return &UsageServiceImpl{
+		repo:     repo,
+		logger:   logger,
+		now:      time.Now,
+		interval: defaultUsageFlushInterval,
+		pending:  make(map[usageKey]int),
+	}
+}
+
+// SetFlushInterval задаёт, как часто Run записывает накопленные счётчики.
+func (s *UsageServiceImpl) SetFlushInterval(interval time.Duration) {
+	if interval > 0 {
+		s.interval = interval
+	}
+}
+
+// Record учитывает выполненную команду в текущем дне по UTC.
+func (s *UsageServiceImpl) Record(command string) {
+	key := usageKey{day: usageDay(s.now()), command: command}
+	s.mu.Lock()
+	s.pending[key]++
+	s.mu.Unlock()
+}
+
+// Flush записывает накопленные счётчики одной пачкой. Если запись не
+// удалась, счётчики возвращаются в память и уходят со следующей пачкой.
+func (s *UsageServiceImpl) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[usageKey]int)
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	usage := make([]models.CommandUsage, 0, len(pending))
+	for key, count := range pending {
+		usage = append(usage, models.CommandUsage{Day: key.day, Command: key.command, Count: count})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if !usage[i].Day.Equal(usage[j].Day) {
+			return usage[i].Day.Before(usage[j].Day)
+		}
+		return usage[i].Command < usage[j].Command
+	})
+	if err := s.repo.AddUsage(ctx, usage); err != nil {
+		s.mu.Lock()
+		for key, count := range pending {
+			s.pending[key] += count
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run записывает счётчики раз в интервал SetFlushInterval, пока не отменён
+// ctx, а после отмены - последний раз, чтобы при остановке бота не
+// потерять накопленное.
+func (s *UsageServiceImpl) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageFinalFlushTimeout)
+			defer cancel()
+			if err := s.Flush(flushCtx); err != nil {
+				s.logger.Error().Err(err).Msg("Статистика команд не записана при остановке")
+			}
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.logger.Warn().Err(err).Msg("Не удалось записать статистику команд")
+			}
+		}
+	}
+}
+
+func (s *UsageServiceImpl) Usage(ctx context.Context, days int) (string, error) {
+	if days <= 0 {
+		days = defaultUsageDays
+	}
+	if days > maxUsageDays {
+		days = maxUsageDays
+	}
+
+	today := usageDay(s.now())
+	usage, err := s.repo.ListUsage(ctx, today.AddDate(0, 0, 1-days))
+	if err != nil {
+		if errors.Is(err, repository.ErrUnavailable) {
+			s.logger.Error().Err(err).Str("operation", string(i18n.OpListUsage)).Msg("Хранилище недоступно")
+			return "", ErrServiceUnavailable
+		}
+		return "", i18n.Wrap(err, i18n.OpListUsage)
+	}
+	return renderUsage(i18n.FromContext(ctx), usage, today, days), nil
+}
+
+// usageDay - начало дня t по UTC: дни статистики одинаковы для всех
+// экземпляров бота, где бы они ни работали.
+func usageDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// renderUsage - таблица команд за days дней до today включительно, от
+// новых дней к старым. Дни без команд тоже показываются, чтобы перерывы
+// были видны.
+func renderUsage(loc *i18n.Localizer, usage []models.CommandUsage, today time.Time, days int) string {
+	if len(usage) == 0 {
+		return loc.T(i18n.UsageEmpty, days)
+	}
+
+	byDay := make(map[time.Time]map[string]int)
+	overall := make(map[string]int)
+	for _, u := range usage {
+		if byDay[u.Day] == nil {
+			byDay[u.Day] = make(map[string]int)
+		}
+		byDay[u.Day][u.Command] += u.Count
+		overall[u.Command] += u.Count
+	}
+
+	var sb strings.Builder
+	sb.WriteString(loc.T(i18n.UsageHeader, days) + "\n")
+	writeTableRow(&sb, []string{loc.T(i18n.UsageTableDay), loc.T(i18n.UsageTableCommands), loc.T(i18n.UsageTableTop)})
+	writeTableRow(&sb, []string{":--", "--:", ":--"})
+	for i := 0; i < days; i++ {
+		day := today.AddDate(0, 0, -i)
+		total, top := usageTop(byDay[day])
+		writeTableRow(&sb, []string{day.Format("02.01.2006"), fmt.Sprint(total), top})
+	}
+	total, top := usageTop(overall)
+	writeTableRow(&sb, []string{"**" + loc.T(i18n.ResultsTableTotal) + "**", fmt.Sprintf("**%d**", total), top})
+	return sb.String()
+}
+
+// usageTop - сумма счётчиков и самые частые команды с числом выполнений;
+// при равенстве команды идут по алфавиту.
+func usageTop(counts map[string]int) (int, string) {
+	total := 0
+	commands := make([]string, 0, len(counts))
+	for command, count := range counts {
+		total += count
+		commands = append(commands, command)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		if counts[commands[i]] != counts[commands[j]] {
+			return counts[commands[i]] > counts[commands[j]]
+		}
+		return commands[i] < commands[j]
+	})
+	if len(commands) > usageTopCommands {
+		commands = commands[:usageTopCommands]
+	}
+	top := make([]string, 0, len(commands))
+	for _, command := range commands {
+		top = append(top, fmt.Sprintf("%s %d", tableCellEscaper.Replace(command), counts[command]))
+	}
+	return total, strings.Join(top, ", ")
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// slowUsageRepo - хранилище статистики, запись в которое ждёт gate, если
+// он задан, и возвращает err, пока тот не сброшен.
+type slowUsageRepo struct {
+	*repository.MemoryUsageRepo
+	gate    chan struct{}
+	started chan struct{}
+	err     error
+}
+
+func (r *slowUsageRepo) AddUsage(ctx context.Context, usage []models.CommandUsage) error {
+	if r.gate != nil {
+		r.started <- struct{}{}
+		<-r.gate
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return r.MemoryUsageRepo.AddUsage(ctx, usage)
+}
+
+var usageMonday = time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+// stored - записанные в хранилище счётчики с начала usageMonday.
+func stored(t *testing.T, repo repository.UsageRepository) []models.CommandUsage {
+	t.Helper()
+	usage, err := repo.ListUsage(context.Background(), usageMonday)
+	require.NoError(t, err)
+	return usage
+}
+
+// Тест проверяет, что Record не ждёт медленного хранилища, а команды,
+// учтённые во время записи, уходят следующей пачкой
+func TestUsageService_RecordDoesNotWait(t *testing.T) {
+	repo := &slowUsageRepo{
+		MemoryUsageRepo: repository.NewMemoryUsageRepo(),
+		gate:            make(chan struct{}),
+		started:         make(chan struct{}, 10),
+	}
+	s := NewUsageService(repo, zerolog.Nop())
+	s.now = func() time.Time { return usageMonday.Add(9 * time.Hour) }
+	s.SetFlushInterval(time.Millisecond)
+
+	s.Record("vote")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	<-repo.started
+
+	recorded := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			s.Record("vote")
+		}
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(time.Second):
+		t.Fatal("Record ждёт записи в хранилище")
+	}
+
+	close(repo.gate)
+	cancel()
+	<-done
+	assert.Equal(t, []models.CommandUsage{{Day: usageMonday, Command: "vote", Count: 101}}, stored(t, repo))
+}
+
+// Тест проверяет, что при остановке накопленное записывается, хотя
+// контекст Run уже отменён, а до остановки - по таймеру
+func TestUsageService_FlushOnShutdown(t *testing.T) {
+	repo := repository.NewMemoryUsageRepo()
+	s := NewUsageService(repo, zerolog.Nop())
+	s.now = func() time.Time { return usageMonday.Add(9 * time.Hour) }
+	s.SetFlushInterval(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	s.Record("create")
+	s.Record("vote")
+	s.Record("vote")
+	assert.Empty(t, stored(t, repo), "до таймера и остановки ничего не записано")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run не завершился после отмены контекста")
+	}
+	assert.Equal(t, []models.CommandUsage{
+		{Day: usageMonday, Command: "create", Count: 1},
+		{Day: usageMonday, Command: "vote", Count: 2},
+	}, stored(t, repo))
+}
+
+// Тест проверяет, что счётчики неудачной записи не теряются и не
+// удваиваются, а команды учитываются в своём дне по UTC
+func TestUsageService_Flush(t *testing.T) {
+	repo := &slowUsageRepo{MemoryUsageRepo: repository.NewMemoryUsageRepo(), err: errors.New("хранилище недоступно")}
+	s := NewUsageService(repo, zerolog.Nop())
+	now := usageMonday.Add(23*time.Hour + 59*time.Minute)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, s.Flush(ctx), "пустая пачка не пишется")
+	s.Record("vote")
+	assert.Error(t, s.Flush(ctx))
+
+	repo.err = nil
+	// 00:29 по Москве - ещё понедельник по UTC
+	now = now.In(time.FixedZone("MSK", 3*60*60))
+	s.Record("vote")
+	now = now.Add(2 * time.Minute)
+	s.Record("vote")
+	require.NoError(t, s.Flush(ctx))
+	require.NoError(t, s.Flush(ctx))
+
+	assert.Equal(t, []models.CommandUsage{
+		{Day: usageMonday, Command: "vote", Count: 2},
+		{Day: usageMonday.AddDate(0, 0, 1), Command: "vote", Count: 1},
+	}, stored(t, repo))
+}
+
+// Тест проверяет таблицу usage: дни от новых к старым, включая дни без
+// команд, не больше трёх частых команд и итог за весь срок
+func TestUsageService_Render(t *testing.T) {
+	repo := repository.NewMemoryUsageRepo()
+	require.NoError(t, repo.AddUsage(context.Background(), []models.CommandUsage{
+		// За пределами срока
+		{Day: usageMonday.AddDate(0, 0, -2), Command: "vote", Count: 50},
+		{Day: usageMonday, Command: "vote", Count: 12},
+		{Day: usageMonday, Command: "results", Count: 5},
+		{Day: usageMonday, Command: "create", Count: 2},
+		{Day: usageMonday, Command: "list", Count: 2},
+		{Day: usageMonday, Command: "unknown", Count: 1},
+		{Day: usageMonday.AddDate(0, 0, 2), Command: "create", Count: 1},
+	}))
+	s := NewUsageService(repo, zerolog.Nop())
+	s.now = func() time.Time { return usageMonday.AddDate(0, 0, 2).Add(15 * time.Hour) }
+
+	forEachLang(t, "usage", func(loc *i18n.Localizer) string {
+		got, err := s.Usage(i18n.WithLocalizer(context.Background(), loc), 3)
+		require.NoError(t, err)
+		return got
+	})
+}
+
+// Тест проверяет число дней по умолчанию и предел, а также ответ, когда
+// команд за срок не было
+func TestUsageService_Days(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryUsageRepo()
+	s := NewUsageService(repo, zerolog.Nop())
+	s.now = func() time.Time { return usageMonday }
+
+	tests := []struct {
+		name string
+		days int
+		want string
+	}{
+		{name: "default", days: 0, want: "За последние 7 дн. команд не было"},
+		{name: "clamped", days: 1000, want: "За последние 90 дн. команд не было"},
+		{name: "given", days: 30, want: "За последние 30 дн. команд не было"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Usage(ctx, tt.days)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Счётчик ровно на границе срока попадает в таблицу
+	require.NoError(t, repo.AddUsage(context.Background(), []models.CommandUsage{
+		{Day: usageMonday.AddDate(0, 0, -6), Command: "vote", Count: 1},
+	}))
+	got, err := s.Usage(ctx, 0)
+	require.NoError(t, err)
+	assert.Contains(t, got, "| 25.02.2025 | 1 | vote 1 |")
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+const (
+	// Сколько не учитывать повторный просмотр итогов одним участником
+	viewDebounce = time.Hour
+	// С какого размера память просмотров очищается от устаревших записей
+	viewCachePrune = 1024
+)
+
+// viewCache помнит, когда участник последний раз учтённо смотрел итоги
+// опроса. Хранится в памяти бота: после перезапуска участник может быть
+// учтён ещё раз раньше, чем через час.
+type viewCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// record сообщает, учитывать ли просмотр в now, и если да - запоминает
+// его. Просмотры внутри viewDebounce от учтённого не учитываются и срок
+// не продлевают: участник, который обновляет итоги, учитывается раз в час.
+func (c *viewCache) record(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.seen[key]; ok && now.Sub(last) < viewDebounce {
+		return false
+	}
+	if c.seen == nil {
+		c.seen = make(map[string]time.Time)
+	}
+	if len(c.seen) >= viewCachePrune {
+		for k, last := range c.seen {
+			if now.Sub(last) >= viewDebounce {
+				delete(c.seen, k)
+			}
+		}
+	}
+	c.seen[key] = now
+	return true
+}
+
+// countView учитывает просмотр итогов опроса участником. Анонимные опросы
+// учитываются так же: ID участника остаётся в памяти бота и в хранилище
+// не попадает. Ошибка записи только логируется - итоги важнее счётчика.
+func (s *PollServiceImpl) countView(ctx context.Context, poll models.Poll, userID string) {
+	if !s.viewCache.record(memberKey(poll.ID, userID), s.now()) {
+		return
+	}
+	if err := s.repo.IncrementViews(ctx, poll.ID); err != nil {
+		s.logger.Warn().Err(err).Str("poll_id", poll.ID).Msg("Не удалось учесть просмотр опроса")
+	}
+}
+
+// PollStats показывает создателю, сколько раз смотрели итоги опроса и
+// какая доля смотревших проголосовала. Голосовать можно и не глядя на
+// итоги, поэтому конверсия может превысить 100%.
+func (s *PollServiceImpl) PollStats(ctx context.Context, userID, pollID string) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpGetPoll)
+	}
+	if poll.Creator != userID {
+		return "", i18n.NewError(i18n.OnlyCreatorStats)
+	}
+
+	voters := poll.VoterCount()
+	return i18n.FromContext(ctx).T(i18n.PollStats, poll.ID, poll.Views, voters, percent(voters, poll.Views)), nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+// Тест проверяет, что повторный просмотр участника учитывается не раньше
+// чем через час после учтённого, а просмотры разных участников и опросов
+// учитываются независимо
+func TestViewCache(t *testing.T) {
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	var c viewCache
+
+	assert.True(t, c.record("p1/u1", start))
+	assert.False(t, c.record("p1/u1", start.Add(30*time.Minute)))
+	assert.True(t, c.record("p1/u2", start.Add(30*time.Minute)))
+	assert.True(t, c.record("p2/u1", start.Add(30*time.Minute)))
+	// Неучтённый просмотр срок не продлевает
+	assert.False(t, c.record("p1/u1", start.Add(59*time.Minute)))
+	assert.True(t, c.record("p1/u1", start.Add(time.Hour)))
+	assert.False(t, c.record("p1/u1", start.Add(90*time.Minute)))
+}
+
+// Тест проверяет, что память просмотров очищается от устаревших записей и
+// не забывает свежие
+func TestViewCache_Prune(t *testing.T) {
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	var c viewCache
+	for i := 0; i < viewCachePrune; i++ {
+		c.record(memberKey("p1", fmt.Sprintf("u%d", i)), start)
+	}
+	c.record("fresh", start.Add(90*time.Minute))
+
+	later := start.Add(2 * time.Hour)
+	assert.True(t, c.record("overflow", later))
+	assert.Len(t, c.seen, 2)
+	assert.False(t, c.record("fresh", later))
+}
+
+// Тест проверяет учёт просмотров командами results: обновление итогов
+// одним участником учитывается раз в час, анонимный опрос - так же
+func TestResults_CountsViews(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	repo := repository.NewMemoryPollRepo()
+	s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	s.SetAnonymousSecret("bot-secret")
+
+	for _, opts := range []CreateOptions{{}, {Anonymous: true}} {
+		created, err := s.CreatePollWithID(ctx, "creator1", "Где обедаем?", []string{"Пицца", "Суши"}, opts)
+		require.NoError(t, err)
+		start := now
+
+		_, err = s.GetResults(ctx, "user1", created.ID)
+		require.NoError(t, err)
+		_, err = s.GetResults(ctx, "user1", created.ID)
+		require.NoError(t, err)
+		_, _, err = s.ResultsSince(ctx, "user1", created.ID, time.Hour)
+		require.NoError(t, err)
+		_, err = s.GetResults(ctx, "user2", created.ID)
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		_, err = s.GetResults(ctx, "user1", created.ID)
+		require.NoError(t, err)
+		now = start
+
+		poll, err := repo.GetPoll(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, poll.Views, "anonymous: %v", opts.Anonymous)
+	}
+}
+
+// Тест проверяет статистику опроса: конверсию просмотров в голоса и
+// доступ только создателю
+func TestPollStats(t *testing.T) {
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("ru"))
+	const pollID = "123e4567-e89b-12d3-a456-426614174000"
+
+	tests := []struct {
+		name    string
+		views   int
+		options map[string]int
+		abstain int
+		want    string
+	}{
+		{name: "no views", options: map[string]int{"Да": 0}, want: "Опрос " + pollID + ": просмотров: 0, проголосовало: 0 (конверсия 0%)"},
+		{name: "rounded", views: 3, options: map[string]int{"Да": 1, "Нет": 1},
+			want: "Опрос " + pollID + ": просмотров: 3, проголосовало: 2 (конверсия 67%)"},
+		{name: "abstained count as voters", views: 8, options: map[string]int{"Да": 1}, abstain: 1,
+			want: "Опрос " + pollID + ": просмотров: 8, проголосовало: 2 (конверсия 25%)"},
+		{name: "voted without viewing", views: 2, options: map[string]int{"Да": 5},
+			want: "Опрос " + pollID + ": просмотров: 2, проголосовало: 5 (конверсия 250%)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			require.NoError(t, repo.SavePoll(ctx, models.Poll{
+				ID: pollID, Creator: "creator1", Question: "Q", Options: tt.options,
+				AllowAbstain: tt.abstain > 0, Abstained: tt.abstain, Views: tt.views,
+			}))
+
+			got, err := s.PollStats(ctx, "creator1", pollID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			_, err = s.PollStats(ctx, "user1", pollID)
+			assert.EqualError(t, err, "только создатель может смотреть статистику опроса")
+		})
+	}
+}
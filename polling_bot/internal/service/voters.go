@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// validateVoters проверяет кворум опроса со списком участников: кворум,
+// больший списка, опрос не набрал бы никогда. До поиска пользователей
+// список - имена из --voters, после - ID без повторов: два имени одного
+// пользователя дают одного участника.
+func validateVoters(voters []string, quorum int) error {
+	if len(voters) > 0 && quorum > len(voters) {
+		return i18n.NewError(i18n.VotersQuorum, quorum, len(voters))
+	}
+	return nil
+}
+
+// resolveVoters переводит имена участников в ID, под которыми они
+// голосуют. Ошибка называет всех ненайденных пользователей сразу, чтобы
+// создатель исправил список за одну попытку. Пустой список - nil.
+func (s *PollServiceImpl) resolveVoters(ctx context.Context, usernames []string) ([]string, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	if s.finder == nil {
+		return nil, i18n.NewError(i18n.VotersUnavailable)
+	}
+	ids := make([]string, 0, len(usernames))
+	var missing []string
+	for _, username := range usernames {
+		user, found, err := s.finder.FindUser(ctx, username)
+		if err != nil {
+			return nil, i18n.Wrap(err, i18n.OpFindUser)
+		}
+		if !found {
+			missing = append(missing, username)
+			continue
+		}
+		// Два имени одного пользователя не должны раздувать список
+		if !slices.Contains(ids, user.ID) {
+			ids = append(ids, user.ID)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, i18n.NewError(i18n.VotersNotFound, renderUsernames(missing))
+	}
+	return ids, nil
+}
+
+// checkVoter отклоняет голос участника, которого нет в списке опроса.
+func checkVoter(poll models.Poll, userID string) error {
+	if poll.Restricted() && !slices.Contains(poll.AllowedVoters, userID) {
+		return i18n.NewError(i18n.VoterNotAllowed)
+	}
+	return nil
+}
+
+// updateVoters заменяет список участников командой set. Голоса тех, кого
+// в новом списке нет, остаются учтёнными: они были отданы по правилам
+// опроса. Кворум не может оказаться больше нового списка.
+func (s *PollServiceImpl) updateVoters(ctx context.Context, userID string, poll models.Poll, usernames []string) (string, error) {
+	if poll.Anonymous() && len(usernames) > 0 {
+		return "", i18n.NewError(i18n.AnonymousVoters)
+	}
+	voters, err := s.resolveVoters(ctx, usernames)
+	if err != nil {
+		return "", err
+	}
+	if err := validateVoters(voters, poll.Quorum); err != nil {
+		return "", err
+	}
+	if err := s.repo.SetAllowedVoters(ctx, poll.ID, voters); err != nil {
+		return "", s.storageError(err, i18n.OpUpdateSettings)
+	}
+
+	loc := i18n.FromContext(ctx)
+	if len(voters) == 0 {
+		s.record(ctx, poll.ID, userID, audit.ActionSettingChanged, SettingVoters+": "+loc.T(i18n.SettingNone))
+		return loc.T(i18n.SettingVotersCleared, poll.ID), nil
+	}
+	names := renderUsernames(usernames)
+	s.record(ctx, poll.ID, userID, audit.ActionSettingChanged, SettingVoters+": "+names)
+	return loc.T(i18n.SettingVotersChanged, poll.ID, names), nil
+}
+
+// voterNames - имена участников из списка опроса для сообщения об опросе.
+func (s *PollServiceImpl) voterNames(ctx context.Context, poll models.Poll) []string {
+	names := make([]string, 0, len(poll.AllowedVoters))
+	for _, userID := range poll.AllowedVoters {
+		names = append(names, strings.TrimPrefix(s.username(ctx, userID), "@"))
+	}
+	return names
+}
+
+// renderUsernames - имена пользователей без "@" через запятую, с "@".
+func renderUsernames(usernames []string) string {
+	mentions := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		mentions = append(mentions, "@"+username)
+	}
+	return strings.Join(mentions, ", ")
+}
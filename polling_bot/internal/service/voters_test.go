@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+var committee = stubUserFinder{
+	"alice": {ID: "u-alice", Username: "alice"},
+	"bob":   {ID: "u-bob", Username: "bob"},
+	"carol": {ID: "u-carol", Username: "carol"},
+	// Второе имя той же учётной записи
+	"alice.old": {ID: "u-alice", Username: "alice"},
+}
+
+// Тест проверяет список участников при создании опроса: имена сохраняются
+// как ID, а ошибка называет всех ненайденных пользователей
+func TestCreatePoll_Voters(t *testing.T) {
+	tests := []struct {
+		name    string
+		finder  UserFinder
+		opts    CreateOptions
+		want    []string
+		wantErr string
+	}{
+		{name: "anyone can vote", finder: committee},
+		{name: "resolved by user ID", finder: committee,
+			opts: CreateOptions{Voters: []string{"alice", "bob", "carol"}},
+			want: []string{"u-alice", "u-bob", "u-carol"}},
+		{name: "same user twice", finder: committee,
+			opts: CreateOptions{Voters: []string{"alice", "alice.old", "bob"}},
+			want: []string{"u-alice", "u-bob"}},
+		{name: "quorum within the list", finder: committee,
+			opts: CreateOptions{Voters: []string{"alice", "bob"}, Quorum: 2},
+			want: []string{"u-alice", "u-bob"}},
+		{name: "unknown users", finder: committee,
+			opts:    CreateOptions{Voters: []string{"alice", "dave", "bob", "erin"}},
+			wantErr: "пользователи из --voters не найдены: @dave, @erin"},
+		{name: "quorum larger than the list", finder: committee,
+			opts:    CreateOptions{Voters: []string{"alice", "bob"}, Quorum: 3},
+			wantErr: "кворум 3 больше списка участников (2): опрос не набрал бы его"},
+		{name: "quorum larger than the unique users", finder: committee,
+			opts:    CreateOptions{Voters: []string{"alice", "alice.old", "bob"}, Quorum: 3},
+			wantErr: "кворум 3 больше списка участников (2): опрос не набрал бы его"},
+		{name: "no user finder",
+			opts:    CreateOptions{Voters: []string{"alice"}},
+			wantErr: "список участников не настроен: боту не по чему найти пользователей"},
+		{name: "user lookup fails", finder: brokenUserFinder{},
+			opts:    CreateOptions{Voters: []string{"alice"}},
+			wantErr: "ошибка поиска пользователя: mattermost недоступен"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			if tt.finder != nil {
+				s.SetUserFinder(tt.finder)
+			}
+
+			created, err := s.CreatePollWithID(ctx, "creator1", "Утверждаем бюджет?", []string{"Да", "Нет"}, tt.opts)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			stored, err := repo.GetPoll(ctx, created.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stored.AllowedVoters)
+		})
+	}
+}
+
+// Тест проверяет голосование в опросе со списком: посторонний получает
+// отказ, а итоги показывают, сколько голосов ещё ждёт опрос
+func TestAddVote_Voters(t *testing.T) {
+	tests := []struct {
+		name       string
+		quorum     int
+		wantWait   string
+		wantClosed bool
+	}{
+		{name: "whole list", wantWait: "Проголосовали 1 из 3, ждём ещё 2\n"},
+		{name: "quorum of the list", quorum: 2, wantWait: "Проголосовали 1 из 2, ждём ещё 1\n", wantClosed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			s.SetUserFinder(committee)
+
+			created, err := s.CreatePollWithID(ctx, "creator1", "Утверждаем бюджет?", []string{"Да", "Нет"},
+				CreateOptions{Voters: []string{"alice", "bob", "carol"}, Quorum: tt.quorum})
+			require.NoError(t, err)
+			assert.Contains(t, created.Message, "Голосовать могут только: @alice, @bob, @carol\n")
+
+			_, err = s.AddVote(ctx, "u-dave", created.ID, []string{"Да"})
+			assert.EqualError(t, err, "вы не входите в список участников этого опроса")
+			_, err = s.AddVote(ctx, "creator1", created.ID, []string{"Да"})
+			assert.EqualError(t, err, "вы не входите в список участников этого опроса", "создатель не из списка тоже не голосует")
+
+			_, err = s.AddVote(ctx, "u-alice", created.ID, []string{"Да"})
+			require.NoError(t, err)
+			results, err := s.GetResults(ctx, "creator1", created.ID)
+			require.NoError(t, err)
+			assert.Contains(t, results, tt.wantWait)
+
+			_, err = s.AddVote(ctx, "u-bob", created.ID, []string{"Нет"})
+			require.NoError(t, err)
+			poll, err := repo.GetPoll(ctx, created.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantClosed, poll.Closed)
+			results, err = s.GetResults(ctx, "creator1", created.ID)
+			require.NoError(t, err)
+			if tt.wantClosed {
+				assert.NotContains(t, results, "ждём ещё")
+			} else {
+				assert.Contains(t, results, "Проголосовали 2 из 3, ждём ещё 1\n")
+			}
+		})
+	}
+}
+
+// Тест проверяет, что опрос без списка не ждёт голосов
+func TestBuildResults_NoVoterList(t *testing.T) {
+	results := BuildResults(models.Poll{ID: "p1", Question: "Q", Options: map[string]int{"A": 1}, Quorum: 5}, nil)
+	assert.Zero(t, results.Expected)
+	assert.NotContains(t, RenderResults(i18n.New("ru"), results), "ждём ещё")
+}
+
+// Тест проверяет изменение списка участников командой set и кворум,
+// который не может оказаться больше списка
+func TestUpdateSetting_Voters(t *testing.T) {
+	restricted := func(p *models.Poll) {
+		p.Quorum = 0
+		p.AllowedVoters = []string{"u-alice", "u-bob"}
+	}
+	tests := []struct {
+		name       string
+		mutate     func(*models.Poll)
+		userID     string
+		change     SettingChange
+		want       string
+		wantVoters []string
+		wantDetail string
+		wantErr    string
+	}{
+		{
+			name:       "set the list",
+			userID:     "creator1",
+			change:     SettingChange{Setting: SettingVoters, Usernames: []string{"alice", "bob", "carol"}},
+			mutate:     func(p *models.Poll) { p.Quorum = 3 },
+			want:       "Голосовать в опросе " + settingsPollID + " могут только: @alice, @bob, @carol",
+			wantVoters: []string{"u-alice", "u-bob", "u-carol"},
+			wantDetail: "voters: @alice, @bob, @carol",
+		},
+		{
+			name:       "clear the list",
+			mutate:     restricted,
+			userID:     "creator1",
+			change:     SettingChange{Setting: SettingVoters},
+			want:       "Голосовать в опросе " + settingsPollID + " может любой",
+			wantDetail: "voters: нет",
+		},
+		{
+			name:    "list smaller than the quorum",
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingVoters, Usernames: []string{"alice", "bob"}},
+			wantErr: "кворум 10 больше списка участников (2): опрос не набрал бы его",
+		},
+		{
+			name:    "list of the same user smaller than the quorum",
+			mutate:  func(p *models.Poll) { p.Quorum = 2 },
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingVoters, Usernames: []string{"alice", "alice.old"}},
+			wantErr: "кворум 2 больше списка участников (1): опрос не набрал бы его",
+		},
+		{
+			name:    "unknown user",
+			mutate:  func(p *models.Poll) { p.Quorum = 0 },
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingVoters, Usernames: []string{"alice", "dave"}},
+			wantErr: "пользователи из --voters не найдены: @dave",
+		},
+		{
+			name:    "quorum larger than the list",
+			mutate:  restricted,
+			userID:  "creator1",
+			change:  SettingChange{Setting: SettingQuorum, Number: 4},
+			wantErr: "кворум 4 больше списка участников (2): опрос не набрал бы его",
+		},
+		{
+			name:    "not the creator",
+			userID:  "u-alice",
+			change:  SettingChange{Setting: SettingVoters, Usernames: []string{"alice"}},
+			wantErr: "только создатель может менять настройки опроса",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, repo, journal := newSettingsService(t, tt.mutate, ExpiryOptions{})
+			s.SetUserFinder(committee)
+			before, err := repo.GetPoll(ctx, settingsPollID)
+			require.NoError(t, err)
+
+			result, err := s.UpdateSetting(ctx, tt.userID, settingsPollID, tt.change)
+			stored, getErr := repo.GetPoll(ctx, settingsPollID)
+			require.NoError(t, getErr)
+			events, listErr := journal.ListEvents(ctx, settingsPollID, 10)
+			require.NoError(t, listErr)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, before, stored)
+				assert.Empty(t, events)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+			assert.Equal(t, tt.wantVoters, stored.AllowedVoters)
+			require.Len(t, events, 1)
+			assert.Equal(t, audit.ActionSettingChanged, events[0].Action)
+			assert.Equal(t, tt.wantDetail, events[0].Detail)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"polling_bot/internal/i18n"
+)
+
+// Наибольший вес голоса одного участника
+const maxVoteWeight = 100
+
+// validateWeights проверяет веса из --weights: имена участников ещё не
+// сопоставлены с пользователями, поэтому проверяются только значения.
+func validateWeights(weights map[string]int, ranked bool) error {
+	if len(weights) == 0 {
+		return nil
+	}
+	// Мгновенный второй тур считает бюллетени, а не счётчики вариантов
+	if ranked {
+		return i18n.NewError(i18n.WeightsRanked)
+	}
+	for _, username := range sortedUsernames(weights) {
+		if weight := weights[username]; weight < 1 || weight > maxVoteWeight {
+			return i18n.NewError(i18n.WeightInvalid, "@"+username, maxVoteWeight)
+		}
+	}
+	return nil
+}
+
+// resolveWeights переводит веса по именам пользователей в веса по их ID,
+// под которыми участники голосуют. Пустые веса - nil: опрос без весов.
+func (s *PollServiceImpl) resolveWeights(ctx context.Context, weights map[string]int) (map[string]int, error) {
+	if len(weights) == 0 {
+		return nil, nil
+	}
+	if s.finder == nil {
+		return nil, i18n.NewError(i18n.WeightsUnavailable)
+	}
+	resolved := make(map[string]int, len(weights))
+	for _, username := range sortedUsernames(weights) {
+		user, found, err := s.finder.FindUser(ctx, username)
+		if err != nil {
+			return nil, i18n.Wrap(err, i18n.OpFindUser)
+		}
+		if !found {
+			return nil, i18n.NewError(i18n.WeightUserNotFound, "@"+username)
+		}
+		resolved[user.ID] = weights[username]
+	}
+	return resolved, nil
+}
+
+func sortedUsernames(weights map[string]int) []string {
+	usernames := make([]string, 0, len(weights))
+	for username := range weights {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
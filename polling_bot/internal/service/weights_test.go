@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/repository"
+)
+
+type brokenUserFinder struct{}
+
+func (brokenUserFinder) FindUser(ctx context.Context, username string) (User, bool, error) {
+	return User{}, false, errors.New("mattermost недоступен")
+}
+
+var weightUsers = stubUserFinder{
+	"alice": {ID: "u-alice", Username: "alice"},
+	"bob":   {ID: "u-bob", Username: "bob"},
+}
+
+// Тест проверяет разбор весов при создании опроса: веса сохраняются по ID
+// участников, а неизвестные пользователи и недопустимые веса отклоняются
+func TestCreatePoll_Weights(t *testing.T) {
+	tests := []struct {
+		name    string
+		finder  UserFinder
+		opts    CreateOptions
+		want    map[string]int
+		wantErr string
+	}{
+		{name: "no weights", finder: weightUsers},
+		{name: "resolved by user ID", finder: weightUsers,
+			opts: CreateOptions{Weights: map[string]int{"alice": 2, "bob": 3}},
+			want: map[string]int{"u-alice": 2, "u-bob": 3}},
+		{name: "largest weight", finder: weightUsers,
+			opts: CreateOptions{Weights: map[string]int{"alice": maxVoteWeight}},
+			want: map[string]int{"u-alice": maxVoteWeight}},
+		{name: "unknown user", finder: weightUsers,
+			opts:    CreateOptions{Weights: map[string]int{"alice": 2, "carol": 3}},
+			wantErr: "пользователь @carol из --weights не найден"},
+		{name: "zero weight", finder: weightUsers,
+			opts:    CreateOptions{Weights: map[string]int{"alice": 0}},
+			wantErr: "вес @alice должен быть от 1 до 100"},
+		{name: "weight too large", finder: weightUsers,
+			opts:    CreateOptions{Weights: map[string]int{"bob": maxVoteWeight + 1}},
+			wantErr: "вес @bob должен быть от 1 до 100"},
+		{name: "ranked poll", finder: weightUsers,
+			opts:    CreateOptions{Ranked: true, Weights: map[string]int{"alice": 2}},
+			wantErr: "в рейтинговом опросе веса голосов не поддерживаются"},
+		{name: "no user finder",
+			opts:    CreateOptions{Weights: map[string]int{"alice": 2}},
+			wantErr: "веса голосов не настроены: боту не по чему найти пользователей"},
+		{name: "user lookup fails", finder: brokenUserFinder{},
+			opts:    CreateOptions{Weights: map[string]int{"alice": 2}},
+			wantErr: "ошибка поиска пользователя: mattermost недоступен"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryPollRepo()
+			s := NewPollService(repo, repository.NewMemoryVoteRepo(repo), zerolog.Nop())
+			if tt.finder != nil {
+				s.SetUserFinder(tt.finder)
+			}
+
+			created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"}, tt.opts)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			stored, err := repo.GetPoll(ctx, created.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stored.Weights)
+		})
+	}
+}
+
+// Тест проверяет подсчёт взвешенных голосов: итоги показывают и число
+// голосов, и сумму весов, а голос хранит вес, с которым он учтён
+func TestAddVote_Weighted(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	votes := repository.NewMemoryVoteRepo(repo)
+	s := NewPollService(repo, votes, zerolog.Nop())
+	s.SetUserFinder(weightUsers)
+
+	created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да", "Нет"},
+		CreateOptions{Weights: map[string]int{"bob": 3, "alice": 2}})
+	require.NoError(t, err)
+	assert.Equal(t, "Голосование создано успешно! ID: `"+created.ID+"`\nВопрос: Релизим?\n"+
+		"Варианты:\n1. Да\n2. Нет\nВеса голосов: @alice=2, @bob=3, у остальных участников вес 1\n", created.Message)
+
+	for user, choice := range map[string]string{"u-alice": "Да", "u-bob": "Нет", "u-carol": "Да", "u-dave": "Да"} {
+		_, err := s.AddVote(ctx, user, created.ID, []string{choice})
+		require.NoError(t, err)
+	}
+
+	results, err := s.GetResults(ctx, "creator1", created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "**Результаты опроса "+created.ID+"**\nРелизим?\n"+
+		"- Да: 3 голосов, с учётом весов: 4\n- Нет: 1 голосов, с учётом весов: 3\n", results)
+
+	bob, err := votes.GetVote(ctx, created.ID, "u-bob")
+	require.NoError(t, err)
+	assert.Equal(t, 3, bob.Weight)
+	carol, err := votes.GetVote(ctx, created.ID, "u-carol")
+	require.NoError(t, err)
+	assert.Equal(t, 1, carol.Weight, "участник не из списка голосует с весом 1")
+}
+
+// Тест проверяет, что голос в опросе без весов учитывается как раньше
+func TestAddVote_Unweighted(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryPollRepo()
+	votes := repository.NewMemoryVoteRepo(repo)
+	s := NewPollService(repo, votes, zerolog.Nop())
+
+	created, err := s.CreatePollWithID(ctx, "creator1", "Релизим?", []string{"Да"}, CreateOptions{})
+	require.NoError(t, err)
+	_, err = s.AddVote(ctx, "u-alice", created.ID, []string{"Да"})
+	require.NoError(t, err)
+
+	stored, err := repo.GetPoll(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.WeightedOptions)
+	vote, err := votes.GetVote(ctx, created.ID, "u-alice")
+	require.NoError(t, err)
+	assert.Zero(t, vote.Weight)
+
+	assert.Nil(t, BuildResults(stored, nil).Weighted)
+}
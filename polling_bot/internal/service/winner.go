@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"sort"
+
+	"polling_bot/internal/audit"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/models"
+)
+
+// UserNames переводит ID пользователя Mattermost в имя для упоминания.
+type UserNames interface {
+	Username(ctx context.Context, userID string) (string, error)
+}
+
+// SetUserNames задаёт, как показывать выбранного победителя; без него
+// показывается ID пользователя.
+func (s *PollServiceImpl) SetUserNames(users UserNames) {
+	s.users = users
+}
+
+// PickWinner выбирает случайного участника завершённого опроса. Если задан
+// option, выбор идёт среди голосовавших за этот вариант (в рейтинговом
+// опросе - поставивших его первым). Повторный выбор требует again.
+func (s *PollServiceImpl) PickWinner(ctx context.Context, userID, pollID, option string, again bool) (string, error) {
+	pollID, err := normalizePollID(pollID)
+	if err != nil {
+		return "", err
+	}
+	poll, err := s.repo.GetPoll(ctx, pollID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpGetPoll)
+	}
+	if poll.Creator != userID {
+		return "", i18n.NewError(i18n.OnlyCreatorWinner)
+	}
+	if err := s.checkChannel(ctx, poll, userID, false); err != nil {
+		return "", err
+	}
+	if !poll.Closed {
+		return "", i18n.NewError(i18n.WinnerPollOpen)
+	}
+	if poll.Anonymous() {
+		return "", i18n.NewError(i18n.WinnerAnonymous)
+	}
+	if poll.Winner != "" && !again {
+		return "", i18n.NewError(i18n.WinnerChosen, s.username(ctx, poll.Winner))
+	}
+
+	votes, err := s.votes.ListVotes(ctx, pollID)
+	if err != nil {
+		return "", s.storageError(err, i18n.OpListVotes)
+	}
+	candidates, err := winnerCandidates(poll, votes, option)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 0 {
+		return "", i18n.NewError(i18n.WinnerNoVoters)
+	}
+	n, err := s.randIntn(len(candidates))
+	if err != nil {
+		return "", err
+	}
+
+	poll.Winner = candidates[n]
+	if err := s.repo.SavePoll(ctx, poll); err != nil {
+		return "", s.storageError(err, i18n.OpSaveWinner)
+	}
+	s.record(ctx, pollID, userID, audit.ActionWinner, poll.Winner)
+
+	s.logger.Info().Str("poll_id", pollID).Str("winner", poll.Winner).Msg("Выбран победитель опроса")
+	message := i18n.FromContext(ctx).T(i18n.WinnerAnnounce, pollID, s.username(ctx, poll.Winner))
+	s.announce(ctx, poll, message)
+	return message, nil
+}
+
+// winnerCandidates возвращает участников, среди которых выбирается
+// победитель, в детерминированном порядке.
+func winnerCandidates(poll models.Poll, votes []models.Vote, choice string) ([]string, error) {
+	var option string
+	if choice != "" {
+		match, suggestion := findOption(poll, choice)
+		switch {
+		case match != "":
+			option = match
+		case suggestion != "":
+			return nil, i18n.NewError(i18n.OptionSuggestion, choice, suggestion)
+		default:
+			return nil, i18n.NewError(i18n.OptionNotFound, choice)
+		}
+	}
+
+	candidates := make([]string, 0, len(votes))
+	for _, vote := range votes {
+		// Воздержавшиеся не голосовали ни за один вариант
+		if vote.Abstain {
+			continue
+		}
+		// Голоса без бюллетеня не известно, за что отданы
+		if option != "" && (len(vote.Choices) == 0 || vote.Choices[0] != option) {
+			continue
+		}
+		candidates = append(candidates, vote.UserID)
+	}
+	sort.Strings(candidates)
+	return candidates, nil
+}
+
+// announce публикует сообщение в канале опроса, если команда пришла
+// из другого канала.
+func (s *PollServiceImpl) announce(ctx context.Context, poll models.Poll, message string) {
+	if s.announcer == nil || poll.ChannelID == "" || OriginFrom(ctx).ChannelID == poll.ChannelID {
+		return
+	}
+	if err := s.announcer.Announce(ctx, poll.ChannelID, message); err != nil {
+		s.logger.Error().Err(err).Str("poll_id", poll.ID).Msg("Не удалось опубликовать сообщение в канале опроса")
+	}
+}
+
+func (s *PollServiceImpl) username(ctx context.Context, userID string) string {
+	if s.users == nil {
+		return userID
+	}
+	name, err := s.users.Username(ctx, userID)
+	if err != nil || name == "" {
+		s.logger.Warn().Err(err).Str("user_id", userID).Msg("Не удалось получить имя пользователя")
+		return userID
+	}
+	return "@" + name
+}
+
+// cryptoIntn возвращает равномерно распределённое число из [0, n).
+func cryptoIntn(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
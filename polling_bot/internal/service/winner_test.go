@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+)
+
+type stubUserNames map[string]string
+
+func (u stubUserNames) Username(ctx context.Context, userID string) (string, error) {
+	if name, ok := u[userID]; ok {
+		return name, nil
+	}
+	return "", errors.New("пользователь не найден")
+}
+
+type recordingAnnouncer struct {
+	channels []string
+}
+
+func (a *recordingAnnouncer) Announce(ctx context.Context, channelID, message string) error {
+	a.channels = append(a.channels, channelID)
+	return nil
+}
+
+const winnerPollID = "123e4567-e89b-12d3-a456-426614174000"
+
+// winnerVotes - голоса опроса для розыгрыша; у old голос без бюллетеня
+var winnerVotes = []models.Vote{
+	{PollID: winnerPollID, UserID: "carol", Choices: []string{"Да"}},
+	{PollID: winnerPollID, UserID: "alice", Choices: []string{"Да"}},
+	{PollID: winnerPollID, UserID: "bob", Choices: []string{"Нет"}},
+	{PollID: winnerPollID, UserID: "old"},
+}
+
+func newWinnerService(t *testing.T, mutate func(*models.Poll), votes []models.Vote) (*PollServiceImpl, repository.PollRepository) {
+	t.Helper()
+	poll := models.Poll{
+		ID:        winnerPollID,
+		Creator:   "creator1",
+		Question:  "Розыгрыш",
+		Options:   map[string]int{"Да": 2, "Нет": 1},
+		Closed:    true,
+		ChannelID: "c1",
+	}
+	if mutate != nil {
+		mutate(&poll)
+	}
+	repo := repository.NewMemoryPollRepo()
+	require.NoError(t, repo.SavePoll(context.Background(), poll))
+
+	voteRepo := repository.NewMemoryVoteRepo(repo)
+	require.NoError(t, voteRepo.ImportVotes(context.Background(), votes))
+
+	s := NewPollService(repo, voteRepo, zerolog.Nop())
+	s.SetUserNames(stubUserNames{"alice": "alice.m", "bob": "bob.k", "carol": "carol.s"})
+	return s, repo
+}
+
+// Тест проверяет выбор победителя с подменённым источником случайности
+func TestPickWinner(t *testing.T) {
+	tests := []struct {
+		name    string
+		option  string
+		pick    int
+		want    string
+		wantErr string
+	}{
+		// Кандидаты упорядочены: alice, bob, carol, old
+		{name: "any voter", pick: 1, want: "Победитель опроса " + winnerPollID + ": @bob.k 🎉"},
+		{name: "voter without a known name", pick: 3, want: "Победитель опроса " + winnerPollID + ": old 🎉"},
+		// Голоса без бюллетеня (old) в выборе по варианту не участвуют
+		{name: "voters of an option", option: "да", pick: 1, want: "Победитель опроса " + winnerPollID + ": @carol.s 🎉"},
+		{name: "unknown option", option: "Может быть", wantErr: "вариант 'Может быть' не существует"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newWinnerService(t, nil, winnerVotes)
+			var gotN int
+			s.randIntn = func(n int) (int, error) {
+				gotN = n
+				return tt.pick, nil
+			}
+
+			result, err := s.PickWinner(context.Background(), "creator1", winnerPollID, tt.option, false)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+			if tt.option == "" {
+				assert.Equal(t, 4, gotN)
+			} else {
+				assert.Equal(t, 2, gotN)
+			}
+
+			stored, err := repo.GetPoll(context.Background(), winnerPollID)
+			require.NoError(t, err)
+			assert.NotEmpty(t, stored.Winner, "победитель сохраняется в опросе")
+		})
+	}
+}
+
+// Тест проверяет ограничения команды winner
+func TestPickWinner_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*models.Poll)
+		noVotes bool
+		userID  string
+		again   bool
+		wantErr string
+	}{
+		{name: "not the creator", userID: "alice", wantErr: "только создатель может выбрать победителя"},
+		{name: "open poll", mutate: func(p *models.Poll) { p.Closed = false }, userID: "creator1", wantErr: "победителя можно выбрать только в завершённом опросе"},
+		{name: "no voters", noVotes: true, userID: "creator1", wantErr: "в опросе нет подходящих участников"},
+		{name: "winner already picked", mutate: func(p *models.Poll) { p.Winner = "alice" }, userID: "creator1", wantErr: "победитель уже выбран: @alice.m. Чтобы выбрать заново, добавьте --again"},
+		{name: "pick again", mutate: func(p *models.Poll) { p.Winner = "alice" }, userID: "creator1", again: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			votes := winnerVotes
+			if tt.noVotes {
+				votes = nil
+			}
+			s, _ := newWinnerService(t, tt.mutate, votes)
+			s.randIntn = func(n int) (int, error) { return 0, nil }
+
+			_, err := s.PickWinner(context.Background(), tt.userID, winnerPollID, "", tt.again)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// Тест проверяет объявление победителя в канале опроса
+func TestPickWinner_AnnouncesInPollChannel(t *testing.T) {
+	s, _ := newWinnerService(t, nil, winnerVotes)
+	announcer := &recordingAnnouncer{}
+	s.SetAnnouncer(announcer)
+	s.randIntn = func(n int) (int, error) { return 0, nil }
+
+	dm := WithOrigin(context.Background(), Origin{ChannelID: "dm", Direct: true})
+	_, err := s.PickWinner(dm, "creator1", winnerPollID, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1"}, announcer.channels)
+
+	// Из канала опроса ответ на команду и есть объявление
+	inChannel := WithOrigin(context.Background(), Origin{ChannelID: "c1"})
+	_, err = s.PickWinner(inChannel, "creator1", winnerPollID, "", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1"}, announcer.channels)
+}
+
+// Тест проверяет, что криптографический источник не выходит за границы
+func TestCryptoIntn(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		n, err := cryptoIntn(3)
+		require.NoError(t, err)
+		assert.True(t, n >= 0 && n < 3)
+	}
+}
@@ -0,0 +1,426 @@
+// Package mmserver - поддельный сервер Mattermost для тестов бота: httptest-сервер
+// с той частью REST API v4, которой пользуется бот, и настоящим WebSocket,
+// в который тест отправляет события. Сообщения и реакции, созданные ботом,
+// сервер запоминает, чтобы тест мог их проверить.
+//
+// Сервер отвечает в формате Mattermost, и бот работает с ним через тот же
+// клиент библиотеки Mattermost, что и с настоящим сервером. На запросы,
+// которых сервер не знает, он отвечает 501: тест сразу видит, что бот
+// обратился к новой части API.
+package mmserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"polling_bot/internal/mmclient"
+)
+
+// Сколько WaitPosts и WaitReactions ждут, прежде чем провалить тест
+const waitTimeout = 5 * time.Second
+
+// Команда, в которой состоит бот, если тест не задал другую
+const DefaultTeamID = "team1"
+
+// Post - сообщение, которое бот создал через API.
+type Post struct {
+	mmclient.Post
+	// Кому показано эфемерное сообщение; пусто - сообщение видит весь канал
+	EphemeralTo string
+	Pinned      bool
+}
+
+// Server - поддельный сервер Mattermost. Создаётся New и закрывается
+// вместе с тестом.
+type Server struct {
+	// Адрес сервера вида http://127.0.0.1:port для MattermostURL бота
+	URL string
+	// Токен бота: запросы с другим токеном получают 401
+	Token string
+
+	srv  *httptest.Server
+	done chan struct{}
+	// События для текущего подключения WebSocket, см. Emit
+	events chan wireEvent
+
+	mu     sync.Mutex
+	bot    mmclient.User
+	teamID string
+	users  map[string]mmclient.User
+	// Все сообщения по ID: созданные ботом и отправленные тестом через Posted
+	byID      map[string]*Post
+	created   []*Post
+	reactions []mmclient.Reaction
+	failures  map[string]int
+	nextID    int
+	// Закрывается и заменяется при каждом новом сообщении или реакции
+	changed chan struct{}
+}
+
+// New запускает сервер, на котором бот входит как пользователь bot.
+func New(t testing.TB, bot mmclient.User) *Server {
+	s := &Server{
+		Token:   "bot-token",
+		done:    make(chan struct{}),
+		events:  make(chan wireEvent, 256),
+		bot:     bot,
+		teamID:  DefaultTeamID,
+		users:   map[string]mmclient.User{bot.ID: bot},
+		byID:    make(map[string]*Post),
+		changed: make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/websocket", s.serveWebSocket)
+	mux.HandleFunc("GET /api/v4/users/me", s.authorized(s.getMe))
+	mux.HandleFunc("GET /api/v4/users/{id}", s.authorized(s.getUser))
+	mux.HandleFunc("POST /api/v4/users/ids", s.authorized(s.getUsersByIDs))
+	// users/username/{name} и users/{id}/teams пересекаются как шаблоны,
+	// поэтому их различает один обработчик
+	mux.HandleFunc("GET /api/v4/users/{id}/{sub}", s.authorized(s.getUserSub))
+	mux.HandleFunc("POST /api/v4/posts", s.authorized(s.createPost))
+	mux.HandleFunc("POST /api/v4/posts/ephemeral", s.authorized(s.createEphemeral))
+	mux.HandleFunc("PUT /api/v4/posts/{id}", s.authorized(s.updatePost))
+	mux.HandleFunc("POST /api/v4/posts/{id}/pin", s.authorized(s.pin(true)))
+	mux.HandleFunc("POST /api/v4/posts/{id}/unpin", s.authorized(s.pin(false)))
+	mux.HandleFunc("POST /api/v4/reactions", s.authorized(s.saveReaction))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotImplemented, "mmserver.not_implemented", r.Method+" "+r.URL.Path)
+	})
+	s.srv = httptest.NewServer(s.failing(mux))
+	s.URL = s.srv.URL
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close закрывает подключения WebSocket и останавливает сервер.
+func (s *Server) Close() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	close(s.done)
+	s.srv.Close()
+}
+
+// AddUser добавляет пользователя, которого бот может запросить по ID или имени.
+func (s *Server) AddUser(user mmclient.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.ID] = user
+}
+
+// SetTeam задаёт команду, в которой состоит бот.
+func (s *Server) SetTeam(teamID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teamID = teamID
+}
+
+// Fail заставляет запросы вида "POST /api/v4/posts" отвечать ошибкой со
+// статусом status; status 0 отменяет сбой. На 429 сервер просит повторить
+// запрос через секунду. Подключение WebSocket - "GET /api/v4/websocket".
+func (s *Server) Fail(route string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures == nil {
+		s.failures = make(map[string]int)
+	}
+	if status == 0 {
+		delete(s.failures, route)
+		return
+	}
+	s.failures[route] = status
+}
+
+// Posts возвращает сообщения, которые бот создал, в порядке создания.
+func (s *Server) Posts() []Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := make([]Post, len(s.created))
+	for i, post := range s.created {
+		posts[i] = *post
+	}
+	return posts
+}
+
+// Reactions возвращает реакции, которые поставил бот, в порядке создания.
+func (s *Server) Reactions() []mmclient.Reaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.reactions)
+}
+
+// WaitPosts ждёт, пока бот создаст не меньше n сообщений, и возвращает их.
+// Если сообщений нет дольше пяти секунд, тест проваливается.
+func (s *Server) WaitPosts(t testing.TB, n int) []Post {
+	t.Helper()
+	s.wait(t, fmt.Sprintf("%d сообщений", n), func() bool { return len(s.created) >= n })
+	return s.Posts()
+}
+
+// WaitReactions ждёт, пока бот поставит не меньше n реакций, и возвращает их.
+func (s *Server) WaitReactions(t testing.TB, n int) []mmclient.Reaction {
+	t.Helper()
+	s.wait(t, fmt.Sprintf("%d реакций", n), func() bool { return len(s.reactions) >= n })
+	return s.Reactions()
+}
+
+// wait ждёт, пока выполнится условие ready; ready вызывается под s.mu.
+func (s *Server) wait(t testing.TB, what string, ready func() bool) {
+	t.Helper()
+	timeout := time.After(waitTimeout)
+	for {
+		s.mu.Lock()
+		ok, changed := ready(), s.changed
+		s.mu.Unlock()
+		if ok {
+			return
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			t.Fatalf("поддельный Mattermost не дождался %s", what)
+		}
+	}
+}
+
+// notify будит тех, кто ждёт в wait. Вызывается под s.mu.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// newID выдаёт ID из 26 символов, как у Mattermost. ID идут по порядку,
+// поэтому повторный запуск теста получает те же ID. Вызывается под s.mu.
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%0*d", prefix, 26-len(prefix), s.nextID)
+}
+
+// failing отвечает ошибкой на запросы, для которых тест вызвал Fail.
+func (s *Server) failing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status := s.failures[r.Method+" "+r.URL.Path]
+		s.mu.Unlock()
+		if status == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		writeError(w, status, "mmserver.injected_failure", http.StatusText(status))
+	})
+}
+
+// authorized пропускает только запросы с токеном бота.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "bearer") || token != s.Token {
+			writeError(w, http.StatusUnauthorized, "api.context.session_expired.app_error", "Invalid or expired session, please login again.")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	bot := s.bot
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, toWireUser(bot))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	user, ok := s.users[r.PathValue("id")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "app.user.missing_account.const", "Unable to find the user.")
+		return
+	}
+	writeJSON(w, http.StatusOK, toWireUser(user))
+}
+
+func (s *Server) getUsersByIDs(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	if !readJSON(w, r, &ids) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]wireUser, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := s.users[id]; ok {
+			users = append(users, toWireUser(user))
+		}
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) getUserSub(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.PathValue("id") == "username":
+		s.getUserByUsername(w, r.PathValue("sub"))
+	case r.PathValue("sub") == "teams":
+		s.getTeams(w)
+	default:
+		writeError(w, http.StatusNotImplemented, "mmserver.not_implemented", r.Method+" "+r.URL.Path)
+	}
+}
+
+func (s *Server) getUserByUsername(w http.ResponseWriter, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Username == name {
+			writeJSON(w, http.StatusOK, toWireUser(user))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "app.user.missing_account.const", "Unable to find the user.")
+}
+
+func (s *Server) getTeams(w http.ResponseWriter) {
+	s.mu.Lock()
+	teamID := s.teamID
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, []map[string]string{{"id": teamID}})
+}
+
+func (s *Server) createPost(w http.ResponseWriter, r *http.Request) {
+	var post wirePost
+	if !readJSON(w, r, &post) {
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.store(post, ""))
+}
+
+func (s *Server) createEphemeral(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string   `json:"user_id"`
+		Post   wirePost `json:"post"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.UserID == "" {
+		writeError(w, http.StatusBadRequest, "api.context.invalid_body_param.app_error", "Invalid or missing user_id in request body.")
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.store(req.Post, req.UserID))
+}
+
+// store запоминает сообщение бота и возвращает его в том виде, в каком
+// его вернул бы Mattermost.
+func (s *Server) store(post wirePost, ephemeralTo string) wirePost {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	post.ID = s.newID("post")
+	post.UserID = s.bot.ID
+	post.CreateAt = time.Now().UnixMilli()
+	post.UpdateAt = post.CreateAt
+	post.EditAt = 0
+	post.Metadata = map[string]interface{}{}
+	stored := &Post{Post: post.toPost(), EphemeralTo: ephemeralTo}
+	s.byID[post.ID] = stored
+	s.created = append(s.created, stored)
+	s.notify()
+	return post
+}
+
+func (s *Server) updatePost(w http.ResponseWriter, r *http.Request) {
+	var post wirePost
+	if !readJSON(w, r, &post) {
+		return
+	}
+	id := r.PathValue("id")
+	if post.ID != id {
+		writeError(w, http.StatusBadRequest, "api.context.invalid_body_param.app_error", "Invalid or missing post_id in request body.")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.byID[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "app.post.get.app_error", "Unable to get the post.")
+		return
+	}
+	stored.Message = post.Message
+	stored.Props = post.Props
+	stored.EditAt = time.Now().UnixMilli()
+	s.notify()
+	writeJSON(w, http.StatusOK, fromPost(*stored))
+}
+
+func (s *Server) pin(pinned bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		stored, ok := s.byID[r.PathValue("id")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "app.post.get.app_error", "Unable to get the post.")
+			return
+		}
+		stored.Pinned = pinned
+		writeJSON(w, http.StatusOK, map[string]string{"status": "OK"})
+	}
+}
+
+func (s *Server) saveReaction(w http.ResponseWriter, r *http.Request) {
+	var reaction wireReaction
+	if !readJSON(w, r, &reaction) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reaction.UserID != s.bot.ID {
+		writeError(w, http.StatusForbidden, "api.context.permissions.app_error", "You do not have the appropriate permissions.")
+		return
+	}
+	if _, ok := s.byID[reaction.PostID]; !ok {
+		writeError(w, http.StatusNotFound, "app.post.get.app_error", "Unable to get the post.")
+		return
+	}
+	reaction.CreateAt = time.Now().UnixMilli()
+	s.reactions = append(s.reactions, mmclient.Reaction{UserID: reaction.UserID, PostID: reaction.PostID, EmojiName: reaction.EmojiName})
+	s.notify()
+	writeJSON(w, http.StatusOK, reaction)
+}
+
+// readJSON разбирает тело запроса; на неверное тело отвечает 400, как Mattermost.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "api.context.invalid_body_param.app_error", err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError отвечает ошибкой в формате AppError Mattermost: клиент
+// библиотеки достаёт из неё текст ошибки.
+func writeError(w http.ResponseWriter, status int, id, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"id":             id,
+		"message":        message,
+		"detailed_error": "",
+		"request_id":     "mmserver",
+		"status_code":    status,
+	})
+}
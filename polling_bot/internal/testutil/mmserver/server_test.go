@@ -0,0 +1,154 @@
+package mmserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/mmclient"
+)
+
+var testBot = mmclient.User{ID: "bot123", Username: "pollbot", IsBot: true}
+
+// Тест проверяет REST API сервера через клиент бота: сообщения, правки,
+// эфемерные ответы и реакции запоминаются, чужой токен получает 401,
+// сбой из Fail - свой статус, неизвестный запрос - 501
+func TestServer_API(t *testing.T) {
+	srv := New(t, testBot)
+	srv.AddUser(mmclient.User{ID: "user1", Username: "alice", Locale: "en"})
+	client := mmclient.NewAPIClient(srv.URL, srv.Token, time.Second)
+
+	me, err := client.GetMe()
+	require.NoError(t, err)
+	assert.Equal(t, &testBot, me)
+	user, err := client.GetUserByUsername("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "user1", user.ID)
+	_, err = client.GetUser("nobody")
+	assert.Equal(t, http.StatusNotFound, mmclient.StatusCode(err))
+	srv.AddUser(mmclient.User{ID: "user2", Username: "bob", Deactivated: true})
+	users, err := client.GetUsersByIds([]string{"user1", "user2", "nobody"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.False(t, users[0].Deactivated)
+	assert.True(t, users[1].Deactivated)
+	teams, err := client.GetTeamsForUser(testBot.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []*mmclient.Team{{ID: DefaultTeamID}}, teams)
+
+	created, err := client.CreatePost(&mmclient.Post{ChannelID: "c1", Message: "Создаю опрос…"})
+	require.NoError(t, err)
+	assert.Len(t, created.ID, 26)
+	assert.Equal(t, testBot.ID, created.UserID)
+	created.Message = "Опрос создан"
+	updated, err := client.UpdatePost(created)
+	require.NoError(t, err)
+	assert.NotZero(t, updated.EditAt)
+	_, err = client.CreatePostEphemeral("user1", &mmclient.Post{ChannelID: "c1", Message: "Формат: ..."})
+	require.NoError(t, err)
+	require.NoError(t, client.PinPost(created.ID))
+
+	posts := srv.WaitPosts(t, 2)
+	require.Len(t, posts, 2)
+	assert.Equal(t, "Опрос создан", posts[0].Message)
+	assert.True(t, posts[0].Pinned)
+	assert.Equal(t, "user1", posts[1].EphemeralTo)
+
+	userPost := srv.Posted(mmclient.Post{ChannelID: "c1", UserID: "user1", Message: "!poll help"}, mmclient.ChannelOpen)
+	_, err = client.SaveReaction(&mmclient.Reaction{UserID: testBot.ID, PostID: userPost.ID, EmojiName: "white_check_mark"})
+	require.NoError(t, err)
+	_, err = client.SaveReaction(&mmclient.Reaction{UserID: testBot.ID, PostID: "missing", EmojiName: "x"})
+	assert.Equal(t, http.StatusNotFound, mmclient.StatusCode(err))
+	assert.Equal(t, []mmclient.Reaction{{UserID: testBot.ID, PostID: userPost.ID, EmojiName: "white_check_mark"}}, srv.Reactions())
+
+	_, err = mmclient.NewAPIClient(srv.URL, "wrong", time.Second).GetMe()
+	assert.Equal(t, http.StatusUnauthorized, mmclient.StatusCode(err))
+
+	srv.Fail("POST /api/v4/posts", http.StatusTooManyRequests)
+	_, err = client.CreatePost(&mmclient.Post{ChannelID: "c1", Message: "Итоги"})
+	assert.Equal(t, http.StatusTooManyRequests, mmclient.StatusCode(err))
+	assert.Equal(t, time.Second, mmclient.RetryAfter(err))
+	srv.Fail("POST /api/v4/posts", 0)
+	_, err = client.CreatePost(&mmclient.Post{ChannelID: "c1", Message: "Итоги"})
+	assert.NoError(t, err)
+
+	_, err = client.GetChannelMember("c1", "user1")
+	assert.Equal(t, http.StatusNotImplemented, mmclient.StatusCode(err))
+}
+
+// Тест проверяет WebSocket через клиент бота: после проверки токена
+// приходит hello, затем события, отправленные до и после подключения,
+// по порядку и в формате Mattermost
+func TestServer_WebSocket(t *testing.T) {
+	srv := New(t, testBot)
+	first := srv.Posted(mmclient.Post{ChannelID: "c1", UserID: "user1", Message: "@pollbot !poll help"}, mmclient.ChannelOpen)
+
+	ws, err := mmclient.DialWebSocket(webSocketURL(srv), srv.Token)
+	require.NoError(t, err)
+	defer ws.Close()
+	ws.Listen()
+
+	hello := nextEvent(t, ws)
+	assert.Equal(t, "hello", hello.Type)
+
+	posted := nextEvent(t, ws)
+	assert.Equal(t, mmclient.EventPosted, posted.Type)
+	assert.Equal(t, "c1", posted.ChannelID)
+	assert.Equal(t, mmclient.ChannelOpen, posted.Data["channel_type"])
+	assert.Equal(t, DefaultTeamID, posted.Data["team_id"])
+	assert.Equal(t, `["bot123"]`, posted.Data["mentions"])
+	var post mmclient.Post
+	require.NoError(t, json.Unmarshal([]byte(posted.Data["post"].(string)), &post))
+	assert.Equal(t, first, post)
+
+	edit := first
+	edit.Message = "!poll list"
+	edit = srv.Edited(edit)
+	edited := nextEvent(t, ws)
+	assert.Equal(t, mmclient.EventPostEdited, edited.Type)
+	require.NoError(t, json.Unmarshal([]byte(edited.Data["post"].(string)), &post))
+	assert.Equal(t, edit, post)
+
+	srv.Emit(mmclient.WSEvent{Type: mmclient.EventUserAdded, ChannelID: "c2", Data: map[string]interface{}{"user_id": testBot.ID}})
+	added := nextEvent(t, ws)
+	assert.Equal(t, &mmclient.WSEvent{Type: mmclient.EventUserAdded, ChannelID: "c2", Data: map[string]interface{}{"user_id": testBot.ID}}, added)
+}
+
+// Тест проверяет, что подключение с чужим токеном закрывается без событий
+func TestServer_WebSocketWrongToken(t *testing.T) {
+	srv := New(t, testBot)
+	srv.Emit(mmclient.WSEvent{Type: mmclient.EventPosted})
+
+	ws, err := mmclient.DialWebSocket(webSocketURL(srv), "wrong")
+	require.NoError(t, err)
+	defer ws.Close()
+	ws.Listen()
+
+	select {
+	case event, ok := <-ws.Events():
+		assert.False(t, ok, "получено событие %v", event)
+	case <-time.After(waitTimeout):
+		t.Fatal("подключение с чужим токеном не закрыто")
+	}
+}
+
+func webSocketURL(srv *Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func nextEvent(t *testing.T, ws mmclient.WebSocket) *mmclient.WSEvent {
+	t.Helper()
+	select {
+	case event, ok := <-ws.Events():
+		require.True(t, ok, "подключение закрыто")
+		return event
+	case <-time.After(waitTimeout):
+		t.Fatal("событие не получено")
+		return nil
+	}
+}
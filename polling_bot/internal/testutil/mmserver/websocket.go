@@ -0,0 +1,204 @@
+package mmserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"polling_bot/internal/mmclient"
+)
+
+// Сколько сервер ждёт проверки токена после подключения
+const challengeTimeout = 5 * time.Second
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// Emit отправляет событие боту по WebSocket. События, отправленные до
+// подключения бота, копятся и уходят по порядку, как только бот подключится.
+func (s *Server) Emit(event mmclient.WSEvent) {
+	data := event.Data
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	s.events <- wireEvent{
+		Event:     event.Type,
+		Data:      data,
+		Broadcast: wireBroadcast{ChannelID: event.ChannelID},
+	}
+}
+
+// Posted отправляет событие posted о сообщении пользователя post.UserID
+// в канале типа channelType, например mmclient.ChannelOpen, и возвращает
+// сообщение с ID, который ему выдал сервер, если ID не задан. На такое
+// сообщение бот может поставить реакцию. Упоминание бота попадает в
+// mentions, как у Mattermost.
+func (s *Server) Posted(post mmclient.Post, channelType string) mmclient.Post {
+	s.mu.Lock()
+	if post.ID == "" {
+		post.ID = s.newID("post")
+	}
+	s.byID[post.ID] = &Post{Post: post}
+	bot, teamID := s.bot, s.teamID
+	s.mu.Unlock()
+
+	data := map[string]interface{}{
+		"channel_display_name": post.ChannelID,
+		"channel_name":         post.ChannelID,
+		"channel_type":         channelType,
+		"post":                 encodePost(post),
+		"sender_name":          "@" + post.UserID,
+		"set_online":           true,
+		"team_id":              teamID,
+	}
+	if channelType == mmclient.ChannelDirect {
+		data["team_id"] = ""
+	}
+	if bot.Username != "" && strings.Contains(post.Message, "@"+bot.Username) {
+		mentions, _ := json.Marshal([]string{bot.ID})
+		data["mentions"] = string(mentions)
+	}
+	s.Emit(mmclient.WSEvent{Type: mmclient.EventPosted, ChannelID: post.ChannelID, Data: data})
+	return post
+}
+
+// Edited отправляет событие post_edited о правке сообщения, которое раньше
+// отправил Posted, и возвращает сообщение с новым временем правки.
+func (s *Server) Edited(post mmclient.Post) mmclient.Post {
+	s.mu.Lock()
+	editAt := time.Now().UnixMilli()
+	if stored, ok := s.byID[post.ID]; ok {
+		// Правки одного сообщения различаются по времени правки
+		editAt = max(editAt, stored.EditAt+1)
+		stored.Message = post.Message
+		stored.EditAt = editAt
+	}
+	s.mu.Unlock()
+
+	post.EditAt = editAt
+	s.Emit(mmclient.WSEvent{
+		Type:      mmclient.EventPostEdited,
+		ChannelID: post.ChannelID,
+		Data:      map[string]interface{}{"post": encodePost(post)},
+	})
+	return post
+}
+
+// encodePost кодирует сообщение строкой JSON: так Mattermost передаёт
+// его в данных событий.
+func encodePost(post mmclient.Post) string {
+	wire := fromPost(Post{Post: post})
+	wire.CreateAt = time.Now().UnixMilli()
+	wire.UpdateAt = max(wire.CreateAt, post.EditAt)
+	encoded, _ := json.Marshal(wire)
+	return string(encoded)
+}
+
+// serveWebSocket обслуживает подключение бота: проверяет токен из
+// authentication_challenge, отправляет hello и затем события из Emit,
+// нумеруя их по порядку. На запросы клиента после проверки токена
+// сервер отвечает OK.
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	c := &wsConn{conn: conn}
+
+	conn.SetReadDeadline(time.Now().Add(challengeTimeout))
+	var challenge wireRequest
+	if err := conn.ReadJSON(&challenge); err != nil {
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	if token, _ := challenge.Data["token"].(string); challenge.Action != "authentication_challenge" || token != s.Token {
+		c.write(wireResponse{
+			Status:   "FAIL",
+			SeqReply: challenge.Seq,
+			Error: map[string]interface{}{
+				"id":          "api.web_socket_router.not_authenticated.app_error",
+				"message":     "WebSocket connection is not authenticated.",
+				"status_code": http.StatusUnauthorized,
+			},
+		})
+		c.close(websocket.ClosePolicyViolation)
+		return
+	}
+	c.write(wireResponse{Status: "OK", SeqReply: challenge.Seq})
+
+	s.mu.Lock()
+	botID := s.bot.ID
+	s.mu.Unlock()
+	var seq int64
+	c.write(wireEvent{
+		Event:     "hello",
+		Data:      map[string]interface{}{"server_version": "mmserver", "connection_id": "conn1"},
+		Broadcast: wireBroadcast{UserID: botID},
+		Seq:       seq,
+	})
+
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		c.readRequests()
+	}()
+
+	for {
+		select {
+		case event := <-s.events:
+			seq++
+			event.Seq = seq
+			if err := c.write(event); err != nil {
+				return
+			}
+		case <-readDone:
+			return
+		case <-s.done:
+			c.close(websocket.CloseGoingAway)
+			return
+		}
+	}
+}
+
+// wsConn - подключение WebSocket. gorilla/websocket допускает одного
+// пишущего, а пишут и отправка событий, и ответы на запросы.
+type wsConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (c *wsConn) write(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (c *wsConn) close(code int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""), time.Now().Add(time.Second))
+}
+
+// readRequests отвечает на запросы клиента, пока подключение не закроется.
+// Чтение нужно и для управляющих кадров: без него не дойдут ping и close.
+func (c *wsConn) readRequests() {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req wireRequest
+		if json.Unmarshal(data, &req) != nil || req.Action == "" {
+			continue
+		}
+		if c.write(wireResponse{Status: "OK", SeqReply: req.Seq}) != nil {
+			return
+		}
+	}
+}
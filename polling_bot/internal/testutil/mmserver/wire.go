@@ -0,0 +1,126 @@
+package mmserver
+
+import (
+	"time"
+
+	"polling_bot/internal/mmclient"
+)
+
+// Объекты API в том виде, в каком их передаёт Mattermost. Поля, которые
+// бот не читает, всё равно заполняются: клиент библиотеки разбирает ответ
+// целиком, и поддельный сервер не должен быть проще настоящего.
+
+type wirePost struct {
+	ID            string                 `json:"id"`
+	CreateAt      int64                  `json:"create_at"`
+	UpdateAt      int64                  `json:"update_at"`
+	EditAt        int64                  `json:"edit_at"`
+	DeleteAt      int64                  `json:"delete_at"`
+	IsPinned      bool                   `json:"is_pinned"`
+	UserID        string                 `json:"user_id"`
+	ChannelID     string                 `json:"channel_id"`
+	RootID        string                 `json:"root_id"`
+	OriginalID    string                 `json:"original_id"`
+	Message       string                 `json:"message"`
+	Type          string                 `json:"type"`
+	Props         map[string]interface{} `json:"props"`
+	Hashtags      string                 `json:"hashtags"`
+	PendingPostID string                 `json:"pending_post_id"`
+	ReplyCount    int64                  `json:"reply_count"`
+	Metadata      map[string]interface{} `json:"metadata"`
+}
+
+func (p wirePost) toPost() mmclient.Post {
+	return mmclient.Post{
+		ID:        p.ID,
+		ChannelID: p.ChannelID,
+		UserID:    p.UserID,
+		Message:   p.Message,
+		EditAt:    p.EditAt,
+		Props:     p.Props,
+	}
+}
+
+func fromPost(post Post) wirePost {
+	return wirePost{
+		ID:        post.ID,
+		EditAt:    post.EditAt,
+		IsPinned:  post.Pinned,
+		UserID:    post.UserID,
+		ChannelID: post.ChannelID,
+		Message:   post.Message,
+		Props:     post.Props,
+		Metadata:  map[string]interface{}{},
+	}
+}
+
+type wireUser struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Nickname  string `json:"nickname"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Locale    string `json:"locale"`
+	IsBot     bool   `json:"is_bot"`
+	Roles     string `json:"roles"`
+	DeleteAt  int64  `json:"delete_at"`
+}
+
+func toWireUser(user mmclient.User) wireUser {
+	roles := "system_user"
+	if user.IsBot {
+		roles = "system_user system_post_all"
+	}
+	var deleteAt int64
+	if user.Deactivated {
+		deleteAt = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	}
+	return wireUser{
+		ID:        user.ID,
+		Username:  user.Username,
+		Nickname:  user.Nickname,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Locale:    user.Locale,
+		IsBot:     user.IsBot,
+		Roles:     roles,
+		DeleteAt:  deleteAt,
+	}
+}
+
+type wireReaction struct {
+	UserID    string `json:"user_id"`
+	PostID    string `json:"post_id"`
+	EmojiName string `json:"emoji_name"`
+	CreateAt  int64  `json:"create_at"`
+}
+
+// wireEvent - событие WebSocket. seq считается по подключению и
+// проставляется при отправке.
+type wireEvent struct {
+	Event     string                 `json:"event"`
+	Data      map[string]interface{} `json:"data"`
+	Broadcast wireBroadcast          `json:"broadcast"`
+	Seq       int64                  `json:"seq"`
+}
+
+type wireBroadcast struct {
+	OmitUsers map[string]bool `json:"omit_users"`
+	UserID    string          `json:"user_id"`
+	ChannelID string          `json:"channel_id"`
+	TeamID    string          `json:"team_id"`
+}
+
+// wireRequest - запрос клиента по WebSocket, например проверка токена.
+type wireRequest struct {
+	Seq    int64                  `json:"seq"`
+	Action string                 `json:"action"`
+	Data   map[string]interface{} `json:"data"`
+}
+
+// wireResponse - ответ сервера на запрос клиента по WebSocket.
+type wireResponse struct {
+	Status   string                 `json:"status"`
+	SeqReply int64                  `json:"seq_reply"`
+	Error    map[string]interface{} `json:"error,omitempty"`
+}
@@ -0,0 +1,254 @@
+// Package wizard ведёт пошаговое создание опроса для тех, кто не знает
+// формата create: бот по очереди спрашивает вопрос, варианты ответа по
+// одному в сообщении и флаги, а затем создаёт опрос в канале, где мастер
+// был запущен. Сессии хранятся в памяти: у каждого пользователя не больше
+// одной, и без ответа она закрывается через таймаут.
+package wizard
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"polling_bot/internal/cmdparse"
+	"polling_bot/internal/i18n"
+)
+
+const (
+	// Сколько по умолчанию ждать ответа, прежде чем закрыть сессию
+	DefaultTimeout = 10 * time.Minute
+	// Сколько вариантов принимает мастер: больше в одном опросе
+	// не прочитает никто, а сессия хранится в памяти
+	MaxOptions = 25
+	// Флаг create, который запускает мастер в канале
+	Flag = "--wizard"
+)
+
+// Ключевые слова ответов; принимаются на любом языке бота, а в подсказках
+// показываются слова языка ответа
+var (
+	cancelWords = []string{"cancel", "отмена"}
+	doneWords   = []string{"done", "готово"}
+	skipWords   = []string{"skip", "пропустить"}
+)
+
+// Step - вопрос, на который мастер ждёт ответа.
+type Step int
+
+const (
+	StepQuestion Step = iota
+	StepOptions
+	StepFlags
+)
+
+// Outcome - чем закончился ответ пользователя.
+type Outcome int
+
+const (
+	// Ответ принят или отклонён с подсказкой, мастер ждёт следующего
+	Continue Outcome = iota
+	// Все ответы получены, Reply.Session можно создавать
+	Finished
+	// Пользователь вышел из мастера
+	Cancelled
+	// Сессия закрылась по таймауту до этого ответа
+	Expired
+)
+
+// Session - ответы одного пользователя.
+type Session struct {
+	UserID string
+	// Канал, в котором запущен мастер: в нём создаётся опрос
+	ChannelID string
+	// Мастер запущен в личных сообщениях боту
+	Direct   bool
+	Question string
+	Options  []string
+	// Флаги create, разобранные как аргументы команды
+	Flags []string
+
+	step     Step
+	deadline time.Time
+}
+
+// Args - аргументы команды create, которая создаст опрос сессии.
+func (s Session) Args() []string {
+	args := make([]string, 0, 1+len(s.Options)+len(s.Flags))
+	args = append(args, s.Question)
+	args = append(args, s.Options...)
+	return append(args, s.Flags...)
+}
+
+// Reply - что ответить пользователю: текст по ключу Prompt с аргументами
+// Args и, если Outcome - Finished, собранная сессия.
+type Reply struct {
+	Outcome Outcome
+	Prompt  i18n.Key
+	Args    []interface{}
+	Session Session
+}
+
+// Wizard хранит сессии мастера. Безопасен для одновременного использования.
+type Wizard struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+// New создаёт мастер, сессии которого закрываются после timeout без
+// ответа; timeout <= 0 - DefaultTimeout.
+func New(timeout time.Duration) *Wizard {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Wizard{timeout: timeout, now: time.Now, sessions: make(map[string]*Session)}
+}
+
+// Requested сообщает, запускает ли create с аргументами args мастер: без
+// аргументов в личных сообщениях или с единственным флагом --wizard.
+func Requested(args []string, direct bool) bool {
+	if len(args) == 0 {
+		return direct
+	}
+	return len(args) == 1 && strings.EqualFold(args[0], Flag)
+}
+
+// Start начинает сессию пользователя в канале channelID, заменяя прежнюю,
+// и возвращает первый вопрос.
+func (w *Wizard) Start(userID, channelID string, direct bool) Reply {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.prune()
+	w.sessions[userID] = &Session{
+		UserID:    userID,
+		ChannelID: channelID,
+		Direct:    direct,
+		step:      StepQuestion,
+		deadline:  w.now().Add(w.timeout),
+	}
+	return Reply{Prompt: i18n.WizardQuestion}
+}
+
+// Active сообщает, ждёт ли мастер ответа пользователя в канале channelID.
+// Сессия, закрытая по таймауту, ещё считается: Answer сообщит о таймауте.
+func (w *Wizard) Active(userID, channelID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	session, ok := w.sessions[userID]
+	return ok && session.ChannelID == channelID
+}
+
+// InChannel сообщает, ведёт ли мастер хоть одну сессию в канале: ответы
+// мастеру приходят без упоминания бота, и такие сообщения нельзя отсеять,
+// не прочитав автора.
+func (w *Wizard) InChannel(channelID string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, session := range w.sessions {
+		if session.ChannelID == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// Answer принимает ответ пользователя на текущий вопрос сессии. Без
+// сессии в канале channelID возвращает Cancelled без подсказки.
+func (w *Wizard) Answer(userID, channelID, text string) Reply {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	session, ok := w.sessions[userID]
+	if !ok || session.ChannelID != channelID {
+		return Reply{Outcome: Cancelled}
+	}
+	if !w.now().Before(session.deadline) {
+		delete(w.sessions, userID)
+		return Reply{Outcome: Expired, Prompt: i18n.WizardExpired}
+	}
+
+	text = strings.TrimSpace(text)
+	if isWord(text, cancelWords) {
+		delete(w.sessions, userID)
+		return Reply{Outcome: Cancelled, Prompt: i18n.WizardCancelled}
+	}
+	session.deadline = w.now().Add(w.timeout)
+	reply := session.answer(text)
+	if reply.Outcome == Finished {
+		delete(w.sessions, userID)
+		reply.Session = *session
+	}
+	return reply
+}
+
+// Restore возвращает сессию к вопросу о флагах, например если опрос по
+// ней создать не удалось: пользователь исправит флаги, не начиная заново.
+func (w *Wizard) Restore(session Session) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	session.Flags = nil
+	session.step = StepFlags
+	session.deadline = w.now().Add(w.timeout)
+	w.sessions[session.UserID] = &session
+}
+
+// prune удаляет сессии, закрытые по таймауту: пользователь, бросивший
+// мастер, может больше не написать ни слова.
+func (w *Wizard) prune() {
+	now := w.now()
+	for userID, session := range w.sessions {
+		if !now.Before(session.deadline) {
+			delete(w.sessions, userID)
+		}
+	}
+}
+
+func (s *Session) answer(text string) Reply {
+	if text == "" {
+		return Reply{Prompt: i18n.WizardEmptyAnswer}
+	}
+	switch s.step {
+	case StepQuestion:
+		s.Question = text
+		s.step = StepOptions
+		return Reply{Prompt: i18n.WizardFirstOption}
+	case StepOptions:
+		if isWord(text, doneWords) {
+			if len(s.Options) == 0 {
+				return Reply{Prompt: i18n.WizardNeedOption}
+			}
+			s.step = StepFlags
+			return Reply{Prompt: i18n.WizardFlags, Args: []interface{}{len(s.Options)}}
+		}
+		// Иначе create принял бы вариант за флаг
+		if strings.HasPrefix(text, "--") {
+			return Reply{Prompt: i18n.WizardOptionFlag}
+		}
+		if len(s.Options) >= MaxOptions {
+			return Reply{Prompt: i18n.WizardTooManyOptions, Args: []interface{}{MaxOptions}}
+		}
+		s.Options = append(s.Options, text)
+		return Reply{Prompt: i18n.WizardNextOption, Args: []interface{}{len(s.Options)}}
+	default:
+		if isWord(text, skipWords) {
+			return Reply{Outcome: Finished}
+		}
+		// Ответ без флагов в начале - скорее лишний вариант, чем флаги
+		flags, err := cmdparse.Split(text)
+		if err != nil || len(flags) == 0 || !strings.HasPrefix(flags[0], "--") {
+			return Reply{Prompt: i18n.WizardFlagsInvalid}
+		}
+		s.Flags = flags
+		return Reply{Outcome: Finished}
+	}
+}
+
+func isWord(text string, words []string) bool {
+	for _, word := range words {
+		if strings.EqualFold(text, word) {
+			return true
+		}
+	}
+	return false
+}
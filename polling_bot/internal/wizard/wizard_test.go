@@ -0,0 +1,222 @@
+package wizard
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/internal/i18n"
+)
+
+// wizardClock - часы мастера, которые тест переводит вручную
+type wizardClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *wizardClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *wizardClock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestWizard(timeout time.Duration) (*Wizard, *wizardClock) {
+	clock := &wizardClock{now: time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)}
+	w := New(timeout)
+	w.now = clock.Now
+	return w, clock
+}
+
+// Тест проверяет сессию от первого вопроса до собранных аргументов create
+func TestWizard_FullSession(t *testing.T) {
+	w, _ := newTestWizard(time.Minute)
+
+	assert.Equal(t, i18n.WizardQuestion, w.Start("u1", "c1", false).Prompt)
+	assert.True(t, w.Active("u1", "c1"))
+	assert.False(t, w.Active("u1", "c2"), "ответы из другого канала мастеру не передаются")
+
+	steps := []struct {
+		text       string
+		wantPrompt i18n.Key
+		wantArgs   []interface{}
+	}{
+		{text: "  ", wantPrompt: i18n.WizardEmptyAnswer},
+		{text: "Где обедаем?", wantPrompt: i18n.WizardFirstOption},
+		{text: "готово", wantPrompt: i18n.WizardNeedOption},
+		{text: "Пицца", wantPrompt: i18n.WizardNextOption, wantArgs: []interface{}{1}},
+		{text: "--pin", wantPrompt: i18n.WizardOptionFlag},
+		{text: "Суши бар", wantPrompt: i18n.WizardNextOption, wantArgs: []interface{}{2}},
+		{text: "DONE", wantPrompt: i18n.WizardFlags, wantArgs: []interface{}{2}},
+		{text: "обычный текст", wantPrompt: i18n.WizardFlagsInvalid},
+		{text: `--desc "незакрытая`, wantPrompt: i18n.WizardFlagsInvalid},
+	}
+	for _, step := range steps {
+		reply := w.Answer("u1", "c1", step.text)
+		assert.Equal(t, Continue, reply.Outcome, step.text)
+		assert.Equal(t, step.wantPrompt, reply.Prompt, step.text)
+		assert.Equal(t, step.wantArgs, reply.Args, step.text)
+	}
+
+	reply := w.Answer("u1", "c1", `--anonymous --desc "Решаем до обеда"`)
+	require.Equal(t, Finished, reply.Outcome)
+	assert.Equal(t, "c1", reply.Session.ChannelID)
+	assert.Equal(t, []string{"Где обедаем?", "Пицца", "Суши бар", "--anonymous", "--desc", "Решаем до обеда"}, reply.Session.Args())
+	assert.False(t, w.Active("u1", "c1"), "законченная сессия удалена")
+
+	// Если опрос не создан, сессия возвращается к флагам
+	w.Restore(reply.Session)
+	reply = w.Answer("u1", "c1", "skip")
+	require.Equal(t, Finished, reply.Outcome)
+	assert.Equal(t, []string{"Где обедаем?", "Пицца", "Суши бар"}, reply.Session.Args())
+}
+
+// Тест проверяет выход из мастера ключевым словом на любом шаге
+func TestWizard_Cancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []string
+		cancel  string
+	}{
+		{name: "at the question", cancel: "cancel"},
+		{name: "at the options", answers: []string{"Где обедаем?", "Пицца"}, cancel: "Отмена"},
+		{name: "at the flags", answers: []string{"Где обедаем?", "Пицца", "done"}, cancel: " cancel "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := newTestWizard(time.Minute)
+			w.Start("u1", "c1", true)
+			for _, answer := range tt.answers {
+				require.Equal(t, Continue, w.Answer("u1", "c1", answer).Outcome)
+			}
+			reply := w.Answer("u1", "c1", tt.cancel)
+			assert.Equal(t, Cancelled, reply.Outcome)
+			assert.Equal(t, i18n.WizardCancelled, reply.Prompt)
+			assert.False(t, w.Active("u1", "c1"))
+		})
+	}
+}
+
+// Тест проверяет, что брошенная сессия закрывается по таймауту, а каждый
+// ответ отодвигает таймаут
+func TestWizard_Timeout(t *testing.T) {
+	w, clock := newTestWizard(time.Minute)
+	w.Start("u1", "c1", false)
+
+	clock.advance(50 * time.Second)
+	assert.Equal(t, Continue, w.Answer("u1", "c1", "Где обедаем?").Outcome)
+	clock.advance(50 * time.Second)
+	assert.Equal(t, Continue, w.Answer("u1", "c1", "Пицца").Outcome, "ответ продлил сессию")
+
+	clock.advance(time.Minute)
+	assert.True(t, w.Active("u1", "c1"), "о таймауте сообщит следующий ответ")
+	reply := w.Answer("u1", "c1", "Суши")
+	assert.Equal(t, Expired, reply.Outcome)
+	assert.Equal(t, i18n.WizardExpired, reply.Prompt)
+	assert.False(t, w.Active("u1", "c1"))
+	assert.Equal(t, Cancelled, w.Answer("u1", "c1", "Суши").Outcome)
+	assert.Empty(t, w.Answer("u1", "c1", "Суши").Prompt, "без сессии ответа нет")
+
+	// Брошенные сессии удаляются, даже если пользователь больше не пишет
+	w.Start("u2", "c2", false)
+	clock.advance(2 * time.Minute)
+	w.Start("u3", "c3", false)
+	assert.False(t, w.InChannel("c2"))
+	assert.True(t, w.InChannel("c3"))
+}
+
+// Тест проверяет, что сессии разных пользователей не смешиваются, в том
+// числе в одном канале и при одновременных ответах
+func TestWizard_ConcurrentSessions(t *testing.T) {
+	w, _ := newTestWizard(time.Minute)
+
+	const users = 20
+	results := make([]Reply, users)
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userID, channelID := fmt.Sprintf("u%d", i), fmt.Sprintf("c%d", i%3)
+			w.Start(userID, channelID, false)
+			for _, answer := range []string{fmt.Sprintf("Вопрос %d", i), fmt.Sprintf("Вариант %d", i), "done", "skip"} {
+				results[i] = w.Answer(userID, channelID, answer)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, reply := range results {
+		require.Equal(t, Finished, reply.Outcome, i)
+		assert.Equal(t, fmt.Sprintf("u%d", i), reply.Session.UserID)
+		assert.Equal(t, fmt.Sprintf("c%d", i%3), reply.Session.ChannelID)
+		assert.Equal(t, []string{fmt.Sprintf("Вопрос %d", i), fmt.Sprintf("Вариант %d", i)}, reply.Session.Args())
+	}
+	for i := 0; i < 3; i++ {
+		assert.False(t, w.InChannel(fmt.Sprintf("c%d", i)))
+	}
+}
+
+// Тест проверяет, что новая сессия заменяет прежнюю, в том числе в другом канале
+func TestWizard_Restart(t *testing.T) {
+	w, _ := newTestWizard(time.Minute)
+	w.Start("u1", "c1", false)
+	w.Answer("u1", "c1", "Старый вопрос")
+
+	w.Start("u1", "c2", false)
+	assert.False(t, w.Active("u1", "c1"))
+	assert.Equal(t, i18n.WizardFirstOption, w.Answer("u1", "c2", "Новый вопрос").Prompt)
+	w.Answer("u1", "c2", "A")
+	reply := w.Answer("u1", "c2", "готово")
+	assert.Equal(t, i18n.WizardFlags, reply.Prompt)
+	reply = w.Answer("u1", "c2", "пропустить")
+	assert.Equal(t, []string{"Новый вопрос", "A"}, reply.Session.Args())
+}
+
+// Тест проверяет ограничение числа вариантов
+func TestWizard_MaxOptions(t *testing.T) {
+	w, _ := newTestWizard(time.Minute)
+	w.Start("u1", "c1", false)
+	w.Answer("u1", "c1", "Q")
+	for i := 0; i < MaxOptions; i++ {
+		w.Answer("u1", "c1", fmt.Sprintf("option %d", i))
+	}
+	reply := w.Answer("u1", "c1", "extra")
+	assert.Equal(t, i18n.WizardTooManyOptions, reply.Prompt)
+	assert.Equal(t, []interface{}{MaxOptions}, reply.Args)
+	reply = w.Answer("u1", "c1", "done")
+	assert.Equal(t, []interface{}{MaxOptions}, reply.Args)
+}
+
+// Тест проверяет, какие вызовы create запускают мастер
+func TestRequested(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		direct bool
+		want   bool
+	}{
+		{name: "no args in a direct message", direct: true, want: true},
+		{name: "no args in a channel"},
+		{name: "wizard flag in a channel", args: []string{"--wizard"}, want: true},
+		{name: "wizard flag in any case", args: []string{"--Wizard"}, direct: true, want: true},
+		{name: "wizard flag with a question", args: []string{"--wizard", "Q"}},
+		{name: "regular create", args: []string{"Q", "A"}, direct: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Requested(tt.args, tt.direct))
+		})
+	}
+}
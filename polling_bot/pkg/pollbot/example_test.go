@@ -0,0 +1,68 @@
+package pollbot_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"polling_bot/pkg/pollbot"
+)
+
+// auditedRepo - своё хранилище опросов: сохраняет опросы в другом
+// хранилище и сообщает о каждом новом.
+type auditedRepo struct {
+	pollbot.PollRepository
+}
+
+func (r auditedRepo) SavePoll(ctx context.Context, poll pollbot.Poll) error {
+	if err := r.PollRepository.SavePoll(ctx, poll); err != nil {
+		return err
+	}
+	fmt.Println("Сохранён опрос:", poll.Question)
+	return nil
+}
+
+// Бот встраивается в сервис со своим хранилищем опросов. Run подключается
+// к Mattermost из настроек; в примере вместо него опрос создаётся из кода.
+func ExampleNew() {
+	cfg := pollbot.LoadConfig()
+	cfg.MattermostURL = "https://mattermost.example.com"
+	cfg.BotToken = "bot-token"
+	// Без HTTP API бота: у сервиса свой HTTP-сервер
+	cfg.HTTPAddr = ""
+
+	polls, votes := pollbot.NewMemoryRepository()
+	b, err := pollbot.New(
+		pollbot.WithConfig(cfg),
+		pollbot.WithRepository(auditedRepo{polls}, votes),
+		pollbot.WithCommandPrefix("/vote"),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	_, err = b.Service().CreatePoll(context.Background(), "user1", "Где обедаем?", []string{"Пицца", "Суши"}, pollbot.CreateOptions{})
+	if err != nil {
+		fmt.Println(err)
+	}
+	// Output: Сохранён опрос: Где обедаем?
+}
+
+// Бот с настройками из окружения работает, пока не отменён ctx: здесь -
+// до Ctrl+C.
+func ExampleBot_Run() {
+	polls, votes := pollbot.NewMemoryRepository()
+	b, err := pollbot.New(pollbot.WithRepository(polls, votes))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := b.Run(ctx); err != nil {
+		fmt.Println(err)
+	}
+}
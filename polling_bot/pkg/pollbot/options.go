@@ -0,0 +1,114 @@
+package pollbot
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Option настраивает бота, собираемого New.
+type Option func(*options)
+
+type options struct {
+	cfg             Config
+	logger          zerolog.Logger
+	polls           PollRepository
+	votes           VoteRepository
+	audit           AuditRepository
+	schedules       ScheduleRepository
+	templates       TemplateRepository
+	channelSettings ChannelSettingsRepository
+	usage           UsageRepository
+	storageName     string
+	pinger          StoragePinger
+	client          Client
+	commandPrefix   string
+	version         string
+	started         time.Time
+	middlewares     []Middleware
+}
+
+func defaultOptions() options {
+	return options{
+		cfg:     LoadConfig(),
+		logger:  zerolog.Nop(),
+		started: time.Now(),
+	}
+}
+
+// WithConfig задаёт настройки бота вместо чтения из окружения.
+func WithConfig(cfg Config) Option {
+	return func(o *options) { o.cfg = cfg }
+}
+
+// WithLogger задаёт лог бота.
+func WithLogger(logger zerolog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithRepository задаёт хранилище опросов и голосов. AddVote хранилища
+// голосов должен менять счётчики опроса в хранилище опросов, поэтому оба
+// репозитория работают с одними данными.
+func WithRepository(polls PollRepository, votes VoteRepository) Option {
+	return func(o *options) { o.polls, o.votes = polls, votes }
+}
+
+// WithAuditRepository включает журнал событий опросов и команду audit.
+func WithAuditRepository(journal AuditRepository) Option {
+	return func(o *options) { o.audit = journal }
+}
+
+// WithScheduleRepository включает опросы по расписанию.
+func WithScheduleRepository(schedules ScheduleRepository) Option {
+	return func(o *options) { o.schedules = schedules }
+}
+
+// WithTemplateRepository включает шаблоны опросов.
+func WithTemplateRepository(templates TemplateRepository) Option {
+	return func(o *options) { o.templates = templates }
+}
+
+// WithChannelSettingsRepository включает настройки каналов и команду
+// digest с ежедневной сводкой открытых опросов.
+func WithChannelSettingsRepository(settings ChannelSettingsRepository) Option {
+	return func(o *options) { o.channelSettings = settings }
+}
+
+// WithUsageRepository включает учёт выполненных команд по дням и команду
+// usage для администраторов.
+func WithUsageRepository(usage UsageRepository) Option {
+	return func(o *options) { o.usage = usage }
+}
+
+// WithStoragePinger задаёт проверку хранилища name для команды ping
+// и проверки готовности HTTP API.
+func WithStoragePinger(name string, pinger StoragePinger) Option {
+	return func(o *options) { o.storageName, o.pinger = name, pinger }
+}
+
+// WithClient подменяет клиент REST API Mattermost, который бот иначе
+// создаёт сам по MattermostURL и BotToken.
+func WithClient(client Client) Option {
+	return func(o *options) { o.client = client }
+}
+
+// WithCommandPrefix задаёт префикс команд вместо CommandPrefix настроек.
+func WithCommandPrefix(prefix string) Option {
+	return func(o *options) { o.commandPrefix = prefix }
+}
+
+// WithVersion задаёт версию сборки и время запуска для ответов ping и
+// version. По умолчанию версия - из -ldflags пакета buildinfo, время
+// запуска - вызов New.
+func WithVersion(version string, started time.Time) Option {
+	return func(o *options) { o.version, o.started = version, started }
+}
+
+// WithMiddleware добавляет middleware вокруг выполнения команд, например
+// свой журнал, проверку прав или отключение команд. Middleware
+// выполняются в порядке передачи, после встроенных счётчика команд и
+// ограничения BOT_COMMAND_RATE_LIMIT; ответ или ошибка middleware без
+// вызова next становится ответом на команду.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *options) { o.middlewares = append(o.middlewares, middlewares...) }
+}
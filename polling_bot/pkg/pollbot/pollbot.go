@@ -0,0 +1,370 @@
+// Package pollbot запускает бота опросов Mattermost внутри другого
+// сервиса. New собирает бота из настроек и хранилища, переданных
+// опциями, Run обрабатывает команды до отмены контекста. Реализация
+// остаётся во внутренних пакетах; здесь - только то, что нужно, чтобы
+// встроить бота и подставить своё хранилище или клиент Mattermost.
+package pollbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"polling_bot/internal/api"
+	"polling_bot/internal/bot"
+	"polling_bot/internal/buildinfo"
+	"polling_bot/internal/config"
+	"polling_bot/internal/handler"
+	"polling_bot/internal/health"
+	"polling_bot/internal/i18n"
+	"polling_bot/internal/metrics"
+	"polling_bot/internal/mmclient"
+	"polling_bot/internal/models"
+	"polling_bot/internal/repository"
+	"polling_bot/internal/service"
+	"polling_bot/internal/wizard"
+)
+
+// Настройки бота; LoadConfig читает их из переменных окружения, как
+// отдельно запущенный бот.
+type Config = config.Config
+
+// Команды опросов и хранилище, которое можно подставить своей реализацией.
+type (
+	PollService    = service.PollService
+	PollRepository = repository.PollRepository
+	VoteRepository = repository.VoteRepository
+	// Журнал, расписания, шаблоны, настройки каналов и статистика команд
+	// необязательны: без них соответствующие команды отвечают, что не
+	// настроены
+	AuditRepository           = repository.AuditRepository
+	ScheduleRepository        = repository.ScheduleRepository
+	TemplateRepository        = repository.TemplateRepository
+	ChannelSettingsRepository = repository.ChannelSettingsRepository
+	UsageRepository           = repository.UsageRepository
+	StoragePinger             = service.StoragePinger
+)
+
+// Типы, которые встречаются в методах интерфейсов выше.
+type (
+	Poll            = models.Poll
+	Vote            = models.Vote
+	ListFilter      = repository.ListFilter
+	SettingsUpdate  = repository.SettingsUpdate
+	ChannelSettings = models.ChannelSettings
+	CommandUsage    = models.CommandUsage
+	CreateOptions   = service.CreateOptions
+	CloneOverrides  = service.CloneOverrides
+	SettingChange   = service.SettingChange
+)
+
+// Клиент REST API Mattermost и типы его методов.
+type (
+	Client        = mmclient.Client
+	User          = mmclient.User
+	Post          = mmclient.Post
+	Channel       = mmclient.Channel
+	ChannelMember = mmclient.ChannelMember
+	Reaction      = mmclient.Reaction
+	Team          = mmclient.Team
+)
+
+// Middleware команд бота и то, что они получают и возвращают.
+type (
+	Middleware     = handler.Middleware
+	HandlerFunc    = handler.HandlerFunc
+	CommandContext = handler.CommandContext
+	Response       = handler.Response
+)
+
+// Ошибки, которыми хранилище сообщает сервису о состоянии опроса и
+// голоса; по ним сервис выбирает ответ пользователю.
+var (
+	ErrNotFound     = repository.ErrNotFound
+	ErrUnavailable  = repository.ErrUnavailable
+	ErrPollClosed   = repository.ErrPollClosed
+	ErrAlreadyVoted = repository.ErrAlreadyVoted
+	ErrPollFull     = repository.ErrPollFull
+)
+
+// errNoRepository - New вызван без WithRepository.
+var errNoRepository = errors.New("pollbot: не задано хранилище опросов, передайте WithRepository")
+
+// LoadConfig читает настройки бота из переменных окружения.
+func LoadConfig() Config {
+	return config.Load()
+}
+
+// NewMemoryRepository возвращает хранилище в памяти: опросы пропадают
+// при остановке процесса. Подходит для тестов и примеров.
+func NewMemoryRepository() (PollRepository, VoteRepository) {
+	polls := repository.NewMemoryPollRepo()
+	return polls, repository.NewMemoryVoteRepo(polls)
+}
+
+// Bot - бот опросов, собранный New.
+type Bot struct {
+	cfg     Config
+	logger  zerolog.Logger
+	build   service.BuildInfo
+	storage string
+	service *service.PollServiceImpl
+	usage   *service.UsageServiceImpl
+	tracker *service.PollTracker
+	bot     *bot.Bot
+	api     *api.Server
+}
+
+// New собирает бота. Без WithConfig настройки читаются из окружения,
+// без WithLogger лог не пишется; хранилище WithRepository обязательно.
+func New(opts ...Option) (*Bot, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.polls == nil || o.votes == nil {
+		return nil, errNoRepository
+	}
+	cfg := o.cfg
+	if o.commandPrefix != "" {
+		cfg.CommandPrefix = o.commandPrefix
+	}
+
+	pollService := service.NewPollService(o.polls, o.votes, o.logger)
+	pollService.SetOnePollPerChannel(cfg.OnePollPerChannel)
+	if cfg.MaxPollsPerChannelPerDay > 0 {
+		loc, err := time.LoadLocation(cfg.PollLimitTimezone)
+		if err != nil {
+			return nil, fmt.Errorf("pollbot: часовой пояс лимита опросов: %w", err)
+		}
+		pollService.SetChannelPollLimit(cfg.MaxPollsPerChannelPerDay, loc)
+	}
+	pollService.SetVoteKeyTTL(cfg.VoteKeyTTL)
+	pollService.SetMaxDescriptionLength(cfg.MaxDescriptionLength)
+	pollService.SetContentRules(service.ContentRules{BlockLinks: cfg.BlockLinksInPolls})
+	pollService.SetVoterNotifications(service.NotifyOptions{
+		Default:     cfg.NotifyVoters,
+		Concurrency: cfg.NotifyConcurrency,
+		Interval:    cfg.NotifyInterval,
+	})
+	pollService.SetVoteReceipts(cfg.VoteReceipts)
+	pollService.SetDisallowSelfVote(cfg.DisallowSelfVoteDefault)
+	pollService.SetAnonymousSecret(cfg.AnonymousSecret)
+	pollService.SetMembersOnly(service.MembersOnlyOptions{
+		CacheTTL: cfg.MembershipCacheTTL,
+		FailOpen: cfg.MembersOnlyFailOpen,
+	})
+	pollService.SetExpiry(service.ExpiryOptions{
+		DefaultTTL:    cfg.DefaultPollTTL,
+		AllowNoExpire: cfg.AllowNoExpire,
+	})
+	if o.audit != nil {
+		pollService.SetAuditRepository(o.audit)
+	}
+	if o.pinger != nil {
+		pollService.SetStoragePinger(o.storageName, o.pinger)
+	}
+	build := buildInfo(cfg, o)
+	pollService.SetBuildInfo(build)
+
+	commands := handler.NewPollCommandHandler(pollService, i18n.New(cfg.Language), cfg.CommandPrefix, cfg.CommandAliases...)
+	commands.SetAdmins(cfg.Admins...)
+	commands.SetResultsTable(service.ResultsTableOptions{Default: cfg.ResultsTable, OptionWidth: cfg.ResultsTableWidth})
+	// Счётчик снаружи, чтобы учитывать и отклонённые ограничением команды
+	commands.Use(handler.CountCommands(metrics.Stats), handler.RateLimit(cfg.CommandRateLimit))
+	// Статистика внутри ограничения: отклонённые команды не выполнялись
+	var usage *service.UsageServiceImpl
+	if o.usage != nil {
+		usage = service.NewUsageService(o.usage, o.logger)
+		usage.SetFlushInterval(cfg.UsageFlushInterval)
+		commands.Use(handler.RecordUsage(usage))
+		commands.SetUsageService(usage)
+	}
+	commands.Use(o.middlewares...)
+	var schedules *service.ScheduleServiceImpl
+	if o.schedules != nil {
+		schedules = service.NewScheduleService(o.schedules, pollService, o.logger)
+		commands.SetScheduleService(schedules)
+	}
+	if o.templates != nil {
+		commands.SetTemplateService(service.NewTemplateService(o.templates, pollService, o.logger))
+	}
+	var digests *service.DigestServiceImpl
+	if o.channelSettings != nil {
+		digests = service.NewDigestService(o.channelSettings, o.polls, o.logger)
+		digests.SetHour(cfg.DigestHour)
+		commands.SetDigestService(digests)
+	}
+
+	mm, err := bot.NewBot(cfg, o.logger, commands)
+	if err != nil {
+		return nil, err
+	}
+	if o.client != nil {
+		mm.SetClient(o.client)
+	}
+	// Бот проверяет членство в канале для голосования из личных сообщений
+	// и публикует сообщения опросов в их каналах
+	pollService.SetChannelMembers(mm)
+	pollService.SetAnnouncer(mm)
+	pollService.SetPinner(mm)
+	pollService.SetUserNames(mm)
+	pollService.SetUserFinder(mm)
+	pollService.SetUserStatuses(mm)
+	pollService.SetDirectMessenger(mm)
+	// Опросы по расписаниям создаёт планировщик бота
+	if schedules != nil {
+		mm.SetScheduler(schedules)
+	}
+	if digests != nil {
+		mm.SetDigester(digests)
+	}
+	mm.SetExpirer(pollService)
+	mm.SetDiscarder(pollService)
+	if cfg.Mode != config.ModeWebhook {
+		mm.SetWizard(wizard.New(cfg.WizardTimeout))
+	}
+
+	b := &Bot{cfg: cfg, logger: o.logger, build: build, storage: o.storageName, service: pollService, usage: usage, bot: mm}
+	if cfg.HTTPAddr != "" {
+		b.api = newAPIServer(cfg, o, pollService, mm)
+		b.api.SetBuildInfo(build.Info(), build.Features)
+	}
+	// Метрики опросов без HTTP-сервера некому отдавать
+	if len(cfg.TrackedPolls) > 0 && b.api != nil {
+		gauges := metrics.NewPollGauges(config.MaxTrackedPolls)
+		b.tracker = service.NewPollTracker(o.polls, gauges, service.TrackOptions{
+			PollIDs:      cfg.TrackedPolls,
+			SyncInterval: cfg.TrackedPollsSyncInterval,
+			Grace:        cfg.TrackedPollsGrace,
+		}, o.logger)
+		pollService.SetPollTracker(b.tracker)
+		b.api.SetMetrics(gauges)
+	}
+	return b, nil
+}
+
+// buildInfo собирает сведения о сборке для команды version, журнала и
+// GET /readyz: версию из WithVersion или -ldflags и возможности, которые
+// включены настройками и переданными хранилищами.
+func buildInfo(cfg Config, o options) service.BuildInfo {
+	info := buildinfo.Get()
+	if o.version != "" {
+		info.Version = o.version
+	}
+	return service.BuildInfo{
+		Version: info.Version,
+		Commit:  info.Commit,
+		Date:    info.Date,
+		Started: o.started,
+		Features: []service.Feature{
+			{Name: "schedules", Enabled: o.schedules != nil},
+			{Name: "templates", Enabled: o.templates != nil},
+			{Name: "digest", Enabled: o.channelSettings != nil},
+			{Name: "usage", Enabled: o.usage != nil},
+			{Name: "audit", Enabled: o.audit != nil},
+			{Name: "http-api", Enabled: cfg.HTTPAddr != ""},
+			{Name: "webhook-mode", Enabled: cfg.Mode == config.ModeWebhook},
+			{Name: "wizard", Enabled: cfg.Mode != config.ModeWebhook},
+			{Name: "one-poll-per-channel", Enabled: cfg.OnePollPerChannel},
+			{Name: "channel-poll-limit", Enabled: cfg.MaxPollsPerChannelPerDay > 0},
+			{Name: "no-expire", Enabled: cfg.AllowNoExpire},
+			{Name: "notify-voters", Enabled: cfg.NotifyVoters},
+			{Name: "vote-receipts", Enabled: cfg.VoteReceipts},
+			{Name: "no-self-vote", Enabled: cfg.DisallowSelfVoteDefault},
+			{Name: "anonymous-polls", Enabled: cfg.AnonymousSecret != ""},
+			{Name: "members-only-fail-open", Enabled: cfg.MembersOnlyFailOpen},
+			{Name: "rich-results", Enabled: cfg.RichResults},
+			{Name: "results-table", Enabled: cfg.ResultsTable},
+			{Name: "reactions", Enabled: cfg.Reactions},
+			{Name: "require-mention", Enabled: cfg.RequireMention},
+			{Name: "block-links", Enabled: cfg.BlockLinksInPolls},
+			{Name: "command-rate-limit", Enabled: cfg.CommandRateLimit > 0},
+			{Name: "tracked-polls", Enabled: len(cfg.TrackedPolls) > 0 && cfg.HTTPAddr != ""},
+		},
+	}
+}
+
+// newAPIServer собирает HTTP API, через которое дашборды читают опросы,
+// а другие сервисы их создают.
+func newAPIServer(cfg Config, o options, pollService *service.PollServiceImpl, mm *bot.Bot) *api.Server {
+	server := api.NewServer(o.polls, o.votes, cfg.APIToken, o.logger)
+	server.SetLocalizer(i18n.New(cfg.Language))
+	readiness := []health.Check{{Name: "mattermost", Run: mm.CheckAuth}}
+	if o.pinger != nil {
+		readiness = append([]health.Check{{Name: "storage", Run: func(ctx context.Context) error {
+			_, err := o.pinger.Ping(ctx)
+			return err
+		}}}, readiness...)
+	}
+	server.SetReadiness(readiness...)
+	if cfg.APIWriteToken != "" {
+		server.AddToken(cfg.APIWriteToken, api.ScopeRead|api.ScopeWrite)
+		server.SetPollCreator(pollService, mm, cfg.APIServiceUser)
+	}
+	return server
+}
+
+// Service возвращает команды опросов, например чтобы создавать опросы
+// из кода встроившего бота сервиса.
+func (b *Bot) Service() PollService {
+	return b.service
+}
+
+// Run подключается к Mattermost и обрабатывает команды, пока не отменён
+// ctx. HTTP API с непустым HTTPAddr работает рядом; его сбой не
+// останавливает бота. Сверка метрик отслеживаемых опросов останавливается
+// вместе с ботом. Статистику команд Run перед возвратом дописывает в
+// хранилище.
+func (b *Bot) Run(ctx context.Context) error {
+	info := b.build.Info()
+	var enabled []string
+	for _, feature := range b.build.Features {
+		if feature.Enabled {
+			enabled = append(enabled, feature.Name)
+		}
+	}
+	b.logger.Info().
+		Str("version", info.Version).
+		Str("commit", info.Commit).
+		Str("date", info.Date).
+		Str("storage", b.storage).
+		Strs("features", enabled).
+		Msg("Запуск бота")
+
+	// Если бот не запустился, фоновые задачи останавливаются вместе с ним,
+	// иначе Run ждал бы их до отмены ctx
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	if b.api != nil {
+		go func() {
+			if err := b.api.ListenAndServe(ctx, b.cfg.HTTPAddr); err != nil {
+				b.logger.Err(err).Msg("HTTP-сервер остановлен с ошибкой")
+			}
+		}()
+	}
+	if b.usage != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.usage.Run(ctx)
+		}()
+	}
+	if b.tracker != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.tracker.Run(ctx)
+		}()
+	}
+	return b.bot.Start(ctx)
+}
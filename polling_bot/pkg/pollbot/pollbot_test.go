@@ -0,0 +1,163 @@
+package pollbot_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"polling_bot/pkg/pollbot"
+)
+
+// countingRepo - своё хранилище опросов поверх хранилища в памяти,
+// которое считает сохранённые опросы.
+type countingRepo struct {
+	pollbot.PollRepository
+	mu    sync.Mutex
+	saved []string
+}
+
+func (r *countingRepo) SavePoll(ctx context.Context, poll pollbot.Poll) error {
+	r.mu.Lock()
+	r.saved = append(r.saved, poll.Question)
+	r.mu.Unlock()
+	return r.PollRepository.SavePoll(ctx, poll)
+}
+
+func (r *countingRepo) questions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.saved...)
+}
+
+// stubClient отвечает на запросы бота к Mattermost без сервера; остальные
+// методы клиента бот в тесте не вызывает.
+type stubClient struct {
+	pollbot.Client
+}
+
+func (stubClient) GetMe() (*pollbot.User, error) {
+	return &pollbot.User{ID: "bot1", Username: "pollbot", IsBot: true}, nil
+}
+
+func (stubClient) GetUser(userID string) (*pollbot.User, error) {
+	return &pollbot.User{ID: userID, Username: "user-" + userID, Locale: "ru"}, nil
+}
+
+func (stubClient) SaveReaction(reaction *pollbot.Reaction) (*pollbot.Reaction, error) {
+	return reaction, nil
+}
+
+func testConfig(t *testing.T) pollbot.Config {
+	t.Helper()
+	cfg := pollbot.LoadConfig()
+	cfg.MattermostURL = "https://mattermost.example.com"
+	cfg.BotToken = "token"
+	cfg.HTTPAddr = ""
+	return cfg
+}
+
+// Тест проверяет, что New без хранилища возвращает ошибку
+func TestNew_RequiresRepository(t *testing.T) {
+	_, err := pollbot.New(pollbot.WithConfig(testConfig(t)))
+	assert.ErrorContains(t, err, "WithRepository")
+}
+
+// Тест проверяет, что сервис встроенного бота пишет в переданное хранилище
+func TestNew_Service(t *testing.T) {
+	polls, votes := pollbot.NewMemoryRepository()
+	repo := &countingRepo{PollRepository: polls}
+	b, err := pollbot.New(pollbot.WithConfig(testConfig(t)), pollbot.WithRepository(repo, votes))
+	require.NoError(t, err)
+
+	_, err = b.Service().CreatePoll(context.Background(), "user1", "Где обедаем?", []string{"Пицца", "Суши"}, pollbot.CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Где обедаем?"}, repo.questions())
+}
+
+// Тест проверяет, что ответ version показывает версию WithVersion
+// и возможности по переданным хранилищам и настройкам
+func TestNew_Version(t *testing.T) {
+	polls, votes := pollbot.NewMemoryRepository()
+	cfg := testConfig(t)
+	cfg.RichResults = true
+	b, err := pollbot.New(
+		pollbot.WithConfig(cfg),
+		pollbot.WithRepository(polls, votes),
+		pollbot.WithVersion("v1.4.0", time.Now()),
+	)
+	require.NoError(t, err)
+
+	msg, err := b.Service().Version(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, msg, "**Бот опросов v1.4.0**")
+	assert.Contains(t, msg, "- schedules: выкл\n- templates: выкл\n")
+	assert.Contains(t, msg, "- rich-results: вкл\n")
+	assert.NotContains(t, msg, "Коммит", "сборка тестов без -ldflags не знает коммита")
+}
+
+// Тест проверяет, что Run выполняет команды с префиксом WithCommandPrefix
+// через клиент WithClient и хранилище WithRepository, а middleware
+// WithMiddleware получает команду с каналом и сообщением
+func TestRun_Webhook(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	cfg := testConfig(t)
+	cfg.Mode = "webhook"
+	cfg.WebhookAddr = addr
+	cfg.WebhookTokens = []string{"hook-token"}
+	polls, votes := pollbot.NewMemoryRepository()
+	repo := &countingRepo{PollRepository: polls}
+	seen := make(chan pollbot.CommandContext, 1)
+	b, err := pollbot.New(
+		pollbot.WithConfig(cfg),
+		pollbot.WithRepository(repo, votes),
+		pollbot.WithClient(stubClient{}),
+		pollbot.WithCommandPrefix("/vote"),
+		pollbot.WithMiddleware(func(next pollbot.HandlerFunc) pollbot.HandlerFunc {
+			return func(ctx context.Context, cmd pollbot.CommandContext) (pollbot.Response, error) {
+				seen <- cmd
+				return next(ctx, cmd)
+			}
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx) }()
+	defer func() {
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	}()
+
+	form := url.Values{
+		"token":      {"hook-token"},
+		"channel_id": {"channel1"},
+		"user_id":    {"user1"},
+		"post_id":    {"post1"},
+		"text":       {`/vote create "Где обедаем?" "Пицца" "Суши"`},
+	}
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.PostForm("http://"+addr, form)
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"Где обедаем?"}, repo.questions())
+	cmd := <-seen
+	assert.Equal(t, "create", cmd.Command)
+	assert.Equal(t, "user1", cmd.UserID)
+	assert.Equal(t, "channel1", cmd.ChannelID)
+	assert.Equal(t, "post1", cmd.PostID)
+}